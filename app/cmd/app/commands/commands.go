@@ -22,4 +22,18 @@ var (
 	FnSyncAllItems                 command = "fn-sync-all-items"
 	FnCleanupAllExpiredManifests   command = "fn-cleanup-all-expired-manifests"
 	FnCleanupPricelistHistories    command = "fn-cleanup-pricelist-histories"
+
+	ExportPricelistHistories command = "export-pricelist-histories"
+	ExportAuctionDumps       command = "export-auction-dumps"
+	ReplayAuctionDumps       command = "replay-auction-dumps"
+
+	RebuildPricelistHistories command = "rebuild-pricelist-histories"
+	MigrateEncodings          command = "migrate-encodings"
+	PruneSchemaBackups        command = "prune-schema-backups"
+	DbCheck                   command = "db-check"
+	RestoreSnapshot           command = "restore-snapshot"
+
+	APIKeysIssue  command = "api-keys-issue"
+	APIKeysRevoke command = "api-keys-revoke"
+	APIKeysList   command = "api-keys-list"
 )
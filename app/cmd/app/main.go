@@ -1,17 +1,31 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/cmd/app/commands"
+	"github.com/sotah-inc/server/app/pkg/apikeys"
+	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/command"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/export/formats"
+	"github.com/sotah-inc/server/app/pkg/health"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/logging/stackdriver"
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	"github.com/sotah-inc/server/app/pkg/messenger/deliveries"
+	"github.com/sotah-inc/server/app/pkg/messenger/roles"
+	"github.com/sotah-inc/server/app/pkg/metric"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/state"
 	"github.com/sotah-inc/server/app/pkg/state/fn"
+	"github.com/sotah-inc/server/app/pkg/tracing"
+	"github.com/sotah-inc/server/app/pkg/tracing/exporterkinds"
 	"github.com/twinj/uuid"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
@@ -36,6 +50,50 @@ func main() {
 		verbosity      = app.Flag("verbosity", "Log verbosity").Default("info").Short('v').String()
 		cacheDir       = app.Flag("cache-dir", "Directory to cache data files to").Required().String()
 		projectID      = app.Flag("project-id", "GCloud Storage Project ID").Default("").Envar("PROJECT_ID").String()
+		dryRun         = app.Flag("dry-run", "Report what retention would delete without deleting").Envar("DRY_RUN").Bool()
+		healthAddr     = app.Flag("health-addr", "Address to serve health and metrics on, such as :8081, left blank to disable").Envar("HEALTH_ADDR").String()
+		intakeMaxAge   = app.Flag("health-intake-max-age", "How long without an intake before liveness fails, zero to disable").Default("2h").Envar("HEALTH_INTAKE_MAX_AGE").Duration()
+		metricsRelay   = app.Flag("metrics-relay", "Relay reported metrics to the app-metrics nats subject").Default("true").Envar("METRICS_RELAY").Bool()
+		traceExporter  = app.Flag("trace-exporter", "Where spans are exported").Default(string(exporterkinds.None)).Envar("TRACE_EXPORTER").Enum(string(exporterkinds.None), string(exporterkinds.Stdout), string(exporterkinds.File), string(exporterkinds.OTLP))
+		traceFile      = app.Flag("trace-file", "File the file exporter appends spans to").Envar("TRACE_FILE").String()
+		traceEndpoint  = app.Flag("trace-otlp-endpoint", "Endpoint the otlp exporter posts spans to").Default(tracing.DefaultOTLPEndpoint).Envar("TRACE_OTLP_ENDPOINT").String()
+		traceRatio     = app.Flag("trace-sample-ratio", "Share of new traces that are recorded").Default("1").Envar("TRACE_SAMPLE_RATIO").Float64()
+
+		messengerMaxPayload         = app.Flag("messenger-max-payload", "Largest request payload handled, in bytes, zero for unbounded").Default(strconv.Itoa(messenger.DefaultMaxPayloadSize)).Envar("MESSENGER_MAX_PAYLOAD").Int()
+		messengerConcurrency        = app.Flag("messenger-concurrency", "How many requests of a subject are handled at once").Default("1").Envar("MESSENGER_CONCURRENCY").Int()
+		messengerChunkSize          = app.Flag("messenger-chunk-size", "Largest reply sent whole, in bytes, where larger ones are sent in chunks of it, zero for never").Default(strconv.Itoa(messenger.DefaultChunkSize)).Envar("MESSENGER_CHUNK_SIZE").Int()
+		messengerSubjectConcurrency = app.Flag("messenger-subject-concurrency", "How many requests of a subject are handled at once, as subject=limit, repeatable").StringMap()
+		messengerQueueGroup         = app.Flag("messenger-queue-group", "Queue group that balanced subjects are subscribed in, left blank to broadcast every subject").Default(messenger.DefaultQueueGroup).Envar("MESSENGER_QUEUE_GROUP").String()
+		messengerSubjectDelivery    = app.Flag("messenger-subject-delivery", "How requests of a subject are delivered among replicas, as subject=balanced or subject=broadcast, repeatable").StringMap()
+		messengerClientKeys         = app.Flag("messenger-client-key", "Api key of the client role, repeatable").Envar("MESSENGER_CLIENT_KEYS").Strings()
+		messengerServiceKeys        = app.Flag("messenger-service-key", "Api key of the service role, repeatable").Envar("MESSENGER_SERVICE_KEYS").Strings()
+		messengerSubjectRoles       = app.Flag("messenger-subject-role", "Least role that may request a subject, as subject=role, repeatable").StringMap()
+		messengerAPIKey             = app.Flag("messenger-api-key", "Api key carried by the requests of this process, which must be a fixed key").Envar("MESSENGER_API_KEY").String()
+
+		sessionSecretRotation = app.Flag("session-secret-rotation", "How often the api rotates session secrets, zero for never").Default("0s").Envar("SESSION_SECRET_ROTATION").Duration()
+
+		apiKeysEnabled       = app.Flag("api-keys", "Authenticate and meter api keys issued to third parties, as kept by the api").Envar("API_KEYS").Bool()
+		apiKeysResolveTTL    = app.Flag("api-keys-resolve-ttl", "How long resolved api keys are held before being resolved again").Default(apikeys.DefaultGateConfig.ResolveTTL.String()).Envar("API_KEYS_RESOLVE_TTL").Duration()
+		apiKeysFlushInterval = app.Flag("api-keys-flush-interval", "How often the usage of api keys is recorded").Default(apikeys.DefaultGateConfig.FlushInterval.String()).Envar("API_KEYS_FLUSH_INTERVAL").Duration()
+
+		natsUser            = app.Flag("nats-user", "NATS user").Envar("NATS_USER").String()
+		natsPassword        = app.Flag("nats-password", "NATS password").Envar("NATS_PASSWORD").String()
+		natsToken           = app.Flag("nats-token", "NATS token").Envar("NATS_TOKEN").String()
+		natsCredentialsFile = app.Flag("nats-credentials", "Path to a NATS credentials file holding a user jwt and nkey seed").Envar("NATS_CREDENTIALS").String()
+		natsNKeySeedFile    = app.Flag("nats-nkey-seed", "Path to a NATS nkey seed file").Envar("NATS_NKEY_SEED").String()
+		natsTLS             = app.Flag("nats-tls", "Connect to NATS over tls").Envar("NATS_TLS").Bool()
+		natsTLSCA           = app.Flag("nats-tls-ca", "Path to the CA certificate NATS is verified against").Envar("NATS_TLS_CA").String()
+		natsTLSCert         = app.Flag("nats-tls-cert", "Path to the client certificate presented to NATS").Envar("NATS_TLS_CERT").String()
+		natsTLSKey          = app.Flag("nats-tls-key", "Path to the key of the client certificate").Envar("NATS_TLS_KEY").String()
+
+		natsServers               = app.Flag("nats-server", "Url of a NATS cluster server, connected to in place of the nats host and port, repeatable").Envar("NATS_SERVERS").Strings()
+		natsMaxReconnects         = app.Flag("nats-max-reconnects", "Attempts at reconnecting to each NATS server before giving up, negative for never").Default(strconv.Itoa(messenger.DefaultReconnectConfig.MaxReconnects)).Envar("NATS_MAX_RECONNECTS").Int()
+		natsReconnectWait         = app.Flag("nats-reconnect-wait", "How long to wait between attempts at reconnecting to a NATS server").Default(messenger.DefaultReconnectConfig.Wait.String()).Envar("NATS_RECONNECT_WAIT").Duration()
+		natsReconnectBufferSize   = app.Flag("nats-reconnect-buffer-size", "Bytes of publishes buffered while reconnecting to NATS").Default(strconv.Itoa(messenger.DefaultReconnectConfig.BufferSize)).Envar("NATS_RECONNECT_BUFFER_SIZE").Int()
+		natsRequestTimeout        = app.Flag("nats-request-timeout", "How long requests wait on a reply").Default(messenger.DefaultRequestTimeout.String()).Envar("NATS_REQUEST_TIMEOUT").Duration()
+		natsSubjectRequestTimeout = app.Flag("nats-subject-request-timeout", "How long requests of a subject wait on a reply, as subject=duration, repeatable").Envar("NATS_SUBJECT_REQUEST_TIMEOUTS").StringMap()
+		bootAttempts              = app.Flag("boot-attempts", "How many times the boot subject is requested on starting up").Default(strconv.Itoa(state.DefaultBootRetryConfig.Attempts)).Envar("BOOT_ATTEMPTS").Int()
+		bootAttemptWait           = app.Flag("boot-attempt-wait", "How long to wait between requests of the boot subject on starting up").Default(state.DefaultBootRetryConfig.Wait.String()).Envar("BOOT_ATTEMPT_WAIT").Duration()
 
 		apiCommand                = app.Command(string(commands.API), "For running sotah-server.")
 		liveAuctionsCommand       = app.Command(string(commands.LiveAuctions), "For in-memory storage of current auctions.")
@@ -53,6 +111,60 @@ func main() {
 		fnSyncAllItems                 = app.Command(string(commands.FnSyncAllItems), "For enqueueing syncing of items and item-icons in gcp ce vm.")
 		fnCleanupAllExpiredManifests   = app.Command(string(commands.FnCleanupAllExpiredManifests), "For gathering all expired auction-manifests for deletion in gcp ce vm.")
 		fnCleanupPricelistHistories    = app.Command(string(commands.FnCleanupPricelistHistories), "For gathering all expired pricelist-histories for deletion in gcp ce vm.")
+
+		exportPricelistHistoriesCommand = app.Command(string(commands.ExportPricelistHistories), "For exporting pricelist-histories as flat rows.")
+		exportFormat                    = exportPricelistHistoriesCommand.Flag("format", "Export format").Default(string(formats.CSV)).Enum(string(formats.CSV), string(formats.Parquet))
+		exportOut                       = exportPricelistHistoriesCommand.Flag("out", "Filepath to export to").Required().String()
+		exportRegion                    = exportPricelistHistoriesCommand.Flag("region", "Region name to export").String()
+		exportRealm                     = exportPricelistHistoriesCommand.Flag("realm", "Realm slug to export").String()
+		exportItemIds                   = exportPricelistHistoriesCommand.Flag("item-id", "Item id to export, repeatable").Int64List()
+		exportLowerBounds               = exportPricelistHistoriesCommand.Flag("lower-bounds", "Unix timestamp to export from").Int64()
+		exportUpperBounds               = exportPricelistHistoriesCommand.Flag("upper-bounds", "Unix timestamp to export until").Int64()
+
+		exportAuctionDumpsCommand = app.Command(string(commands.ExportAuctionDumps), "For exporting raw auction dumps to an archive.")
+		exportDumpsOut            = exportAuctionDumpsCommand.Flag("out", "Filepath of the archive to write").Required().String()
+		exportDumpsRegion         = exportAuctionDumpsCommand.Flag("region", "Region name to export").String()
+		exportDumpsRealms         = exportAuctionDumpsCommand.Flag("realm", "Realm slug to export, repeatable").Strings()
+		exportDumpsLowerBounds    = exportAuctionDumpsCommand.Flag("lower-bounds", "Unix timestamp to export from").Int64()
+		exportDumpsUpperBounds    = exportAuctionDumpsCommand.Flag("upper-bounds", "Unix timestamp to export until").Int64()
+
+		replayAuctionDumpsCommand = app.Command(string(commands.ReplayAuctionDumps), "For replaying an archive of raw auction dumps into the local databases.")
+		replayArchive             = replayAuctionDumpsCommand.Flag("archive", "Filepath of the archive to replay").Required().String()
+
+		rebuildPricelistHistoriesCommand = app.Command(string(commands.RebuildPricelistHistories), "For recomputing pricelist-histories from stored raw auction dumps.")
+		rebuildCheckpoint                = rebuildPricelistHistoriesCommand.Flag("checkpoint", "Filepath of the checkpoint used to resume").Default("rebuild-pricelist-histories.json").String()
+		rebuildConcurrency               = rebuildPricelistHistoriesCommand.Flag("concurrency", "Number of realm-days rebuilt at once").Default("4").Int()
+		rebuildSkipLocal                 = rebuildPricelistHistoriesCommand.Flag("skip-local", "Only rewrite the stored pricelist-histories, not the local databases").Bool()
+		rebuildRegion                    = rebuildPricelistHistoriesCommand.Flag("region", "Region name to rebuild").String()
+		rebuildRealms                    = rebuildPricelistHistoriesCommand.Flag("realm", "Realm slug to rebuild, repeatable").Strings()
+		rebuildLowerBounds               = rebuildPricelistHistoriesCommand.Flag("lower-bounds", "Unix timestamp to rebuild from").Int64()
+		rebuildUpperBounds               = rebuildPricelistHistoriesCommand.Flag("upper-bounds", "Unix timestamp to rebuild until").Int64()
+
+		migrateEncodingsCommand = app.Command(string(commands.MigrateEncodings), "For re-encoding local databases into the binary encoding.")
+
+		pruneSchemaBackupsCommand = app.Command(string(commands.PruneSchemaBackups), "For removing the backups taken ahead of schema migrations.")
+		pruneSchemaBackupsKeep    = pruneSchemaBackupsCommand.Flag("keep", "Number of the most recent backups kept per database").Default("1").Int()
+
+		dbCheckCommand    = app.Command(string(commands.DbCheck), "For verifying the local databases and repairing broken ones.")
+		dbCheckQuarantine = dbCheckCommand.Flag("quarantine", "Move broken databases out of the way").Bool()
+		dbCheckResync     = dbCheckCommand.Flag("resync", "Quarantine broken databases and re-download pricelist-histories from the store").Bool()
+
+		restoreSnapshotCommand   = app.Command(string(commands.RestoreSnapshot), "For bootstrapping local databases from a stored snapshot.")
+		restoreSnapshotKind      = restoreSnapshotCommand.Flag("kind", "Kind of databases to restore").Required().Enum(string(schemakinds.Items), string(schemakinds.LiveAuctions))
+		restoreSnapshotId        = restoreSnapshotCommand.Flag("id", "Snapshot id to restore, defaulting to the latest").Int64()
+		restoreSnapshotOverwrite = restoreSnapshotCommand.Flag("overwrite", "Replace existing databases").Bool()
+
+		apiKeysIssueCommand    = app.Command(string(commands.APIKeysIssue), "For issuing an api key to a third party, through the api.")
+		apiKeysIssueName       = apiKeysIssueCommand.Flag("name", "Name of the holder of the key").Required().String()
+		apiKeysIssueRole       = apiKeysIssueCommand.Flag("role", "Role of the key").Default(string(roles.Client)).Enum(string(roles.Public), string(roles.Client))
+		apiKeysIssueRateLimit  = apiKeysIssueCommand.Flag("rate-limit", "Requests a second, zero for unbounded").Float64()
+		apiKeysIssueBurst      = apiKeysIssueCommand.Flag("burst", "Requests made at once after going without").Int()
+		apiKeysIssueDailyQuota = apiKeysIssueCommand.Flag("daily-quota", "Requests a day, zero for unbounded").Int()
+
+		apiKeysRevokeCommand = app.Command(string(commands.APIKeysRevoke), "For revoking an issued api key, through the api.")
+		apiKeysRevokeId      = apiKeysRevokeCommand.Flag("id", "Id of the key to revoke").Required().String()
+
+		apiKeysListCommand = app.Command(string(commands.APIKeysList), "For listing issued api keys with their usage today, through the api.")
 	)
 	cmd := kingpin.MustParse(app.Parse(os.Args[1:]))
 
@@ -75,6 +187,9 @@ func main() {
 
 		return
 	}
+	if *dryRun {
+		c.Retention.DryRun = true
+	}
 
 	// optionally adding stackdriver hook
 	if c.UseGCloud {
@@ -94,105 +209,387 @@ func main() {
 
 	logging.WithField("command", cmd).Info("Running command")
 
+	// labelling every metric with the command, and optionally relaying reported metrics
+	metric.DefaultRegistry.SetConstLabels(metric.Labels{metric.CommandLabel: cmd})
+	metric.SetRelay(*metricsRelay)
+
+	// optionally exporting spans, where trace context is carried along either way
+	tracer, err := tracing.NewTracerFromConfig(tracing.Config{
+		ExporterKind: exporterkinds.ExporterKind(*traceExporter),
+		File:         *traceFile,
+		OTLPEndpoint: *traceEndpoint,
+		SampleRatio:  *traceRatio,
+		Command:      cmd,
+	})
+	if err != nil {
+		logging.WithField("error", err.Error()).Fatal("Could not create tracer")
+
+		return
+	}
+	tracing.DefaultTracer = tracer
+
+	// bounding the handling of requests received by every messenger
+	subjectConcurrency := map[string]int{}
+	for subject, limit := range *messengerSubjectConcurrency {
+		parsedLimit, err := strconv.Atoi(limit)
+		if err != nil {
+			logging.WithFields(logrus.Fields{
+				"error":   err.Error(),
+				"subject": subject,
+			}).Fatal("Could not parse subject concurrency")
+
+			return
+		}
+
+		subjectConcurrency[subject] = parsedLimit
+	}
+	messenger.DefaultHandlingConfig = messenger.HandlingConfig{
+		MaxPayloadSize:     *messengerMaxPayload,
+		MaxConcurrency:     *messengerConcurrency,
+		SubjectConcurrency: subjectConcurrency,
+		ChunkSize:          *messengerChunkSize,
+	}
+
+	// balancing queries among the replicas of a process, where intake is broadcast to every one of them
+	subjectDeliveries := messenger.Deliveries{}
+	for subject, delivery := range state.SubjectDeliveries {
+		subjectDeliveries[subject] = delivery
+	}
+	for subject, delivery := range *messengerSubjectDelivery {
+		switch deliveries.Delivery(delivery) {
+		case deliveries.Balanced, deliveries.Broadcast:
+			subjectDeliveries[subject] = deliveries.Delivery(delivery)
+		default:
+			logging.WithFields(logrus.Fields{
+				"subject":  subject,
+				"delivery": delivery,
+			}).Fatal("Could not parse subject delivery")
+
+			return
+		}
+	}
+	messenger.DefaultHandlingConfig.QueueGroup = *messengerQueueGroup
+	messenger.DefaultHandlingConfig.Deliveries = subjectDeliveries
+
+	// authenticating requests by api key once any are given, where each subject is permitted to a least role
+	apiKeys := messenger.StaticKeys{}
+	for _, key := range *messengerClientKeys {
+		apiKeys[key] = roles.Client
+	}
+	for _, key := range *messengerServiceKeys {
+		apiKeys[key] = roles.Service
+	}
+	permissions := messenger.Permissions{}
+	for subject, role := range state.SubjectPermissions {
+		permissions[subject] = role
+	}
+	for subject, role := range *messengerSubjectRoles {
+		switch roles.Role(role) {
+		case roles.Public, roles.Client, roles.Service:
+			permissions[subject] = roles.Role(role)
+		default:
+			logging.WithFields(logrus.Fields{
+				"subject": subject,
+				"role":    role,
+			}).Fatal("Could not parse subject role")
+
+			return
+		}
+	}
+	messenger.DefaultHandlingConfig.Keys = apiKeys
+	messenger.DefaultHandlingConfig.Permissions = permissions
+
+	// resolving and metering issued api keys through the api, which keeps them
+	if *apiKeysEnabled {
+		gateConfig := apikeys.GateConfig{ResolveTTL: *apiKeysResolveTTL, FlushInterval: *apiKeysFlushInterval}
+		messenger.DefaultHandlingConfig.NewKeyStore = func(mess messenger.Messenger) messenger.KeyStore {
+			return apikeys.NewGate(apikeys.NewClient(mess), metric.NewReporter(mess), gateConfig)
+		}
+	}
+
+	// connecting to and authenticating with nats
+	subjectRequestTimeouts := map[string]time.Duration{}
+	for subject, timeout := range *natsSubjectRequestTimeout {
+		parsedTimeout, err := time.ParseDuration(timeout)
+		if err != nil {
+			logging.WithFields(logrus.Fields{
+				"error":   err.Error(),
+				"subject": subject,
+			}).Fatal("Could not parse subject request timeout")
+
+			return
+		}
+
+		subjectRequestTimeouts[subject] = parsedTimeout
+	}
+	connectConfig := messenger.ConnectConfig{
+		Servers: *natsServers,
+		Reconnect: messenger.ReconnectConfig{
+			MaxReconnects: *natsMaxReconnects,
+			Wait:          *natsReconnectWait,
+			BufferSize:    *natsReconnectBufferSize,
+		},
+		RequestTimeout:         *natsRequestTimeout,
+		SubjectRequestTimeouts: subjectRequestTimeouts,
+
+		User:            *natsUser,
+		Password:        *natsPassword,
+		Token:           *natsToken,
+		CredentialsFile: *natsCredentialsFile,
+		NKeySeedFile:    *natsNKeySeedFile,
+		TLS: messenger.TLSConfig{
+			Enabled:  *natsTLS,
+			CAFile:   *natsTLSCA,
+			CertFile: *natsTLSCert,
+			KeyFile:  *natsTLSKey,
+		},
+		APIKey: *messengerAPIKey,
+	}
+	bootRetryConfig := state.BootRetryConfig{Attempts: *bootAttempts, Wait: *bootAttemptWait}
+
+	// the root context, from which every command derives its deadlines
+	ctx := context.Background()
+
+	// where long-running commands serve health
+	healthConfig := health.Config{Addr: *healthAddr, IntakeMaxAge: *intakeMaxAge}
+
 	// declaring a command map
 	cMap := commandMap{
 		apiCommand.FullCommand(): func() error {
-			return command.Api(state.APIStateConfig{
-				SotahConfig:          c,
-				DiskStoreCacheDir:    *cacheDir,
-				ItemsDatabaseDir:     fmt.Sprintf("%s/databases", *cacheDir),
-				BlizzardClientSecret: *clientSecret,
-				BlizzardClientId:     *clientID,
-				MessengerPort:        *natsPort,
-				MessengerHost:        *natsHost,
-				GCloudProjectID:      *projectID,
-			})
+			return command.Api(ctx, state.APIStateConfig{
+				SotahConfig:            c,
+				DiskStoreCacheDir:      *cacheDir,
+				ItemsDatabaseDir:       fmt.Sprintf("%s/databases", *cacheDir),
+				APIKeysDatabaseDir:     fmt.Sprintf("%s/databases", *cacheDir),
+				SessionSecretsFilepath: fmt.Sprintf("%s/session-secrets.json", *cacheDir),
+				SessionSecretRotation:  *sessionSecretRotation,
+				BlizzardClientSecret:   *clientSecret,
+				BlizzardClientId:       *clientID,
+				MessengerPort:          *natsPort,
+				MessengerHost:          *natsHost,
+				MessengerConnect:       connectConfig,
+				GCloudProjectID:        *projectID,
+			}, healthConfig)
 		},
 		liveAuctionsCommand.FullCommand(): func() error {
-			return command.LiveAuctions(state.LiveAuctionsStateConfig{
+			return command.LiveAuctions(ctx, state.LiveAuctionsStateConfig{
 				MessengerHost:           *natsHost,
 				MessengerPort:           *natsPort,
+				MessengerConnect:        connectConfig,
+				BootRetry:               bootRetryConfig,
 				DiskStoreCacheDir:       *cacheDir,
 				LiveAuctionsDatabaseDir: fmt.Sprintf("%s/databases", *cacheDir),
-			})
+				Retention:               c.Retention,
+			}, healthConfig)
 		},
 		pricelistHistoriesCommand.FullCommand(): func() error {
-			return command.PricelistHistories(state.PricelistHistoriesStateConfig{
+			return command.PricelistHistories(ctx, state.PricelistHistoriesStateConfig{
 				DiskStoreCacheDir:             *cacheDir,
 				MessengerPort:                 *natsPort,
 				MessengerHost:                 *natsHost,
+				MessengerConnect:              connectConfig,
+				BootRetry:                     bootRetryConfig,
 				PricelistHistoriesDatabaseDir: fmt.Sprintf("%s/databases", *cacheDir),
-			})
+				Retention:                     c.Retention,
+			}, healthConfig)
 		},
 		prodApiCommand.FullCommand(): func() error {
-			return command.ProdApi(state.ProdApiStateConfig{
-				SotahConfig:     c,
-				MessengerPort:   *natsPort,
-				MessengerHost:   *natsHost,
-				GCloudProjectID: *projectID,
-			})
+			return command.ProdApi(ctx, state.ProdApiStateConfig{
+				SotahConfig:           c,
+				MessengerPort:         *natsPort,
+				MessengerHost:         *natsHost,
+				MessengerConnect:      connectConfig,
+				GCloudProjectID:       *projectID,
+				APIKeysDatabaseDir:    fmt.Sprintf("%s/databases", *cacheDir),
+				RecipesDatabaseDir:    fmt.Sprintf("%s/databases", *cacheDir),
+				SessionSecretRotation: *sessionSecretRotation,
+			}, healthConfig)
 		},
 		prodMetricsCommand.FullCommand(): func() error {
 			return command.ProdMetrics(state.ProdMetricsStateConfig{
-				MessengerPort:   *natsPort,
-				MessengerHost:   *natsHost,
-				GCloudProjectID: *projectID,
-			})
+				MessengerPort:    *natsPort,
+				MessengerHost:    *natsHost,
+				MessengerConnect: connectConfig,
+				GCloudProjectID:  *projectID,
+			}, healthConfig)
 		},
 		prodLiveAuctionsCommand.FullCommand(): func() error {
-			return command.ProdLiveAuctions(state.ProdLiveAuctionsStateConfig{
+			return command.ProdLiveAuctions(ctx, state.ProdLiveAuctionsStateConfig{
 				MessengerPort:           *natsPort,
 				MessengerHost:           *natsHost,
+				MessengerConnect:        connectConfig,
 				GCloudProjectID:         *projectID,
 				LiveAuctionsDatabaseDir: fmt.Sprintf("%s/databases", *cacheDir),
-			})
+				Retention:               c.Retention,
+				Snapshots:               c.Snapshots,
+			}, healthConfig)
 		},
 		prodPricelistHistoriesCommand.FullCommand(): func() error {
-			return command.ProdPricelistHistories(state.ProdPricelistHistoriesStateConfig{
+			return command.ProdPricelistHistories(ctx, state.ProdPricelistHistoriesStateConfig{
 				MessengerPort:                 *natsPort,
 				MessengerHost:                 *natsHost,
+				MessengerConnect:              connectConfig,
 				GCloudProjectID:               *projectID,
 				PricelistHistoriesDatabaseDir: fmt.Sprintf("%s/databases", *cacheDir),
-			})
+				Retention:                     c.Retention,
+			}, healthConfig)
 		},
 		prodItemsCommand.FullCommand(): func() error {
-			return command.ProdItems(state.ProdItemsStateConfig{
+			return command.ProdItems(ctx, state.ProdItemsStateConfig{
 				MessengerPort:    *natsPort,
 				MessengerHost:    *natsHost,
+				MessengerConnect: connectConfig,
 				GCloudProjectID:  *projectID,
 				ItemsDatabaseDir: fmt.Sprintf("%s/databases", *cacheDir),
-			})
+				Snapshots:        c.Snapshots,
+			}, healthConfig)
 		},
 		fnDownloadAllAuctions.FullCommand(): func() error {
-			return command.FnDownloadAllAuctions(fn.DownloadAllAuctionsStateConfig{
-				ProjectId:     *projectID,
-				MessengerHost: *natsHost,
-				MessengerPort: *natsPort,
+			return command.FnDownloadAllAuctions(ctx, fn.DownloadAllAuctionsStateConfig{
+				ProjectId:        *projectID,
+				MessengerHost:    *natsHost,
+				MessengerPort:    *natsPort,
+				MessengerConnect: connectConfig,
 			})
 		},
 		fnComputeAllLiveAuctions.FullCommand(): func() error {
-			return command.FnComputeAllLiveAuctions(fn.ComputeAllLiveAuctionsStateConfig{
+			return command.FnComputeAllLiveAuctions(ctx, fn.ComputeAllLiveAuctionsStateConfig{
 				ProjectId: *projectID,
 			})
 		},
 		fnComputeAllPricelistHistories.FullCommand(): func() error {
-			return command.FnComputeAllPricelistHistories(fn.ComputeAllPricelistHistoriesStateConfig{
+			return command.FnComputeAllPricelistHistories(ctx, fn.ComputeAllPricelistHistoriesStateConfig{
 				ProjectId: *projectID,
 			})
 		},
 		fnSyncAllItems.FullCommand(): func() error {
-			return command.FnSyncAllItems(fn.SyncAllItemsStateConfig{
+			return command.FnSyncAllItems(ctx, fn.SyncAllItemsStateConfig{
 				ProjectId: *projectID,
 			})
 		},
 		fnCleanupAllExpiredManifests.FullCommand(): func() error {
-			return command.FnCleanupAllExpiredManifests(fn.CleanupAllExpiredManifestsStateConfig{
+			return command.FnCleanupAllExpiredManifests(ctx, fn.CleanupAllExpiredManifestsStateConfig{
 				ProjectId: *projectID,
+				Retention: c.Retention,
 			})
 		},
 		fnCleanupPricelistHistories.FullCommand(): func() error {
-			return command.FnCleanupPricelistHistories(fn.CleanupPricelistHistoriesStateConfig{
+			return command.FnCleanupPricelistHistories(ctx, fn.CleanupPricelistHistoriesStateConfig{
 				ProjectId: *projectID,
+				Retention: c.Retention,
+			})
+		},
+		exportPricelistHistoriesCommand.FullCommand(): func() error {
+			itemIds := []blizzard.ItemID{}
+			for _, itemId := range *exportItemIds {
+				itemIds = append(itemIds, blizzard.ItemID(itemId))
+			}
+
+			return command.ExportPricelistHistories(command.ExportPricelistHistoriesConfig{
+				PricelistHistoriesDatabaseDir: fmt.Sprintf("%s/databases", *cacheDir),
+				OutFilepath:                   *exportOut,
+				Format:                        formats.Format(*exportFormat),
+				RegionName:                    blizzard.RegionName(*exportRegion),
+				RealmSlug:                     blizzard.RealmSlug(*exportRealm),
+				ItemIds:                       itemIds,
+				LowerBounds:                   *exportLowerBounds,
+				UpperBounds:                   *exportUpperBounds,
+			})
+		},
+		exportAuctionDumpsCommand.FullCommand(): func() error {
+			realmSlugs := []blizzard.RealmSlug{}
+			for _, realmSlug := range *exportDumpsRealms {
+				realmSlugs = append(realmSlugs, blizzard.RealmSlug(realmSlug))
+			}
+
+			return command.ExportAuctionDumps(ctx, command.ExportAuctionDumpsConfig{
+				ProjectId:   *projectID,
+				OutFilepath: *exportDumpsOut,
+				RegionName:  blizzard.RegionName(*exportDumpsRegion),
+				RealmSlugs:  realmSlugs,
+				LowerBounds: *exportDumpsLowerBounds,
+				UpperBounds: *exportDumpsUpperBounds,
+			})
+		},
+		replayAuctionDumpsCommand.FullCommand(): func() error {
+			return command.ReplayAuctionDumps(command.ReplayAuctionDumpsConfig{
+				ArchiveFilepath: *replayArchive,
+				DatabaseDir:     fmt.Sprintf("%s/databases", *cacheDir),
+				Retention:       c.Retention,
+			})
+		},
+		rebuildPricelistHistoriesCommand.FullCommand(): func() error {
+			realmSlugs := []blizzard.RealmSlug{}
+			for _, realmSlug := range *rebuildRealms {
+				realmSlugs = append(realmSlugs, blizzard.RealmSlug(realmSlug))
+			}
+
+			return command.RebuildPricelistHistories(ctx, command.RebuildPricelistHistoriesConfig{
+				ProjectId:          *projectID,
+				DatabaseDir:        fmt.Sprintf("%s/databases", *cacheDir),
+				CheckpointFilepath: *rebuildCheckpoint,
+				Concurrency:        *rebuildConcurrency,
+				SkipLocal:          *rebuildSkipLocal,
+				Retention:          c.Retention,
+				RegionName:         blizzard.RegionName(*rebuildRegion),
+				RealmSlugs:         realmSlugs,
+				LowerBounds:        *rebuildLowerBounds,
+				UpperBounds:        *rebuildUpperBounds,
+			})
+		},
+		migrateEncodingsCommand.FullCommand(): func() error {
+			return command.MigrateEncodings(command.MigrateEncodingsConfig{
+				DatabaseDir: fmt.Sprintf("%s/databases", *cacheDir),
+			})
+		},
+		pruneSchemaBackupsCommand.FullCommand(): func() error {
+			return command.PruneSchemaBackups(command.PruneSchemaBackupsConfig{
+				DatabaseDir: fmt.Sprintf("%s/databases", *cacheDir),
+				Keep:        *pruneSchemaBackupsKeep,
+			})
+		},
+		restoreSnapshotCommand.FullCommand(): func() error {
+			return command.RestoreSnapshot(ctx, command.RestoreSnapshotConfig{
+				ProjectId:   *projectID,
+				DatabaseDir: fmt.Sprintf("%s/databases", *cacheDir),
+				Kind:        schemakinds.SchemaKind(*restoreSnapshotKind),
+				SnapshotId:  *restoreSnapshotId,
+				Overwrite:   *restoreSnapshotOverwrite,
+			})
+		},
+		dbCheckCommand.FullCommand(): func() error {
+			return command.DbCheck(ctx, command.DbCheckConfig{
+				ProjectId:   *projectID,
+				DatabaseDir: fmt.Sprintf("%s/databases", *cacheDir),
+				Quarantine:  *dbCheckQuarantine,
+				Resync:      *dbCheckResync,
+			})
+		},
+		apiKeysIssueCommand.FullCommand(): func() error {
+			return command.IssueAPIKey(ctx, command.APIKeysConfig{
+				MessengerHost:    *natsHost,
+				MessengerPort:    *natsPort,
+				MessengerConnect: connectConfig,
+			}, apikeys.IssueRequest{
+				Name:       *apiKeysIssueName,
+				Role:       roles.Role(*apiKeysIssueRole),
+				RateLimit:  *apiKeysIssueRateLimit,
+				Burst:      *apiKeysIssueBurst,
+				DailyQuota: *apiKeysIssueDailyQuota,
+			})
+		},
+		apiKeysRevokeCommand.FullCommand(): func() error {
+			return command.RevokeAPIKey(ctx, command.APIKeysConfig{
+				MessengerHost:    *natsHost,
+				MessengerPort:    *natsPort,
+				MessengerConnect: connectConfig,
+			}, apikeys.RevokeRequest{ID: sotah.APIKeyID(*apiKeysRevokeId)})
+		},
+		apiKeysListCommand.FullCommand(): func() error {
+			return command.ListAPIKeys(ctx, command.APIKeysConfig{
+				MessengerHost:    *natsHost,
+				MessengerPort:    *natsPort,
+				MessengerConnect: connectConfig,
 			})
 		},
 	}
@@ -205,11 +602,37 @@ func main() {
 		return
 	}
 
-	// calling the command func
+	// calling the command func, where a forced shutdown exits apart from a failure
 	if err := cmdFunc(); err != nil {
+		if err == command.ErrForcedShutdown {
+			logging.WithField("command", cmd).Error("Forced shutdown")
+			logging.Flush()
+
+			os.Exit(2)
+		}
+
+		flushTraces()
 		logging.WithFields(logrus.Fields{
 			"error":   err.Error(),
 			"command": cmd,
 		}).Fatal("Failed to execute command")
 	}
+
+	flushTraces()
+
+	if err := logging.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to flush logs: %s\n", err.Error())
+	}
+}
+
+// traceShutdownTimeout - how long the remaining spans are given to export once a command is done
+const traceShutdownTimeout = 10 * time.Second
+
+func flushTraces() {
+	ctx, cancel := context.WithTimeout(context.Background(), traceShutdownTimeout)
+	defer cancel()
+
+	if err := tracing.DefaultTracer.Shutdown(ctx); err != nil {
+		logging.WithField("error", err.Error()).Error("Failed to flush traces")
+	}
 }
@@ -0,0 +1,147 @@
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/messenger/roles"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+)
+
+// keys are issued as their id and secret joined by a separator, so that a key is looked up by id before its secret
+const separator = "."
+
+const (
+	idSize     = 8
+	secretSize = 32
+)
+
+func randomHex(size int) (string, error) {
+	out := make([]byte, size)
+	if _, err := rand.Read(out); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(out), nil
+}
+
+func hashSecret(secret string) string {
+	hashed := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(hashed[:])
+}
+
+/*
+Issue fills in the id, secret hash and creation time of the key, returning the key along with the api key that is
+handed to its holder, which cannot be recovered later on since only a hash of the secret is kept
+*/
+func Issue(key sotah.APIKey, now time.Time) (sotah.APIKey, string, error) {
+	id, err := randomHex(idSize)
+	if err != nil {
+		return sotah.APIKey{}, "", err
+	}
+
+	secret, err := randomHex(secretSize)
+	if err != nil {
+		return sotah.APIKey{}, "", err
+	}
+
+	key.ID = sotah.APIKeyID(id)
+	key.SecretHash = hashSecret(secret)
+	key.CreatedAt = sotah.UnixTimestamp(now.Unix())
+	key.RevokedAt = 0
+
+	return key, strings.Join([]string{id, secret}, separator), nil
+}
+
+// ParseKey splits an api key into its id and secret, where keys that were not issued, such as fixed keys, are not ok
+func ParseKey(apiKey string) (sotah.APIKeyID, string, bool) {
+	parts := strings.Split(apiKey, separator)
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return "", "", false
+	}
+
+	return sotah.APIKeyID(parts[0]), parts[1], true
+}
+
+// Matches compares the secret against the kept hash in constant time
+func Matches(key sotah.APIKey, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(key.SecretHash)) == 1
+}
+
+// IssueRequest - the name, role and limits of a key to issue
+type IssueRequest struct {
+	Name       string     `json:"name"`
+	Role       roles.Role `json:"role"`
+	RateLimit  float64    `json:"rate_limit"`
+	Burst      int        `json:"burst"`
+	DailyQuota int        `json:"daily_quota"`
+}
+
+func (req IssueRequest) Validate() error {
+	if len(req.Name) == 0 {
+		return errors.New("name cannot be blank")
+	}
+
+	switch req.Role {
+	case roles.Public, roles.Client:
+	default:
+		return errors.New("issued keys may only be of the public or client role")
+	}
+
+	if req.RateLimit < 0 || req.Burst < 0 || req.DailyQuota < 0 {
+		return errors.New("limits cannot be negative")
+	}
+
+	return nil
+}
+
+func (req IssueRequest) APIKey() sotah.APIKey {
+	return sotah.APIKey{
+		Name:       req.Name,
+		Role:       req.Role,
+		RateLimit:  req.RateLimit,
+		Burst:      req.Burst,
+		DailyQuota: req.DailyQuota,
+	}
+}
+
+// IssueResponse - the issued key along with the api key handed to its holder, which is only ever sent this once
+type IssueResponse struct {
+	Key    sotah.APIKey `json:"key"`
+	APIKey string       `json:"issued_api_key"`
+}
+
+type RevokeRequest struct {
+	ID sotah.APIKeyID `json:"id"`
+}
+
+func (req RevokeRequest) Validate() error {
+	if len(req.ID) == 0 {
+		return errors.New("id cannot be blank")
+	}
+
+	return nil
+}
+
+// ResolveRequest - carries the api key under a field of its own, since the credentials field is taken off of payloads
+type ResolveRequest struct {
+	Key string `json:"key"`
+}
+
+// Resolution - a key that was found by its api key, along with how many requests it made today
+type Resolution struct {
+	Key       sotah.APIKey `json:"key"`
+	UsedToday int          `json:"used_today"`
+}
+
+// ListedKey - a key along with its usage today by subject
+type ListedKey struct {
+	Key   sotah.APIKey       `json:"key"`
+	Usage sotah.SubjectUsage `json:"usage"`
+}
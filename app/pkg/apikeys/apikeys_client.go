@@ -0,0 +1,112 @@
+package apikeys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	"github.com/sotah-inc/server/app/pkg/messenger/codes"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/state/subjects"
+)
+
+/*
+Keeper - where keys are looked up and their usage recorded, where a key that is not found is not ok, and an error is
+only returned where the key could not be looked up at all
+*/
+type Keeper interface {
+	Resolve(ctx context.Context, apiKey string) (Resolution, bool, error)
+	Record(ctx context.Context, usage sotah.APIKeyUsage) error
+}
+
+func NewClient(mess messenger.Messenger) Client {
+	return Client{mess: mess}
+}
+
+// Client - requests the api-keys subjects of the api, which keeps the key store, and is the keeper of every process
+type Client struct {
+	mess messenger.Messenger
+}
+
+func (c Client) request(ctx context.Context, subject subjects.Subject, req interface{}, resp interface{}) error {
+	encodedRequest, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	msg, err := c.mess.Request(ctx, string(subject), encodedRequest)
+	if err != nil {
+		return err
+	}
+
+	if msg.Code != codes.Ok {
+		return errors.New(msg.Err)
+	}
+
+	return json.Unmarshal([]byte(msg.Data), resp)
+}
+
+func (c Client) Resolve(ctx context.Context, apiKey string) (Resolution, bool, error) {
+	encodedRequest, err := json.Marshal(ResolveRequest{Key: apiKey})
+	if err != nil {
+		return Resolution{}, false, err
+	}
+
+	msg, err := c.mess.Request(ctx, string(subjects.APIKeysResolve), encodedRequest)
+	if err != nil {
+		return Resolution{}, false, err
+	}
+
+	switch msg.Code {
+	case codes.Ok:
+	case codes.NotFound:
+		return Resolution{}, false, nil
+	default:
+		return Resolution{}, false, errors.New(msg.Err)
+	}
+
+	out := Resolution{}
+	if err := json.Unmarshal([]byte(msg.Data), &out); err != nil {
+		return Resolution{}, false, err
+	}
+
+	return out, true, nil
+}
+
+// Record publishes the usage, since recording it is not waited on
+func (c Client) Record(_ context.Context, usage sotah.APIKeyUsage) error {
+	encoded, err := json.Marshal(usage)
+	if err != nil {
+		return err
+	}
+
+	return c.mess.Publish(string(subjects.APIKeysUsage), encoded)
+}
+
+func (c Client) Issue(ctx context.Context, req IssueRequest) (IssueResponse, error) {
+	out := IssueResponse{}
+	if err := c.request(ctx, subjects.APIKeysIssue, req, &out); err != nil {
+		return IssueResponse{}, err
+	}
+
+	return out, nil
+}
+
+func (c Client) Revoke(ctx context.Context, req RevokeRequest) (sotah.APIKey, error) {
+	out := sotah.APIKey{}
+	if err := c.request(ctx, subjects.APIKeysRevoke, req, &out); err != nil {
+		return sotah.APIKey{}, err
+	}
+
+	return out, nil
+}
+
+func (c Client) List(ctx context.Context) ([]ListedKey, error) {
+	out := []ListedKey{}
+	if err := c.request(ctx, subjects.APIKeysList, struct{}{}, &out); err != nil {
+		return []ListedKey{}, err
+	}
+
+	return out, nil
+}
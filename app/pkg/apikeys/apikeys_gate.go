@@ -0,0 +1,321 @@
+package apikeys
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	"github.com/sotah-inc/server/app/pkg/messenger/roles"
+	"github.com/sotah-inc/server/app/pkg/metric"
+	"github.com/sotah-inc/server/app/pkg/metric/kinds"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+)
+
+const (
+	keyLabel    = "key"
+	reasonLabel = "reason"
+)
+
+var (
+	admittedCounter = metric.NewCounter(
+		"sotah_api_key_requests_total",
+		"Requests admitted for issued api keys.",
+		keyLabel,
+		metric.SubjectLabel,
+	)
+	rejectedCounter = metric.NewCounter(
+		"sotah_api_key_rejected_total",
+		"Requests of issued api keys turned away for their limits.",
+		keyLabel,
+		reasonLabel,
+	)
+)
+
+// GateConfig - how long resolved keys are trusted, and how often usage is recorded
+type GateConfig struct {
+	// bounds how long a revoked key goes on being let through, and how stale the usage of other processes may get
+	ResolveTTL time.Duration
+
+	// where zero leaves usage to be recorded on closing only
+	FlushInterval time.Duration
+}
+
+var DefaultGateConfig = GateConfig{ResolveTTL: time.Minute, FlushInterval: 10 * time.Second}
+
+// maxResolved - how many resolved keys are held before those that expired are dropped, since guesses are held too
+const maxResolved = 10000
+
+// flushTimeout - how long recording usage on closing is given
+const flushTimeout = 5 * time.Second
+
+func NewGate(keeper Keeper, reporter metric.Reporter, config GateConfig) Gate {
+	g := Gate{&gateState{
+		keeper:    keeper,
+		reporter:  reporter,
+		config:    config,
+		now:       time.Now,
+		resolved:  map[string]resolvedKey{},
+		limiters:  map[sotah.APIKeyID]*limiter{},
+		usedToday: map[sotah.APIKeyID]int{},
+		pending:   map[string]sotah.APIKeyUsage{},
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}}
+
+	if config.FlushInterval <= 0 {
+		close(g.stopped)
+
+		return g
+	}
+
+	go g.flushEvery(config.FlushInterval)
+
+	return g
+}
+
+/*
+Gate - resolves and meters the api keys issued to third parties for a messenger, holding resolved keys for a while so
+that keys are not looked up on every request, and counting usage in memory until it is recorded, so that quotas are
+held to the usage recorded by every process as of resolving plus the usage of this process since
+*/
+type Gate struct {
+	*gateState
+}
+
+type resolvedKey struct {
+	key       sotah.APIKey
+	found     bool
+	expiresAt time.Time
+}
+
+type gateState struct {
+	keeper   Keeper
+	reporter metric.Reporter
+	config   GateConfig
+	now      func() time.Time
+
+	mu        sync.Mutex
+	resolved  map[string]resolvedKey
+	limiters  map[sotah.APIKeyID]*limiter
+	day       string
+	usedToday map[sotah.APIKeyID]int
+	pending   map[string]sotah.APIKeyUsage
+	admitted  int
+	rejected  int
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func (g Gate) resolve(ctx context.Context, apiKey string) (resolvedKey, error) {
+	// holding hashes rather than the api keys themselves
+	cacheKey := hashSecret(apiKey)
+
+	g.mu.Lock()
+	entry, ok := g.resolved[cacheKey]
+	g.mu.Unlock()
+	if ok && g.now().Before(entry.expiresAt) {
+		return entry, nil
+	}
+
+	resolution, found, err := g.keeper.Resolve(ctx, apiKey)
+	if err != nil {
+		return resolvedKey{}, err
+	}
+
+	now := g.now()
+	entry = resolvedKey{
+		key:       resolution.Key,
+		found:     found && !resolution.Key.IsRevoked(),
+		expiresAt: now.Add(g.config.ResolveTTL),
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.rollover(now)
+	if entry.found {
+		used := resolution.UsedToday + g.pending[g.day].Counts[entry.key.ID].Total()
+		if used > g.usedToday[entry.key.ID] {
+			g.usedToday[entry.key.ID] = used
+		}
+	}
+
+	if len(g.resolved) >= maxResolved {
+		for k, v := range g.resolved {
+			if !now.Before(v.expiresAt) {
+				delete(g.resolved, k)
+			}
+		}
+	}
+	g.resolved[cacheKey] = entry
+
+	return entry, nil
+}
+
+// rollover starts counting towards a new day once it comes, keeping usage of the day before that is yet to be recorded
+func (g Gate) rollover(now time.Time) {
+	day := sotah.APIKeyUsageDay(now)
+	if day == g.day {
+		return
+	}
+
+	g.day = day
+	g.usedToday = map[sotah.APIKeyID]int{}
+	if _, ok := g.pending[day]; !ok {
+		g.pending[day] = sotah.APIKeyUsage{Day: day, Counts: map[sotah.APIKeyID]sotah.SubjectUsage{}}
+	}
+}
+
+// ResolveKey resolves the role of issued keys, leaving any other key to the fixed keys
+func (g Gate) ResolveKey(ctx context.Context, apiKey string) (roles.Role, bool, error) {
+	if _, _, ok := ParseKey(apiKey); !ok {
+		return roles.Public, false, nil
+	}
+
+	entry, err := g.resolve(ctx, apiKey)
+	if err != nil {
+		return roles.Public, false, err
+	}
+
+	if !entry.found {
+		return roles.Public, false, nil
+	}
+
+	return entry.key.Role, true, nil
+}
+
+// Admit turns away requests of issued keys beyond their daily quota or rate limit, and counts the rest towards usage
+func (g Gate) Admit(ctx context.Context, subject string, credentials messenger.Credentials) error {
+	if _, _, ok := ParseKey(credentials.APIKey); !ok {
+		return nil
+	}
+
+	entry, err := g.resolve(ctx, credentials.APIKey)
+	if err != nil {
+		return err
+	}
+
+	// fixed keys that merely look issued are not metered
+	if !entry.found {
+		return nil
+	}
+	key := entry.key
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.rollover(now)
+
+	if key.DailyQuota > 0 && g.usedToday[key.ID] >= key.DailyQuota {
+		g.reject(key.ID, "quota")
+
+		return messenger.ErrQuotaExceeded
+	}
+
+	if key.RateLimit > 0 {
+		l, ok := g.limiters[key.ID]
+		if !ok {
+			l = newLimiter(key.RateLimit, key.Burst, now)
+			g.limiters[key.ID] = l
+		}
+
+		if !l.allow(now) {
+			g.reject(key.ID, "rate")
+
+			return messenger.ErrRateLimited
+		}
+	}
+
+	g.usedToday[key.ID]++
+	g.pending[g.day].Add(key.ID, subject, 1)
+	g.admitted++
+	admittedCounter.Inc(metric.Labels{keyLabel: string(key.ID), metric.SubjectLabel: subject})
+
+	return nil
+}
+
+func (g Gate) reject(id sotah.APIKeyID, reason string) {
+	g.rejected++
+	rejectedCounter.Inc(metric.Labels{keyLabel: string(id), reasonLabel: reason})
+}
+
+// Flush records the usage counted since last recorded, holding on to any that could not be recorded for next time
+func (g Gate) Flush(ctx context.Context) error {
+	g.mu.Lock()
+	pending := g.pending
+	g.pending = map[string]sotah.APIKeyUsage{}
+	if len(g.day) > 0 {
+		g.pending[g.day] = sotah.APIKeyUsage{Day: g.day, Counts: map[sotah.APIKeyID]sotah.SubjectUsage{}}
+	}
+	admitted, rejected := g.admitted, g.rejected
+	g.admitted, g.rejected = 0, 0
+	g.mu.Unlock()
+
+	if admitted > 0 || rejected > 0 {
+		g.reporter.ReportWithPrefix(metric.Metrics{"admitted": admitted, "rejected": rejected}, kinds.APIKeys)
+	}
+
+	var firstErr error
+	for day, usage := range pending {
+		if usage.IsEmpty() {
+			continue
+		}
+
+		if err := g.keeper.Record(ctx, usage); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			g.mu.Lock()
+			if _, ok := g.pending[day]; !ok {
+				g.pending[day] = sotah.APIKeyUsage{Day: day, Counts: map[sotah.APIKeyID]sotah.SubjectUsage{}}
+			}
+			for id, subjectUsage := range usage.Counts {
+				for subject, count := range subjectUsage {
+					g.pending[day].Add(id, subject, count)
+				}
+			}
+			g.mu.Unlock()
+		}
+	}
+
+	return firstErr
+}
+
+func (g Gate) flushEvery(interval time.Duration) {
+	defer close(g.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.stop:
+			return
+		case <-ticker.C:
+			if err := g.Flush(context.Background()); err != nil {
+				logging.WithField("error", err.Error()).Error("Failed to record api key usage")
+			}
+		}
+	}
+}
+
+// Close stops recording usage periodically, and records what is left
+func (g Gate) Close() error {
+	select {
+	case <-g.stop:
+		return nil
+	default:
+		close(g.stop)
+	}
+	<-g.stopped
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	return g.Flush(ctx)
+}
@@ -0,0 +1,43 @@
+package apikeys
+
+import (
+	"time"
+)
+
+func newLimiter(rate float64, burst int, now time.Time) *limiter {
+	capacity := float64(burst)
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &limiter{rate: rate, capacity: capacity, tokens: capacity, last: now}
+}
+
+/*
+limiter - a token bucket refilled at the rate limit, holding at most the burst, where a burst below one lets through one
+request at a time
+*/
+type limiter struct {
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func (l *limiter) allow(now time.Time) bool {
+	if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+		l.tokens += elapsed * l.rate
+		if l.tokens > l.capacity {
+			l.tokens = l.capacity
+		}
+		l.last = now
+	}
+
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+
+	return true
+}
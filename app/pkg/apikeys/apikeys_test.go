@@ -0,0 +1,212 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	"github.com/sotah-inc/server/app/pkg/messenger/roles"
+	"github.com/sotah-inc/server/app/pkg/metric"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssue(t *testing.T) {
+	key, apiKey, err := Issue(sotah.APIKey{Name: "tool", Role: roles.Client}, time.Unix(100, 0))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	id, secret, ok := ParseKey(apiKey)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	assert.Equal(t, key.ID, id)
+	assert.Equal(t, sotah.UnixTimestamp(100), key.CreatedAt)
+	assert.True(t, Matches(key, secret))
+	assert.False(t, Matches(key, secret+"0"))
+	assert.NotContains(t, key.SecretHash, secret)
+}
+
+func TestParseKey(t *testing.T) {
+	_, _, ok := ParseKey("fixed-key")
+	assert.False(t, ok)
+
+	_, _, ok = ParseKey(".secret")
+	assert.False(t, ok)
+
+	_, _, ok = ParseKey("a.b.c")
+	assert.False(t, ok)
+
+	id, secret, ok := ParseKey("id.secret")
+	assert.True(t, ok)
+	assert.Equal(t, sotah.APIKeyID("id"), id)
+	assert.Equal(t, "secret", secret)
+}
+
+func TestIssueRequestValidate(t *testing.T) {
+	assert.Nil(t, IssueRequest{Name: "tool", Role: roles.Client}.Validate())
+	assert.NotNil(t, IssueRequest{Role: roles.Client}.Validate())
+	assert.NotNil(t, IssueRequest{Name: "tool", Role: roles.Service}.Validate())
+	assert.NotNil(t, IssueRequest{Name: "tool", Role: roles.Client, DailyQuota: -1}.Validate())
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newLimiter(2, 3, now)
+
+	assert.True(t, l.allow(now))
+	assert.True(t, l.allow(now))
+	assert.True(t, l.allow(now))
+	assert.False(t, l.allow(now))
+
+	// refilling at two a second
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, l.allow(now))
+	assert.False(t, l.allow(now))
+
+	// holding no more than the burst after going without
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.allow(now))
+	}
+	assert.False(t, l.allow(now))
+}
+
+// testKeeper - keys by api key, recording usage unless failing
+type testKeeper struct {
+	resolutions map[string]Resolution
+	resolved    int
+	recorded    []sotah.APIKeyUsage
+	failing     bool
+}
+
+func (k *testKeeper) Resolve(_ context.Context, apiKey string) (Resolution, bool, error) {
+	k.resolved++
+	if k.failing {
+		return Resolution{}, false, errors.New("keeper is down")
+	}
+
+	resolution, ok := k.resolutions[apiKey]
+
+	return resolution, ok, nil
+}
+
+func (k *testKeeper) Record(_ context.Context, usage sotah.APIKeyUsage) error {
+	if k.failing {
+		return errors.New("keeper is down")
+	}
+
+	k.recorded = append(k.recorded, usage)
+
+	return nil
+}
+
+func newTestGate(keeper *testKeeper, now *time.Time) Gate {
+	g := NewGate(keeper, metric.Reporter{}, GateConfig{ResolveTTL: time.Minute})
+	g.now = func() time.Time {
+		return *now
+	}
+
+	return g
+}
+
+func TestGateResolveKey(t *testing.T) {
+	now := time.Unix(0, 0)
+	keeper := &testKeeper{resolutions: map[string]Resolution{
+		"a.secret": {Key: sotah.APIKey{ID: "a", Role: roles.Client}},
+		"b.secret": {Key: sotah.APIKey{ID: "b", Role: roles.Client, RevokedAt: 1}},
+	}}
+	g := newTestGate(keeper, &now)
+	ctx := context.Background()
+
+	role, ok, err := g.ResolveKey(ctx, "a.secret")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, roles.Client, role)
+
+	_, ok, err = g.ResolveKey(ctx, "b.secret")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = g.ResolveKey(ctx, "fixed-key")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// holding resolved keys until they expire
+	_, _, _ = g.ResolveKey(ctx, "a.secret")
+	assert.Equal(t, 2, keeper.resolved)
+
+	now = now.Add(2 * time.Minute)
+	_, _, _ = g.ResolveKey(ctx, "a.secret")
+	assert.Equal(t, 3, keeper.resolved)
+
+	keeper.failing = true
+	now = now.Add(2 * time.Minute)
+	_, _, err = g.ResolveKey(ctx, "a.secret")
+	assert.NotNil(t, err)
+}
+
+func TestGateAdmit(t *testing.T) {
+	now := time.Date(2020, 1, 1, 23, 59, 0, 0, time.UTC)
+	keeper := &testKeeper{resolutions: map[string]Resolution{
+		"quota.secret": {Key: sotah.APIKey{ID: "quota", Role: roles.Client, DailyQuota: 3}, UsedToday: 1},
+		"rate.secret":  {Key: sotah.APIKey{ID: "rate", Role: roles.Client, RateLimit: 1, Burst: 1}},
+	}}
+	g := newTestGate(keeper, &now)
+	ctx := context.Background()
+
+	quota := messenger.Credentials{APIKey: "quota.secret"}
+	assert.Nil(t, g.Admit(ctx, "auctions", quota))
+	assert.Nil(t, g.Admit(ctx, "auctions", quota))
+	assert.Equal(t, messenger.ErrQuotaExceeded, g.Admit(ctx, "auctions", quota))
+
+	rate := messenger.Credentials{APIKey: "rate.secret"}
+	assert.Nil(t, g.Admit(ctx, "priceList", rate))
+	assert.Equal(t, messenger.ErrRateLimited, g.Admit(ctx, "priceList", rate))
+
+	assert.Nil(t, g.Admit(ctx, "auctions", messenger.Credentials{}))
+	assert.Nil(t, g.Admit(ctx, "auctions", messenger.Credentials{APIKey: "unknown.secret"}))
+
+	// the quota is held to a day
+	now = now.Add(2 * time.Minute)
+	assert.Nil(t, g.Admit(ctx, "auctions", quota))
+
+	if !assert.Nil(t, g.Flush(ctx)) {
+		return
+	}
+
+	recorded := map[string]sotah.APIKeyUsage{}
+	for _, usage := range keeper.recorded {
+		recorded[usage.Day] = usage
+	}
+	assert.Equal(t, sotah.SubjectUsage{"auctions": 2}, recorded["2020-01-01"].Counts["quota"])
+	assert.Equal(t, sotah.SubjectUsage{"priceList": 1}, recorded["2020-01-01"].Counts["rate"])
+	assert.Equal(t, sotah.SubjectUsage{"auctions": 1}, recorded["2020-01-02"].Counts["quota"])
+}
+
+func TestGateFlushRetains(t *testing.T) {
+	now := time.Unix(0, 0)
+	keeper := &testKeeper{resolutions: map[string]Resolution{
+		"a.secret": {Key: sotah.APIKey{ID: "a", Role: roles.Client}},
+	}}
+	g := newTestGate(keeper, &now)
+	ctx := context.Background()
+
+	assert.Nil(t, g.Admit(ctx, "auctions", messenger.Credentials{APIKey: "a.secret"}))
+
+	keeper.failing = true
+	assert.NotNil(t, g.Flush(ctx))
+
+	keeper.failing = false
+	assert.Nil(t, g.Admit(ctx, "auctions", messenger.Credentials{APIKey: "a.secret"}))
+	assert.Nil(t, g.Close())
+
+	if !assert.Equal(t, 1, len(keeper.recorded)) {
+		return
+	}
+	assert.Equal(t, sotah.SubjectUsage{"auctions": 2}, keeper.recorded[0].Counts["a"])
+}
@@ -0,0 +1,214 @@
+package archive
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/util"
+)
+
+/*
+an archive is a tar file whose first entry is the manifest, followed by each raw auction dump (gzipped json, as it is
+kept in the store) in the order listed by the manifest, which is ascending last-modified
+*/
+const (
+	manifestName    = "manifest.json"
+	manifestVersion = 1
+)
+
+func NewEntry(realm sotah.Realm, lastModified sotah.UnixTimestamp) Entry {
+	return Entry{
+		Realm:        realm,
+		LastModified: lastModified,
+		Path:         fmt.Sprintf("dumps/%s/%s/%d.json.gz", realm.Region.Name, realm.Slug, lastModified),
+	}
+}
+
+type Entry struct {
+	Realm        sotah.Realm         `json:"realm"`
+	LastModified sotah.UnixTimestamp `json:"last_modified"`
+	Path         string              `json:"path"`
+}
+
+func (e Entry) LastModifiedTime() time.Time {
+	return time.Unix(int64(e.LastModified), 0)
+}
+
+func NewManifest(entries []Entry) Manifest {
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].LastModified < sorted[j].LastModified
+	})
+
+	return Manifest{
+		Version:   manifestVersion,
+		CreatedAt: sotah.UnixTimestamp(time.Now().Unix()),
+		Entries:   sorted,
+	}
+}
+
+type Manifest struct {
+	Version   int                 `json:"version"`
+	CreatedAt sotah.UnixTimestamp `json:"created_at"`
+	Entries   []Entry             `json:"entries"`
+}
+
+// Statuses - realms covered by the archive, in the shape the databases are established with
+func (m Manifest) Statuses() sotah.Statuses {
+	seen := map[blizzard.RegionName]map[blizzard.RealmSlug]struct{}{}
+	out := sotah.Statuses{}
+	for _, entry := range m.Entries {
+		regionName := entry.Realm.Region.Name
+		if _, ok := seen[regionName]; !ok {
+			seen[regionName] = map[blizzard.RealmSlug]struct{}{}
+		}
+		if _, ok := seen[regionName][entry.Realm.Slug]; ok {
+			continue
+		}
+		seen[regionName][entry.Realm.Slug] = struct{}{}
+
+		status := out[regionName]
+		status.Region = entry.Realm.Region
+		status.Realms = append(status.Realms, entry.Realm)
+		out[regionName] = status
+	}
+
+	return out
+}
+
+// Writer - writes dumps in the order of the manifest, which is written up front
+type Writer struct {
+	tw       *tar.Writer
+	manifest Manifest
+	next     int
+}
+
+func NewWriter(w io.Writer, manifest Manifest) (*Writer, error) {
+	out := &Writer{tw: tar.NewWriter(w), manifest: manifest}
+
+	jsonEncoded, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := out.writeFile(manifestName, jsonEncoded); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (w *Writer) writeFile(name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err := w.tw.Write(data)
+
+	return err
+}
+
+// WriteDump - writes the gzipped dump for the next entry in the manifest
+func (w *Writer) WriteDump(entry Entry, gzipEncoded []byte) error {
+	if w.next >= len(w.manifest.Entries) || w.manifest.Entries[w.next].Path != entry.Path {
+		return errors.New("dumps must be written in manifest order")
+	}
+
+	if err := w.writeFile(entry.Path, gzipEncoded); err != nil {
+		return err
+	}
+	w.next++
+
+	return nil
+}
+
+func (w *Writer) Close() error {
+	if w.next != len(w.manifest.Entries) {
+		return fmt.Errorf("archive is missing %d dumps", len(w.manifest.Entries)-w.next)
+	}
+
+	return w.tw.Close()
+}
+
+// Reader - reads dumps back out in manifest order
+type Reader struct {
+	tr       *tar.Reader
+	Manifest Manifest
+	next     int
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	tr := tar.NewReader(r)
+
+	header, err := tr.Next()
+	if err != nil {
+		return nil, err
+	}
+	if header.Name != manifestName {
+		return nil, fmt.Errorf("expected %s as first archive entry, found %s", manifestName, header.Name)
+	}
+
+	data, err := ioutil.ReadAll(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := Manifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported archive version: %d", manifest.Version)
+	}
+
+	return &Reader{tr: tr, Manifest: manifest}, nil
+}
+
+// Next - returns the next entry with its decoded auctions, or io.EOF once every entry has been read
+func (r *Reader) Next() (Entry, blizzard.Auctions, error) {
+	if r.next >= len(r.Manifest.Entries) {
+		return Entry{}, blizzard.Auctions{}, io.EOF
+	}
+	entry := r.Manifest.Entries[r.next]
+
+	header, err := r.tr.Next()
+	if err != nil {
+		return Entry{}, blizzard.Auctions{}, err
+	}
+	if header.Name != entry.Path {
+		return Entry{}, blizzard.Auctions{}, fmt.Errorf("expected %s in archive, found %s", entry.Path, header.Name)
+	}
+
+	gzipEncoded, err := ioutil.ReadAll(r.tr)
+	if err != nil {
+		return Entry{}, blizzard.Auctions{}, err
+	}
+
+	jsonEncoded, err := util.GzipDecode(gzipEncoded)
+	if err != nil {
+		return Entry{}, blizzard.Auctions{}, err
+	}
+
+	aucs, err := blizzard.NewAuctions(jsonEncoded)
+	if err != nil {
+		return Entry{}, blizzard.Auctions{}, err
+	}
+	r.next++
+
+	return entry, aucs, nil
+}
@@ -0,0 +1,74 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDump(t *testing.T, auc int64) []byte {
+	jsonEncoded, err := json.Marshal(blizzard.Auctions{Auctions: []blizzard.Auction{{Auc: auc}}})
+	if !assert.Nil(t, err) {
+		return nil
+	}
+
+	gzipEncoded, err := util.GzipEncode(jsonEncoded)
+	if !assert.Nil(t, err) {
+		return nil
+	}
+
+	return gzipEncoded
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	realm := sotah.Realm{
+		Realm:  blizzard.Realm{Slug: "earthen-ring"},
+		Region: sotah.Region{Name: "us"},
+	}
+	manifest := NewManifest([]Entry{NewEntry(realm, 200), NewEntry(realm, 100)})
+
+	buf := bytes.Buffer{}
+	w, err := NewWriter(&buf, manifest)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.NotNil(t, w.WriteDump(manifest.Entries[1], newTestDump(t, 2)))
+	for i, entry := range manifest.Entries {
+		if !assert.Nil(t, w.WriteDump(entry, newTestDump(t, int64(i+1)))) {
+			return
+		}
+	}
+	if !assert.Nil(t, w.Close()) {
+		return
+	}
+
+	r, err := NewReader(&buf)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, r.Manifest.Statuses()["us"].Realms, 1)
+
+	entry, aucs, err := r.Next()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, sotah.UnixTimestamp(100), entry.LastModified)
+	assert.Equal(t, int64(1), aucs.Auctions[0].Auc)
+
+	entry, aucs, err = r.Next()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, sotah.UnixTimestamp(200), entry.LastModified)
+	assert.Equal(t, int64(2), aucs.Auctions[0].Auc)
+
+	_, _, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
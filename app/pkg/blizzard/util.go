@@ -1,6 +1,7 @@
 package blizzard
 
 import (
+	"context"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -76,11 +77,17 @@ type ResponseMeta struct {
 
 // Download - performs HTTP GET request against url, including adding gzip header and ungzipping
 func Download(url string) (ResponseMeta, error) {
+	return DownloadWithContext(context.Background(), url)
+}
+
+// DownloadWithContext - performs Download, aborting the request once the context is done
+func DownloadWithContext(ctx context.Context, url string) (ResponseMeta, error) {
 	// forming a request
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return ResponseMeta{}, err
 	}
+	req = req.WithContext(ctx)
 	req.Header.Add("Accept-Encoding", "gzip")
 
 	// running it into a client
@@ -19,6 +19,7 @@ import (
 	"github.com/sotah-inc/server/app/pkg/metric"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
+	"github.com/sotah-inc/server/app/pkg/tracing"
 	"github.com/sotah-inc/server/app/pkg/util"
 	"github.com/twinj/uuid"
 )
@@ -136,6 +137,42 @@ type Message struct {
 	Code      codes.Code `json:"code"`
 	ReplyTo   string     `json:"reply_to"`
 	ReplyToId string     `json:"reply_to_id"`
+
+	// unix-nano deadline of the requester, where zero means the requester is not waiting on a reply
+	Deadline int64 `json:"deadline,omitempty"`
+
+	// w3c trace-context of the publisher, so that handling the message carries on its trace
+	Traceparent string `json:"traceparent,omitempty"`
+}
+
+/*
+Context derives a context for handling the message, bounded by the deadline of its requester and carrying on the trace
+of its publisher; it is derived from a fresh context rather than the subscription, so that stopping a subscription lets
+in-flight callbacks finish
+*/
+func (msg Message) Context() (context.Context, context.CancelFunc) {
+	ctx := msg.TraceContext(context.Background())
+	if msg.Deadline == 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithDeadline(ctx, time.Unix(0, msg.Deadline))
+}
+
+// TraceContext carries on the trace of the publisher, for messages that are decoded outside of a subscription
+func (msg Message) TraceContext(ctx context.Context) context.Context {
+	return tracing.WithRemoteParent(ctx, msg.Traceparent)
+}
+
+// withDeadline stamps the message with the earlier of the context deadline and the timeout
+func (msg Message) withDeadline(ctx context.Context, timeout time.Duration) Message {
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	msg.Deadline = deadline.UnixNano()
+
+	return msg
 }
 
 func NewClient(projectID string, subscriberId string) (Client, error) {
@@ -147,31 +184,29 @@ func NewClient(projectID string, subscriberId string) (Client, error) {
 
 	return Client{
 		client:       client,
-		context:      ctx,
 		projectId:    projectID,
 		subscriberId: subscriberId,
 	}, nil
 }
 
 type Client struct {
-	context      context.Context
 	projectId    string
 	client       *pubsub.Client
 	subscriberId string
 }
 
-func (c Client) CreateTopic(id string) (*pubsub.Topic, error) {
-	return c.client.CreateTopic(c.context, id)
+func (c Client) CreateTopic(ctx context.Context, id string) (*pubsub.Topic, error) {
+	return c.client.CreateTopic(ctx, id)
 }
 
 func (c Client) Topic(topicName string) *pubsub.Topic {
 	return c.client.Topic(topicName)
 }
 
-func (c Client) FirmTopic(topicName string) (*pubsub.Topic, error) {
+func (c Client) FirmTopic(ctx context.Context, topicName string) (*pubsub.Topic, error) {
 	topic := c.Topic(topicName)
 
-	exists, err := topic.Exists(c.context)
+	exists, err := topic.Exists(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -183,9 +218,9 @@ func (c Client) FirmTopic(topicName string) (*pubsub.Topic, error) {
 	return topic, nil
 }
 
-func (c Client) ResolveTopic(id string) (*pubsub.Topic, error) {
+func (c Client) ResolveTopic(ctx context.Context, id string) (*pubsub.Topic, error) {
 	topic := c.Topic(id)
-	exists, err := topic.Exists(c.context)
+	exists, err := topic.Exists(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -193,22 +228,31 @@ func (c Client) ResolveTopic(id string) (*pubsub.Topic, error) {
 		return topic, nil
 	}
 
-	return c.CreateTopic(id)
+	return c.CreateTopic(ctx, id)
 }
 
-func (c Client) CreateSubscription(topic *pubsub.Topic) (*pubsub.Subscription, error) {
-	return c.client.CreateSubscription(c.context, c.subscriberName(topic), pubsub.SubscriptionConfig{
+func (c Client) CreateSubscription(ctx context.Context, topic *pubsub.Topic) (*pubsub.Subscription, error) {
+	return c.client.CreateSubscription(ctx, c.subscriberName(topic), pubsub.SubscriptionConfig{
 		Topic: topic,
 	})
 }
 
-func (c Client) Publish(topic *pubsub.Topic, msg Message) (string, error) {
+func (c Client) Publish(ctx context.Context, topic *pubsub.Topic, msg Message) (string, error) {
+	ctx, span := tracing.Start(ctx, fmt.Sprintf("bus publish %s", topic.ID()))
+	defer span.End()
+
+	msg.Traceparent = tracing.Traceparent(ctx)
 	data, err := json.Marshal(msg)
 	if err != nil {
+		span.SetError(err)
+
 		return "", err
 	}
 
-	return topic.Publish(c.context, &pubsub.Message{Data: data}).Get(c.context)
+	id, err := topic.Publish(ctx, &pubsub.Message{Data: data}).Get(ctx)
+	span.SetError(err)
+
+	return id, err
 }
 
 type BulkPublishOutJob struct {
@@ -216,13 +260,13 @@ type BulkPublishOutJob struct {
 	Msg Message
 }
 
-func (c Client) BulkPublish(topic *pubsub.Topic, messages []Message) chan BulkPublishOutJob {
+func (c Client) BulkPublish(ctx context.Context, topic *pubsub.Topic, messages []Message) chan BulkPublishOutJob {
 	// opening workers and channels
 	in := make(chan Message)
 	out := make(chan BulkPublishOutJob)
 	worker := func() {
 		for msg := range in {
-			if _, err := c.Publish(topic, msg); err != nil {
+			if _, err := c.Publish(ctx, topic, msg); err != nil {
 				out <- BulkPublishOutJob{
 					Err: err,
 					Msg: msg,
@@ -258,14 +302,19 @@ func (c Client) subscriberName(topic *pubsub.Topic) string {
 	return fmt.Sprintf("subscriber-%s-%s-%s", c.subscriberId, topic.ID(), uuid.NewV4().String())
 }
 
-func (c Client) SubscribeToTopic(id string, config SubscribeConfig) error {
-	topic, err := c.ResolveTopic(id)
+// subscribeTimeout - the deadline for setting up a subscription, past which it is considered stuck
+const subscribeTimeout = 30 * time.Second
+
+func (c Client) SubscribeToTopic(ctx context.Context, id string, config SubscribeConfig) error {
+	resolveCtx, cancel := context.WithTimeout(ctx, subscribeTimeout)
+	topic, err := c.ResolveTopic(resolveCtx, id)
+	cancel()
 	if err != nil {
 		return err
 	}
 	config.Topic = topic
 
-	return c.Subscribe(config)
+	return c.Subscribe(ctx, config)
 }
 
 type SubscribeConfig struct {
@@ -273,11 +322,16 @@ type SubscribeConfig struct {
 	Stop      chan interface{}
 	OnReady   chan interface{}
 	OnStopped chan interface{}
-	Callback  func(Message)
+
+	// the context of each callback is bounded by the deadline of the message
+	Callback func(ctx context.Context, msg Message)
 }
 
-func (c Client) Subscribe(config SubscribeConfig) error {
-	sub, err := c.CreateSubscription(config.Topic)
+// Subscribe uses the context only to set up the subscription, which otherwise runs until it is stopped
+func (c Client) Subscribe(ctx context.Context, config SubscribeConfig) error {
+	createCtx, cancel := context.WithTimeout(ctx, subscribeTimeout)
+	sub, err := c.CreateSubscription(createCtx, config.Topic)
+	cancel()
 	if err != nil {
 		return err
 	}
@@ -289,18 +343,21 @@ func (c Client) Subscribe(config SubscribeConfig) error {
 		"topic":           config.Topic.ID(),
 	})
 
-	cctx, cancel := context.WithCancel(c.context)
+	// receiving returns only once every in-flight callback has returned, so stopping waits on that
+	cctx, cancel := context.WithCancel(context.Background())
+	receiveDone := make(chan interface{})
 	go func() {
 		<-config.Stop
 
 		cancel()
+		<-receiveDone
 		config.Topic.Stop()
 
 		config.OnStopped <- struct{}{}
 	}()
 
 	entry.Info("Waiting for messages")
-	err = sub.Receive(cctx, func(ctx context.Context, pubsubMsg *pubsub.Message) {
+	err = sub.Receive(cctx, func(_ context.Context, pubsubMsg *pubsub.Message) {
 		pubsubMsg.Ack()
 
 		var msg Message
@@ -310,8 +367,15 @@ func (c Client) Subscribe(config SubscribeConfig) error {
 			return
 		}
 
-		config.Callback(msg)
+		msgCtx, msgCancel := msg.Context()
+		defer msgCancel()
+
+		msgCtx, span := tracing.Start(msgCtx, fmt.Sprintf("bus receive %s", config.Topic.ID()))
+		defer span.End()
+
+		config.Callback(msgCtx, msg)
 	})
+	close(receiveDone)
 	if err != nil {
 		if err == context.Canceled {
 			return nil
@@ -323,29 +387,34 @@ func (c Client) Subscribe(config SubscribeConfig) error {
 	return nil
 }
 
-func (c Client) ReplyTo(target Message, payload Message) (string, error) {
+func (c Client) ReplyTo(ctx context.Context, target Message, payload Message) (string, error) {
 	if target.ReplyTo == "" {
 		return "", errors.New("cannot reply to blank reply-to topic name")
 	}
 
 	// validating topic already exists
-	topic, err := c.FirmTopic(target.ReplyTo)
+	topic, err := c.FirmTopic(ctx, target.ReplyTo)
 	if err != nil {
 		return "", err
 	}
 
 	logging.WithField("reply-to-topic", topic.ID()).Info("Replying to topic")
 
-	return c.Publish(topic, payload)
+	return c.Publish(ctx, topic, payload)
 }
 
-func (c Client) RequestFromTopic(topicName string, payload string, timeout time.Duration) (Message, error) {
-	topic, err := c.FirmTopic(topicName)
+func (c Client) RequestFromTopic(
+	ctx context.Context,
+	topicName string,
+	payload string,
+	timeout time.Duration,
+) (Message, error) {
+	topic, err := c.FirmTopic(ctx, topicName)
 	if err != nil {
 		return Message{}, err
 	}
 
-	return c.Request(topic, payload, timeout)
+	return c.Request(ctx, topic, payload, timeout)
 }
 
 type MessageResponses struct {
@@ -387,18 +456,26 @@ func NewBulkRequestMessages(messages []Message) BulkRequestMessages {
 
 type BulkRequestMessages map[string]Message
 
-func (c Client) BulkRequest(intakeTopic *pubsub.Topic, messages []Message, timeout time.Duration) (BulkRequestMessages, error) {
+func (c Client) BulkRequest(
+	ctx context.Context,
+	intakeTopic *pubsub.Topic,
+	messages []Message,
+	timeout time.Duration,
+) (BulkRequestMessages, error) {
+	ctx, span := tracing.Start(ctx, fmt.Sprintf("bus bulk-request %s", intakeTopic.ID()))
+	defer span.End()
+
 	// producing a topic to receive responses
 	logging.Info("Producing a topic and subscription to receive responses")
-	recipientTopic, err := c.CreateTopic(fmt.Sprintf("bulk-request-%s", uuid.NewV4().String()))
+	recipientTopic, err := c.CreateTopic(ctx, fmt.Sprintf("bulk-request-%s", uuid.NewV4().String()))
 	if err != nil {
 		return BulkRequestMessages{}, err
 	}
 
-	// updating messages with reply-to topic
+	// updating messages with reply-to topic and deadline
 	for i, msg := range messages {
 		msg.ReplyTo = recipientTopic.ID()
-		messages[i] = msg
+		messages[i] = msg.withDeadline(ctx, timeout)
 	}
 
 	// producing a blank list of message responses
@@ -409,13 +486,13 @@ func (c Client) BulkRequest(intakeTopic *pubsub.Topic, messages []Message, timeo
 
 	// opening a listener
 	logging.Info("Opening a listener and waiting for it to finish opening")
-	onComplete := make(chan interface{})
+	onComplete := make(chan interface{}, 1)
 	receiveConfig := SubscribeConfig{
 		Topic:     recipientTopic,
 		OnReady:   make(chan interface{}),
 		Stop:      make(chan interface{}),
 		OnStopped: make(chan interface{}),
-		Callback: func(busMsg Message) {
+		Callback: func(_ context.Context, busMsg Message) {
 			responses.Mutex.Lock()
 			defer responses.Mutex.Unlock()
 			responses.Items[busMsg.ReplyToId] = busMsg
@@ -424,13 +501,17 @@ func (c Client) BulkRequest(intakeTopic *pubsub.Topic, messages []Message, timeo
 				return
 			}
 
-			onComplete <- struct{}{}
+			// never blocking, since stopping the receiver waits on every callback
+			select {
+			case onComplete <- struct{}{}:
+			default:
+			}
 
 			return
 		},
 	}
 	go func() {
-		if err := c.Subscribe(receiveConfig); err != nil {
+		if err := c.Subscribe(ctx, receiveConfig); err != nil {
 			logging.Fatalf("Failed to subscribe to recipient topic: %s", err.Error())
 
 			return
@@ -441,7 +522,7 @@ func (c Client) BulkRequest(intakeTopic *pubsub.Topic, messages []Message, timeo
 	// bulk publishing
 	logging.Info("Bulk publishing")
 	startTime := time.Now()
-	for outJob := range c.BulkPublish(intakeTopic, messages) {
+	for outJob := range c.BulkPublish(ctx, intakeTopic, messages) {
 		if outJob.Err != nil {
 			return BulkRequestMessages{}, outJob.Err
 		}
@@ -454,6 +535,10 @@ func (c Client) BulkRequest(intakeTopic *pubsub.Topic, messages []Message, timeo
 	case <-timer:
 		logging.Info("Timer timed out, going over results in allotted time")
 
+		break
+	case <-ctx.Done():
+		logging.WithField("error", ctx.Err().Error()).Info("Context is done, going over results in allotted time")
+
 		break
 	case <-onComplete:
 		logging.Info("Received all responses, going over all responses")
@@ -481,22 +566,35 @@ type requestJob struct {
 	Payload Message
 }
 
-func (c Client) Request(recipientTopic *pubsub.Topic, payload string, timeout time.Duration) (Message, error) {
+func (c Client) Request(
+	ctx context.Context,
+	recipientTopic *pubsub.Topic,
+	payload string,
+	timeout time.Duration,
+) (Message, error) {
+	ctx, span := tracing.Start(ctx, fmt.Sprintf("bus request %s", recipientTopic.ID()))
+	defer span.End()
+
 	// producing a reply-to topic
-	replyToTopic, err := c.client.CreateTopic(c.context, fmt.Sprintf("reply-to-%s", uuid.NewV4().String()))
+	replyToTopic, err := c.client.CreateTopic(ctx, fmt.Sprintf("reply-to-%s", uuid.NewV4().String()))
 	if err != nil {
 		return Message{}, err
 	}
 
 	// producing a reply-to subscription
-	replyToSub, err := c.client.CreateSubscription(c.context, c.subscriberName(replyToTopic), pubsub.SubscriptionConfig{
+	replyToSub, err := c.client.CreateSubscription(ctx, c.subscriberName(replyToTopic), pubsub.SubscriptionConfig{
 		Topic: replyToTopic,
 	})
 	if err != nil {
 		return Message{}, err
 	}
 
-	cctx, cancel := context.WithCancel(c.context)
+	// cleaning up must happen even once the request context is done
+	cleanupCtx := context.Background()
+	requestCtx, requestCancel := context.WithTimeout(ctx, timeout)
+	defer requestCancel()
+
+	cctx, cancel := context.WithCancel(context.Background())
 
 	// spawning a worker to wait for a response on the reply-to topic
 	out := make(chan requestJob)
@@ -509,7 +607,7 @@ func (c Client) Request(recipientTopic *pubsub.Topic, payload string, timeout ti
 				close(receiver)
 
 				cancel()
-				if err := replyToSub.Delete(c.context); err != nil {
+				if err := replyToSub.Delete(cleanupCtx); err != nil {
 					logging.WithFields(logrus.Fields{
 						"error":        err.Error(),
 						"subscription": replyToSub.ID(),
@@ -524,7 +622,7 @@ func (c Client) Request(recipientTopic *pubsub.Topic, payload string, timeout ti
 				}
 
 				replyToTopic.Stop()
-				if err := replyToTopic.Delete(c.context); err != nil {
+				if err := replyToTopic.Delete(cleanupCtx); err != nil {
 					logging.WithFields(logrus.Fields{
 						"error": err.Error(),
 						"topic": replyToTopic.ID(),
@@ -536,11 +634,11 @@ func (c Client) Request(recipientTopic *pubsub.Topic, payload string, timeout ti
 				out <- result
 
 				return
-			case <-time.After(timeout):
+			case <-requestCtx.Done():
 				close(receiver)
 
 				cancel()
-				if err := replyToSub.Delete(c.context); err != nil {
+				if err := replyToSub.Delete(cleanupCtx); err != nil {
 					logging.WithFields(logrus.Fields{
 						"error":        err.Error(),
 						"subscription": replyToSub.ID(),
@@ -555,7 +653,7 @@ func (c Client) Request(recipientTopic *pubsub.Topic, payload string, timeout ti
 				}
 
 				replyToTopic.Stop()
-				if err := replyToTopic.Delete(c.context); err != nil {
+				if err := replyToTopic.Delete(cleanupCtx); err != nil {
 					logging.WithFields(logrus.Fields{
 						"error": err.Error(),
 						"topic": replyToTopic.ID(),
@@ -570,7 +668,7 @@ func (c Client) Request(recipientTopic *pubsub.Topic, payload string, timeout ti
 				}
 
 				out <- requestJob{
-					Err:     errors.New("timed out"),
+					Err:     fmt.Errorf("timed out: %s", requestCtx.Err().Error()),
 					Payload: Message{},
 				}
 
@@ -619,6 +717,8 @@ func (c Client) Request(recipientTopic *pubsub.Topic, payload string, timeout ti
 	msg := NewMessage()
 	msg.Data = payload
 	msg.ReplyTo = replyToTopic.ID()
+	msg.Traceparent = tracing.Traceparent(ctx)
+	msg = msg.withDeadline(ctx, timeout)
 	jsonEncodedMessage, err := json.Marshal(msg)
 	if err != nil {
 		close(out)
@@ -626,7 +726,7 @@ func (c Client) Request(recipientTopic *pubsub.Topic, payload string, timeout ti
 		return Message{}, err
 	}
 
-	if _, err := recipientTopic.Publish(c.context, &pubsub.Message{Data: jsonEncodedMessage}).Get(c.context); err != nil {
+	if _, err := recipientTopic.Publish(ctx, &pubsub.Message{Data: jsonEncodedMessage}).Get(ctx); err != nil {
 		close(out)
 
 		return Message{}, err
@@ -638,14 +738,16 @@ func (c Client) Request(recipientTopic *pubsub.Topic, payload string, timeout ti
 	close(out)
 
 	if requestResult.Err != nil {
+		span.SetError(requestResult.Err)
+
 		return Message{}, requestResult.Err
 	}
 
 	return requestResult.Payload, nil
 }
 
-func (c Client) PublishMetrics(m metric.Metrics) error {
-	topic, err := c.FirmTopic(string(subjects.AppMetrics))
+func (c Client) PublishMetrics(ctx context.Context, m metric.Metrics) error {
+	topic, err := c.FirmTopic(ctx, string(subjects.AppMetrics))
 	if err != nil {
 		return err
 	}
@@ -657,7 +759,7 @@ func (c Client) PublishMetrics(m metric.Metrics) error {
 
 	msg := NewMessage()
 	msg.Data = string(jsonEncoded)
-	if _, err := c.Publish(topic, msg); err != nil {
+	if _, err := c.Publish(ctx, topic, msg); err != nil {
 		return err
 	}
 
@@ -1,6 +1,7 @@
 package bus
 
 import (
+	"context"
 	"encoding/json"
 
 	"cloud.google.com/go/pubsub"
@@ -15,7 +16,7 @@ type LoadRegionRealmsOutJob struct {
 	Realm sotah.Realm
 }
 
-func (c Client) LoadRegionRealms(recipientTopic *pubsub.Topic, regionRealms map[blizzard.RegionName]sotah.Realms) chan LoadRegionRealmsOutJob {
+func (c Client) LoadRegionRealms(ctx context.Context, recipientTopic *pubsub.Topic, regionRealms map[blizzard.RegionName]sotah.Realms) chan LoadRegionRealmsOutJob {
 	// establishing channels for intake
 	in := make(chan sotah.Realm)
 	out := make(chan LoadRegionRealmsOutJob)
@@ -39,7 +40,7 @@ func (c Client) LoadRegionRealms(recipientTopic *pubsub.Topic, regionRealms map[
 
 			msg := NewMessage()
 			msg.Data = string(jsonEncoded)
-			if _, err := c.Publish(recipientTopic, msg); err != nil {
+			if _, err := c.Publish(ctx, recipientTopic, msg); err != nil {
 				out <- LoadRegionRealmsOutJob{
 					Err:   err,
 					Realm: realm,
@@ -1,6 +1,7 @@
 package bus
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"time"
@@ -16,14 +17,14 @@ type StatusRequest struct {
 	RegionName blizzard.RegionName `json:"region_name"`
 }
 
-func (c Client) NewStatus(reg sotah.Region) (sotah.Status, error) {
+func (c Client) NewStatus(ctx context.Context, reg sotah.Region) (sotah.Status, error) {
 	lm := StatusRequest{RegionName: reg.Name}
 	encodedMessage, err := json.Marshal(lm)
 	if err != nil {
 		return sotah.Status{}, err
 	}
 
-	msg, err := c.RequestFromTopic(string(subjects.Status), string(encodedMessage), 5*time.Second)
+	msg, err := c.RequestFromTopic(ctx, string(subjects.Status), string(encodedMessage), 5*time.Second)
 	if err != nil {
 		return sotah.Status{}, err
 	}
@@ -46,7 +47,7 @@ type LoadStatusesJob struct {
 	Status sotah.Status
 }
 
-func (c Client) LoadStatuses(regions sotah.RegionList) chan LoadStatusesJob {
+func (c Client) LoadStatuses(ctx context.Context, regions sotah.RegionList) chan LoadStatusesJob {
 	// establishing channels
 	in := make(chan sotah.Region)
 	out := make(chan LoadStatusesJob)
@@ -54,7 +55,7 @@ func (c Client) LoadStatuses(regions sotah.RegionList) chan LoadStatusesJob {
 	// spinning up the workers
 	worker := func() {
 		for region := range in {
-			status, err := c.NewStatus(region)
+			status, err := c.NewStatus(ctx, region)
 			if err != nil {
 				out <- LoadStatusesJob{
 					Err:    err,
@@ -1,25 +1,35 @@
 package command
 
 import (
-	"os"
-	"os/signal"
+	"context"
+	"time"
 
+	"github.com/sotah-inc/server/app/pkg/health"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/state"
 )
 
-func Api(config state.APIStateConfig) error {
+func Api(ctx context.Context, config state.APIStateConfig, healthConfig health.Config) error {
 	logging.Info("Starting api")
 
+	// serving health while starting up
+	hs, err := startHealthServer(healthConfig)
+	if err != nil {
+		return err
+	}
+
 	// establishing a state
-	apiState, err := state.NewAPIState(config)
+	apiState, err := state.NewAPIState(ctx, config)
 	if err != nil {
 		logging.WithField("error", err.Error()).Error("Failed to establish api-state")
 
 		return err
 	}
 
+	addStateHealthChecks(hs, apiState.State)
+	hs.AddReadinessCheck("statuses", apiState.CheckStatuses)
+
 	// opening all listeners
 	if err := apiState.Listeners.Listen(); err != nil {
 		return err
@@ -35,28 +45,46 @@ func Api(config state.APIStateConfig) error {
 	collectorStop := make(sotah.WorkerStopChan)
 	onCollectorStop := make(sotah.WorkerStopChan)
 	if !config.SotahConfig.UseGCloud {
-		onCollectorStop = apiState.StartCollector(collectorStop)
-	}
-
-	// catching SIGINT
-	logging.Info("Waiting for SIGINT")
-	sigIn := make(chan os.Signal, 1)
-	signal.Notify(sigIn, os.Interrupt)
-	<-sigIn
+		heartbeat := addHeartbeat(hs, "collector", state.CollectorInterval)
+		hs.AddLivenessCheck("intake", health.NewFreshnessCheck(healthConfig.IntakeMaxAge, func() time.Time {
+			return apiState.RealmModificationDates().Latest()
+		}))
+		hs.AddStatus("realms", func() interface{} {
+			return state.NewRegionRealmFreshness(apiState.RealmModificationDates())
+		})
 
-	logging.Info("Caught SIGINT, exiting")
+		onCollectorStop = apiState.StartCollector(collectorStop, heartbeat)
+	}
 
-	// stopping listeners
-	apiState.Listeners.Stop()
+	// optionally rotating session secrets
+	rotationStop := make(sotah.WorkerStopChan)
+	onRotationStop := make(sotah.WorkerStopChan)
+	if config.SessionSecretRotation > 0 {
+		onRotationStop = apiState.SessionSecrets.StartRotating(rotationStop)
+	}
+	hs.Started()
 
-	if !config.SotahConfig.UseGCloud {
-		logging.Info("Stopping collector")
-		collectorStop <- struct{}{}
+	// waiting for a signal to shut down
+	sigIn := waitForShutdownSignal()
 
-		logging.Info("Waiting for collector to stop")
-		<-onCollectorStop
+	steps := []shutdownStep{
+		readinessShutdownStep(hs),
+		listenersShutdownStep(apiState.Listeners, apiState.IO.Messenger),
+	}
+	if config.SotahConfig.UseGCloud {
+		steps = append(steps, busListenersShutdownStep(apiState.BusListeners))
+	} else {
+		steps = append(steps, workerShutdownStep("collector", collectorStop, onCollectorStop))
+	}
+	if config.SessionSecretRotation > 0 {
+		steps = append(steps, workerShutdownStep("session-secret rotation", rotationStop, onRotationStop))
 	}
+	steps = append(
+		steps,
+		databasesShutdownStep(apiState.IO.Databases),
+		messengerShutdownStep(apiState.IO.Messenger),
+		healthShutdownStep(hs),
+	)
 
-	logging.Info("Exiting")
-	return nil
+	return shutdown(sigIn, steps)
 }
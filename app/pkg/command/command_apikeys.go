@@ -0,0 +1,77 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sotah-inc/server/app/pkg/apikeys"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/messenger"
+)
+
+/*
+APIKeysConfig - where the api keeping the key store is reached, where requests must carry a key of the service role as
+given by the messenger api key
+*/
+type APIKeysConfig struct {
+	MessengerHost    string
+	MessengerPort    int
+	MessengerConnect messenger.ConnectConfig
+}
+
+func withAPIKeysClient(config APIKeysConfig, call func(client apikeys.Client) (interface{}, error)) error {
+	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort, config.MessengerConnect)
+	if err != nil {
+		return err
+	}
+	defer mess.Close()
+
+	out, err := call(apikeys.NewClient(mess))
+	if err != nil {
+		return err
+	}
+
+	// printing the outcome, since an issued api key is not sent again
+	encoded, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(encoded))
+
+	return nil
+}
+
+// IssueAPIKey issues a key by the api, printing the key along with the api key that is handed to its holder
+func IssueAPIKey(ctx context.Context, config APIKeysConfig, req apikeys.IssueRequest) error {
+	logging.WithField("name", req.Name).Info("Starting api-keys-issue")
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	return withAPIKeysClient(config, func(client apikeys.Client) (interface{}, error) {
+		return client.Issue(ctx, req)
+	})
+}
+
+func RevokeAPIKey(ctx context.Context, config APIKeysConfig, req apikeys.RevokeRequest) error {
+	logging.WithField("id", req.ID).Info("Starting api-keys-revoke")
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	return withAPIKeysClient(config, func(client apikeys.Client) (interface{}, error) {
+		return client.Revoke(ctx, req)
+	})
+}
+
+// ListAPIKeys prints every issued key along with its usage today by subject
+func ListAPIKeys(ctx context.Context, config APIKeysConfig) error {
+	logging.Info("Starting api-keys-list")
+
+	return withAPIKeysClient(config, func(client apikeys.Client) (interface{}, error) {
+		return client.List(ctx)
+	})
+}
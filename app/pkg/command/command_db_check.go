@@ -0,0 +1,284 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/sotah/gameversions"
+	"github.com/sotah-inc/server/app/pkg/store"
+	"github.com/sotah-inc/server/app/pkg/store/regions"
+)
+
+type DbCheckConfig struct {
+	ProjectId   string
+	DatabaseDir string
+	Quarantine  bool
+	Resync      bool
+}
+
+// resyncPricelistHistories re-downloads the shards from the store, recording the versions that were written
+func resyncPricelistHistories(
+	ctx context.Context,
+	config DbCheckConfig,
+	metaDatabase database.MetaDatabase,
+	resyncAll bool,
+	brokenShards []database.BrokenDatabase,
+) (database.RegionTargetTimestamps, int, error) {
+	storeClient, err := store.NewClient(config.ProjectId)
+	if err != nil {
+		return database.RegionTargetTimestamps{}, 0, err
+	}
+
+	pricelistHistoriesBase := store.NewPricelistHistoriesBaseV2(storeClient, regions.USCentral1, gameversions.Retail)
+	pricelistHistoriesBucket, err := pricelistHistoriesBase.GetFirmBucket(ctx)
+	if err != nil {
+		return database.RegionTargetTimestamps{}, 0, err
+	}
+
+	getInJobs := []store.GetAllPricelistHistoriesInJob{}
+	if resyncAll {
+		// without a meta database every shard of the local realms is re-downloaded, as the boot sync would
+		regionRealms, err := database.PricelistHistoryRealms(config.DatabaseDir)
+		if err != nil {
+			return database.RegionTargetTimestamps{}, 0, err
+		}
+
+		versions, err := pricelistHistoriesBase.GetVersions(ctx, regionRealms, pricelistHistoriesBucket)
+		if err != nil {
+			return database.RegionTargetTimestamps{}, 0, err
+		}
+
+		getInJobs = store.NewGetAllPricelistHistoriesInJobs(versions)
+	} else {
+		for _, broken := range brokenShards {
+			getInJobs = append(getInJobs, store.GetAllPricelistHistoriesInJob{
+				RegionName:      broken.RegionName,
+				RealmSlug:       broken.RealmSlug,
+				TargetTimestamp: broken.TargetTimestamp,
+			})
+		}
+	}
+
+	in := make(chan store.GetAllPricelistHistoriesInJob)
+	out := pricelistHistoriesBase.GetAll(ctx, in, pricelistHistoriesBucket)
+	go func() {
+		for _, job := range getInJobs {
+			in <- job
+		}
+
+		close(in)
+	}()
+
+	totalFailed := 0
+	versionsToSet := sotah.PricelistHistoryVersions{}
+	regionTimestamps := database.RegionTargetTimestamps{}
+	for job := range out {
+		if job.Err != nil {
+			logging.WithFields(job.ToLogrusFields()).Error("Failed to get pricelist-histories")
+			totalFailed++
+
+			continue
+		}
+
+		err := database.ResyncPricelistHistoryDatabase(
+			config.DatabaseDir,
+			job.RegionName,
+			job.RealmSlug,
+			job.TargetTimestamp,
+			job.Data,
+		)
+		if err != nil {
+			logging.WithFields(logrus.Fields{
+				"error":            err.Error(),
+				"region":           job.RegionName,
+				"realm":            job.RealmSlug,
+				"target-timestamp": job.TargetTimestamp,
+			}).Error("Failed to resync pricelist-history")
+			totalFailed++
+
+			continue
+		}
+
+		versionsToSet = versionsToSet.Insert(job.RegionName, job.RealmSlug, job.TargetTimestamp, job.VersionId)
+		regionTimestamps = regionTimestamps.Insert(job.RegionName, job.TargetTimestamp)
+	}
+
+	if err := metaDatabase.SetPricelistHistoriesVersions(versionsToSet); err != nil {
+		return database.RegionTargetTimestamps{}, 0, err
+	}
+
+	totalResynced := len(getInJobs) - totalFailed
+	if totalFailed > 0 {
+		return regionTimestamps, totalResynced, fmt.Errorf("failed to resync %d pricelist-histories", totalFailed)
+	}
+
+	return regionTimestamps, totalResynced, nil
+}
+
+// rebuildRegionPricelistHistories recomputes the region aggregates of the days from their realm shards
+func rebuildRegionPricelistHistories(databaseDir string, regionTimestamps database.RegionTargetTimestamps) error {
+	totalFailed := 0
+	for regionName, timestamps := range regionTimestamps {
+		for targetTimestamp := range timestamps {
+			err := database.RebuildRegionPricelistHistoryDatabase(databaseDir, regionName, targetTimestamp)
+			if err != nil {
+				logging.WithFields(logrus.Fields{
+					"error":            err.Error(),
+					"region":           regionName,
+					"target-timestamp": targetTimestamp,
+				}).Error("Failed to rebuild region pricelist-history")
+				totalFailed++
+			}
+		}
+	}
+
+	if totalFailed > 0 {
+		return fmt.Errorf("failed to rebuild %d region pricelist-histories", totalFailed)
+	}
+
+	return nil
+}
+
+func DbCheck(ctx context.Context, config DbCheckConfig) error {
+	logging.WithField("dir", config.DatabaseDir).Info("Starting db-check")
+	startTime := time.Now()
+
+	result, err := database.CheckDatabases(config.DatabaseDir)
+	if err != nil {
+		return err
+	}
+
+	for _, broken := range result.Broken {
+		logging.WithFields(broken.ToLogrusFields()).Error("Found broken database")
+	}
+
+	logging.WithFields(logrus.Fields{
+		"checked":  result.Checked,
+		"broken":   len(result.Broken),
+		"duration": time.Since(startTime).String(),
+	}).Info("Checked databases")
+
+	if len(result.Broken) == 0 {
+		return nil
+	}
+
+	if !config.Quarantine && !config.Resync {
+		return fmt.Errorf(
+			"found %d broken databases, re-run with --quarantine or --resync to repair",
+			len(result.Broken),
+		)
+	}
+
+	// a quarantined meta database holds no versions, which means every pricelist-history is to be resynced
+	brokenMeta := false
+	for _, broken := range result.Broken {
+		if broken.Kind != schemakinds.Meta {
+			continue
+		}
+
+		if _, err := database.QuarantineDatabase(config.DatabaseDir, broken); err != nil {
+			return err
+		}
+
+		brokenMeta = true
+	}
+
+	if _, err := database.MigrateSchemas(config.DatabaseDir, schemakinds.Meta); err != nil {
+		return err
+	}
+
+	metaDatabase, err := database.NewMetaDatabase(config.DatabaseDir)
+	if err != nil {
+		return err
+	}
+	defer metaDatabase.Close()
+
+	// moving every other broken file out of the way, gathering the shards that may be re-synced
+	brokenShards := []database.BrokenDatabase{}
+	regionTimestamps := database.RegionTargetTimestamps{}
+	for _, broken := range result.Broken {
+		if broken.Kind == schemakinds.Meta {
+			continue
+		}
+
+		if !broken.IsPricelistHistoryShard() {
+			if _, err := database.QuarantineDatabase(config.DatabaseDir, broken); err != nil {
+				return err
+			}
+
+			if broken.IsRegionPricelistHistoryShard() {
+				regionTimestamps = regionTimestamps.Insert(broken.RegionName, broken.TargetTimestamp)
+			}
+
+			continue
+		}
+
+		hasVersion, err := metaDatabase.HasPricelistHistoriesVersion(
+			broken.RegionName,
+			broken.RealmSlug,
+			broken.TargetTimestamp,
+		)
+		if err != nil {
+			return err
+		}
+
+		if _, err := database.QuarantineDatabase(config.DatabaseDir, broken); err != nil {
+			return err
+		}
+
+		// dropping the version so that the next sync re-downloads the shard should it not be resynced here
+		err = metaDatabase.DeletePricelistHistoriesVersion(broken.RegionName, broken.RealmSlug, broken.TargetTimestamp)
+		if err != nil {
+			return err
+		}
+
+		regionTimestamps = regionTimestamps.Insert(broken.RegionName, broken.TargetTimestamp)
+
+		if !hasVersion {
+			logging.WithFields(broken.ToLogrusFields()).Warn("Skipping resync of shard without a stored version")
+
+			continue
+		}
+
+		brokenShards = append(brokenShards, broken)
+	}
+
+	// recomputing the region aggregates of whatever was resynced, even when some of the resync failed
+	totalResynced := 0
+	var resyncErr error
+	if config.Resync && (brokenMeta || len(brokenShards) > 0) {
+		var resyncedTimestamps database.RegionTargetTimestamps
+		resyncedTimestamps, totalResynced, resyncErr = resyncPricelistHistories(
+			ctx,
+			config,
+			metaDatabase,
+			brokenMeta,
+			brokenShards,
+		)
+		for regionName, timestamps := range resyncedTimestamps {
+			for targetTimestamp := range timestamps {
+				regionTimestamps = regionTimestamps.Insert(regionName, targetTimestamp)
+			}
+		}
+	} else if brokenMeta {
+		logging.Info("Quarantined meta database, the next sync re-downloads every pricelist-history")
+	}
+
+	if err := rebuildRegionPricelistHistories(config.DatabaseDir, regionTimestamps); err != nil {
+		return err
+	}
+
+	if resyncErr != nil {
+		return resyncErr
+	}
+
+	logging.WithField("resynced", totalResynced).Info("Finished db-check")
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package command
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/archive"
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/sotah/gameversions"
+	"github.com/sotah-inc/server/app/pkg/store"
+	"github.com/sotah-inc/server/app/pkg/store/regions"
+)
+
+type ExportAuctionDumpsConfig struct {
+	ProjectId   string
+	OutFilepath string
+
+	RegionName  blizzard.RegionName
+	RealmSlugs  []blizzard.RealmSlug
+	LowerBounds int64
+	UpperBounds int64
+}
+
+func matchesRealmSelection(regionName blizzard.RegionName, realmSlugs []blizzard.RealmSlug, realm sotah.Realm) bool {
+	if regionName != "" && regionName != realm.Region.Name {
+		return false
+	}
+
+	if len(realmSlugs) == 0 {
+		return true
+	}
+
+	for _, realmSlug := range realmSlugs {
+		if realmSlug == realm.Slug {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c ExportAuctionDumpsConfig) matchesRealm(realm sotah.Realm) bool {
+	return matchesRealmSelection(c.RegionName, c.RealmSlugs, realm)
+}
+
+func (c ExportAuctionDumpsConfig) bounds() (time.Time, time.Time) {
+	upperBounds := time.Now()
+	if c.UpperBounds > 0 {
+		upperBounds = time.Unix(c.UpperBounds, 0)
+	}
+
+	return time.Unix(c.LowerBounds, 0), upperBounds
+}
+
+func ExportAuctionDumps(ctx context.Context, config ExportAuctionDumpsConfig) error {
+	logging.WithField("out", config.OutFilepath).Info("Starting export-auction-dumps")
+
+	storeClient, err := store.NewClient(config.ProjectId)
+	if err != nil {
+		return err
+	}
+
+	bootBase := store.NewBootBase(storeClient, regions.USCentral1)
+	bootBucket, err := bootBase.GetFirmBucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	realmsBase := store.NewRealmsBase(storeClient, regions.USCentral1, gameversions.Retail)
+	realmsBucket, err := realmsBase.GetFirmBucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	manifestBase := store.NewAuctionManifestBaseV2(storeClient, regions.USCentral1, gameversions.Retail)
+	manifestBucket, err := manifestBase.GetFirmBucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	auctionsBase := store.NewAuctionsBaseV2(storeClient, regions.USCentral1, gameversions.Retail)
+	auctionsBucket, err := auctionsBase.GetFirmBucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	regionList, err := bootBase.GetRegions(ctx, bootBucket)
+	if err != nil {
+		return err
+	}
+
+	// gathering the dumps of each selected realm
+	lowerBounds, upperBounds := config.bounds()
+	entries := []archive.Entry{}
+	for _, region := range regionList {
+		realms, err := realmsBase.GetAllRealms(ctx, region.Name, realmsBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, realm := range realms {
+			if !config.matchesRealm(realm) {
+				continue
+			}
+
+			lastModifieds, err := manifestBase.GetLastModifiedBetween(ctx, realm, lowerBounds, upperBounds, manifestBucket)
+			if err != nil {
+				return err
+			}
+
+			logging.WithFields(logrus.Fields{
+				"region": realm.Region.Name,
+				"realm":  realm.Slug,
+				"dumps":  len(lastModifieds),
+			}).Info("Found dumps to export")
+
+			for _, lastModified := range lastModifieds {
+				entries = append(entries, archive.NewEntry(realm, lastModified))
+			}
+		}
+	}
+
+	outFile, err := os.Create(config.OutFilepath)
+	if err != nil {
+		return err
+	}
+
+	manifest := archive.NewManifest(entries)
+	err = writeAuctionDumps(ctx, auctionsBase, auctionsBucket, manifest, outFile)
+	if closeErr := outFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	logging.WithField("dumps", len(manifest.Entries)).Info("Finished export-auction-dumps")
+
+	return nil
+}
+
+// writeAuctionDumps fetches each dump of the manifest into the archive, leaving closing the file to the caller
+func writeAuctionDumps(
+	ctx context.Context,
+	auctionsBase store.AuctionsBaseV2,
+	auctionsBucket *storage.BucketHandle,
+	manifest archive.Manifest,
+	out io.Writer,
+) error {
+	w, err := archive.NewWriter(out, manifest)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range manifest.Entries {
+		data, err := auctionsBase.GetCompressedDump(ctx, entry.Realm, entry.LastModifiedTime(), auctionsBucket)
+		if err != nil {
+			logging.WithFields(logrus.Fields{
+				"error":         err.Error(),
+				"region":        entry.Realm.Region.Name,
+				"realm":         entry.Realm.Slug,
+				"last-modified": entry.LastModified,
+			}).Error("Failed to fetch dump")
+
+			return err
+		}
+
+		if err := w.WriteDump(entry, data); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
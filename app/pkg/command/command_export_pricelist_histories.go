@@ -0,0 +1,81 @@
+package command
+
+import (
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/export"
+	"github.com/sotah-inc/server/app/pkg/export/formats"
+	"github.com/sotah-inc/server/app/pkg/logging"
+)
+
+type ExportPricelistHistoriesConfig struct {
+	PricelistHistoriesDatabaseDir string
+	OutFilepath                   string
+	Format                        formats.Format
+
+	RegionName  blizzard.RegionName
+	RealmSlug   blizzard.RealmSlug
+	ItemIds     []blizzard.ItemID
+	LowerBounds int64
+	UpperBounds int64
+}
+
+func ExportPricelistHistories(config ExportPricelistHistoriesConfig) error {
+	logging.WithFields(logrus.Fields{
+		"format": config.Format,
+		"out":    config.OutFilepath,
+	}).Info("Starting export-pricelist-histories")
+
+	filter, err := export.NewFilter(
+		config.RegionName,
+		config.RealmSlug,
+		config.ItemIds,
+		config.LowerBounds,
+		config.UpperBounds,
+	)
+	if err != nil {
+		return err
+	}
+
+	outFile, err := os.Create(config.OutFilepath)
+	if err != nil {
+		return err
+	}
+
+	totalRows, err := exportPricelistHistories(config, filter, outFile)
+	if closeErr := outFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	logging.WithField("rows", totalRows).Info("Finished export-pricelist-histories")
+
+	return nil
+}
+
+// exportPricelistHistories writes the matching rows in the configured format, leaving closing the file to the caller
+func exportPricelistHistories(config ExportPricelistHistoriesConfig, filter export.Filter, out io.Writer) (int, error) {
+	w, err := export.NewRowWriter(config.Format, out)
+	if err != nil {
+		return 0, err
+	}
+
+	totalRows, err := database.ExportPricelistHistories(config.PricelistHistoriesDatabaseDir, filter, w)
+	if err != nil {
+		logging.WithField("error", err.Error()).Error("Failed to export pricelist-histories")
+
+		return 0, err
+	}
+
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+
+	return totalRows, nil
+}
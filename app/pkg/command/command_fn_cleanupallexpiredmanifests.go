@@ -1,18 +1,17 @@
 package command
 
 import (
-	"os"
-	"os/signal"
+	"context"
 
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/state/fn"
 )
 
-func FnCleanupAllExpiredManifests(config fn.CleanupAllExpiredManifestsStateConfig) error {
+func FnCleanupAllExpiredManifests(ctx context.Context, config fn.CleanupAllExpiredManifestsStateConfig) error {
 	logging.Info("Starting fn-cleanup-all-expired-manifests")
 
 	// establishing a state
-	apiState, err := fn.NewCleanupAllExpiredManifestsState(config)
+	apiState, err := fn.NewCleanupAllExpiredManifestsState(ctx, config)
 	if err != nil {
 		logging.WithField("error", err.Error()).Error("Failed to establish fn-cleanup-all-expired-manifests")
 
@@ -28,18 +27,13 @@ func FnCleanupAllExpiredManifests(config fn.CleanupAllExpiredManifestsStateConfi
 	logging.Info("Opening all bus-listeners")
 	apiState.BusListeners.Listen()
 
-	// catching SIGINT
-	logging.Info("Waiting for SIGINT")
-	sigIn := make(chan os.Signal, 1)
-	signal.Notify(sigIn, os.Interrupt)
-	<-sigIn
+	// waiting for a signal to shut down
+	sigIn := waitForShutdownSignal()
 
-	logging.Info("Caught SIGINT, exiting")
-
-	// stopping listeners
-	apiState.Listeners.Stop()
-	apiState.BusListeners.Stop()
-
-	logging.Info("Exiting")
-	return nil
+	return shutdown(sigIn, []shutdownStep{
+		listenersShutdownStep(apiState.Listeners, apiState.IO.Messenger),
+		busListenersShutdownStep(apiState.BusListeners),
+		databasesShutdownStep(apiState.IO.Databases),
+		messengerShutdownStep(apiState.IO.Messenger),
+	})
 }
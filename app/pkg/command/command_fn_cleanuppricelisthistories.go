@@ -1,18 +1,17 @@
 package command
 
 import (
-	"os"
-	"os/signal"
+	"context"
 
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/state/fn"
 )
 
-func FnCleanupPricelistHistories(config fn.CleanupPricelistHistoriesStateConfig) error {
+func FnCleanupPricelistHistories(ctx context.Context, config fn.CleanupPricelistHistoriesStateConfig) error {
 	logging.Info("Starting fn-cleanup-pricelist-histories")
 
 	// establishing a state
-	apiState, err := fn.NewCleanupPricelistHistoriesState(config)
+	apiState, err := fn.NewCleanupPricelistHistoriesState(ctx, config)
 	if err != nil {
 		logging.WithField("error", err.Error()).Error("Failed to establish fn-cleanup-pricelist-histories")
 
@@ -28,18 +27,13 @@ func FnCleanupPricelistHistories(config fn.CleanupPricelistHistoriesStateConfig)
 	logging.Info("Opening all bus-listeners")
 	apiState.BusListeners.Listen()
 
-	// catching SIGINT
-	logging.Info("Waiting for SIGINT")
-	sigIn := make(chan os.Signal, 1)
-	signal.Notify(sigIn, os.Interrupt)
-	<-sigIn
+	// waiting for a signal to shut down
+	sigIn := waitForShutdownSignal()
 
-	logging.Info("Caught SIGINT, exiting")
-
-	// stopping listeners
-	apiState.Listeners.Stop()
-	apiState.BusListeners.Stop()
-
-	logging.Info("Exiting")
-	return nil
+	return shutdown(sigIn, []shutdownStep{
+		listenersShutdownStep(apiState.Listeners, apiState.IO.Messenger),
+		busListenersShutdownStep(apiState.BusListeners),
+		databasesShutdownStep(apiState.IO.Databases),
+		messengerShutdownStep(apiState.IO.Messenger),
+	})
 }
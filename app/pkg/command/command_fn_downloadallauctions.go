@@ -1,18 +1,17 @@
 package command
 
 import (
-	"os"
-	"os/signal"
+	"context"
 
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/state/fn"
 )
 
-func FnDownloadAllAuctions(config fn.DownloadAllAuctionsStateConfig) error {
+func FnDownloadAllAuctions(ctx context.Context, config fn.DownloadAllAuctionsStateConfig) error {
 	logging.Info("Starting fn-download-all-auctions")
 
 	// establishing a state
-	apiState, err := fn.NewDownloadAllAuctionsState(config)
+	apiState, err := fn.NewDownloadAllAuctionsState(ctx, config)
 	if err != nil {
 		logging.WithField("error", err.Error()).Error("Failed to establish fn-download-all-auctions")
 
@@ -28,18 +27,13 @@ func FnDownloadAllAuctions(config fn.DownloadAllAuctionsStateConfig) error {
 	logging.Info("Opening all bus-listeners")
 	apiState.BusListeners.Listen()
 
-	// catching SIGINT
-	logging.Info("Waiting for SIGINT")
-	sigIn := make(chan os.Signal, 1)
-	signal.Notify(sigIn, os.Interrupt)
-	<-sigIn
+	// waiting for a signal to shut down
+	sigIn := waitForShutdownSignal()
 
-	logging.Info("Caught SIGINT, exiting")
-
-	// stopping listeners
-	apiState.Listeners.Stop()
-	apiState.BusListeners.Stop()
-
-	logging.Info("Exiting")
-	return nil
+	return shutdown(sigIn, []shutdownStep{
+		listenersShutdownStep(apiState.Listeners, apiState.IO.Messenger),
+		busListenersShutdownStep(apiState.BusListeners),
+		databasesShutdownStep(apiState.IO.Databases),
+		messengerShutdownStep(apiState.IO.Messenger),
+	})
 }
@@ -0,0 +1,76 @@
+package command
+
+import (
+	"context"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/health"
+	"github.com/sotah-inc/server/app/pkg/metric"
+	"github.com/sotah-inc/server/app/pkg/state"
+)
+
+// healthShutdownTimeout - how long in-flight health requests are given once everything else has shut down
+const healthShutdownTimeout = 5 * time.Second
+
+/*
+startHealthServer serves health and metrics from the very start of a command, where readiness fails until it calls
+Started
+*/
+func startHealthServer(config health.Config) (health.Server, error) {
+	hs := health.NewServer(config)
+	hs.Handle(metric.Path, metric.Handler())
+	if err := hs.Listen(); err != nil {
+		return health.Server{}, err
+	}
+
+	return hs, nil
+}
+
+// addStateHealthChecks adds the readiness and liveness checks and status that apply to every state
+func addStateHealthChecks(hs health.Server, sta state.State) {
+	hs.AddReadinessCheck("messenger", sta.IO.Messenger.Check)
+	hs.AddLivenessCheck("messenger", sta.IO.Messenger.CheckAlive)
+	hs.AddReadinessCheck("databases", sta.IO.Databases.Ping)
+	if len(sta.BusListeners) > 0 {
+		hs.AddReadinessCheck("bus-listeners", sta.BusListeners.Check)
+	}
+
+	hs.AddStatus("run-id", func() interface{} {
+		return sta.RunID.String()
+	})
+}
+
+// addHeartbeat establishes the heartbeat of a ticker-driven worker, failing liveness once the worker stops beating
+func addHeartbeat(hs health.Server, name string, interval time.Duration) health.Heartbeat {
+	heartbeat := health.NewHeartbeat(interval)
+	hs.AddLivenessCheck(name, heartbeat.Check)
+	hs.AddStatus(name, func() interface{} {
+		return map[string]int64{"last_beat": heartbeat.Last().Unix()}
+	})
+
+	return heartbeat
+}
+
+// readinessShutdownStep fails readiness before anything else shuts down, so that no new work is routed here
+func readinessShutdownStep(hs health.Server) shutdownStep {
+	return shutdownStep{
+		name: "readiness",
+		call: func() error {
+			hs.Drain()
+
+			return nil
+		},
+	}
+}
+
+func healthShutdownStep(hs health.Server) shutdownStep {
+	return shutdownStep{
+		name: "health",
+		call: func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), healthShutdownTimeout)
+			defer cancel()
+
+			return hs.Shutdown(ctx)
+		},
+	}
+}
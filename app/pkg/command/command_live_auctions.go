@@ -1,38 +1,50 @@
 package command
 
 import (
-	"os"
-	"os/signal"
+	"context"
 
+	"github.com/sotah-inc/server/app/pkg/health"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/state"
 )
 
-func LiveAuctions(config state.LiveAuctionsStateConfig) error {
+func LiveAuctions(ctx context.Context, config state.LiveAuctionsStateConfig, healthConfig health.Config) error {
 	logging.Info("Starting live-auctions")
 
-	// establishing a state
-	laState, err := state.NewLiveAuctionsState(config)
+	// serving health while starting up
+	hs, err := startHealthServer(healthConfig)
 	if err != nil {
 		return err
 	}
 
-	// opening all listeners
-	if err := laState.Listeners.Listen(); err != nil {
+	// establishing a state
+	laState, err := state.NewLiveAuctionsState(ctx, config)
+	if err != nil {
 		return err
 	}
 
-	// catching SIGINT
-	logging.Info("Waiting for SIGINT")
-	sigIn := make(chan os.Signal, 1)
-	signal.Notify(sigIn, os.Interrupt)
-	<-sigIn
-
-	logging.Info("Caught SIGINT, exiting")
+	addStateHealthChecks(hs, laState.State)
+	hs.AddReadinessCheck("statuses", laState.CheckStatuses)
 
-	// stopping listeners
-	laState.Listeners.Stop()
+	// clearing the live-auctions of realms that stopped updating
+	if err := laState.IO.Databases.LiveAuctionsDatabases.Prune(config.Retention); err != nil {
+		return err
+	}
 
-	logging.Info("Exiting")
-	return nil
+	// opening all listeners
+	if err := laState.Listeners.Listen(); err != nil {
+		return err
+	}
+	hs.Started()
+
+	// waiting for a signal to shut down
+	sigIn := waitForShutdownSignal()
+
+	return shutdown(sigIn, []shutdownStep{
+		readinessShutdownStep(hs),
+		listenersShutdownStep(laState.Listeners, laState.IO.Messenger),
+		databasesShutdownStep(laState.IO.Databases),
+		messengerShutdownStep(laState.IO.Messenger),
+		healthShutdownStep(hs),
+	})
 }
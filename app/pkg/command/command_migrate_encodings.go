@@ -0,0 +1,23 @@
+package command
+
+import (
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/logging"
+)
+
+type MigrateEncodingsConfig struct {
+	DatabaseDir string
+}
+
+func MigrateEncodings(config MigrateEncodingsConfig) error {
+	logging.WithField("dir", config.DatabaseDir).Info("Starting migrate-encodings")
+
+	totalMigrated, err := database.MigrateEncodings(config.DatabaseDir)
+	if err != nil {
+		return err
+	}
+
+	logging.WithField("migrated", totalMigrated).Info("Finished migrate-encodings")
+
+	return nil
+}
@@ -1,81 +1,79 @@
 package command
 
 import (
-	"fmt"
-	"os"
-	"os/signal"
+	"context"
 
-	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/health"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/state"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
 )
 
-func PricelistHistories(config state.PricelistHistoriesStateConfig) error {
+func PricelistHistories(
+	ctx context.Context,
+	config state.PricelistHistoriesStateConfig,
+	healthConfig health.Config,
+) error {
 	logging.Info("Starting pricelist-histories")
 
-	// establishing a state
-	phState, err := state.NewPricelistHistoriesState(config)
+	// serving health while starting up
+	hs, err := startHealthServer(healthConfig)
 	if err != nil {
 		return err
 	}
 
-	// pruning old data
-	earliestTime := database.RetentionLimit()
-	for _, reg := range phState.Regions {
-		regionDatabaseDir := fmt.Sprintf("%s/%s", config.PricelistHistoriesDatabaseDir, reg.Name)
-
-		for _, rea := range phState.Statuses[reg.Name].Realms {
-			realmDatabaseDir := fmt.Sprintf("%s/%s", regionDatabaseDir, rea.Slug)
-			dbPaths, err := database.Paths(realmDatabaseDir)
-			if err != nil {
-				logging.WithFields(logrus.Fields{
-					"error": err.Error(),
-					"dir":   realmDatabaseDir,
-				}).Error("Failed to resolve database paths")
-
-				return err
-			}
-			for _, dbPathPair := range dbPaths {
-				if dbPathPair.TargetTime.After(earliestTime) {
-					continue
-				}
-
-				logging.WithFields(logrus.Fields{
-					"pathname": dbPathPair.FullPath,
-				}).Debug("Pruning old pricelist-history database file")
-
-				if err := os.Remove(dbPathPair.FullPath); err != nil {
-					logging.WithFields(logrus.Fields{
-						"error":    err.Error(),
-						"dir":      realmDatabaseDir,
-						"pathname": dbPathPair.FullPath,
-					}).Error("Failed to remove database file")
-
-					return err
-				}
-			}
-		}
+	// establishing a state
+	phState, err := state.NewPricelistHistoriesState(ctx, config)
+	if err != nil {
+		return err
 	}
+	hs.AddReadinessCheck("statuses", phState.CheckStatuses)
 
 	// loading the pricelist-histories databases
-	phDatabases, err := database.NewPricelistHistoryDatabases(config.PricelistHistoriesDatabaseDir, phState.Statuses)
+	_, err = database.MigrateSchemas(
+		config.PricelistHistoriesDatabaseDir,
+		schemakinds.PricelistHistories,
+		schemakinds.PricelistHistoryRollups,
+	)
+	if err != nil {
+		return err
+	}
+	phDatabases, err := database.NewPricelistHistoryDatabases(
+		config.PricelistHistoriesDatabaseDir,
+		phState.Statuses,
+		config.Retention,
+	)
 	if err != nil {
 		return err
 	}
 	phState.IO.Databases.PricelistHistoryDatabases = phDatabases
 
+	// pruning old data, rolling up whatever the rollup-builder did not get to before it is dropped
+	if err := phDatabases.Prune(); err != nil {
+		return err
+	}
+
 	// starting up a pruner
 	logging.Info("Starting up the pricelist-histories file pruner")
 	prunerStop := make(sotah.WorkerStopChan)
-	onPrunerStop := phDatabases.StartPruner(prunerStop)
+	onPrunerStop := phDatabases.StartPruner(prunerStop, addHeartbeat(hs, "pruner", database.PrunerInterval))
+
+	// starting up a rollup-builder
+	logging.Info("Starting up the pricelist-histories rollup-builder")
+	rollupBuilderStop := make(sotah.WorkerStopChan)
+	onRollupBuilderStop := phDatabases.StartRollupBuilder(
+		rollupBuilderStop,
+		addHeartbeat(hs, "rollup-builder", database.RollupBuilderInterval),
+	)
 
 	// establishing listeners
 	phState.Listeners = state.NewListeners(state.SubjectListeners{
 		subjects.PriceListHistory:         phState.ListenForPriceListHistory,
 		subjects.PricelistHistoriesIntake: phState.ListenForPricelistHistoriesIntake,
+		subjects.PriceListForecast:        phState.ListenForPriceListForecast,
 	})
 
 	// opening all listeners
@@ -83,25 +81,19 @@ func PricelistHistories(config state.PricelistHistoriesStateConfig) error {
 	if err := phState.Listeners.Listen(); err != nil {
 		return err
 	}
-
-	// catching SIGINT
-	logging.Info("Waiting for SIGINT")
-	sigIn := make(chan os.Signal, 1)
-	signal.Notify(sigIn, os.Interrupt)
-	<-sigIn
-
-	logging.Info("Caught SIGINT, exiting")
-
-	// stopping listeners
-	phState.Listeners.Stop()
-
-	// stopping pruner
-	logging.Info("Stopping pruner")
-	prunerStop <- struct{}{}
-
-	logging.Info("Waiting for pruner to stop")
-	<-onPrunerStop
-
-	logging.Info("Exiting")
-	return nil
+	addStateHealthChecks(hs, phState.State)
+	hs.Started()
+
+	// waiting for a signal to shut down
+	sigIn := waitForShutdownSignal()
+
+	return shutdown(sigIn, []shutdownStep{
+		readinessShutdownStep(hs),
+		listenersShutdownStep(phState.Listeners, phState.IO.Messenger),
+		workerShutdownStep("pruner", prunerStop, onPrunerStop),
+		workerShutdownStep("rollup-builder", rollupBuilderStop, onRollupBuilderStop),
+		databasesShutdownStep(phState.IO.Databases),
+		messengerShutdownStep(phState.IO.Messenger),
+		healthShutdownStep(hs),
+	})
 }
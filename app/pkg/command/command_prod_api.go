@@ -1,24 +1,41 @@
 package command
 
 import (
-	"os"
-	"os/signal"
+	"context"
+	"time"
 
+	"github.com/sotah-inc/server/app/pkg/health"
 	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/state"
 )
 
-func ProdApi(config state.ProdApiStateConfig) error {
+func ProdApi(ctx context.Context, config state.ProdApiStateConfig, healthConfig health.Config) error {
 	logging.Info("Starting prod-api")
 
+	// serving health while starting up
+	hs, err := startHealthServer(healthConfig)
+	if err != nil {
+		return err
+	}
+
 	// establishing a state
-	apiState, err := state.NewProdApiState(config)
+	apiState, err := state.NewProdApiState(ctx, config)
 	if err != nil {
 		logging.WithField("error", err.Error()).Error("Failed to establish api-state")
 
 		return err
 	}
 
+	addStateHealthChecks(hs, apiState.State)
+	hs.AddReadinessCheck("statuses", apiState.CheckStatuses)
+	hs.AddLivenessCheck("intake", health.NewFreshnessCheck(healthConfig.IntakeMaxAge, func() time.Time {
+		return apiState.RealmModificationDates().Latest()
+	}))
+	hs.AddStatus("realms", func() interface{} {
+		return state.NewRegionRealmFreshness(apiState.RealmModificationDates())
+	})
+
 	// opening all listeners
 	if err := apiState.Listeners.Listen(); err != nil {
 		return err
@@ -28,18 +45,31 @@ func ProdApi(config state.ProdApiStateConfig) error {
 	logging.Info("Opening all bus-listeners")
 	apiState.BusListeners.Listen()
 
-	// catching SIGINT
-	logging.Info("Waiting for SIGINT")
-	sigIn := make(chan os.Signal, 1)
-	signal.Notify(sigIn, os.Interrupt)
-	<-sigIn
+	// optionally rotating session secrets
+	rotationStop := make(sotah.WorkerStopChan)
+	onRotationStop := make(sotah.WorkerStopChan)
+	if config.SessionSecretRotation > 0 {
+		onRotationStop = apiState.SessionSecrets.StartRotating(rotationStop)
+	}
+	hs.Started()
 
-	logging.Info("Caught SIGINT, exiting")
+	// waiting for a signal to shut down
+	sigIn := waitForShutdownSignal()
 
-	// stopping listeners
-	apiState.Listeners.Stop()
-	apiState.BusListeners.Stop()
+	steps := []shutdownStep{
+		readinessShutdownStep(hs),
+		listenersShutdownStep(apiState.Listeners, apiState.IO.Messenger),
+		busListenersShutdownStep(apiState.BusListeners),
+	}
+	if config.SessionSecretRotation > 0 {
+		steps = append(steps, workerShutdownStep("session-secret rotation", rotationStop, onRotationStop))
+	}
+	steps = append(
+		steps,
+		databasesShutdownStep(apiState.IO.Databases),
+		messengerShutdownStep(apiState.IO.Messenger),
+		healthShutdownStep(hs),
+	)
 
-	logging.Info("Exiting")
-	return nil
+	return shutdown(sigIn, steps)
 }
@@ -1,23 +1,42 @@
 package command
 
 import (
-	"os"
-	"os/signal"
+	"context"
 
+	"github.com/sotah-inc/server/app/pkg/health"
 	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/state"
 )
 
-func ProdItems(config state.ProdItemsStateConfig) error {
+func ProdItems(ctx context.Context, config state.ProdItemsStateConfig, healthConfig health.Config) error {
 	logging.Info("Starting prod-items")
 
+	// serving health while starting up
+	hs, err := startHealthServer(healthConfig)
+	if err != nil {
+		return err
+	}
+
 	// establishing a state
-	itemsState, err := state.NewProdItemsState(config)
+	itemsState, err := state.NewProdItemsState(ctx, config)
 	if err != nil {
 		logging.WithField("error", err.Error()).Error("Failed to establish prod-items state")
 
 		return err
 	}
+	addStateHealthChecks(hs, itemsState.State)
+
+	// starting up a snapshotter
+	snapshotterStop := make(sotah.WorkerStopChan)
+	var onSnapshotterStop sotah.WorkerStopChan
+	if !config.Snapshots.Disabled {
+		logging.Info("Starting up the snapshotter")
+		onSnapshotterStop = itemsState.Snapshotter.Start(
+			snapshotterStop,
+			addHeartbeat(hs, "snapshotter", config.Snapshots.Interval()),
+		)
+	}
 
 	// opening all listeners
 	if err := itemsState.Listeners.Listen(); err != nil {
@@ -27,18 +46,25 @@ func ProdItems(config state.ProdItemsStateConfig) error {
 	// opening all bus-listeners
 	logging.Info("Opening all bus-listeners")
 	itemsState.BusListeners.Listen()
+	hs.Started()
 
-	// catching SIGINT
-	logging.Info("Waiting for SIGINT")
-	sigIn := make(chan os.Signal, 1)
-	signal.Notify(sigIn, os.Interrupt)
-	<-sigIn
-
-	logging.Info("Caught SIGINT, exiting")
+	// waiting for a signal to shut down
+	sigIn := waitForShutdownSignal()
 
-	// stopping listeners
-	itemsState.Listeners.Stop()
+	steps := []shutdownStep{
+		readinessShutdownStep(hs),
+		listenersShutdownStep(itemsState.Listeners, itemsState.IO.Messenger),
+		busListenersShutdownStep(itemsState.BusListeners),
+	}
+	if !config.Snapshots.Disabled {
+		steps = append(steps, workerShutdownStep("snapshotter", snapshotterStop, onSnapshotterStop))
+	}
+	steps = append(
+		steps,
+		databasesShutdownStep(itemsState.IO.Databases),
+		messengerShutdownStep(itemsState.IO.Messenger),
+		healthShutdownStep(hs),
+	)
 
-	logging.Info("Exiting")
-	return nil
+	return shutdown(sigIn, steps)
 }
@@ -1,23 +1,52 @@
 package command
 
 import (
-	"os"
-	"os/signal"
+	"context"
 
+	"github.com/sotah-inc/server/app/pkg/health"
 	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/state"
 )
 
-func ProdLiveAuctions(config state.ProdLiveAuctionsStateConfig) error {
+func ProdLiveAuctions(
+	ctx context.Context,
+	config state.ProdLiveAuctionsStateConfig,
+	healthConfig health.Config,
+) error {
 	logging.Info("Starting prod-liveauctions")
 
+	// serving health while starting up
+	hs, err := startHealthServer(healthConfig)
+	if err != nil {
+		return err
+	}
+
 	// establishing a state
-	liveAuctionsState, err := state.NewProdLiveAuctionsState(config)
+	liveAuctionsState, err := state.NewProdLiveAuctionsState(ctx, config)
 	if err != nil {
 		logging.WithField("error", err.Error()).Error("Failed to establish prod-liveauctions state")
 
 		return err
 	}
+	addStateHealthChecks(hs, liveAuctionsState.State)
+	hs.AddReadinessCheck("statuses", liveAuctionsState.CheckStatuses)
+
+	// clearing the live-auctions of realms that stopped updating
+	if err := liveAuctionsState.IO.Databases.LiveAuctionsDatabases.Prune(config.Retention); err != nil {
+		return err
+	}
+
+	// starting up a snapshotter
+	snapshotterStop := make(sotah.WorkerStopChan)
+	var onSnapshotterStop sotah.WorkerStopChan
+	if !config.Snapshots.Disabled {
+		logging.Info("Starting up the snapshotter")
+		onSnapshotterStop = liveAuctionsState.Snapshotter.Start(
+			snapshotterStop,
+			addHeartbeat(hs, "snapshotter", config.Snapshots.Interval()),
+		)
+	}
 
 	// opening all listeners
 	if err := liveAuctionsState.Listeners.Listen(); err != nil {
@@ -27,18 +56,25 @@ func ProdLiveAuctions(config state.ProdLiveAuctionsStateConfig) error {
 	// opening all bus-listeners
 	logging.Info("Opening all bus-listeners")
 	liveAuctionsState.BusListeners.Listen()
+	hs.Started()
 
-	// catching SIGINT
-	logging.Info("Waiting for SIGINT")
-	sigIn := make(chan os.Signal, 1)
-	signal.Notify(sigIn, os.Interrupt)
-	<-sigIn
+	// waiting for a signal to shut down
+	sigIn := waitForShutdownSignal()
 
-	logging.Info("Caught SIGINT, exiting")
-
-	// stopping listeners
-	liveAuctionsState.Listeners.Stop()
+	steps := []shutdownStep{
+		readinessShutdownStep(hs),
+		listenersShutdownStep(liveAuctionsState.Listeners, liveAuctionsState.IO.Messenger),
+		busListenersShutdownStep(liveAuctionsState.BusListeners),
+	}
+	if !config.Snapshots.Disabled {
+		steps = append(steps, workerShutdownStep("snapshotter", snapshotterStop, onSnapshotterStop))
+	}
+	steps = append(
+		steps,
+		databasesShutdownStep(liveAuctionsState.IO.Databases),
+		messengerShutdownStep(liveAuctionsState.IO.Messenger),
+		healthShutdownStep(hs),
+	)
 
-	logging.Info("Exiting")
-	return nil
+	return shutdown(sigIn, steps)
 }
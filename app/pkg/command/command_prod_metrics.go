@@ -1,16 +1,20 @@
 package command
 
 import (
-	"os"
-	"os/signal"
-
+	"github.com/sotah-inc/server/app/pkg/health"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/state"
 )
 
-func ProdMetrics(config state.ProdMetricsStateConfig) error {
+func ProdMetrics(config state.ProdMetricsStateConfig, healthConfig health.Config) error {
 	logging.Info("Starting prod-metrics")
 
+	// serving health while starting up
+	hs, err := startHealthServer(healthConfig)
+	if err != nil {
+		return err
+	}
+
 	// establishing a state
 	metricsState, err := state.NewProdMetricsState(config)
 	if err != nil {
@@ -27,18 +31,18 @@ func ProdMetrics(config state.ProdMetricsStateConfig) error {
 	// opening all bus-listeners
 	logging.Info("Opening all bus-listeners")
 	metricsState.BusListeners.Listen()
-
-	// catching SIGINT
-	logging.Info("Waiting for SIGINT")
-	sigIn := make(chan os.Signal, 1)
-	signal.Notify(sigIn, os.Interrupt)
-	<-sigIn
-
-	logging.Info("Caught SIGINT, exiting")
-
-	// stopping listeners
-	metricsState.Listeners.Stop()
-
-	logging.Info("Exiting")
-	return nil
+	addStateHealthChecks(hs, metricsState.State)
+	hs.Started()
+
+	// waiting for a signal to shut down
+	sigIn := waitForShutdownSignal()
+
+	return shutdown(sigIn, []shutdownStep{
+		readinessShutdownStep(hs),
+		listenersShutdownStep(metricsState.Listeners, metricsState.IO.Messenger),
+		busListenersShutdownStep(metricsState.BusListeners),
+		databasesShutdownStep(metricsState.IO.Databases),
+		messengerShutdownStep(metricsState.IO.Messenger),
+		healthShutdownStep(hs),
+	})
 }
@@ -1,26 +1,39 @@
 package command
 
 import (
-	"os"
-	"os/signal"
+	"context"
 	"time"
 
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/health"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/metric"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/state"
 )
 
-func ProdPricelistHistories(config state.ProdPricelistHistoriesStateConfig) error {
+func ProdPricelistHistories(
+	ctx context.Context,
+	config state.ProdPricelistHistoriesStateConfig,
+	healthConfig health.Config,
+) error {
 	logging.Info("Starting prod-metrics")
 
+	// serving health while starting up
+	hs, err := startHealthServer(healthConfig)
+	if err != nil {
+		return err
+	}
+
 	// establishing a state
-	pricelistHistoriesState, err := state.NewProdPricelistHistoriesState(config)
+	pricelistHistoriesState, err := state.NewProdPricelistHistoriesState(ctx, config)
 	if err != nil {
 		logging.WithField("error", err.Error()).Error("Failed to establish prod-pricelisthistories state")
 
 		return err
 	}
+	addStateHealthChecks(hs, pricelistHistoriesState.State)
+	hs.AddReadinessCheck("statuses", pricelistHistoriesState.CheckStatuses)
 
 	// syncing local pricelist-histories with base pricelist-histories
 	startTime := time.Now()
@@ -32,7 +45,7 @@ func ProdPricelistHistories(config state.ProdPricelistHistoriesStateConfig) erro
 
 	// reporting sync duration
 	m := metric.Metrics{"pricelist_histories_sync": int(int64(time.Since(startTime)) / 1000 / 1000 / 1000)}
-	if err := pricelistHistoriesState.IO.BusClient.PublishMetrics(m); err != nil {
+	if err := pricelistHistoriesState.IO.BusClient.PublishMetrics(ctx, m); err != nil {
 		logging.WithField("error", err.Error()).Error("Failed to publish metric")
 
 		return err
@@ -41,7 +54,33 @@ func ProdPricelistHistories(config state.ProdPricelistHistoriesStateConfig) erro
 	// starting up a pruner
 	logging.Info("Starting up the pricelist-histories file pruner")
 	prunerStop := make(sotah.WorkerStopChan)
-	onPrunerStop := pricelistHistoriesState.IO.Databases.PricelistHistoryDatabases.StartPruner(prunerStop)
+	onPrunerStop := pricelistHistoriesState.IO.Databases.PricelistHistoryDatabases.StartPruner(
+		prunerStop,
+		addHeartbeat(hs, "pruner", database.PrunerInterval),
+	)
+
+	logging.Info("Starting up the region pricelist-histories file pruner")
+	regionPrunerStop := make(sotah.WorkerStopChan)
+	onRegionPrunerStop := pricelistHistoriesState.IO.Databases.RegionPricelistHistoryDatabases.StartPruner(
+		regionPrunerStop,
+		addHeartbeat(hs, "region-pruner", database.PrunerInterval),
+	)
+
+	// starting up a region computer, which intake queues region aggregates for
+	logging.Info("Starting up the region pricelist-histories computer")
+	regionComputerStop := make(sotah.WorkerStopChan)
+	onRegionComputerStop := pricelistHistoriesState.IO.Databases.RegionPricelistHistoryDatabases.StartComputer(
+		regionComputerStop,
+		pricelistHistoriesState.IO.Databases.PricelistHistoryDatabases,
+	)
+
+	// starting up a rollup-builder
+	logging.Info("Starting up the pricelist-histories rollup-builder")
+	rollupBuilderStop := make(sotah.WorkerStopChan)
+	onRollupBuilderStop := pricelistHistoriesState.IO.Databases.PricelistHistoryDatabases.StartRollupBuilder(
+		rollupBuilderStop,
+		addHeartbeat(hs, "rollup-builder", database.RollupBuilderInterval),
+	)
 
 	// opening all listeners
 	if err := pricelistHistoriesState.Listeners.Listen(); err != nil {
@@ -51,25 +90,21 @@ func ProdPricelistHistories(config state.ProdPricelistHistoriesStateConfig) erro
 	// opening all bus-listeners
 	logging.Info("Opening all bus-listeners")
 	pricelistHistoriesState.BusListeners.Listen()
-
-	// catching SIGINT
-	logging.Info("Waiting for SIGINT")
-	sigIn := make(chan os.Signal, 1)
-	signal.Notify(sigIn, os.Interrupt)
-	<-sigIn
-
-	logging.Info("Caught SIGINT, exiting")
-
-	// stopping listeners
-	pricelistHistoriesState.Listeners.Stop()
-
-	// stopping pruner
-	logging.Info("Stopping pruner")
-	prunerStop <- struct{}{}
-
-	logging.Info("Waiting for pruner to stop")
-	<-onPrunerStop
-
-	logging.Info("Exiting")
-	return nil
+	hs.Started()
+
+	// waiting for a signal to shut down
+	sigIn := waitForShutdownSignal()
+
+	return shutdown(sigIn, []shutdownStep{
+		readinessShutdownStep(hs),
+		listenersShutdownStep(pricelistHistoriesState.Listeners, pricelistHistoriesState.IO.Messenger),
+		busListenersShutdownStep(pricelistHistoriesState.BusListeners),
+		workerShutdownStep("pruner", prunerStop, onPrunerStop),
+		workerShutdownStep("region pruner", regionPrunerStop, onRegionPrunerStop),
+		workerShutdownStep("rollup-builder", rollupBuilderStop, onRollupBuilderStop),
+		workerShutdownStep("region computer", regionComputerStop, onRegionComputerStop),
+		databasesShutdownStep(pricelistHistoriesState.IO.Databases),
+		messengerShutdownStep(pricelistHistoriesState.IO.Messenger),
+		healthShutdownStep(hs),
+	})
 }
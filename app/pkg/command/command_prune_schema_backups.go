@@ -0,0 +1,28 @@
+package command
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/logging"
+)
+
+type PruneSchemaBackupsConfig struct {
+	DatabaseDir string
+	Keep        int
+}
+
+func PruneSchemaBackups(config PruneSchemaBackupsConfig) error {
+	logging.WithFields(logrus.Fields{
+		"dir":  config.DatabaseDir,
+		"keep": config.Keep,
+	}).Info("Starting prune-schema-backups")
+
+	totalRemoved, err := database.PruneSchemaBackups(config.DatabaseDir, config.Keep)
+	if err != nil {
+		return err
+	}
+
+	logging.WithField("removed", totalRemoved).Info("Finished prune-schema-backups")
+
+	return nil
+}
@@ -0,0 +1,384 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/sotah/gameversions"
+	"github.com/sotah-inc/server/app/pkg/store"
+	"github.com/sotah-inc/server/app/pkg/store/regions"
+	"github.com/sotah-inc/server/app/pkg/util"
+)
+
+type RebuildPricelistHistoriesConfig struct {
+	ProjectId          string
+	DatabaseDir        string
+	CheckpointFilepath string
+	Concurrency        int
+	SkipLocal          bool
+	Retention          sotah.RetentionConfig
+
+	RegionName  blizzard.RegionName
+	RealmSlugs  []blizzard.RealmSlug
+	LowerBounds int64
+	UpperBounds int64
+}
+
+// rebuild checkpoint, listing every realm-day that has been fully rewritten
+func newRebuildCheckpoint(checkpointFilepath string) (sotah.RegionRealmTimestamps, error) {
+	if _, err := os.Stat(checkpointFilepath); os.IsNotExist(err) {
+		return sotah.RegionRealmTimestamps{}, nil
+	}
+
+	data, err := util.ReadFile(checkpointFilepath)
+	if err != nil {
+		return sotah.RegionRealmTimestamps{}, err
+	}
+
+	out := sotah.RegionRealmTimestamps{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return sotah.RegionRealmTimestamps{}, err
+	}
+
+	return out, nil
+}
+
+func isCheckpointed(checkpoint sotah.RegionRealmTimestamps, realm sotah.Realm, targetTimestamp sotah.UnixTimestamp) bool {
+	for _, completedTimestamp := range checkpoint[realm.Region.Name][realm.Slug] {
+		if completedTimestamp == targetTimestamp {
+			return true
+		}
+	}
+
+	return false
+}
+
+func saveRebuildCheckpoint(checkpointFilepath string, checkpoint sotah.RegionRealmTimestamps) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	// writing to a sibling file first, so an interrupted write never corrupts the checkpoint
+	tempFilepath := fmt.Sprintf("%s.tmp", checkpointFilepath)
+	if err := util.WriteFile(tempFilepath, data); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFilepath, checkpointFilepath)
+}
+
+type rebuildPricelistHistoryJob struct {
+	Err             error
+	Realm           sotah.Realm
+	TargetTimestamp sotah.UnixTimestamp
+	VersionId       string
+	Dumps           int
+}
+
+func (job rebuildPricelistHistoryJob) ToLogrusFields() logrus.Fields {
+	return logrus.Fields{
+		"error":            job.Err.Error(),
+		"region":           job.Realm.Region.Name,
+		"realm":            job.Realm.Slug,
+		"target-timestamp": job.TargetTimestamp,
+	}
+}
+
+type pricelistHistoriesRebuilder struct {
+	config RebuildPricelistHistoriesConfig
+
+	manifestBase           store.AuctionManifestBaseV2
+	manifestBucket         *storage.BucketHandle
+	auctionsBase           store.AuctionsBaseV2
+	auctionsBucket         *storage.BucketHandle
+	pricelistHistoriesBase store.PricelistHistoriesBaseV2
+	pricelistHistoriesBkt  *storage.BucketHandle
+}
+
+/*
+rebuild recomputes the item-prices of every dump in the day's manifest and overwrites the day's histories, returning the
+number of dumps and the version id of the stored histories
+*/
+func (r pricelistHistoriesRebuilder) rebuild(
+	ctx context.Context,
+	realm sotah.Realm,
+	targetTimestamp sotah.UnixTimestamp,
+) (int, string, error) {
+	manifest, err := r.manifestBase.NewAuctionManifest(ctx, r.manifestBase.GetObject(targetTimestamp, realm, r.manifestBucket))
+	if err != nil {
+		return 0, "", err
+	}
+
+	sort.Slice(manifest, func(i, j int) bool {
+		return manifest[i] < manifest[j]
+	})
+
+	ipHistories := sotah.ItemPriceHistories{}
+	for _, lastModified := range manifest {
+		aucs, err := r.auctionsBase.GetAuctions(ctx, realm, time.Unix(int64(lastModified), 0), r.auctionsBucket)
+		if err != nil {
+			return 0, "", err
+		}
+
+		ipHistories.Merge(
+			lastModified,
+			sotah.NewItemPrices(sotah.NewMiniAuctionListFromMiniAuctions(sotah.NewMiniAuctions(aucs))),
+		)
+	}
+
+	targetTime := time.Unix(int64(targetTimestamp), 0)
+	versionId, err := r.pricelistHistoriesBase.Replace(ctx, ipHistories, targetTime, realm, r.pricelistHistoriesBkt)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if r.config.SkipLocal {
+		return len(manifest), versionId, nil
+	}
+
+	err = database.RebuildPricelistHistoryDatabase(
+		r.config.DatabaseDir,
+		realm,
+		targetTime,
+		ipHistories,
+		r.config.Retention,
+	)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return len(manifest), versionId, nil
+}
+
+func RebuildPricelistHistories(ctx context.Context, config RebuildPricelistHistoriesConfig) error {
+	logging.WithField("checkpoint", config.CheckpointFilepath).Info("Starting rebuild-pricelist-histories")
+
+	checkpoint, err := newRebuildCheckpoint(config.CheckpointFilepath)
+	if err != nil {
+		return err
+	}
+
+	/*
+		the local meta database records the version of each rebuilt day, so that syncing does not download it again,
+		where skipping the local databases leaves the old versions for syncing to replace
+	*/
+	var metaDatabase database.MetaDatabase
+	if !config.SkipLocal {
+		_, err = database.MigrateSchemas(config.DatabaseDir, schemakinds.PricelistHistories, schemakinds.Meta)
+		if err != nil {
+			return err
+		}
+
+		metaDatabase, err = database.NewMetaDatabase(config.DatabaseDir)
+		if err != nil {
+			return err
+		}
+		defer metaDatabase.Close()
+	}
+
+	storeClient, err := store.NewClient(config.ProjectId)
+	if err != nil {
+		return err
+	}
+
+	r := pricelistHistoriesRebuilder{
+		config:                 config,
+		manifestBase:           store.NewAuctionManifestBaseV2(storeClient, regions.USCentral1, gameversions.Retail),
+		auctionsBase:           store.NewAuctionsBaseV2(storeClient, regions.USCentral1, gameversions.Retail),
+		pricelistHistoriesBase: store.NewPricelistHistoriesBaseV2(storeClient, regions.USCentral1, gameversions.Retail),
+	}
+	if r.manifestBucket, err = r.manifestBase.GetFirmBucket(ctx); err != nil {
+		return err
+	}
+	if r.auctionsBucket, err = r.auctionsBase.GetFirmBucket(ctx); err != nil {
+		return err
+	}
+	if r.pricelistHistoriesBkt, err = r.pricelistHistoriesBase.GetFirmBucket(ctx); err != nil {
+		return err
+	}
+
+	bootBase := store.NewBootBase(storeClient, regions.USCentral1)
+	bootBucket, err := bootBase.GetFirmBucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	realmsBase := store.NewRealmsBase(storeClient, regions.USCentral1, gameversions.Retail)
+	realmsBucket, err := realmsBase.GetFirmBucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	regionList, err := bootBase.GetRegions(ctx, bootBucket)
+	if err != nil {
+		return err
+	}
+
+	// gathering the realm-days left to rebuild
+	regionRealms := sotah.RegionRealms{}
+	for _, region := range regionList {
+		realms, err := realmsBase.GetAllRealms(ctx, region.Name, realmsBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, realm := range realms {
+			if !matchesRealmSelection(config.RegionName, config.RealmSlugs, realm) {
+				continue
+			}
+
+			regionRealms[region.Name] = append(regionRealms[region.Name], realm)
+		}
+	}
+
+	regionRealmTimestamps, err := r.manifestBase.GetAllTimestamps(ctx, regionRealms, r.manifestBucket)
+	if err != nil {
+		return err
+	}
+
+	type inJob struct {
+		realm           sotah.Realm
+		targetTimestamp sotah.UnixTimestamp
+	}
+	inJobs := []inJob{}
+	regionTimestamps := database.RegionTargetTimestamps{}
+	for _, realms := range regionRealms {
+		for _, realm := range realms {
+			for _, targetTimestamp := range regionRealmTimestamps[realm.Region.Name][realm.Slug] {
+				if config.LowerBounds > 0 && int64(targetTimestamp)+24*60*60 < config.LowerBounds {
+					continue
+				}
+				if config.UpperBounds > 0 && int64(targetTimestamp) > config.UpperBounds {
+					continue
+				}
+
+				// including checkpointed realm-days, as an interrupted run may not have reached their region aggregate
+				regionTimestamps = regionTimestamps.Insert(realm.Region.Name, targetTimestamp)
+
+				if isCheckpointed(checkpoint, realm, targetTimestamp) {
+					continue
+				}
+
+				inJobs = append(inJobs, inJob{realm, targetTimestamp})
+			}
+		}
+	}
+
+	logging.WithField("realm-days", len(inJobs)).Info("Rebuilding pricelist-histories")
+
+	// spinning up workers
+	in := make(chan inJob)
+	out := make(chan rebuildPricelistHistoryJob)
+	worker := func() {
+		for job := range in {
+			dumps, versionId, err := r.rebuild(ctx, job.realm, job.targetTimestamp)
+			out <- rebuildPricelistHistoryJob{
+				Err:             err,
+				Realm:           job.realm,
+				TargetTimestamp: job.targetTimestamp,
+				VersionId:       versionId,
+				Dumps:           dumps,
+			}
+		}
+	}
+	postWork := func() {
+		close(out)
+	}
+	concurrency := config.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	util.Work(concurrency, worker, postWork)
+
+	// queueing it up
+	go func() {
+		for _, job := range inJobs {
+			in <- job
+		}
+
+		close(in)
+	}()
+
+	// checkpointing each realm-day as it completes
+	totalFailed := 0
+	totalRebuilt := 0
+	for job := range out {
+		if job.Err != nil {
+			logging.WithFields(job.ToLogrusFields()).Error("Failed to rebuild pricelist-history")
+			totalFailed++
+
+			continue
+		}
+
+		if !config.SkipLocal {
+			versions := sotah.PricelistHistoryVersions{}.Insert(
+				job.Realm.Region.Name,
+				job.Realm.Slug,
+				job.TargetTimestamp,
+				job.VersionId,
+			)
+			if err := metaDatabase.SetPricelistHistoriesVersions(versions); err != nil {
+				return err
+			}
+		}
+
+		if _, ok := checkpoint[job.Realm.Region.Name]; !ok {
+			checkpoint[job.Realm.Region.Name] = sotah.RealmTimestamps{}
+		}
+		checkpoint[job.Realm.Region.Name][job.Realm.Slug] = append(
+			checkpoint[job.Realm.Region.Name][job.Realm.Slug],
+			job.TargetTimestamp,
+		)
+		if err := saveRebuildCheckpoint(config.CheckpointFilepath, checkpoint); err != nil {
+			return err
+		}
+
+		logging.WithFields(logrus.Fields{
+			"region":           job.Realm.Region.Name,
+			"realm":            job.Realm.Slug,
+			"target-timestamp": job.TargetTimestamp,
+			"dumps":            job.Dumps,
+		}).Info("Rebuilt pricelist-history")
+		totalRebuilt++
+	}
+
+	// recomputing the region aggregates once every realm of the day has been rebuilt
+	if !config.SkipLocal {
+		for regionName, timestamps := range regionTimestamps {
+			for targetTimestamp := range timestamps {
+				err := database.RebuildRegionPricelistHistoryDatabase(config.DatabaseDir, regionName, targetTimestamp)
+				if err != nil {
+					logging.WithFields(logrus.Fields{
+						"error":            err.Error(),
+						"region":           regionName,
+						"target-timestamp": targetTimestamp,
+					}).Error("Failed to rebuild region pricelist-history")
+					totalFailed++
+				}
+			}
+		}
+	}
+
+	logging.WithFields(logrus.Fields{
+		"rebuilt": totalRebuilt,
+		"failed":  totalFailed,
+	}).Info("Finished rebuild-pricelist-histories")
+
+	if totalFailed > 0 {
+		return fmt.Errorf("failed to rebuild %d realm-days or region-days, re-run to resume", totalFailed)
+	}
+
+	return nil
+}
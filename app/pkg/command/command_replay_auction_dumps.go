@@ -0,0 +1,128 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/archive"
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/util"
+)
+
+type ReplayAuctionDumpsConfig struct {
+	ArchiveFilepath string
+	DatabaseDir     string
+	Retention       sotah.RetentionConfig
+}
+
+func ReplayAuctionDumps(config ReplayAuctionDumpsConfig) error {
+	logging.WithField("archive", config.ArchiveFilepath).Info("Starting replay-auction-dumps")
+
+	archiveFile, err := os.Open(config.ArchiveFilepath)
+	if err != nil {
+		return err
+	}
+	defer archiveFile.Close()
+
+	r, err := archive.NewReader(archiveFile)
+	if err != nil {
+		return err
+	}
+
+	// ensuring database paths exist
+	statuses := r.Manifest.Statuses()
+	databasePaths := []string{}
+	for regionName, status := range statuses {
+		databasePaths = append(databasePaths, fmt.Sprintf("%s/live-auctions/%s", config.DatabaseDir, regionName))
+
+		for _, realm := range status.Realms {
+			databasePaths = append(databasePaths, fmt.Sprintf(
+				"%s/pricelist-histories/%s/%s",
+				config.DatabaseDir,
+				regionName,
+				realm.Slug,
+			))
+		}
+	}
+	if err := util.EnsureDirsExist(databasePaths); err != nil {
+		return err
+	}
+
+	_, err = database.MigrateSchemas(
+		config.DatabaseDir,
+		schemakinds.LiveAuctions,
+		schemakinds.PricelistHistories,
+		schemakinds.PricelistHistoryRollups,
+	)
+	if err != nil {
+		return err
+	}
+
+	ladBases, err := database.NewLiveAuctionsDatabases(config.DatabaseDir, statuses)
+	if err != nil {
+		return err
+	}
+	defer ladBases.Close()
+
+	phdBases, err := database.NewPricelistHistoryDatabases(config.DatabaseDir, statuses, config.Retention)
+	if err != nil {
+		return err
+	}
+	defer phdBases.Close()
+
+	// loading one dump at a time, so that each realm is replayed in last-modified order
+	liveAuctionsIn := make(chan database.LoadInJob)
+	liveAuctionsOut := ladBases.Load(liveAuctionsIn)
+	pricelistHistoriesIn := make(chan database.LoadInJob)
+	pricelistHistoriesOut := phdBases.Load(pricelistHistoriesIn)
+	defer close(liveAuctionsIn)
+	defer close(pricelistHistoriesIn)
+
+	totalReplayed := 0
+	for {
+		entry, aucs, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		job := database.LoadInJob{
+			Realm:      entry.Realm,
+			TargetTime: entry.LastModifiedTime(),
+			Auctions:   aucs,
+		}
+
+		liveAuctionsIn <- job
+		if outJob := <-liveAuctionsOut; outJob.Err != nil {
+			logging.WithFields(outJob.ToLogrusFields()).Error("Failed to replay dump into live-auctions")
+
+			return outJob.Err
+		}
+
+		pricelistHistoriesIn <- job
+		if outJob := <-pricelistHistoriesOut; outJob.Err != nil {
+			logging.WithFields(outJob.ToLogrusFields()).Error("Failed to replay dump into pricelist-histories")
+
+			return outJob.Err
+		}
+
+		logging.WithFields(logrus.Fields{
+			"region":        entry.Realm.Region.Name,
+			"realm":         entry.Realm.Slug,
+			"last-modified": entry.LastModified,
+		}).Debug("Replayed dump")
+
+		totalReplayed++
+	}
+
+	logging.WithField("dumps", totalReplayed).Info("Finished replay-auction-dumps")
+
+	return nil
+}
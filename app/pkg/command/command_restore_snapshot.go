@@ -0,0 +1,120 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah/gameversions"
+	"github.com/sotah-inc/server/app/pkg/store"
+	"github.com/sotah-inc/server/app/pkg/store/regions"
+)
+
+type RestoreSnapshotConfig struct {
+	ProjectId   string
+	DatabaseDir string
+	Kind        schemakinds.SchemaKind
+
+	// zero restores the latest snapshot
+	SnapshotId int64
+	Overwrite  bool
+}
+
+// RestoreSnapshot bootstraps the local databases of a kind from a stored snapshot, before the owning process runs
+func RestoreSnapshot(ctx context.Context, config RestoreSnapshotConfig) error {
+	logging.WithFields(logrus.Fields{
+		"kind": config.Kind,
+		"id":   config.SnapshotId,
+	}).Info("Starting restore-snapshot")
+
+	storeClient, err := store.NewClient(config.ProjectId)
+	if err != nil {
+		return err
+	}
+
+	snapshotsBase := store.NewSnapshotsBase(storeClient, regions.USCentral1, gameversions.Retail)
+	snapshotsBucket, err := snapshotsBase.GetFirmBucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := func() (store.SnapshotManifest, error) {
+		if config.SnapshotId == 0 {
+			return snapshotsBase.GetLatestManifest(ctx, config.Kind, snapshotsBucket)
+		}
+
+		return snapshotsBase.GetManifest(ctx, config.Kind, config.SnapshotId, snapshotsBucket)
+	}()
+	if err != nil {
+		return err
+	}
+
+	err = restoreSnapshotDatabases(
+		config.DatabaseDir,
+		config.Kind,
+		manifest,
+		config.Overwrite,
+		func(path string) (io.ReadCloser, error) {
+			return snapshotsBase.NewDatabaseReader(ctx, config.Kind, manifest.Id, path, snapshotsBucket)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	logging.WithFields(logrus.Fields{
+		"kind":      config.Kind,
+		"id":        manifest.Id,
+		"databases": len(manifest.Databases),
+	}).Info("Finished restore-snapshot")
+
+	return nil
+}
+
+// restoreSnapshotDatabases writes every database of the snapshot into the database dir, reading each through newReader
+func restoreSnapshotDatabases(
+	databaseDir string,
+	kind schemakinds.SchemaKind,
+	manifest store.SnapshotManifest,
+	overwrite bool,
+	newReader func(path string) (io.ReadCloser, error),
+) error {
+	// older snapshots are upgraded by the schema migrations on boot, newer ones cannot be read
+	if manifest.SchemaVersion > database.CurrentSchemaVersion(kind) {
+		return fmt.Errorf(
+			"snapshot %d is at schema version %d but only %d is supported",
+			manifest.Id,
+			manifest.SchemaVersion,
+			database.CurrentSchemaVersion(kind),
+		)
+	}
+
+	for _, entry := range manifest.Databases {
+		r, err := newReader(entry.Path)
+		if err != nil {
+			return err
+		}
+
+		err = database.RestoreDatabase(databaseDir, entry.Path, r, overwrite)
+		r.Close()
+		if err != nil {
+			logging.WithFields(logrus.Fields{
+				"error":    err.Error(),
+				"pathname": entry.Path,
+			}).Error("Failed to restore database")
+
+			return err
+		}
+
+		logging.WithFields(logrus.Fields{
+			"pathname": entry.Path,
+			"size":     entry.Size,
+		}).Debug("Restored database")
+	}
+
+	return nil
+}
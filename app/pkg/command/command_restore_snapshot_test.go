@@ -0,0 +1,84 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSnapshotReader(databases map[string][]byte) func(path string) (io.ReadCloser, error) {
+	return func(path string) (io.ReadCloser, error) {
+		data, ok := databases[path]
+		if !ok {
+			return nil, fmt.Errorf("snapshot database not found: %s", path)
+		}
+
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
+func TestRestoreSnapshotDatabases(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "restore-snapshot")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	databases := map[string][]byte{
+		"live-auctions/us/earthen-ring/live-auctions.db": []byte("earthen-ring"),
+		"live-auctions/us/stormrage/live-auctions.db":    []byte("stormrage"),
+	}
+	manifest := store.SnapshotManifest{
+		Id:            100,
+		Kind:          schemakinds.LiveAuctions,
+		SchemaVersion: database.CurrentSchemaVersion(schemakinds.LiveAuctions),
+		Databases: []store.SnapshotManifestEntry{
+			{Path: "live-auctions/us/earthen-ring/live-auctions.db"},
+			{Path: "live-auctions/us/stormrage/live-auctions.db"},
+		},
+	}
+
+	// a snapshot newer than the supported schema is refused before anything is written
+	newerManifest := manifest
+	newerManifest.SchemaVersion++
+	err = restoreSnapshotDatabases(
+		dbDir,
+		schemakinds.LiveAuctions,
+		newerManifest,
+		false,
+		newTestSnapshotReader(databases),
+	)
+	assert.NotNil(t, err)
+
+	_, err = os.Stat(fmt.Sprintf("%s/live-auctions", dbDir))
+	assert.True(t, os.IsNotExist(err))
+
+	// every database of the snapshot is restored
+	err = restoreSnapshotDatabases(dbDir, schemakinds.LiveAuctions, manifest, false, newTestSnapshotReader(databases))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	for path, expected := range databases {
+		data, err := ioutil.ReadFile(fmt.Sprintf("%s/%s", dbDir, path))
+		if !assert.Nil(t, err) {
+			return
+		}
+		assert.Equal(t, expected, data)
+	}
+
+	// restoring again only replaces the databases when told to
+	err = restoreSnapshotDatabases(dbDir, schemakinds.LiveAuctions, manifest, false, newTestSnapshotReader(databases))
+	assert.NotNil(t, err)
+
+	err = restoreSnapshotDatabases(dbDir, schemakinds.LiveAuctions, manifest, true, newTestSnapshotReader(databases))
+	assert.Nil(t, err)
+}
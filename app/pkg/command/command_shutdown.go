@@ -0,0 +1,136 @@
+package command
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/state"
+)
+
+// shutdownTimeout - the deadline for every shutdown step together, once a signal is caught
+const shutdownTimeout = 30 * time.Second
+
+// ErrForcedShutdown - the shutdown deadline passed, or a second signal was caught, before every step finished
+var ErrForcedShutdown = errors.New("forced shutdown before draining and closing finished")
+
+// waitForShutdownSignal blocks until SIGINT or SIGTERM, and returns the channel that catches any further signal
+func waitForShutdownSignal() chan os.Signal {
+	logging.Info("Waiting for SIGINT or SIGTERM")
+	sigIn := make(chan os.Signal, 1)
+	signal.Notify(sigIn, os.Interrupt, syscall.SIGTERM)
+	sig := <-sigIn
+
+	logging.WithField("signal", sig.String()).Info("Caught signal, shutting down")
+
+	return sigIn
+}
+
+type shutdownStep struct {
+	name string
+	call func() error
+}
+
+func listenersShutdownStep(ls state.Listeners, mess messenger.Messenger) shutdownStep {
+	return shutdownStep{
+		name: "listeners",
+		call: func() error {
+			ls.Stop()
+			mess.Drain()
+
+			return nil
+		},
+	}
+}
+
+func busListenersShutdownStep(ls state.BusListeners) shutdownStep {
+	return shutdownStep{
+		name: "bus-listeners",
+		call: func() error {
+			ls.Stop()
+
+			return nil
+		},
+	}
+}
+
+func workerShutdownStep(name string, stop sotah.WorkerStopChan, onStop sotah.WorkerStopChan) shutdownStep {
+	return shutdownStep{
+		name: name,
+		call: func() error {
+			stop <- struct{}{}
+			<-onStop
+
+			return nil
+		},
+	}
+}
+
+func databasesShutdownStep(dbs state.Databases) shutdownStep {
+	return shutdownStep{name: "databases", call: dbs.Close}
+}
+
+func messengerShutdownStep(mess messenger.Messenger) shutdownStep {
+	return shutdownStep{name: "messenger", call: mess.Close}
+}
+
+/*
+shutdown runs the steps in order within the deadline, where a failing step does not prevent the later ones: listeners
+should come first so that no new work arrives, and databases and connections last so that in-flight work may finish
+*/
+func shutdown(sigIn chan os.Signal, steps []shutdownStep) error {
+	done := make(chan error, 1)
+	go func() {
+		var firstErr error
+		for _, step := range steps {
+			startTime := time.Now()
+			if err := step.call(); err != nil {
+				logging.WithFields(logrus.Fields{
+					"error": err.Error(),
+					"step":  step.name,
+				}).Error("Failed to shut down")
+
+				if firstErr == nil {
+					firstErr = err
+				}
+
+				continue
+			}
+
+			logging.WithFields(logrus.Fields{
+				"step":     step.name,
+				"duration": time.Since(startTime).String(),
+			}).Info("Shut down")
+		}
+
+		done <- firstErr
+	}()
+
+	timer := time.NewTimer(shutdownTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+
+		logging.Info("Exiting")
+
+		return nil
+	case <-timer.C:
+		logging.WithField("timeout", shutdownTimeout.String()).Error("Shutdown deadline passed")
+
+		return ErrForcedShutdown
+	case sig := <-sigIn:
+		logging.WithField("signal", sig.String()).Error("Caught another signal during shutdown")
+
+		return ErrForcedShutdown
+	}
+}
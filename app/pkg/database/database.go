@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
@@ -11,10 +12,21 @@ import (
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/tracing"
 )
 
-func RetentionLimit() time.Time {
-	return time.Now().Add(-1 * time.Hour * 24 * 30)
+// startLoadSpan starts a span around loading the data of a realm into its database
+func startLoadSpan(
+	ctx context.Context,
+	name string,
+	regionName blizzard.RegionName,
+	realmSlug blizzard.RealmSlug,
+) (context.Context, tracing.Span) {
+	ctx, span := tracing.Start(ctx, name)
+	span.SetAttribute(tracing.RegionAttribute, string(regionName))
+	span.SetAttribute(tracing.RealmAttribute, string(realmSlug))
+
+	return ctx, span
 }
 
 type databasePathPair struct {
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/sotah-inc/server/app/pkg/sotah"
+)
+
+// bucketing
+func apiKeysBucketName() []byte {
+	return []byte("api-keys")
+}
+
+func apiKeyUsageBucketName(id sotah.APIKeyID) []byte {
+	return []byte(fmt.Sprintf("api-key-usage/%s", id))
+}
+
+// keying
+func apiKeyKeyName(id sotah.APIKeyID) []byte {
+	return []byte(id)
+}
+
+func apiKeyUsageDayPrefix(day string) []byte {
+	return []byte(fmt.Sprintf("%s/", day))
+}
+
+func apiKeyUsageKeyName(day string, subject string) []byte {
+	return []byte(fmt.Sprintf("%s/%s", day, subject))
+}
+
+// db
+func apiKeysDatabaseFilePath(dirPath string) string {
+	return fmt.Sprintf("%s/api-keys.db", dirPath)
+}
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/boltdb/bolt"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+)
+
+func NewAPIKeysDatabase(dbDir string) (APIKeysDatabase, error) {
+	dbFilepath := apiKeysDatabaseFilePath(dbDir)
+
+	logging.WithField("filepath", dbFilepath).Info("Initializing api-keys database")
+
+	db, err := bolt.Open(dbFilepath, 0600, nil)
+	if err != nil {
+		return APIKeysDatabase{}, err
+	}
+
+	if err := ensureSchemaVersion(db, schemakinds.APIKeys); err != nil {
+		db.Close()
+
+		return APIKeysDatabase{}, err
+	}
+
+	return APIKeysDatabase{db}, nil
+}
+
+/*
+APIKeysDatabase - the api keys issued to third parties, along with their usage by day and subject, where usage is kept
+in a bucket per key so that a day of it is read with one seek
+*/
+type APIKeysDatabase struct {
+	db *bolt.DB
+}
+
+func (d APIKeysDatabase) PersistAPIKey(key sotah.APIKey) error {
+	encoded, err := key.EncodeForPersistence()
+	if err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists(apiKeysBucketName())
+		if err != nil {
+			return err
+		}
+
+		return bkt.Put(apiKeyKeyName(key.ID), encoded)
+	})
+}
+
+// GetAPIKey returns whether there is a key by the id, along with the key
+func (d APIKeysDatabase) GetAPIKey(id sotah.APIKeyID) (sotah.APIKey, bool, error) {
+	out := sotah.APIKey{}
+	found := false
+	err := d.db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(apiKeysBucketName())
+		if bkt == nil {
+			return nil
+		}
+
+		v := bkt.Get(apiKeyKeyName(id))
+		if v == nil {
+			return nil
+		}
+
+		key, err := sotah.NewAPIKey(v)
+		if err != nil {
+			return err
+		}
+
+		out = key
+		found = true
+
+		return nil
+	})
+	if err != nil {
+		return sotah.APIKey{}, false, err
+	}
+
+	return out, found, nil
+}
+
+func (d APIKeysDatabase) GetAPIKeys() (sotah.APIKeys, error) {
+	out := sotah.APIKeys{}
+	err := d.db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(apiKeysBucketName())
+		if bkt == nil {
+			return nil
+		}
+
+		return bkt.ForEach(func(k, v []byte) error {
+			key, err := sotah.NewAPIKey(v)
+			if err != nil {
+				return err
+			}
+
+			out = append(out, key)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return sotah.APIKeys{}, err
+	}
+
+	return out, nil
+}
+
+// PersistAPIKeyUsage adds the counts of the usage onto those already persisted for its day
+func (d APIKeysDatabase) PersistAPIKeyUsage(usage sotah.APIKeyUsage) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		for id, subjectUsage := range usage.Counts {
+			bkt, err := tx.CreateBucketIfNotExists(apiKeyUsageBucketName(id))
+			if err != nil {
+				return err
+			}
+
+			for subject, count := range subjectUsage {
+				keyName := apiKeyUsageKeyName(usage.Day, subject)
+
+				total := uint64(count)
+				if v := bkt.Get(keyName); len(v) == 8 {
+					total += binary.BigEndian.Uint64(v)
+				}
+
+				encoded := make([]byte, 8)
+				binary.BigEndian.PutUint64(encoded, total)
+				if err := bkt.Put(keyName, encoded); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (d APIKeysDatabase) GetAPIKeyUsage(id sotah.APIKeyID, day string) (sotah.SubjectUsage, error) {
+	out := sotah.SubjectUsage{}
+	err := d.db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(apiKeyUsageBucketName(id))
+		if bkt == nil {
+			return nil
+		}
+
+		prefix := apiKeyUsageDayPrefix(day)
+		c := bkt.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			if len(v) != 8 {
+				continue
+			}
+
+			out[string(k[len(prefix):])] = int(binary.BigEndian.Uint64(v))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return sotah.SubjectUsage{}, err
+	}
+
+	return out, nil
+}
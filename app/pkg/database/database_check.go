@@ -0,0 +1,658 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/util"
+)
+
+// databases are opened read-only with a timeout, so a check fails fast rather than blocking on a running process
+const checkOpenTimeout = 5 * time.Second
+
+// BrokenDatabase - a database file that could not be opened or holds a value that does not decode
+type BrokenDatabase struct {
+	Kind     schemakinds.SchemaKind
+	Filepath string
+	Reason   string
+
+	// only set for pricelist-history and region pricelist-history shards with a valid filename, where region shards
+	// have no realm
+	RegionName      blizzard.RegionName
+	RealmSlug       blizzard.RealmSlug
+	TargetTimestamp sotah.UnixTimestamp
+}
+
+func (b BrokenDatabase) IsPricelistHistoryShard() bool {
+	return b.Kind == schemakinds.PricelistHistories && b.TargetTimestamp > 0
+}
+
+func (b BrokenDatabase) IsRegionPricelistHistoryShard() bool {
+	return b.Kind == schemakinds.RegionPricelistHistories && b.TargetTimestamp > 0
+}
+
+func (b BrokenDatabase) ToLogrusFields() logrus.Fields {
+	return logrus.Fields{
+		"kind":     b.Kind,
+		"pathname": b.Filepath,
+		"reason":   b.Reason,
+	}
+}
+
+type CheckResult struct {
+	Checked int
+	Broken  []BrokenDatabase
+}
+
+func (r *CheckResult) add(broken *BrokenDatabase) {
+	r.Checked++
+	if broken != nil {
+		r.Broken = append(r.Broken, *broken)
+	}
+}
+
+// value checkers, where each verifies every value of a database opened read-only
+type databaseChecker func(tx *bolt.Tx) error
+
+func checkSchemaVersion(tx *bolt.Tx, kind schemakinds.SchemaKind) error {
+	version, err := getSchemaVersion(tx)
+	if err != nil {
+		return err
+	}
+
+	if version > CurrentSchemaVersion(kind) {
+		return fmt.Errorf("schema version %d is newer than the supported %d", version, CurrentSchemaVersion(kind))
+	}
+
+	return nil
+}
+
+func checkItemsDatabase(tx *bolt.Tx) error {
+	if bkt := tx.Bucket(databaseItemsBucketName()); bkt != nil {
+		err := bkt.ForEach(func(k, v []byte) error {
+			if _, err := strconv.Atoi(strings.TrimPrefix(string(k), "item-")); err != nil {
+				return fmt.Errorf("invalid item key %s: %s", k, err.Error())
+			}
+
+			gzipDecoded, err := util.GzipDecode(v)
+			if err != nil {
+				return fmt.Errorf("item %s does not decode: %s", k, err.Error())
+			}
+
+			if _, err := sotah.NewItem(gzipDecoded); err != nil {
+				return fmt.Errorf("item %s does not decode: %s", k, err.Error())
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if bkt := tx.Bucket(databaseItemNamesBucketName()); bkt != nil {
+		return bkt.ForEach(func(k, _ []byte) error {
+			if _, err := itemIdFromItemNameKeyName(k); err != nil {
+				return fmt.Errorf("invalid item-name key %s: %s", k, err.Error())
+			}
+
+			return nil
+		})
+	}
+
+	return nil
+}
+
+func checkMetaDatabase(tx *bolt.Tx) error {
+	return tx.ForEach(func(name []byte, bkt *bolt.Bucket) error {
+		if isSchemaBucketName(name) {
+			return nil
+		}
+
+		return bkt.ForEach(func(k, _ []byte) error {
+			unparsedTimestamp := strings.TrimPrefix(string(k), "pricelist-histories/")
+			if _, err := strconv.Atoi(unparsedTimestamp); err != nil {
+				return fmt.Errorf("invalid version key %s in bucket %s: %s", k, name, err.Error())
+			}
+
+			return nil
+		})
+	})
+}
+
+func checkAPIKeysDatabase(tx *bolt.Tx) error {
+	bkt := tx.Bucket(apiKeysBucketName())
+	if bkt == nil {
+		return nil
+	}
+
+	return bkt.ForEach(func(k, v []byte) error {
+		if _, err := sotah.NewAPIKey(v); err != nil {
+			return fmt.Errorf("invalid api key %s: %s", k, err.Error())
+		}
+
+		return nil
+	})
+}
+
+func checkRecipesDatabase(tx *bolt.Tx) error {
+	bkt := tx.Bucket(databaseRecipesBucketName())
+	if bkt == nil {
+		return nil
+	}
+
+	return bkt.ForEach(func(k, v []byte) error {
+		if _, err := recipeIdFromRecipeKeyName(k); err != nil {
+			return fmt.Errorf("invalid recipe key %s: %s", k, err.Error())
+		}
+
+		if _, err := sotah.NewRecipe(v); err != nil {
+			return fmt.Errorf("recipe %s does not decode: %s", k, err.Error())
+		}
+
+		return nil
+	})
+}
+
+func checkLiveAuctionsDatabase(tx *bolt.Tx) error {
+	bkt := tx.Bucket(liveAuctionsBucketName())
+	if bkt == nil {
+		return nil
+	}
+
+	value := bkt.Get(liveAuctionsKeyName())
+	if value == nil {
+		return nil
+	}
+
+	if _, err := sotah.NewMiniAuctionListFromEncoded(value); err != nil {
+		return fmt.Errorf("mini-auction-list does not decode: %s", err.Error())
+	}
+
+	return nil
+}
+
+func checkPricelistHistoryDatabase(tx *bolt.Tx) error {
+	return tx.ForEach(func(name []byte, bkt *bolt.Bucket) error {
+		if isSchemaBucketName(name) {
+			return nil
+		}
+
+		if _, err := itemIdFromPricelistHistoryBucketName(name); err != nil {
+			return fmt.Errorf("invalid bucket %s: %s", name, err.Error())
+		}
+
+		value := bkt.Get(pricelistHistoryKeyName())
+		if value == nil {
+			return nil
+		}
+
+		if _, err := sotah.NewPriceHistoryFromBytes(value); err != nil {
+			return fmt.Errorf("price-history in bucket %s does not decode: %s", name, err.Error())
+		}
+
+		return nil
+	})
+}
+
+func checkPricelistHistoryRollupDatabase(tx *bolt.Tx) error {
+	return tx.ForEach(func(name []byte, bkt *bolt.Bucket) error {
+		if isSchemaBucketName(name) {
+			return nil
+		}
+
+		if bytes.Equal(name, pricelistHistoryRolledUpBucketName()) {
+			return bkt.ForEach(func(k, _ []byte) error {
+				if len(k) != len(pricelistHistoryRolledUpKeyName(0)) {
+					return fmt.Errorf("invalid rolled-up key %x", k)
+				}
+
+				return nil
+			})
+		}
+
+		if _, err := itemIdFromPricelistHistoryRollupBucketName(name); err != nil {
+			return fmt.Errorf("invalid bucket %s: %s", name, err.Error())
+		}
+
+		value := bkt.Get(pricelistHistoryRollupKeyName())
+		if value == nil {
+			return nil
+		}
+
+		if _, err := sotah.NewPriceRollupHistoryFromBytes(value); err != nil {
+			return fmt.Errorf("price-rollup-history in bucket %s does not decode: %s", name, err.Error())
+		}
+
+		return nil
+	})
+}
+
+func checkRegionPricelistHistoryDatabase(tx *bolt.Tx) error {
+	return tx.ForEach(func(name []byte, bkt *bolt.Bucket) error {
+		if isSchemaBucketName(name) {
+			return nil
+		}
+
+		if _, err := itemIdFromRegionPricelistHistoryBucketName(name); err != nil {
+			return fmt.Errorf("invalid bucket %s: %s", name, err.Error())
+		}
+
+		value := bkt.Get(regionPricelistHistoryKeyName())
+		if value == nil {
+			return nil
+		}
+
+		if _, err := sotah.NewRegionPriceHistoryFromBytes(value); err != nil {
+			return fmt.Errorf("region-price-history in bucket %s does not decode: %s", name, err.Error())
+		}
+
+		return nil
+	})
+}
+
+// checkDatabaseFile returns why the file is broken, recovering from the panics bolt raises on corrupt pages
+func checkDatabaseFile(dbFilepath string, kind schemakinds.SchemaKind, check databaseChecker) (reason string) {
+	defer func() {
+		if r := recover(); r != nil {
+			reason = fmt.Sprintf("corrupt database: %v", r)
+		}
+	}()
+
+	db, err := bolt.Open(dbFilepath, 0600, &bolt.Options{ReadOnly: true, Timeout: checkOpenTimeout})
+	if err != nil {
+		return fmt.Sprintf("failed to open: %s", err.Error())
+	}
+	defer db.Close()
+
+	err = db.View(func(tx *bolt.Tx) error {
+		// draining every error, since the checker goroutine reads from the transaction until it closes the channel
+		var checkErr error
+		for err := range tx.Check() {
+			if checkErr == nil {
+				checkErr = fmt.Errorf("corrupt database: %s", err.Error())
+			}
+		}
+		if checkErr != nil {
+			return checkErr
+		}
+
+		if err := checkSchemaVersion(tx, kind); err != nil {
+			return err
+		}
+
+		return check(tx)
+	})
+	if err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+func checkDatabase(dbFilepath string, kind schemakinds.SchemaKind, check databaseChecker) *BrokenDatabase {
+	reason := checkDatabaseFile(dbFilepath, kind, check)
+	if reason == "" {
+		return nil
+	}
+
+	return &BrokenDatabase{Kind: kind, Filepath: dbFilepath, Reason: reason}
+}
+
+func checkLiveAuctionsDatabases(databaseDir string, result *CheckResult) error {
+	liveAuctionsDir := fmt.Sprintf("%s/live-auctions", databaseDir)
+	if _, err := os.Stat(liveAuctionsDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	regionNames, err := listDirNames(liveAuctionsDir)
+	if err != nil {
+		return err
+	}
+
+	for _, regionName := range regionNames {
+		regionDir := fmt.Sprintf("%s/%s", liveAuctionsDir, regionName)
+		fileInfos, err := ioutil.ReadDir(regionDir)
+		if err != nil {
+			return err
+		}
+
+		for _, fileInfo := range fileInfos {
+			dbFilepath := fmt.Sprintf("%s/%s", regionDir, fileInfo.Name())
+			if fileInfo.IsDir() || !strings.HasSuffix(fileInfo.Name(), ".db") {
+				result.add(&BrokenDatabase{
+					Kind:     schemakinds.LiveAuctions,
+					Filepath: dbFilepath,
+					Reason:   "unexpected filename, expected <realm>.db",
+				})
+
+				continue
+			}
+
+			result.add(checkDatabase(dbFilepath, schemakinds.LiveAuctions, checkLiveAuctionsDatabase))
+		}
+	}
+
+	return nil
+}
+
+// every entry of a realm dir must be a <unix-timestamp>.db shard, otherwise Paths fails the whole boot
+func checkPricelistHistoryDatabases(databaseDir string, result *CheckResult) error {
+	historiesDir := fmt.Sprintf("%s/pricelist-histories", databaseDir)
+	if _, err := os.Stat(historiesDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	regionNames, err := listDirNames(historiesDir)
+	if err != nil {
+		return err
+	}
+
+	for _, regionName := range regionNames {
+		realmSlugs, err := listDirNames(fmt.Sprintf("%s/%s", historiesDir, regionName))
+		if err != nil {
+			return err
+		}
+
+		for _, realmSlug := range realmSlugs {
+			realmDir := fmt.Sprintf("%s/%s/%s", historiesDir, regionName, realmSlug)
+			fileInfos, err := ioutil.ReadDir(realmDir)
+			if err != nil {
+				return err
+			}
+
+			for _, fileInfo := range fileInfos {
+				dbFilepath := fmt.Sprintf("%s/%s", realmDir, fileInfo.Name())
+				targetTimestamp, err := strconv.Atoi(strings.TrimSuffix(fileInfo.Name(), ".db"))
+				if err != nil || fileInfo.IsDir() || !strings.HasSuffix(fileInfo.Name(), ".db") {
+					result.add(&BrokenDatabase{
+						Kind:     schemakinds.PricelistHistories,
+						Filepath: dbFilepath,
+						Reason:   "unexpected filename, expected <unix-timestamp>.db",
+					})
+
+					continue
+				}
+
+				broken := checkDatabase(dbFilepath, schemakinds.PricelistHistories, checkPricelistHistoryDatabase)
+				if broken != nil {
+					broken.RegionName = blizzard.RegionName(regionName)
+					broken.RealmSlug = blizzard.RealmSlug(realmSlug)
+					broken.TargetTimestamp = sotah.UnixTimestamp(targetTimestamp)
+				}
+				result.add(broken)
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkPricelistHistoryRollupDatabases(databaseDir string, result *CheckResult) error {
+	rollupsDir := fmt.Sprintf("%s/pricelist-history-rollups", databaseDir)
+	if _, err := os.Stat(rollupsDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	regionNames, err := listDirNames(rollupsDir)
+	if err != nil {
+		return err
+	}
+
+	for _, regionName := range regionNames {
+		realmSlugs, err := listDirNames(fmt.Sprintf("%s/%s", rollupsDir, regionName))
+		if err != nil {
+			return err
+		}
+
+		for _, realmSlug := range realmSlugs {
+			dbFilepath := pricelistHistoryRollupDatabaseFilePath(
+				databaseDir,
+				blizzard.RegionName(regionName),
+				blizzard.RealmSlug(realmSlug),
+			)
+			for _, existingFilepath := range existingFilePaths(dbFilepath) {
+				result.add(checkDatabase(
+					existingFilepath,
+					schemakinds.PricelistHistoryRollups,
+					checkPricelistHistoryRollupDatabase,
+				))
+			}
+		}
+	}
+
+	return nil
+}
+
+// every entry of a region dir must be a <unix-timestamp>.db shard, otherwise Paths fails the whole boot
+func checkRegionPricelistHistoryDatabases(databaseDir string, result *CheckResult) error {
+	regionHistoriesDir := fmt.Sprintf("%s/region-pricelist-histories", databaseDir)
+	if _, err := os.Stat(regionHistoriesDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	regionNames, err := listDirNames(regionHistoriesDir)
+	if err != nil {
+		return err
+	}
+
+	for _, regionName := range regionNames {
+		regionDir := regionPricelistHistoryDatabaseDir(databaseDir, blizzard.RegionName(regionName))
+		fileInfos, err := ioutil.ReadDir(regionDir)
+		if err != nil {
+			return err
+		}
+
+		for _, fileInfo := range fileInfos {
+			dbFilepath := fmt.Sprintf("%s/%s", regionDir, fileInfo.Name())
+			targetTimestamp, err := strconv.Atoi(strings.TrimSuffix(fileInfo.Name(), ".db"))
+			if err != nil || fileInfo.IsDir() || !strings.HasSuffix(fileInfo.Name(), ".db") {
+				result.add(&BrokenDatabase{
+					Kind:     schemakinds.RegionPricelistHistories,
+					Filepath: dbFilepath,
+					Reason:   "unexpected filename, expected <unix-timestamp>.db",
+				})
+
+				continue
+			}
+
+			broken := checkDatabase(
+				dbFilepath,
+				schemakinds.RegionPricelistHistories,
+				checkRegionPricelistHistoryDatabase,
+			)
+			if broken != nil {
+				broken.RegionName = blizzard.RegionName(regionName)
+				broken.TargetTimestamp = sotah.UnixTimestamp(targetTimestamp)
+			}
+			result.add(broken)
+		}
+	}
+
+	return nil
+}
+
+// CheckDatabases verifies every database under the database dir, which requires that no other process has them open
+func CheckDatabases(databaseDir string) (CheckResult, error) {
+	result := CheckResult{}
+
+	itemsFilepath, err := itemsDatabasePath(databaseDir)
+	if err != nil {
+		return CheckResult{}, err
+	}
+	for _, dbFilepath := range existingFilePaths(itemsFilepath) {
+		result.add(checkDatabase(dbFilepath, schemakinds.Items, checkItemsDatabase))
+	}
+
+	for _, dbFilepath := range existingFilePaths(metaDatabaseFilePath(databaseDir)) {
+		result.add(checkDatabase(dbFilepath, schemakinds.Meta, checkMetaDatabase))
+	}
+
+	for _, dbFilepath := range existingFilePaths(apiKeysDatabaseFilePath(databaseDir)) {
+		result.add(checkDatabase(dbFilepath, schemakinds.APIKeys, checkAPIKeysDatabase))
+	}
+
+	for _, dbFilepath := range existingFilePaths(recipesDatabasePath(databaseDir)) {
+		result.add(checkDatabase(dbFilepath, schemakinds.Recipes, checkRecipesDatabase))
+	}
+
+	if err := checkLiveAuctionsDatabases(databaseDir, &result); err != nil {
+		return CheckResult{}, err
+	}
+
+	if err := checkPricelistHistoryDatabases(databaseDir, &result); err != nil {
+		return CheckResult{}, err
+	}
+
+	if err := checkPricelistHistoryRollupDatabases(databaseDir, &result); err != nil {
+		return CheckResult{}, err
+	}
+
+	if err := checkRegionPricelistHistoryDatabases(databaseDir, &result); err != nil {
+		return CheckResult{}, err
+	}
+
+	return result, nil
+}
+
+// quarantined files are moved outside of the database dirs, keeping their relative path
+func quarantineFilePath(databaseDir string, dbFilepath string) (string, error) {
+	relativePath, err := filepath.Rel(databaseDir, dbFilepath)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.HasPrefix(relativePath, "..") {
+		return "", errors.New("database file is outside of the database dir")
+	}
+
+	return fmt.Sprintf("%s/quarantine/%s", databaseDir, relativePath), nil
+}
+
+// QuarantineDatabase moves the broken file out of the way so the owning process can boot and recreate it
+func QuarantineDatabase(databaseDir string, broken BrokenDatabase) (string, error) {
+	destination, err := quarantineFilePath(databaseDir, broken.Filepath)
+	if err != nil {
+		return "", err
+	}
+
+	if err := util.EnsureDirExists(filepath.Dir(destination)); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(broken.Filepath, destination); err != nil {
+		return "", err
+	}
+
+	logging.WithFields(broken.ToLogrusFields()).WithField("quarantine", destination).Info("Quarantined database")
+
+	return destination, nil
+}
+
+// ResyncPricelistHistoryDatabase writes a fresh shard from the encoded item-prices of the store
+func ResyncPricelistHistoryDatabase(
+	databaseDir string,
+	regionName blizzard.RegionName,
+	realmSlug blizzard.RealmSlug,
+	targetTimestamp sotah.UnixTimestamp,
+	data map[blizzard.ItemID][]byte,
+) error {
+	realmDir := fmt.Sprintf("%s/pricelist-histories/%s/%s", databaseDir, regionName, realmSlug)
+	if err := util.EnsureDirExists(realmDir); err != nil {
+		return err
+	}
+
+	phdBase, err := newPricelistHistoryDatabase(
+		pricelistHistoryDatabaseFilePath(databaseDir, regionName, realmSlug, targetTimestamp),
+		time.Unix(int64(targetTimestamp), 0),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := phdBase.persistEncodedItemPrices(data); err != nil {
+		phdBase.db.Close()
+
+		return err
+	}
+
+	if err := phdBase.db.Close(); err != nil {
+		return err
+	}
+
+	return clearPricelistHistoryRollup(databaseDir, regionName, realmSlug, targetTimestamp)
+}
+
+// clearPricelistHistoryRollup flags a past day as needing to be rolled up again, where the current day is never flagged
+func clearPricelistHistoryRollup(
+	databaseDir string,
+	regionName blizzard.RegionName,
+	realmSlug blizzard.RealmSlug,
+	targetTimestamp sotah.UnixTimestamp,
+) error {
+	if targetTimestamp >= sotah.UnixTimestamp(sotah.NormalizeTargetDate(time.Now()).Unix()) {
+		return nil
+	}
+
+	dbFilepath := pricelistHistoryRollupDatabaseFilePath(databaseDir, regionName, realmSlug)
+	if _, err := os.Stat(dbFilepath); os.IsNotExist(err) {
+		return nil
+	}
+
+	prdBase, err := newPricelistHistoryRollupDatabase(dbFilepath)
+	if err != nil {
+		return err
+	}
+
+	if err := prdBase.clearRolledUp(targetTimestamp); err != nil {
+		prdBase.db.Close()
+
+		return err
+	}
+
+	return prdBase.db.Close()
+}
+
+// PricelistHistoryRealms gathers the realms that have a pricelist-histories dir, for resyncing without a meta database
+func PricelistHistoryRealms(databaseDir string) (map[blizzard.RegionName]sotah.Realms, error) {
+	out := map[blizzard.RegionName]sotah.Realms{}
+
+	historiesDir := fmt.Sprintf("%s/pricelist-histories", databaseDir)
+	if _, err := os.Stat(historiesDir); os.IsNotExist(err) {
+		return out, nil
+	}
+
+	regionNames, err := listDirNames(historiesDir)
+	if err != nil {
+		return map[blizzard.RegionName]sotah.Realms{}, err
+	}
+
+	for _, regionName := range regionNames {
+		realmSlugs, err := listDirNames(fmt.Sprintf("%s/%s", historiesDir, regionName))
+		if err != nil {
+			return map[blizzard.RegionName]sotah.Realms{}, err
+		}
+
+		for _, realmSlug := range realmSlugs {
+			out[blizzard.RegionName(regionName)] = append(
+				out[blizzard.RegionName(regionName)],
+				sotah.NewSkeletonRealm(blizzard.RegionName(regionName), blizzard.RealmSlug(realmSlug)),
+			)
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,210 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCorruptDatabase overwrites the file with bytes that bolt does not recognise
+func writeCorruptDatabase(dbFilepath string) error {
+	return ioutil.WriteFile(dbFilepath, bytes.Repeat([]byte("garbage!"), 1024), 0600)
+}
+
+func TestCheckDatabasesQuarantineResync(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "db-check")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	yesterday := sotah.NormalizeTargetDate(time.Now()).AddDate(0, 0, -1)
+	yesterdayTimestamp := sotah.UnixTimestamp(yesterday.Unix())
+	prices := sotah.Prices{MedianBuyoutPer: 10, Volume: 1}
+
+	// a rolled-up shard with a recorded version
+	phdBases, ok := newTestPricelistHistoryDatabases(t, dbDir)
+	if !ok {
+		return
+	}
+	if !assert.Nil(t, persistTestItemPrices(phdBases, yesterday, prices)) {
+		phdBases.Close()
+
+		return
+	}
+	if !assert.Nil(t, phdBases.buildRollups()) {
+		phdBases.Close()
+
+		return
+	}
+	phdBases.Close()
+
+	metaDatabase, err := NewMetaDatabase(dbDir)
+	if !assert.Nil(t, err) {
+		return
+	}
+	versions := sotah.PricelistHistoryVersions{}.Insert(testRegionName, testRealmSlug, yesterdayTimestamp, "v1")
+	if !assert.Nil(t, metaDatabase.SetPricelistHistoriesVersions(versions)) {
+		metaDatabase.Close()
+
+		return
+	}
+	metaDatabase.Close()
+
+	if !assert.Nil(t, RebuildRegionPricelistHistoryDatabase(dbDir, testRegionName, yesterdayTimestamp)) {
+		return
+	}
+
+	result, err := CheckDatabases(dbDir)
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.Empty(t, result.Broken) {
+		return
+	}
+
+	shardFilepath := pricelistHistoryDatabaseFilePath(dbDir, testRegionName, testRealmSlug, yesterdayTimestamp)
+	if !assert.Nil(t, writeCorruptDatabase(shardFilepath)) {
+		return
+	}
+
+	result, err = CheckDatabases(dbDir)
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.Len(t, result.Broken, 1) {
+		return
+	}
+
+	broken := result.Broken[0]
+	assert.Equal(t, schemakinds.PricelistHistories, broken.Kind)
+	assert.True(t, broken.IsPricelistHistoryShard())
+	assert.Equal(t, testRegionName, broken.RegionName)
+	assert.Equal(t, testRealmSlug, broken.RealmSlug)
+	assert.Equal(t, yesterdayTimestamp, broken.TargetTimestamp)
+
+	destination, err := QuarantineDatabase(dbDir, broken)
+	if !assert.Nil(t, err) {
+		return
+	}
+	_, err = os.Stat(destination)
+	assert.Nil(t, err)
+	_, err = os.Stat(shardFilepath)
+	assert.True(t, os.IsNotExist(err))
+
+	// the version is dropped with the shard, so that a sync would re-download it
+	metaDatabase, err = NewMetaDatabase(dbDir)
+	if !assert.Nil(t, err) {
+		return
+	}
+	err = metaDatabase.DeletePricelistHistoriesVersion(testRegionName, testRealmSlug, yesterdayTimestamp)
+	if !assert.Nil(t, err) {
+		metaDatabase.Close()
+
+		return
+	}
+	hasVersion, err := metaDatabase.HasPricelistHistoriesVersion(testRegionName, testRealmSlug, yesterdayTimestamp)
+	metaDatabase.Close()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.False(t, hasVersion)
+
+	encoded, err := sotah.PriceHistory{yesterdayTimestamp: prices}.EncodeForPersistence()
+	if !assert.Nil(t, err) {
+		return
+	}
+	err = ResyncPricelistHistoryDatabase(
+		dbDir,
+		testRegionName,
+		testRealmSlug,
+		yesterdayTimestamp,
+		map[blizzard.ItemID][]byte{testItemId: encoded},
+	)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	result, err = CheckDatabases(dbDir)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Empty(t, result.Broken)
+
+	// the resynced day is rolled up again on the next build
+	prdBase, err := newPricelistHistoryRollupDatabase(
+		pricelistHistoryRollupDatabaseFilePath(dbDir, testRegionName, testRealmSlug),
+	)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer prdBase.db.Close()
+
+	rolledUp, err := prdBase.isRolledUp(yesterdayTimestamp)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.False(t, rolledUp)
+}
+
+func TestCheckDatabasesRollupsAndRegionShards(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "db-check")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	rollupFilepath := pricelistHistoryRollupDatabaseFilePath(dbDir, testRegionName, testRealmSlug)
+	if !assert.Nil(t, util.EnsureDirExists(pricelistHistoryRollupDatabaseDir(dbDir, testRegionName, testRealmSlug))) {
+		return
+	}
+	if !assert.Nil(t, writeCorruptDatabase(rollupFilepath)) {
+		return
+	}
+
+	regionDir := regionPricelistHistoryDatabaseDir(dbDir, testRegionName)
+	if !assert.Nil(t, util.EnsureDirExists(regionDir)) {
+		return
+	}
+	regionFilepath := regionPricelistHistoryDatabaseFilePath(dbDir, testRegionName, sotah.UnixTimestamp(100))
+	if !assert.Nil(t, writeCorruptDatabase(regionFilepath)) {
+		return
+	}
+	strayFilepath := fmt.Sprintf("%s/stray.txt", regionDir)
+	if !assert.Nil(t, ioutil.WriteFile(strayFilepath, []byte("stray"), 0600)) {
+		return
+	}
+
+	result, err := CheckDatabases(dbDir)
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.Len(t, result.Broken, 3) {
+		return
+	}
+
+	brokenByFilepath := map[string]BrokenDatabase{}
+	for _, broken := range result.Broken {
+		brokenByFilepath[broken.Filepath] = broken
+	}
+
+	assert.Equal(t, schemakinds.PricelistHistoryRollups, brokenByFilepath[rollupFilepath].Kind)
+
+	regionBroken := brokenByFilepath[regionFilepath]
+	assert.Equal(t, schemakinds.RegionPricelistHistories, regionBroken.Kind)
+	assert.True(t, regionBroken.IsRegionPricelistHistoryShard())
+	assert.Equal(t, sotah.UnixTimestamp(100), regionBroken.TargetTimestamp)
+
+	strayBroken := brokenByFilepath[strayFilepath]
+	assert.Equal(t, schemakinds.RegionPricelistHistories, strayBroken.Kind)
+	assert.False(t, strayBroken.IsRegionPricelistHistoryShard())
+}
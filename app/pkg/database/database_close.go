@@ -0,0 +1,137 @@
+package database
+
+import (
+	"github.com/boltdb/bolt"
+)
+
+// closeDatabase tolerates handles that were never opened, such as those of a zero-value database
+func closeDatabase(db *bolt.DB) error {
+	if db == nil {
+		return nil
+	}
+
+	return db.Close()
+}
+
+func (idBase ItemsDatabase) Close() error {
+	return closeDatabase(idBase.db)
+}
+
+func (d MetaDatabase) Close() error {
+	return closeDatabase(d.db)
+}
+
+func (rBase RecipesDatabase) Close() error {
+	return closeDatabase(rBase.db)
+}
+
+func (d APIKeysDatabase) Close() error {
+	return closeDatabase(d.db)
+}
+
+// Close closes every realm database, returning the first error after attempting all of them
+func (ladBases LiveAuctionsDatabases) Close() error {
+	var firstErr error
+	for _, realmDatabases := range ladBases {
+		for _, ladBase := range realmDatabases {
+			if err := closeDatabase(ladBase.db); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// Close closes every shard and rollup database, returning the first error after attempting all of them
+func (phdBases PricelistHistoryDatabases) Close() error {
+	// states other than pricelist-histories never open shards
+	if phdBases.mu == nil {
+		return nil
+	}
+
+	phdBases.mu.Lock()
+	defer phdBases.mu.Unlock()
+
+	var firstErr error
+	for _, realmShards := range phdBases.Databases {
+		for _, shards := range realmShards {
+			for _, phdBase := range shards {
+				if err := closeDatabase(phdBase.db); err != nil && firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+	}
+
+	for _, realmRollupDatabases := range phdBases.rollupDatabases {
+		for _, prdBase := range realmRollupDatabases {
+			if err := closeDatabase(prdBase.db); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// Close closes every region shard, returning the first error after attempting all of them
+func (rphdBases RegionPricelistHistoryDatabases) Close() error {
+	// states other than prod-pricelist-histories never open region shards
+	if rphdBases.mu == nil {
+		return nil
+	}
+
+	rphdBases.mu.Lock()
+	defer rphdBases.mu.Unlock()
+
+	var firstErr error
+	for _, shards := range rphdBases.Databases {
+		for _, rphdBase := range shards {
+			if err := closeDatabase(rphdBase.db); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// pingDatabase opens a read transaction, which fails once the database was closed
+func pingDatabase(db *bolt.DB) error {
+	if db == nil {
+		return nil
+	}
+
+	return db.View(func(tx *bolt.Tx) error {
+		return nil
+	})
+}
+
+func (idBase ItemsDatabase) Ping() error {
+	return pingDatabase(idBase.db)
+}
+
+func (d MetaDatabase) Ping() error {
+	return pingDatabase(d.db)
+}
+
+func (rBase RecipesDatabase) Ping() error {
+	return pingDatabase(rBase.db)
+}
+
+func (d APIKeysDatabase) Ping() error {
+	return pingDatabase(d.db)
+}
+
+func (ladBases LiveAuctionsDatabases) Ping() error {
+	for _, realmDatabases := range ladBases {
+		for _, ladBase := range realmDatabases {
+			if err := pingDatabase(ladBase.db); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
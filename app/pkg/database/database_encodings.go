@@ -0,0 +1,73 @@
+package database
+
+import (
+	"github.com/boltdb/bolt"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+)
+
+// encodingMigrator - re-encodes a legacy value into the binary encoding
+type encodingMigrator func(value []byte) ([]byte, error)
+
+func migratePriceHistoryEncoding(value []byte) ([]byte, error) {
+	pHistory, err := sotah.NewPriceHistoryFromBytes(value)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return pHistory.EncodeBinary(), nil
+}
+
+func migrateMiniAuctionListEncoding(value []byte) ([]byte, error) {
+	maList, err := sotah.NewMiniAuctionListFromEncoded(value)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return maList.EncodeBinary(), nil
+}
+
+// migrateBucketEncodings rewrites every legacy value under the matching buckets within the transaction
+func migrateBucketEncodings(
+	tx *bolt.Tx,
+	matchesBucket func(name []byte) bool,
+	key []byte,
+	migrate encodingMigrator,
+) (int, error) {
+	totalMigrated := 0
+	err := tx.ForEach(func(name []byte, bkt *bolt.Bucket) error {
+		if !matchesBucket(name) {
+			return nil
+		}
+
+		value := bkt.Get(key)
+		if value == nil || sotah.IsBinaryEncoded(value) {
+			return nil
+		}
+
+		migratedValue, err := migrate(value)
+		if err != nil {
+			return err
+		}
+
+		if err := bkt.Put(key, migratedValue); err != nil {
+			return err
+		}
+		totalMigrated++
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return totalMigrated, nil
+}
+
+/*
+MigrateEncodings rewrites the pricelist-history shards and live-auctions databases into the binary encoding by way of
+their schema migrations, returning how many files were rewritten, which requires that no other process has them open
+*/
+func MigrateEncodings(databaseDir string) (int, error) {
+	return MigrateSchemas(databaseDir, schemakinds.PricelistHistories, schemakinds.LiveAuctions)
+}
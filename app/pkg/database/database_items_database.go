@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"strconv"
@@ -8,6 +9,7 @@ import (
 	"github.com/boltdb/bolt"
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/util"
@@ -26,6 +28,12 @@ func NewItemsDatabase(dbDir string) (ItemsDatabase, error) {
 		return ItemsDatabase{}, err
 	}
 
+	if err := ensureSchemaVersion(db, schemakinds.Items); err != nil {
+		db.Close()
+
+		return ItemsDatabase{}, err
+	}
+
 	return ItemsDatabase{db}, nil
 }
 
@@ -109,7 +117,7 @@ func (idBase ItemsDatabase) GetIdNormalizedNameMap() (sotah.ItemIdNameMap, error
 	return out, nil
 }
 
-func (idBase ItemsDatabase) FindItems(itemIds []blizzard.ItemID) (sotah.ItemsMap, error) {
+func (idBase ItemsDatabase) FindItems(ctx context.Context, itemIds []blizzard.ItemID) (sotah.ItemsMap, error) {
 	out := sotah.ItemsMap{}
 	err := idBase.db.View(func(tx *bolt.Tx) error {
 		bkt := tx.Bucket(databaseItemsBucketName())
@@ -118,6 +126,10 @@ func (idBase ItemsDatabase) FindItems(itemIds []blizzard.ItemID) (sotah.ItemsMap
 		}
 
 		for _, id := range itemIds {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
 			value := bkt.Get(itemsKeyName(id))
 			if value == nil {
 				continue
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"encoding/json"
 	"sort"
 
@@ -92,12 +93,18 @@ func (r QueryItemsResponse) EncodeForDelivery() ([]byte, error) {
 	return json.Marshal(r)
 }
 
-func (idBase ItemsDatabase) QueryItems(req QueryItemsRequest) (QueryItemsResponse, codes.Code, error) {
+func (idBase ItemsDatabase) QueryItems(
+	ctx context.Context,
+	req QueryItemsRequest,
+) (QueryItemsResponse, codes.Code, error) {
 	// gathering items
 	idNormalizedNameMap, err := idBase.GetIdNormalizedNameMap()
 	if err != nil {
 		return QueryItemsResponse{}, codes.GenericError, err
 	}
+	if err := ctx.Err(); err != nil {
+		return QueryItemsResponse{}, codes.GenericError, err
+	}
 
 	// reformatting into query-items-items
 	queryItems := NewQueryItemsItems(idNormalizedNameMap)
@@ -4,6 +4,7 @@ import (
 	"github.com/boltdb/bolt"
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 )
@@ -15,6 +16,12 @@ func newLiveAuctionsDatabase(dirPath string, rea sotah.Realm) (liveAuctionsDatab
 		return liveAuctionsDatabase{}, err
 	}
 
+	if err := ensureSchemaVersion(db, schemakinds.LiveAuctions); err != nil {
+		db.Close()
+
+		return liveAuctionsDatabase{}, err
+	}
+
 	return liveAuctionsDatabase{db, rea}, nil
 }
 
@@ -29,12 +36,9 @@ func (ladBase liveAuctionsDatabase) persistMiniAuctionList(maList sotah.MiniAuct
 		"mini-auctions-list": len(maList),
 	}).Debug("Persisting mini-auction-list")
 
-	encodedData, err := maList.EncodeForDatabase()
-	if err != nil {
-		return err
-	}
+	encodedData := maList.EncodeBinary()
 
-	err = ladBase.db.Update(func(tx *bolt.Tx) error {
+	err := ladBase.db.Update(func(tx *bolt.Tx) error {
 		bkt, err := tx.CreateBucketIfNotExists(liveAuctionsBucketName())
 		if err != nil {
 			return err
@@ -93,7 +97,7 @@ func (ladBase liveAuctionsDatabase) GetMiniAuctionList() (sotah.MiniAuctionList,
 		}
 
 		var err error
-		out, err = sotah.NewMiniAuctionListFromGzipped(bkt.Get(liveAuctionsKeyName()))
+		out, err = sotah.NewMiniAuctionListFromEncoded(bkt.Get(liveAuctionsKeyName()))
 		if err != nil {
 			return err
 		}
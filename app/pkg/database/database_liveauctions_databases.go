@@ -1,9 +1,11 @@
 package database
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"os"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -11,6 +13,7 @@ import (
 	"github.com/sotah-inc/server/app/pkg/database/codes"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/sotah/retentionkinds"
 	"github.com/sotah-inc/server/app/pkg/sotah/sortdirections"
 	"github.com/sotah-inc/server/app/pkg/sotah/sortkinds"
 	"github.com/sotah-inc/server/app/pkg/util"
@@ -165,7 +168,9 @@ func (job LiveAuctionsLoadEncodedDataOutJob) ToLogrusFields() logrus.Fields {
 	}
 }
 
+// LoadEncodedData traces each job as a child of the context, which is otherwise not used
 func (ladBases LiveAuctionsDatabases) LoadEncodedData(
+	ctx context.Context,
 	in chan LiveAuctionsLoadEncodedDataInJob,
 ) chan LiveAuctionsLoadEncodedDataOutJob {
 	// establishing channels
@@ -177,7 +182,11 @@ func (ladBases LiveAuctionsDatabases) LoadEncodedData(
 			// resolving the live-auctions database and gathering current Stats
 			ladBase := ladBases[job.RegionName][job.RealmSlug]
 
-			if err := ladBase.persistEncodedData(job.EncodedData); err != nil {
+			_, span := startLoadSpan(ctx, "database load live-auctions", job.RegionName, job.RealmSlug)
+			err := ladBase.persistEncodedData(job.EncodedData)
+			span.SetError(err)
+			span.End()
+			if err != nil {
 				logging.WithFields(logrus.Fields{
 					"error":  err.Error(),
 					"region": job.RegionName,
@@ -248,6 +257,57 @@ func (ladBases LiveAuctionsDatabases) GetStats(realms sotah.Realms) chan GetStat
 	return out
 }
 
+/*
+Prune clears the live-auctions of realms that were not written to since the retention limit, so that realms which
+stopped updating do not keep serving stale auctions
+*/
+func (ladBases LiveAuctionsDatabases) Prune(retention sotah.RetentionConfig) error {
+	for regionName, realmDatabases := range ladBases {
+		limit := retention.Limit(regionName, retentionkinds.LiveAuctions)
+
+		for realmSlug, ladBase := range realmDatabases {
+			dbPath := ladBase.db.Path()
+			fileInfo, err := os.Stat(dbPath)
+			if err != nil {
+				return err
+			}
+
+			if fileInfo.ModTime().After(limit) {
+				continue
+			}
+
+			if retention.DryRun {
+				logging.WithFields(logrus.Fields{
+					"region":        regionName,
+					"realm":         realmSlug,
+					"last-modified": fileInfo.ModTime().Unix(),
+				}).Info("Dry-run: would clear stale live-auctions")
+
+				continue
+			}
+
+			if err := ladBase.persistMiniAuctionList(sotah.MiniAuctionList{}); err != nil {
+				logging.WithFields(logrus.Fields{
+					"error":    err.Error(),
+					"region":   regionName,
+					"realm":    realmSlug,
+					"database": dbPath,
+				}).Error("Failed to clear stale live-auctions")
+
+				return err
+			}
+
+			logging.WithFields(logrus.Fields{
+				"region":        regionName,
+				"realm":         realmSlug,
+				"last-modified": fileInfo.ModTime().Unix(),
+			}).Info("Cleared stale live-auctions")
+		}
+	}
+
+	return nil
+}
+
 func NewQueryRequest(data []byte) (QueryAuctionsRequest, error) {
 	ar := &QueryAuctionsRequest{}
 	err := json.Unmarshal(data, &ar)
@@ -290,6 +350,7 @@ func (qr QueryAuctionsResponse) EncodeForDelivery() (string, error) {
 }
 
 func (ladBases LiveAuctionsDatabases) QueryAuctions(
+	ctx context.Context,
 	qr QueryAuctionsRequest,
 ) (QueryAuctionsResponse, codes.Code, error) {
 	regionLadBases, ok := ladBases[qr.RegionName]
@@ -311,10 +372,17 @@ func (ladBases LiveAuctionsDatabases) QueryAuctions(
 		return QueryAuctionsResponse{}, codes.UserError, errors.New("page must be <= 1000")
 	}
 
+	if err := ctx.Err(); err != nil {
+		return QueryAuctionsResponse{}, codes.GenericError, err
+	}
+
 	maList, err := realmLadbase.GetMiniAuctionList()
 	if err != nil {
 		return QueryAuctionsResponse{}, codes.GenericError, err
 	}
+	if err := ctx.Err(); err != nil {
+		return QueryAuctionsResponse{}, codes.GenericError, err
+	}
 
 	// initial response format
 	aResponse := QueryAuctionsResponse{Total: -1, TotalCount: -1, AuctionList: maList}
@@ -333,6 +401,10 @@ func (ladBases LiveAuctionsDatabases) QueryAuctions(
 	// calculating the total-count for review
 	totalCount := 0
 	for _, mAuction := range maList {
+		if err := ctx.Err(); err != nil {
+			return QueryAuctionsResponse{}, codes.GenericError, err
+		}
+
 		totalCount += len(mAuction.AucList)
 	}
 	aResponse.TotalCount = totalCount
@@ -370,6 +442,25 @@ type GetPricelistRequest struct {
 	ItemIds    blizzard.ItemIds    `json:"item_ids"`
 }
 
+func NewGetPricelistResponseFromEncoded(data string) (GetPricelistResponse, error) {
+	base64Decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return GetPricelistResponse{}, err
+	}
+
+	gzipDecoded, err := util.GzipDecode(base64Decoded)
+	if err != nil {
+		return GetPricelistResponse{}, err
+	}
+
+	var out GetPricelistResponse
+	if err := json.Unmarshal(gzipDecoded, &out); err != nil {
+		return GetPricelistResponse{}, err
+	}
+
+	return out, nil
+}
+
 type GetPricelistResponse struct {
 	Pricelist sotah.ItemPrices `json:"price_list"`
 }
@@ -389,6 +480,7 @@ func (plResponse GetPricelistResponse) EncodeForDelivery() (string, error) {
 }
 
 func (ladBases LiveAuctionsDatabases) GetPricelist(
+	ctx context.Context,
 	plRequest GetPricelistRequest,
 ) (GetPricelistResponse, codes.Code, error) {
 	regionLadBases, ok := ladBases[plRequest.RegionName]
@@ -401,14 +493,25 @@ func (ladBases LiveAuctionsDatabases) GetPricelist(
 		return GetPricelistResponse{}, codes.UserError, errors.New("invalid realm")
 	}
 
+	if err := ctx.Err(); err != nil {
+		return GetPricelistResponse{}, codes.GenericError, err
+	}
+
 	maList, err := ladBase.GetMiniAuctionList()
 	if err != nil {
 		return GetPricelistResponse{}, codes.GenericError, err
 	}
+	if err := ctx.Err(); err != nil {
+		return GetPricelistResponse{}, codes.GenericError, err
+	}
 
 	iPrices := sotah.NewItemPrices(maList)
 	responseItemPrices := sotah.ItemPrices{}
 	for _, itemId := range plRequest.ItemIds {
+		if err := ctx.Err(); err != nil {
+			return GetPricelistResponse{}, codes.GenericError, err
+		}
+
 		if iPrice, ok := iPrices[itemId]; ok {
 			responseItemPrices[itemId] = iPrice
 
@@ -456,6 +559,7 @@ func (plResponse QueryOwnersByItemsResponse) EncodeForDelivery() (string, error)
 }
 
 func (ladBases LiveAuctionsDatabases) QueryOwnersByItems(
+	ctx context.Context,
 	req QueryOwnersByItemsRequest,
 ) (QueryOwnersByItemsResponse, codes.Code, error) {
 	regionLadBases, ok := ladBases[req.RegionName]
@@ -468,10 +572,17 @@ func (ladBases LiveAuctionsDatabases) QueryOwnersByItems(
 		return QueryOwnersByItemsResponse{}, codes.UserError, errors.New("invalid realm")
 	}
 
+	if err := ctx.Err(); err != nil {
+		return QueryOwnersByItemsResponse{}, codes.GenericError, err
+	}
+
 	maList, err := ladBase.GetMiniAuctionList()
 	if err != nil {
 		return QueryOwnersByItemsResponse{}, codes.GenericError, err
 	}
+	if err := ctx.Err(); err != nil {
+		return QueryOwnersByItemsResponse{}, codes.GenericError, err
+	}
 
 	iMap := sotah.NewItemIdsMap(req.Items)
 	result := QueryOwnersByItemsResponse{
@@ -480,6 +591,10 @@ func (ladBases LiveAuctionsDatabases) QueryOwnersByItems(
 		TotalVolume: 0,
 	}
 	for _, mAuction := range maList {
+		if err := ctx.Err(); err != nil {
+			return QueryOwnersByItemsResponse{}, codes.GenericError, err
+		}
+
 		if _, ok := iMap[mAuction.ItemID]; !ok {
 			continue
 		}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"sort"
@@ -80,7 +81,10 @@ func (r QueryOwnersResponse) EncodeForDelivery() ([]byte, error) {
 	return json.Marshal(r)
 }
 
-func (ladBases LiveAuctionsDatabases) QueryOwners(qr QueryOwnersRequest) (QueryOwnersResponse, codes.Code, error) {
+func (ladBases LiveAuctionsDatabases) QueryOwners(
+	ctx context.Context,
+	qr QueryOwnersRequest,
+) (QueryOwnersResponse, codes.Code, error) {
 	regionLadBases, ok := ladBases[qr.RegionName]
 	if !ok {
 		return QueryOwnersResponse{}, codes.UserError, errors.New("invalid region")
@@ -95,6 +99,9 @@ func (ladBases LiveAuctionsDatabases) QueryOwners(qr QueryOwnersRequest) (QueryO
 	if err != nil {
 		return QueryOwnersResponse{}, codes.GenericError, err
 	}
+	if err := ctx.Err(); err != nil {
+		return QueryOwnersResponse{}, codes.GenericError, err
+	}
 
 	// resolving owners from auctions
 	owners, err := sotah.NewOwnersFromAuctions(maList)
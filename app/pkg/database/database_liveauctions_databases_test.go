@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database/codes"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveAuctionsDatabasesCancelled(t *testing.T) {
+	// the shard is never opened, so reading from it would panic were the context not checked first
+	ladBases := LiveAuctionsDatabases{
+		testRegionName: map[blizzard.RealmSlug]liveAuctionsDatabase{testRealmSlug: {}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, code, err := ladBases.QueryAuctions(ctx, QueryAuctionsRequest{
+		RegionName: testRegionName,
+		RealmSlug:  testRealmSlug,
+		Count:      10,
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, codes.GenericError, code)
+
+	_, code, err = ladBases.GetPricelist(ctx, GetPricelistRequest{
+		RegionName: testRegionName,
+		RealmSlug:  testRealmSlug,
+		ItemIds:    blizzard.ItemIds{testItemId},
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, codes.GenericError, code)
+
+	_, code, err = ladBases.QueryOwnersByItems(ctx, QueryOwnersByItemsRequest{
+		RegionName: testRegionName,
+		RealmSlug:  testRealmSlug,
+		Items:      blizzard.ItemIds{testItemId},
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, codes.GenericError, code)
+}
+
+func TestLiveAuctionsDatabasesPrune(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "live-auctions")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	staleRealm := sotah.NewSkeletonRealm(testRegionName, "stale")
+	freshRealm := sotah.NewSkeletonRealm(testRegionName, testRealmSlug)
+	statuses := sotah.Statuses{testRegionName: sotah.Status{Realms: sotah.Realms{staleRealm, freshRealm}}}
+	if !assert.Nil(t, util.EnsureDirExists(fmt.Sprintf("%s/live-auctions/%s", dbDir, testRegionName))) {
+		return
+	}
+
+	ladBases, err := NewLiveAuctionsDatabases(dbDir, statuses)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer ladBases.Close()
+
+	maList := sotah.NewMiniAuctionListFromMiniAuctions(sotah.NewMiniAuctions(blizzard.Auctions{
+		Auctions: []blizzard.Auction{{Auc: 1, Item: testItemId, Owner: "owner", Buyout: 10, Quantity: 1}},
+	}))
+	for _, realmDatabase := range ladBases[testRegionName] {
+		if !assert.Nil(t, realmDatabase.persistMiniAuctionList(maList)) {
+			return
+		}
+	}
+
+	// the stale realm was last written to before the retention limit
+	lastModified := time.Now().AddDate(0, 0, -30)
+	stalePath := ladBases[testRegionName][staleRealm.Slug].db.Path()
+	if !assert.Nil(t, os.Chtimes(stalePath, lastModified, lastModified)) {
+		return
+	}
+
+	if !assert.Nil(t, ladBases.Prune(sotah.RetentionConfig{})) {
+		return
+	}
+
+	staleList, err := ladBases[testRegionName][staleRealm.Slug].GetMiniAuctionList()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Empty(t, staleList)
+
+	freshList, err := ladBases[testRegionName][freshRealm.Slug].GetMiniAuctionList()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, freshList, 1)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/boltdb/bolt"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 )
 
@@ -17,6 +18,12 @@ func NewMetaDatabase(dbDir string) (MetaDatabase, error) {
 		return MetaDatabase{}, err
 	}
 
+	if err := ensureSchemaVersion(db, schemakinds.Meta); err != nil {
+		db.Close()
+
+		return MetaDatabase{}, err
+	}
+
 	return MetaDatabase{db}, nil
 }
 
@@ -51,6 +58,10 @@ func (d MetaDatabase) HasPricelistHistoriesVersion(
 	out := false
 	err := d.db.View(func(tx *bolt.Tx) error {
 		bkt := tx.Bucket(metaBucketName(regionName, realmSlug))
+		if bkt == nil {
+			return nil
+		}
+
 		v := bkt.Get(metaPricelistHistoryVersionKeyName(targetTimestamp))
 		if v == nil {
 			out = false
@@ -92,6 +103,22 @@ func (d MetaDatabase) GetPricelistHistoriesVersion(
 	return out, nil
 }
 
+// DeletePricelistHistoriesVersion forgets the version of the shard, so that syncing downloads it again
+func (d MetaDatabase) DeletePricelistHistoriesVersion(
+	regionName blizzard.RegionName,
+	realmSlug blizzard.RealmSlug,
+	targetTimestamp sotah.UnixTimestamp,
+) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(metaBucketName(regionName, realmSlug))
+		if bkt == nil {
+			return nil
+		}
+
+		return bkt.Delete(metaPricelistHistoryVersionKeyName(targetTimestamp))
+	})
+}
+
 func (d MetaDatabase) SetPricelistHistoriesVersions(versions sotah.PricelistHistoryVersions) error {
 	err := d.db.Update(func(tx *bolt.Tx) error {
 		for regionName, realmVersions := range versions {
@@ -3,6 +3,8 @@ package database
 import (
 	"encoding/binary"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/sotah"
@@ -21,6 +23,15 @@ func pricelistHistoryBucketName(ID blizzard.ItemID) []byte {
 	return []byte(fmt.Sprintf("item-prices/%d", ID))
 }
 
+func itemIdFromPricelistHistoryBucketName(bucketName []byte) (blizzard.ItemID, error) {
+	unparsedItemId, err := strconv.Atoi(strings.TrimPrefix(string(bucketName), "item-prices/"))
+	if err != nil {
+		return blizzard.ItemID(0), err
+	}
+
+	return blizzard.ItemID(unparsedItemId), nil
+}
+
 // db
 func pricelistHistoryDatabaseFilePath(
 	dirPath string,
@@ -6,6 +6,7 @@ import (
 	"github.com/boltdb/bolt"
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/util"
@@ -17,6 +18,12 @@ func newPricelistHistoryDatabase(dbFilepath string, targetDate time.Time) (Price
 		return PricelistHistoryDatabase{}, err
 	}
 
+	if err := ensureSchemaVersion(db, schemakinds.PricelistHistories); err != nil {
+		db.Close()
+
+		return PricelistHistoryDatabase{}, err
+	}
+
 	return PricelistHistoryDatabase{db, targetDate}, nil
 }
 
@@ -90,6 +97,42 @@ func (phdBase PricelistHistoryDatabase) getItemPriceHistory(itemID blizzard.Item
 	return out, nil
 }
 
+func (phdBase PricelistHistoryDatabase) getAllItemPriceHistories() (sotah.ItemPriceHistories, error) {
+	out := sotah.ItemPriceHistories{}
+
+	err := phdBase.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, bkt *bolt.Bucket) error {
+			if isSchemaBucketName(name) {
+				return nil
+			}
+
+			itemId, err := itemIdFromPricelistHistoryBucketName(name)
+			if err != nil {
+				return err
+			}
+
+			value := bkt.Get(pricelistHistoryKeyName())
+			if value == nil {
+				return nil
+			}
+
+			pHistory, err := sotah.NewPriceHistoryFromBytes(value)
+			if err != nil {
+				return err
+			}
+
+			out[itemId] = pHistory
+
+			return nil
+		})
+	})
+	if err != nil {
+		return sotah.ItemPriceHistories{}, err
+	}
+
+	return out, nil
+}
+
 func (phdBase PricelistHistoryDatabase) persistItemPrices(targetTime time.Time, iPrices sotah.ItemPrices) error {
 	targetTimestamp := sotah.UnixTimestamp(targetTime.Unix())
 
@@ -124,12 +167,7 @@ func (phdBase PricelistHistoryDatabase) persistItemPrices(targetTime time.Time,
 				return err
 			}
 
-			encodedValue, err := pHistory.EncodeForPersistence()
-			if err != nil {
-				return err
-			}
-
-			if err := bkt.Put(pricelistHistoryKeyName(), encodedValue); err != nil {
+			if err := bkt.Put(pricelistHistoryKeyName(), pHistory.EncodeBinary()); err != nil {
 				return err
 			}
 		}
@@ -171,3 +209,41 @@ func (phdBase PricelistHistoryDatabase) persistEncodedItemPrices(data map[blizza
 
 	return nil
 }
+
+// replaceItemPriceHistories - drops every item-price-history in the shard before writing the given ones
+func (phdBase PricelistHistoryDatabase) replaceItemPriceHistories(ipHistories sotah.ItemPriceHistories) error {
+	return phdBase.db.Update(func(tx *bolt.Tx) error {
+		bucketNames := [][]byte{}
+		err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if isSchemaBucketName(name) {
+				return nil
+			}
+
+			bucketNames = append(bucketNames, append([]byte{}, name...))
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, name := range bucketNames {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+
+		for itemId, pHistory := range ipHistories {
+			bkt, err := tx.CreateBucket(pricelistHistoryBucketName(itemId))
+			if err != nil {
+				return err
+			}
+
+			if err := bkt.Put(pricelistHistoryKeyName(), pHistory.EncodeBinary()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
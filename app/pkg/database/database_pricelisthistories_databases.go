@@ -1,37 +1,63 @@
 package database
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/database/codes"
+	"github.com/sotah-inc/server/app/pkg/health"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/sotah/resolutions"
+	"github.com/sotah-inc/server/app/pkg/sotah/retentionkinds"
 	"github.com/sotah-inc/server/app/pkg/util"
 )
 
-func NewPricelistHistoryDatabases(dirPath string, statuses sotah.Statuses) (PricelistHistoryDatabases, error) {
+func NewPricelistHistoryDatabases(
+	dirPath string,
+	statuses sotah.Statuses,
+	retention sotah.RetentionConfig,
+) (PricelistHistoryDatabases, error) {
 	if len(dirPath) == 0 {
 		return PricelistHistoryDatabases{}, errors.New("dir-path cannot be blank")
 	}
 
 	phdBases := PricelistHistoryDatabases{
-		databaseDir: dirPath,
-		Databases:   regionRealmDatabaseShards{},
+		databaseDir:     dirPath,
+		Databases:       regionRealmDatabaseShards{},
+		rollupDatabases: regionRealmRollupDatabases{},
+		retention:       retention,
+		mu:              &sync.RWMutex{},
 	}
 
 	for regionName, regionStatuses := range statuses {
 		phdBases.Databases[regionName] = realmDatabaseShards{}
+		phdBases.rollupDatabases[regionName] = map[blizzard.RealmSlug]PricelistHistoryRollupDatabase{}
 
 		for _, rea := range regionStatuses.Realms {
 			phdBases.Databases[regionName][rea.Slug] = PricelistHistoryDatabaseShards{}
 
+			rollupDir := pricelistHistoryRollupDatabaseDir(dirPath, regionName, rea.Slug)
+			if err := util.EnsureDirExists(rollupDir); err != nil {
+				return PricelistHistoryDatabases{}, err
+			}
+
+			prdBase, err := newPricelistHistoryRollupDatabase(
+				pricelistHistoryRollupDatabaseFilePath(dirPath, regionName, rea.Slug),
+			)
+			if err != nil {
+				return PricelistHistoryDatabases{}, err
+			}
+			phdBases.rollupDatabases[regionName][rea.Slug] = prdBase
+
 			dbPathPairs, err := Paths(fmt.Sprintf("%s/pricelist-histories/%s/%s", dirPath, regionName, rea.Slug))
 			if err != nil {
 				return PricelistHistoryDatabases{}, err
@@ -51,9 +77,89 @@ func NewPricelistHistoryDatabases(dirPath string, statuses sotah.Statuses) (Pric
 	return phdBases, nil
 }
 
+type regionRealmRollupDatabases map[blizzard.RegionName]map[blizzard.RealmSlug]PricelistHistoryRollupDatabase
+
+/*
+PricelistHistoryDatabases - realm shards are opened while loading, so the shard maps are guarded by mu against the
+computer, the rollup-builder and the pruner walking them
+*/
 type PricelistHistoryDatabases struct {
-	databaseDir string
-	Databases   regionRealmDatabaseShards
+	databaseDir     string
+	Databases       regionRealmDatabaseShards
+	rollupDatabases regionRealmRollupDatabases
+	retention       sotah.RetentionConfig
+
+	mu *sync.RWMutex
+}
+
+// regionShards returns a copy of the region's realm shards, so that they may be walked without holding the lock
+func (phdBases PricelistHistoryDatabases) regionShards(regionName blizzard.RegionName) (realmDatabaseShards, bool) {
+	phdBases.mu.RLock()
+	defer phdBases.mu.RUnlock()
+
+	realmShards, ok := phdBases.Databases[regionName]
+	if !ok {
+		return realmDatabaseShards{}, false
+	}
+
+	out := make(realmDatabaseShards, len(realmShards))
+	for realmSlug, shards := range realmShards {
+		out[realmSlug] = make(PricelistHistoryDatabaseShards, len(shards))
+		for targetTimestamp, phdBase := range shards {
+			out[realmSlug][targetTimestamp] = phdBase
+		}
+	}
+
+	return out, true
+}
+
+// shards returns a copy of every realm shard, so that they may be walked without holding the lock
+func (phdBases PricelistHistoryDatabases) shards() regionRealmDatabaseShards {
+	phdBases.mu.RLock()
+	defer phdBases.mu.RUnlock()
+
+	out := make(regionRealmDatabaseShards, len(phdBases.Databases))
+	for regionName, realmShards := range phdBases.Databases {
+		out[regionName] = make(realmDatabaseShards, len(realmShards))
+		for realmSlug, shards := range realmShards {
+			out[regionName][realmSlug] = make(PricelistHistoryDatabaseShards, len(shards))
+			for targetTimestamp, phdBase := range shards {
+				out[regionName][realmSlug][targetTimestamp] = phdBase
+			}
+		}
+	}
+
+	return out
+}
+
+func (phdBases PricelistHistoryDatabases) RetentionLimit(regionName blizzard.RegionName) time.Time {
+	return phdBases.retention.Limit(regionName, retentionkinds.PricelistHistoryShards)
+}
+
+// DailyRollupRetentionLimit - daily rollups before this are dropped, leaving only the weekly rollups
+func (phdBases PricelistHistoryDatabases) DailyRollupRetentionLimit(regionName blizzard.RegionName) time.Time {
+	return phdBases.retention.Limit(regionName, retentionkinds.DailyRollups)
+}
+
+/*
+invalidateRollup clears the rolled-up flag of a complete day that was written to again, so that the rollup-builder
+rolls it up again with the late points included
+*/
+func (phdBases PricelistHistoryDatabases) invalidateRollup(
+	regionName blizzard.RegionName,
+	realmSlug blizzard.RealmSlug,
+	targetTimestamp sotah.UnixTimestamp,
+) error {
+	if targetTimestamp >= sotah.UnixTimestamp(sotah.NormalizeTargetDate(time.Now()).Unix()) {
+		return nil
+	}
+
+	prdBase, ok := phdBases.rollupDatabases[regionName][realmSlug]
+	if !ok {
+		return nil
+	}
+
+	return prdBase.clearRolledUp(targetTimestamp)
 }
 
 func (phdBases PricelistHistoryDatabases) resolveDatabaseFromLoadInJob(
@@ -62,7 +168,18 @@ func (phdBases PricelistHistoryDatabases) resolveDatabaseFromLoadInJob(
 	normalizedTargetDate := sotah.NormalizeTargetDate(job.TargetTime)
 	normalizedTargetTimestamp := sotah.UnixTimestamp(normalizedTargetDate.Unix())
 
+	phdBases.mu.RLock()
 	phdBase, ok := phdBases.Databases[job.Realm.Region.Name][job.Realm.Slug][normalizedTargetTimestamp]
+	phdBases.mu.RUnlock()
+	if ok {
+		return phdBase, nil
+	}
+
+	phdBases.mu.Lock()
+	defer phdBases.mu.Unlock()
+
+	// checking again, since the shard may have been opened while waiting on the lock
+	phdBase, ok = phdBases.Databases[job.Realm.Region.Name][job.Realm.Slug][normalizedTargetTimestamp]
 	if ok {
 		return phdBase, nil
 	}
@@ -122,7 +239,15 @@ func (phdBases PricelistHistoryDatabases) Load(in chan LoadInJob) chan pricelist
 			}
 
 			iPrices := sotah.NewItemPrices(sotah.NewMiniAuctionListFromMiniAuctions(sotah.NewMiniAuctions(job.Auctions)))
-			if err := phdBase.persistItemPrices(job.TargetTime, iPrices); err != nil {
+			err = phdBase.persistItemPrices(job.TargetTime, iPrices)
+			if err == nil {
+				err = phdBases.invalidateRollup(
+					job.Realm.Region.Name,
+					job.Realm.Slug,
+					sotah.UnixTimestamp(sotah.NormalizeTargetDate(job.TargetTime).Unix()),
+				)
+			}
+			if err != nil {
 				logging.WithFields(logrus.Fields{
 					"error":  err.Error(),
 					"region": job.Realm.Region.Name,
@@ -153,22 +278,48 @@ func (phdBases PricelistHistoryDatabases) Load(in chan LoadInJob) chan pricelist
 	return out
 }
 
+// Prune drops the shards past retention once they are rolled up, as the pruner does on each tick
+func (phdBases PricelistHistoryDatabases) Prune() error {
+	return phdBases.pruneDatabases()
+}
+
 func (phdBases PricelistHistoryDatabases) pruneDatabases() error {
-	earliestUnixTimestamp := RetentionLimit().Unix()
-	logging.WithField("limit", earliestUnixTimestamp).Info("Checking for databases to prune")
-	for rName, realmDatabases := range phdBases.Databases {
+	for rName, realmDatabases := range phdBases.shards() {
+		earliestUnixTimestamp := phdBases.RetentionLimit(rName).Unix()
+		logging.WithFields(logrus.Fields{
+			"region": rName,
+			"limit":  earliestUnixTimestamp,
+		}).Info("Checking for databases to prune")
+
 		for rSlug, databaseShards := range realmDatabases {
 			for unixTimestamp, phdBase := range databaseShards {
 				if int64(unixTimestamp) > earliestUnixTimestamp {
 					continue
 				}
 
+				// rolling the day up before dropping it, so that its prices are still served from the rollups
+				if err := phdBases.rollUpShard(rName, rSlug, unixTimestamp, phdBase); err != nil {
+					return err
+				}
+
+				if phdBases.retention.DryRun {
+					logging.WithFields(logrus.Fields{
+						"region":   rName,
+						"realm":    rSlug,
+						"filepath": phdBase.db.Path(),
+					}).Info("Dry-run: would delete database file")
+
+					continue
+				}
+
 				logging.WithFields(logrus.Fields{
 					"region":             rName,
 					"realm":              rSlug,
 					"database-timestamp": unixTimestamp,
 				}).Debug("Removing database from shard map")
+				phdBases.mu.Lock()
 				delete(phdBases.Databases[rName][rSlug], unixTimestamp)
+				phdBases.mu.Unlock()
 
 				dbPath := phdBase.db.Path()
 
@@ -208,16 +359,23 @@ func (phdBases PricelistHistoryDatabases) pruneDatabases() error {
 	return nil
 }
 
-func (phdBases PricelistHistoryDatabases) StartPruner(stopChan sotah.WorkerStopChan) sotah.WorkerStopChan {
+// PrunerInterval - how often the pruners drop databases past retention
+const PrunerInterval = 20 * time.Minute
+
+func (phdBases PricelistHistoryDatabases) StartPruner(
+	stopChan sotah.WorkerStopChan,
+	heartbeat health.Heartbeat,
+) sotah.WorkerStopChan {
 	onStop := make(sotah.WorkerStopChan)
 	go func() {
-		ticker := time.NewTicker(20 * time.Minute)
+		ticker := time.NewTicker(PrunerInterval)
 
 		logging.Info("Starting pruner")
 	outer:
 		for {
 			select {
 			case <-ticker.C:
+				heartbeat.Beat()
 				if err := phdBases.pruneDatabases(); err != nil {
 					logging.WithField("error", err.Error()).Error("Failed to prune databases")
 
@@ -236,10 +394,121 @@ func (phdBases PricelistHistoryDatabases) StartPruner(stopChan sotah.WorkerStopC
 	return onStop
 }
 
+func (phdBases PricelistHistoryDatabases) buildRollups() error {
+	currentDayTimestamp := sotah.UnixTimestamp(sotah.NormalizeTargetDate(time.Now()).Unix())
+
+	logging.Info("Checking for databases to roll up")
+	for rName, realmDatabases := range phdBases.shards() {
+		for rSlug, databaseShards := range realmDatabases {
+			for unixTimestamp, phdBase := range databaseShards {
+				// only rolling up days that are complete
+				if unixTimestamp >= currentDayTimestamp {
+					continue
+				}
+
+				if err := phdBases.rollUpShard(rName, rSlug, unixTimestamp, phdBase); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// rollUpShard rolls up the day of a shard, unless it was already rolled up
+func (phdBases PricelistHistoryDatabases) rollUpShard(
+	rName blizzard.RegionName,
+	rSlug blizzard.RealmSlug,
+	unixTimestamp sotah.UnixTimestamp,
+	phdBase PricelistHistoryDatabase,
+) error {
+	prdBase, ok := phdBases.rollupDatabases[rName][rSlug]
+	if !ok {
+		return nil
+	}
+
+	rolledUp, err := prdBase.isRolledUp(unixTimestamp)
+	if err != nil {
+		return err
+	}
+	if rolledUp {
+		return nil
+	}
+
+	ipHistories, err := phdBase.getAllItemPriceHistories()
+	if err != nil {
+		return err
+	}
+
+	err = prdBase.persistRollups(phdBase.targetDate, ipHistories, phdBases.DailyRollupRetentionLimit(rName))
+	if err != nil {
+		return err
+	}
+
+	logging.WithFields(logrus.Fields{
+		"region":             rName,
+		"realm":              rSlug,
+		"database-timestamp": unixTimestamp,
+		"items":              len(ipHistories),
+	}).Info("Rolled up database")
+
+	return nil
+}
+
+// RollupBuilderInterval - how often the rollup-builder checks for complete days to roll up
+const RollupBuilderInterval = 1 * time.Hour
+
+func (phdBases PricelistHistoryDatabases) StartRollupBuilder(
+	stopChan sotah.WorkerStopChan,
+	heartbeat health.Heartbeat,
+) sotah.WorkerStopChan {
+	onStop := make(sotah.WorkerStopChan)
+	go func() {
+		ticker := time.NewTicker(RollupBuilderInterval)
+
+		logging.Info("Starting rollup-builder")
+		if err := phdBases.buildRollups(); err != nil {
+			logging.WithField("error", err.Error()).Error("Failed to build rollups")
+		}
+	outer:
+		for {
+			select {
+			case <-ticker.C:
+				heartbeat.Beat()
+				if err := phdBases.buildRollups(); err != nil {
+					logging.WithField("error", err.Error()).Error("Failed to build rollups")
+
+					continue
+				}
+			case <-stopChan:
+				ticker.Stop()
+
+				break outer
+			}
+		}
+
+		onStop <- struct{}{}
+	}()
+
+	return onStop
+}
+
 func (phdBases PricelistHistoryDatabases) resolveDatabaseFromLoadInEncodedJob(
 	job PricelistHistoryDatabaseEncodedLoadInJob,
 ) (PricelistHistoryDatabase, error) {
+	phdBases.mu.RLock()
 	phdBase, ok := phdBases.Databases[job.RegionName][job.RealmSlug][job.NormalizedTargetTimestamp]
+	phdBases.mu.RUnlock()
+	if ok {
+		return phdBase, nil
+	}
+
+	phdBases.mu.Lock()
+	defer phdBases.mu.Unlock()
+
+	// checking again, since the shard may have been opened while waiting on the lock
+	phdBase, ok = phdBases.Databases[job.RegionName][job.RealmSlug][job.NormalizedTargetTimestamp]
 	if ok {
 		return phdBase, nil
 	}
@@ -336,7 +605,9 @@ func (job PricelistHistoryDatabaseEncodedLoadOutJob) ToLogrusFields() logrus.Fie
 	}
 }
 
+// LoadEncoded traces each job as a child of the context, which is otherwise not used
 func (phdBases PricelistHistoryDatabases) LoadEncoded(
+	ctx context.Context,
 	in chan PricelistHistoryDatabaseEncodedLoadInJob,
 ) chan PricelistHistoryDatabaseEncodedLoadOutJob {
 	// establishing channels
@@ -345,8 +616,13 @@ func (phdBases PricelistHistoryDatabases) LoadEncoded(
 	// spinning up workers for receiving pre-encoded auctions and persisting them
 	worker := func() {
 		for job := range in {
+			_, span := startLoadSpan(ctx, "database load pricelist-histories", job.RegionName, job.RealmSlug)
+
 			phdBase, err := phdBases.resolveDatabaseFromLoadInEncodedJob(job)
 			if err != nil {
+				span.SetError(err)
+				span.End()
+
 				logging.WithFields(logrus.Fields{
 					"error":  err.Error(),
 					"region": job.RegionName,
@@ -363,7 +639,13 @@ func (phdBases PricelistHistoryDatabases) LoadEncoded(
 				continue
 			}
 
-			if err := phdBase.persistEncodedItemPrices(job.Data); err != nil {
+			err = phdBase.persistEncodedItemPrices(job.Data)
+			if err == nil {
+				err = phdBases.invalidateRollup(job.RegionName, job.RealmSlug, job.NormalizedTargetTimestamp)
+			}
+			span.SetError(err)
+			span.End()
+			if err != nil {
 				logging.WithFields(logrus.Fields{
 					"error":  err.Error(),
 					"region": job.RegionName,
@@ -415,8 +697,33 @@ type GetPricelistHistoryRequest struct {
 	UpperBounds int64               `json:"upper_bounds"`
 }
 
+func NewGetPricelistHistoryResponseFromEncoded(data string) (GetPricelistHistoryResponse, error) {
+	base64Decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return GetPricelistHistoryResponse{}, err
+	}
+
+	gzipDecoded, err := util.GzipDecode(base64Decoded)
+	if err != nil {
+		return GetPricelistHistoryResponse{}, err
+	}
+
+	var out GetPricelistHistoryResponse
+	if err := json.Unmarshal(gzipDecoded, &out); err != nil {
+		return GetPricelistHistoryResponse{}, err
+	}
+
+	return out, nil
+}
+
+/*
+GetPricelistHistoryResponse - where the request reaches past the hourly retention, the resolution is that of the
+rollups, and the history carries the rollups followed by the hourly points of the days that have yet to be pruned
+*/
 type GetPricelistHistoryResponse struct {
-	History sotah.ItemPriceHistories `json:"history"`
+	History    sotah.ItemPriceHistories       `json:"history"`
+	Rollups    sotah.ItemPriceRollupHistories `json:"rollups"`
+	Resolution resolutions.Resolution         `json:"resolution"`
 }
 
 func (res GetPricelistHistoryResponse) EncodeForDelivery() (string, error) {
@@ -434,9 +741,10 @@ func (res GetPricelistHistoryResponse) EncodeForDelivery() (string, error) {
 }
 
 func (phdBases PricelistHistoryDatabases) GetPricelistHistory(
+	ctx context.Context,
 	req GetPricelistHistoryRequest,
 ) (GetPricelistHistoryResponse, codes.Code, error) {
-	regionShards, ok := phdBases.Databases[req.RegionName]
+	regionShards, ok := phdBases.regionShards(req.RegionName)
 	if !ok {
 		return GetPricelistHistoryResponse{}, codes.UserError, errors.New("invalid region")
 	}
@@ -452,20 +760,99 @@ func (phdBases PricelistHistoryDatabases) GetPricelistHistory(
 	}).Info("Querying shards")
 
 	realm := sotah.NewSkeletonRealm(req.RegionName, req.RealmSlug)
+	upperBounds := time.Unix(req.UpperBounds, 0)
+
+	// serving from rollups where the lower bounds predates the hourly retention, and from the shards for the days that
+	// the rollups do not cover
+	res := GetPricelistHistoryResponse{
+		History:    sotah.ItemPriceHistories{},
+		Rollups:    sotah.ItemPriceRollupHistories{},
+		Resolution: resolutions.Hourly,
+	}
+	liveFrom := time.Unix(req.LowerBounds, 0)
+	if retentionLimit := phdBases.RetentionLimit(req.RegionName); liveFrom.Before(retentionLimit) {
+		resolution := resolutions.Weekly
+		if !liveFrom.Before(phdBases.DailyRollupRetentionLimit(req.RegionName)) {
+			resolution = resolutions.Daily
+		}
 
-	res := GetPricelistHistoryResponse{History: sotah.ItemPriceHistories{}}
-	for _, ID := range req.ItemIds {
-		plHistory, err := realmShards.GetPriceHistory(
-			realm,
-			ID,
-			time.Unix(req.LowerBounds, 0),
-			time.Unix(req.UpperBounds, 0),
+		liveFrom = rollupPeriodEnd(resolution, retentionLimit)
+
+		var (
+			code codes.Code
+			err  error
 		)
+		res, code, err = phdBases.getPricelistHistoryRollups(ctx, req, resolution, liveFrom)
+		if err != nil {
+			return GetPricelistHistoryResponse{}, code, err
+		}
+	}
+
+	for _, ID := range req.ItemIds {
+		if err := ctx.Err(); err != nil {
+			return GetPricelistHistoryResponse{}, codes.GenericError, err
+		}
+
+		plHistory, err := realmShards.GetPriceHistory(realm, ID, liveFrom, upperBounds)
+		if err != nil {
+			return GetPricelistHistoryResponse{}, codes.GenericError, err
+		}
+
+		pHistory, ok := res.History[ID]
+		if !ok {
+			pHistory = sotah.PriceHistory{}
+		}
+		for targetTimestamp, prices := range plHistory {
+			pHistory[targetTimestamp] = prices
+		}
+
+		res.History[ID] = pHistory
+	}
+
+	return res, codes.Ok, nil
+}
+
+func (phdBases PricelistHistoryDatabases) getPricelistHistoryRollups(
+	ctx context.Context,
+	req GetPricelistHistoryRequest,
+	resolution resolutions.Resolution,
+	coveredUntil time.Time,
+) (GetPricelistHistoryResponse, codes.Code, error) {
+	prdBase, ok := phdBases.rollupDatabases[req.RegionName][req.RealmSlug]
+	if !ok {
+		return GetPricelistHistoryResponse{}, codes.UserError, errors.New("invalid realm")
+	}
+
+	logging.WithFields(logrus.Fields{
+		"resolution": resolution,
+		"req":        fmt.Sprintf("+%v", req),
+	}).Info("Querying rollups")
+
+	res := GetPricelistHistoryResponse{
+		History:    sotah.ItemPriceHistories{},
+		Rollups:    sotah.ItemPriceRollupHistories{},
+		Resolution: resolution,
+	}
+	for _, ID := range req.ItemIds {
+		if err := ctx.Err(); err != nil {
+			return GetPricelistHistoryResponse{}, codes.GenericError, err
+		}
+
+		prHistory, err := prdBase.getPriceRollupHistory(resolution, ID)
 		if err != nil {
 			return GetPricelistHistoryResponse{}, codes.GenericError, err
 		}
 
-		res.History[ID] = plHistory
+		for targetTimestamp := range prHistory {
+			// leaving the days past the hourly retention to the shards
+			outOfBounds := int64(targetTimestamp) < req.LowerBounds || int64(targetTimestamp) > req.UpperBounds
+			if outOfBounds || int64(targetTimestamp) >= coveredUntil.Unix() {
+				delete(prHistory, targetTimestamp)
+			}
+		}
+
+		res.Rollups[ID] = prHistory
+		res.History[ID] = prHistory.ToPriceHistory()
 	}
 
 	return res, codes.Ok, nil
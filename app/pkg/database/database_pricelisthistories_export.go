@@ -0,0 +1,165 @@
+package database
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/export"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+)
+
+// shards are opened read-only, so an export fails fast rather than blocking on a running pricelist-histories process
+const exportOpenTimeout = 5 * time.Second
+
+func openReadOnlyPricelistHistoryDatabase(dbFilepath string, targetDate time.Time) (PricelistHistoryDatabase, error) {
+	db, err := bolt.Open(dbFilepath, 0600, &bolt.Options{ReadOnly: true, Timeout: exportOpenTimeout})
+	if err != nil {
+		return PricelistHistoryDatabase{}, err
+	}
+
+	return PricelistHistoryDatabase{db, targetDate}, nil
+}
+
+func (phdBase PricelistHistoryDatabase) getFilteredItemPriceHistories(
+	itemIds []blizzard.ItemID,
+) (sotah.ItemPriceHistories, error) {
+	if len(itemIds) == 0 {
+		return phdBase.getAllItemPriceHistories()
+	}
+
+	out := sotah.ItemPriceHistories{}
+	for job := range phdBase.getItemPriceHistories(itemIds) {
+		if job.err != nil {
+			return sotah.ItemPriceHistories{}, job.err
+		}
+
+		if len(job.history) == 0 {
+			continue
+		}
+
+		out[job.ItemID] = job.history
+	}
+
+	return out, nil
+}
+
+func listDirNames(dirPath string) ([]string, error) {
+	fileInfos, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return []string{}, err
+	}
+
+	out := []string{}
+	for _, fileInfo := range fileInfos {
+		if !fileInfo.IsDir() {
+			continue
+		}
+
+		out = append(out, fileInfo.Name())
+	}
+
+	return out, nil
+}
+
+// ExportPricelistHistories walks every shard on disk and writes each matching item-price as a flat row
+func ExportPricelistHistories(databaseDir string, filter export.Filter, w export.RowWriter) (int, error) {
+	historiesDir := fmt.Sprintf("%s/pricelist-histories", databaseDir)
+	regionNames, err := listDirNames(historiesDir)
+	if err != nil {
+		return 0, err
+	}
+
+	totalRows := 0
+	for _, regionName := range regionNames {
+		if !filter.MatchesRegion(blizzard.RegionName(regionName)) {
+			continue
+		}
+
+		regionDir := fmt.Sprintf("%s/%s", historiesDir, regionName)
+		realmSlugs, err := listDirNames(regionDir)
+		if err != nil {
+			return totalRows, err
+		}
+
+		for _, realmSlug := range realmSlugs {
+			if !filter.MatchesRealm(blizzard.RealmSlug(realmSlug)) {
+				continue
+			}
+
+			realmRows, err := exportRealmPricelistHistories(
+				fmt.Sprintf("%s/%s", regionDir, realmSlug),
+				blizzard.RegionName(regionName),
+				blizzard.RealmSlug(realmSlug),
+				filter,
+				w,
+			)
+			if err != nil {
+				return totalRows, err
+			}
+
+			logging.WithFields(logrus.Fields{
+				"region": regionName,
+				"realm":  realmSlug,
+				"rows":   realmRows,
+			}).Info("Exported realm pricelist-histories")
+
+			totalRows += realmRows
+		}
+	}
+
+	return totalRows, nil
+}
+
+func exportRealmPricelistHistories(
+	realmDir string,
+	regionName blizzard.RegionName,
+	realmSlug blizzard.RealmSlug,
+	filter export.Filter,
+	w export.RowWriter,
+) (int, error) {
+	dbPaths, err := Paths(realmDir)
+	if err != nil {
+		return 0, err
+	}
+
+	totalRows := 0
+	for _, dbPathPair := range dbPaths {
+		// each shard holds one day of history
+		if !filter.MatchesRange(dbPathPair.TargetTime, dbPathPair.TargetTime.Add(24*time.Hour)) {
+			continue
+		}
+
+		phdBase, err := openReadOnlyPricelistHistoryDatabase(dbPathPair.FullPath, dbPathPair.TargetTime)
+		if err != nil {
+			logging.WithFields(logrus.Fields{
+				"error":    err.Error(),
+				"pathname": dbPathPair.FullPath,
+			}).Error("Failed to open pricelist-history database read-only")
+
+			return totalRows, err
+		}
+
+		ipHistories, err := phdBase.getFilteredItemPriceHistories(filter.ItemIds)
+		if closeErr := phdBase.db.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return totalRows, err
+		}
+
+		for _, row := range export.NewRows(regionName, realmSlug, ipHistories, filter) {
+			if err := w.Write(row); err != nil {
+				return totalRows, err
+			}
+
+			totalRows++
+		}
+	}
+
+	return totalRows, nil
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database/codes"
+	"github.com/sotah-inc/server/app/pkg/forecast"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/util"
+)
+
+const (
+	defaultForecastDays = 7
+	maxForecastDays     = 14
+)
+
+func NewGetPricelistForecastRequest(data []byte) (GetPricelistForecastRequest, error) {
+	req := &GetPricelistForecastRequest{}
+	err := json.Unmarshal(data, &req)
+	if err != nil {
+		return GetPricelistForecastRequest{}, err
+	}
+
+	return *req, nil
+}
+
+type GetPricelistForecastRequest struct {
+	RegionName blizzard.RegionName `json:"region_name"`
+	RealmSlug  blizzard.RealmSlug  `json:"realm_slug"`
+	ItemIds    []blizzard.ItemID   `json:"item_ids"`
+	Days       int                 `json:"days"`
+}
+
+type GetPricelistForecastResponse struct {
+	Forecasts map[blizzard.ItemID]forecast.Forecast `json:"forecasts"`
+}
+
+func (res GetPricelistForecastResponse) EncodeForDelivery() (string, error) {
+	jsonEncoded, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+
+	gzipEncoded, err := util.GzipEncode(jsonEncoded)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(gzipEncoded), nil
+}
+
+func (phdBases PricelistHistoryDatabases) GetPricelistForecast(
+	ctx context.Context,
+	req GetPricelistForecastRequest,
+) (GetPricelistForecastResponse, codes.Code, error) {
+	days := req.Days
+	if days == 0 {
+		days = defaultForecastDays
+	}
+	if days < 0 || days > maxForecastDays {
+		return GetPricelistForecastResponse{}, codes.UserError, errors.New("days out of range")
+	}
+
+	regionShards, ok := phdBases.regionShards(req.RegionName)
+	if !ok {
+		return GetPricelistForecastResponse{}, codes.UserError, errors.New("invalid region")
+	}
+
+	realmShards, ok := regionShards[req.RealmSlug]
+	if !ok {
+		return GetPricelistForecastResponse{}, codes.UserError, errors.New("invalid realm")
+	}
+
+	realm := sotah.NewSkeletonRealm(req.RegionName, req.RealmSlug)
+
+	res := GetPricelistForecastResponse{Forecasts: map[blizzard.ItemID]forecast.Forecast{}}
+	for _, ID := range req.ItemIds {
+		if err := ctx.Err(); err != nil {
+			return GetPricelistForecastResponse{}, codes.GenericError, err
+		}
+
+		plHistory, err := realmShards.GetPriceHistory(
+			realm,
+			ID,
+			phdBases.RetentionLimit(req.RegionName),
+			time.Now(),
+		)
+		if err != nil {
+			return GetPricelistForecastResponse{}, codes.GenericError, err
+		}
+
+		res.Forecasts[ID] = forecast.NewForecast(plHistory, days)
+	}
+
+	return res, codes.Ok, nil
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/sotah/retentionkinds"
+	"github.com/sotah-inc/server/app/pkg/util"
+)
+
+/*
+RebuildPricelistHistoryDatabase overwrites the local shard of the realm for the day of the target-time, and rolls the
+day up again where it is complete
+*/
+func RebuildPricelistHistoryDatabase(
+	databaseDir string,
+	realm sotah.Realm,
+	targetTime time.Time,
+	ipHistories sotah.ItemPriceHistories,
+	retention sotah.RetentionConfig,
+) error {
+	normalizedTargetDate := sotah.NormalizeTargetDate(targetTime)
+
+	realmDir := fmt.Sprintf("%s/pricelist-histories/%s/%s", databaseDir, realm.Region.Name, realm.Slug)
+	if err := util.EnsureDirExists(realmDir); err != nil {
+		return err
+	}
+
+	phdBase, err := newPricelistHistoryDatabase(
+		pricelistHistoryDatabaseFilePath(
+			databaseDir,
+			realm.Region.Name,
+			realm.Slug,
+			sotah.UnixTimestamp(normalizedTargetDate.Unix()),
+		),
+		normalizedTargetDate,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := phdBase.replaceItemPriceHistories(ipHistories); err != nil {
+		phdBase.db.Close()
+
+		return err
+	}
+
+	if err := phdBase.db.Close(); err != nil {
+		return err
+	}
+
+	return rebuildPricelistHistoryRollups(databaseDir, realm, normalizedTargetDate, ipHistories, retention)
+}
+
+func rebuildPricelistHistoryRollups(
+	databaseDir string,
+	realm sotah.Realm,
+	normalizedTargetDate time.Time,
+	ipHistories sotah.ItemPriceHistories,
+	retention sotah.RetentionConfig,
+) error {
+	// the current day is rolled up by the rollup-builder once it is complete
+	if !normalizedTargetDate.Before(sotah.NormalizeTargetDate(time.Now())) {
+		return nil
+	}
+
+	// weekly rollups are recomputed from the daily rollups of the week, which are gone past the daily retention
+	dailyRetentionLimit := retention.Limit(realm.Region.Name, retentionkinds.DailyRollups)
+	if normalizedTargetDate.Before(dailyRetentionLimit) {
+		logging.WithFields(logrus.Fields{
+			"region":           realm.Region.Name,
+			"realm":            realm.Slug,
+			"target-timestamp": normalizedTargetDate.Unix(),
+		}).Warn("Day predates the daily rollup retention, leaving its rollups as they were")
+
+		return nil
+	}
+
+	rollupDir := pricelistHistoryRollupDatabaseDir(databaseDir, realm.Region.Name, realm.Slug)
+	if err := util.EnsureDirExists(rollupDir); err != nil {
+		return err
+	}
+
+	prdBase, err := newPricelistHistoryRollupDatabase(
+		pricelistHistoryRollupDatabaseFilePath(databaseDir, realm.Region.Name, realm.Slug),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := prdBase.persistRollups(normalizedTargetDate, ipHistories, dailyRetentionLimit); err != nil {
+		prdBase.db.Close()
+
+		return err
+	}
+
+	return prdBase.db.Close()
+}
+
+/*
+RebuildRegionPricelistHistoryDatabase recomputes the region aggregate of the day from the local shard of each realm in
+the region, opening one shard at a time
+*/
+func RebuildRegionPricelistHistoryDatabase(
+	databaseDir string,
+	regionName blizzard.RegionName,
+	targetTimestamp sotah.UnixTimestamp,
+) error {
+	realmDirs, err := ioutil.ReadDir(fmt.Sprintf("%s/pricelist-histories/%s", databaseDir, regionName))
+	if err != nil {
+		return err
+	}
+
+	realmHistories := []sotah.ItemPriceHistories{}
+	for _, realmDir := range realmDirs {
+		if !realmDir.IsDir() {
+			continue
+		}
+
+		dbPath := pricelistHistoryDatabaseFilePath(
+			databaseDir,
+			regionName,
+			blizzard.RealmSlug(realmDir.Name()),
+			targetTimestamp,
+		)
+		if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+			continue
+		}
+
+		phdBase, err := newPricelistHistoryDatabase(dbPath, time.Unix(int64(targetTimestamp), 0))
+		if err != nil {
+			return err
+		}
+
+		ipHistories, err := phdBase.getAllItemPriceHistories()
+		phdBase.db.Close()
+		if err != nil {
+			return err
+		}
+
+		realmHistories = append(realmHistories, ipHistories)
+	}
+
+	if err := util.EnsureDirExists(regionPricelistHistoryDatabaseDir(databaseDir, regionName)); err != nil {
+		return err
+	}
+
+	rphdBase, err := newRegionPricelistHistoryDatabase(
+		regionPricelistHistoryDatabaseFilePath(databaseDir, regionName, targetTimestamp),
+		time.Unix(int64(targetTimestamp), 0),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := rphdBase.persistItemRegionPriceHistories(sotah.NewItemRegionPriceHistories(realmHistories)); err != nil {
+		rphdBase.db.Close()
+
+		return err
+	}
+
+	return rphdBase.db.Close()
+}
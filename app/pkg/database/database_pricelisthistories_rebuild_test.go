@@ -0,0 +1,148 @@
+package database
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/sotah/resolutions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebuildPricelistHistoryDatabase(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "rebuild")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	realm := sotah.NewSkeletonRealm(testRegionName, testRealmSlug)
+	yesterday := sotah.NormalizeTargetDate(time.Now()).AddDate(0, 0, -1)
+	yesterdayTimestamp := sotah.UnixTimestamp(yesterday.Unix())
+
+	// a rollup built before the rebuild, which the rebuild replaces
+	for _, prices := range []sotah.Prices{{MedianBuyoutPer: 10, Volume: 1}, {MedianBuyoutPer: 20, Volume: 2}} {
+		err := RebuildPricelistHistoryDatabase(
+			dbDir,
+			realm,
+			yesterday,
+			sotah.ItemPriceHistories{testItemId: sotah.PriceHistory{yesterdayTimestamp: prices}},
+			sotah.RetentionConfig{},
+		)
+		if !assert.Nil(t, err) {
+			return
+		}
+	}
+
+	phdBases, ok := newTestPricelistHistoryDatabases(t, dbDir)
+	if !ok {
+		return
+	}
+	defer phdBases.Close()
+
+	pHistory, err := phdBases.Databases[testRegionName][testRealmSlug][yesterdayTimestamp].getItemPriceHistory(testItemId)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, sotah.PriceHistory{yesterdayTimestamp: {MedianBuyoutPer: 20, Volume: 2}}, pHistory)
+
+	prdBase := phdBases.rollupDatabases[testRegionName][testRealmSlug]
+	rolledUp, err := prdBase.isRolledUp(yesterdayTimestamp)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, rolledUp)
+
+	for _, resolution := range []resolutions.Resolution{resolutions.Daily, resolutions.Weekly} {
+		prHistory, err := prdBase.getPriceRollupHistory(resolution, testItemId)
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		for _, rollup := range prHistory {
+			assert.Equal(t, float64(20), rollup.MedianBuyoutPer)
+		}
+	}
+}
+
+func TestRebuildPricelistHistoryDatabaseToday(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "rebuild")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	today := sotah.NormalizeTargetDate(time.Now())
+	err = RebuildPricelistHistoryDatabase(
+		dbDir,
+		sotah.NewSkeletonRealm(testRegionName, testRealmSlug),
+		today,
+		sotah.ItemPriceHistories{
+			testItemId: sotah.PriceHistory{sotah.UnixTimestamp(today.Unix()): {MedianBuyoutPer: 10, Volume: 1}},
+		},
+		sotah.RetentionConfig{},
+	)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// the current day is left to the rollup-builder
+	_, err = os.Stat(pricelistHistoryRollupDatabaseFilePath(dbDir, testRegionName, testRealmSlug))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRebuildRegionPricelistHistoryDatabase(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "rebuild")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	today := sotah.NormalizeTargetDate(time.Now())
+	todayTimestamp := sotah.UnixTimestamp(today.Unix())
+	realmPrices := map[blizzard.RealmSlug]sotah.Prices{
+		"earthen-ring": {MedianBuyoutPer: 10, Volume: 1},
+		"sargeras":     {MedianBuyoutPer: 30, Volume: 3},
+	}
+	for realmSlug, prices := range realmPrices {
+		err := RebuildPricelistHistoryDatabase(
+			dbDir,
+			sotah.NewSkeletonRealm(testRegionName, realmSlug),
+			today,
+			sotah.ItemPriceHistories{testItemId: sotah.PriceHistory{todayTimestamp: prices}},
+			sotah.RetentionConfig{},
+		)
+		if !assert.Nil(t, err) {
+			return
+		}
+	}
+
+	if !assert.Nil(t, RebuildRegionPricelistHistoryDatabase(dbDir, testRegionName, todayTimestamp)) {
+		return
+	}
+
+	rphdBase, err := newRegionPricelistHistoryDatabase(
+		regionPricelistHistoryDatabaseFilePath(dbDir, testRegionName, todayTimestamp),
+		today,
+	)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer rphdBase.db.Close()
+
+	rpHistory, err := rphdBase.getItemRegionPriceHistory(testItemId)
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.Len(t, rpHistory, 1) {
+		return
+	}
+
+	for _, prices := range rpHistory {
+		assert.Equal(t, 2, prices.RealmCount)
+		assert.Equal(t, int64(4), prices.Volume)
+	}
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/sotah/resolutions"
+)
+
+// rollupPeriodEnd - the end of the rollup period of the resolution that holds the given time
+func rollupPeriodEnd(resolution resolutions.Resolution, targetTime time.Time) time.Time {
+	if resolution == resolutions.Weekly {
+		return sotah.NormalizeTargetWeek(targetTime).AddDate(0, 0, 7)
+	}
+
+	return sotah.NormalizeTargetDate(targetTime).AddDate(0, 0, 1)
+}
+
+// keying
+func pricelistHistoryRollupKeyName() []byte {
+	return pricelistHistoryKeyName()
+}
+
+func pricelistHistoryRolledUpKeyName(targetTimestamp sotah.UnixTimestamp) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(targetTimestamp))
+
+	return key
+}
+
+// bucketing
+func pricelistHistoryRollupBucketName(resolution resolutions.Resolution, ID blizzard.ItemID) []byte {
+	return []byte(fmt.Sprintf("%s/item-prices/%d", resolution, ID))
+}
+
+func itemIdFromPricelistHistoryRollupBucketName(bucketName []byte) (blizzard.ItemID, error) {
+	parts := strings.SplitN(string(bucketName), "/item-prices/", 2)
+	if len(parts) != 2 {
+		return blizzard.ItemID(0), fmt.Errorf("bucket %s is not a rollup bucket", bucketName)
+	}
+
+	switch resolutions.Resolution(parts[0]) {
+	case resolutions.Daily, resolutions.Weekly:
+	default:
+		return blizzard.ItemID(0), fmt.Errorf("unsupported rollup resolution %s", parts[0])
+	}
+
+	unparsedItemId, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return blizzard.ItemID(0), err
+	}
+
+	return blizzard.ItemID(unparsedItemId), nil
+}
+
+func pricelistHistoryRolledUpBucketName() []byte {
+	return []byte("rolled-up")
+}
+
+// db
+func pricelistHistoryRollupDatabaseDir(
+	dirPath string,
+	regionName blizzard.RegionName,
+	realmSlug blizzard.RealmSlug,
+) string {
+	return fmt.Sprintf("%s/pricelist-history-rollups/%s/%s", dirPath, regionName, realmSlug)
+}
+
+func pricelistHistoryRollupDatabaseFilePath(
+	dirPath string,
+	regionName blizzard.RegionName,
+	realmSlug blizzard.RealmSlug,
+) string {
+	return fmt.Sprintf("%s/rollups.db", pricelistHistoryRollupDatabaseDir(dirPath, regionName, realmSlug))
+}
@@ -0,0 +1,178 @@
+package database
+
+import (
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/sotah/resolutions"
+)
+
+func newPricelistHistoryRollupDatabase(dbFilepath string) (PricelistHistoryRollupDatabase, error) {
+	db, err := bolt.Open(dbFilepath, 0600, nil)
+	if err != nil {
+		return PricelistHistoryRollupDatabase{}, err
+	}
+
+	if err := ensureSchemaVersion(db, schemakinds.PricelistHistoryRollups); err != nil {
+		db.Close()
+
+		return PricelistHistoryRollupDatabase{}, err
+	}
+
+	return PricelistHistoryRollupDatabase{db}, nil
+}
+
+type PricelistHistoryRollupDatabase struct {
+	db *bolt.DB
+}
+
+// clearRolledUp flags the day as needing to be rolled up again, where its hourly points have changed since
+func (prdBase PricelistHistoryRollupDatabase) clearRolledUp(targetTimestamp sotah.UnixTimestamp) error {
+	return prdBase.db.Batch(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(pricelistHistoryRolledUpBucketName())
+		if bkt == nil {
+			return nil
+		}
+
+		return bkt.Delete(pricelistHistoryRolledUpKeyName(targetTimestamp))
+	})
+}
+
+func (prdBase PricelistHistoryRollupDatabase) isRolledUp(targetTimestamp sotah.UnixTimestamp) (bool, error) {
+	out := false
+	err := prdBase.db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(pricelistHistoryRolledUpBucketName())
+		if bkt == nil {
+			return nil
+		}
+
+		out = bkt.Get(pricelistHistoryRolledUpKeyName(targetTimestamp)) != nil
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return out, nil
+}
+
+func getPriceRollupHistory(
+	tx *bolt.Tx,
+	resolution resolutions.Resolution,
+	itemId blizzard.ItemID,
+) (sotah.PriceRollupHistory, error) {
+	bkt := tx.Bucket(pricelistHistoryRollupBucketName(resolution, itemId))
+	if bkt == nil {
+		return sotah.PriceRollupHistory{}, nil
+	}
+
+	value := bkt.Get(pricelistHistoryRollupKeyName())
+	if value == nil {
+		return sotah.PriceRollupHistory{}, nil
+	}
+
+	return sotah.NewPriceRollupHistoryFromBytes(value)
+}
+
+func putPriceRollupHistory(
+	tx *bolt.Tx,
+	resolution resolutions.Resolution,
+	itemId blizzard.ItemID,
+	prHistory sotah.PriceRollupHistory,
+) error {
+	bkt, err := tx.CreateBucketIfNotExists(pricelistHistoryRollupBucketName(resolution, itemId))
+	if err != nil {
+		return err
+	}
+
+	encodedValue, err := prHistory.EncodeForPersistence()
+	if err != nil {
+		return err
+	}
+
+	return bkt.Put(pricelistHistoryRollupKeyName(), encodedValue)
+}
+
+func (prdBase PricelistHistoryRollupDatabase) getPriceRollupHistory(
+	resolution resolutions.Resolution,
+	itemId blizzard.ItemID,
+) (sotah.PriceRollupHistory, error) {
+	out := sotah.PriceRollupHistory{}
+	err := prdBase.db.View(func(tx *bolt.Tx) error {
+		var err error
+		out, err = getPriceRollupHistory(tx, resolution, itemId)
+
+		return err
+	})
+	if err != nil {
+		return sotah.PriceRollupHistory{}, err
+	}
+
+	return out, nil
+}
+
+// persistRollups folds a full day of hourly points into the daily rollups, recomputes the enclosing week from those
+// daily rollups, and flags the day as rolled-up, where daily rollups before the daily retention limit are dropped
+func (prdBase PricelistHistoryRollupDatabase) persistRollups(
+	dayTime time.Time,
+	ipHistories sotah.ItemPriceHistories,
+	dailyRetentionLimit time.Time,
+) error {
+	dayTimestamp := sotah.UnixTimestamp(dayTime.Unix())
+	weekTime := sotah.NormalizeTargetWeek(dayTime)
+	weekTimestamp := sotah.UnixTimestamp(weekTime.Unix())
+	weekEndTimestamp := sotah.UnixTimestamp(weekTime.AddDate(0, 0, 7).Unix() - 1)
+	dailyRetentionTimestamp := sotah.UnixTimestamp(dailyRetentionLimit.Unix())
+
+	logging.WithFields(logrus.Fields{
+		"day":   dayTimestamp,
+		"week":  weekTimestamp,
+		"items": len(ipHistories),
+	}).Debug("Writing pricelist-history rollups")
+
+	return prdBase.db.Batch(func(tx *bolt.Tx) error {
+		for itemId, pHistory := range ipHistories {
+			dailyHistory, err := getPriceRollupHistory(tx, resolutions.Daily, itemId)
+			if err != nil {
+				return err
+			}
+
+			dailyHistory[dayTimestamp] = sotah.NewPriceRollup(pHistory.Samples())
+			for targetTimestamp := range dailyHistory {
+				if targetTimestamp < dailyRetentionTimestamp {
+					delete(dailyHistory, targetTimestamp)
+				}
+			}
+
+			if err := putPriceRollupHistory(tx, resolutions.Daily, itemId, dailyHistory); err != nil {
+				return err
+			}
+
+			weeklyHistory, err := getPriceRollupHistory(tx, resolutions.Weekly, itemId)
+			if err != nil {
+				return err
+			}
+
+			weeklyHistory[weekTimestamp] = sotah.MergePriceRollups(
+				dailyHistory.Between(weekTimestamp, weekEndTimestamp),
+			)
+
+			if err := putPriceRollupHistory(tx, resolutions.Weekly, itemId, weeklyHistory); err != nil {
+				return err
+			}
+		}
+
+		bkt, err := tx.CreateBucketIfNotExists(pricelistHistoryRolledUpBucketName())
+		if err != nil {
+			return err
+		}
+
+		return bkt.Put(pricelistHistoryRolledUpKeyName(dayTimestamp), []byte{1})
+	})
+}
@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database/codes"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/sotah/resolutions"
+	"github.com/sotah-inc/server/app/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testRegionName = blizzard.RegionName("us")
+	testRealmSlug  = blizzard.RealmSlug("earthen-ring")
+	testItemId     = blizzard.ItemID(1)
+)
+
+func newTestPricelistHistoryDatabases(t *testing.T, dbDir string) (PricelistHistoryDatabases, bool) {
+	shardDir := fmt.Sprintf("%s/pricelist-histories/%s/%s", dbDir, testRegionName, testRealmSlug)
+	if !assert.Nil(t, util.EnsureDirExists(shardDir)) {
+		return PricelistHistoryDatabases{}, false
+	}
+
+	statuses := sotah.Statuses{
+		testRegionName: sotah.Status{
+			Realms: sotah.Realms{sotah.NewSkeletonRealm(testRegionName, testRealmSlug)},
+		},
+	}
+	phdBases, err := NewPricelistHistoryDatabases(dbDir, statuses, sotah.RetentionConfig{})
+	if !assert.Nil(t, err) {
+		return PricelistHistoryDatabases{}, false
+	}
+
+	return phdBases, true
+}
+
+func persistTestItemPrices(phdBases PricelistHistoryDatabases, targetTime time.Time, prices sotah.Prices) error {
+	phdBase, err := phdBases.resolveDatabaseFromLoadInJob(LoadInJob{
+		Realm:      sotah.NewSkeletonRealm(testRegionName, testRealmSlug),
+		TargetTime: targetTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	return phdBase.persistItemPrices(targetTime, sotah.ItemPrices{testItemId: prices})
+}
+
+func TestPricelistHistoryDatabasesGetPricelistHistoryMergesShards(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "pricelist-histories")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	phdBases, ok := newTestPricelistHistoryDatabases(t, dbDir)
+	if !ok {
+		return
+	}
+	defer phdBases.Close()
+
+	// a day that has since been pruned, leaving only its rollup
+	retentionLimit := phdBases.RetentionLimit(testRegionName)
+	prunedDay := retentionLimit.AddDate(0, 0, -1)
+	prdBase := phdBases.rollupDatabases[testRegionName][testRealmSlug]
+	err = prdBase.persistRollups(
+		prunedDay,
+		sotah.ItemPriceHistories{
+			testItemId: sotah.PriceHistory{
+				sotah.UnixTimestamp(prunedDay.Unix()): sotah.Prices{MedianBuyoutPer: 10, Volume: 1},
+			},
+		},
+		phdBases.DailyRollupRetentionLimit(testRegionName),
+	)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// the current day, which is not rolled up yet
+	now := time.Now()
+	if !assert.Nil(t, persistTestItemPrices(phdBases, now, sotah.Prices{MedianBuyoutPer: 20, Volume: 2})) {
+		return
+	}
+
+	res, code, err := phdBases.GetPricelistHistory(context.Background(), GetPricelistHistoryRequest{
+		RegionName:  testRegionName,
+		RealmSlug:   testRealmSlug,
+		ItemIds:     []blizzard.ItemID{testItemId},
+		LowerBounds: prunedDay.Unix(),
+		UpperBounds: now.Unix(),
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, codes.Ok, code)
+	assert.Equal(t, resolutions.Daily, res.Resolution)
+	assert.Len(t, res.Rollups[testItemId], 1)
+	assert.Equal(t, sotah.PriceHistory{
+		sotah.UnixTimestamp(prunedDay.Unix()): sotah.Prices{MedianBuyoutPer: 10, Volume: 1},
+		sotah.UnixTimestamp(now.Unix()):       sotah.Prices{MedianBuyoutPer: 20, Volume: 2},
+	}, res.History[testItemId])
+}
+
+func TestPricelistHistoryDatabasesInvalidateRollup(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "pricelist-histories")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	phdBases, ok := newTestPricelistHistoryDatabases(t, dbDir)
+	if !ok {
+		return
+	}
+	defer phdBases.Close()
+
+	yesterday := sotah.NormalizeTargetDate(time.Now()).AddDate(0, 0, -1)
+	yesterdayTimestamp := sotah.UnixTimestamp(yesterday.Unix())
+	if !assert.Nil(t, persistTestItemPrices(phdBases, yesterday, sotah.Prices{MedianBuyoutPer: 10, Volume: 1})) {
+		return
+	}
+
+	if !assert.Nil(t, phdBases.buildRollups()) {
+		return
+	}
+
+	prdBase := phdBases.rollupDatabases[testRegionName][testRealmSlug]
+	rolledUp, err := prdBase.isRolledUp(yesterdayTimestamp)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, rolledUp)
+
+	// a late write to the rolled-up day clears its flag, and the next build rolls the late point in
+	lateTime := yesterday.Add(time.Hour)
+	if !assert.Nil(t, persistTestItemPrices(phdBases, lateTime, sotah.Prices{MedianBuyoutPer: 30, Volume: 3})) {
+		return
+	}
+	if !assert.Nil(t, phdBases.invalidateRollup(testRegionName, testRealmSlug, yesterdayTimestamp)) {
+		return
+	}
+
+	rolledUp, err = prdBase.isRolledUp(yesterdayTimestamp)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.False(t, rolledUp)
+
+	if !assert.Nil(t, phdBases.buildRollups()) {
+		return
+	}
+
+	prHistory, err := prdBase.getPriceRollupHistory(resolutions.Daily, testItemId)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 2, prHistory[yesterdayTimestamp].Samples)
+
+	// the current day is never flagged, so there is nothing to clear
+	today := sotah.UnixTimestamp(sotah.NormalizeTargetDate(time.Now()).Unix())
+	assert.Nil(t, phdBases.invalidateRollup(testRegionName, testRealmSlug, today))
+}
+
+func TestPricelistHistoryDatabasesPruneRollsUpFirst(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "pricelist-histories")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	phdBases, ok := newTestPricelistHistoryDatabases(t, dbDir)
+	if !ok {
+		return
+	}
+	defer phdBases.Close()
+
+	// a day past retention that the rollup-builder never got to
+	expiredDay := sotah.NormalizeTargetDate(phdBases.RetentionLimit(testRegionName)).AddDate(0, 0, -1)
+	expiredTimestamp := sotah.UnixTimestamp(expiredDay.Unix())
+	if !assert.Nil(t, persistTestItemPrices(phdBases, expiredDay, sotah.Prices{MedianBuyoutPer: 10, Volume: 1})) {
+		return
+	}
+	dbPath := phdBases.Databases[testRegionName][testRealmSlug][expiredTimestamp].db.Path()
+
+	if !assert.Nil(t, phdBases.Prune()) {
+		return
+	}
+
+	_, ok = phdBases.Databases[testRegionName][testRealmSlug][expiredTimestamp]
+	assert.False(t, ok)
+	_, err = os.Stat(dbPath)
+	assert.True(t, os.IsNotExist(err))
+
+	prdBase := phdBases.rollupDatabases[testRegionName][testRealmSlug]
+	rolledUp, err := prdBase.isRolledUp(expiredTimestamp)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, rolledUp)
+
+	prHistory, err := prdBase.getPriceRollupHistory(resolutions.Weekly, testItemId)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, prHistory, 1)
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/sotah-inc/server/app/pkg/sotah"
+)
+
+// bucketing
+func databaseRecipesBucketName() []byte {
+	return []byte("recipes")
+}
+
+// keying
+func recipeKeyName(id sotah.RecipeID) []byte {
+	return []byte(fmt.Sprintf("recipe-%d", id))
+}
+
+func recipeIdFromRecipeKeyName(key []byte) (sotah.RecipeID, error) {
+	unparsedRecipeId, err := strconv.Atoi(string(key)[len("recipe-"):])
+	if err != nil {
+		return sotah.RecipeID(0), err
+	}
+
+	return sotah.RecipeID(unparsedRecipeId), nil
+}
+
+// db
+func recipesDatabasePath(dbDir string) string {
+	return fmt.Sprintf("%s/recipes.db", dbDir)
+}
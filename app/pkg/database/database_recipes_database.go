@@ -0,0 +1,145 @@
+package database
+
+import (
+	"github.com/boltdb/bolt"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+)
+
+func NewRecipesDatabase(dbDir string) (RecipesDatabase, error) {
+	dbFilepath := recipesDatabasePath(dbDir)
+
+	logging.WithField("filepath", dbFilepath).Info("Initializing recipes database")
+
+	db, err := bolt.Open(dbFilepath, 0600, nil)
+	if err != nil {
+		return RecipesDatabase{}, err
+	}
+
+	if err := ensureSchemaVersion(db, schemakinds.Recipes); err != nil {
+		db.Close()
+
+		return RecipesDatabase{}, err
+	}
+
+	return RecipesDatabase{db}, nil
+}
+
+type RecipesDatabase struct {
+	db *bolt.DB
+}
+
+func (rBase RecipesDatabase) GetRecipes() (sotah.Recipes, error) {
+	out := sotah.Recipes{}
+
+	err := rBase.db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(databaseRecipesBucketName())
+		if bkt == nil {
+			return nil
+		}
+
+		return bkt.ForEach(func(k, v []byte) error {
+			if _, err := recipeIdFromRecipeKeyName(k); err != nil {
+				return err
+			}
+
+			r, err := sotah.NewRecipe(v)
+			if err != nil {
+				return err
+			}
+
+			out = append(out, r)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return sotah.Recipes{}, err
+	}
+
+	return out, nil
+}
+
+func (rBase RecipesDatabase) FindRecipes(recipeIds []sotah.RecipeID) (sotah.Recipes, error) {
+	out := sotah.Recipes{}
+
+	err := rBase.db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(databaseRecipesBucketName())
+		if bkt == nil {
+			return nil
+		}
+
+		for _, id := range recipeIds {
+			value := bkt.Get(recipeKeyName(id))
+			if value == nil {
+				continue
+			}
+
+			r, err := sotah.NewRecipe(value)
+			if err != nil {
+				return err
+			}
+
+			out = append(out, r)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return sotah.Recipes{}, err
+	}
+
+	return out, nil
+}
+
+// PersistRecipes replaces the persisted recipes with the given ones, removing those no longer given
+func (rBase RecipesDatabase) PersistRecipes(recipes sotah.Recipes) error {
+	logging.WithField("recipes", len(recipes)).Info("Persisting recipes")
+
+	err := rBase.db.Batch(func(tx *bolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists(databaseRecipesBucketName())
+		if err != nil {
+			return err
+		}
+
+		// gathering keys of recipes no longer given, since keys may not be deleted while iterating
+		given := map[string]struct{}{}
+		for _, r := range recipes {
+			given[string(recipeKeyName(r.ID))] = struct{}{}
+		}
+		stale := [][]byte{}
+		if err := bkt.ForEach(func(k, v []byte) error {
+			if _, ok := given[string(k)]; !ok {
+				stale = append(stale, append([]byte{}, k...))
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err := bkt.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		for _, r := range recipes {
+			encodedRecipe, err := r.EncodeForPersistence()
+			if err != nil {
+				return err
+			}
+
+			if err := bkt.Put(recipeKeyName(r.ID), encodedRecipe); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecipesDatabasePersistRecipes(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "recipes")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	rBase, err := NewRecipesDatabase(dbDir)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer rBase.db.Close()
+
+	first := sotah.Recipes{{ID: 1, Name: "first"}, {ID: 2, Name: "second"}}
+	if !assert.Nil(t, rBase.PersistRecipes(first)) {
+		return
+	}
+
+	found, err := rBase.FindRecipes([]sotah.RecipeID{2, 3})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, sotah.Recipes{{ID: 2, Name: "second"}}, found)
+
+	// persisting again replaces the recipes, removing those no longer given
+	second := sotah.Recipes{{ID: 2, Name: "second, renamed"}, {ID: 3, Name: "third"}}
+	if !assert.Nil(t, rBase.PersistRecipes(second)) {
+		return
+	}
+
+	recipes, err := rBase.GetRecipes()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.ElementsMatch(t, second, recipes)
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+)
+
+// keying
+func regionPricelistHistoryKeyName() []byte {
+	return pricelistHistoryKeyName()
+}
+
+// bucketing
+func regionPricelistHistoryBucketName(ID blizzard.ItemID) []byte {
+	return []byte(fmt.Sprintf("region-item-prices/%d", ID))
+}
+
+func itemIdFromRegionPricelistHistoryBucketName(bucketName []byte) (blizzard.ItemID, error) {
+	unparsedItemId, err := strconv.Atoi(strings.TrimPrefix(string(bucketName), "region-item-prices/"))
+	if err != nil {
+		return blizzard.ItemID(0), err
+	}
+
+	return blizzard.ItemID(unparsedItemId), nil
+}
+
+// db
+func regionPricelistHistoryDatabaseDir(dirPath string, regionName blizzard.RegionName) string {
+	return fmt.Sprintf("%s/region-pricelist-histories/%s", dirPath, regionName)
+}
+
+func regionPricelistHistoryDatabaseFilePath(
+	dirPath string,
+	regionName blizzard.RegionName,
+	targetTimestamp sotah.UnixTimestamp,
+) string {
+	return fmt.Sprintf("%s/%d.db", regionPricelistHistoryDatabaseDir(dirPath, regionName), targetTimestamp)
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+)
+
+func newRegionPricelistHistoryDatabase(dbFilepath string, targetDate time.Time) (RegionPricelistHistoryDatabase, error) {
+	db, err := bolt.Open(dbFilepath, 0600, nil)
+	if err != nil {
+		return RegionPricelistHistoryDatabase{}, err
+	}
+
+	if err := ensureSchemaVersion(db, schemakinds.RegionPricelistHistories); err != nil {
+		db.Close()
+
+		return RegionPricelistHistoryDatabase{}, err
+	}
+
+	return RegionPricelistHistoryDatabase{db, targetDate}, nil
+}
+
+type RegionPricelistHistoryDatabase struct {
+	db         *bolt.DB
+	targetDate time.Time
+}
+
+func (rphdBase RegionPricelistHistoryDatabase) getItemRegionPriceHistory(
+	itemID blizzard.ItemID,
+) (sotah.RegionPriceHistory, error) {
+	out := sotah.RegionPriceHistory{}
+
+	err := rphdBase.db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(regionPricelistHistoryBucketName(itemID))
+		if bkt == nil {
+			return nil
+		}
+
+		value := bkt.Get(regionPricelistHistoryKeyName())
+		if value == nil {
+			return nil
+		}
+
+		var err error
+		out, err = sotah.NewRegionPriceHistoryFromBytes(value)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return sotah.RegionPriceHistory{}, err
+	}
+
+	return out, nil
+}
+
+func (rphdBase RegionPricelistHistoryDatabase) persistItemRegionPriceHistories(
+	irpHistories sotah.ItemRegionPriceHistories,
+) error {
+	logging.WithFields(logrus.Fields{
+		"target-date": rphdBase.targetDate.Unix(),
+		"items":       len(irpHistories),
+	}).Debug("Writing item-region-price-histories")
+
+	err := rphdBase.db.Batch(func(tx *bolt.Tx) error {
+		for itemId, rpHistory := range irpHistories {
+			bkt, err := tx.CreateBucketIfNotExists(regionPricelistHistoryBucketName(itemId))
+			if err != nil {
+				return err
+			}
+
+			encodedValue, err := rpHistory.EncodeForPersistence()
+			if err != nil {
+				return err
+			}
+
+			if err := bkt.Put(regionPricelistHistoryKeyName(), encodedValue); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
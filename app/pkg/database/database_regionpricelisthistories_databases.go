@@ -0,0 +1,439 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database/codes"
+	"github.com/sotah-inc/server/app/pkg/health"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/sotah/retentionkinds"
+	"github.com/sotah-inc/server/app/pkg/util"
+)
+
+type regionDatabaseShards map[blizzard.RegionName]RegionPricelistHistoryDatabaseShards
+
+type RegionPricelistHistoryDatabaseShards map[sotah.UnixTimestamp]RegionPricelistHistoryDatabase
+
+func (rphdShards RegionPricelistHistoryDatabaseShards) GetRegionPriceHistory(
+	ItemId blizzard.ItemID,
+	lowerBounds time.Time,
+	upperBounds time.Time,
+) (sotah.RegionPriceHistory, error) {
+	rpHistory := sotah.RegionPriceHistory{}
+
+	for _, rphdBase := range rphdShards {
+		receivedHistory, err := rphdBase.getItemRegionPriceHistory(ItemId)
+		if err != nil {
+			return sotah.RegionPriceHistory{}, err
+		}
+
+		for targetTimestamp, pricesValue := range receivedHistory {
+			if int64(targetTimestamp) < lowerBounds.Unix() {
+				continue
+			}
+			if int64(targetTimestamp) > upperBounds.Unix() {
+				continue
+			}
+
+			rpHistory[targetTimestamp] = pricesValue
+		}
+	}
+
+	return rpHistory, nil
+}
+
+func NewRegionPricelistHistoryDatabases(
+	dirPath string,
+	statuses sotah.Statuses,
+	retention sotah.RetentionConfig,
+) (RegionPricelistHistoryDatabases, error) {
+	if len(dirPath) == 0 {
+		return RegionPricelistHistoryDatabases{}, errors.New("dir-path cannot be blank")
+	}
+
+	rphdBases := RegionPricelistHistoryDatabases{
+		databaseDir: dirPath,
+		Databases:   regionDatabaseShards{},
+		retention:   retention,
+		mu:          &sync.RWMutex{},
+		queue:       &regionComputeQueue{pending: RegionTargetTimestamps{}, ready: make(chan struct{}, 1)},
+	}
+
+	for regionName := range statuses {
+		rphdBases.Databases[regionName] = RegionPricelistHistoryDatabaseShards{}
+
+		dbPathPairs, err := Paths(regionPricelistHistoryDatabaseDir(dirPath, regionName))
+		if err != nil {
+			return RegionPricelistHistoryDatabases{}, err
+		}
+
+		for _, dbPathPair := range dbPathPairs {
+			rphdBase, err := newRegionPricelistHistoryDatabase(dbPathPair.FullPath, dbPathPair.TargetTime)
+			if err != nil {
+				return RegionPricelistHistoryDatabases{}, err
+			}
+
+			rphdBases.Databases[regionName][sotah.UnixTimestamp(dbPathPair.TargetTime.Unix())] = rphdBase
+		}
+	}
+
+	return rphdBases, nil
+}
+
+/*
+RegionPricelistHistoryDatabases - the region shards, which are added to by computing and removed from by pruning while
+being queried, and so are guarded by a lock
+*/
+type RegionPricelistHistoryDatabases struct {
+	databaseDir string
+	Databases   regionDatabaseShards
+	retention   sotah.RetentionConfig
+
+	mu    *sync.RWMutex
+	queue *regionComputeQueue
+}
+
+// shards returns a copy of the shards of the region, so that they may be walked without holding the lock
+func (rphdBases RegionPricelistHistoryDatabases) shards(
+	regionName blizzard.RegionName,
+) (RegionPricelistHistoryDatabaseShards, bool) {
+	rphdBases.mu.RLock()
+	defer rphdBases.mu.RUnlock()
+
+	regionShards, ok := rphdBases.Databases[regionName]
+	if !ok {
+		return RegionPricelistHistoryDatabaseShards{}, false
+	}
+
+	out := make(RegionPricelistHistoryDatabaseShards, len(regionShards))
+	for targetTimestamp, rphdBase := range regionShards {
+		out[targetTimestamp] = rphdBase
+	}
+
+	return out, true
+}
+
+func (rphdBases RegionPricelistHistoryDatabases) resolveDatabase(
+	regionName blizzard.RegionName,
+	normalizedTargetTimestamp sotah.UnixTimestamp,
+) (RegionPricelistHistoryDatabase, error) {
+	rphdBases.mu.RLock()
+	rphdBase, ok := rphdBases.Databases[regionName][normalizedTargetTimestamp]
+	rphdBases.mu.RUnlock()
+	if ok {
+		return rphdBase, nil
+	}
+
+	rphdBases.mu.Lock()
+	defer rphdBases.mu.Unlock()
+
+	// checking again, since the shard may have been opened while waiting on the lock
+	rphdBase, ok = rphdBases.Databases[regionName][normalizedTargetTimestamp]
+	if ok {
+		return rphdBase, nil
+	}
+
+	if _, ok := rphdBases.Databases[regionName]; !ok {
+		return RegionPricelistHistoryDatabase{}, errors.New("invalid region")
+	}
+
+	dbPath := regionPricelistHistoryDatabaseFilePath(rphdBases.databaseDir, regionName, normalizedTargetTimestamp)
+	rphdBase, err := newRegionPricelistHistoryDatabase(dbPath, time.Unix(int64(normalizedTargetTimestamp), 0))
+	if err != nil {
+		return RegionPricelistHistoryDatabase{}, err
+	}
+	rphdBases.Databases[regionName][normalizedTargetTimestamp] = rphdBase
+
+	return rphdBase, nil
+}
+
+// RegionTargetTimestamps - normalized target timestamps that need their region aggregate recomputed
+type RegionTargetTimestamps map[blizzard.RegionName]map[sotah.UnixTimestamp]struct{}
+
+func (v RegionTargetTimestamps) Insert(
+	regionName blizzard.RegionName,
+	targetTimestamp sotah.UnixTimestamp,
+) RegionTargetTimestamps {
+	if _, ok := v[regionName]; !ok {
+		v[regionName] = map[sotah.UnixTimestamp]struct{}{}
+	}
+
+	v[regionName][targetTimestamp] = struct{}{}
+
+	return v
+}
+
+// regionComputeQueue - target timestamps waiting on their region aggregate, merged until the computer takes them up
+type regionComputeQueue struct {
+	sync.Mutex
+	pending RegionTargetTimestamps
+	ready   chan struct{}
+}
+
+func (queue *regionComputeQueue) take() RegionTargetTimestamps {
+	queue.Lock()
+	defer queue.Unlock()
+
+	out := queue.pending
+	queue.pending = RegionTargetTimestamps{}
+
+	return out
+}
+
+// Enqueue queues the region aggregates of the given shards for the computer, without waiting on them to be computed
+func (rphdBases RegionPricelistHistoryDatabases) Enqueue(targetTimestamps RegionTargetTimestamps) {
+	rphdBases.queue.Lock()
+	for regionName, timestamps := range targetTimestamps {
+		for targetTimestamp := range timestamps {
+			rphdBases.queue.pending = rphdBases.queue.pending.Insert(regionName, targetTimestamp)
+		}
+	}
+	rphdBases.queue.Unlock()
+
+	select {
+	case rphdBases.queue.ready <- struct{}{}:
+	default:
+	}
+}
+
+/*
+StartComputer computes the queued region aggregates off of the intake path, where shards queued again while being
+computed are computed once more after
+*/
+func (rphdBases RegionPricelistHistoryDatabases) StartComputer(
+	stopChan sotah.WorkerStopChan,
+	phdBases PricelistHistoryDatabases,
+) sotah.WorkerStopChan {
+	onStop := make(sotah.WorkerStopChan)
+	go func() {
+		logging.Info("Starting region computer")
+	outer:
+		for {
+			select {
+			case <-rphdBases.queue.ready:
+				if err := rphdBases.Compute(phdBases, rphdBases.queue.take()); err != nil {
+					logging.WithField("error", err.Error()).Error("Failed to compute region pricelist-histories")
+
+					continue
+				}
+			case <-stopChan:
+				break outer
+			}
+		}
+
+		onStop <- struct{}{}
+	}()
+
+	return onStop
+}
+
+// Compute rebuilds the region aggregate for each given shard from every realm shard sharing its target timestamp
+func (rphdBases RegionPricelistHistoryDatabases) Compute(
+	phdBases PricelistHistoryDatabases,
+	targetTimestamps RegionTargetTimestamps,
+) error {
+	for regionName, timestamps := range targetTimestamps {
+		regionShards, _ := phdBases.regionShards(regionName)
+
+		for targetTimestamp := range timestamps {
+			startTime := time.Now()
+
+			realmHistories := []sotah.ItemPriceHistories{}
+			for _, realmShards := range regionShards {
+				phdBase, ok := realmShards[targetTimestamp]
+				if !ok {
+					continue
+				}
+
+				ipHistories, err := phdBase.getAllItemPriceHistories()
+				if err != nil {
+					return err
+				}
+
+				realmHistories = append(realmHistories, ipHistories)
+			}
+
+			rphdBase, err := rphdBases.resolveDatabase(regionName, targetTimestamp)
+			if err != nil {
+				return err
+			}
+
+			irpHistories := sotah.NewItemRegionPriceHistories(realmHistories)
+			if err := rphdBase.persistItemRegionPriceHistories(irpHistories); err != nil {
+				return err
+			}
+
+			logging.WithFields(logrus.Fields{
+				"region":                      regionName,
+				"normalized-target-timestamp": targetTimestamp,
+				"realms":                      len(realmHistories),
+				"items":                       len(irpHistories),
+				"duration-in-ms":              int64(time.Since(startTime)) / 1000 / 1000,
+			}).Info("Computed region pricelist-history")
+		}
+	}
+
+	return nil
+}
+
+func (rphdBases RegionPricelistHistoryDatabases) pruneDatabases() error {
+	rphdBases.mu.Lock()
+	defer rphdBases.mu.Unlock()
+
+	for rName, databaseShards := range rphdBases.Databases {
+		earliestUnixTimestamp := rphdBases.retention.Limit(rName, retentionkinds.PricelistHistoryShards).Unix()
+		logging.WithFields(logrus.Fields{
+			"region": rName,
+			"limit":  earliestUnixTimestamp,
+		}).Info("Checking for region databases to prune")
+
+		for unixTimestamp, rphdBase := range databaseShards {
+			if int64(unixTimestamp) > earliestUnixTimestamp {
+				continue
+			}
+
+			if rphdBases.retention.DryRun {
+				logging.WithFields(logrus.Fields{
+					"region":   rName,
+					"filepath": rphdBase.db.Path(),
+				}).Info("Dry-run: would delete region database file")
+
+				continue
+			}
+
+			delete(rphdBases.Databases[rName], unixTimestamp)
+
+			dbPath := rphdBase.db.Path()
+
+			logging.WithFields(logrus.Fields{
+				"region":             rName,
+				"database-timestamp": unixTimestamp,
+			}).Debug("Closing region database")
+			if err := rphdBase.db.Close(); err != nil {
+				logging.WithFields(logrus.Fields{
+					"region":   rName,
+					"database": dbPath,
+				}).Error("Failed to close region database")
+
+				return err
+			}
+
+			if err := os.Remove(dbPath); err != nil {
+				logging.WithFields(logrus.Fields{
+					"region":   rName,
+					"database": dbPath,
+				}).Error("Failed to remove region database file")
+
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (rphdBases RegionPricelistHistoryDatabases) StartPruner(
+	stopChan sotah.WorkerStopChan,
+	heartbeat health.Heartbeat,
+) sotah.WorkerStopChan {
+	onStop := make(sotah.WorkerStopChan)
+	go func() {
+		ticker := time.NewTicker(PrunerInterval)
+
+		logging.Info("Starting region pruner")
+	outer:
+		for {
+			select {
+			case <-ticker.C:
+				heartbeat.Beat()
+				if err := rphdBases.pruneDatabases(); err != nil {
+					logging.WithField("error", err.Error()).Error("Failed to prune region databases")
+
+					continue
+				}
+			case <-stopChan:
+				ticker.Stop()
+
+				break outer
+			}
+		}
+
+		onStop <- struct{}{}
+	}()
+
+	return onStop
+}
+
+func NewGetRegionPricelistHistoryRequest(data []byte) (GetRegionPricelistHistoryRequest, error) {
+	req := &GetRegionPricelistHistoryRequest{}
+	err := json.Unmarshal(data, &req)
+	if err != nil {
+		return GetRegionPricelistHistoryRequest{}, err
+	}
+
+	return *req, nil
+}
+
+type GetRegionPricelistHistoryRequest struct {
+	RegionName  blizzard.RegionName `json:"region_name"`
+	ItemIds     []blizzard.ItemID   `json:"item_ids"`
+	LowerBounds int64               `json:"lower_bounds"`
+	UpperBounds int64               `json:"upper_bounds"`
+}
+
+type GetRegionPricelistHistoryResponse struct {
+	History sotah.ItemRegionPriceHistories `json:"history"`
+}
+
+func (res GetRegionPricelistHistoryResponse) EncodeForDelivery() (string, error) {
+	jsonEncoded, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+
+	gzipEncoded, err := util.GzipEncode(jsonEncoded)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(gzipEncoded), nil
+}
+
+func (rphdBases RegionPricelistHistoryDatabases) GetRegionPricelistHistory(
+	ctx context.Context,
+	req GetRegionPricelistHistoryRequest,
+) (GetRegionPricelistHistoryResponse, codes.Code, error) {
+	regionShards, ok := rphdBases.shards(req.RegionName)
+	if !ok {
+		return GetRegionPricelistHistoryResponse{}, codes.UserError, errors.New("invalid region")
+	}
+
+	res := GetRegionPricelistHistoryResponse{History: sotah.ItemRegionPriceHistories{}}
+	for _, ID := range req.ItemIds {
+		if err := ctx.Err(); err != nil {
+			return GetRegionPricelistHistoryResponse{}, codes.GenericError, err
+		}
+
+		rpHistory, err := regionShards.GetRegionPriceHistory(
+			ID,
+			time.Unix(req.LowerBounds, 0),
+			time.Unix(req.UpperBounds, 0),
+		)
+		if err != nil {
+			return GetRegionPricelistHistoryResponse{}, codes.GenericError, err
+		}
+
+		res.History[ID] = rpHistory
+	}
+
+	return res, codes.Ok, nil
+}
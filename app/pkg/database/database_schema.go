@@ -0,0 +1,147 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/boltdb/bolt"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+)
+
+// bucketing
+func schemaBucketName() []byte {
+	return []byte("schema")
+}
+
+func isSchemaBucketName(name []byte) bool {
+	return bytes.Equal(name, schemaBucketName())
+}
+
+// keying
+func schemaVersionKeyName() []byte {
+	return []byte("version")
+}
+
+/*
+schema migrations: each step upgrades a database from the version matching its index plus one to the next version,
+so the current version of a kind is one more than its number of steps
+*/
+type schemaMigration func(tx *bolt.Tx) error
+
+var schemaMigrations = map[schemakinds.SchemaKind][]schemaMigration{
+	schemakinds.Items:                    {},
+	schemakinds.Meta:                     {},
+	schemakinds.APIKeys:                  {},
+	schemakinds.PricelistHistoryRollups:  {},
+	schemakinds.RegionPricelistHistories: {},
+	schemakinds.Recipes:                  {},
+	schemakinds.LiveAuctions: {
+		// v1 -> v2: re-encoding the mini-auction-list into the binary encoding
+		func(tx *bolt.Tx) error {
+			_, err := migrateBucketEncodings(
+				tx,
+				func(name []byte) bool {
+					return bytes.Equal(name, liveAuctionsBucketName())
+				},
+				liveAuctionsKeyName(),
+				migrateMiniAuctionListEncoding,
+			)
+
+			return err
+		},
+	},
+	schemakinds.PricelistHistories: {
+		// v1 -> v2: re-encoding the price-histories into the binary encoding
+		func(tx *bolt.Tx) error {
+			_, err := migrateBucketEncodings(
+				tx,
+				func(name []byte) bool {
+					return bytes.HasPrefix(name, []byte("item-prices/"))
+				},
+				pricelistHistoryKeyName(),
+				migratePriceHistoryEncoding,
+			)
+
+			return err
+		},
+	},
+}
+
+func CurrentSchemaVersion(kind schemakinds.SchemaKind) int {
+	return len(schemaMigrations[kind]) + 1
+}
+
+/*
+getSchemaVersion - reads the stored schema version, where a database without one is either fresh (0) or was written
+before versioning existed (1)
+*/
+func getSchemaVersion(tx *bolt.Tx) (int, error) {
+	bkt := tx.Bucket(schemaBucketName())
+	if bkt != nil {
+		value := bkt.Get(schemaVersionKeyName())
+		if value != nil {
+			return strconv.Atoi(string(value))
+		}
+	}
+
+	hasData := false
+	err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if !isSchemaBucketName(name) {
+			hasData = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if hasData {
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+func putSchemaVersion(tx *bolt.Tx, version int) error {
+	bkt, err := tx.CreateBucketIfNotExists(schemaBucketName())
+	if err != nil {
+		return err
+	}
+
+	return bkt.Put(schemaVersionKeyName(), []byte(strconv.Itoa(version)))
+}
+
+// ensureSchemaVersion stamps a fresh database with the current version and refuses any database at another version
+func ensureSchemaVersion(db *bolt.DB, kind schemakinds.SchemaKind) error {
+	version := 0
+	err := db.View(func(tx *bolt.Tx) error {
+		var err error
+		version, err = getSchemaVersion(tx)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	expectedVersion := CurrentSchemaVersion(kind)
+	if version == expectedVersion {
+		return nil
+	}
+
+	if version == 0 {
+		return db.Update(func(tx *bolt.Tx) error {
+			return putSchemaVersion(tx, expectedVersion)
+		})
+	}
+
+	return fmt.Errorf(
+		"%s database %s is at schema version %d but %d is expected, run the schema migrations first",
+		kind,
+		db.Path(),
+		version,
+		expectedVersion,
+	)
+}
@@ -0,0 +1,313 @@
+package database
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/util"
+)
+
+// databases are opened with a timeout, so a migration fails fast rather than blocking on another running process
+const schemaMigrationOpenTimeout = 5 * time.Second
+
+// backups live outside of the database dirs, since shard dirs may only hold shards
+func schemaBackupFilePath(databaseDir string, dbFilepath string, version int) (string, error) {
+	relativePath, err := filepath.Rel(databaseDir, dbFilepath)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/v%d/%s", schemaBackupsDir(databaseDir), version, relativePath), nil
+}
+
+func schemaBackupsDir(databaseDir string) string {
+	return fmt.Sprintf("%s/schema-backups", databaseDir)
+}
+
+// pruneSchemaBackup removes the backup of a migrated database, along with any dirs it leaves empty
+func pruneSchemaBackup(databaseDir string, backupFilepath string) error {
+	if err := os.Remove(backupFilepath); err != nil {
+		return err
+	}
+
+	backupsDir := filepath.Clean(schemaBackupsDir(databaseDir))
+	for dir := filepath.Dir(backupFilepath); strings.HasPrefix(dir, backupsDir); dir = filepath.Dir(dir) {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		if len(entries) > 0 {
+			return nil
+		}
+
+		if err := os.Remove(dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func schemaDatabaseFilePaths(databaseDir string, kind schemakinds.SchemaKind) ([]string, error) {
+	var walkDir string
+	switch kind {
+	case schemakinds.Items:
+		dbFilepath, err := itemsDatabasePath(databaseDir)
+		if err != nil {
+			return []string{}, err
+		}
+
+		return existingFilePaths(dbFilepath), nil
+	case schemakinds.Meta:
+		return existingFilePaths(metaDatabaseFilePath(databaseDir)), nil
+	case schemakinds.APIKeys:
+		return existingFilePaths(apiKeysDatabaseFilePath(databaseDir)), nil
+	case schemakinds.Recipes:
+		return existingFilePaths(recipesDatabasePath(databaseDir)), nil
+	case schemakinds.PricelistHistoryRollups:
+		walkDir = fmt.Sprintf("%s/pricelist-history-rollups", databaseDir)
+	case schemakinds.RegionPricelistHistories:
+		walkDir = fmt.Sprintf("%s/region-pricelist-histories", databaseDir)
+	case schemakinds.LiveAuctions:
+		walkDir = fmt.Sprintf("%s/live-auctions", databaseDir)
+	case schemakinds.PricelistHistories:
+		walkDir = fmt.Sprintf("%s/pricelist-histories", databaseDir)
+	default:
+		return []string{}, fmt.Errorf("unsupported schema kind: %s", kind)
+	}
+
+	if _, err := os.Stat(walkDir); os.IsNotExist(err) {
+		return []string{}, nil
+	}
+
+	out := []string{}
+	err := filepath.Walk(walkDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".db") {
+			return nil
+		}
+
+		out = append(out, path)
+
+		return nil
+	})
+	if err != nil {
+		return []string{}, err
+	}
+
+	return out, nil
+}
+
+func existingFilePaths(dbFilepath string) []string {
+	if _, err := os.Stat(dbFilepath); os.IsNotExist(err) {
+		return []string{}
+	}
+
+	return []string{dbFilepath}
+}
+
+// migrateSchema upgrades the database file to the current version of its kind, returning whether it was upgraded
+func migrateSchema(databaseDir string, dbFilepath string, kind schemakinds.SchemaKind) (bool, error) {
+	db, err := bolt.Open(dbFilepath, 0600, &bolt.Options{Timeout: schemaMigrationOpenTimeout})
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	version := 0
+	err = db.View(func(tx *bolt.Tx) error {
+		var err error
+		version, err = getSchemaVersion(tx)
+
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	expectedVersion := CurrentSchemaVersion(kind)
+	if version == expectedVersion {
+		return false, nil
+	}
+
+	if version > expectedVersion {
+		return false, fmt.Errorf("schema version %d is newer than the supported %d", version, expectedVersion)
+	}
+
+	// backing up the file before touching any data, where backups are kept until PruneSchemaBackups removes them
+	if version > 0 {
+		backupFilepath, err := schemaBackupFilePath(databaseDir, dbFilepath, version)
+		if err != nil {
+			return false, err
+		}
+
+		if err := util.EnsureDirExists(filepath.Dir(backupFilepath)); err != nil {
+			return false, err
+		}
+
+		err = db.View(func(tx *bolt.Tx) error {
+			return tx.CopyFile(backupFilepath, 0600)
+		})
+		if err != nil {
+			return false, err
+		}
+
+		logging.WithFields(logrus.Fields{
+			"pathname": dbFilepath,
+			"backup":   backupFilepath,
+		}).Debug("Backed up database ahead of schema migration")
+	}
+
+	// applying every pending step in one transaction, so a failed step leaves the file untouched
+	err = db.Update(func(tx *bolt.Tx) error {
+		if version > 0 {
+			for _, step := range schemaMigrations[kind][version-1:] {
+				if err := step(tx); err != nil {
+					return err
+				}
+			}
+		}
+
+		return putSchemaVersion(tx, expectedVersion)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return version > 0, nil
+}
+
+/*
+MigrateSchemas upgrades every database of the given kinds under the database dir to its current schema version and
+returns how many files were upgraded, which requires that no other process has them open
+*/
+func MigrateSchemas(databaseDir string, kinds ...schemakinds.SchemaKind) (int, error) {
+	totalMigrated := 0
+	for _, kind := range kinds {
+		dbFilepaths, err := schemaDatabaseFilePaths(databaseDir, kind)
+		if err != nil {
+			return totalMigrated, err
+		}
+
+		kindMigrated := 0
+		for _, dbFilepath := range dbFilepaths {
+			migrated, err := migrateSchema(databaseDir, dbFilepath, kind)
+			if err != nil {
+				logging.WithFields(logrus.Fields{
+					"error":    err.Error(),
+					"kind":     kind,
+					"pathname": dbFilepath,
+				}).Error("Failed to migrate database schema")
+
+				return totalMigrated, err
+			}
+
+			if migrated {
+				kindMigrated++
+			}
+		}
+
+		logging.WithFields(logrus.Fields{
+			"kind":     kind,
+			"version":  CurrentSchemaVersion(kind),
+			"files":    len(dbFilepaths),
+			"migrated": kindMigrated,
+		}).Info("Migrated database schemas")
+		totalMigrated += kindMigrated
+	}
+
+	return totalMigrated, nil
+}
+
+/*
+PruneSchemaBackups removes the backups taken ahead of schema migrations, keeping the given number of the most recent
+versions of each database file, and returns how many backups were removed
+*/
+func PruneSchemaBackups(databaseDir string, keep int) (int, error) {
+	backupsDir := schemaBackupsDir(databaseDir)
+	if _, err := os.Stat(backupsDir); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	versionDirs, err := ioutil.ReadDir(backupsDir)
+	if err != nil {
+		return 0, err
+	}
+
+	// gathering the backed up versions of each database file
+	fileVersions := map[string][]int{}
+	for _, versionDir := range versionDirs {
+		if !versionDir.IsDir() || !strings.HasPrefix(versionDir.Name(), "v") {
+			continue
+		}
+
+		version, err := strconv.Atoi(strings.TrimPrefix(versionDir.Name(), "v"))
+		if err != nil {
+			continue
+		}
+
+		walkDir := fmt.Sprintf("%s/%s", backupsDir, versionDir.Name())
+		err = filepath.Walk(walkDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() {
+				return nil
+			}
+
+			relativePath, err := filepath.Rel(walkDir, path)
+			if err != nil {
+				return err
+			}
+
+			fileVersions[relativePath] = append(fileVersions[relativePath], version)
+
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	totalRemoved := 0
+	for relativePath, versions := range fileVersions {
+		if len(versions) <= keep {
+			continue
+		}
+
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+		for _, version := range versions[keep:] {
+			backupFilepath, err := schemaBackupFilePath(
+				databaseDir,
+				fmt.Sprintf("%s/%s", databaseDir, relativePath),
+				version,
+			)
+			if err != nil {
+				return totalRemoved, err
+			}
+
+			if err := pruneSchemaBackup(databaseDir, backupFilepath); err != nil {
+				return totalRemoved, err
+			}
+
+			totalRemoved++
+		}
+	}
+
+	return totalRemoved, nil
+}
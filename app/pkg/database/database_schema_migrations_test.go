@@ -0,0 +1,305 @@
+package database
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeLegacyPricelistHistoryShard writes a shard as it was before schema versioning, with a legacy-encoded value
+func writeLegacyPricelistHistoryShard(dbFilepath string, pHistory sotah.PriceHistory) error {
+	encoded, err := pHistory.EncodeForPersistence()
+	if err != nil {
+		return err
+	}
+
+	db, err := bolt.Open(dbFilepath, 0600, nil)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists(pricelistHistoryBucketName(testItemId))
+		if err != nil {
+			return err
+		}
+
+		return bkt.Put(pricelistHistoryKeyName(), encoded)
+	})
+}
+
+func readMigratedPricelistHistoryShard(dbFilepath string) (int, []byte, error) {
+	db, err := bolt.Open(dbFilepath, 0600, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer db.Close()
+
+	version := 0
+	var value []byte
+	err = db.View(func(tx *bolt.Tx) error {
+		var err error
+		version, err = getSchemaVersion(tx)
+		if err != nil {
+			return err
+		}
+
+		if bkt := tx.Bucket(pricelistHistoryBucketName(testItemId)); bkt != nil {
+			value = append([]byte{}, bkt.Get(pricelistHistoryKeyName())...)
+		}
+
+		return nil
+	})
+
+	return version, value, err
+}
+
+func TestMigrateSchemas(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "schemas")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	shardDir := fmt.Sprintf("%s/pricelist-histories/%s/%s", dbDir, testRegionName, testRealmSlug)
+	if !assert.Nil(t, util.EnsureDirExists(shardDir)) {
+		return
+	}
+
+	pHistory := sotah.PriceHistory{sotah.UnixTimestamp(100): {MedianBuyoutPer: 10, Volume: 1}}
+	legacyFilepath := fmt.Sprintf("%s/100.db", shardDir)
+	if !assert.Nil(t, writeLegacyPricelistHistoryShard(legacyFilepath, pHistory)) {
+		return
+	}
+
+	// a shard stamped with the current version on creation is left alone
+	freshBase, err := newPricelistHistoryDatabase(fmt.Sprintf("%s/200.db", shardDir), time.Unix(200, 0))
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.Nil(t, freshBase.db.Close()) {
+		return
+	}
+
+	totalMigrated, err := MigrateSchemas(dbDir, schemakinds.PricelistHistories)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 1, totalMigrated)
+
+	version, value, err := readMigratedPricelistHistoryShard(legacyFilepath)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, CurrentSchemaVersion(schemakinds.PricelistHistories), version)
+	assert.True(t, sotah.IsBinaryEncoded(value))
+
+	migratedHistory, err := sotah.NewPriceHistoryFromBytes(value)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, pHistory, migratedHistory)
+
+	// the backup of the legacy shard is kept after its migration succeeds
+	backupFilepath, err := schemaBackupFilePath(dbDir, legacyFilepath, 1)
+	if !assert.Nil(t, err) {
+		return
+	}
+	_, err = os.Stat(backupFilepath)
+	assert.Nil(t, err)
+
+	// running again has nothing left to migrate
+	totalMigrated, err = MigrateSchemas(dbDir, schemakinds.PricelistHistories)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 0, totalMigrated)
+}
+
+func TestPruneSchemaBackups(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "schemas")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	shardFilepath := fmt.Sprintf("%s/pricelist-histories/%s/%s/100.db", dbDir, testRegionName, testRealmSlug)
+	metaFilepath := metaDatabaseFilePath(dbDir)
+	backups := map[string][]int{
+		shardFilepath: {1, 2, 3},
+		metaFilepath:  {1},
+	}
+	for dbFilepath, versions := range backups {
+		for _, version := range versions {
+			backupFilepath, err := schemaBackupFilePath(dbDir, dbFilepath, version)
+			if !assert.Nil(t, err) {
+				return
+			}
+			if !assert.Nil(t, util.EnsureDirExists(filepath.Dir(backupFilepath))) {
+				return
+			}
+			if !assert.Nil(t, ioutil.WriteFile(backupFilepath, []byte{}, 0600)) {
+				return
+			}
+		}
+	}
+
+	// only the most recent versions of each database are kept
+	totalRemoved, err := PruneSchemaBackups(dbDir, 2)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 1, totalRemoved)
+
+	expected := map[string]map[int]bool{
+		shardFilepath: {1: false, 2: true, 3: true},
+		metaFilepath:  {1: true},
+	}
+	for dbFilepath, versions := range expected {
+		for version, exists := range versions {
+			backupFilepath, err := schemaBackupFilePath(dbDir, dbFilepath, version)
+			if !assert.Nil(t, err) {
+				return
+			}
+
+			_, err = os.Stat(backupFilepath)
+			assert.Equal(t, exists, err == nil, backupFilepath)
+		}
+	}
+
+	// pruning every backup removes the backups dir along with them
+	totalRemoved, err = PruneSchemaBackups(dbDir, 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 3, totalRemoved)
+
+	_, err = os.Stat(schemaBackupsDir(dbDir))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMigrateSchemasNewerVersion(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "schemas")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	db, err := bolt.Open(metaDatabaseFilePath(dbDir), 0600, nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return putSchemaVersion(tx, CurrentSchemaVersion(schemakinds.Meta)+1)
+	})
+	db.Close()
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = MigrateSchemas(dbDir, schemakinds.Meta)
+	assert.NotNil(t, err)
+
+	// nor does the database open at a version it does not support
+	_, err = NewMetaDatabase(dbDir)
+	assert.NotNil(t, err)
+}
+
+func TestMigrateEncodings(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "schemas")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	shardDir := fmt.Sprintf("%s/pricelist-histories/%s/%s", dbDir, testRegionName, testRealmSlug)
+	if !assert.Nil(t, util.EnsureDirExists(shardDir)) {
+		return
+	}
+
+	legacyFilepath := fmt.Sprintf("%s/100.db", shardDir)
+	pHistory := sotah.PriceHistory{sotah.UnixTimestamp(100): {MedianBuyoutPer: 10, Volume: 1}}
+	if !assert.Nil(t, writeLegacyPricelistHistoryShard(legacyFilepath, pHistory)) {
+		return
+	}
+
+	totalMigrated, err := MigrateEncodings(dbDir)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 1, totalMigrated)
+
+	_, value, err := readMigratedPricelistHistoryShard(legacyFilepath)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, sotah.IsBinaryEncoded(value))
+}
+
+func TestSchemaVersionStampedOnOpen(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "schemas")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	type opener func() (*bolt.DB, error)
+	scenarios := map[schemakinds.SchemaKind]opener{
+		schemakinds.Recipes: func() (*bolt.DB, error) {
+			rBase, err := NewRecipesDatabase(dbDir)
+
+			return rBase.db, err
+		},
+		schemakinds.PricelistHistoryRollups: func() (*bolt.DB, error) {
+			prdBase, err := newPricelistHistoryRollupDatabase(fmt.Sprintf("%s/rollups.db", dbDir))
+
+			return prdBase.db, err
+		},
+		schemakinds.RegionPricelistHistories: func() (*bolt.DB, error) {
+			rphdBase, err := newRegionPricelistHistoryDatabase(fmt.Sprintf("%s/region.db", dbDir), time.Unix(0, 0))
+
+			return rphdBase.db, err
+		},
+	}
+
+	for kind, open := range scenarios {
+		db, err := open()
+		if !assert.Nil(t, err, kind) {
+			return
+		}
+
+		// a fresh database is stamped with the current version
+		version := 0
+		err = db.View(func(tx *bolt.Tx) error {
+			version, err = getSchemaVersion(tx)
+
+			return err
+		})
+		if !assert.Nil(t, err, kind) {
+			return
+		}
+		assert.Equal(t, CurrentSchemaVersion(kind), version, kind)
+
+		// and refuses to open once it is at a version that is not supported
+		err = db.Update(func(tx *bolt.Tx) error {
+			return putSchemaVersion(tx, CurrentSchemaVersion(kind)+1)
+		})
+		db.Close()
+		if !assert.Nil(t, err, kind) {
+			return
+		}
+
+		_, err = open()
+		assert.NotNil(t, err, kind)
+	}
+}
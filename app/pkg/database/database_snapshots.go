@@ -0,0 +1,127 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/sotah-inc/server/app/pkg/util"
+)
+
+// SnapshotTarget - an open database that may be streamed while it keeps serving reads and writes
+type SnapshotTarget struct {
+	// relative to the database dir
+	Path string
+
+	db *bolt.DB
+}
+
+// WriteTo streams a consistent copy of the database from within a read transaction
+func (t SnapshotTarget) WriteTo(w io.Writer) (int64, error) {
+	var size int64
+	err := t.db.View(func(tx *bolt.Tx) error {
+		var err error
+		size, err = tx.WriteTo(w)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+func newSnapshotTarget(databaseDir string, db *bolt.DB) (SnapshotTarget, error) {
+	absDatabaseDir, err := filepath.Abs(databaseDir)
+	if err != nil {
+		return SnapshotTarget{}, err
+	}
+
+	absPath, err := filepath.Abs(db.Path())
+	if err != nil {
+		return SnapshotTarget{}, err
+	}
+
+	relativePath, err := filepath.Rel(absDatabaseDir, absPath)
+	if err != nil {
+		return SnapshotTarget{}, err
+	}
+
+	return SnapshotTarget{Path: filepath.ToSlash(relativePath), db: db}, nil
+}
+
+func (idBase ItemsDatabase) SnapshotTargets(databaseDir string) ([]SnapshotTarget, error) {
+	target, err := newSnapshotTarget(databaseDir, idBase.db)
+	if err != nil {
+		return []SnapshotTarget{}, err
+	}
+
+	return []SnapshotTarget{target}, nil
+}
+
+func (ladBases LiveAuctionsDatabases) SnapshotTargets(databaseDir string) ([]SnapshotTarget, error) {
+	out := []SnapshotTarget{}
+	for _, realmDatabases := range ladBases {
+		for _, ladBase := range realmDatabases {
+			target, err := newSnapshotTarget(databaseDir, ladBase.db)
+			if err != nil {
+				return []SnapshotTarget{}, err
+			}
+
+			out = append(out, target)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Path < out[j].Path
+	})
+
+	return out, nil
+}
+
+/*
+RestoreDatabase writes the snapshot of a database into place via a sibling temp file, so that an interrupted restore
+never leaves a partial database behind, and refuses to replace an existing database unless told to
+*/
+func RestoreDatabase(databaseDir string, path string, r io.Reader, overwrite bool) error {
+	cleanPath := filepath.Clean(filepath.FromSlash(path))
+	if filepath.IsAbs(cleanPath) || strings.HasPrefix(cleanPath, "..") || !strings.HasSuffix(cleanPath, ".db") {
+		return fmt.Errorf("invalid snapshot database path: %s", path)
+	}
+
+	dbFilepath := filepath.Join(databaseDir, cleanPath)
+	if _, err := os.Stat(dbFilepath); err == nil && !overwrite {
+		return errors.New("database already exists")
+	}
+
+	if err := util.EnsureDirExists(filepath.Dir(dbFilepath)); err != nil {
+		return err
+	}
+
+	tempFilepath := fmt.Sprintf("%s.restore", dbFilepath)
+	tempFile, err := os.OpenFile(tempFilepath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(tempFile, r); err != nil {
+		tempFile.Close()
+		os.Remove(tempFilepath)
+
+		return err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempFilepath)
+
+		return err
+	}
+
+	return os.Rename(tempFilepath, dbFilepath)
+}
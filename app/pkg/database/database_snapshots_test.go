@@ -0,0 +1,204 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingReader returns some data and then fails, as an interrupted download would
+type failingReader struct {
+	data []byte
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, errors.New("connection reset")
+	}
+
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+
+	return n, nil
+}
+
+func TestRestoreDatabase(t *testing.T) {
+	type scenario struct {
+		path      string
+		existing  bool
+		overwrite bool
+		reader    io.Reader
+		expectErr bool
+		expected  []byte
+	}
+
+	scenarios := map[string]scenario{
+		"restores": {
+			path:     "live-auctions/us/earthen-ring/live-auctions.db",
+			reader:   bytes.NewReader([]byte("restored")),
+			expected: []byte("restored"),
+		},
+		"rejects a parent path": {
+			path:      "../escaped.db",
+			reader:    bytes.NewReader([]byte("restored")),
+			expectErr: true,
+		},
+		"rejects a nested parent path": {
+			path:      "live-auctions/../../escaped.db",
+			reader:    bytes.NewReader([]byte("restored")),
+			expectErr: true,
+		},
+		"rejects an absolute path": {
+			path:      "/tmp/escaped.db",
+			reader:    bytes.NewReader([]byte("restored")),
+			expectErr: true,
+		},
+		"rejects a path that is not a database": {
+			path:      "items.json",
+			reader:    bytes.NewReader([]byte("restored")),
+			expectErr: true,
+		},
+		"refuses to overwrite": {
+			path:      "items.db",
+			existing:  true,
+			reader:    bytes.NewReader([]byte("restored")),
+			expectErr: true,
+			expected:  []byte("existing"),
+		},
+		"overwrites when told to": {
+			path:      "items.db",
+			existing:  true,
+			overwrite: true,
+			reader:    bytes.NewReader([]byte("restored")),
+			expected:  []byte("restored"),
+		},
+		"leaves the existing database on a copy error": {
+			path:      "items.db",
+			existing:  true,
+			overwrite: true,
+			reader:    &failingReader{data: []byte("partial")},
+			expectErr: true,
+			expected:  []byte("existing"),
+		},
+		"leaves nothing behind on a copy error": {
+			path:      "items.db",
+			reader:    &failingReader{data: []byte("partial")},
+			expectErr: true,
+		},
+	}
+
+	for name, s := range scenarios {
+		dbDir, err := ioutil.TempDir("", "restore")
+		if !assert.Nil(t, err, name) {
+			return
+		}
+		defer os.RemoveAll(dbDir)
+
+		dbFilepath := filepath.Join(dbDir, filepath.FromSlash(s.path))
+		if s.existing {
+			if !assert.Nil(t, ioutil.WriteFile(dbFilepath, []byte("existing"), 0600), name) {
+				return
+			}
+		}
+
+		err = RestoreDatabase(dbDir, s.path, s.reader, s.overwrite)
+		if s.expectErr {
+			assert.NotNil(t, err, name)
+		} else if !assert.Nil(t, err, name) {
+			return
+		}
+
+		// the temp file never outlives the restore
+		_, err = os.Stat(fmt.Sprintf("%s.restore", dbFilepath))
+		assert.True(t, os.IsNotExist(err), name)
+
+		data, err := ioutil.ReadFile(dbFilepath)
+		if s.expected == nil {
+			assert.True(t, os.IsNotExist(err), name)
+
+			continue
+		}
+		if !assert.Nil(t, err, name) {
+			return
+		}
+		assert.Equal(t, s.expected, data, name)
+	}
+}
+
+func TestSnapshotTargetRestoreDatabase(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "snapshot")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dbDir)
+
+	restoreDir, err := ioutil.TempDir("", "restore")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(restoreDir)
+
+	statuses := sotah.Statuses{
+		testRegionName: sotah.Status{Realms: sotah.Realms{sotah.NewSkeletonRealm(testRegionName, testRealmSlug)}},
+	}
+	for _, dir := range []string{dbDir, restoreDir} {
+		if !assert.Nil(t, util.EnsureDirExists(fmt.Sprintf("%s/live-auctions/%s", dir, testRegionName))) {
+			return
+		}
+	}
+
+	ladBases, err := NewLiveAuctionsDatabases(dbDir, statuses)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer ladBases.Close()
+
+	maList := sotah.NewMiniAuctionListFromMiniAuctions(sotah.NewMiniAuctions(blizzard.Auctions{
+		Auctions: []blizzard.Auction{{Auc: 1, Item: testItemId, Owner: "owner", Buyout: 10, Quantity: 1}},
+	}))
+	if !assert.Nil(t, ladBases[testRegionName][testRealmSlug].persistMiniAuctionList(maList)) {
+		return
+	}
+
+	targets, err := ladBases.SnapshotTargets(dbDir)
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.Len(t, targets, 1) {
+		return
+	}
+
+	// streaming the open database into a restore elsewhere
+	buf := &bytes.Buffer{}
+	size, err := targets[0].WriteTo(buf)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, int64(buf.Len()), size)
+
+	if !assert.Nil(t, RestoreDatabase(restoreDir, targets[0].Path, buf, false)) {
+		return
+	}
+
+	restoredBases, err := NewLiveAuctionsDatabases(restoreDir, statuses)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer restoredBases.Close()
+
+	restoredList, err := restoredBases[testRegionName][testRealmSlug].GetMiniAuctionList()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, maList, restoredList)
+}
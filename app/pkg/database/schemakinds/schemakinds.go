@@ -0,0 +1,18 @@
+package schemakinds
+
+// SchemaKind - typehint for these enums
+type SchemaKind string
+
+/*
+SchemaKinds - types of local database with their own schema version
+*/
+const (
+	Items                    SchemaKind = "items"
+	Meta                     SchemaKind = "meta"
+	LiveAuctions             SchemaKind = "live_auctions"
+	PricelistHistories       SchemaKind = "pricelist_histories"
+	APIKeys                  SchemaKind = "api_keys"
+	PricelistHistoryRollups  SchemaKind = "pricelist_history_rollups"
+	RegionPricelistHistories SchemaKind = "region_pricelist_histories"
+	Recipes                  SchemaKind = "recipes"
+)
@@ -0,0 +1,155 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/export/formats"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+)
+
+// Row - a single flattened pricelist-history point
+type Row struct {
+	RegionName       blizzard.RegionName
+	RealmSlug        blizzard.RealmSlug
+	ItemID           blizzard.ItemID
+	Timestamp        sotah.UnixTimestamp
+	MinBuyoutPer     float64
+	MaxBuyoutPer     float64
+	AverageBuyoutPer float64
+	MedianBuyoutPer  float64
+	Volume           int64
+}
+
+// RowWriter - writes rows to an underlying file, where Close flushes any buffered rows but does not close the file
+type RowWriter interface {
+	Write(row Row) error
+	Close() error
+}
+
+func NewRowWriter(format formats.Format, w io.Writer) (RowWriter, error) {
+	switch format {
+	case formats.CSV:
+		return newCsvWriter(w)
+	case formats.Parquet:
+		return newParquetWriter(w), nil
+	}
+
+	return nil, fmt.Errorf("unsupported export format: %s", format)
+}
+
+func NewFilter(
+	regionName blizzard.RegionName,
+	realmSlug blizzard.RealmSlug,
+	itemIds []blizzard.ItemID,
+	lowerBounds int64,
+	upperBounds int64,
+) (Filter, error) {
+	if upperBounds > 0 && lowerBounds > upperBounds {
+		return Filter{}, errors.New("lower bounds must not be after upper bounds")
+	}
+
+	out := Filter{
+		RegionName: regionName,
+		RealmSlug:  realmSlug,
+		ItemIds:    itemIds,
+	}
+	if lowerBounds > 0 {
+		out.LowerBounds = time.Unix(lowerBounds, 0)
+	}
+	if upperBounds > 0 {
+		out.UpperBounds = time.Unix(upperBounds, 0)
+	}
+
+	return out, nil
+}
+
+// Filter - narrows an export, where zero values match everything
+type Filter struct {
+	RegionName  blizzard.RegionName
+	RealmSlug   blizzard.RealmSlug
+	ItemIds     []blizzard.ItemID
+	LowerBounds time.Time
+	UpperBounds time.Time
+}
+
+func (f Filter) MatchesRegion(regionName blizzard.RegionName) bool {
+	return f.RegionName == "" || f.RegionName == regionName
+}
+
+func (f Filter) MatchesRealm(realmSlug blizzard.RealmSlug) bool {
+	return f.RealmSlug == "" || f.RealmSlug == realmSlug
+}
+
+// MatchesRange - checks whether anything between the two times may pass the filter
+func (f Filter) MatchesRange(lower time.Time, upper time.Time) bool {
+	if !f.LowerBounds.IsZero() && upper.Before(f.LowerBounds) {
+		return false
+	}
+
+	if !f.UpperBounds.IsZero() && lower.After(f.UpperBounds) {
+		return false
+	}
+
+	return true
+}
+
+func (f Filter) MatchesTimestamp(targetTimestamp sotah.UnixTimestamp) bool {
+	targetTime := time.Unix(int64(targetTimestamp), 0)
+
+	return f.MatchesRange(targetTime, targetTime)
+}
+
+// NewRows flattens the histories of a realm into rows ordered by item id and timestamp
+func NewRows(
+	regionName blizzard.RegionName,
+	realmSlug blizzard.RealmSlug,
+	ipHistories sotah.ItemPriceHistories,
+	filter Filter,
+) []Row {
+	itemIds := make([]blizzard.ItemID, 0, len(ipHistories))
+	for itemId := range ipHistories {
+		itemIds = append(itemIds, itemId)
+	}
+	sort.Slice(itemIds, func(i, j int) bool {
+		return itemIds[i] < itemIds[j]
+	})
+
+	out := []Row{}
+	for _, itemId := range itemIds {
+		pHistory := ipHistories[itemId]
+
+		timestamps := make([]sotah.UnixTimestamp, 0, len(pHistory))
+		for targetTimestamp := range pHistory {
+			if !filter.MatchesTimestamp(targetTimestamp) {
+				continue
+			}
+
+			timestamps = append(timestamps, targetTimestamp)
+		}
+		sort.Slice(timestamps, func(i, j int) bool {
+			return timestamps[i] < timestamps[j]
+		})
+
+		for _, targetTimestamp := range timestamps {
+			pricesValue := pHistory[targetTimestamp]
+			out = append(out, Row{
+				RegionName:       regionName,
+				RealmSlug:        realmSlug,
+				ItemID:           itemId,
+				Timestamp:        targetTimestamp,
+				MinBuyoutPer:     pricesValue.MinBuyoutPer,
+				MaxBuyoutPer:     pricesValue.MaxBuyoutPer,
+				AverageBuyoutPer: pricesValue.AverageBuyoutPer,
+				MedianBuyoutPer:  pricesValue.MedianBuyoutPer,
+				Volume:           pricesValue.Volume,
+			})
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,52 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+var csvHeader = []string{
+	"region",
+	"realm",
+	"item_id",
+	"timestamp",
+	"min_buyout_per",
+	"max_buyout_per",
+	"average_buyout_per",
+	"median_buyout_per",
+	"volume",
+}
+
+func newCsvWriter(w io.Writer) (RowWriter, error) {
+	out := csvWriter{csv.NewWriter(w)}
+	if err := out.w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (cw csvWriter) Write(row Row) error {
+	return cw.w.Write([]string{
+		string(row.RegionName),
+		string(row.RealmSlug),
+		strconv.FormatInt(int64(row.ItemID), 10),
+		strconv.FormatInt(int64(row.Timestamp), 10),
+		strconv.FormatFloat(row.MinBuyoutPer, 'f', -1, 64),
+		strconv.FormatFloat(row.MaxBuyoutPer, 'f', -1, 64),
+		strconv.FormatFloat(row.AverageBuyoutPer, 'f', -1, 64),
+		strconv.FormatFloat(row.MedianBuyoutPer, 'f', -1, 64),
+		strconv.FormatInt(row.Volume, 10),
+	})
+}
+
+func (cw csvWriter) Close() error {
+	cw.w.Flush()
+
+	return cw.w.Error()
+}
@@ -0,0 +1,295 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+/*
+parquet files are written as flat schemas of required columns, with one plain-encoded and gzip-compressed data page
+per column in each row-group, which is the simplest layout that common readers (arrow, spark, duckdb) accept
+*/
+const (
+	parquetMagic        = "PAR1"
+	parquetRowGroupSize = 64 * 1024
+	parquetCreatedBy    = "sotah-server"
+
+	// physical types
+	parquetInt64     int32 = 2
+	parquetDouble    int32 = 5
+	parquetByteArray int32 = 6
+
+	// converted types
+	parquetNoConvertedType int32 = -1
+	parquetUtf8            int32 = 0
+	parquetTimestampMillis int32 = 9
+
+	// remaining enums used in the page headers and file metadata
+	parquetRequired        int32 = 0
+	parquetPlainEncoding   int32 = 0
+	parquetRleEncoding     int32 = 3
+	parquetGzipCodec       int32 = 2
+	parquetDataPageType    int32 = 0
+	parquetMetadataVersion int32 = 1
+)
+
+type parquetColumn struct {
+	name          string
+	physicalType  int32
+	convertedType int32
+	encode        func(row Row, buf *bytes.Buffer)
+}
+
+func encodeParquetString(v string, buf *bytes.Buffer) {
+	encodeParquetLength(len(v), buf)
+	buf.WriteString(v)
+}
+
+func encodeParquetLength(v int, buf *bytes.Buffer) {
+	scratch := make([]byte, 4)
+	binary.LittleEndian.PutUint32(scratch, uint32(v))
+	buf.Write(scratch)
+}
+
+func encodeParquetInt64(v int64, buf *bytes.Buffer) {
+	scratch := make([]byte, 8)
+	binary.LittleEndian.PutUint64(scratch, uint64(v))
+	buf.Write(scratch)
+}
+
+func encodeParquetDouble(v float64, buf *bytes.Buffer) {
+	encodeParquetInt64(int64(math.Float64bits(v)), buf)
+}
+
+var parquetColumns = []parquetColumn{
+	{"region", parquetByteArray, parquetUtf8, func(row Row, buf *bytes.Buffer) {
+		encodeParquetString(string(row.RegionName), buf)
+	}},
+	{"realm", parquetByteArray, parquetUtf8, func(row Row, buf *bytes.Buffer) {
+		encodeParquetString(string(row.RealmSlug), buf)
+	}},
+	{"item_id", parquetInt64, parquetNoConvertedType, func(row Row, buf *bytes.Buffer) {
+		encodeParquetInt64(int64(row.ItemID), buf)
+	}},
+	{"timestamp", parquetInt64, parquetTimestampMillis, func(row Row, buf *bytes.Buffer) {
+		encodeParquetInt64(int64(row.Timestamp)*1000, buf)
+	}},
+	{"min_buyout_per", parquetDouble, parquetNoConvertedType, func(row Row, buf *bytes.Buffer) {
+		encodeParquetDouble(row.MinBuyoutPer, buf)
+	}},
+	{"max_buyout_per", parquetDouble, parquetNoConvertedType, func(row Row, buf *bytes.Buffer) {
+		encodeParquetDouble(row.MaxBuyoutPer, buf)
+	}},
+	{"average_buyout_per", parquetDouble, parquetNoConvertedType, func(row Row, buf *bytes.Buffer) {
+		encodeParquetDouble(row.AverageBuyoutPer, buf)
+	}},
+	{"median_buyout_per", parquetDouble, parquetNoConvertedType, func(row Row, buf *bytes.Buffer) {
+		encodeParquetDouble(row.MedianBuyoutPer, buf)
+	}},
+	{"volume", parquetInt64, parquetNoConvertedType, func(row Row, buf *bytes.Buffer) {
+		encodeParquetInt64(row.Volume, buf)
+	}},
+}
+
+type parquetColumnChunk struct {
+	column           parquetColumn
+	offset           int64
+	numValues        int64
+	uncompressedSize int64
+	compressedSize   int64
+}
+
+type parquetRowGroup struct {
+	chunks  []parquetColumnChunk
+	numRows int64
+}
+
+func (rg parquetRowGroup) totalByteSize() int64 {
+	out := int64(0)
+	for _, chunk := range rg.chunks {
+		out += chunk.uncompressedSize
+	}
+
+	return out
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	return &parquetWriter{w: w}
+}
+
+type parquetWriter struct {
+	w         io.Writer
+	offset    int64
+	rows      []Row
+	rowGroups []parquetRowGroup
+	numRows   int64
+}
+
+func (pw *parquetWriter) write(data []byte) error {
+	n, err := pw.w.Write(data)
+	pw.offset += int64(n)
+
+	return err
+}
+
+func (pw *parquetWriter) Write(row Row) error {
+	if pw.offset == 0 {
+		if err := pw.write([]byte(parquetMagic)); err != nil {
+			return err
+		}
+	}
+
+	pw.rows = append(pw.rows, row)
+	if len(pw.rows) < parquetRowGroupSize {
+		return nil
+	}
+
+	return pw.flushRowGroup()
+}
+
+func (pw *parquetWriter) flushRowGroup() error {
+	if len(pw.rows) == 0 {
+		return nil
+	}
+
+	rowGroup := parquetRowGroup{numRows: int64(len(pw.rows))}
+	for _, column := range parquetColumns {
+		chunk, err := pw.writeColumnChunk(column)
+		if err != nil {
+			return err
+		}
+
+		rowGroup.chunks = append(rowGroup.chunks, chunk)
+	}
+
+	pw.rowGroups = append(pw.rowGroups, rowGroup)
+	pw.numRows += rowGroup.numRows
+	pw.rows = []Row{}
+
+	return nil
+}
+
+func (pw *parquetWriter) writeColumnChunk(column parquetColumn) (parquetColumnChunk, error) {
+	values := bytes.Buffer{}
+	for _, row := range pw.rows {
+		column.encode(row, &values)
+	}
+
+	compressed := bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(&compressed)
+	if _, err := gzipWriter.Write(values.Bytes()); err != nil {
+		return parquetColumnChunk{}, err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return parquetColumnChunk{}, err
+	}
+
+	header := newThriftWriter()
+	header.I32(1, parquetDataPageType)
+	header.I32(2, int32(values.Len()))
+	header.I32(3, int32(compressed.Len()))
+	header.BeginStruct(5)
+	header.I32(1, int32(len(pw.rows)))
+	header.I32(2, parquetPlainEncoding)
+	header.I32(3, parquetRleEncoding)
+	header.I32(4, parquetRleEncoding)
+	header.EndStruct()
+	header.EndStruct()
+
+	out := parquetColumnChunk{
+		column:           column,
+		offset:           pw.offset,
+		numValues:        int64(len(pw.rows)),
+		uncompressedSize: int64(len(header.Bytes()) + values.Len()),
+		compressedSize:   int64(len(header.Bytes()) + compressed.Len()),
+	}
+
+	if err := pw.write(header.Bytes()); err != nil {
+		return parquetColumnChunk{}, err
+	}
+	if err := pw.write(compressed.Bytes()); err != nil {
+		return parquetColumnChunk{}, err
+	}
+
+	return out, nil
+}
+
+func (pw *parquetWriter) encodeFileMetadata() []byte {
+	tw := newThriftWriter()
+	tw.I32(1, parquetMetadataVersion)
+
+	// schema, as a root element followed by its children
+	tw.StructList(2, len(parquetColumns)+1, func(i int) {
+		if i == 0 {
+			tw.String(4, "schema")
+			tw.I32(5, int32(len(parquetColumns)))
+
+			return
+		}
+
+		column := parquetColumns[i-1]
+		tw.I32(1, column.physicalType)
+		tw.I32(3, parquetRequired)
+		tw.String(4, column.name)
+		if column.convertedType != parquetNoConvertedType {
+			tw.I32(6, column.convertedType)
+		}
+	})
+
+	tw.I64(3, pw.numRows)
+
+	tw.StructList(4, len(pw.rowGroups), func(i int) {
+		rowGroup := pw.rowGroups[i]
+
+		tw.StructList(1, len(rowGroup.chunks), func(j int) {
+			chunk := rowGroup.chunks[j]
+
+			tw.I64(2, chunk.offset)
+			tw.BeginStruct(3)
+			tw.I32(1, chunk.column.physicalType)
+			tw.I32List(2, []int32{parquetPlainEncoding, parquetRleEncoding})
+			tw.StringList(3, []string{chunk.column.name})
+			tw.I32(4, parquetGzipCodec)
+			tw.I64(5, chunk.numValues)
+			tw.I64(6, chunk.uncompressedSize)
+			tw.I64(7, chunk.compressedSize)
+			tw.I64(9, chunk.offset)
+			tw.EndStruct()
+		})
+		tw.I64(2, rowGroup.totalByteSize())
+		tw.I64(3, rowGroup.numRows)
+	})
+
+	tw.String(6, parquetCreatedBy)
+	tw.EndStruct()
+
+	return tw.Bytes()
+}
+
+func (pw *parquetWriter) Close() error {
+	if pw.offset == 0 {
+		if err := pw.write([]byte(parquetMagic)); err != nil {
+			return err
+		}
+	}
+
+	if err := pw.flushRowGroup(); err != nil {
+		return err
+	}
+
+	metadata := pw.encodeFileMetadata()
+	if err := pw.write(metadata); err != nil {
+		return err
+	}
+
+	footerLength := make([]byte, 4)
+	binary.LittleEndian.PutUint32(footerLength, uint32(len(metadata)))
+	if err := pw.write(footerLength); err != nil {
+		return err
+	}
+
+	return pw.write([]byte(parquetMagic))
+}
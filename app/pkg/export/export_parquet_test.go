@@ -0,0 +1,234 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+)
+
+/*
+thriftReader - minimal thrift compact-protocol decoder for reading back what the writer produces, independent of the
+writer's own bookkeeping, where structs are decoded into maps of field id to value
+*/
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+type thriftValues map[int16]interface{}
+
+func (tr *thriftReader) readByte() (byte, error) {
+	if tr.pos >= len(tr.data) {
+		return 0, errors.New("unexpected end of thrift data")
+	}
+
+	b := tr.data[tr.pos]
+	tr.pos++
+
+	return b, nil
+}
+
+func (tr *thriftReader) readVarint() (uint64, error) {
+	v, n := binary.Uvarint(tr.data[tr.pos:])
+	if n <= 0 {
+		return 0, errors.New("invalid thrift varint")
+	}
+	tr.pos += n
+
+	return v, nil
+}
+
+func (tr *thriftReader) readZigzag() (int64, error) {
+	v, err := tr.readVarint()
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(v>>1) ^ -int64(v&1), nil
+}
+
+func (tr *thriftReader) readValue(valueType byte) (interface{}, error) {
+	switch valueType {
+	case thriftI32, thriftI64:
+		return tr.readZigzag()
+	case thriftBinary:
+		size, err := tr.readVarint()
+		if err != nil {
+			return nil, err
+		}
+		if tr.pos+int(size) > len(tr.data) {
+			return nil, errors.New("thrift binary exceeds data")
+		}
+
+		out := string(tr.data[tr.pos : tr.pos+int(size)])
+		tr.pos += int(size)
+
+		return out, nil
+	case thriftList:
+		header, err := tr.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		size := uint64(header >> 4)
+		if size == 15 {
+			if size, err = tr.readVarint(); err != nil {
+				return nil, err
+			}
+		}
+
+		out := make([]interface{}, size)
+		for i := range out {
+			if out[i], err = tr.readValue(header & 0x0F); err != nil {
+				return nil, err
+			}
+		}
+
+		return out, nil
+	case thriftStruct:
+		return tr.readStruct()
+	}
+
+	return nil, fmt.Errorf("unsupported thrift type %d", valueType)
+}
+
+func (tr *thriftReader) readStruct() (thriftValues, error) {
+	out := thriftValues{}
+	lastFieldId := int16(0)
+	for {
+		header, err := tr.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return out, nil
+		}
+
+		fieldId := lastFieldId + int16(header>>4)
+		if header>>4 == 0 {
+			id, err := tr.readZigzag()
+			if err != nil {
+				return nil, err
+			}
+			fieldId = int16(id)
+		}
+		lastFieldId = fieldId
+
+		if out[fieldId], err = tr.readValue(header & 0x0F); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readParquetColumnChunk decodes the single data page of a column chunk into its values
+func readParquetColumnChunk(data []byte, meta thriftValues) ([]interface{}, error) {
+	tr := &thriftReader{data: data, pos: int(meta[9].(int64))}
+	header, err := tr.readStruct()
+	if err != nil {
+		return nil, err
+	}
+
+	if header[1].(int64) != int64(parquetDataPageType) {
+		return nil, errors.New("column chunk does not start with a data page")
+	}
+
+	compressedSize := int(header[3].(int64))
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data[tr.pos : tr.pos+compressedSize]))
+	if err != nil {
+		return nil, err
+	}
+	page, err := ioutil.ReadAll(gzipReader)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(page) != int(header[2].(int64)) {
+		return nil, fmt.Errorf("page is %d bytes where %d were declared", len(page), header[2].(int64))
+	}
+
+	numValues := int(header[5].(thriftValues)[1].(int64))
+	out := make([]interface{}, numValues)
+	for i := range out {
+		switch int32(meta[1].(int64)) {
+		case parquetByteArray:
+			size := int(binary.LittleEndian.Uint32(page))
+			out[i] = string(page[4 : 4+size])
+			page = page[4+size:]
+		case parquetInt64:
+			out[i] = int64(binary.LittleEndian.Uint64(page))
+			page = page[8:]
+		case parquetDouble:
+			out[i] = math.Float64frombits(binary.LittleEndian.Uint64(page))
+			page = page[8:]
+		default:
+			return nil, fmt.Errorf("unsupported physical type %d", meta[1].(int64))
+		}
+	}
+
+	if len(page) != 0 {
+		return nil, fmt.Errorf("%d bytes left over in the page", len(page))
+	}
+
+	return out, nil
+}
+
+// readParquetRows reads the rows back out of a file by way of its footer metadata
+func readParquetRows(data []byte) ([]Row, error) {
+	if len(data) < 12 || string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		return nil, errors.New("missing parquet magic")
+	}
+
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8 : len(data)-4]))
+	tr := &thriftReader{data: data[len(data)-8-footerLength : len(data)-8]}
+	metadata, err := tr.readStruct()
+	if err != nil {
+		return nil, err
+	}
+
+	out := []Row{}
+	for _, rowGroupValue := range metadata[4].([]interface{}) {
+		rowGroup := rowGroupValue.(thriftValues)
+		numRows := int(rowGroup[3].(int64))
+
+		columns := map[string][]interface{}{}
+		for _, chunkValue := range rowGroup[1].([]interface{}) {
+			meta := chunkValue.(thriftValues)[3].(thriftValues)
+			values, err := readParquetColumnChunk(data, meta)
+			if err != nil {
+				return nil, err
+			}
+			if len(values) != numRows {
+				return nil, fmt.Errorf("column has %d values where the row-group has %d rows", len(values), numRows)
+			}
+
+			columns[meta[3].([]interface{})[0].(string)] = values
+		}
+
+		for i := 0; i < numRows; i++ {
+			out = append(out, Row{
+				RegionName:       blizzard.RegionName(columns["region"][i].(string)),
+				RealmSlug:        blizzard.RealmSlug(columns["realm"][i].(string)),
+				ItemID:           blizzard.ItemID(columns["item_id"][i].(int64)),
+				Timestamp:        sotah.UnixTimestamp(columns["timestamp"][i].(int64) / 1000),
+				MinBuyoutPer:     columns["min_buyout_per"][i].(float64),
+				MaxBuyoutPer:     columns["max_buyout_per"][i].(float64),
+				AverageBuyoutPer: columns["average_buyout_per"][i].(float64),
+				MedianBuyoutPer:  columns["median_buyout_per"][i].(float64),
+				Volume:           columns["volume"][i].(int64),
+			})
+		}
+	}
+
+	if int64(len(out)) != metadata[3].(int64) {
+		return nil, fmt.Errorf("read %d rows where the metadata declares %d", len(out), metadata[3].(int64))
+	}
+
+	return out, nil
+}
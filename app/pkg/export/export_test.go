@@ -0,0 +1,223 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/export/formats"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/utiltest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestItemPriceHistories() sotah.ItemPriceHistories {
+	return sotah.ItemPriceHistories{
+		blizzard.ItemID(2): sotah.PriceHistory{
+			sotah.UnixTimestamp(200): sotah.Prices{MinBuyoutPer: 4, MedianBuyoutPer: 5, Volume: 6},
+			sotah.UnixTimestamp(100): sotah.Prices{MinBuyoutPer: 1, MedianBuyoutPer: 2, Volume: 3},
+		},
+		blizzard.ItemID(1): sotah.PriceHistory{
+			sotah.UnixTimestamp(300): sotah.Prices{MinBuyoutPer: 1.5, Volume: 1},
+		},
+	}
+}
+
+func TestNewRows(t *testing.T) {
+	filter, err := NewFilter("", "", nil, 150, 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	rows := NewRows("us", "earthen-ring", newTestItemPriceHistories(), filter)
+	if !assert.Len(t, rows, 2) {
+		return
+	}
+
+	assert.Equal(t, blizzard.ItemID(1), rows[0].ItemID)
+	assert.Equal(t, blizzard.ItemID(2), rows[1].ItemID)
+	assert.Equal(t, sotah.UnixTimestamp(200), rows[1].Timestamp)
+	assert.Equal(t, int64(6), rows[1].Volume)
+	assert.False(t, filter.MatchesRange(time.Unix(0, 0), time.Unix(100, 0)))
+}
+
+func TestCsvWriter(t *testing.T) {
+	buf := bytes.Buffer{}
+	w, err := NewRowWriter(formats.CSV, &buf)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	for _, row := range NewRows("us", "earthen-ring", newTestItemPriceHistories(), Filter{}) {
+		if !assert.Nil(t, w.Write(row)) {
+			return
+		}
+	}
+	if !assert.Nil(t, w.Close()) {
+		return
+	}
+
+	assert.Equal(
+		t,
+		"region,realm,item_id,timestamp,min_buyout_per,max_buyout_per,average_buyout_per,median_buyout_per,volume\n"+
+			"us,earthen-ring,1,300,1.5,0,0,0,1\n"+
+			"us,earthen-ring,2,100,1,0,0,2,3\n"+
+			"us,earthen-ring,2,200,4,0,0,5,6\n",
+		buf.String(),
+	)
+}
+
+func writeTestParquet(t *testing.T, rows []Row) ([]byte, bool) {
+	buf := bytes.Buffer{}
+	w, err := NewRowWriter(formats.Parquet, &buf)
+	if !assert.Nil(t, err) {
+		return nil, false
+	}
+
+	for _, row := range rows {
+		if !assert.Nil(t, w.Write(row)) {
+			return nil, false
+		}
+	}
+	if !assert.Nil(t, w.Close()) {
+		return nil, false
+	}
+
+	return buf.Bytes(), true
+}
+
+func TestParquetWriter(t *testing.T) {
+	rows := NewRows("us", "earthen-ring", newTestItemPriceHistories(), Filter{})
+	data, ok := writeTestParquet(t, rows)
+	if !ok {
+		return
+	}
+
+	if !assert.True(t, len(data) > 12) {
+		return
+	}
+
+	assert.Equal(t, parquetMagic, string(data[:4]))
+	assert.Equal(t, parquetMagic, string(data[len(data)-4:]))
+
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8 : len(data)-4]))
+	assert.True(t, footerLength > 0 && footerLength < len(data)-12)
+
+	readRows, err := readParquetRows(data)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, rows, readRows)
+}
+
+/*
+the golden fixture is read back with pyarrow where it is installed, so the writer producing it byte for byte keeps its
+output readable by a real parquet implementation and not only by the reader in these tests
+*/
+const parquetGoldenFixture = "../../TestData/pricelist-histories.parquet"
+
+func TestParquetWriterGolden(t *testing.T) {
+	golden, err := utiltest.ReadFile(parquetGoldenFixture)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	data, ok := writeTestParquet(t, NewRows("us", "earthen-ring", newTestItemPriceHistories(), Filter{}))
+	if !ok {
+		return
+	}
+
+	assert.Equal(t, golden, data)
+}
+
+// pyarrowReadScript prints the rows of the given parquet file as json, with the timestamps as unix milliseconds
+const pyarrowReadScript = `
+import json, sys
+import pyarrow as pa
+import pyarrow.parquet as pq
+
+table = pq.read_table(sys.argv[1])
+timestamps = table.column("timestamp").cast(pa.int64())
+table = table.set_column(table.schema.get_field_index("timestamp"), "timestamp", timestamps)
+print(json.dumps(table.to_pylist()))
+`
+
+func TestParquetGoldenPyarrow(t *testing.T) {
+	if err := exec.Command("python3", "-c", "import pyarrow.parquet").Run(); err != nil {
+		t.Skip("pyarrow is not installed")
+	}
+
+	path, err := filepath.Abs(parquetGoldenFixture)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	out, err := exec.Command("python3", "-c", pyarrowReadScript, path).Output()
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var readRows []map[string]interface{}
+	if !assert.Nil(t, json.Unmarshal(out, &readRows)) {
+		return
+	}
+
+	expected := []map[string]interface{}{}
+	for _, row := range NewRows("us", "earthen-ring", newTestItemPriceHistories(), Filter{}) {
+		expected = append(expected, map[string]interface{}{
+			"region":             string(row.RegionName),
+			"realm":              string(row.RealmSlug),
+			"item_id":            float64(row.ItemID),
+			"timestamp":          float64(row.Timestamp * 1000),
+			"min_buyout_per":     row.MinBuyoutPer,
+			"max_buyout_per":     row.MaxBuyoutPer,
+			"average_buyout_per": row.AverageBuyoutPer,
+			"median_buyout_per":  row.MedianBuyoutPer,
+			"volume":             float64(row.Volume),
+		})
+	}
+	assert.Equal(t, expected, readRows)
+}
+
+func TestParquetWriterRowGroups(t *testing.T) {
+	rows := make([]Row, parquetRowGroupSize+1)
+	for i := range rows {
+		rows[i] = Row{
+			RegionName:      "us",
+			RealmSlug:       "earthen-ring",
+			ItemID:          blizzard.ItemID(i),
+			Timestamp:       sotah.UnixTimestamp(i),
+			MedianBuyoutPer: float64(i) / 2,
+			Volume:          int64(i),
+		}
+	}
+
+	data, ok := writeTestParquet(t, rows)
+	if !ok {
+		return
+	}
+
+	readRows, err := readParquetRows(data)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, rows, readRows)
+}
+
+func TestParquetWriterEmpty(t *testing.T) {
+	data, ok := writeTestParquet(t, []Row{})
+	if !ok {
+		return
+	}
+
+	readRows, err := readParquetRows(data)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Empty(t, readRows)
+}
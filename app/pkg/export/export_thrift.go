@@ -0,0 +1,119 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// thrift compact-protocol type ids, covering only what the parquet metadata needs
+const (
+	thriftI32    byte = 5
+	thriftI64    byte = 6
+	thriftBinary byte = 8
+	thriftList   byte = 9
+	thriftStruct byte = 12
+)
+
+// thriftWriter - minimal thrift compact-protocol encoder for parquet page headers and file metadata
+type thriftWriter struct {
+	buf         bytes.Buffer
+	lastFieldId []int16
+}
+
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{lastFieldId: []int16{0}}
+}
+
+func (tw *thriftWriter) Bytes() []byte {
+	return tw.buf.Bytes()
+}
+
+func (tw *thriftWriter) writeVarint(v uint64) {
+	scratch := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(scratch, v)
+	tw.buf.Write(scratch[:n])
+}
+
+func (tw *thriftWriter) writeZigzag(v int64) {
+	tw.writeVarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (tw *thriftWriter) fieldHeader(id int16, fieldType byte) {
+	last := tw.lastFieldId[len(tw.lastFieldId)-1]
+	if delta := id - last; delta > 0 && delta <= 15 {
+		tw.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		tw.buf.WriteByte(fieldType)
+		tw.writeZigzag(int64(id))
+	}
+
+	tw.lastFieldId[len(tw.lastFieldId)-1] = id
+}
+
+func (tw *thriftWriter) I32(id int16, v int32) {
+	tw.fieldHeader(id, thriftI32)
+	tw.writeZigzag(int64(v))
+}
+
+func (tw *thriftWriter) I64(id int16, v int64) {
+	tw.fieldHeader(id, thriftI64)
+	tw.writeZigzag(v)
+}
+
+func (tw *thriftWriter) String(id int16, v string) {
+	tw.fieldHeader(id, thriftBinary)
+	tw.writeVarint(uint64(len(v)))
+	tw.buf.WriteString(v)
+}
+
+// BeginStruct - opens a struct field, which must be closed with EndStruct
+func (tw *thriftWriter) BeginStruct(id int16) {
+	tw.fieldHeader(id, thriftStruct)
+	tw.beginStructValue()
+}
+
+func (tw *thriftWriter) beginStructValue() {
+	tw.lastFieldId = append(tw.lastFieldId, 0)
+}
+
+func (tw *thriftWriter) EndStruct() {
+	tw.buf.WriteByte(0)
+	tw.lastFieldId = tw.lastFieldId[:len(tw.lastFieldId)-1]
+}
+
+func (tw *thriftWriter) listHeader(id int16, elemType byte, size int) {
+	tw.fieldHeader(id, thriftList)
+	if size < 15 {
+		tw.buf.WriteByte(byte(size)<<4 | elemType)
+
+		return
+	}
+
+	tw.buf.WriteByte(0xF0 | elemType)
+	tw.writeVarint(uint64(size))
+}
+
+func (tw *thriftWriter) I32List(id int16, values []int32) {
+	tw.listHeader(id, thriftI32, len(values))
+	for _, v := range values {
+		tw.writeZigzag(int64(v))
+	}
+}
+
+func (tw *thriftWriter) StringList(id int16, values []string) {
+	tw.listHeader(id, thriftBinary, len(values))
+	for _, v := range values {
+		tw.writeVarint(uint64(len(v)))
+		tw.buf.WriteString(v)
+	}
+}
+
+// StructList - writes a list of structs, where each element is written by the callback between begin and end
+func (tw *thriftWriter) StructList(id int16, size int, writeElem func(i int)) {
+	tw.listHeader(id, thriftStruct, size)
+	for i := 0; i < size; i++ {
+		tw.beginStructValue()
+		writeElem(i)
+		tw.EndStruct()
+	}
+}
@@ -0,0 +1,12 @@
+package formats
+
+// Format - typehint for these enums
+type Format string
+
+/*
+Formats - file formats that rows may be exported to
+*/
+const (
+	CSV     Format = "csv"
+	Parquet Format = "parquet"
+)
@@ -0,0 +1,206 @@
+package forecast
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/sotah"
+)
+
+const (
+	step = 60 * 60
+
+	hoursPerDay  = 24
+	hoursPerWeek = hoursPerDay * 7
+
+	// smoothing factors for the level and trend, and a damping factor so the trend flattens out over days
+	alpha = 0.3
+	beta  = 0.05
+	phi   = 0.98
+
+	// z-score for a 95% confidence interval
+	confidenceZ = 1.96
+)
+
+// Point - predicted value with its confidence interval
+type Point struct {
+	Value float64 `json:"value"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+type Prediction struct {
+	MinBuyoutPer    Point `json:"min_buyout_per"`
+	MedianBuyoutPer Point `json:"median_buyout_per"`
+}
+
+type Forecast map[sotah.UnixTimestamp]Prediction
+
+// NewForecast fits a damped-trend exponential smoothing model with an additive seasonal component over the history
+// and projects it hourly for the given amount of days. Weekly seasonality is used when the history covers at least
+// two weeks, daily seasonality when it covers at least two days.
+func NewForecast(pHistory sotah.PriceHistory, days int) Forecast {
+	out := Forecast{}
+	if len(pHistory) == 0 || days <= 0 {
+		return out
+	}
+
+	startTimestamp, minSeries, medianSeries := resample(pHistory)
+	lastTimestamp := startTimestamp + sotah.UnixTimestamp((len(minSeries)-1)*step)
+
+	minModel := fit(startTimestamp, minSeries)
+	medianModel := fit(startTimestamp, medianSeries)
+
+	for h := 1; h <= days*hoursPerDay; h++ {
+		targetTimestamp := lastTimestamp + sotah.UnixTimestamp(h*step)
+
+		out[targetTimestamp] = Prediction{
+			MinBuyoutPer:    minModel.predict(targetTimestamp, h),
+			MedianBuyoutPer: medianModel.predict(targetTimestamp, h),
+		}
+	}
+
+	return out
+}
+
+// resample buckets the history into hourly points, keeping the latest sample per hour and carrying the previous
+// value forward across gaps
+func resample(pHistory sotah.PriceHistory) (sotah.UnixTimestamp, []float64, []float64) {
+	timestamps := make([]sotah.UnixTimestamp, 0, len(pHistory))
+	for targetTimestamp := range pHistory {
+		timestamps = append(timestamps, targetTimestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
+	})
+
+	startTimestamp := timestamps[0] - timestamps[0]%step
+	endTimestamp := timestamps[len(timestamps)-1] - timestamps[len(timestamps)-1]%step
+	length := int((endTimestamp-startTimestamp)/step) + 1
+
+	minSeries := make([]float64, length)
+	medianSeries := make([]float64, length)
+	filled := make([]bool, length)
+	for _, targetTimestamp := range timestamps {
+		i := int((targetTimestamp - targetTimestamp%step - startTimestamp) / step)
+		minSeries[i] = pHistory[targetTimestamp].MinBuyoutPer
+		medianSeries[i] = pHistory[targetTimestamp].MedianBuyoutPer
+		filled[i] = true
+	}
+
+	for i := 1; i < length; i++ {
+		if filled[i] {
+			continue
+		}
+
+		minSeries[i] = minSeries[i-1]
+		medianSeries[i] = medianSeries[i-1]
+	}
+
+	return startTimestamp, minSeries, medianSeries
+}
+
+func seasonIndex(targetTimestamp sotah.UnixTimestamp, period int) int {
+	t := time.Unix(int64(targetTimestamp), 0).UTC()
+	if period == hoursPerWeek {
+		return int(t.Weekday())*hoursPerDay + t.Hour()
+	}
+
+	return t.Hour()
+}
+
+type model struct {
+	level    float64
+	trend    float64
+	sigma    float64
+	period   int
+	seasonal []float64
+}
+
+func fit(startTimestamp sotah.UnixTimestamp, series []float64) model {
+	m := model{}
+
+	switch {
+	case len(series) >= 2*hoursPerWeek:
+		m.period = hoursPerWeek
+	case len(series) >= 2*hoursPerDay:
+		m.period = hoursPerDay
+	}
+
+	// gathering seasonal offsets as the mean deviation of each season slot from the overall mean
+	if m.period > 0 {
+		total := float64(0)
+		for _, v := range series {
+			total += v
+		}
+		mean := total / float64(len(series))
+
+		sums := make([]float64, m.period)
+		counts := make([]int, m.period)
+		for i, v := range series {
+			k := seasonIndex(startTimestamp+sotah.UnixTimestamp(i*step), m.period)
+			sums[k] += v - mean
+			counts[k]++
+		}
+
+		m.seasonal = make([]float64, m.period)
+		for k := range sums {
+			if counts[k] == 0 {
+				continue
+			}
+
+			m.seasonal[k] = sums[k] / float64(counts[k])
+		}
+	}
+
+	deseasonalize := func(i int) float64 {
+		if m.period == 0 {
+			return series[i]
+		}
+
+		return series[i] - m.seasonal[seasonIndex(startTimestamp+sotah.UnixTimestamp(i*step), m.period)]
+	}
+
+	// running the damped holt recursion and gathering one-step-ahead errors
+	m.level = deseasonalize(0)
+	if len(series) > 1 {
+		m.trend = deseasonalize(1) - deseasonalize(0)
+	}
+
+	squaredErrors := float64(0)
+	for i := 1; i < len(series); i++ {
+		y := deseasonalize(i)
+		expected := m.level + phi*m.trend
+		squaredErrors += (y - expected) * (y - expected)
+
+		previousLevel := m.level
+		m.level = alpha*y + (1-alpha)*expected
+		m.trend = beta*(m.level-previousLevel) + (1-beta)*phi*m.trend
+	}
+	if len(series) > 1 {
+		m.sigma = math.Sqrt(squaredErrors / float64(len(series)-1))
+	}
+
+	return m
+}
+
+func (m model) predict(targetTimestamp sotah.UnixTimestamp, h int) Point {
+	dampedTrend := float64(0)
+	for i := 1; i <= h; i++ {
+		dampedTrend += math.Pow(phi, float64(i)) * m.trend
+	}
+
+	value := m.level + dampedTrend
+	if m.period > 0 {
+		value += m.seasonal[seasonIndex(targetTimestamp, m.period)]
+	}
+
+	spread := confidenceZ * m.sigma * math.Sqrt(float64(h))
+
+	return Point{
+		Value: math.Max(value, 0),
+		Lower: math.Max(value-spread, 0),
+		Upper: math.Max(value+spread, 0),
+	}
+}
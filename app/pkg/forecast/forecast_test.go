@@ -0,0 +1,61 @@
+package forecast
+
+import (
+	"testing"
+
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewForecastFlat(t *testing.T) {
+	pHistory := sotah.PriceHistory{}
+	for i := 0; i < 72; i++ {
+		pHistory[sotah.UnixTimestamp(i*step)] = sotah.Prices{MinBuyoutPer: 100, MedianBuyoutPer: 150}
+	}
+
+	result := NewForecast(pHistory, 2)
+	if !assert.Len(t, result, 2*hoursPerDay) {
+		return
+	}
+
+	for _, prediction := range result {
+		if !assert.InDelta(t, 100, prediction.MinBuyoutPer.Value, 0.001) {
+			return
+		}
+		if !assert.InDelta(t, 150, prediction.MedianBuyoutPer.Value, 0.001) {
+			return
+		}
+	}
+}
+
+func TestNewForecastDailySeason(t *testing.T) {
+	pHistory := sotah.PriceHistory{}
+	for i := 0; i < 7*hoursPerDay; i++ {
+		value := float64(100)
+		if i%hoursPerDay == 12 {
+			value = 200
+		}
+
+		pHistory[sotah.UnixTimestamp(i*step)] = sotah.Prices{MinBuyoutPer: value, MedianBuyoutPer: value}
+	}
+
+	result := NewForecast(pHistory, 1)
+	for targetTimestamp, prediction := range result {
+		if seasonIndex(targetTimestamp, hoursPerDay) != 12 {
+			continue
+		}
+
+		if !assert.True(t, prediction.MinBuyoutPer.Value > 150) {
+			return
+		}
+		if !assert.True(t, prediction.MinBuyoutPer.Lower <= prediction.MinBuyoutPer.Value) {
+			return
+		}
+	}
+}
+
+func TestNewForecastEmpty(t *testing.T) {
+	if !assert.Empty(t, NewForecast(sotah.PriceHistory{}, 7)) {
+		return
+	}
+}
@@ -0,0 +1,106 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Check - reports nil when whatever it covers is healthy
+type Check func() error
+
+// Config - where the health server listens, with a blank address disabling it
+type Config struct {
+	Addr string
+
+	// how long a command may go without a successful intake before it is no longer considered alive, where zero
+	// disables the intake check
+	IntakeMaxAge time.Duration
+}
+
+func (c Config) Enabled() bool {
+	return len(c.Addr) > 0
+}
+
+const (
+	checkOk      = "ok"
+	statusOk     = "ok"
+	statusFailed = "failed"
+)
+
+type checks map[string]Check
+
+// run calls every check, reporting the error of each failing one by its name
+func (cs checks) run() (map[string]string, bool) {
+	out := map[string]string{}
+	ok := true
+	for name, check := range cs {
+		if err := check(); err != nil {
+			out[name] = err.Error()
+			ok = false
+
+			continue
+		}
+
+		out[name] = checkOk
+	}
+
+	return out, ok
+}
+
+func NewFlag(name string) Flag {
+	var raised int32
+
+	return Flag{name: name, raised: &raised}
+}
+
+// Flag - a check for a one-off step, such as opening listeners, failing until the step is done
+type Flag struct {
+	name   string
+	raised *int32
+}
+
+func (f Flag) Raise() {
+	atomic.StoreInt32(f.raised, 1)
+}
+
+func (f Flag) Lower() {
+	atomic.StoreInt32(f.raised, 0)
+}
+
+func (f Flag) Check() error {
+	if atomic.LoadInt32(f.raised) == 0 {
+		return fmt.Errorf("%s not done", f.name)
+	}
+
+	return nil
+}
+
+/*
+NewFreshnessCheck fails once latest reports a time older than the max-age, where a zero time is measured from the
+creation of the check so that a command which has yet to take anything in is given as long as any other
+*/
+func NewFreshnessCheck(maxAge time.Duration, latest func() time.Time) Check {
+	createdAt := time.Now()
+
+	return func() error {
+		if maxAge == 0 {
+			return nil
+		}
+
+		last := latest()
+		if last.IsZero() {
+			last = createdAt
+		}
+
+		if age := time.Since(last); age > maxAge {
+			return fmt.Errorf("last intake was %s ago, past the max-age of %s", age.Truncate(time.Second), maxAge)
+		}
+
+		return nil
+	}
+}
+
+// ErrDraining - readiness fails once shutdown starts, so that no new work is routed here
+var ErrDraining = errors.New("shutting down")
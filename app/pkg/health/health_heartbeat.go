@@ -0,0 +1,60 @@
+package health
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// staleIntervals - how many intervals a heartbeat may miss before its worker is considered stuck
+const staleIntervals = 3
+
+func NewHeartbeat(interval time.Duration) Heartbeat {
+	return newHeartbeat(interval, time.Now)
+}
+
+func newHeartbeat(interval time.Duration, now func() time.Time) Heartbeat {
+	last := now().UnixNano()
+
+	return Heartbeat{interval: interval, last: &last, now: now}
+}
+
+/*
+Heartbeat - beaten by a ticker-driven worker on every tick, so that a worker which stopped progressing fails its
+liveness check; the zero value never fails, for workers that are not monitored
+*/
+type Heartbeat struct {
+	interval time.Duration
+	last     *int64
+
+	// the clock beats and checks are taken by, which tests may control
+	now func() time.Time
+}
+
+func (h Heartbeat) Beat() {
+	if h.last == nil {
+		return
+	}
+
+	atomic.StoreInt64(h.last, h.now().UnixNano())
+}
+
+func (h Heartbeat) Last() time.Time {
+	if h.last == nil {
+		return time.Time{}
+	}
+
+	return time.Unix(0, atomic.LoadInt64(h.last))
+}
+
+func (h Heartbeat) Check() error {
+	if h.last == nil {
+		return nil
+	}
+
+	if age := h.now().Sub(h.Last()); age > staleIntervals*h.interval {
+		return fmt.Errorf("last beat was %s ago, on an interval of %s", age.Truncate(time.Second), h.interval)
+	}
+
+	return nil
+}
@@ -0,0 +1,254 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/logging"
+)
+
+const (
+	LivenessPath  = "/healthz/live"
+	ReadinessPath = "/healthz/ready"
+	StatusPath    = "/debug/status"
+)
+
+func NewServer(config Config) Server {
+	return Server{
+		config:    config,
+		startTime: time.Now(),
+		state: &serverState{
+			readinessChecks: checks{},
+			livenessChecks:  checks{},
+			statuses:        map[string]func() interface{}{},
+			handlers:        map[string]http.Handler{},
+		},
+	}
+}
+
+/*
+Server - serves liveness, readiness and a status page over http, where readiness fails until Started is called and
+again once Drain is called, so that checks may be added while the command starts up
+*/
+type Server struct {
+	config    Config
+	startTime time.Time
+	state     *serverState
+}
+
+type serverState struct {
+	sync.RWMutex
+
+	httpServer *http.Server
+
+	readinessChecks checks
+	livenessChecks  checks
+	statuses        map[string]func() interface{}
+	handlers        map[string]http.Handler
+
+	started  bool
+	draining bool
+}
+
+func (s Server) Config() Config {
+	return s.config
+}
+
+func (s Server) AddReadinessCheck(name string, check Check) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.state.readinessChecks[name] = check
+}
+
+func (s Server) AddLivenessCheck(name string, check Check) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.state.livenessChecks[name] = check
+}
+
+// AddStatus adds a section to the status page, where provide must be safe to call from any goroutine
+func (s Server) AddStatus(name string, provide func() interface{}) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.state.statuses[name] = provide
+}
+
+// Handle serves another handler alongside health, such as metrics, and must be called before Listen
+func (s Server) Handle(pattern string, handler http.Handler) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.state.handlers[pattern] = handler
+}
+
+func (s Server) Started() {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.state.started = true
+}
+
+func (s Server) Drain() {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.state.draining = true
+}
+
+func (s Server) readiness() (map[string]string, bool) {
+	s.state.RLock()
+	defer s.state.RUnlock()
+
+	results, ok := s.state.readinessChecks.run()
+	switch {
+	case s.state.draining:
+		results["startup"] = ErrDraining.Error()
+		ok = false
+	case !s.state.started:
+		results["startup"] = "starting"
+		ok = false
+	default:
+		results["startup"] = checkOk
+	}
+
+	return results, ok
+}
+
+func (s Server) liveness() (map[string]string, bool) {
+	s.state.RLock()
+	defer s.state.RUnlock()
+
+	return s.state.livenessChecks.run()
+}
+
+type checkResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+type statusResponse struct {
+	Uptime    string                 `json:"uptime"`
+	StartTime int64                  `json:"start_time"`
+	Readiness checkResponse          `json:"readiness"`
+	Liveness  checkResponse          `json:"liveness"`
+	Statuses  map[string]interface{} `json:"statuses"`
+}
+
+func newCheckResponse(results map[string]string, ok bool) checkResponse {
+	if !ok {
+		return checkResponse{Status: statusFailed, Checks: results}
+	}
+
+	return checkResponse{Status: statusOk, Checks: results}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.WithField("error", err.Error()).Error("Failed to write health response")
+	}
+}
+
+func writeCheckResponse(w http.ResponseWriter, results map[string]string, ok bool) {
+	if !ok {
+		writeJSON(w, http.StatusServiceUnavailable, newCheckResponse(results, ok))
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newCheckResponse(results, ok))
+}
+
+func (s Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	s.state.RLock()
+	for pattern, handler := range s.state.handlers {
+		mux.Handle(pattern, handler)
+	}
+	s.state.RUnlock()
+
+	mux.HandleFunc(LivenessPath, func(w http.ResponseWriter, r *http.Request) {
+		results, ok := s.liveness()
+		writeCheckResponse(w, results, ok)
+	})
+	mux.HandleFunc(ReadinessPath, func(w http.ResponseWriter, r *http.Request) {
+		results, ok := s.readiness()
+		writeCheckResponse(w, results, ok)
+	})
+	mux.HandleFunc(StatusPath, func(w http.ResponseWriter, r *http.Request) {
+		readinessResults, readinessOk := s.readiness()
+		livenessResults, livenessOk := s.liveness()
+
+		s.state.RLock()
+		statuses := map[string]interface{}{}
+		for name, provide := range s.state.statuses {
+			statuses[name] = provide()
+		}
+		s.state.RUnlock()
+
+		writeJSON(w, http.StatusOK, statusResponse{
+			Uptime:    time.Since(s.startTime).Truncate(time.Second).String(),
+			StartTime: s.startTime.Unix(),
+			Readiness: newCheckResponse(readinessResults, readinessOk),
+			Liveness:  newCheckResponse(livenessResults, livenessOk),
+			Statuses:  statuses,
+		})
+	})
+
+	return mux
+}
+
+// Listen binds the address up front so that a taken port fails the command, then serves in the background
+func (s Server) Listen() error {
+	if !s.config.Enabled() {
+		logging.Info("Health server disabled")
+
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return err
+	}
+
+	httpServer := &http.Server{Handler: s.Handler()}
+	s.state.Lock()
+	s.state.httpServer = httpServer
+	s.state.Unlock()
+
+	logging.WithField("addr", listener.Addr().String()).Info("Serving health")
+
+	go func() {
+		if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logging.WithField("error", err.Error()).Error("Failed to serve health")
+		}
+	}()
+
+	return nil
+}
+
+func (s Server) Shutdown(ctx context.Context) error {
+	s.state.RLock()
+	httpServer := s.state.httpServer
+	s.state.RUnlock()
+
+	if httpServer == nil {
+		return nil
+	}
+
+	if err := httpServer.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
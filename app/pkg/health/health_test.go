@@ -0,0 +1,84 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getCheckResponse(t *testing.T, s Server, path string) (int, checkResponse) {
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var res checkResponse
+	if !assert.Nil(t, json.NewDecoder(rec.Body).Decode(&res)) {
+		return 0, checkResponse{}
+	}
+
+	return rec.Code, res
+}
+
+func TestReadinessFollowsStartup(t *testing.T) {
+	s := NewServer(Config{})
+	flag := NewFlag("listeners")
+	s.AddReadinessCheck("listeners", flag.Check)
+
+	code, res := getCheckResponse(t, s, ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "starting", res.Checks["startup"])
+	assert.Equal(t, "listeners not done", res.Checks["listeners"])
+
+	flag.Raise()
+	s.Started()
+	code, res = getCheckResponse(t, s, ReadinessPath)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, statusOk, res.Status)
+
+	s.Drain()
+	code, res = getCheckResponse(t, s, ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, ErrDraining.Error(), res.Checks["startup"])
+}
+
+func TestLivenessReportsFailingChecks(t *testing.T) {
+	s := NewServer(Config{})
+	s.AddLivenessCheck("collector", func() error { return errors.New("stuck") })
+	s.AddLivenessCheck("pruner", func() error { return nil })
+
+	code, res := getCheckResponse(t, s, LivenessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, statusFailed, res.Status)
+	assert.Equal(t, "stuck", res.Checks["collector"])
+	assert.Equal(t, checkOk, res.Checks["pruner"])
+}
+
+func TestHeartbeat(t *testing.T) {
+	assert.Nil(t, Heartbeat{}.Check())
+
+	now := time.Unix(1000, 0)
+	h := newHeartbeat(time.Minute, func() time.Time { return now })
+	assert.Nil(t, h.Check())
+
+	// missing beats is tolerated up to the stale intervals
+	now = now.Add(staleIntervals * time.Minute)
+	assert.Nil(t, h.Check())
+
+	now = now.Add(time.Second)
+	assert.NotNil(t, h.Check())
+
+	h.Beat()
+	assert.Equal(t, now, h.Last())
+	assert.Nil(t, h.Check())
+}
+
+func TestFreshnessCheck(t *testing.T) {
+	assert.Nil(t, NewFreshnessCheck(0, func() time.Time { return time.Unix(0, 0) })())
+	assert.Nil(t, NewFreshnessCheck(time.Hour, func() time.Time { return time.Time{} })())
+	assert.Nil(t, NewFreshnessCheck(time.Hour, time.Now)())
+	assert.NotNil(t, NewFreshnessCheck(time.Hour, func() time.Time { return time.Now().Add(-2 * time.Hour) })())
+}
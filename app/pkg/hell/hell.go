@@ -16,14 +16,12 @@ func NewClient(projectId string) (Client, error) {
 	}
 
 	return Client{
-		Context:   ctx,
 		client:    firestoreClient,
 		projectID: projectId,
 	}, nil
 }
 
 type Client struct {
-	Context   context.Context
 	projectID string
 	client    *firestore.Client
 }
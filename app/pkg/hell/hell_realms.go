@@ -1,6 +1,7 @@
 package hell
 
 import (
+	"context"
 	"fmt"
 
 	"cloud.google.com/go/firestore"
@@ -13,8 +14,8 @@ import (
 	"github.com/sotah-inc/server/app/pkg/util"
 )
 
-func (c Client) GetRealm(realmRef *firestore.DocumentRef) (Realm, error) {
-	docsnap, err := realmRef.Get(c.Context)
+func (c Client) GetRealm(ctx context.Context, realmRef *firestore.DocumentRef) (Realm, error) {
+	docsnap, err := realmRef.Get(ctx)
 	if err != nil {
 		return Realm{}, err
 	}
@@ -34,7 +35,7 @@ type WriteRegionRealmsJob struct {
 	Realm      Realm
 }
 
-func (c Client) WriteRegionRealms(regionRealms RegionRealmsMap, version gameversions.GameVersion) error {
+func (c Client) WriteRegionRealms(ctx context.Context, regionRealms RegionRealmsMap, version gameversions.GameVersion) error {
 	// spawning workers
 	in := make(chan WriteRegionRealmsJob)
 	out := make(chan WriteRegionRealmsJob)
@@ -56,7 +57,7 @@ func (c Client) WriteRegionRealms(regionRealms RegionRealmsMap, version gamevers
 				continue
 			}
 
-			if _, err := realmRef.Set(c.Context, inJob.Realm); err != nil {
+			if _, err := realmRef.Set(ctx, inJob.Realm); err != nil {
 				inJob.Err = err
 				out <- inJob
 
@@ -104,6 +105,7 @@ type GetRegionRealmsJob struct {
 }
 
 func (c Client) GetRegionRealms(
+	ctx context.Context,
 	regionRealmSlugs map[blizzard.RegionName][]blizzard.RealmSlug,
 	version gameversions.GameVersion,
 ) (RegionRealmsMap, error) {
@@ -128,7 +130,7 @@ func (c Client) GetRegionRealms(
 				continue
 			}
 
-			realm, err := c.GetRealm(realmRef)
+			realm, err := c.GetRealm(ctx, realmRef)
 			if err != nil {
 				inJob.Err = err
 				out <- inJob
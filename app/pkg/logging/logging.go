@@ -6,16 +6,47 @@ import (
 
 var logger = logrus.New()
 
+// flusher - a hook that buffers entries, such as the stackdriver hook
+type flusher interface {
+	Flush() error
+}
+
+var flushers []flusher
+
+// flushing on fatal logs too, since those exit without returning
+func init() {
+	logrus.RegisterExitHandler(func() {
+		Flush()
+	})
+}
+
 // ResetLogger re-establishes the logger instance
 func ResetLogger(level logrus.Level, hook logrus.Hook) {
 	logger = logrus.New()
 	logger.SetLevel(level)
-	logger.AddHook(hook)
+	flushers = nil
+	AddHook(hook)
 }
 
 // AddHook adds a hook to the internal logrus instance
 func AddHook(hook logrus.Hook) {
 	logger.Hooks.Add(hook)
+
+	if f, ok := hook.(flusher); ok {
+		flushers = append(flushers, f)
+	}
+}
+
+// Flush sends any buffered entries of the hooks, and should be called before exiting
+func Flush() error {
+	var firstErr error
+	for _, f := range flushers {
+		if err := f.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
 }
 
 // SetLevel sets log level
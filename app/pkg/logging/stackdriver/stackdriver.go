@@ -87,6 +87,13 @@ func (h Hook) Fire(entry *logrus.Entry) error {
 	}
 }
 
+// Flush sends the buffered log entries and error reports, which are otherwise lost on exit
+func (h Hook) Flush() error {
+	h.errorReportingClient.Flush()
+
+	return h.logger.Flush()
+}
+
 func (h Hook) Levels() []logrus.Level {
 	return logrus.AllLevels
 }
@@ -13,4 +13,6 @@ var (
 	MsgJSONParseError Code = -2
 	NotFound          Code = -3
 	UserError         Code = -4
+	Unauthorized      Code = -5
+	TooManyRequests   Code = -6
 )
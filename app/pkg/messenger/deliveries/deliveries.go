@@ -0,0 +1,13 @@
+package deliveries
+
+// Delivery - typehint for these enums
+type Delivery string
+
+/*
+Deliveries - how the requests of a subject are delivered where more than one process subscribes to it, either to every
+one of them or to one of them in turn
+*/
+const (
+	Broadcast Delivery = "broadcast"
+	Balanced  Delivery = "balanced"
+)
@@ -1,21 +1,45 @@
 package messenger
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	nats "github.com/nats-io/go-nats"
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/messenger/codes"
+	"github.com/sotah-inc/server/app/pkg/tracing"
 )
 
 type Messenger struct {
 	conn *nats.Conn
+
+	// tracks subscription callbacks that have yet to return, so that shutdown may drain them
+	inflight *sync.WaitGroup
+
+	// each request being handled, by its reply subject, so that replying may record its outcome
+	requests *sync.Map
+
+	// wraps the handling of every request, see Use
+	middlewares []Middleware
+
+	// bounds on the handling of requests, as taken from DefaultHandlingConfig on connecting
+	handling HandlingConfig
+
+	// how requests are made and how long they wait, as given on connecting
+	connect ConnectConfig
+
+	// subscriptions that have yet to be stopped, so that shutdown may drain them
+	subscriptions *subscriptions
+
+	// the api keys issued to third parties, where the handling config builds one
+	keyStore KeyStore
 }
 
 func NewMessage() Message {
@@ -26,6 +50,18 @@ type Message struct {
 	Data string     `json:"data"`
 	Err  string     `json:"error"`
 	Code codes.Code `json:"code"`
+
+	// w3c trace-context of the handling span, so that requesters may look up how their request was handled
+	Traceparent string `json:"traceparent,omitempty"`
+
+	// id of the request, as propagated by the requester or else assigned on receipt, for correlating logs
+	RequestID string `json:"request_id,omitempty"`
+
+	// how many chunks the data was sent in ahead of this message, where the data of this message is left blank
+	Chunks int `json:"chunks,omitempty"`
+
+	// id of the chunked reply, as stamped on each of its chunks, so that requesters may tell apart the responders
+	ReplyID string `json:"reply_id,omitempty"`
 }
 
 func (m Message) parse() ([]byte, error) {
@@ -48,118 +84,337 @@ func NewMessengerFromEnvVars(hostKey string, portKey string) (Messenger, error)
 		return Messenger{}, err
 	}
 
-	return NewMessenger(natsHost, parsedNatsPort)
+	return NewMessenger(natsHost, parsedNatsPort, ConnectConfig{Reconnect: DefaultReconnectConfig})
 }
 
-func NewMessenger(host string, port int) (Messenger, error) {
-	if len(host) == 0 {
-		return Messenger{}, errors.New("host cannot be blank")
-	}
-
-	if port == 0 {
-		return Messenger{}, errors.New("port cannot be zero")
+// NewMessenger connects to the given host and port, or else to the servers of the cluster given by the connect config
+func NewMessenger(host string, port int, config ConnectConfig) (Messenger, error) {
+	natsURI, err := config.url(host, port)
+	if err != nil {
+		return Messenger{}, err
 	}
 
-	natsURI := fmt.Sprintf("nats://%s:%d", host, port)
-
 	logging.WithField("uri", natsURI).Info("Connecting to nats")
 
-	conn, err := nats.Connect(natsURI)
+	options, err := config.options()
 	if err != nil {
 		return Messenger{}, err
 	}
 
-	mess := Messenger{conn: conn}
+	subs := newSubscriptions()
+	conn, err := nats.Connect(natsURI, append(options, subs.options()...)...)
+	if err != nil {
+		return Messenger{}, err
+	}
+	connectedGauge.Set(nil, 1)
+
+	mess := Messenger{
+		conn:          conn,
+		inflight:      &sync.WaitGroup{},
+		requests:      &sync.Map{},
+		handling:      DefaultHandlingConfig,
+		connect:       config,
+		subscriptions: subs,
+	}
+	if mess.handling.NewKeyStore != nil {
+		mess.keyStore = mess.handling.NewKeyStore(mess)
+	}
 
-	return mess, nil
+	return mess.Use(mess.handling.middlewares(mess)...), nil
 }
 
-func (mess Messenger) Subscribe(subject string, stop chan interface{}, cb func(nats.Msg)) error {
-	logging.WithField("subject", subject).Debug("Subscribing to subject")
+/*
+Subscribe calls back with a context that is done once the requester stops waiting on a reply, since nats requests carry
+no deadline of their own, and that carries the request id and trace of the requester where its reply subject carries
+them; every request is logged once handled, after passing through the middlewares given to Use, and requests of the
+subject are handled one at a time unless the handling config allows more, and by this subscriber alone among those of
+its queue group where the handling config balances the subject
+*/
+func (mess Messenger) Subscribe(subject string, stop chan interface{}, cb Handler) error {
+	entry := &subscription{
+		subject: subject,
+		queue:   mess.handling.queueGroupOf(subject),
+		handler: mess.deliver(subject, mess.chain(subject, cb)),
+	}
 
-	sub, err := mess.conn.Subscribe(subject, func(natsMsg *nats.Msg) {
-		logging.WithField("subject", subject).Debug("Received Request")
+	logging.WithFields(logrus.Fields{
+		"subject": subject,
+		"queue":   entry.queue,
+	}).Debug("Subscribing to subject")
 
-		cb(*natsMsg)
-	})
+	sub, err := entry.subscribe(mess.conn)
 	if err != nil {
 		return err
 	}
+	entry.sub = sub
+	mess.subscriptions.add(entry)
 
 	go func() {
 		<-stop
 
-		logging.WithField("subject", subject).Info("Unsubscribing from subject")
-
-		if err := sub.Unsubscribe(); err != nil {
-			logging.WithField("error", err.Error()).Error("failed to unsubscribe")
-
+		// where Drain got to the entry first, it drains the entry itself
+		if !mess.subscriptions.remove(entry) {
 			return
 		}
+		defer mess.subscriptions.stopping.Done()
+
+		entry.drain(mess.conn)
 	}()
 
 	return nil
 }
 
-func (mess Messenger) ReplyTo(natsMsg nats.Msg, m Message) {
-	if m.Code == codes.Blank {
-		logging.WithField("error", "code cannot be blank").Fatal("Failed to call ReplyTo")
+/*
+deliver handles each request in the order received, or else hands each to a goroutine of its own once one of a limited
+number of slots for the subject frees up, since nats delivers the messages of a subscription one at a time
+*/
+func (mess Messenger) deliver(subject string, handler Handler) nats.MsgHandler {
+	limit := mess.handling.concurrencyOf(subject)
+	if limit <= 1 {
+		return func(natsMsg *nats.Msg) {
+			mess.inflight.Add(1)
+			defer mess.inflight.Done()
+
+			mess.handle(subject, handler, *natsMsg)
+		}
+	}
 
-		return
+	slots := make(chan struct{}, limit)
+
+	return func(natsMsg *nats.Msg) {
+		// counting the request while it waits on a slot, so that draining waits on it too
+		mess.inflight.Add(1)
+		slots <- struct{}{}
+
+		go func() {
+			defer func() { <-slots }()
+			defer mess.inflight.Done()
+
+			mess.handle(subject, handler, *natsMsg)
+		}()
 	}
+}
 
-	// json-encoding the message
-	jsonMessage, err := json.Marshal(m)
-	if err != nil {
-		logging.WithField("error", err.Error()).Fatal("Failed to call ReplyTo")
+func (mess Messenger) handle(subject string, handler Handler, natsMsg nats.Msg) {
+	requestID, traceparent := parseInbox(natsMsg.Reply)
+	if len(requestID) == 0 {
+		requestID = newRequestID()
+	}
 
-		return
+	ctx, cancel := context.WithTimeout(
+		tracing.WithRemoteParent(context.Background(), traceparent),
+		mess.connect.timeoutOf(subject),
+	)
+	defer cancel()
+
+	ctx, span := tracing.Start(ctx, fmt.Sprintf("nats handle %s", subject))
+	defer span.End()
+	span.SetAttribute(tracing.SubjectAttribute, subject)
+
+	r := &request{id: requestID, span: span}
+	ctx = withRequest(ctx, r)
+	if len(natsMsg.Reply) > 0 && mess.requests != nil {
+		mess.requests.Store(natsMsg.Reply, r)
+		defer mess.requests.Delete(natsMsg.Reply)
 	}
 
-	if m.Code != codes.Ok {
-		logging.WithFields(logrus.Fields{
-			"error":          m.Err,
-			"code":           m.Code,
-			"reply_to":       natsMsg.Reply,
-			"payload_length": len(jsonMessage),
-		}).Error("Publishing an erroneous reply")
-	} else {
-		logging.WithFields(logrus.Fields{
-			"reply_to":       natsMsg.Reply,
-			"payload_length": len(jsonMessage),
-			"code":           m.Code,
-		}).Debug("Publishing a reply")
+	handler(ctx, natsMsg)
+}
+
+// ErrBlankCode - replies must carry a code, so that requesters may tell a success from a failure
+var ErrBlankCode = errors.New("code cannot be blank")
+
+/*
+ReplyTo publishes the reply to the requester, and records its outcome on the request being handled, so that failing to
+reply is logged along with the request; the error is returned too, for replies made outside of handling a request
+*/
+func (mess Messenger) ReplyTo(natsMsg nats.Msg, m Message) error {
+	r, handling := mess.findRequest(natsMsg.Reply)
+	if handling {
+		m.RequestID = r.id
+		m.Traceparent = r.span.SpanContext().Traceparent()
+	}
+
+	m = mess.fitReply(natsMsg.Reply, m)
+	if handling {
+		r.span.SetAttribute(tracing.CodeAttribute, int(m.Code))
+		if m.Code != codes.Ok {
+			r.span.SetError(errors.New(m.Err))
+		}
+	}
+
+	responseSize, err := mess.replyTo(natsMsg, m)
+	if handling {
+		r.recordReply(m, responseSize, err)
+		r.span.SetError(err)
+
+		return err
 	}
 
-	// attempting to Publish it
-	err = mess.conn.Publish(natsMsg.Reply, jsonMessage)
 	if err != nil {
 		logging.WithFields(logrus.Fields{
-			"error":   err.Error(),
-			"subject": natsMsg.Reply,
-		}).Error("Failed to Publish message")
+			"error":    err.Error(),
+			"reply_to": natsMsg.Reply,
+			"code":     m.Code,
+		}).Error("Failed to reply")
+	}
+
+	return err
+}
 
-		logging.WithField("error", err.Error()).Fatal("Failed to call ReplyTo")
+/*
+fitReply replaces a reply that exceeds the max payload of the server with an error, where the requester does not
+reassemble chunks, so that it hears back rather than waiting out its timeout on a publish that failed
+*/
+func (mess Messenger) fitReply(reply string, m Message) Message {
+	if mess.conn == nil || (acceptsChunks(reply) && mess.handling.ChunkSize > 0) {
+		return m
+	}
 
-		return
+	maxPayload := int(mess.conn.MaxPayload())
+	if maxPayload <= 0 {
+		return m
 	}
+
+	encoded, err := json.Marshal(m)
+	if err != nil || len(encoded) <= maxPayload {
+		return m
+	}
+
+	return oversizedReply(m, len(encoded), maxPayload)
 }
 
-func (mess Messenger) Request(subject string, data []byte) (Message, error) {
-	natsMsg, err := mess.conn.Request(subject, data, 5*time.Second)
+// replyTo publishes the reply in chunks where it is too large and the requester reassembles them, see encodeReply
+func (mess Messenger) replyTo(natsMsg nats.Msg, m Message) (int, error) {
+	if m.Code == codes.Blank {
+		return 0, ErrBlankCode
+	}
+
+	chunkSize := 0
+	if acceptsChunks(natsMsg.Reply) {
+		chunkSize = mess.handling.ChunkSize
+	}
+
+	payloads, err := encodeReply(m, chunkSize)
 	if err != nil {
-		return Message{}, err
+		return 0, err
+	}
+
+	size := 0
+	for _, payload := range payloads {
+		size += len(payload)
+		if err := mess.conn.Publish(natsMsg.Reply, payload); err != nil {
+			return size, err
+		}
+	}
+
+	return size, nil
+}
+
+/*
+Request waits on a reply until the context is done, or for the request timeout of the subject where the context has no
+deadline
+*/
+func (mess Messenger) Request(ctx context.Context, subject string, data []byte) (Message, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, mess.connect.timeoutOf(subject))
+		defer cancel()
 	}
 
-	// json-decoding the message
-	msg := &Message{}
-	if err = json.Unmarshal(natsMsg.Data, &msg); err != nil {
+	ctx, span := tracing.Start(ctx, fmt.Sprintf("nats request %s", subject))
+	defer span.End()
+	span.SetAttribute(tracing.SubjectAttribute, subject)
+
+	// propagating the id of the request being handled, where there is one
+	requestID := RequestIDFromContext(ctx)
+	if len(requestID) == 0 {
+		requestID = newRequestID()
+	}
+
+	msg, err := mess.request(ctx, subject, requestID, withCredentials(data, mess.connect.APIKey))
+	if err != nil {
+		span.SetError(err)
+
 		return Message{}, err
 	}
 
-	return *msg, nil
+	return msg, nil
 }
 
 func (mess Messenger) Publish(subject string, data []byte) error {
-	return mess.conn.Publish(subject, data)
+	return mess.conn.Publish(subject, withCredentials(data, mess.connect.APIKey))
+}
+
+/*
+Drain drains every subscription that has yet to be, waits on those being drained following their stop channel, and then
+blocks until every request nats handed out has been handled
+*/
+func (mess Messenger) Drain() {
+	if mess.subscriptions != nil {
+		for _, entry := range mess.subscriptions.removeAll() {
+			go func(entry *subscription) {
+				defer mess.subscriptions.stopping.Done()
+
+				entry.drain(mess.conn)
+			}(entry)
+		}
+
+		mess.subscriptions.stopping.Wait()
+	}
+
+	if mess.inflight == nil {
+		return
+	}
+
+	logging.Info("Draining in-flight requests")
+	mess.inflight.Wait()
+}
+
+// Close closes the key store and flushes any buffered publishes, such as its usage, before closing the connection
+func (mess Messenger) Close() error {
+	if mess.conn == nil {
+		return nil
+	}
+
+	if mess.keyStore != nil {
+		if err := mess.keyStore.Close(); err != nil {
+			logging.WithField("error", err.Error()).Error("Failed to close key store")
+		}
+	}
+
+	logging.Info("Closing nats connection")
+	err := mess.conn.FlushTimeout(5 * time.Second)
+	mess.conn.Close()
+
+	return err
+}
+
+/*
+CheckAlive reports whether the nats connection was closed, for liveness, since nats closes it only once out of attempts
+at reconnecting and it is not opened again; being disconnected meanwhile is left to readiness, see Check
+*/
+func (mess Messenger) CheckAlive() error {
+	if mess.conn == nil {
+		return nil
+	}
+
+	if mess.conn.IsClosed() {
+		return errors.New("nats connection was closed and will not reconnect")
+	}
+
+	return nil
+}
+
+// Check reports whether the nats connection is up, for readiness
+func (mess Messenger) Check() error {
+	if mess.conn == nil {
+		return errors.New("nats connection was never established")
+	}
+
+	if !mess.conn.IsConnected() {
+		return fmt.Errorf("nats connection is not connected, with status %d", mess.conn.Status())
+	}
+
+	return nil
 }
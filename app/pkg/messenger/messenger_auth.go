@@ -0,0 +1,239 @@
+package messenger
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	nats "github.com/nats-io/go-nats"
+	"github.com/sotah-inc/server/app/pkg/messenger/codes"
+	"github.com/sotah-inc/server/app/pkg/messenger/roles"
+)
+
+// credentialsField - the payload field credentials are carried in
+const credentialsField = "api_key"
+
+/*
+Credentials - carried as fields of a request payload alongside the request itself, since nats messages carry no headers
+and request payloads are not wrapped in an envelope
+*/
+type Credentials struct {
+	APIKey string `json:"api_key"`
+}
+
+// CredentialsFromPayload returns blank credentials for payloads that are not json objects
+func CredentialsFromPayload(data []byte) Credentials {
+	credentials := Credentials{}
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return Credentials{}
+	}
+
+	return credentials
+}
+
+/*
+withCredentials splices the api key into a payload that is a json object, or makes a payload of it where there is none,
+leaving any other payload as it is since it has nowhere to carry credentials
+*/
+func withCredentials(data []byte, apiKey string) []byte {
+	if len(apiKey) == 0 {
+		return data
+	}
+
+	encodedKey, err := json.Marshal(apiKey)
+	if err != nil {
+		return data
+	}
+	field := fmt.Sprintf(`"%s":%s`, credentialsField, encodedKey)
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return []byte(fmt.Sprintf("{%s}", field))
+	}
+	if trimmed[0] != '{' {
+		return data
+	}
+
+	rest := bytes.TrimSpace(trimmed[1:])
+	if len(rest) > 0 && rest[0] == '}' {
+		return []byte(fmt.Sprintf("{%s%s", field, rest))
+	}
+
+	return []byte(fmt.Sprintf("{%s,%s", field, rest))
+}
+
+// withoutCredentials removes the credentials of a payload, so that handlers decoding into maps do not see them
+func withoutCredentials(data []byte) []byte {
+	if !bytes.Contains(data, []byte(credentialsField)) {
+		return data
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return data
+	}
+
+	if _, ok := fields[credentialsField]; !ok {
+		return data
+	}
+	delete(fields, credentialsField)
+
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return data
+	}
+
+	return encoded
+}
+
+// Authenticator - decides whether a request of a subject may be handled, by the credentials it carries
+type Authenticator interface {
+	Authenticate(ctx context.Context, subject string, credentials Credentials) error
+}
+
+var (
+	// ErrUnauthenticated - a request carried credentials that were not recognized
+	ErrUnauthenticated = errors.New("request could not be authenticated")
+
+	// ErrForbidden - a request carried no credentials, or credentials of a role that may not request the subject
+	ErrForbidden = errors.New("request is not permitted on this subject")
+)
+
+type credentialsContextKey struct{}
+
+// CredentialsFromContext returns the credentials a request was authenticated by, or blank ones for anonymous requests
+func CredentialsFromContext(ctx context.Context) Credentials {
+	credentials, ok := ctx.Value(credentialsContextKey{}).(Credentials)
+	if !ok {
+		return Credentials{}
+	}
+
+	return credentials
+}
+
+/*
+Authenticate replies with an unauthorized error to requests that the authenticator turns away, or a generic error where
+it could not tell, where a nil authenticator turns none away, and hands the rest on without their credentials, which are
+carried by the context instead
+*/
+func (mess Messenger) Authenticate(authenticator Authenticator) Middleware {
+	return func(subject string, next Handler) Handler {
+		return func(ctx context.Context, natsMsg nats.Msg) {
+			credentials := CredentialsFromPayload(natsMsg.Data)
+			if authenticator != nil {
+				if err := authenticator.Authenticate(ctx, subject, credentials); err != nil {
+					m := NewMessage()
+					m.Err = err.Error()
+					m.Code = codes.Unauthorized
+					if err != ErrUnauthenticated && err != ErrForbidden {
+						m.Code = codes.GenericError
+					}
+					mess.respond(ctx, natsMsg, m)
+
+					return
+				}
+			}
+
+			if len(credentials.APIKey) > 0 {
+				natsMsg.Data = withoutCredentials(natsMsg.Data)
+				ctx = context.WithValue(ctx, credentialsContextKey{}, credentials)
+			}
+
+			next(ctx, natsMsg)
+		}
+	}
+}
+
+var roleRanks = map[roles.Role]int{
+	roles.Public:  0,
+	roles.Client:  1,
+	roles.Service: 2,
+}
+
+// Permissions - the least role that may request each subject, where subjects not listed may be requested by anyone
+type Permissions map[string]roles.Role
+
+func (permissions Permissions) Permits(subject string, role roles.Role) bool {
+	required, ok := permissions[subject]
+	if !ok {
+		return true
+	}
+
+	return roleRanks[role] >= roleRanks[required]
+}
+
+/*
+KeyResolver - resolves the role of an api key, where keys it does not know are not ok, and an error is only returned
+where the key could not be looked up at all
+*/
+type KeyResolver interface {
+	ResolveKey(ctx context.Context, apiKey string) (roles.Role, bool, error)
+}
+
+// StaticKeys - the fixed api keys of roles, as given on starting
+type StaticKeys map[string]roles.Role
+
+func (keys StaticKeys) ResolveKey(_ context.Context, apiKey string) (roles.Role, bool, error) {
+	// comparing against every key, so that the time taken does not tell how close a guess was
+	found := roles.Public
+	ok := false
+	for key, role := range keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+			found = role
+			ok = true
+		}
+	}
+
+	return found, ok, nil
+}
+
+func NewKeyAuthenticator(permissions Permissions, resolvers ...KeyResolver) KeyAuthenticator {
+	return KeyAuthenticator{resolvers: resolvers, permissions: permissions}
+}
+
+/*
+KeyAuthenticator - resolves the role of a request by the api key it carries, asking each resolver in turn, where
+requests without one are public, and lets through requests whose role the permissions of the subject allow
+*/
+type KeyAuthenticator struct {
+	resolvers   []KeyResolver
+	permissions Permissions
+}
+
+func (auth KeyAuthenticator) roleOf(ctx context.Context, apiKey string) (roles.Role, bool, error) {
+	if len(apiKey) == 0 {
+		return roles.Public, true, nil
+	}
+
+	for _, resolver := range auth.resolvers {
+		role, ok, err := resolver.ResolveKey(ctx, apiKey)
+		if err != nil {
+			return roles.Public, false, err
+		}
+
+		if ok {
+			return role, true, nil
+		}
+	}
+
+	return roles.Public, false, nil
+}
+
+func (auth KeyAuthenticator) Authenticate(ctx context.Context, subject string, credentials Credentials) error {
+	role, ok, err := auth.roleOf(ctx, credentials.APIKey)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if !auth.permissions.Permits(subject, role) {
+		return ErrForbidden
+	}
+
+	return nil
+}
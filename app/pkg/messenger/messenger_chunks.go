@@ -0,0 +1,202 @@
+package messenger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	nats "github.com/nats-io/go-nats"
+	"github.com/sotah-inc/server/app/pkg/messenger/codes"
+	"github.com/twinj/uuid"
+)
+
+// DefaultChunkSize - half the default max_payload of a nats server, leaving room for the rest of the reply
+const DefaultChunkSize = DefaultMaxPayloadSize / 2
+
+/*
+chunkedInboxToken - follows the unique token of an inbox whose requester reassembles chunked replies, since requesters
+other than this one produce inboxes of their own and would not know what to do with a chunk
+*/
+const chunkedInboxToken = "chunked"
+
+/*
+chunkFrameMarker - leads every chunk, ahead of the id of its reply and its big-endian sequence number, where a reply
+that is not a chunk is a json-encoded message and so never starts with it
+*/
+const chunkFrameMarker = byte(0)
+
+const (
+	chunkReplyIDSize = 16
+	chunkHeaderSize  = 1 + chunkReplyIDSize + 4
+)
+
+func newChunkReplyID() []byte {
+	return uuid.NewV4().Bytes()
+}
+
+// acceptsChunks reports whether the requester of the reply subject reassembles chunked replies
+func acceptsChunks(reply string) bool {
+	tokens := strings.Split(reply, ".")
+
+	return len(tokens) >= 3 && tokens[2] == chunkedInboxToken
+}
+
+func encodeChunk(replyID []byte, seq int, data []byte) []byte {
+	out := make([]byte, chunkHeaderSize+len(data))
+	out[0] = chunkFrameMarker
+	copy(out[1:1+chunkReplyIDSize], replyID)
+	binary.BigEndian.PutUint32(out[1+chunkReplyIDSize:chunkHeaderSize], uint32(seq))
+	copy(out[chunkHeaderSize:], data)
+
+	return out
+}
+
+func decodeChunk(frame []byte) ([]byte, int, []byte, bool) {
+	if len(frame) < chunkHeaderSize || frame[0] != chunkFrameMarker {
+		return nil, 0, nil, false
+	}
+
+	replyID := frame[1 : 1+chunkReplyIDSize]
+	seq := int(binary.BigEndian.Uint32(frame[1+chunkReplyIDSize : chunkHeaderSize]))
+
+	return replyID, seq, frame[chunkHeaderSize:], true
+}
+
+/*
+encodeReply json-encodes the message as one payload where it fits in the chunk size, or else splits its data into
+sequence-numbered chunks of the chunk size followed by a terminal message carrying the number of chunks in place of the
+data, where a chunk size of zero never splits; the chunks and the terminal message are stamped with an id of the reply,
+since every responder to a subject without a queue group replies to the same inbox
+*/
+func encodeReply(m Message, chunkSize int) ([][]byte, error) {
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	if chunkSize <= 0 || len(encoded) <= chunkSize {
+		return [][]byte{encoded}, nil
+	}
+
+	replyID := newChunkReplyID()
+	data := []byte(m.Data)
+	out := make([][]byte, 0, len(data)/chunkSize+2)
+	for start := 0; start < len(data); start += chunkSize {
+		end := start + chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		out = append(out, encodeChunk(replyID, len(out), data[start:end]))
+	}
+
+	m.Chunks = len(out)
+	m.ReplyID = hex.EncodeToString(replyID)
+	m.Data = ""
+	terminal, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(out, terminal), nil
+}
+
+// oversizedReply replaces the reply with an error for requesters that cannot take it whole and do not reassemble chunks
+func oversizedReply(m Message, size int, maxPayload int) Message {
+	out := NewMessage()
+	out.Code = codes.GenericError
+	out.Err = fmt.Sprintf(
+		"reply of %d bytes exceeds the max payload of %d bytes, and the requester does not accept chunks",
+		size,
+		maxPayload,
+	)
+	out.RequestID = m.RequestID
+	out.Traceparent = m.Traceparent
+
+	return out
+}
+
+/*
+chunkAssembler - gathers the chunks of a reply in the order published, until the terminal message arrives, where it
+locks onto the reply of the first chunk and ignores the replies of any other responder
+*/
+type chunkAssembler struct {
+	replyID []byte
+	chunks  [][]byte
+	size    int
+}
+
+// add returns the reassembled message once the payload is the terminal message, or else holds on to the chunk
+func (assembler *chunkAssembler) add(payload []byte) (Message, bool, error) {
+	replyID, seq, data, ok := decodeChunk(payload)
+	if ok {
+		if assembler.replyID == nil {
+			assembler.replyID = append([]byte{}, replyID...)
+		} else if !bytes.Equal(assembler.replyID, replyID) {
+			return Message{}, false, nil
+		}
+
+		if seq != len(assembler.chunks) {
+			return Message{}, false, fmt.Errorf(
+				"received chunk %d where chunk %d was expected",
+				seq,
+				len(assembler.chunks),
+			)
+		}
+
+		assembler.chunks = append(assembler.chunks, data)
+		assembler.size += len(data)
+
+		return Message{}, false, nil
+	}
+
+	msg := Message{}
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return Message{}, false, err
+	}
+
+	// once locked onto a reply, whole replies and the terminal messages of other replies are those of other responders
+	if assembler.replyID != nil && msg.ReplyID != hex.EncodeToString(assembler.replyID) {
+		return Message{}, false, nil
+	}
+
+	if msg.Chunks != len(assembler.chunks) {
+		return Message{}, false, fmt.Errorf("received %d chunks where %d were sent", len(assembler.chunks), msg.Chunks)
+	}
+
+	if msg.Chunks > 0 {
+		data := make([]byte, 0, assembler.size)
+		for _, chunk := range assembler.chunks {
+			data = append(data, chunk...)
+		}
+
+		msg.Data = string(data)
+		msg.Chunks = 0
+		msg.ReplyID = ""
+	}
+
+	return msg, true, nil
+}
+
+// receiveReply reads replies off of the inbox subscription until the message is whole
+func receiveReply(next func() (*nats.Msg, error)) (Message, error) {
+	assembler := &chunkAssembler{}
+	for {
+		natsMsg, err := next()
+		if err != nil {
+			return Message{}, err
+		}
+
+		msg, done, err := assembler.add(natsMsg.Data)
+		if err != nil {
+			return Message{}, err
+		}
+
+		if done {
+			return msg, nil
+		}
+	}
+}
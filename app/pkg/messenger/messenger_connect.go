@@ -0,0 +1,186 @@
+package messenger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	nats "github.com/nats-io/go-nats"
+)
+
+// DefaultRequestTimeout - how long a requester waits on a reply where no timeout is given for its subject
+const DefaultRequestTimeout = 5 * time.Second
+
+/*
+ReconnectConfig - how a messenger reconnects once disconnected, where publishes made meanwhile are buffered until the
+buffer is full, and subscriptions are made again on reconnecting
+*/
+type ReconnectConfig struct {
+	// attempts at reconnecting to each server before the connection is closed, where a negative count never gives up
+	MaxReconnects int
+
+	// how long to wait between attempts at reconnecting to the same server
+	Wait time.Duration
+
+	// bytes of publishes buffered while reconnecting, where zero takes the nats default and a negative size buffers none
+	BufferSize int
+}
+
+// DefaultReconnectConfig - the nats defaults, of sixty attempts two seconds apart with an eight megabyte buffer
+var DefaultReconnectConfig = ReconnectConfig{
+	MaxReconnects: nats.DefaultMaxReconnect,
+	Wait:          nats.DefaultReconnectWait,
+	BufferSize:    nats.DefaultReconnectBufSize,
+}
+
+func (config ReconnectConfig) options() ([]nats.Option, error) {
+	if config.Wait < 0 {
+		return nil, errors.New("nats reconnect wait cannot be negative")
+	}
+
+	return []nats.Option{
+		nats.MaxReconnects(config.MaxReconnects),
+		nats.ReconnectWait(config.Wait),
+		nats.ReconnectBufSize(config.BufferSize),
+	}, nil
+}
+
+// TLSConfig - how the nats connection is secured, where files left blank fall back to the system roots and no client cert
+type TLSConfig struct {
+	Enabled  bool
+	CAFile   string
+	CertFile string
+	KeyFile  string
+}
+
+func (config TLSConfig) options() ([]nats.Option, error) {
+	if !config.Enabled {
+		if len(config.CAFile) > 0 || len(config.CertFile) > 0 || len(config.KeyFile) > 0 {
+			return nil, errors.New("tls files were given without enabling tls")
+		}
+
+		return nil, nil
+	}
+
+	out := []nats.Option{nats.Secure()}
+	if len(config.CAFile) > 0 {
+		out = append(out, nats.RootCAs(config.CAFile))
+	}
+	if len(config.CertFile) > 0 || len(config.KeyFile) > 0 {
+		if len(config.CertFile) == 0 || len(config.KeyFile) == 0 {
+			return nil, errors.New("tls cert and key must be given together")
+		}
+
+		out = append(out, nats.ClientCert(config.CertFile, config.KeyFile))
+	}
+
+	return out, nil
+}
+
+/*
+ConnectConfig - how every messenger connects to nats, which it authenticates with by at most one of user and password,
+token, a credentials file holding a user jwt and nkey seed, or an nkey seed file
+*/
+type ConnectConfig struct {
+	// urls of the servers of a nats cluster, which are connected to in place of the host and port given once any are given
+	Servers []string
+
+	Reconnect ReconnectConfig
+
+	// how long requests wait on a reply, which bounds the handling of them too, where zero takes DefaultRequestTimeout
+	RequestTimeout time.Duration
+
+	// overrides RequestTimeout by subject, such as for subjects whose handling is known to be slow
+	SubjectRequestTimeouts map[string]time.Duration
+
+	User     string
+	Password string
+
+	Token string
+
+	CredentialsFile string
+
+	NKeySeedFile string
+
+	TLS TLSConfig
+
+	// carried by the requests and publishes of this process, for the subjects that are permitted to its role only
+	APIKey string
+}
+
+// url is the servers given, or else the host and port, as the comma-separated list that nats connects to
+func (config ConnectConfig) url(host string, port int) (string, error) {
+	if len(config.Servers) > 0 {
+		return strings.Join(config.Servers, ","), nil
+	}
+
+	if len(host) == 0 {
+		return "", errors.New("host cannot be blank")
+	}
+
+	if port == 0 {
+		return "", errors.New("port cannot be zero")
+	}
+
+	return fmt.Sprintf("nats://%s:%d", host, port), nil
+}
+
+func (config ConnectConfig) timeoutOf(subject string) time.Duration {
+	if timeout, ok := config.SubjectRequestTimeouts[subject]; ok {
+		return timeout
+	}
+
+	if config.RequestTimeout > 0 {
+		return config.RequestTimeout
+	}
+
+	return DefaultRequestTimeout
+}
+
+func (config ConnectConfig) options() ([]nats.Option, error) {
+	out := []nats.Option{}
+
+	methods := 0
+	if len(config.User) > 0 || len(config.Password) > 0 {
+		if len(config.User) == 0 {
+			return nil, errors.New("nats password was given without a user")
+		}
+
+		methods++
+		out = append(out, nats.UserInfo(config.User, config.Password))
+	}
+	if len(config.Token) > 0 {
+		methods++
+		out = append(out, nats.Token(config.Token))
+	}
+	if len(config.CredentialsFile) > 0 {
+		methods++
+		out = append(out, nats.UserCredentials(config.CredentialsFile))
+	}
+	if len(config.NKeySeedFile) > 0 {
+		methods++
+		nkeyOption, err := nats.NkeyOptionFromSeed(config.NKeySeedFile)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, nkeyOption)
+	}
+	if methods > 1 {
+		return nil, errors.New("only one of nats user, token, credentials or nkey seed may be given")
+	}
+
+	tlsOptions, err := config.TLS.options()
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, tlsOptions...)
+
+	reconnectOptions, err := config.Reconnect.options()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(out, reconnectOptions...), nil
+}
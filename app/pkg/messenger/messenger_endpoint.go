@@ -0,0 +1,149 @@
+package messenger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	nats "github.com/nats-io/go-nats"
+	"github.com/sotah-inc/server/app/pkg/messenger/codes"
+)
+
+/*
+HandlerFunc - resolves a decoded request into a response, where a code other than ok is replied with the error, and an
+error without a code is replied as a generic error
+*/
+type HandlerFunc[Req any, Resp any] func(ctx context.Context, req Req) (Resp, codes.Code, error)
+
+// Validator - implemented by requests that are checked before being handled, where failing is replied as a user error
+type Validator interface {
+	Validate() error
+}
+
+/*
+Endpoint - a typed handler of the requests of a subject, where requests are json-decoded and responses json-encoded
+unless Decode or Encode are given
+*/
+type Endpoint[Req any, Resp any] struct {
+	Decode func(data []byte) (Req, error)
+	Encode func(resp Resp) (string, error)
+	Handle HandlerFunc[Req, Resp]
+}
+
+// Empty - the request of endpoints that take none, where the payload is not read
+type Empty struct{}
+
+func DecodeEmpty(_ []byte) (Empty, error) {
+	return Empty{}, nil
+}
+
+// EncodeEmpty replies with blank data, for endpoints that only acknowledge requests
+func EncodeEmpty(_ Empty) (string, error) {
+	return "", nil
+}
+
+func decodeJSON[Req any](data []byte) (Req, error) {
+	req := new(Req)
+	if err := json.Unmarshal(data, req); err != nil {
+		return *req, err
+	}
+
+	return *req, nil
+}
+
+func encodeJSON[Resp any](resp Resp) (string, error) {
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), nil
+}
+
+// EncodeBytes adapts an encoder of bytes, such as the EncodeForDelivery of some responses, for an endpoint
+func EncodeBytes[Resp any](encode func(resp Resp) ([]byte, error)) func(resp Resp) (string, error) {
+	return func(resp Resp) (string, error) {
+		encoded, err := encode(resp)
+		if err != nil {
+			return "", err
+		}
+
+		return string(encoded), nil
+	}
+}
+
+/*
+Handler adapts the endpoint for subscribing, replying to every request through the messenger, other than messages that
+were published rather than requested
+*/
+func (e Endpoint[Req, Resp]) Handler(mess Messenger) Handler {
+	decode := e.Decode
+	if decode == nil {
+		decode = decodeJSON[Req]
+	}
+	encode := e.Encode
+	if encode == nil {
+		encode = encodeJSON[Resp]
+	}
+
+	return func(ctx context.Context, natsMsg nats.Msg) {
+		mess.respond(ctx, natsMsg, e.resolve(ctx, natsMsg.Data, decode, encode))
+	}
+}
+
+func (e Endpoint[Req, Resp]) resolve(
+	ctx context.Context,
+	data []byte,
+	decode func(data []byte) (Req, error),
+	encode func(resp Resp) (string, error),
+) Message {
+	m := NewMessage()
+
+	req, err := decode(data)
+	if err != nil {
+		m.Err = err.Error()
+		m.Code = codes.MsgJSONParseError
+
+		return m
+	}
+
+	if validator, ok := any(req).(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			m.Err = err.Error()
+			m.Code = codes.UserError
+
+			return m
+		}
+	}
+
+	resp, code, err := e.Handle(ctx, req)
+	if code != codes.Ok || err != nil {
+		m.Code = code
+		if m.Code == codes.Ok || m.Code == codes.Blank {
+			m.Code = codes.GenericError
+		}
+		if err == nil {
+			err = errors.New("request could not be handled")
+		}
+		m.Err = err.Error()
+
+		return m
+	}
+
+	encoded, err := encode(resp)
+	if err != nil {
+		m.Err = err.Error()
+		m.Code = codes.GenericError
+
+		return m
+	}
+
+	m.Data = encoded
+
+	return m
+}
+
+// Serve subscribes the endpoint to the subject until stopped
+func Serve[Req any, Resp any](mess Messenger, subject string, stop chan interface{}, e Endpoint[Req, Resp]) error {
+	return mess.Subscribe(subject, stop, e.Handler(mess))
+}
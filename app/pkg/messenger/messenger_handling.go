@@ -0,0 +1,194 @@
+package messenger
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	nats "github.com/nats-io/go-nats"
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/messenger/codes"
+	"github.com/sotah-inc/server/app/pkg/messenger/deliveries"
+)
+
+// DefaultMaxPayloadSize - the default max_payload of a nats server, where larger requests could not be received anyway
+const DefaultMaxPayloadSize = 1024 * 1024
+
+// DefaultQueueGroup - the queue group of balanced subjects, where nats scopes each queue group to its subject
+const DefaultQueueGroup = "sotah"
+
+// Deliveries - how the requests of each subject are delivered, where subjects not listed are broadcast
+type Deliveries map[string]deliveries.Delivery
+
+func (d Deliveries) Of(subject string) deliveries.Delivery {
+	if delivery, ok := d[subject]; ok {
+		return delivery
+	}
+
+	return deliveries.Broadcast
+}
+
+// HandlingConfig - bounds on the handling of requests received by a messenger
+type HandlingConfig struct {
+	// requests with larger payloads are replied to with a user error, where zero leaves payloads unbounded
+	MaxPayloadSize int
+
+	// how many requests of one subject are handled at once, where one or less handles them in the order received
+	MaxConcurrency int
+
+	// overrides MaxConcurrency by subject, such as for intake subjects that must be handled in order
+	SubjectConcurrency map[string]int
+
+	/*
+		replies larger than this many bytes are sent in chunks of it to requesters that reassemble them, where zero sends
+		every reply whole
+	*/
+	ChunkSize int
+
+	// the queue group that balanced subjects are subscribed in, where blank broadcasts every subject
+	QueueGroup string
+
+	/*
+		how the requests of each subject are delivered, where balanced subjects are handled by one subscriber in turn so
+		that replicas of a process may share its queries, and broadcast ones by every subscriber, as intake must be
+	*/
+	Deliveries Deliveries
+
+	// fixed api keys of roles, where requests are authenticated once any keys are given or a key store is built
+	Keys StaticKeys
+
+	// the least role that may request each subject, for authenticated requests
+	Permissions Permissions
+
+	/*
+		builds the store of issued api keys for each messenger on connecting, so that the store may look keys up through
+		that messenger, where nil issues none
+	*/
+	NewKeyStore func(mess Messenger) KeyStore
+}
+
+// DefaultHandlingConfig - taken by every messenger on connecting, and meant to be set from flags before then
+var DefaultHandlingConfig = HandlingConfig{
+	MaxPayloadSize: DefaultMaxPayloadSize,
+	MaxConcurrency: 1,
+	ChunkSize:      DefaultChunkSize,
+	QueueGroup:     DefaultQueueGroup,
+}
+
+func (config HandlingConfig) concurrencyOf(subject string) int {
+	if limit, ok := config.SubjectConcurrency[subject]; ok {
+		return limit
+	}
+
+	return config.MaxConcurrency
+}
+
+// queueGroupOf is blank for subjects that are broadcast
+func (config HandlingConfig) queueGroupOf(subject string) string {
+	if config.Deliveries.Of(subject) != deliveries.Balanced {
+		return ""
+	}
+
+	return config.QueueGroup
+}
+
+// authenticator resolves keys by the fixed keys ahead of the key store, and is nil where there are neither
+func (config HandlingConfig) authenticator(keyStore KeyStore) Authenticator {
+	resolvers := []KeyResolver{}
+	if len(config.Keys) > 0 {
+		resolvers = append(resolvers, config.Keys)
+	}
+	if keyStore != nil {
+		resolvers = append(resolvers, keyStore)
+	}
+	if len(resolvers) == 0 {
+		return nil
+	}
+
+	return NewKeyAuthenticator(config.Permissions, resolvers...)
+}
+
+/*
+middlewares are ordered such that a panic anywhere is recovered, and oversized payloads are not read for credentials,
+where credentials are taken off of payloads even when they are not checked, and only authenticated requests are metered
+*/
+func (config HandlingConfig) middlewares(mess Messenger) []Middleware {
+	out := []Middleware{mess.RecoverPanics}
+	if config.MaxPayloadSize > 0 {
+		out = append(out, mess.LimitPayloadSize(config.MaxPayloadSize))
+	}
+
+	out = append(out, mess.Authenticate(config.authenticator(mess.keyStore)))
+	if mess.keyStore != nil {
+		out = append(out, mess.MeterUsage(mess.keyStore))
+	}
+
+	return out
+}
+
+// RecoverPanics replies with a generic error when handling a request panics, rather than letting the process die
+func (mess Messenger) RecoverPanics(subject string, next Handler) Handler {
+	return func(ctx context.Context, natsMsg nats.Msg) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			logging.WithFields(logrus.Fields{
+				"subject":    subject,
+				"request_id": RequestIDFromContext(ctx),
+				"panic":      fmt.Sprintf("%v", recovered),
+				"stack":      string(debug.Stack()),
+			}).Error("Recovered from panic while handling request")
+
+			if outcome, _ := RequestOutcomeFromContext(ctx); outcome.Replied {
+				return
+			}
+
+			m := NewMessage()
+			m.Err = "internal error"
+			m.Code = codes.GenericError
+			mess.respond(ctx, natsMsg, m)
+		}()
+
+		next(ctx, natsMsg)
+	}
+}
+
+// LimitPayloadSize replies with a user error to requests with payloads larger than the given number of bytes
+func (mess Messenger) LimitPayloadSize(maxSize int) Middleware {
+	return func(subject string, next Handler) Handler {
+		return func(ctx context.Context, natsMsg nats.Msg) {
+			if len(natsMsg.Data) > maxSize {
+				m := NewMessage()
+				m.Err = fmt.Sprintf("payload of %d bytes exceeds the limit of %d bytes", len(natsMsg.Data), maxSize)
+				m.Code = codes.UserError
+				mess.respond(ctx, natsMsg, m)
+
+				return
+			}
+
+			next(ctx, natsMsg)
+		}
+	}
+}
+
+// respond replies to requests, and logs the failures of messages that were published rather than requested
+func (mess Messenger) respond(ctx context.Context, natsMsg nats.Msg, m Message) {
+	if len(natsMsg.Reply) > 0 {
+		mess.ReplyTo(natsMsg, m)
+
+		return
+	}
+
+	if m.Code != codes.Ok {
+		logging.WithFields(logrus.Fields{
+			"subject":    natsMsg.Subject,
+			"request_id": RequestIDFromContext(ctx),
+			"error":      m.Err,
+			"code":       m.Code,
+		}).Error("Failed to handle published message")
+	}
+}
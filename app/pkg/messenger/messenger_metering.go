@@ -0,0 +1,57 @@
+package messenger
+
+import (
+	"context"
+	"errors"
+
+	nats "github.com/nats-io/go-nats"
+	"github.com/sotah-inc/server/app/pkg/messenger/codes"
+)
+
+var (
+	// ErrRateLimited - an api key made more requests in a short while than its rate limit allows
+	ErrRateLimited = errors.New("rate limit of the api key was exceeded")
+
+	// ErrQuotaExceeded - an api key made as many requests today as its daily quota allows
+	ErrQuotaExceeded = errors.New("daily quota of the api key was exceeded")
+)
+
+// Meter - admits the requests of api keys, counting those it admits towards the usage of the key
+type Meter interface {
+	Admit(ctx context.Context, subject string, credentials Credentials) error
+}
+
+/*
+KeyStore - the api keys issued to third parties, which are resolved for authenticating and metered once authenticated,
+and which is closed along with the messenger so that usage yet to be recorded is not lost
+*/
+type KeyStore interface {
+	KeyResolver
+	Meter
+	Close() error
+}
+
+/*
+MeterUsage replies with a too-many-requests error to requests that the meter turns away for their rate limit or quota,
+or a generic error where it could not tell, and is meant to follow Authenticate since it reads credentials off of the
+context
+*/
+func (mess Messenger) MeterUsage(meter Meter) Middleware {
+	return func(subject string, next Handler) Handler {
+		return func(ctx context.Context, natsMsg nats.Msg) {
+			if err := meter.Admit(ctx, subject, CredentialsFromContext(ctx)); err != nil {
+				m := NewMessage()
+				m.Err = err.Error()
+				m.Code = codes.GenericError
+				if err == ErrRateLimited || err == ErrQuotaExceeded {
+					m.Code = codes.TooManyRequests
+				}
+				mess.respond(ctx, natsMsg, m)
+
+				return
+			}
+
+			next(ctx, natsMsg)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package messenger
+
+import (
+	"sync"
+	"time"
+
+	nats "github.com/nats-io/go-nats"
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/metric"
+)
+
+var (
+	connectedGauge = metric.NewGauge(
+		"sotah_messenger_connected",
+		"Whether the nats connection is up.",
+	)
+	disconnectsCounter = metric.NewCounter(
+		"sotah_messenger_disconnects_total",
+		"Disconnections from nats.",
+	)
+	reconnectsCounter = metric.NewCounter(
+		"sotah_messenger_reconnects_total",
+		"Reconnections to nats.",
+	)
+)
+
+// subscription - a subscription made by Subscribe, along with what it was made of
+type subscription struct {
+	subject string
+	queue   string
+	handler nats.MsgHandler
+	sub     *nats.Subscription
+}
+
+// subscribe joins the queue group of the subscription, where there is one
+func (entry *subscription) subscribe(conn *nats.Conn) (*nats.Subscription, error) {
+	if len(entry.queue) == 0 {
+		return conn.Subscribe(entry.subject, entry.handler)
+	}
+
+	return conn.QueueSubscribe(entry.subject, entry.queue, entry.handler)
+}
+
+// drainPollInterval - how often draining checks whether nats has handed out every message a subscription holds
+const drainPollInterval = 50 * time.Millisecond
+
+/*
+drain stops interest in the subject while letting nats hand out the messages the subscription already holds, and blocks
+until nats has removed the subscription or the connection is closed
+*/
+func (entry *subscription) drain(conn *nats.Conn) {
+	logging.WithField("subject", entry.subject).Info("Draining subscription")
+
+	if err := entry.sub.Drain(); err != nil {
+		logging.WithFields(logrus.Fields{
+			"error":   err.Error(),
+			"subject": entry.subject,
+		}).Error("Failed to drain subscription")
+
+		return
+	}
+
+	for entry.sub.IsValid() && !conn.IsClosed() {
+		time.Sleep(drainPollInterval)
+	}
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{entries: map[*subscription]struct{}{}, stopping: &sync.WaitGroup{}}
+}
+
+// subscriptions - the subscriptions of a messenger that have yet to be stopped
+type subscriptions struct {
+	sync.Mutex
+	entries map[*subscription]struct{}
+
+	// subscriptions that were removed and have yet to finish draining
+	stopping *sync.WaitGroup
+}
+
+func (subs *subscriptions) add(entry *subscription) {
+	subs.Lock()
+	defer subs.Unlock()
+
+	subs.entries[entry] = struct{}{}
+}
+
+/*
+remove takes the entry out of those yet to be stopped, and reports whether it was still there, in which case the caller
+is to drain it and mark it done on the stopping wait-group
+*/
+func (subs *subscriptions) remove(entry *subscription) bool {
+	subs.Lock()
+	defer subs.Unlock()
+
+	if _, ok := subs.entries[entry]; !ok {
+		return false
+	}
+
+	delete(subs.entries, entry)
+	subs.stopping.Add(1)
+
+	return true
+}
+
+// removeAll takes out every entry, as remove does for each
+func (subs *subscriptions) removeAll() []*subscription {
+	subs.Lock()
+	defer subs.Unlock()
+
+	out := make([]*subscription, 0, len(subs.entries))
+	for entry := range subs.entries {
+		out = append(out, entry)
+		delete(subs.entries, entry)
+		subs.stopping.Add(1)
+	}
+
+	return out
+}
+
+/*
+options logs and reports each change in the state of the connection, where nats itself sends every subscription it holds
+again on reconnecting, and a connection that closed once out of attempts at reconnecting fails liveness, see CheckAlive
+*/
+func (subs *subscriptions) options() []nats.Option {
+	return []nats.Option{
+		nats.DisconnectHandler(func(conn *nats.Conn) {
+			fields := logrus.Fields{"uri": conn.ConnectedUrl()}
+			if err := conn.LastError(); err != nil {
+				fields["error"] = err.Error()
+			}
+			logging.WithFields(fields).Warn("Disconnected from nats")
+
+			connectedGauge.Set(nil, 0)
+			disconnectsCounter.Inc(nil)
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			logging.WithField("uri", conn.ConnectedUrl()).Info("Reconnected to nats")
+
+			connectedGauge.Set(nil, 1)
+			reconnectsCounter.Inc(nil)
+		}),
+		nats.ClosedHandler(func(conn *nats.Conn) {
+			connectedGauge.Set(nil, 0)
+
+			if err := conn.LastError(); err != nil {
+				logging.WithField("error", err.Error()).Error("Nats connection was closed, failing liveness")
+
+				return
+			}
+
+			logging.Info("Nats connection was closed")
+		}),
+	}
+}
@@ -0,0 +1,123 @@
+package messenger
+
+import (
+	"context"
+	"sync"
+
+	nats "github.com/nats-io/go-nats"
+	"github.com/sotah-inc/server/app/pkg/messenger/codes"
+	"github.com/sotah-inc/server/app/pkg/tracing"
+	"github.com/twinj/uuid"
+)
+
+// Handler - handles a request received on a subject
+type Handler func(ctx context.Context, natsMsg nats.Msg)
+
+// Middleware - wraps the handling of every request received on a subject
+type Middleware func(subject string, next Handler) Handler
+
+// Use returns a messenger whose subscriptions are wrapped by the middlewares, where the first given is the outermost
+func (mess Messenger) Use(middlewares ...Middleware) Messenger {
+	next := make([]Middleware, 0, len(mess.middlewares)+len(middlewares))
+	next = append(next, mess.middlewares...)
+	mess.middlewares = append(next, middlewares...)
+
+	return mess
+}
+
+// chain wraps the handler by every middleware, with request logging outermost so that it sees every outcome
+func (mess Messenger) chain(subject string, handler Handler) Handler {
+	for i := len(mess.middlewares) - 1; i >= 0; i-- {
+		handler = mess.middlewares[i](subject, handler)
+	}
+
+	return logRequests(subject, handler)
+}
+
+type requestIDContextKey struct{}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the id of the request being handled, or blank outside of handling a request
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, ok := ctx.Value(requestIDContextKey{}).(string)
+	if !ok {
+		return ""
+	}
+
+	return requestID
+}
+
+func newRequestID() string {
+	return uuid.NewV4().String()
+}
+
+// RequestOutcome - how a request was replied to, as recorded by ReplyTo
+type RequestOutcome struct {
+	Replied      bool
+	Code         codes.Code
+	Err          string
+	ResponseSize int
+
+	// why the reply could not be published, where the requester received nothing
+	ReplyErr error
+}
+
+/*
+request - what is known of a request while it is handled, where ReplyTo records the outcome for middleware to report on
+once the handler returns, since replying is keyed by reply subject rather than by context
+*/
+type request struct {
+	sync.Mutex
+
+	id      string
+	span    tracing.Span
+	outcome RequestOutcome
+}
+
+func (r *request) recordReply(m Message, responseSize int, replyErr error) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.outcome = RequestOutcome{
+		Replied:      replyErr == nil,
+		Code:         m.Code,
+		Err:          m.Err,
+		ResponseSize: responseSize,
+		ReplyErr:     replyErr,
+	}
+}
+
+type requestContextKey struct{}
+
+func withRequest(ctx context.Context, r *request) context.Context {
+	return context.WithValue(WithRequestID(ctx, r.id), requestContextKey{}, r)
+}
+
+// RequestOutcomeFromContext returns how the request of the context was replied to so far
+func RequestOutcomeFromContext(ctx context.Context) (RequestOutcome, bool) {
+	r, ok := ctx.Value(requestContextKey{}).(*request)
+	if !ok {
+		return RequestOutcome{}, false
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	return r.outcome, true
+}
+
+func (mess Messenger) findRequest(reply string) (*request, bool) {
+	if mess.requests == nil || len(reply) == 0 {
+		return nil, false
+	}
+
+	found, ok := mess.requests.Load(reply)
+	if !ok {
+		return nil, false
+	}
+
+	return found.(*request), true
+}
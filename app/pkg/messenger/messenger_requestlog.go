@@ -0,0 +1,77 @@
+package messenger
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	nats "github.com/nats-io/go-nats"
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/messenger/codes"
+	"github.com/sotah-inc/server/app/pkg/metric"
+)
+
+const codeLabel = "code"
+
+var (
+	requestDurationHistogram = metric.NewHistogram(
+		"sotah_messenger_request_duration_seconds",
+		"Time taken to handle a request.",
+		metric.DurationBuckets,
+		metric.SubjectLabel,
+		codeLabel,
+	)
+	requestSizeHistogram = metric.NewHistogram(
+		"sotah_messenger_request_size_bytes",
+		"Size of request payloads.",
+		metric.SizeBuckets,
+		metric.SubjectLabel,
+	)
+	responseSizeHistogram = metric.NewHistogram(
+		"sotah_messenger_response_size_bytes",
+		"Size of encoded replies.",
+		metric.SizeBuckets,
+		metric.SubjectLabel,
+	)
+)
+
+// logRequests logs every request once it is handled, and records its latency and payload sizes by subject
+func logRequests(subject string, next Handler) Handler {
+	return func(ctx context.Context, natsMsg nats.Msg) {
+		startTime := time.Now()
+		next(ctx, natsMsg)
+		duration := time.Since(startTime)
+
+		outcome, _ := RequestOutcomeFromContext(ctx)
+
+		requestDurationHistogram.Observe(
+			metric.Labels{metric.SubjectLabel: subject, codeLabel: strconv.Itoa(int(outcome.Code))},
+			duration.Seconds(),
+		)
+		requestSizeHistogram.Observe(metric.Labels{metric.SubjectLabel: subject}, float64(len(natsMsg.Data)))
+		if outcome.Replied {
+			responseSizeHistogram.Observe(metric.Labels{metric.SubjectLabel: subject}, float64(outcome.ResponseSize))
+		}
+
+		entry := logging.WithFields(logrus.Fields{
+			"subject":       subject,
+			"request_id":    RequestIDFromContext(ctx),
+			"duration_ms":   int64(duration / time.Millisecond),
+			"request_size":  len(natsMsg.Data),
+			"response_size": outcome.ResponseSize,
+			"code":          outcome.Code,
+		})
+
+		switch {
+		case outcome.ReplyErr != nil:
+			entry.WithField("error", outcome.ReplyErr.Error()).Error("Failed to reply to request")
+		case len(natsMsg.Reply) > 0 && !outcome.Replied:
+			entry.Warn("Handled request without replying")
+		case outcome.Code != codes.Ok && outcome.Replied:
+			entry.WithField("error", outcome.Err).Error("Handled request erroneously")
+		default:
+			entry.Info("Handled request")
+		}
+	}
+}
@@ -1,10 +1,19 @@
 package messenger
 
 import (
+	"context"
+	"errors"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	nats "github.com/nats-io/go-nats"
+	"github.com/sotah-inc/server/app/pkg/messenger/codes"
+	"github.com/sotah-inc/server/app/pkg/messenger/deliveries"
+	"github.com/sotah-inc/server/app/pkg/messenger/roles"
+	"github.com/sotah-inc/server/app/pkg/tracing"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,8 +34,385 @@ func TestNewMessenger(t *testing.T) {
 		return
 	}
 
-	_, err = NewMessenger(natsHost, natsPort)
+	_, err = NewMessenger(natsHost, natsPort, ConnectConfig{Reconnect: DefaultReconnectConfig})
 	if !assert.Nil(t, err) {
 		return
 	}
 }
+
+func TestParseInbox(t *testing.T) {
+	ctx, span := tracing.Start(context.Background(), "request")
+	defer span.End()
+
+	requestID, traceparent := parseInbox(newInbox(ctx, "request-id"))
+	assert.Equal(t, "request-id", requestID)
+	assert.Equal(t, tracing.Traceparent(ctx), traceparent)
+
+	// inboxes of other requesters carry neither
+	requestID, traceparent = parseInbox(nats.NewInbox())
+	assert.Empty(t, requestID)
+	assert.Empty(t, traceparent)
+}
+
+func TestUseWrapsInOrder(t *testing.T) {
+	calls := []string{}
+	middleware := func(name string) Middleware {
+		return func(subject string, next Handler) Handler {
+			return func(ctx context.Context, natsMsg nats.Msg) {
+				calls = append(calls, name)
+				next(ctx, natsMsg)
+			}
+		}
+	}
+
+	mess := Messenger{}.Use(middleware("first")).Use(middleware("second"))
+	mess.chain("subject", func(ctx context.Context, natsMsg nats.Msg) {
+		calls = append(calls, "handler")
+	})(context.Background(), nats.Msg{})
+
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+}
+
+type testRequest struct {
+	Count int `json:"count"`
+}
+
+func (req testRequest) Validate() error {
+	if req.Count <= 0 {
+		return errors.New("count must be >0")
+	}
+
+	return nil
+}
+
+func TestEndpointResolve(t *testing.T) {
+	e := Endpoint[testRequest, testRequest]{
+		Handle: func(_ context.Context, req testRequest) (testRequest, codes.Code, error) {
+			if req.Count > 10 {
+				return testRequest{}, codes.NotFound, errors.New("not found")
+			}
+			if req.Count > 5 {
+				return testRequest{}, codes.Ok, errors.New("failed")
+			}
+
+			return req, codes.Ok, nil
+		},
+	}
+	resolve := func(data string) Message {
+		return e.resolve(context.Background(), []byte(data), decodeJSON[testRequest], encodeJSON[testRequest])
+	}
+
+	m := resolve(`{"count":2}`)
+	assert.Equal(t, codes.Ok, m.Code)
+	assert.Equal(t, `{"count":2}`, m.Data)
+
+	assert.Equal(t, codes.MsgJSONParseError, resolve(`{`).Code)
+	assert.Equal(t, codes.UserError, resolve(`{"count":0}`).Code)
+	assert.Equal(t, codes.NotFound, resolve(`{"count":11}`).Code)
+
+	m = resolve(`{"count":6}`)
+	assert.Equal(t, codes.GenericError, m.Code)
+	assert.Equal(t, "failed", m.Err)
+}
+
+func TestRecoverPanics(t *testing.T) {
+	handler := Messenger{}.RecoverPanics("subject", func(ctx context.Context, natsMsg nats.Msg) {
+		panic("handler failed")
+	})
+
+	assert.NotPanics(t, func() {
+		handler(context.Background(), nats.Msg{Subject: "subject"})
+	})
+}
+
+func TestLimitPayloadSize(t *testing.T) {
+	handled := 0
+	handler := Messenger{}.LimitPayloadSize(4)("subject", func(ctx context.Context, natsMsg nats.Msg) {
+		handled++
+	})
+
+	handler(context.Background(), nats.Msg{Data: []byte("1234")})
+	handler(context.Background(), nats.Msg{Data: []byte("12345")})
+
+	assert.Equal(t, 1, handled)
+}
+
+func TestKeyAuthenticator(t *testing.T) {
+	auth := NewKeyAuthenticator(
+		Permissions{"intake": roles.Service, "dates": roles.Client, "auctions": roles.Client},
+		StaticKeys{"client-key": roles.Client, "service-key": roles.Service},
+		testKeyStore{"issued.key": roles.Client},
+	)
+	ctx := context.Background()
+
+	assert.Nil(t, auth.Authenticate(ctx, "open", Credentials{}))
+	assert.Nil(t, auth.Authenticate(ctx, "dates", CredentialsFromPayload([]byte(`{"api_key":"client-key"}`))))
+	assert.Nil(t, auth.Authenticate(ctx, "intake", Credentials{APIKey: "service-key"}))
+	assert.Equal(t, ErrForbidden, auth.Authenticate(ctx, "intake", Credentials{APIKey: "client-key"}))
+	assert.Equal(t, ErrForbidden, auth.Authenticate(ctx, "dates", CredentialsFromPayload([]byte(`[]`))))
+	assert.Equal(t, ErrUnauthenticated, auth.Authenticate(ctx, "open", Credentials{APIKey: "guess"}))
+	assert.Nil(t, auth.Authenticate(ctx, "auctions", Credentials{APIKey: "issued.key"}))
+	assert.Equal(t, ErrForbidden, auth.Authenticate(ctx, "intake", Credentials{APIKey: "issued.key"}))
+	assert.Equal(t, errKeyStoreDown, auth.Authenticate(ctx, "auctions", Credentials{APIKey: "down"}))
+}
+
+var errKeyStoreDown = errors.New("key store is down")
+
+// testKeyStore - issued keys by role, where the key "down" could not be looked up and every request is rate limited
+type testKeyStore map[string]roles.Role
+
+func (keys testKeyStore) ResolveKey(_ context.Context, apiKey string) (roles.Role, bool, error) {
+	if apiKey == "down" {
+		return roles.Public, false, errKeyStoreDown
+	}
+
+	role, ok := keys[apiKey]
+
+	return role, ok, nil
+}
+
+func (keys testKeyStore) Admit(_ context.Context, _ string, credentials Credentials) error {
+	if _, ok := keys[credentials.APIKey]; ok {
+		return ErrRateLimited
+	}
+
+	return nil
+}
+
+func (keys testKeyStore) Close() error {
+	return nil
+}
+
+func TestMeterUsage(t *testing.T) {
+	handled := 0
+	handler := Messenger{}.MeterUsage(testKeyStore{"issued.key": roles.Client})(
+		"auctions",
+		func(ctx context.Context, natsMsg nats.Msg) {
+			handled++
+		},
+	)
+
+	handler(context.Background(), nats.Msg{})
+	ctx := context.WithValue(context.Background(), credentialsContextKey{}, Credentials{APIKey: "issued.key"})
+	handler(ctx, nats.Msg{})
+
+	assert.Equal(t, 1, handled)
+}
+
+func TestWithCredentials(t *testing.T) {
+	assert.Equal(t, `{"api_key":"key"}`, string(withCredentials([]byte{}, "key")))
+	assert.Equal(t, `{"api_key":"key"}`, string(withCredentials([]byte(`{ }`), "key")))
+	assert.Equal(t, `{"api_key":"key","a":1}`, string(withCredentials([]byte(`{"a":1}`), "key")))
+	assert.Equal(t, `[1]`, string(withCredentials([]byte(`[1]`), "key")))
+	assert.Equal(t, `{"a":1}`, string(withCredentials([]byte(`{"a":1}`), "")))
+
+	assert.Equal(t, `{"a":1}`, string(withoutCredentials(withCredentials([]byte(`{"a":1}`), "key"))))
+	assert.Equal(t, `{}`, string(withoutCredentials(withCredentials([]byte{}, "key"))))
+}
+
+func TestConcurrencyOf(t *testing.T) {
+	config := HandlingConfig{MaxConcurrency: 4, SubjectConcurrency: map[string]int{"intake": 1}}
+
+	assert.Equal(t, 4, config.concurrencyOf("auctions"))
+	assert.Equal(t, 1, config.concurrencyOf("intake"))
+}
+
+func TestTimeoutOf(t *testing.T) {
+	config := ConnectConfig{SubjectRequestTimeouts: map[string]time.Duration{"boot": time.Minute}}
+
+	assert.Equal(t, DefaultRequestTimeout, config.timeoutOf("auctions"))
+	assert.Equal(t, time.Minute, config.timeoutOf("boot"))
+
+	config.RequestTimeout = time.Second
+	assert.Equal(t, time.Second, config.timeoutOf("auctions"))
+}
+
+func TestConnectURL(t *testing.T) {
+	url, err := ConnectConfig{}.url("localhost", 4222)
+	assert.Nil(t, err)
+	assert.Equal(t, "nats://localhost:4222", url)
+
+	_, err = ConnectConfig{}.url("", 4222)
+	assert.NotNil(t, err)
+
+	url, err = ConnectConfig{Servers: []string{"nats://a:4222", "nats://b:4222"}}.url("", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "nats://a:4222,nats://b:4222", url)
+}
+
+func TestEncodeReply(t *testing.T) {
+	m := NewMessage()
+	m.Data = strings.Repeat("abcdefghij", 10)
+	m.RequestID = "request-id"
+
+	// fitting in the chunk size, or never chunking
+	payloads, err := encodeReply(m, 1024)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, payloads, 1)
+
+	payloads, err = encodeReply(m, 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, payloads, 1)
+
+	// chunking, where every chunk but the last is full and the terminal message follows
+	payloads, err = encodeReply(m, 30)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, payloads, 5)
+
+	assembler := &chunkAssembler{}
+	for i, payload := range payloads {
+		msg, done, err := assembler.add(payload)
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		if i < len(payloads)-1 {
+			assert.False(t, done)
+
+			continue
+		}
+
+		assert.True(t, done)
+		assert.Equal(t, m, msg)
+	}
+}
+
+func TestChunkAssembler(t *testing.T) {
+	m := NewMessage()
+	m.Data = strings.Repeat("a", 100)
+	payloads, err := encodeReply(m, 30)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// chunks out of order
+	_, _, err = (&chunkAssembler{}).add(payloads[1])
+	assert.NotNil(t, err)
+
+	// chunks missing ahead of the terminal message
+	assembler := &chunkAssembler{}
+	_, _, err = assembler.add(payloads[0])
+	assert.Nil(t, err)
+	_, _, err = assembler.add(payloads[len(payloads)-1])
+	assert.NotNil(t, err)
+}
+
+func TestChunkAssemblerLocksOntoFirstResponder(t *testing.T) {
+	first := NewMessage()
+	first.Data = strings.Repeat("a", 100)
+	firstPayloads, err := encodeReply(first, 30)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	second := NewMessage()
+	second.Data = strings.Repeat("b", 100)
+	secondPayloads, err := encodeReply(second, 30)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	whole, err := encodeReply(NewMessage(), 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// interleaving the replies of both responders, along with a whole reply from a third
+	interleaved := [][]byte{}
+	for i := range firstPayloads {
+		interleaved = append(interleaved, firstPayloads[i], secondPayloads[i])
+		if i == 1 {
+			interleaved = append(interleaved, whole[0])
+		}
+	}
+
+	assembler := &chunkAssembler{}
+	for _, payload := range interleaved {
+		msg, done, err := assembler.add(payload)
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		if done {
+			assert.Equal(t, first, msg)
+
+			return
+		}
+	}
+
+	t.Fatal("reply of the first responder was never reassembled")
+}
+
+func TestOversizedReply(t *testing.T) {
+	m := NewMessage()
+	m.Data = "data"
+	m.RequestID = "request-id"
+
+	out := oversizedReply(m, 2048, 1024)
+	assert.Equal(t, codes.GenericError, out.Code)
+	assert.Empty(t, out.Data)
+	assert.NotEmpty(t, out.Err)
+	assert.Equal(t, "request-id", out.RequestID)
+}
+
+func TestAcceptsChunks(t *testing.T) {
+	ctx, span := tracing.Start(context.Background(), "test")
+	defer span.End()
+
+	assert.True(t, acceptsChunks(newInbox(context.Background(), "request-id")))
+	assert.True(t, acceptsChunks(newInbox(ctx, "request-id")))
+	assert.False(t, acceptsChunks(nats.NewInbox()))
+}
+
+func TestQueueGroupOf(t *testing.T) {
+	config := HandlingConfig{
+		QueueGroup: DefaultQueueGroup,
+		Deliveries: Deliveries{"auctions": deliveries.Balanced, "intake": deliveries.Broadcast},
+	}
+
+	assert.Equal(t, DefaultQueueGroup, config.queueGroupOf("auctions"))
+	assert.Empty(t, config.queueGroupOf("intake"))
+	assert.Empty(t, config.queueGroupOf("status"))
+
+	// broadcasting every subject without a queue group
+	config.QueueGroup = ""
+	assert.Empty(t, config.queueGroupOf("auctions"))
+}
+
+func TestSubscriptionsRemove(t *testing.T) {
+	subs := newSubscriptions()
+	stopped := &subscription{subject: "auctions"}
+	remaining := &subscription{subject: "status"}
+	subs.add(stopped)
+	subs.add(remaining)
+
+	// only the first to remove an entry drains it
+	assert.True(t, subs.remove(stopped))
+	assert.False(t, subs.remove(stopped))
+
+	entries := subs.removeAll()
+	assert.Equal(t, []*subscription{remaining}, entries)
+	assert.False(t, subs.remove(remaining))
+	assert.Empty(t, subs.removeAll())
+
+	// draining waits on every entry that was taken out
+	drained := make(chan struct{})
+	go func() {
+		subs.stopping.Wait()
+		close(drained)
+	}()
+
+	subs.stopping.Done()
+	subs.stopping.Done()
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("stopping did not finish once every entry was drained")
+	}
+}
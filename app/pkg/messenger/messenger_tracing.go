@@ -0,0 +1,65 @@
+package messenger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	nats "github.com/nats-io/go-nats"
+	"github.com/sotah-inc/server/app/pkg/tracing"
+)
+
+/*
+newInbox produces a reply subject whose last tokens are the request id and the traceparent of the context, since nats
+messages carry no headers and request payloads are not wrapped in an envelope, and which is marked as accepting chunked
+replies
+*/
+func newInbox(ctx context.Context, requestID string) string {
+	inbox := fmt.Sprintf("%s.%s", nats.NewInbox(), chunkedInboxToken)
+
+	traceparent := tracing.Traceparent(ctx)
+	if len(traceparent) == 0 {
+		return inbox
+	}
+
+	return fmt.Sprintf("%s.%s.%s", inbox, requestID, traceparent)
+}
+
+/*
+parseInbox returns the request id and traceparent carried by a reply subject, where neither is returned unless the last
+token parses as a traceparent, since requesters other than this one produce inboxes of their own
+*/
+func parseInbox(reply string) (string, string) {
+	tokens := strings.Split(reply, ".")
+	if len(tokens) < 4 {
+		return "", ""
+	}
+
+	traceparent := tokens[len(tokens)-1]
+	if _, err := tracing.ParseTraceparent(traceparent); err != nil {
+		return "", ""
+	}
+
+	return tokens[len(tokens)-2], traceparent
+}
+
+/*
+request subscribes to its own inbox rather than the shared one, so that the inbox may carry the request id and trace,
+and reads replies off of it until the message is whole, since large replies arrive in chunks
+*/
+func (mess Messenger) request(ctx context.Context, subject string, requestID string, data []byte) (Message, error) {
+	inbox := newInbox(ctx, requestID)
+	sub, err := mess.conn.SubscribeSync(inbox)
+	if err != nil {
+		return Message{}, err
+	}
+	defer sub.Unsubscribe()
+
+	if err := mess.conn.PublishRequest(subject, inbox, data); err != nil {
+		return Message{}, err
+	}
+
+	return receiveReply(func() (*nats.Msg, error) {
+		return sub.NextMsgWithContext(ctx)
+	})
+}
@@ -0,0 +1,13 @@
+package roles
+
+// Role - typehint for these enums
+type Role string
+
+/*
+Roles - who may request a subject, where each role may request the subjects of the roles before it
+*/
+const (
+	Public  Role = "public"
+	Client  Role = "client"
+	Service Role = "service"
+)
@@ -8,4 +8,5 @@ const (
 	PricelistHistoriesIntake        Kind = "pricelisthistories_intake"
 	PricelistHistoriesIntakeV2      Kind = "pricelisthistories_intake_v2"
 	PricelistHistoriesComputeIntake Kind = "pricelisthistories_compute_intake"
+	APIKeys                         Kind = "api_keys"
 )
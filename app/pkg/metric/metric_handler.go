@@ -0,0 +1,36 @@
+package metric
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/sotah-inc/server/app/pkg/logging"
+)
+
+const (
+	Path = "/metrics"
+
+	textContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// Handler serves the registry in the prometheus text format, buffering so that a failed write is not served partially
+func (r Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		buf := bytes.Buffer{}
+		if err := r.Write(&buf); err != nil {
+			logging.WithField("error", err.Error()).Error("Failed to write metrics")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", textContentType)
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			logging.WithField("error", err.Error()).Error("Failed to serve metrics")
+		}
+	})
+}
+
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
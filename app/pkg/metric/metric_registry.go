@@ -0,0 +1,404 @@
+package metric
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// label names shared across metrics
+const (
+	RegionLabel  = "region"
+	RealmLabel   = "realm"
+	CommandLabel = "command"
+	SubjectLabel = "subject"
+)
+
+type Labels map[string]string
+
+type labelsContextKey struct{}
+
+// WithLabels carries labels down the call stack, for metrics recorded far from where the labels are known
+func WithLabels(ctx context.Context, labels Labels) context.Context {
+	merged := Labels{}
+	for k, v := range LabelsFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+
+	return context.WithValue(ctx, labelsContextKey{}, merged)
+}
+
+func LabelsFromContext(ctx context.Context) Labels {
+	labels, ok := ctx.Value(labelsContextKey{}).(Labels)
+	if !ok {
+		return Labels{}
+	}
+
+	return labels
+}
+
+type metricType string
+
+const (
+	counterType   metricType = "counter"
+	gaugeType     metricType = "gauge"
+	histogramType metricType = "histogram"
+)
+
+// DurationBuckets - histogram buckets in seconds, spanning quick lookups up to slow downloads
+var DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// SizeBuckets - histogram buckets in bytes, spanning empty payloads up to whole realms of auctions
+var SizeBuckets = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216}
+
+func NewRegistry() Registry {
+	return Registry{state: &registryState{families: map[string]*family{}, constLabels: Labels{}}}
+}
+
+// Registry - holds every metric family of a process, and writes them in the prometheus text format
+type Registry struct {
+	state *registryState
+}
+
+type registryState struct {
+	sync.RWMutex
+
+	families    map[string]*family
+	constLabels Labels
+}
+
+// SetConstLabels sets labels written on every series, such as the command being run
+func (r Registry) SetConstLabels(labels Labels) {
+	r.state.Lock()
+	defer r.state.Unlock()
+
+	r.state.constLabels = labels
+}
+
+/*
+resolveFamily returns the family of the name when one was already registered, so that registering is idempotent, and
+errors where that family is of another type or labelled otherwise
+*/
+func (r Registry) resolveFamily(
+	name string,
+	help string,
+	kind metricType,
+	buckets []float64,
+	labelNames []string,
+) (*family, error) {
+	r.state.Lock()
+	defer r.state.Unlock()
+
+	if found, ok := r.state.families[name]; ok {
+		if found.kind != kind {
+			return nil, fmt.Errorf("metric %s is registered as a %s, not a %s", name, found.kind, kind)
+		}
+
+		if strings.Join(found.labelNames, ",") != strings.Join(labelNames, ",") {
+			return nil, fmt.Errorf(
+				"metric %s is registered with labels [%s], not [%s]",
+				name,
+				strings.Join(found.labelNames, ","),
+				strings.Join(labelNames, ","),
+			)
+		}
+
+		return found, nil
+	}
+
+	f := &family{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		buckets:    buckets,
+		labelNames: labelNames,
+		series:     map[string]*series{},
+	}
+	r.state.families[name] = f
+
+	return f, nil
+}
+
+// mustResolveFamily panics where resolving fails, since metrics declared in code conflicting is a programming error
+func (r Registry) mustResolveFamily(
+	name string,
+	help string,
+	kind metricType,
+	buckets []float64,
+	labelNames []string,
+) *family {
+	f, err := r.resolveFamily(name, help, kind, buckets, labelNames)
+	if err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+func (r Registry) NewCounter(name string, help string, labelNames ...string) Counter {
+	return Counter{r.mustResolveFamily(name, help, counterType, nil, labelNames)}
+}
+
+func (r Registry) NewGauge(name string, help string, labelNames ...string) Gauge {
+	return Gauge{r.mustResolveFamily(name, help, gaugeType, nil, labelNames)}
+}
+
+func (r Registry) NewHistogram(name string, help string, buckets []float64, labelNames ...string) Histogram {
+	sortedBuckets := append([]float64{}, buckets...)
+	sort.Float64s(sortedBuckets)
+
+	return Histogram{r.mustResolveFamily(name, help, histogramType, sortedBuckets, labelNames)}
+}
+
+type family struct {
+	sync.Mutex
+
+	name       string
+	help       string
+	kind       metricType
+	buckets    []float64
+	labelNames []string
+	series     map[string]*series
+}
+
+type series struct {
+	labelValues []string
+
+	value float64
+
+	bucketCounts []uint64
+	sum          float64
+	count        uint64
+}
+
+// resolveSeries must be called with the family locked, and ignores labels that the family does not declare
+func (f *family) resolveSeries(labels Labels) *series {
+	labelValues := make([]string, len(f.labelNames))
+	for i, labelName := range f.labelNames {
+		labelValues[i] = labels[labelName]
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	if found, ok := f.series[key]; ok {
+		return found
+	}
+
+	s := &series{labelValues: labelValues, bucketCounts: make([]uint64, len(f.buckets))}
+	f.series[key] = s
+
+	return s
+}
+
+type Counter struct {
+	f *family
+}
+
+func (c Counter) Add(labels Labels, v float64) {
+	if v < 0 {
+		return
+	}
+
+	c.f.Lock()
+	defer c.f.Unlock()
+
+	c.f.resolveSeries(labels).value += v
+}
+
+func (c Counter) Inc(labels Labels) {
+	c.Add(labels, 1)
+}
+
+type Gauge struct {
+	f *family
+}
+
+func (g Gauge) Set(labels Labels, v float64) {
+	g.f.Lock()
+	defer g.f.Unlock()
+
+	g.f.resolveSeries(labels).value = v
+}
+
+func (g Gauge) Add(labels Labels, v float64) {
+	g.f.Lock()
+	defer g.f.Unlock()
+
+	g.f.resolveSeries(labels).value += v
+}
+
+type Histogram struct {
+	f *family
+}
+
+func (h Histogram) Observe(labels Labels, v float64) {
+	h.f.Lock()
+	defer h.f.Unlock()
+
+	s := h.f.resolveSeries(labels)
+	for i, upperBound := range h.f.buckets {
+		if v <= upperBound {
+			s.bucketCounts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// formatLabels writes the const labels, then the series labels, then an optional extra pair such as a bucket bound
+func formatLabels(constLabels Labels, labelNames []string, labelValues []string, extra ...string) string {
+	pairs := []string{}
+
+	constNames := make([]string, 0, len(constLabels))
+	for name := range constLabels {
+		constNames = append(constNames, name)
+	}
+	sort.Strings(constNames)
+	for _, name := range constNames {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, labelValueEscaper.Replace(constLabels[name])))
+	}
+
+	for i, name := range labelNames {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, labelValueEscaper.Replace(labelValues[i])))
+	}
+
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], labelValueEscaper.Replace(extra[i+1])))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ","))
+}
+
+func (f *family) write(w io.Writer, constLabels Labels) error {
+	f.Lock()
+	defer f.Unlock()
+
+	if len(f.series) == 0 {
+		return nil
+	}
+
+	header := fmt.Sprintf("# HELP %s %s\n# TYPE %s %s\n", f.name, helpEscaper.Replace(f.help), f.name, f.kind)
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+
+		if f.kind != histogramType {
+			line := fmt.Sprintf(
+				"%s%s %s\n",
+				f.name,
+				formatLabels(constLabels, f.labelNames, s.labelValues),
+				formatFloat(s.value),
+			)
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		for i, upperBound := range f.buckets {
+			line := fmt.Sprintf(
+				"%s_bucket%s %d\n",
+				f.name,
+				formatLabels(constLabels, f.labelNames, s.labelValues, "le", formatFloat(upperBound)),
+				s.bucketCounts[i],
+			)
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+
+		lines := fmt.Sprintf(
+			"%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			f.name,
+			formatLabels(constLabels, f.labelNames, s.labelValues, "le", "+Inf"),
+			s.count,
+			f.name,
+			formatLabels(constLabels, f.labelNames, s.labelValues),
+			formatFloat(s.sum),
+			f.name,
+			formatLabels(constLabels, f.labelNames, s.labelValues),
+			s.count,
+		)
+		if _, err := io.WriteString(w, lines); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Write writes every family in the prometheus text exposition format, ordered by name
+func (r Registry) Write(w io.Writer) error {
+	r.state.RLock()
+	constLabels := r.state.constLabels
+	families := make([]*family, 0, len(r.state.families))
+	for _, f := range r.state.families {
+		families = append(families, f)
+	}
+	r.state.RUnlock()
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+
+	for _, f := range families {
+		if err := f.write(w, constLabels); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DefaultRegistry - where the metrics of this process are registered, and what the metrics endpoint serves
+var DefaultRegistry = NewRegistry()
+
+func NewCounter(name string, help string, labelNames ...string) Counter {
+	return DefaultRegistry.NewCounter(name, help, labelNames...)
+}
+
+func NewGauge(name string, help string, labelNames ...string) Gauge {
+	return DefaultRegistry.NewGauge(name, help, labelNames...)
+}
+
+func NewHistogram(name string, help string, buckets []float64, labelNames ...string) Histogram {
+	return DefaultRegistry.NewHistogram(name, help, buckets, labelNames...)
+}
@@ -0,0 +1,112 @@
+package metric
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+	r.SetConstLabels(Labels{CommandLabel: "api"})
+
+	counter := r.NewCounter("sotah_test_total", "A test counter.", RegionLabel)
+	counter.Inc(Labels{RegionLabel: "us"})
+	counter.Add(Labels{RegionLabel: "us", RealmLabel: "ignored"}, 2)
+
+	// families without any series are not written
+	r.NewGauge("sotah_test_empty", "An empty gauge.")
+
+	buf := bytes.Buffer{}
+	if !assert.Nil(t, r.Write(&buf)) {
+		return
+	}
+
+	expected := "# HELP sotah_test_total A test counter.\n" +
+		"# TYPE sotah_test_total counter\n" +
+		"sotah_test_total{command=\"api\",region=\"us\"} 3\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestRegistryIsIdempotent(t *testing.T) {
+	r := NewRegistry()
+
+	r.NewGauge("sotah_test_gauge", "A test gauge.").Set(Labels{}, 1)
+	r.NewGauge("sotah_test_gauge", "A test gauge.").Add(Labels{}, 1)
+
+	buf := bytes.Buffer{}
+	if !assert.Nil(t, r.Write(&buf)) {
+		return
+	}
+
+	assert.Contains(t, buf.String(), "sotah_test_gauge 2\n")
+}
+
+func TestHistogramObserve(t *testing.T) {
+	r := NewRegistry()
+
+	h := r.NewHistogram("sotah_test_seconds", "A test histogram.", []float64{1, 0.5}, SubjectLabel)
+	h.Observe(Labels{SubjectLabel: "status"}, 0.25)
+	h.Observe(Labels{SubjectLabel: "status"}, 0.75)
+	h.Observe(Labels{SubjectLabel: "status"}, 2)
+
+	buf := bytes.Buffer{}
+	if !assert.Nil(t, r.Write(&buf)) {
+		return
+	}
+
+	expected := "# HELP sotah_test_seconds A test histogram.\n" +
+		"# TYPE sotah_test_seconds histogram\n" +
+		"sotah_test_seconds_bucket{subject=\"status\",le=\"0.5\"} 1\n" +
+		"sotah_test_seconds_bucket{subject=\"status\",le=\"1\"} 2\n" +
+		"sotah_test_seconds_bucket{subject=\"status\",le=\"+Inf\"} 3\n" +
+		"sotah_test_seconds_sum{subject=\"status\"} 3\n" +
+		"sotah_test_seconds_count{subject=\"status\"} 3\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestLabelsFromContext(t *testing.T) {
+	ctx := WithLabels(context.Background(), Labels{RegionLabel: "us"})
+	ctx = WithLabels(ctx, Labels{RealmLabel: "earthfury"})
+
+	assert.Equal(t, Labels{RegionLabel: "us", RealmLabel: "earthfury"}, LabelsFromContext(ctx))
+	assert.Equal(t, Labels{}, LabelsFromContext(context.Background()))
+}
+
+func TestRegistryConflicts(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("sotah_test_total", "A test counter.", RegionLabel)
+
+	_, err := r.resolveFamily("sotah_test_total", "A test gauge.", gaugeType, nil, []string{RegionLabel})
+	assert.NotNil(t, err)
+
+	_, err = r.resolveFamily("sotah_test_total", "A test counter.", counterType, nil, []string{RealmLabel})
+	assert.NotNil(t, err)
+
+	assert.Panics(t, func() {
+		r.NewGauge("sotah_test_total", "A test gauge.", RegionLabel)
+	})
+}
+
+func TestReporterRecordsConflictsWithoutPanicking(t *testing.T) {
+	SetRelay(false)
+	defer SetRelay(true)
+
+	r := NewRegistry()
+	r.NewCounter("sotah_test_total", "A test counter.")
+
+	re := Reporter{Registry: r}
+	assert.NotPanics(t, func() {
+		re.Report(Metrics{"test_total": 1, "test_gauge": 2})
+	})
+
+	buf := bytes.Buffer{}
+	if !assert.Nil(t, r.Write(&buf)) {
+		return
+	}
+
+	assert.Contains(t, buf.String(), "sotah_test_gauge 2\n")
+	assert.NotContains(t, buf.String(), "sotah_test_total 1\n")
+}
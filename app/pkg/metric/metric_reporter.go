@@ -3,9 +3,10 @@ package metric
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"sync/atomic"
 
 	"github.com/sotah-inc/server/app/pkg/logging"
-	"github.com/sotah-inc/server/app/pkg/messenger"
 	"github.com/sotah-inc/server/app/pkg/metric/kinds"
 )
 
@@ -15,17 +16,70 @@ const (
 	ClassB           = "class_b"
 )
 
-func NewReporter(mess messenger.Messenger) Reporter {
-	return Reporter{mess}
+// Publisher - where reported metrics are relayed, such as a messenger, which in turn records metrics of its own
+type Publisher interface {
+	Publish(subject string, data []byte) error
 }
 
+func NewReporter(publisher Publisher) Reporter {
+	return Reporter{Publisher: publisher, Registry: DefaultRegistry}
+}
+
+// Reporter - records flat metrics as gauges, and relays them to the app-metrics subject unless relaying is disabled
 type Reporter struct {
-	Messenger messenger.Messenger
+	Publisher Publisher
+	Registry  Registry
 }
 
 type Metrics map[string]int
 
+// relayDisabled - whether reported metrics are only recorded, for deployments that scrape instead of relaying
+var relayDisabled int32
+
+func SetRelay(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&relayDisabled, 0)
+
+		return
+	}
+
+	atomic.StoreInt32(&relayDisabled, 1)
+}
+
+func isRelayDisabled() bool {
+	return atomic.LoadInt32(&relayDisabled) == 1
+}
+
+// reportedPrefix - prefixes gauges recorded from reported metrics, which are otherwise named freely
+const reportedPrefix = "sotah_"
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+
+func (re Reporter) record(m Metrics) {
+	if re.Registry.state == nil {
+		return
+	}
+
+	for name, value := range m {
+		gaugeName := reportedPrefix + invalidNameChars.ReplaceAllString(name, "_")
+		f, err := re.Registry.resolveFamily(gaugeName, fmt.Sprintf("Last reported %s.", name), gaugeType, nil, nil)
+		if err != nil {
+			logging.WithField("error", err.Error()).Error("Failed to record reported metric")
+
+			continue
+		}
+
+		Gauge{f}.Set(Labels{}, float64(value))
+	}
+}
+
 func (re Reporter) Report(m Metrics) {
+	re.record(m)
+
+	if isRelayDisabled() || re.Publisher == nil {
+		return
+	}
+
 	data, err := json.Marshal(m)
 	if err != nil {
 		logging.WithField("error", err.Error()).Error("Failed to marshal report metric")
@@ -33,7 +87,7 @@ func (re Reporter) Report(m Metrics) {
 		return
 	}
 
-	if err := re.Messenger.Publish(appMetricSubject, data); err != nil {
+	if err := re.Publisher.Publish(appMetricSubject, data); err != nil {
 		logging.WithField("error", err.Error()).Error("Failed to publish to app-metrics subject")
 
 		return
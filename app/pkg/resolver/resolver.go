@@ -1,10 +1,53 @@
 package resolver
 
 import (
+	"context"
+	"net/url"
+	"strconv"
+
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/metric"
+	"github.com/sotah-inc/server/app/pkg/tracing"
+)
+
+var (
+	connDurationHistogram = metric.NewHistogram(
+		"sotah_blizzard_conn_duration_seconds",
+		"Time taken to connect to the blizzard api.",
+		metric.DurationBuckets,
+		metric.RegionLabel,
+		metric.RealmLabel,
+		statusLabel,
+	)
+	requestDurationHistogram = metric.NewHistogram(
+		"sotah_blizzard_request_duration_seconds",
+		"Time taken to download a response from the blizzard api.",
+		metric.DurationBuckets,
+		metric.RegionLabel,
+		metric.RealmLabel,
+		statusLabel,
+	)
+)
+
+const statusLabel = "status"
+
+// span attribute keys of downloads
+const (
+	urlPathAttribute    = "http.url_path"
+	statusCodeAttribute = "http.status_code"
 )
 
+// downloadLabels labels a download by the region and realm carried on the context, and by its response status
+func downloadLabels(ctx context.Context, resp blizzard.ResponseMeta) metric.Labels {
+	labels := metric.LabelsFromContext(ctx)
+
+	return metric.Labels{
+		metric.RegionLabel: labels[metric.RegionLabel],
+		metric.RealmLabel:  labels[metric.RealmLabel],
+		statusLabel:        strconv.Itoa(resp.Status),
+	}
+}
+
 func NewResolver(bc blizzard.Client, re metric.Reporter) Resolver {
 	return Resolver{
 		BlizzardClient: bc,
@@ -35,7 +78,24 @@ func (r Resolver) AppendAccessToken(destination string) (string, error) {
 	return r.BlizzardClient.AppendAccessToken(destination)
 }
 
-func (r Resolver) Download(uri string, shouldAppendAccessToken bool) (blizzard.ResponseMeta, error) {
+// downloadSpan starts a span labelled like the download metrics, leaving out the query since it may hold a token
+func downloadSpan(ctx context.Context, uri string) (context.Context, tracing.Span) {
+	ctx, span := tracing.Start(ctx, "blizzard download")
+
+	labels := metric.LabelsFromContext(ctx)
+	span.SetAttribute(tracing.RegionAttribute, labels[metric.RegionLabel])
+	span.SetAttribute(tracing.RealmAttribute, labels[metric.RealmLabel])
+	if parsed, err := url.Parse(uri); err == nil {
+		span.SetAttribute(urlPathAttribute, parsed.Path)
+	}
+
+	return ctx, span
+}
+
+func (r Resolver) Download(ctx context.Context, uri string, shouldAppendAccessToken bool) (blizzard.ResponseMeta, error) {
+	ctx, span := downloadSpan(ctx, uri)
+	defer span.End()
+
 	uri, err := func() (string, error) {
 		if !shouldAppendAccessToken {
 			return uri, nil
@@ -44,11 +104,18 @@ func (r Resolver) Download(uri string, shouldAppendAccessToken bool) (blizzard.R
 		return r.AppendAccessToken(uri)
 	}()
 	if err != nil {
+		span.SetError(err)
+
 		return blizzard.ResponseMeta{}, err
 	}
 
-	resp, err := blizzard.Download(uri)
+	resp, err := blizzard.DownloadWithContext(ctx, uri)
+	span.SetAttribute(statusCodeAttribute, resp.Status)
 	if resp.RequestDuration > 0 || resp.ConnectionDuration > 0 {
+		labels := downloadLabels(ctx, resp)
+		connDurationHistogram.Observe(labels, resp.ConnectionDuration.Seconds())
+		requestDurationHistogram.Observe(labels, resp.RequestDuration.Seconds())
+
 		r.Reporter.Report(metric.Metrics{
 			"conn_duration":    int(resp.ConnectionDuration / 1000 / 1000),
 			"request_duration": int(resp.RequestDuration / 1000 / 1000),
@@ -56,6 +123,8 @@ func (r Resolver) Download(uri string, shouldAppendAccessToken bool) (blizzard.R
 	}
 
 	if err != nil {
+		span.SetError(err)
+
 		return blizzard.ResponseMeta{}, err
 	}
 
@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"time"
@@ -8,12 +9,13 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/metric"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/util"
 )
 
-func (r Resolver) NewAuctionInfoFromHTTP(uri string) (blizzard.AuctionInfo, error) {
-	resp, err := r.Download(uri, true)
+func (r Resolver) NewAuctionInfoFromHTTP(ctx context.Context, uri string) (blizzard.AuctionInfo, error) {
+	resp, err := r.Download(ctx, uri, true)
 	if err != nil {
 		return blizzard.AuctionInfo{}, err
 	}
@@ -24,8 +26,8 @@ func (r Resolver) NewAuctionInfoFromHTTP(uri string) (blizzard.AuctionInfo, erro
 	return blizzard.NewAuctionInfo(resp.Body)
 }
 
-func (r Resolver) NewAuctionsFromHTTP(uri string) (blizzard.Auctions, error) {
-	resp, err := r.Download(uri, false)
+func (r Resolver) NewAuctionsFromHTTP(ctx context.Context, uri string) (blizzard.Auctions, error) {
+	resp, err := r.Download(ctx, uri, false)
 	if err != nil {
 		return blizzard.Auctions{}, err
 	}
@@ -37,11 +39,17 @@ func (r Resolver) NewAuctionsFromHTTP(uri string) (blizzard.Auctions, error) {
 }
 
 func (r Resolver) GetAuctionsForRealm(
+	ctx context.Context,
 	rea sotah.Realm,
 	realmModDates sotah.RealmModificationDates,
 ) (blizzard.Auctions, time.Time, error) {
+	ctx = metric.WithLabels(ctx, metric.Labels{
+		metric.RegionLabel: string(rea.Region.Name),
+		metric.RealmLabel:  string(rea.Slug),
+	})
+
 	// resolving auction-info from the api
-	aInfo, err := r.NewAuctionInfoFromHTTP(r.GetAuctionInfoURL(rea.Region.Hostname, rea.Slug))
+	aInfo, err := r.NewAuctionInfoFromHTTP(ctx, r.GetAuctionInfoURL(rea.Region.Hostname, rea.Slug))
 	if err != nil {
 		return blizzard.Auctions{}, time.Time{}, err
 	}
@@ -60,7 +68,7 @@ func (r Resolver) GetAuctionsForRealm(
 
 	// optionally downloading where the Realm has stale data
 	if realmModDates.Downloaded == 0 || time.Unix(realmModDates.Downloaded, 0).Before(aFile.LastModifiedAsTime()) {
-		aucs, err := r.NewAuctionsFromHTTP(aFile.URL)
+		aucs, err := r.NewAuctionsFromHTTP(ctx, aFile.URL)
 		if err != nil {
 			return blizzard.Auctions{}, time.Time{}, err
 		}
@@ -94,6 +102,7 @@ func (job GetAuctionsJob) ToLogrusFields() logrus.Fields {
 }
 
 func (r Resolver) GetAuctionsForRealms(
+	ctx context.Context,
 	reas sotah.Realms,
 	modDates sotah.RegionRealmModificationDates,
 ) chan GetAuctionsJob {
@@ -104,7 +113,7 @@ func (r Resolver) GetAuctionsForRealms(
 	// spinning up the workers for fetching Auctions
 	worker := func() {
 		for rea := range in {
-			aucs, lastModified, err := r.GetAuctionsForRealm(rea, modDates.Get(rea.Region.Name, rea.Slug))
+			aucs, lastModified, err := r.GetAuctionsForRealm(ctx, rea, modDates.Get(rea.Region.Name, rea.Slug))
 
 			// optionally halting on error
 			if err != nil {
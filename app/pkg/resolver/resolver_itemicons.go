@@ -1,13 +1,15 @@
 package resolver
 
 import (
+	"context"
+
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/util"
 )
 
-func (r Resolver) GetItemIconData(uri string) ([]byte, error) {
-	resp, err := r.Download(uri, false)
+func (r Resolver) GetItemIconData(ctx context.Context, uri string) ([]byte, error) {
+	resp, err := r.Download(ctx, uri, false)
 	if err != nil {
 		return []byte{}, err
 	}
@@ -28,7 +30,7 @@ func (job GetItemIconsJob) ToLogrusFields() logrus.Fields {
 	}
 }
 
-func (r Resolver) GetItemIcons(iconNames []string) chan GetItemIconsJob {
+func (r Resolver) GetItemIcons(ctx context.Context, iconNames []string) chan GetItemIconsJob {
 	// establishing channels
 	out := make(chan GetItemIconsJob)
 	in := make(chan string)
@@ -36,7 +38,7 @@ func (r Resolver) GetItemIcons(iconNames []string) chan GetItemIconsJob {
 	// spinning up the workers for fetching items
 	worker := func() {
 		for iconName := range in {
-			iconData, err := r.GetItemIconData(blizzard.DefaultGetItemIconURL(iconName))
+			iconData, err := r.GetItemIconData(ctx, blizzard.DefaultGetItemIconURL(iconName))
 			out <- GetItemIconsJob{err, iconName, iconData}
 		}
 	}
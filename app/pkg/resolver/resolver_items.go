@@ -1,15 +1,19 @@
 package resolver
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/metric"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/util"
 )
 
-func (r Resolver) NewItem(primaryRegion sotah.Region, ID blizzard.ItemID) (blizzard.Item, error) {
-	resp, err := r.Download(r.GetItemURL(primaryRegion.Hostname, ID), true)
+func (r Resolver) NewItem(ctx context.Context, primaryRegion sotah.Region, ID blizzard.ItemID) (blizzard.Item, error) {
+	ctx = metric.WithLabels(ctx, metric.Labels{metric.RegionLabel: string(primaryRegion.Name)})
+
+	resp, err := r.Download(ctx, r.GetItemURL(primaryRegion.Hostname, ID), true)
 	if err != nil {
 		return blizzard.Item{}, err
 	}
@@ -27,7 +31,7 @@ type GetItemsJob struct {
 	Exists bool
 }
 
-func (r Resolver) GetItems(primaryRegion sotah.Region, IDs []blizzard.ItemID) chan GetItemsJob {
+func (r Resolver) GetItems(ctx context.Context, primaryRegion sotah.Region, IDs []blizzard.ItemID) chan GetItemsJob {
 	// establishing channels
 	out := make(chan GetItemsJob)
 	in := make(chan blizzard.ItemID)
@@ -35,7 +39,7 @@ func (r Resolver) GetItems(primaryRegion sotah.Region, IDs []blizzard.ItemID) ch
 	// spinning up the workers for fetching items
 	worker := func() {
 		for itemId := range in {
-			item, err := r.NewItem(primaryRegion, itemId)
+			item, err := r.NewItem(ctx, primaryRegion, itemId)
 			if err != nil {
 				out <- GetItemsJob{
 					Err:    err,
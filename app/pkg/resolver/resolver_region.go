@@ -1,16 +1,20 @@
 package resolver
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
 	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/metric"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/util"
 )
 
-func (r Resolver) NewStatus(reg sotah.Region) (sotah.Status, error) {
-	resp, err := r.Download(r.GetStatusURL(reg.Hostname), true)
+func (r Resolver) NewStatus(ctx context.Context, reg sotah.Region) (sotah.Status, error) {
+	ctx = metric.WithLabels(ctx, metric.Labels{metric.RegionLabel: string(reg.Name)})
+
+	resp, err := r.Download(ctx, r.GetStatusURL(reg.Hostname), true)
 	if err != nil {
 		return sotah.Status{}, err
 	}
@@ -32,7 +36,7 @@ type GetStatusesJob struct {
 	Status sotah.Status
 }
 
-func (r Resolver) GetStatuses(regions sotah.RegionList) chan GetStatusesJob {
+func (r Resolver) GetStatuses(ctx context.Context, regions sotah.RegionList) chan GetStatusesJob {
 	// establishing channels
 	out := make(chan GetStatusesJob)
 	in := make(chan sotah.Region)
@@ -40,7 +44,7 @@ func (r Resolver) GetStatuses(regions sotah.RegionList) chan GetStatusesJob {
 	// spinning up the workers for fetching items
 	worker := func() {
 		for region := range in {
-			status, err := r.NewStatus(region)
+			status, err := r.NewStatus(ctx, region)
 			if err != nil {
 				out <- GetStatusesJob{
 					Err:    err,
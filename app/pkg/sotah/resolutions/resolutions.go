@@ -0,0 +1,13 @@
+package resolutions
+
+// Resolution - typehint for these enums
+type Resolution string
+
+/*
+Resolutions - granularity of pricelist-history points
+*/
+const (
+	Hourly Resolution = "hourly"
+	Daily  Resolution = "daily"
+	Weekly Resolution = "weekly"
+)
@@ -0,0 +1,17 @@
+package retentionkinds
+
+// RetentionKind - typehint for these enums
+type RetentionKind string
+
+/*
+RetentionKinds - types of data with their own retention, where pricelist-histories are the objects in the store and
+pricelist-history shards are the local databases, and live-auctions are the auctions of realms that stopped updating
+*/
+const (
+	AuctionDumps           RetentionKind = "auction_dumps"
+	Manifests              RetentionKind = "manifests"
+	PricelistHistories     RetentionKind = "pricelist_histories"
+	PricelistHistoryShards RetentionKind = "pricelist_history_shards"
+	DailyRollups           RetentionKind = "daily_rollups"
+	LiveAuctions           RetentionKind = "live_auctions"
+)
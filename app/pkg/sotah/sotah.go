@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/sotah/retentionkinds"
 	"github.com/sotah-inc/server/app/pkg/util"
 )
 
@@ -108,6 +109,36 @@ func (d RegionRealmModificationDates) Set(
 	return d
 }
 
+func (d RegionRealmModificationDates) Clone() RegionRealmModificationDates {
+	out := RegionRealmModificationDates{}
+	for regionName, realmsModDates := range d {
+		out[regionName] = map[blizzard.RealmSlug]RealmModificationDates{}
+		for realmSlug, modDates := range realmsModDates {
+			out[regionName][realmSlug] = modDates
+		}
+	}
+
+	return out
+}
+
+// Latest returns the most recent modification of any realm, or the zero time when there is none
+func (d RegionRealmModificationDates) Latest() time.Time {
+	var latest int64
+	for _, realmsModDates := range d {
+		for _, modDates := range realmsModDates {
+			if next := modDates.Latest(); next > latest {
+				latest = next
+			}
+		}
+	}
+
+	if latest == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(latest, 0)
+}
+
 func (d RegionRealmModificationDates) EncodeForDelivery() ([]byte, error) {
 	return json.Marshal(d)
 }
@@ -118,6 +149,19 @@ type RealmModificationDates struct {
 	PricelistHistoriesReceived int64 `json:"pricelist_histories_received"`
 }
 
+// Latest returns the unix timestamp of the most recent modification of any kind
+func (d RealmModificationDates) Latest() int64 {
+	latest := d.Downloaded
+	if d.LiveAuctionsReceived > latest {
+		latest = d.LiveAuctionsReceived
+	}
+	if d.PricelistHistoriesReceived > latest {
+		latest = d.PricelistHistoriesReceived
+	}
+
+	return latest
+}
+
 func NewSkeletonRealm(regionName blizzard.RegionName, realmSlug blizzard.RealmSlug) Realm {
 	return Realm{
 		Region: Region{Name: regionName},
@@ -245,6 +289,30 @@ type RealmTimestamps map[blizzard.RealmSlug][]UnixTimestamp
 
 type RegionRealmTimestamps map[blizzard.RegionName]RealmTimestamps
 
+// filter keeps the timestamps matching the predicate, leaving out regions and realms without any
+func (r RegionRealmTimestamps) filter(
+	predicate func(regionName blizzard.RegionName, targetTime time.Time) bool,
+) RegionRealmTimestamps {
+	out := RegionRealmTimestamps{}
+	for regionName, realmTimestamps := range r {
+		for realmSlug, timestamps := range realmTimestamps {
+			for _, timestamp := range timestamps {
+				if !predicate(regionName, time.Unix(int64(timestamp), 0)) {
+					continue
+				}
+
+				if _, ok := out[regionName]; !ok {
+					out[regionName] = RealmTimestamps{}
+				}
+
+				out[regionName][realmSlug] = append(out[regionName][realmSlug], timestamp)
+			}
+		}
+	}
+
+	return out
+}
+
 func NormalizeTargetDate(targetDate time.Time) time.Time {
 	nearestWeekStartOffset := targetDate.Second() + targetDate.Minute()*60 + targetDate.Hour()*60*60
 	return time.Unix(targetDate.Unix()-int64(nearestWeekStartOffset), 0)
@@ -410,13 +478,18 @@ type IconItemsPayload struct {
 	Ids  blizzard.ItemIds
 }
 
-func NewCleanupPricelistPayloads(regionRealmMap map[blizzard.RegionName]Realms) CleanupPricelistPayloads {
+func NewCleanupPricelistPayloads(
+	regionRealmMap map[blizzard.RegionName]Realms,
+	retention RetentionConfig,
+) CleanupPricelistPayloads {
 	out := CleanupPricelistPayloads{}
 	for regionName, realms := range regionRealmMap {
+		expiredBefore := retention.Limit(regionName, retentionkinds.PricelistHistories).Unix()
 		for _, realm := range realms {
 			out = append(out, CleanupPricelistPayload{
-				RegionName: string(regionName),
-				RealmSlug:  string(realm.Slug),
+				RegionName:    string(regionName),
+				RealmSlug:     string(realm.Slug),
+				ExpiredBefore: expiredBefore,
 			})
 		}
 	}
@@ -438,6 +511,18 @@ func NewCleanupPricelistPayload(data string) (CleanupPricelistPayload, error) {
 type CleanupPricelistPayload struct {
 	RegionName string `json:"region_name"`
 	RealmSlug  string `json:"realm_slug"`
+
+	// ExpiredBefore - unix timestamp of the retention limit, where zero falls back to the default limit
+	ExpiredBefore int64 `json:"expired_before,omitempty"`
+}
+
+// Limit - the retention limit of the payload, falling back to the default retention of the region
+func (p CleanupPricelistPayload) Limit() time.Time {
+	if p.ExpiredBefore == 0 {
+		return RetentionConfig{}.Limit(blizzard.RegionName(p.RegionName), retentionkinds.PricelistHistories)
+	}
+
+	return time.Unix(p.ExpiredBefore, 0)
 }
 
 func (p CleanupPricelistPayload) EncodeForDelivery() (string, error) {
@@ -0,0 +1,93 @@
+package sotah
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/messenger/roles"
+)
+
+// APIKeyID - the part of an issued api key ahead of its secret, by which the key is looked up
+type APIKeyID string
+
+func NewAPIKey(data []byte) (APIKey, error) {
+	key := &APIKey{}
+	if err := json.Unmarshal(data, key); err != nil {
+		return APIKey{}, err
+	}
+
+	return *key, nil
+}
+
+/*
+APIKey - issued to a third party, where only a hash of its secret is kept, and where a rate limit, burst or daily quota
+of zero leaves its requests unbounded by that
+*/
+type APIKey struct {
+	ID         APIKeyID   `json:"id"`
+	Name       string     `json:"name"`
+	Role       roles.Role `json:"role"`
+	SecretHash string     `json:"secret_hash,omitempty"`
+
+	// requests a second, and how many may be made at once after going without
+	RateLimit float64 `json:"rate_limit"`
+	Burst     int     `json:"burst"`
+
+	// requests a day, where days run in utc
+	DailyQuota int `json:"daily_quota"`
+
+	CreatedAt UnixTimestamp `json:"created_at"`
+	RevokedAt UnixTimestamp `json:"revoked_at,omitempty"`
+}
+
+func (key APIKey) EncodeForPersistence() ([]byte, error) {
+	return json.Marshal(key)
+}
+
+func (key APIKey) IsRevoked() bool {
+	return key.RevokedAt > 0
+}
+
+// Redacted leaves out the secret hash, for keys that are sent anywhere
+func (key APIKey) Redacted() APIKey {
+	key.SecretHash = ""
+
+	return key
+}
+
+type APIKeys []APIKey
+
+// APIKeyUsageDay - the utc day that usage is counted towards, as yyyy-mm-dd
+func APIKeyUsageDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// SubjectUsage - counts of requests by subject
+type SubjectUsage map[string]int
+
+func (usage SubjectUsage) Total() int {
+	out := 0
+	for _, count := range usage {
+		out += count
+	}
+
+	return out
+}
+
+// APIKeyUsage - counts of the requests of each api key by subject, over a day
+type APIKeyUsage struct {
+	Day    string                    `json:"day"`
+	Counts map[APIKeyID]SubjectUsage `json:"counts"`
+}
+
+func (usage APIKeyUsage) Add(id APIKeyID, subject string, count int) {
+	if _, ok := usage.Counts[id]; !ok {
+		usage.Counts[id] = SubjectUsage{}
+	}
+
+	usage.Counts[id][subject] += count
+}
+
+func (usage APIKeyUsage) IsEmpty() bool {
+	return len(usage.Counts) == 0
+}
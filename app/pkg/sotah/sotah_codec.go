@@ -0,0 +1,373 @@
+package sotah
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+)
+
+/*
+binary encoding: a magic header followed by the format version and then varint-packed values, where any data
+without the header is treated as the legacy gzipped-json encoding so that both can be read side by side
+*/
+var binaryCodecMagic = []byte{0xff, 's', 'o', 't'}
+
+const binaryCodecVersion byte = 1
+
+// IsBinaryEncoded - checks whether the data carries the binary codec header
+func IsBinaryEncoded(data []byte) bool {
+	return bytes.HasPrefix(data, binaryCodecMagic)
+}
+
+// encoder
+func newBinaryEncoder() *binaryEncoder {
+	e := &binaryEncoder{}
+	e.buf.Write(binaryCodecMagic)
+	e.buf.WriteByte(binaryCodecVersion)
+
+	return e
+}
+
+type binaryEncoder struct {
+	buf     bytes.Buffer
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (e *binaryEncoder) Bytes() []byte {
+	return e.buf.Bytes()
+}
+
+func (e *binaryEncoder) putUvarint(v uint64) {
+	n := binary.PutUvarint(e.scratch[:], v)
+	e.buf.Write(e.scratch[:n])
+}
+
+func (e *binaryEncoder) putVarint(v int64) {
+	n := binary.PutVarint(e.scratch[:], v)
+	e.buf.Write(e.scratch[:n])
+}
+
+func (e *binaryEncoder) putFloat64(v float64) {
+	binary.LittleEndian.PutUint64(e.scratch[:8], math.Float64bits(v))
+	e.buf.Write(e.scratch[:8])
+}
+
+func (e *binaryEncoder) putFloat32(v float32) {
+	binary.LittleEndian.PutUint32(e.scratch[:4], math.Float32bits(v))
+	e.buf.Write(e.scratch[:4])
+}
+
+func (e *binaryEncoder) putString(v string) {
+	e.putUvarint(uint64(len(v)))
+	e.buf.WriteString(v)
+}
+
+// decoder, which records the first error and returns zero values from then on
+func newBinaryDecoder(data []byte) (*binaryDecoder, error) {
+	if !IsBinaryEncoded(data) {
+		return nil, errors.New("data is not binary encoded")
+	}
+
+	headerLength := len(binaryCodecMagic) + 1
+	if len(data) < headerLength {
+		return nil, errors.New("binary encoded data is missing its version")
+	}
+
+	if version := data[len(binaryCodecMagic)]; version != binaryCodecVersion {
+		return nil, fmt.Errorf("unsupported binary encoding version: %d", version)
+	}
+
+	return &binaryDecoder{data: data, pos: headerLength}, nil
+}
+
+type binaryDecoder struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+var errBinaryTruncated = errors.New("binary encoded data is truncated")
+
+func (d *binaryDecoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *binaryDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(d.data[d.pos:])
+	if n <= 0 {
+		d.fail(errBinaryTruncated)
+
+		return 0
+	}
+	d.pos += n
+
+	return v
+}
+
+func (d *binaryDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(d.data[d.pos:])
+	if n <= 0 {
+		d.fail(errBinaryTruncated)
+
+		return 0
+	}
+	d.pos += n
+
+	return v
+}
+
+func (d *binaryDecoder) next(length int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if length < 0 || d.pos+length > len(d.data) {
+		d.fail(errBinaryTruncated)
+
+		return nil
+	}
+
+	out := d.data[d.pos : d.pos+length]
+	d.pos += length
+
+	return out
+}
+
+// length - reads a collection length, refusing lengths that could not fit in the remaining data
+func (d *binaryDecoder) length() int {
+	v := d.uvarint()
+	if v > uint64(len(d.data)-d.pos) {
+		d.fail(errBinaryTruncated)
+
+		return 0
+	}
+
+	return int(v)
+}
+
+func (d *binaryDecoder) float64() float64 {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
+
+func (d *binaryDecoder) float32() float32 {
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+
+	return math.Float32frombits(binary.LittleEndian.Uint32(b))
+}
+
+func (d *binaryDecoder) string() string {
+	return string(d.next(d.length()))
+}
+
+// prices
+func (p Prices) encodeBinary(e *binaryEncoder) {
+	e.putFloat64(p.MinBuyoutPer)
+	e.putFloat64(p.MaxBuyoutPer)
+	e.putFloat64(p.AverageBuyoutPer)
+	e.putFloat64(p.MedianBuyoutPer)
+	e.putVarint(p.Volume)
+}
+
+func decodeBinaryPrices(d *binaryDecoder) Prices {
+	return Prices{
+		MinBuyoutPer:     d.float64(),
+		MaxBuyoutPer:     d.float64(),
+		AverageBuyoutPer: d.float64(),
+		MedianBuyoutPer:  d.float64(),
+		Volume:           d.varint(),
+	}
+}
+
+func (p Prices) EncodeBinary() []byte {
+	e := newBinaryEncoder()
+	p.encodeBinary(e)
+
+	return e.Bytes()
+}
+
+func newPricesFromBinary(data []byte) (Prices, error) {
+	d, err := newBinaryDecoder(data)
+	if err != nil {
+		return Prices{}, err
+	}
+
+	out := decodeBinaryPrices(d)
+	if d.err != nil {
+		return Prices{}, d.err
+	}
+
+	return out, nil
+}
+
+// price-history, with timestamps delta-encoded in ascending order
+func (pHistory PriceHistory) encodeBinary(e *binaryEncoder) {
+	timestamps := make([]UnixTimestamp, 0, len(pHistory))
+	for targetTimestamp := range pHistory {
+		timestamps = append(timestamps, targetTimestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
+	})
+
+	e.putUvarint(uint64(len(timestamps)))
+	previous := UnixTimestamp(0)
+	for _, targetTimestamp := range timestamps {
+		e.putVarint(int64(targetTimestamp - previous))
+		pHistory[targetTimestamp].encodeBinary(e)
+		previous = targetTimestamp
+	}
+}
+
+func decodeBinaryPriceHistory(d *binaryDecoder) PriceHistory {
+	count := d.length()
+	out := make(PriceHistory, count)
+	previous := UnixTimestamp(0)
+	for i := 0; i < count && d.err == nil; i++ {
+		targetTimestamp := previous + UnixTimestamp(d.varint())
+		out[targetTimestamp] = decodeBinaryPrices(d)
+		previous = targetTimestamp
+	}
+
+	return out
+}
+
+func (pHistory PriceHistory) EncodeBinary() []byte {
+	e := newBinaryEncoder()
+	pHistory.encodeBinary(e)
+
+	return e.Bytes()
+}
+
+func newPriceHistoryFromBinary(data []byte) (PriceHistory, error) {
+	d, err := newBinaryDecoder(data)
+	if err != nil {
+		return PriceHistory{}, err
+	}
+
+	out := decodeBinaryPriceHistory(d)
+	if d.err != nil {
+		return PriceHistory{}, d.err
+	}
+
+	return out, nil
+}
+
+// item-price-histories
+func (ipHistories ItemPriceHistories) EncodeBinary() []byte {
+	itemIds := make([]blizzard.ItemID, 0, len(ipHistories))
+	for itemId := range ipHistories {
+		itemIds = append(itemIds, itemId)
+	}
+	sort.Slice(itemIds, func(i, j int) bool {
+		return itemIds[i] < itemIds[j]
+	})
+
+	e := newBinaryEncoder()
+	e.putUvarint(uint64(len(itemIds)))
+	for _, itemId := range itemIds {
+		e.putVarint(int64(itemId))
+		ipHistories[itemId].encodeBinary(e)
+	}
+
+	return e.Bytes()
+}
+
+func newItemPriceHistoriesFromBinary(data []byte) (ItemPriceHistories, error) {
+	d, err := newBinaryDecoder(data)
+	if err != nil {
+		return ItemPriceHistories{}, err
+	}
+
+	count := d.length()
+	out := make(ItemPriceHistories, count)
+	for i := 0; i < count && d.err == nil; i++ {
+		itemId := blizzard.ItemID(d.varint())
+		out[itemId] = decodeBinaryPriceHistory(d)
+	}
+	if d.err != nil {
+		return ItemPriceHistories{}, d.err
+	}
+
+	return out, nil
+}
+
+// mini-auction-list
+func (maList MiniAuctionList) EncodeBinary() []byte {
+	e := newBinaryEncoder()
+	e.putUvarint(uint64(len(maList)))
+	for _, mAuction := range maList {
+		e.putVarint(int64(mAuction.ItemID))
+		e.putString(string(mAuction.Owner))
+		e.putString(mAuction.OwnerRealm)
+		e.putVarint(mAuction.Bid)
+		e.putVarint(mAuction.Buyout)
+		e.putFloat32(mAuction.BuyoutPer)
+		e.putVarint(mAuction.Quantity)
+		e.putString(mAuction.TimeLeft)
+
+		e.putUvarint(uint64(len(mAuction.AucList)))
+		for _, auc := range mAuction.AucList {
+			e.putVarint(auc)
+		}
+	}
+
+	return e.Bytes()
+}
+
+func newMiniAuctionListFromBinary(data []byte) (MiniAuctionList, error) {
+	d, err := newBinaryDecoder(data)
+	if err != nil {
+		return MiniAuctionList{}, err
+	}
+
+	count := d.length()
+	out := make(MiniAuctionList, 0, count)
+	for i := 0; i < count && d.err == nil; i++ {
+		mAuction := miniAuction{
+			ItemID:     blizzard.ItemID(d.varint()),
+			Owner:      OwnerName(d.string()),
+			OwnerRealm: d.string(),
+			Bid:        d.varint(),
+			Buyout:     d.varint(),
+			BuyoutPer:  d.float32(),
+			Quantity:   d.varint(),
+			TimeLeft:   d.string(),
+		}
+
+		aucCount := d.length()
+		mAuction.AucList = make([]int64, 0, aucCount)
+		for j := 0; j < aucCount && d.err == nil; j++ {
+			mAuction.AucList = append(mAuction.AucList, d.varint())
+		}
+
+		out = append(out, mAuction)
+	}
+	if d.err != nil {
+		return MiniAuctionList{}, d.err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,99 @@
+package sotah
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPriceHistory() PriceHistory {
+	return PriceHistory{
+		UnixTimestamp(1500000000): Prices{
+			MinBuyoutPer:     1.5,
+			MaxBuyoutPer:     9,
+			AverageBuyoutPer: 4.25,
+			MedianBuyoutPer:  4,
+			Volume:           12,
+		},
+		UnixTimestamp(1500003600): Prices{
+			MinBuyoutPer:     2,
+			MaxBuyoutPer:     8,
+			AverageBuyoutPer: 5,
+			MedianBuyoutPer:  5,
+			Volume:           7,
+		},
+	}
+}
+
+func TestPriceHistoryCodecs(t *testing.T) {
+	pHistory := newTestPriceHistory()
+
+	binaryEncoded := pHistory.EncodeBinary()
+	assert.True(t, IsBinaryEncoded(binaryEncoded))
+	fromBinary, err := NewPriceHistoryFromBytes(binaryEncoded)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, pHistory, fromBinary)
+
+	legacyEncoded, err := pHistory.EncodeForPersistence()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.False(t, IsBinaryEncoded(legacyEncoded))
+	fromLegacy, err := NewPriceHistoryFromBytes(legacyEncoded)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, pHistory, fromLegacy)
+
+	_, err = NewPriceHistoryFromBytes(binaryEncoded[:len(binaryEncoded)-3])
+	assert.NotNil(t, err)
+}
+
+func TestItemPriceHistoriesCodecs(t *testing.T) {
+	ipHistories := ItemPriceHistories{
+		blizzard.ItemID(1):  newTestPriceHistory(),
+		blizzard.ItemID(25): PriceHistory{},
+	}
+
+	fromBinary, err := NewItemPriceHistoriesFromMinimized(bytes.NewReader(ipHistories.EncodeBinary()))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, ipHistories, fromBinary)
+}
+
+func TestMiniAuctionListCodecs(t *testing.T) {
+	maList := MiniAuctionList{
+		{
+			ItemID:     blizzard.ItemID(2589),
+			Owner:      OwnerName("Lyrica"),
+			OwnerRealm: "Earthen Ring",
+			Bid:        100,
+			Buyout:     200,
+			BuyoutPer:  10,
+			Quantity:   20,
+			TimeLeft:   "LONG",
+			AucList:    []int64{1, 2, 3},
+		},
+	}
+
+	fromBinary, err := NewMiniAuctionListFromEncoded(maList.EncodeBinary())
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, maList, fromBinary)
+
+	legacyEncoded, err := maList.EncodeForDatabase()
+	if !assert.Nil(t, err) {
+		return
+	}
+	fromLegacy, err := NewMiniAuctionListFromEncoded(legacyEncoded)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, maList, fromLegacy)
+}
@@ -37,6 +37,27 @@ type Config struct {
 	Expansions    []Expansion                                  `json:"expansions"`
 	Professions   []Profession                                 `json:"professions"`
 	ItemBlacklist []blizzard.ItemID                            `json:"item_blacklist"`
+	Recipes       Recipes                                      `json:"recipes"`
+	RecipesFile   string                                       `json:"recipes_file"`
+	Retention     RetentionConfig                              `json:"retention"`
+	Snapshots     SnapshotConfig                               `json:"snapshots"`
+}
+
+// ResolveRecipes - merges the inline recipes with those from the optional recipes file
+func (c Config) ResolveRecipes() (Recipes, error) {
+	out := Recipes{}
+	out = append(out, c.Recipes...)
+
+	if len(c.RecipesFile) == 0 {
+		return out, nil
+	}
+
+	fileRecipes, err := NewRecipesFromFilepath(c.RecipesFile)
+	if err != nil {
+		return Recipes{}, err
+	}
+
+	return append(out, fileRecipes...), nil
 }
 
 func (c Config) FilterInRegions(regs RegionList) RegionList {
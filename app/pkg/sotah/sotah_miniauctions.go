@@ -52,6 +52,15 @@ func NewMiniAuctionListFromMiniAuctions(ma MiniAuctions) MiniAuctionList {
 	return out
 }
 
+// NewMiniAuctionListFromEncoded - decodes either the binary or the legacy gzipped encoding
+func NewMiniAuctionListFromEncoded(body []byte) (MiniAuctionList, error) {
+	if IsBinaryEncoded(body) {
+		return newMiniAuctionListFromBinary(body)
+	}
+
+	return NewMiniAuctionListFromGzipped(body)
+}
+
 func NewMiniAuctionListFromGzipped(body []byte) (MiniAuctionList, error) {
 	gzipDecodedData, err := util.GzipDecode(body)
 	if err != nil {
@@ -1,6 +1,7 @@
 package sotah
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/base64"
 	"encoding/csv"
@@ -101,6 +102,10 @@ func (iPrices ItemPrices) ItemIds() []blizzard.ItemID {
 
 // prices
 func NewPricesFromBytes(data []byte) (Prices, error) {
+	if IsBinaryEncoded(data) {
+		return newPricesFromBinary(data)
+	}
+
 	gzipDecoded, err := util.GzipDecode(data)
 	if err != nil {
 		return Prices{}, err
@@ -138,9 +143,19 @@ func (p Prices) EncodeForPersistence() ([]byte, error) {
 
 // item-price-histories
 func NewItemPriceHistoriesFromMinimized(reader io.Reader) (ItemPriceHistories, error) {
+	bufferedReader := bufio.NewReader(reader)
+	if header, _ := bufferedReader.Peek(len(binaryCodecMagic)); IsBinaryEncoded(header) {
+		data, err := ioutil.ReadAll(bufferedReader)
+		if err != nil {
+			return ItemPriceHistories{}, err
+		}
+
+		return newItemPriceHistoriesFromBinary(data)
+	}
+
 	out := ItemPriceHistories{}
 
-	r := csv.NewReader(reader)
+	r := csv.NewReader(bufferedReader)
 	for {
 		record, err := r.Read()
 		if err == io.EOF {
@@ -179,6 +194,19 @@ func NewItemPriceHistoriesFromMinimized(reader io.Reader) (ItemPriceHistories, e
 
 type ItemPriceHistories map[blizzard.ItemID]PriceHistory
 
+// Merge - sets the item-prices of a single dump into each item's history
+func (ipHistories ItemPriceHistories) Merge(targetTimestamp UnixTimestamp, iPrices ItemPrices) {
+	for itemId, pricesValue := range iPrices {
+		pHistory, ok := ipHistories[itemId]
+		if !ok {
+			pHistory = PriceHistory{}
+		}
+		pHistory[targetTimestamp] = pricesValue
+
+		ipHistories[itemId] = pHistory
+	}
+}
+
 type EncodeForPersistenceInJob struct {
 	itemId       blizzard.ItemID
 	priceHistory PriceHistory
@@ -269,6 +297,10 @@ func (ipHistories ItemPriceHistories) EncodeForPersistence() ([]byte, error) {
 
 // price-history
 func NewPriceHistoryFromBytes(data []byte) (PriceHistory, error) {
+	if IsBinaryEncoded(data) {
+		return newPriceHistoryFromBinary(data)
+	}
+
 	gzipDecoded, err := util.GzipDecode(data)
 	if err != nil {
 		return PriceHistory{}, err
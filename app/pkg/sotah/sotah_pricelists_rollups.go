@@ -0,0 +1,230 @@
+package sotah
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/util"
+)
+
+// price-rollups
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := sort.Float64Slice(append([]float64{}, values...))
+	sorted.Sort()
+	if len(sorted)%2 == 0 {
+		return (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	}
+
+	return sorted[(len(sorted)-1)/2]
+}
+
+// NewPriceRollup aggregates a time-ordered set of samples, with the ohlc fields tracking the median buyout
+func NewPriceRollup(samples []Prices) PriceRollup {
+	out := PriceRollup{Samples: len(samples)}
+	if len(samples) == 0 {
+		return out
+	}
+
+	medians := []float64{}
+	averageTotal := float64(0)
+	averageCount := 0
+	volumeTotal := int64(0)
+	for _, p := range samples {
+		volumeTotal += p.Volume
+
+		if p.MinBuyoutPer > 0 && (out.MinBuyoutPer == 0 || p.MinBuyoutPer < out.MinBuyoutPer) {
+			out.MinBuyoutPer = p.MinBuyoutPer
+		}
+		if p.MaxBuyoutPer > out.MaxBuyoutPer {
+			out.MaxBuyoutPer = p.MaxBuyoutPer
+		}
+		if p.AverageBuyoutPer > 0 {
+			averageTotal += p.AverageBuyoutPer
+			averageCount++
+		}
+
+		if p.MedianBuyoutPer == 0 {
+			continue
+		}
+
+		if len(medians) == 0 {
+			out.Open = p.MedianBuyoutPer
+		}
+		out.Close = p.MedianBuyoutPer
+		if p.MedianBuyoutPer > out.High {
+			out.High = p.MedianBuyoutPer
+		}
+		if out.Low == 0 || p.MedianBuyoutPer < out.Low {
+			out.Low = p.MedianBuyoutPer
+		}
+
+		medians = append(medians, p.MedianBuyoutPer)
+	}
+
+	if averageCount > 0 {
+		out.AverageBuyoutPer = averageTotal / float64(averageCount)
+	}
+	out.MedianBuyoutPer = median(medians)
+	out.Volume = int64(math.Round(float64(volumeTotal) / float64(len(samples))))
+
+	return out
+}
+
+// MergePriceRollups combines time-ordered rollups into a coarser one, weighting averages by sample count
+func MergePriceRollups(rollups []PriceRollup) PriceRollup {
+	out := PriceRollup{}
+
+	medians := []float64{}
+	averageTotal := float64(0)
+	volumeTotal := float64(0)
+	for _, r := range rollups {
+		if r.Samples == 0 {
+			continue
+		}
+
+		out.Samples += r.Samples
+		averageTotal += r.AverageBuyoutPer * float64(r.Samples)
+		volumeTotal += float64(r.Volume) * float64(r.Samples)
+
+		if r.MinBuyoutPer > 0 && (out.MinBuyoutPer == 0 || r.MinBuyoutPer < out.MinBuyoutPer) {
+			out.MinBuyoutPer = r.MinBuyoutPer
+		}
+		if r.MaxBuyoutPer > out.MaxBuyoutPer {
+			out.MaxBuyoutPer = r.MaxBuyoutPer
+		}
+
+		if r.MedianBuyoutPer == 0 {
+			continue
+		}
+
+		if len(medians) == 0 {
+			out.Open = r.Open
+		}
+		out.Close = r.Close
+		if r.High > out.High {
+			out.High = r.High
+		}
+		if out.Low == 0 || (r.Low > 0 && r.Low < out.Low) {
+			out.Low = r.Low
+		}
+
+		medians = append(medians, r.MedianBuyoutPer)
+	}
+
+	if out.Samples == 0 {
+		return out
+	}
+
+	out.AverageBuyoutPer = averageTotal / float64(out.Samples)
+	out.MedianBuyoutPer = median(medians)
+	out.Volume = int64(math.Round(volumeTotal / float64(out.Samples)))
+
+	return out
+}
+
+type PriceRollup struct {
+	Prices
+
+	Open    float64 `json:"open"`
+	High    float64 `json:"high"`
+	Low     float64 `json:"low"`
+	Close   float64 `json:"close"`
+	Samples int     `json:"samples"`
+}
+
+// price-rollup-history
+func NewPriceRollupHistoryFromBytes(data []byte) (PriceRollupHistory, error) {
+	gzipDecoded, err := util.GzipDecode(data)
+	if err != nil {
+		return PriceRollupHistory{}, err
+	}
+
+	out := PriceRollupHistory{}
+	if err := json.Unmarshal(gzipDecoded, &out); err != nil {
+		return PriceRollupHistory{}, err
+	}
+
+	return out, nil
+}
+
+type PriceRollupHistory map[UnixTimestamp]PriceRollup
+
+func (prHistory PriceRollupHistory) EncodeForPersistence() ([]byte, error) {
+	jsonEncoded, err := json.Marshal(prHistory)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	gzipEncoded, err := util.GzipEncode(jsonEncoded)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return gzipEncoded, nil
+}
+
+func (prHistory PriceRollupHistory) ToPriceHistory() PriceHistory {
+	out := PriceHistory{}
+	for targetTimestamp, rollup := range prHistory {
+		out[targetTimestamp] = rollup.Prices
+	}
+
+	return out
+}
+
+// Between - gathers the rollups within the given timestamps in time order
+func (prHistory PriceRollupHistory) Between(lower UnixTimestamp, upper UnixTimestamp) []PriceRollup {
+	timestamps := []UnixTimestamp{}
+	for targetTimestamp := range prHistory {
+		if targetTimestamp < lower || targetTimestamp > upper {
+			continue
+		}
+
+		timestamps = append(timestamps, targetTimestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
+	})
+
+	out := make([]PriceRollup, len(timestamps))
+	for i, targetTimestamp := range timestamps {
+		out[i] = prHistory[targetTimestamp]
+	}
+
+	return out
+}
+
+// Samples - gathers the history in time order
+func (pHistory PriceHistory) Samples() []Prices {
+	timestamps := []UnixTimestamp{}
+	for targetTimestamp := range pHistory {
+		timestamps = append(timestamps, targetTimestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
+	})
+
+	out := make([]Prices, len(timestamps))
+	for i, targetTimestamp := range timestamps {
+		out[i] = pHistory[targetTimestamp]
+	}
+
+	return out
+}
+
+type ItemPriceRollupHistories map[blizzard.ItemID]PriceRollupHistory
+
+// NormalizeTargetWeek - normalizes the target date to the start of its week (monday)
+func NormalizeTargetWeek(targetDate time.Time) time.Time {
+	normalizedTargetDate := NormalizeTargetDate(targetDate)
+	daysSinceMonday := (int(normalizedTargetDate.Weekday()) + 6) % 7
+
+	return normalizedTargetDate.AddDate(0, 0, -daysSinceMonday)
+}
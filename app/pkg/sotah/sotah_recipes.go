@@ -0,0 +1,210 @@
+package sotah
+
+import (
+	"encoding/json"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/util"
+)
+
+type RecipeID int64
+
+type RecipeReagent struct {
+	ItemID   blizzard.ItemID `json:"item_id"`
+	Quantity int64           `json:"quantity"`
+}
+
+func NewRecipe(data []byte) (Recipe, error) {
+	r := &Recipe{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return Recipe{}, err
+	}
+
+	return *r, nil
+}
+
+type Recipe struct {
+	ID         RecipeID        `json:"id"`
+	Name       string          `json:"name"`
+	Profession string          `json:"profession"`
+	Reagents   []RecipeReagent `json:"reagents"`
+	ItemID     blizzard.ItemID `json:"item_id"`
+	Yield      int64           `json:"yield"`
+}
+
+func (r Recipe) EncodeForPersistence() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+func (r Recipe) ItemIds() []blizzard.ItemID {
+	out := []blizzard.ItemID{r.ItemID}
+	for _, reagent := range r.Reagents {
+		out = append(out, reagent.ItemID)
+	}
+
+	return out
+}
+
+func (r Recipe) resolveYield() int64 {
+	if r.Yield <= 0 {
+		return 1
+	}
+
+	return r.Yield
+}
+
+// NewRecipesFromFilepath - reads a json list of recipes, in the same shape as the config recipes list
+func NewRecipesFromFilepath(relativePath string) (Recipes, error) {
+	logging.WithField("path", relativePath).Info("Reading recipes")
+
+	body, err := util.ReadFile(relativePath)
+	if err != nil {
+		return Recipes{}, err
+	}
+
+	var out Recipes
+	if err := json.Unmarshal(body, &out); err != nil {
+		return Recipes{}, err
+	}
+
+	return out, nil
+}
+
+type Recipes []Recipe
+
+func (recipes Recipes) ItemIds() []blizzard.ItemID {
+	seen := map[blizzard.ItemID]struct{}{}
+	out := []blizzard.ItemID{}
+	for _, r := range recipes {
+		for _, id := range r.ItemIds() {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+
+			seen[id] = struct{}{}
+			out = append(out, id)
+		}
+	}
+
+	return out
+}
+
+func (recipes Recipes) FilterInProfession(profession string) Recipes {
+	if len(profession) == 0 {
+		return recipes
+	}
+
+	out := Recipes{}
+	for _, r := range recipes {
+		if r.Profession != profession {
+			continue
+		}
+
+		out = append(out, r)
+	}
+
+	return out
+}
+
+func (recipes Recipes) ToProfessionRecipes() ProfessionRecipes {
+	out := ProfessionRecipes{}
+	for _, r := range recipes {
+		out[r.Profession] = append(out[r.Profession], r)
+	}
+
+	return out
+}
+
+type ProfessionRecipes map[string]Recipes
+
+// recipe profits
+func newRecipeProfit(r Recipe, iPrices ItemPrices) RecipeProfit {
+	out := RecipeProfit{RecipeID: r.ID}
+
+	for _, reagent := range r.Reagents {
+		p, ok := iPrices[reagent.ItemID]
+		if !ok || p.MinBuyoutPer == 0 {
+			out.MissingReagents = append(out.MissingReagents, reagent.ItemID)
+
+			continue
+		}
+
+		out.ReagentCost += p.MinBuyoutPer * float64(reagent.Quantity)
+	}
+
+	p, ok := iPrices[r.ItemID]
+	if !ok || p.MinBuyoutPer == 0 {
+		out.MissingProduct = true
+	} else {
+		out.ProductPrice = p.MinBuyoutPer * float64(r.resolveYield())
+	}
+
+	if len(out.MissingReagents) > 0 || out.MissingProduct {
+		return out
+	}
+
+	out.Profit = out.ProductPrice - out.ReagentCost
+	if out.ReagentCost > 0 {
+		out.Margin = out.Profit / out.ReagentCost
+	}
+
+	return out
+}
+
+type RecipeProfit struct {
+	RecipeID        RecipeID          `json:"recipe_id"`
+	ReagentCost     float64           `json:"reagent_cost"`
+	ProductPrice    float64           `json:"product_price"`
+	Profit          float64           `json:"profit"`
+	Margin          float64           `json:"margin"`
+	MissingReagents []blizzard.ItemID `json:"missing_reagents"`
+	MissingProduct  bool              `json:"missing_product"`
+}
+
+// NewRecipeProfits - prices each recipe at the current min-buyout of its reagents and product
+func NewRecipeProfits(recipes Recipes, iPrices ItemPrices) RecipeProfits {
+	out := RecipeProfits{}
+	for _, r := range recipes {
+		out[r.ID] = newRecipeProfit(r, iPrices)
+	}
+
+	return out
+}
+
+type RecipeProfits map[RecipeID]RecipeProfit
+
+// NewRecipeProfitHistory - replays the recipe against every snapshot where the product was listed
+func NewRecipeProfitHistory(r Recipe, ipHistories ItemPriceHistories) RecipeProfitHistory {
+	out := RecipeProfitHistory{}
+
+	productHistory, ok := ipHistories[r.ItemID]
+	if !ok {
+		return out
+	}
+
+	for targetTimestamp := range productHistory {
+		iPrices := ItemPrices{}
+		for _, id := range r.ItemIds() {
+			pricesValue, ok := ipHistories[id][targetTimestamp]
+			if !ok {
+				continue
+			}
+
+			iPrices[id] = pricesValue
+		}
+
+		profit := newRecipeProfit(r, iPrices)
+		if len(profit.MissingReagents) > 0 || profit.MissingProduct {
+			continue
+		}
+
+		out[targetTimestamp] = profit
+	}
+
+	return out
+}
+
+type RecipeProfitHistory map[UnixTimestamp]RecipeProfit
+
+type RecipeProfitHistories map[RecipeID]RecipeProfitHistory
@@ -0,0 +1,73 @@
+package sotah
+
+import (
+	"testing"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRecipe() Recipe {
+	return Recipe{
+		ID:       RecipeID(1),
+		ItemID:   blizzard.ItemID(10),
+		Yield:    2,
+		Reagents: []RecipeReagent{{ItemID: blizzard.ItemID(20), Quantity: 3}, {ItemID: blizzard.ItemID(30), Quantity: 1}},
+	}
+}
+
+func TestNewRecipeProfits(t *testing.T) {
+	recipe := newTestRecipe()
+
+	profits := NewRecipeProfits(Recipes{recipe}, ItemPrices{
+		blizzard.ItemID(10): Prices{MinBuyoutPer: 50},
+		blizzard.ItemID(20): Prices{MinBuyoutPer: 10},
+		blizzard.ItemID(30): Prices{MinBuyoutPer: 20},
+	})
+
+	assert.Equal(t, RecipeProfit{
+		RecipeID:     recipe.ID,
+		ReagentCost:  50,
+		ProductPrice: 100,
+		Profit:       50,
+		Margin:       1,
+	}, profits[recipe.ID])
+}
+
+func TestNewRecipeProfitsMissing(t *testing.T) {
+	recipe := newTestRecipe()
+
+	profit := NewRecipeProfits(Recipes{recipe}, ItemPrices{
+		blizzard.ItemID(20): Prices{MinBuyoutPer: 10},
+		// reagents without a buyout are missing
+		blizzard.ItemID(30): Prices{},
+	})[recipe.ID]
+
+	assert.True(t, profit.MissingProduct)
+	assert.Equal(t, []blizzard.ItemID{blizzard.ItemID(30)}, profit.MissingReagents)
+	assert.Equal(t, float64(0), profit.Profit)
+}
+
+func TestNewRecipeProfitHistory(t *testing.T) {
+	recipe := newTestRecipe()
+
+	history := NewRecipeProfitHistory(recipe, ItemPriceHistories{
+		blizzard.ItemID(10): PriceHistory{
+			UnixTimestamp(1): Prices{MinBuyoutPer: 50},
+			UnixTimestamp(2): Prices{MinBuyoutPer: 20},
+		},
+		blizzard.ItemID(20): PriceHistory{
+			UnixTimestamp(1): Prices{MinBuyoutPer: 10},
+			UnixTimestamp(2): Prices{MinBuyoutPer: 10},
+		},
+		// the second snapshot is skipped for missing a reagent
+		blizzard.ItemID(30): PriceHistory{
+			UnixTimestamp(1): Prices{MinBuyoutPer: 20},
+		},
+	})
+
+	if !assert.Len(t, history, 1) {
+		return
+	}
+	assert.Equal(t, float64(50), history[UnixTimestamp(1)].Profit)
+}
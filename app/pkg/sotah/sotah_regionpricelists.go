@@ -0,0 +1,150 @@
+package sotah
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/util"
+)
+
+// region-prices
+func NewRegionPrices(realmPrices []Prices) RegionPrices {
+	out := RegionPrices{}
+
+	listed := []Prices{}
+	for _, p := range realmPrices {
+		if p.Volume == 0 && p.MedianBuyoutPer == 0 {
+			continue
+		}
+
+		out.RealmCount++
+		out.Volume += p.Volume
+
+		if p.MedianBuyoutPer == 0 {
+			continue
+		}
+
+		listed = append(listed, p)
+	}
+
+	if len(listed) == 0 {
+		return out
+	}
+
+	// sorting realm medians and walking them until half of the region volume is covered
+	sort.Slice(listed, func(i, j int) bool {
+		return listed[i].MedianBuyoutPer < listed[j].MedianBuyoutPer
+	})
+
+	totalWeight := int64(0)
+	for _, p := range listed {
+		totalWeight += p.Volume
+	}
+
+	// falling back to an unweighted median when no volume was recorded
+	if totalWeight == 0 {
+		out.MedianBuyoutPer = listed[(len(listed)-1)/2].MedianBuyoutPer
+
+		return out
+	}
+
+	cumulativeWeight := int64(0)
+	for _, p := range listed {
+		cumulativeWeight += p.Volume
+		if cumulativeWeight*2 >= totalWeight {
+			out.MedianBuyoutPer = p.MedianBuyoutPer
+
+			break
+		}
+	}
+
+	return out
+}
+
+type RegionPrices struct {
+	MedianBuyoutPer float64 `json:"median_buyout_per"`
+	RealmCount      int     `json:"realm_count"`
+	Volume          int64   `json:"volume"`
+}
+
+// region-price-history
+func NewRegionPriceHistoryFromBytes(data []byte) (RegionPriceHistory, error) {
+	gzipDecoded, err := util.GzipDecode(data)
+	if err != nil {
+		return RegionPriceHistory{}, err
+	}
+
+	out := RegionPriceHistory{}
+	if err := json.Unmarshal(gzipDecoded, &out); err != nil {
+		return RegionPriceHistory{}, err
+	}
+
+	return out, nil
+}
+
+type RegionPriceHistory map[UnixTimestamp]RegionPrices
+
+func (rpHistory RegionPriceHistory) EncodeForPersistence() ([]byte, error) {
+	jsonEncoded, err := json.Marshal(rpHistory)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	gzipEncoded, err := util.GzipEncode(jsonEncoded)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return gzipEncoded, nil
+}
+
+// item-region-price-histories
+const regionPriceHistoryResolution = 60 * 60
+
+// NewItemRegionPriceHistories aggregates per-realm histories into hourly region-wide points, keeping only the
+// latest realm sample in each hour so that realms with frequent dumps are not over-represented
+func NewItemRegionPriceHistories(realmHistories []ItemPriceHistories) ItemRegionPriceHistories {
+	type realmSample struct {
+		timestamp UnixTimestamp
+		prices    Prices
+	}
+
+	samples := map[blizzard.ItemID]map[UnixTimestamp][]Prices{}
+	for _, ipHistories := range realmHistories {
+		for itemId, pHistory := range ipHistories {
+			latest := map[UnixTimestamp]realmSample{}
+			for targetTimestamp, pricesValue := range pHistory {
+				hourTimestamp := targetTimestamp - targetTimestamp%regionPriceHistoryResolution
+
+				found, ok := latest[hourTimestamp]
+				if ok && found.timestamp > targetTimestamp {
+					continue
+				}
+
+				latest[hourTimestamp] = realmSample{targetTimestamp, pricesValue}
+			}
+
+			if _, ok := samples[itemId]; !ok {
+				samples[itemId] = map[UnixTimestamp][]Prices{}
+			}
+			for hourTimestamp, sample := range latest {
+				samples[itemId][hourTimestamp] = append(samples[itemId][hourTimestamp], sample.prices)
+			}
+		}
+	}
+
+	out := ItemRegionPriceHistories{}
+	for itemId, hourSamples := range samples {
+		rpHistory := RegionPriceHistory{}
+		for hourTimestamp, realmPrices := range hourSamples {
+			rpHistory[hourTimestamp] = NewRegionPrices(realmPrices)
+		}
+
+		out[itemId] = rpHistory
+	}
+
+	return out
+}
+
+type ItemRegionPriceHistories map[blizzard.ItemID]RegionPriceHistory
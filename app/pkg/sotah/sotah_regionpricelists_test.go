@@ -0,0 +1,78 @@
+package sotah
+
+import (
+	"testing"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRegionPrices(t *testing.T) {
+	prices := NewRegionPrices([]Prices{
+		{MedianBuyoutPer: 10, Volume: 1},
+		{MedianBuyoutPer: 20, Volume: 8},
+		{MedianBuyoutPer: 30, Volume: 1},
+		// realms without any auctions are not counted
+		{},
+	})
+
+	assert.Equal(t, 3, prices.RealmCount)
+	assert.Equal(t, int64(10), prices.Volume)
+	assert.Equal(t, float64(20), prices.MedianBuyoutPer)
+}
+
+func TestNewRegionPricesWeighted(t *testing.T) {
+	// the realm holding most of the volume outweighs the others
+	prices := NewRegionPrices([]Prices{
+		{MedianBuyoutPer: 10, Volume: 1},
+		{MedianBuyoutPer: 20, Volume: 1},
+		{MedianBuyoutPer: 90, Volume: 10},
+	})
+	assert.Equal(t, float64(90), prices.MedianBuyoutPer)
+
+	// falling back to the unweighted median without any volume
+	prices = NewRegionPrices([]Prices{
+		{MedianBuyoutPer: 30},
+		{MedianBuyoutPer: 10},
+		{MedianBuyoutPer: 20},
+	})
+	assert.Equal(t, 3, prices.RealmCount)
+	assert.Equal(t, float64(20), prices.MedianBuyoutPer)
+
+	assert.Equal(t, RegionPrices{}, NewRegionPrices([]Prices{}))
+}
+
+func TestNewItemRegionPriceHistories(t *testing.T) {
+	hour := UnixTimestamp(1500000000 - 1500000000%regionPriceHistoryResolution)
+
+	irpHistories := NewItemRegionPriceHistories([]ItemPriceHistories{
+		{
+			blizzard.ItemID(1): PriceHistory{
+				// only the latest sample of the hour is kept
+				hour + 60:  Prices{MedianBuyoutPer: 1000, Volume: 100},
+				hour + 120: Prices{MedianBuyoutPer: 10, Volume: 1},
+			},
+		},
+		{
+			blizzard.ItemID(1): PriceHistory{
+				hour + 30:                           Prices{MedianBuyoutPer: 20, Volume: 1},
+				hour + regionPriceHistoryResolution: Prices{MedianBuyoutPer: 30, Volume: 2},
+			},
+			blizzard.ItemID(2): PriceHistory{
+				hour: Prices{MedianBuyoutPer: 5, Volume: 3},
+			},
+		},
+	})
+
+	if !assert.Len(t, irpHistories, 2) {
+		return
+	}
+
+	assert.Equal(t, RegionPriceHistory{
+		hour:                                RegionPrices{MedianBuyoutPer: 10, RealmCount: 2, Volume: 2},
+		hour + regionPriceHistoryResolution: RegionPrices{MedianBuyoutPer: 30, RealmCount: 1, Volume: 2},
+	}, irpHistories[blizzard.ItemID(1)])
+	assert.Equal(t, RegionPriceHistory{
+		hour: RegionPrices{MedianBuyoutPer: 5, RealmCount: 1, Volume: 3},
+	}, irpHistories[blizzard.ItemID(2)])
+}
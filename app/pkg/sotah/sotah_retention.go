@@ -0,0 +1,105 @@
+package sotah
+
+import (
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/sotah/retentionkinds"
+)
+
+// default retention in days
+var defaultRetentionPolicy = RetentionPolicy{
+	AuctionDumps:           14,
+	Manifests:              14,
+	PricelistHistories:     14,
+	PricelistHistoryShards: 30,
+	DailyRollups:           365,
+	LiveAuctions:           14,
+}
+
+// RetentionPolicy - days to keep each data type, where zero falls back to the next level up
+type RetentionPolicy struct {
+	AuctionDumps           int `json:"auction_dumps"`
+	Manifests              int `json:"manifests"`
+	PricelistHistories     int `json:"pricelist_histories"`
+	PricelistHistoryShards int `json:"pricelist_history_shards"`
+	DailyRollups           int `json:"daily_rollups"`
+	LiveAuctions           int `json:"live_auctions"`
+}
+
+func (p RetentionPolicy) days(kind retentionkinds.RetentionKind) int {
+	switch kind {
+	case retentionkinds.AuctionDumps:
+		return p.AuctionDumps
+	case retentionkinds.Manifests:
+		return p.Manifests
+	case retentionkinds.PricelistHistories:
+		return p.PricelistHistories
+	case retentionkinds.PricelistHistoryShards:
+		return p.PricelistHistoryShards
+	case retentionkinds.DailyRollups:
+		return p.DailyRollups
+	case retentionkinds.LiveAuctions:
+		return p.LiveAuctions
+	}
+
+	return 0
+}
+
+type RetentionConfig struct {
+	RetentionPolicy
+
+	Regions map[blizzard.RegionName]RetentionPolicy `json:"regions"`
+	DryRun  bool                                    `json:"dry_run"`
+}
+
+// Days - resolves the retention for the data type in the region, falling back to the global and then default policy
+func (c RetentionConfig) Days(regionName blizzard.RegionName, kind retentionkinds.RetentionKind) int {
+	if regionPolicy, ok := c.Regions[regionName]; ok {
+		if days := regionPolicy.days(kind); days > 0 {
+			return days
+		}
+	}
+
+	if days := c.RetentionPolicy.days(kind); days > 0 {
+		return days
+	}
+
+	return defaultRetentionPolicy.days(kind)
+}
+
+// Limit - anything at or before the returned time is expired
+func (c RetentionConfig) Limit(regionName blizzard.RegionName, kind retentionkinds.RetentionKind) time.Time {
+	return NormalizeTargetDate(time.Now()).AddDate(0, 0, -c.Days(regionName, kind))
+}
+
+// ManifestsLimit - deleting a manifest also deletes the auction dumps it tracks, so manifests are kept for the longer
+// of the two retentions
+func (c RetentionConfig) ManifestsLimit(regionName blizzard.RegionName) time.Time {
+	dumpsLimit := c.Limit(regionName, retentionkinds.AuctionDumps)
+	manifestsLimit := c.Limit(regionName, retentionkinds.Manifests)
+	if dumpsLimit.Before(manifestsLimit) {
+		return dumpsLimit
+	}
+
+	return manifestsLimit
+}
+
+// ExpiredManifests - manifests past retention, which are deleted along with the auction dumps they track
+func (c RetentionConfig) ExpiredManifests(regionRealmTimestamps RegionRealmTimestamps) RegionRealmTimestamps {
+	return regionRealmTimestamps.filter(func(regionName blizzard.RegionName, targetTime time.Time) bool {
+		return !targetTime.After(c.ManifestsLimit(regionName))
+	})
+}
+
+/*
+ExpiredAuctionDumps - manifests that are kept while the auction dumps they track are past retention, so that the dumps
+are deleted without waiting on the manifests
+*/
+func (c RetentionConfig) ExpiredAuctionDumps(regionRealmTimestamps RegionRealmTimestamps) RegionRealmTimestamps {
+	return regionRealmTimestamps.filter(func(regionName blizzard.RegionName, targetTime time.Time) bool {
+		dumpsExpired := !targetTime.After(c.Limit(regionName, retentionkinds.AuctionDumps))
+
+		return dumpsExpired && targetTime.After(c.ManifestsLimit(regionName))
+	})
+}
@@ -0,0 +1,57 @@
+package sotah
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/sotah/retentionkinds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionConfigDays(t *testing.T) {
+	c := RetentionConfig{
+		RetentionPolicy: RetentionPolicy{PricelistHistories: 7},
+		Regions: map[blizzard.RegionName]RetentionPolicy{
+			"eu": {PricelistHistoryShards: 60},
+		},
+	}
+
+	// the store objects and the local shards are kept for their own durations
+	assert.Equal(t, 7, c.Days("us", retentionkinds.PricelistHistories))
+	assert.Equal(t, 30, c.Days("us", retentionkinds.PricelistHistoryShards))
+
+	// falling back from the region to the global and then the default policy
+	assert.Equal(t, 7, c.Days("eu", retentionkinds.PricelistHistories))
+	assert.Equal(t, 60, c.Days("eu", retentionkinds.PricelistHistoryShards))
+	assert.Equal(t, 14, c.Days("eu", retentionkinds.Manifests))
+}
+
+func TestRetentionConfigExpiredAuctionDumps(t *testing.T) {
+	c := RetentionConfig{RetentionPolicy: RetentionPolicy{AuctionDumps: 7, Manifests: 14}}
+
+	today := NormalizeTargetDate(time.Now())
+	fresh := UnixTimestamp(today.Unix())
+	dumpsExpired := UnixTimestamp(today.AddDate(0, 0, -10).Unix())
+	bothExpired := UnixTimestamp(today.AddDate(0, 0, -20).Unix())
+	timestamps := RegionRealmTimestamps{
+		"us": RealmTimestamps{"earthen-ring": []UnixTimestamp{fresh, dumpsExpired, bothExpired}},
+	}
+
+	// the manifests go along with their dumps once both are past retention
+	assert.Equal(t, RegionRealmTimestamps{
+		"us": RealmTimestamps{"earthen-ring": []UnixTimestamp{bothExpired}},
+	}, c.ExpiredManifests(timestamps))
+
+	// the dumps of kept manifests are deleted on their own
+	assert.Equal(t, RegionRealmTimestamps{
+		"us": RealmTimestamps{"earthen-ring": []UnixTimestamp{dumpsExpired}},
+	}, c.ExpiredAuctionDumps(timestamps))
+
+	// dumps outliving their manifests keep the manifests around instead
+	c = RetentionConfig{RetentionPolicy: RetentionPolicy{AuctionDumps: 14, Manifests: 7}}
+	assert.Empty(t, c.ExpiredAuctionDumps(timestamps))
+	assert.Equal(t, RegionRealmTimestamps{
+		"us": RealmTimestamps{"earthen-ring": []UnixTimestamp{bothExpired}},
+	}, c.ExpiredManifests(timestamps))
+}
@@ -0,0 +1,73 @@
+package sotah
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/twinj/uuid"
+)
+
+// SessionSecret - a secret that sessions are signed with, along with when it came into use
+type SessionSecret struct {
+	Secret    string        `json:"secret"`
+	CreatedAt UnixTimestamp `json:"created_at"`
+}
+
+func NewSessionSecret(now time.Time) SessionSecret {
+	return SessionSecret{Secret: uuid.NewV4().String(), CreatedAt: UnixTimestamp(now.Unix())}
+}
+
+// NewSessionSecrets makes a first secret, due to be rotated after the given interval, where zero never rotates it
+func NewSessionSecrets(now time.Time, rotation time.Duration) SessionSecrets {
+	return SessionSecrets{Current: NewSessionSecret(now)}.scheduled(now, rotation)
+}
+
+func NewSessionSecretsFromJSON(data []byte) (SessionSecrets, error) {
+	out := &SessionSecrets{}
+	if err := json.Unmarshal(data, out); err != nil {
+		return SessionSecrets{}, err
+	}
+
+	return *out, nil
+}
+
+/*
+SessionSecrets - the secret that sessions are signed with, and the one it replaced, which sessions signed before the
+rotation are still verified against, so that sessions outliving the rotation interval are the only ones cut short
+*/
+type SessionSecrets struct {
+	Current  SessionSecret  `json:"current"`
+	Previous *SessionSecret `json:"previous,omitempty"`
+
+	// when the current secret is due to be rotated, where zero never rotates it
+	NextRotationAt UnixTimestamp `json:"next_rotation_at,omitempty"`
+}
+
+func (secrets SessionSecrets) EncodeForStorage() ([]byte, error) {
+	return json.Marshal(secrets)
+}
+
+func (secrets SessionSecrets) scheduled(now time.Time, rotation time.Duration) SessionSecrets {
+	secrets.NextRotationAt = 0
+	if rotation > 0 {
+		secrets.NextRotationAt = UnixTimestamp(now.Add(rotation).Unix())
+	}
+
+	return secrets
+}
+
+// Reschedule moves the next rotation to the given interval after the current secret came into use
+func (secrets SessionSecrets) Reschedule(rotation time.Duration) SessionSecrets {
+	return secrets.scheduled(time.Unix(int64(secrets.Current.CreatedAt), 0), rotation)
+}
+
+func (secrets SessionSecrets) IsDue(now time.Time) bool {
+	return secrets.NextRotationAt > 0 && now.Unix() >= int64(secrets.NextRotationAt)
+}
+
+// Rotate makes a new current secret, keeping the current one as the previous and dropping the one before
+func (secrets SessionSecrets) Rotate(now time.Time, rotation time.Duration) SessionSecrets {
+	previous := secrets.Current
+
+	return SessionSecrets{Current: NewSessionSecret(now), Previous: &previous}.scheduled(now, rotation)
+}
@@ -0,0 +1,55 @@
+package sotah
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionSecretsRotate(t *testing.T) {
+	now := time.Unix(1000, 0)
+	secrets := NewSessionSecrets(now, time.Hour)
+
+	assert.Nil(t, secrets.Previous)
+	assert.Equal(t, UnixTimestamp(1000+3600), secrets.NextRotationAt)
+	assert.False(t, secrets.IsDue(now.Add(time.Minute)))
+	assert.True(t, secrets.IsDue(now.Add(time.Hour)))
+
+	rotated := secrets.Rotate(now.Add(time.Hour), time.Hour)
+	if !assert.NotNil(t, rotated.Previous) {
+		return
+	}
+	assert.Equal(t, secrets.Current, *rotated.Previous)
+	assert.NotEqual(t, secrets.Current.Secret, rotated.Current.Secret)
+	assert.Equal(t, UnixTimestamp(1000+3600), rotated.Current.CreatedAt)
+	assert.Equal(t, UnixTimestamp(1000+2*3600), rotated.NextRotationAt)
+
+	// dropping the secret before the previous one
+	twiceRotated := rotated.Rotate(now.Add(2*time.Hour), time.Hour)
+	assert.Equal(t, rotated.Current, *twiceRotated.Previous)
+}
+
+func TestSessionSecretsReschedule(t *testing.T) {
+	secrets := NewSessionSecrets(time.Unix(1000, 0), 0)
+	assert.False(t, secrets.IsDue(time.Unix(1000000, 0)))
+
+	rescheduled := secrets.Reschedule(time.Hour)
+	assert.Equal(t, UnixTimestamp(1000+3600), rescheduled.NextRotationAt)
+	assert.Equal(t, UnixTimestamp(0), rescheduled.Reschedule(0).NextRotationAt)
+}
+
+func TestSessionSecretsCodec(t *testing.T) {
+	secrets := NewSessionSecrets(time.Unix(1000, 0), time.Hour).Rotate(time.Unix(5000, 0), time.Hour)
+
+	encoded, err := secrets.EncodeForStorage()
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	decoded, err := NewSessionSecretsFromJSON(encoded)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, secrets, decoded)
+}
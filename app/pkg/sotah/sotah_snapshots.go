@@ -0,0 +1,34 @@
+package sotah
+
+import (
+	"time"
+)
+
+// default snapshot schedule, four snapshots a day with the last week of them kept
+var defaultSnapshotConfig = SnapshotConfig{
+	IntervalMinutes: 6 * 60,
+	Keep:            28,
+}
+
+// SnapshotConfig - how often local databases are snapshotted to the store and how many snapshots are kept
+type SnapshotConfig struct {
+	IntervalMinutes int  `json:"interval_minutes"`
+	Keep            int  `json:"keep"`
+	Disabled        bool `json:"disabled"`
+}
+
+func (c SnapshotConfig) Interval() time.Duration {
+	if c.IntervalMinutes > 0 {
+		return time.Duration(c.IntervalMinutes) * time.Minute
+	}
+
+	return time.Duration(defaultSnapshotConfig.IntervalMinutes) * time.Minute
+}
+
+func (c SnapshotConfig) KeepCount() int {
+	if c.Keep > 0 {
+		return c.Keep
+	}
+
+	return defaultSnapshotConfig.Keep
+}
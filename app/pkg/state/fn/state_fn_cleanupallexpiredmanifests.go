@@ -1,12 +1,14 @@
 package fn
 
 import (
+	"context"
 	"log"
 
 	"cloud.google.com/go/pubsub"
 	"cloud.google.com/go/storage"
 	"github.com/sotah-inc/server/app/pkg/bus"
 	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/sotah/gameversions"
 	"github.com/sotah-inc/server/app/pkg/state"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
@@ -17,14 +19,18 @@ import (
 
 type CleanupAllExpiredManifestsStateConfig struct {
 	ProjectId string
+
+	Retention sotah.RetentionConfig
 }
 
 func NewCleanupAllExpiredManifestsState(
+	ctx context.Context,
 	config CleanupAllExpiredManifestsStateConfig,
 ) (CleanupAllExpiredManifestsState, error) {
 	// establishing an initial state
 	sta := CleanupAllExpiredManifestsState{
-		State: state.NewState(uuid.NewV4(), true),
+		State:     state.NewState(uuid.NewV4(), true),
+		retention: config.Retention,
 	}
 
 	var err error
@@ -34,7 +40,7 @@ func NewCleanupAllExpiredManifestsState(
 
 		return CleanupAllExpiredManifestsState{}, err
 	}
-	sta.auctionsCleanupTopic, err = sta.IO.BusClient.FirmTopic(string(subjects.CleanupExpiredManifest))
+	sta.auctionsCleanupTopic, err = sta.IO.BusClient.FirmTopic(ctx, string(subjects.CleanupExpiredManifest))
 	if err != nil {
 		log.Fatalf("Failed to get firm topic: %s", err.Error())
 
@@ -49,7 +55,7 @@ func NewCleanupAllExpiredManifestsState(
 	}
 
 	sta.bootBase = store.NewBootBase(sta.IO.StoreClient, regions.USCentral1)
-	sta.bootBucket, err = sta.bootBase.GetFirmBucket()
+	sta.bootBucket, err = sta.bootBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm bucket: %s", err.Error())
 
@@ -57,7 +63,7 @@ func NewCleanupAllExpiredManifestsState(
 	}
 
 	sta.realmsBase = store.NewRealmsBase(sta.IO.StoreClient, regions.USCentral1, gameversions.Retail)
-	sta.realmsBucket, err = sta.realmsBase.GetFirmBucket()
+	sta.realmsBucket, err = sta.realmsBase.GetFirmBucket(ctx)
 	if err != nil {
 		return CleanupAllExpiredManifestsState{}, err
 	}
@@ -67,13 +73,21 @@ func NewCleanupAllExpiredManifestsState(
 		regions.USCentral1,
 		gameversions.Retail,
 	)
-	sta.auctionManifestBucket, err = sta.auctionManifestStoreBase.GetFirmBucket()
+	sta.auctionManifestBucket, err = sta.auctionManifestStoreBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm auction-manifest bucket: %s", err.Error())
 
 		return CleanupAllExpiredManifestsState{}, err
 	}
 
+	sta.auctionsStoreBase = store.NewAuctionsBaseV2(sta.IO.StoreClient, regions.USCentral1, gameversions.Retail)
+	sta.auctionsBucket, err = sta.auctionsStoreBase.GetFirmBucket(ctx)
+	if err != nil {
+		log.Fatalf("Failed to get firm raw-auctions bucket: %s", err.Error())
+
+		return CleanupAllExpiredManifestsState{}, err
+	}
+
 	// establishing bus-listeners
 	sta.BusListeners = state.NewBusListeners(state.SubjectBusListeners{
 		subjects.CleanupAllExpiredManifests: sta.ListenForCleanupAllExpiredManifests,
@@ -85,10 +99,14 @@ func NewCleanupAllExpiredManifestsState(
 type CleanupAllExpiredManifestsState struct {
 	state.State
 
+	retention sotah.RetentionConfig
+
 	auctionsCleanupTopic *pubsub.Topic
 
 	auctionManifestStoreBase store.AuctionManifestBaseV2
 	auctionManifestBucket    *storage.BucketHandle
+	auctionsStoreBase        store.AuctionsBaseV2
+	auctionsBucket           *storage.BucketHandle
 	realmsBase               store.RealmsBase
 	realmsBucket             *storage.BucketHandle
 	bootBase                 store.BootBase
@@ -103,8 +121,8 @@ func (sta CleanupAllExpiredManifestsState) ListenForCleanupAllExpiredManifests(
 	// establishing subscriber config
 	config := bus.SubscribeConfig{
 		Stop: stop,
-		Callback: func(_ bus.Message) {
-			if err := sta.Run(); err != nil {
+		Callback: func(ctx context.Context, _ bus.Message) {
+			if err := sta.Run(ctx); err != nil {
 				logging.WithField("error", err.Error()).Error("Failed to run")
 			}
 		},
@@ -114,7 +132,12 @@ func (sta CleanupAllExpiredManifestsState) ListenForCleanupAllExpiredManifests(
 
 	// starting up worker for the subscription
 	go func() {
-		if err := sta.IO.BusClient.SubscribeToTopic(string(subjects.CleanupAllExpiredManifests), config); err != nil {
+		err := sta.IO.BusClient.SubscribeToTopic(
+			context.Background(),
+			string(subjects.CleanupAllExpiredManifests),
+			config,
+		)
+		if err != nil {
 			logging.WithField("error", err.Error()).Fatal("Failed to subscribe to topic")
 		}
 	}()
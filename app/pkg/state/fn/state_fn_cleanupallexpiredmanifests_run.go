@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -9,12 +10,13 @@ import (
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/metric"
 	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/sotah/retentionkinds"
 )
 
-func (sta CleanupAllExpiredManifestsState) Run() error {
+func (sta CleanupAllExpiredManifestsState) Run(ctx context.Context) error {
 	logging.Info("Starting CleanupAllExpiredManifests.Run()")
 
-	regions, err := sta.bootBase.GetRegions(sta.bootBucket)
+	regions, err := sta.bootBase.GetRegions(ctx, sta.bootBucket)
 	if err != nil {
 		return err
 	}
@@ -23,7 +25,7 @@ func (sta CleanupAllExpiredManifestsState) Run() error {
 
 	regionRealms := sotah.RegionRealms{}
 	for _, region := range regions {
-		realms, err := sta.realmsBase.GetAllRealms(region.Name, sta.realmsBucket)
+		realms, err := sta.realmsBase.GetAllRealms(ctx, region.Name, sta.realmsBucket)
 		if err != nil {
 			return err
 		}
@@ -37,10 +39,42 @@ func (sta CleanupAllExpiredManifestsState) Run() error {
 	}
 
 	logging.WithField("realms", regionRealms.TotalRealms()).Info("Gathering expired timestamps")
-	regionExpiredTimestamps, err := sta.auctionManifestStoreBase.GetAllExpiredTimestamps(
-		regionRealms,
-		sta.auctionManifestBucket,
-	)
+	regionTimestamps, err := sta.auctionManifestStoreBase.GetAllTimestamps(ctx, regionRealms, sta.auctionManifestBucket)
+	if err != nil {
+		return err
+	}
+	regionExpiredTimestamps := sta.retention.ExpiredManifests(regionTimestamps)
+	regionExpiredDumpsTimestamps := sta.retention.ExpiredAuctionDumps(regionTimestamps)
+
+	if sta.retention.DryRun {
+		for regionName, realmTimestamps := range regionExpiredTimestamps {
+			for realmSlug, timestamps := range realmTimestamps {
+				logging.WithFields(logrus.Fields{
+					"region":     regionName,
+					"realm":      realmSlug,
+					"manifests":  len(timestamps),
+					"expired-at": sta.retention.ManifestsLimit(regionName).Unix(),
+				}).Info("Dry-run: would delete expired manifests")
+			}
+		}
+		for regionName, realmTimestamps := range regionExpiredDumpsTimestamps {
+			for realmSlug, timestamps := range realmTimestamps {
+				logging.WithFields(logrus.Fields{
+					"region":     regionName,
+					"realm":      realmSlug,
+					"manifests":  len(timestamps),
+					"expired-at": sta.retention.Limit(regionName, retentionkinds.AuctionDumps).Unix(),
+				}).Info("Dry-run: would delete the expired auction dumps of kept manifests")
+			}
+		}
+
+		logging.Info("Finished CleanupAllExpiredManifests.Run() in dry-run mode")
+
+		return nil
+	}
+
+	// deleting the dumps that expire before their manifests, since the manifest jobs only cover expired manifests
+	totalDumpsRemoved, err := sta.deleteExpiredAuctionDumps(ctx, regionExpiredDumpsTimestamps)
 	if err != nil {
 		return err
 	}
@@ -55,11 +89,13 @@ func (sta CleanupAllExpiredManifestsState) Run() error {
 	if len(messages) == 0 {
 		logging.Info("No expired-manifest job messages found, exiting early")
 
-		return nil
+		return sta.IO.BusClient.PublishMetrics(ctx, metric.Metrics{
+			"total_expired_auction_dumps_removed": totalDumpsRemoved,
+		})
 	}
 
 	logging.Info("Bulk publishing")
-	responses, err := sta.IO.BusClient.BulkRequest(sta.auctionsCleanupTopic, messages, 120*time.Second)
+	responses, err := sta.IO.BusClient.BulkRequest(ctx, sta.auctionsCleanupTopic, messages, 120*time.Second)
 	if err != nil {
 		return err
 	}
@@ -83,8 +119,9 @@ func (sta CleanupAllExpiredManifestsState) Run() error {
 		totalRemoved += jobResponse.TotalDeleted
 	}
 
-	if err := sta.IO.BusClient.PublishMetrics(metric.Metrics{
-		"total_expired_manifests_removed": totalRemoved,
+	if err := sta.IO.BusClient.PublishMetrics(ctx, metric.Metrics{
+		"total_expired_manifests_removed":     totalRemoved,
+		"total_expired_auction_dumps_removed": totalDumpsRemoved,
 	}); err != nil {
 		return err
 	}
@@ -93,3 +130,56 @@ func (sta CleanupAllExpiredManifestsState) Run() error {
 
 	return nil
 }
+
+func (sta CleanupAllExpiredManifestsState) deleteExpiredAuctionDumps(
+	ctx context.Context,
+	regionExpiredDumpsTimestamps sotah.RegionRealmTimestamps,
+) (int, error) {
+	totalRemoved := 0
+	for regionName, realmTimestamps := range regionExpiredDumpsTimestamps {
+		for realmSlug, timestamps := range realmTimestamps {
+			realm := sotah.NewSkeletonRealm(regionName, realmSlug)
+
+			for _, targetTimestamp := range timestamps {
+				obj, err := sta.auctionManifestStoreBase.GetFirmObject(
+					ctx,
+					targetTimestamp,
+					realm,
+					sta.auctionManifestBucket,
+				)
+				if err != nil {
+					return 0, err
+				}
+
+				manifest, err := sta.auctionManifestStoreBase.NewAuctionManifest(ctx, obj)
+				if err != nil {
+					return 0, err
+				}
+
+				var firstErr error
+				for outJob := range sta.auctionsStoreBase.DeleteAll(ctx, sta.auctionsBucket, realm, manifest) {
+					if outJob.Err != nil {
+						if firstErr == nil {
+							firstErr = outJob.Err
+						}
+
+						continue
+					}
+
+					totalRemoved += 1
+				}
+				if firstErr != nil {
+					return 0, firstErr
+				}
+
+				logging.WithFields(logrus.Fields{
+					"region":           regionName,
+					"realm":            realmSlug,
+					"target-timestamp": targetTimestamp,
+				}).Info("Deleted expired auction dumps of kept manifest")
+			}
+		}
+	}
+
+	return totalRemoved, nil
+}
@@ -1,12 +1,14 @@
 package fn
 
 import (
+	"context"
 	"log"
 
 	"cloud.google.com/go/pubsub"
 	"cloud.google.com/go/storage"
 	"github.com/sotah-inc/server/app/pkg/bus"
 	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/sotah/gameversions"
 	"github.com/sotah-inc/server/app/pkg/state"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
@@ -17,14 +19,18 @@ import (
 
 type CleanupPricelistHistoriesStateConfig struct {
 	ProjectId string
+
+	Retention sotah.RetentionConfig
 }
 
 func NewCleanupPricelistHistoriesState(
+	ctx context.Context,
 	config CleanupPricelistHistoriesStateConfig,
 ) (CleanupPricelistHistoriesState, error) {
 	// establishing an initial state
 	sta := CleanupPricelistHistoriesState{
-		State: state.NewState(uuid.NewV4(), true),
+		State:     state.NewState(uuid.NewV4(), true),
+		retention: config.Retention,
 	}
 
 	var err error
@@ -34,7 +40,7 @@ func NewCleanupPricelistHistoriesState(
 
 		return CleanupPricelistHistoriesState{}, err
 	}
-	sta.pricelistsCleanupTopic, err = sta.IO.BusClient.FirmTopic(string(subjects.CleanupPricelistHistories))
+	sta.pricelistsCleanupTopic, err = sta.IO.BusClient.FirmTopic(ctx, string(subjects.CleanupPricelistHistories))
 	if err != nil {
 		log.Fatalf("Failed to get firm topic: %s", err.Error())
 
@@ -49,7 +55,7 @@ func NewCleanupPricelistHistoriesState(
 	}
 
 	sta.bootStoreBase = store.NewBootBase(sta.IO.StoreClient, regions.USCentral1)
-	sta.bootBucket, err = sta.bootStoreBase.GetFirmBucket()
+	sta.bootBucket, err = sta.bootStoreBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm bucket: %s", err.Error())
 
@@ -61,7 +67,7 @@ func NewCleanupPricelistHistoriesState(
 		regions.USCentral1,
 		gameversions.Retail,
 	)
-	sta.pricelistHistoriesBucket, err = sta.pricelistHistoriesStoreBase.GetFirmBucket()
+	sta.pricelistHistoriesBucket, err = sta.pricelistHistoriesStoreBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm bucket: %s", err.Error())
 
@@ -69,7 +75,7 @@ func NewCleanupPricelistHistoriesState(
 	}
 
 	sta.realmsBase = store.NewRealmsBase(sta.IO.StoreClient, "us-central1", gameversions.Retail)
-	sta.realmsBucket, err = sta.realmsBase.GetFirmBucket()
+	sta.realmsBucket, err = sta.realmsBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm bucket: %s", err.Error())
 
@@ -87,6 +93,8 @@ func NewCleanupPricelistHistoriesState(
 type CleanupPricelistHistoriesState struct {
 	state.State
 
+	retention sotah.RetentionConfig
+
 	pricelistsCleanupTopic *pubsub.Topic
 
 	pricelistHistoriesStoreBase store.PricelistHistoriesBaseV2
@@ -106,8 +114,8 @@ func (sta CleanupPricelistHistoriesState) ListenForCleanupAllPricelistHistories(
 	// establishing subscriber config
 	config := bus.SubscribeConfig{
 		Stop: stop,
-		Callback: func(_ bus.Message) {
-			if err := sta.Run(); err != nil {
+		Callback: func(ctx context.Context, _ bus.Message) {
+			if err := sta.Run(ctx); err != nil {
 				logging.WithField("error", err.Error()).Error("Failed to run")
 			}
 		},
@@ -117,7 +125,12 @@ func (sta CleanupPricelistHistoriesState) ListenForCleanupAllPricelistHistories(
 
 	// starting up worker for the subscription
 	go func() {
-		if err := sta.IO.BusClient.SubscribeToTopic(string(subjects.CleanupAllPricelistHistories), config); err != nil {
+		err := sta.IO.BusClient.SubscribeToTopic(
+			context.Background(),
+			string(subjects.CleanupAllPricelistHistories),
+			config,
+		)
+		if err != nil {
 			logging.WithField("error", err.Error()).Fatal("Failed to subscribe to topic")
 		}
 	}()
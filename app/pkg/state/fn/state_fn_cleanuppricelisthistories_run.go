@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -13,23 +14,23 @@ import (
 	"github.com/sotah-inc/server/app/pkg/store"
 )
 
-func (sta CleanupPricelistHistoriesState) Run() error {
+func (sta CleanupPricelistHistoriesState) Run(ctx context.Context) error {
 	logging.Info("Starting CleanupPricelistHistories.Run()")
 
-	regions, err := sta.bootStoreBase.GetRegions(sta.bootBucket)
+	regions, err := sta.bootStoreBase.GetRegions(ctx, sta.bootBucket)
 	if err != nil {
 		return err
 	}
 
 	realmsBase := store.NewRealmsBase(sta.IO.StoreClient, "us-central1", gameversions.Retail)
-	realmsBucket, err := realmsBase.GetFirmBucket()
+	realmsBucket, err := realmsBase.GetFirmBucket(ctx)
 	if err != nil {
 		return err
 	}
 
 	regionRealms := sotah.RegionRealms{}
 	for _, region := range regions {
-		realms, err := realmsBase.GetAllRealms(region.Name, realmsBucket)
+		realms, err := realmsBase.GetAllRealms(ctx, region.Name, realmsBucket)
 		if err != nil {
 			return err
 		}
@@ -37,13 +38,17 @@ func (sta CleanupPricelistHistoriesState) Run() error {
 		regionRealms[region.Name] = realms
 	}
 
-	payloads := sotah.NewCleanupPricelistPayloads(regionRealms)
+	if sta.retention.DryRun {
+		return sta.dryRun(ctx, regionRealms)
+	}
+
+	payloads := sotah.NewCleanupPricelistPayloads(regionRealms, sta.retention)
 	messages, err := bus.NewCleanupPricelistPayloadsMessages(payloads)
 	if err != nil {
 		return err
 	}
 
-	responses, err := sta.IO.BusClient.BulkRequest(sta.pricelistsCleanupTopic, messages, 120*time.Second)
+	responses, err := sta.IO.BusClient.BulkRequest(ctx, sta.pricelistsCleanupTopic, messages, 120*time.Second)
 	if err != nil {
 		return err
 	}
@@ -67,7 +72,7 @@ func (sta CleanupPricelistHistoriesState) Run() error {
 		totalRemoved += jobResponse.TotalDeleted
 	}
 
-	if err := sta.IO.BusClient.PublishMetrics(metric.Metrics{
+	if err := sta.IO.BusClient.PublishMetrics(ctx, metric.Metrics{
 		"total_pricelist_histories_removed": totalRemoved,
 	}); err != nil {
 		return err
@@ -77,3 +82,32 @@ func (sta CleanupPricelistHistoriesState) Run() error {
 
 	return nil
 }
+
+func (sta CleanupPricelistHistoriesState) dryRun(ctx context.Context, regionRealms sotah.RegionRealms) error {
+	regionExpiredTimestamps, err := sta.pricelistHistoriesStoreBase.GetAllExpiredTimestamps(
+		ctx,
+		regionRealms,
+		sta.retention,
+		sta.pricelistHistoriesBucket,
+	)
+	if err != nil {
+		return err
+	}
+
+	totalExpired := 0
+	for regionName, realmTimestamps := range regionExpiredTimestamps {
+		for realmSlug, timestamps := range realmTimestamps {
+			logging.WithFields(logrus.Fields{
+				"region":              regionName,
+				"realm":               realmSlug,
+				"pricelist-histories": len(timestamps),
+			}).Info("Dry-run: would delete expired pricelist-histories")
+
+			totalExpired += len(timestamps)
+		}
+	}
+
+	logging.WithField("total-expired", totalExpired).Info("Finished CleanupPricelistHistories.Run() in dry-run mode")
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"log"
 
 	"cloud.google.com/go/pubsub"
@@ -9,6 +10,7 @@ import (
 	"github.com/sotah-inc/server/app/pkg/state"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
 	"github.com/sotah-inc/server/app/pkg/store"
+	"github.com/sotah-inc/server/app/pkg/tracing"
 	"github.com/twinj/uuid"
 )
 
@@ -16,7 +18,7 @@ type ComputeAllLiveAuctionsStateConfig struct {
 	ProjectId string
 }
 
-func NewComputeAllLiveAuctionsState(config ComputeAllLiveAuctionsStateConfig) (ComputeAllLiveAuctionsState, error) {
+func NewComputeAllLiveAuctionsState(ctx context.Context, config ComputeAllLiveAuctionsStateConfig) (ComputeAllLiveAuctionsState, error) {
 	// establishing an initial state
 	sta := ComputeAllLiveAuctionsState{
 		State: state.NewState(uuid.NewV4(), true),
@@ -29,19 +31,20 @@ func NewComputeAllLiveAuctionsState(config ComputeAllLiveAuctionsStateConfig) (C
 
 		return ComputeAllLiveAuctionsState{}, err
 	}
-	sta.computeLiveAuctionsTopic, err = sta.IO.BusClient.FirmTopic(string(subjects.ComputeLiveAuctions))
+	sta.computeLiveAuctionsTopic, err = sta.IO.BusClient.FirmTopic(ctx, string(subjects.ComputeLiveAuctions))
 	if err != nil {
 		log.Fatalf("Failed to get firm topic: %s", err.Error())
 
 		return ComputeAllLiveAuctionsState{}, err
 	}
-	sta.syncAllItemsTopic, err = sta.IO.BusClient.FirmTopic(string(subjects.SyncAllItems))
+	sta.syncAllItemsTopic, err = sta.IO.BusClient.FirmTopic(ctx, string(subjects.SyncAllItems))
 	if err != nil {
 		log.Fatalf("Failed to get firm topic: %s", err.Error())
 
 		return ComputeAllLiveAuctionsState{}, err
 	}
 	sta.receiveComputedLiveAuctionsTopic, err = sta.IO.BusClient.FirmTopic(
+		ctx,
 		string(subjects.ReceiveComputedLiveAuctions),
 	)
 	if err != nil {
@@ -78,21 +81,25 @@ func (sta ComputeAllLiveAuctionsState) ListenForComputeAllLiveAuctions(
 	stop chan interface{},
 	onStopped chan interface{},
 ) {
-	in := make(chan string)
+	in := make(chan bus.Message)
 	go func() {
-		for {
-			data := <-in
-			if err := sta.Run(data); err != nil {
+		for busMsg := range in {
+			ctx, cancel := busMsg.Context()
+			ctx, span := tracing.Start(ctx, "ComputeAllLiveAuctions")
+			if err := sta.Run(ctx, busMsg.Data); err != nil {
+				span.SetError(err)
 				logging.WithField("error", err.Error()).Error("Failed to run")
 			}
+			span.End()
+			cancel()
 		}
 	}()
 
 	// establishing subscriber config
 	config := bus.SubscribeConfig{
 		Stop: stop,
-		Callback: func(busMsg bus.Message) {
-			in <- busMsg.Data
+		Callback: func(ctx context.Context, busMsg bus.Message) {
+			in <- busMsg
 		},
 		OnReady:   onReady,
 		OnStopped: onStopped,
@@ -100,7 +107,8 @@ func (sta ComputeAllLiveAuctionsState) ListenForComputeAllLiveAuctions(
 
 	// starting up worker for the subscription
 	go func() {
-		if err := sta.IO.BusClient.SubscribeToTopic(string(subjects.ComputeAllLiveAuctions), config); err != nil {
+		err := sta.IO.BusClient.SubscribeToTopic(context.Background(), string(subjects.ComputeAllLiveAuctions), config)
+		if err != nil {
 			logging.WithField("error", err.Error()).Fatal("Failed to subscribe to topic")
 		}
 	}()
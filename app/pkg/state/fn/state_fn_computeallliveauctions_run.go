@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"time"
 
 	"github.com/sotah-inc/server/app/pkg/blizzard"
@@ -11,7 +12,7 @@ import (
 	"github.com/sotah-inc/server/app/pkg/sotah"
 )
 
-func (sta ComputeAllLiveAuctionsState) PublishToSyncAllItems(tuples bus.RegionRealmTimestampTuples) error {
+func (sta ComputeAllLiveAuctionsState) PublishToSyncAllItems(ctx context.Context, tuples bus.RegionRealmTimestampTuples) error {
 	itemIdsMap := sotah.ItemIdsMap{}
 	for _, tuple := range tuples {
 		for _, id := range tuple.ItemIds {
@@ -33,7 +34,7 @@ func (sta ComputeAllLiveAuctionsState) PublishToSyncAllItems(tuples bus.RegionRe
 
 	// publishing to sync-all-items
 	logging.Info("Publishing to sync-all-items")
-	if _, err := sta.IO.BusClient.Publish(sta.syncAllItemsTopic, msg); err != nil {
+	if _, err := sta.IO.BusClient.Publish(ctx, sta.syncAllItemsTopic, msg); err != nil {
 		return err
 	}
 
@@ -41,6 +42,7 @@ func (sta ComputeAllLiveAuctionsState) PublishToSyncAllItems(tuples bus.RegionRe
 }
 
 func (sta ComputeAllLiveAuctionsState) PublishToReceiveComputedLiveAuctions(
+	ctx context.Context,
 	tuples bus.RegionRealmTimestampTuples,
 ) error {
 	// stripping non-essential data
@@ -59,14 +61,14 @@ func (sta ComputeAllLiveAuctionsState) PublishToReceiveComputedLiveAuctions(
 
 	// publishing to receive-computed-live-auctions
 	logging.Info("Publishing to receive-computed-live-auctions")
-	if _, err := sta.IO.BusClient.Publish(sta.receiveComputedLiveAuctionsTopic, msg); err != nil {
+	if _, err := sta.IO.BusClient.Publish(ctx, sta.receiveComputedLiveAuctionsTopic, msg); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (sta ComputeAllLiveAuctionsState) Run(data string) error {
+func (sta ComputeAllLiveAuctionsState) Run(ctx context.Context, data string) error {
 	// formatting the response-items as tuples for processing
 	tuples, err := bus.NewRegionRealmTimestampTuples(data)
 	if err != nil {
@@ -83,7 +85,7 @@ func (sta ComputeAllLiveAuctionsState) Run(data string) error {
 	// enqueueing them and gathering result jobs
 	logging.WithField("messages", len(messages)).Info("Enqueueing compute-live-auctions messages")
 	startTime := time.Now()
-	responseItems, err := sta.IO.BusClient.BulkRequest(sta.computeLiveAuctionsTopic, messages, 120*time.Second)
+	responseItems, err := sta.IO.BusClient.BulkRequest(ctx, sta.computeLiveAuctionsTopic, messages, 120*time.Second)
 	if err != nil {
 		return err
 	}
@@ -102,7 +104,7 @@ func (sta ComputeAllLiveAuctionsState) Run(data string) error {
 	}
 
 	// reporting metrics
-	if err := sta.IO.BusClient.PublishMetrics(metric.Metrics{
+	if err := sta.IO.BusClient.PublishMetrics(ctx, metric.Metrics{
 		"compute_all_live_auctions_duration": int(int64(time.Since(startTime)) / 1000 / 1000 / 1000),
 		"included_realms":                    len(validatedResponseItems),
 	}); err != nil {
@@ -111,12 +113,12 @@ func (sta ComputeAllLiveAuctionsState) Run(data string) error {
 
 	// publishing to sync-all-items
 	logging.Info("Publishing tuples to sync-all-items")
-	if err := sta.PublishToSyncAllItems(nextTuples); err != nil {
+	if err := sta.PublishToSyncAllItems(ctx, nextTuples); err != nil {
 		return err
 	}
 
 	// publishing to receive-computed-live-auctions
-	if err := sta.PublishToReceiveComputedLiveAuctions(tuples); err != nil {
+	if err := sta.PublishToReceiveComputedLiveAuctions(ctx, tuples); err != nil {
 		return err
 	}
 
@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"log"
 
 	"cloud.google.com/go/pubsub"
@@ -9,6 +10,7 @@ import (
 	"github.com/sotah-inc/server/app/pkg/state"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
 	"github.com/sotah-inc/server/app/pkg/store"
+	"github.com/sotah-inc/server/app/pkg/tracing"
 	"github.com/twinj/uuid"
 )
 
@@ -17,6 +19,7 @@ type ComputeAllPricelistHistoriesStateConfig struct {
 }
 
 func NewComputeAllPricelistHistoriesState(
+	ctx context.Context,
 	config ComputeAllPricelistHistoriesStateConfig,
 ) (ComputeAllPricelistHistoriesState, error) {
 	// establishing an initial state
@@ -31,13 +34,14 @@ func NewComputeAllPricelistHistoriesState(
 
 		return ComputeAllPricelistHistoriesState{}, err
 	}
-	sta.computePricelistHistoriesTopic, err = sta.IO.BusClient.FirmTopic(string(subjects.ComputePricelistHistories))
+	sta.computePricelistHistoriesTopic, err = sta.IO.BusClient.FirmTopic(ctx, string(subjects.ComputePricelistHistories))
 	if err != nil {
 		log.Fatalf("Failed to get firm topic: %s", err.Error())
 
 		return ComputeAllPricelistHistoriesState{}, err
 	}
 	sta.receiveComputedPricelistHistoriesTopic, err = sta.IO.BusClient.FirmTopic(
+		ctx,
 		string(subjects.ReceiveComputedPricelistHistories),
 	)
 	if err != nil {
@@ -73,21 +77,25 @@ func (sta ComputeAllPricelistHistoriesState) ListenForComputeAllPricelistHistori
 	stop chan interface{},
 	onStopped chan interface{},
 ) {
-	in := make(chan string)
+	in := make(chan bus.Message)
 	go func() {
-		for {
-			data := <-in
-			if err := sta.Run(data); err != nil {
+		for busMsg := range in {
+			ctx, cancel := busMsg.Context()
+			ctx, span := tracing.Start(ctx, "ComputeAllPricelistHistories")
+			if err := sta.Run(ctx, busMsg.Data); err != nil {
+				span.SetError(err)
 				logging.WithField("error", err.Error()).Error("Failed to run")
 			}
+			span.End()
+			cancel()
 		}
 	}()
 
 	// establishing subscriber config
 	config := bus.SubscribeConfig{
 		Stop: stop,
-		Callback: func(busMsg bus.Message) {
-			in <- busMsg.Data
+		Callback: func(ctx context.Context, busMsg bus.Message) {
+			in <- busMsg
 		},
 		OnReady:   onReady,
 		OnStopped: onStopped,
@@ -95,7 +103,12 @@ func (sta ComputeAllPricelistHistoriesState) ListenForComputeAllPricelistHistori
 
 	// starting up worker for the subscription
 	go func() {
-		if err := sta.IO.BusClient.SubscribeToTopic(string(subjects.ComputeAllPricelistHistories), config); err != nil {
+		err := sta.IO.BusClient.SubscribeToTopic(
+			context.Background(),
+			string(subjects.ComputeAllPricelistHistories),
+			config,
+		)
+		if err != nil {
 			logging.WithField("error", err.Error()).Fatal("Failed to subscribe to topic")
 		}
 	}()
@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"time"
 
 	"github.com/sotah-inc/server/app/pkg/bus"
@@ -11,6 +12,7 @@ import (
 )
 
 func (sta ComputeAllPricelistHistoriesState) PublishToReceivePricelistHistories(
+	ctx context.Context,
 	tuples bus.RegionRealmTimestampTuples,
 ) error {
 	// producing pricelist-histories-compute-intake-requests
@@ -33,14 +35,14 @@ func (sta ComputeAllPricelistHistoriesState) PublishToReceivePricelistHistories(
 
 	// publishing to receive-computed-pricelist-histories
 	logging.Info("Publishing to receive-computed-pricelist-histories")
-	if _, err := sta.IO.BusClient.Publish(sta.receiveComputedPricelistHistoriesTopic, msg); err != nil {
+	if _, err := sta.IO.BusClient.Publish(ctx, sta.receiveComputedPricelistHistoriesTopic, msg); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (sta ComputeAllPricelistHistoriesState) Run(data string) error {
+func (sta ComputeAllPricelistHistoriesState) Run(ctx context.Context, data string) error {
 	// formatting the response-items as tuples for processing
 	tuples, err := bus.NewRegionRealmTimestampTuples(data)
 	if err != nil {
@@ -56,7 +58,7 @@ func (sta ComputeAllPricelistHistoriesState) Run(data string) error {
 
 	// enqueueing them and gathering result jobs
 	startTime := time.Now()
-	responseItems, err := sta.IO.BusClient.BulkRequest(sta.computePricelistHistoriesTopic, messages, 400*time.Second)
+	responseItems, err := sta.IO.BusClient.BulkRequest(ctx, sta.computePricelistHistoriesTopic, messages, 400*time.Second)
 	if err != nil {
 		return err
 	}
@@ -75,7 +77,7 @@ func (sta ComputeAllPricelistHistoriesState) Run(data string) error {
 	}
 
 	// reporting metrics
-	if err := sta.IO.BusClient.PublishMetrics(metric.Metrics{
+	if err := sta.IO.BusClient.PublishMetrics(ctx, metric.Metrics{
 		"compute_all_pricelist_histories_duration": int(int64(time.Since(startTime)) / 1000 / 1000 / 1000),
 		"included_realms":                          len(validatedResponseItems),
 	}); err != nil {
@@ -83,7 +85,7 @@ func (sta ComputeAllPricelistHistoriesState) Run(data string) error {
 	}
 
 	// publishing to receive-computed-pricelist-histories
-	if err := sta.PublishToReceivePricelistHistories(nextTuples); err != nil {
+	if err := sta.PublishToReceivePricelistHistories(ctx, nextTuples); err != nil {
 		return err
 	}
 
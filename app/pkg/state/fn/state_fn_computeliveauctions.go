@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"log"
 
 	"cloud.google.com/go/storage"
@@ -16,7 +17,7 @@ type ComputeLiveAuctionsStateConfig struct {
 	ProjectId string
 }
 
-func NewComputeLiveAuctionsState(config ComputeLiveAuctionsStateConfig) (ComputeLiveAuctionsState, error) {
+func NewComputeLiveAuctionsState(ctx context.Context, config ComputeLiveAuctionsStateConfig) (ComputeLiveAuctionsState, error) {
 	// establishing an initial state
 	sta := ComputeLiveAuctionsState{
 		State: state.NewState(uuid.NewV4(), true),
@@ -39,7 +40,7 @@ func NewComputeLiveAuctionsState(config ComputeLiveAuctionsStateConfig) (Compute
 	}
 
 	sta.auctionsStoreBase = store.NewAuctionsBaseV2(sta.IO.StoreClient, regions.USCentral1, gameversions.Retail)
-	sta.auctionsBucket, err = sta.auctionsStoreBase.GetFirmBucket()
+	sta.auctionsBucket, err = sta.auctionsStoreBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm bucket: %s", err.Error())
 
@@ -47,7 +48,7 @@ func NewComputeLiveAuctionsState(config ComputeLiveAuctionsStateConfig) (Compute
 	}
 
 	sta.liveAuctionsStoreBase = store.NewLiveAuctionsBase(sta.IO.StoreClient, regions.USCentral1, gameversions.Retail)
-	sta.liveAuctionsBucket, err = sta.liveAuctionsStoreBase.GetFirmBucket()
+	sta.liveAuctionsBucket, err = sta.liveAuctionsStoreBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm bucket: %s", err.Error())
 
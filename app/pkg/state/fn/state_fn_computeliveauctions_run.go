@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 
@@ -11,12 +12,12 @@ import (
 	"github.com/sotah-inc/server/app/pkg/logging"
 )
 
-func (sta ComputeLiveAuctionsState) Handle(job bus.LoadRegionRealmTimestampsInJob) bus.Message {
+func (sta ComputeLiveAuctionsState) Handle(ctx context.Context, job bus.LoadRegionRealmTimestampsInJob) bus.Message {
 	m := bus.NewMessage()
 
 	realm, targetTime := job.ToRealmTime()
 
-	obj, err := sta.auctionsStoreBase.GetFirmObject(realm, targetTime, sta.auctionsBucket)
+	obj, err := sta.auctionsStoreBase.GetFirmObject(ctx, realm, targetTime, sta.auctionsBucket)
 	if err != nil {
 		m.Err = err.Error()
 		m.Code = codes.GenericError
@@ -24,7 +25,7 @@ func (sta ComputeLiveAuctionsState) Handle(job bus.LoadRegionRealmTimestampsInJo
 		return m
 	}
 
-	reader, err := obj.NewReader(sta.IO.StoreClient.Context)
+	reader, err := obj.NewReader(ctx)
 	if err != nil {
 		m.Err = err.Error()
 		m.Code = codes.GenericError
@@ -53,7 +54,7 @@ func (sta ComputeLiveAuctionsState) Handle(job bus.LoadRegionRealmTimestampsInJo
 		"realm":         realm.Slug,
 		"last-modified": targetTime.Unix(),
 	}).Info("Parsing into live-auctions")
-	if err := sta.liveAuctionsStoreBase.Handle(aucs, realm, sta.liveAuctionsBucket); err != nil {
+	if err := sta.liveAuctionsStoreBase.Handle(ctx, aucs, realm, sta.liveAuctionsBucket); err != nil {
 		m.Err = err.Error()
 		m.Code = codes.GenericError
 
@@ -79,7 +80,7 @@ func (sta ComputeLiveAuctionsState) Handle(job bus.LoadRegionRealmTimestampsInJo
 	return m
 }
 
-func (sta ComputeLiveAuctionsState) Run(data string) error {
+func (sta ComputeLiveAuctionsState) Run(ctx context.Context, data string) error {
 	var in bus.Message
 	if err := json.Unmarshal([]byte(data), &in); err != nil {
 		return err
@@ -90,9 +91,13 @@ func (sta ComputeLiveAuctionsState) Run(data string) error {
 		return err
 	}
 
-	msg := sta.Handle(job)
+	ctx, span := startRealmJobSpan(ctx, "ComputeLiveAuctions", in, job.RegionName, job.RealmSlug)
+	defer span.End()
+
+	msg := sta.Handle(ctx, job)
+	recordReply(span, msg)
 	msg.ReplyToId = in.ReplyToId
-	if _, err := sta.IO.BusClient.ReplyTo(in, msg); err != nil {
+	if _, err := sta.IO.BusClient.ReplyTo(ctx, in, msg); err != nil {
 		return err
 	}
 
@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"log"
 
 	"cloud.google.com/go/storage"
@@ -17,6 +18,7 @@ type ComputePricelistHistoriesStateConfig struct {
 }
 
 func NewComputePricelistHistoriesState(
+	ctx context.Context,
 	config ComputePricelistHistoriesStateConfig,
 ) (ComputePricelistHistoriesState, error) {
 	// establishing an initial state
@@ -41,7 +43,7 @@ func NewComputePricelistHistoriesState(
 	}
 
 	sta.auctionsStoreBase = store.NewAuctionsBaseV2(sta.IO.StoreClient, regions.USCentral1, gameversions.Retail)
-	sta.auctionsBucket, err = sta.auctionsStoreBase.GetFirmBucket()
+	sta.auctionsBucket, err = sta.auctionsStoreBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm bucket: %s", err.Error())
 
@@ -53,7 +55,7 @@ func NewComputePricelistHistoriesState(
 		regions.USCentral1,
 		gameversions.Retail,
 	)
-	sta.pricelistHistoriesBucket, err = sta.pricelistHistoriesStoreBase.GetFirmBucket()
+	sta.pricelistHistoriesBucket, err = sta.pricelistHistoriesStoreBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm bucket: %s", err.Error())
 
@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 
@@ -11,12 +12,12 @@ import (
 	"github.com/sotah-inc/server/app/pkg/logging"
 )
 
-func (sta ComputePricelistHistoriesState) Handle(job bus.LoadRegionRealmTimestampsInJob) bus.Message {
+func (sta ComputePricelistHistoriesState) Handle(ctx context.Context, job bus.LoadRegionRealmTimestampsInJob) bus.Message {
 	m := bus.NewMessage()
 
 	realm, targetTime := job.ToRealmTime()
 
-	obj, err := sta.auctionsStoreBase.GetFirmObject(realm, targetTime, sta.auctionsBucket)
+	obj, err := sta.auctionsStoreBase.GetFirmObject(ctx, realm, targetTime, sta.auctionsBucket)
 	if err != nil {
 		m.Err = err.Error()
 		m.Code = codes.GenericError
@@ -24,7 +25,7 @@ func (sta ComputePricelistHistoriesState) Handle(job bus.LoadRegionRealmTimestam
 		return m
 	}
 
-	reader, err := obj.NewReader(sta.IO.StoreClient.Context)
+	reader, err := obj.NewReader(ctx)
 	if err != nil {
 		m.Err = err.Error()
 		m.Code = codes.GenericError
@@ -54,6 +55,7 @@ func (sta ComputePricelistHistoriesState) Handle(job bus.LoadRegionRealmTimestam
 		"last-modified": targetTime.Unix(),
 	}).Info("Parsed into live-auctions, handling pricelist-history")
 	normalizedTargetTimestamp, err := sta.pricelistHistoriesStoreBase.Handle(
+		ctx,
 		aucs,
 		targetTime,
 		realm,
@@ -85,7 +87,7 @@ func (sta ComputePricelistHistoriesState) Handle(job bus.LoadRegionRealmTimestam
 	return m
 }
 
-func (sta ComputePricelistHistoriesState) Run(data string) error {
+func (sta ComputePricelistHistoriesState) Run(ctx context.Context, data string) error {
 	var in bus.Message
 	if err := json.Unmarshal([]byte(data), &in); err != nil {
 		return err
@@ -96,9 +98,13 @@ func (sta ComputePricelistHistoriesState) Run(data string) error {
 		return err
 	}
 
-	msg := sta.Handle(job)
+	ctx, span := startRealmJobSpan(ctx, "ComputePricelistHistories", in, job.RegionName, job.RealmSlug)
+	defer span.End()
+
+	msg := sta.Handle(ctx, job)
+	recordReply(span, msg)
 	msg.ReplyToId = in.ReplyToId
-	if _, err := sta.IO.BusClient.ReplyTo(in, msg); err != nil {
+	if _, err := sta.IO.BusClient.ReplyTo(ctx, in, msg); err != nil {
 		return err
 	}
 
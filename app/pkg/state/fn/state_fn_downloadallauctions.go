@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"log"
 
 	"cloud.google.com/go/pubsub"
@@ -13,17 +14,19 @@ import (
 	"github.com/sotah-inc/server/app/pkg/state"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
 	"github.com/sotah-inc/server/app/pkg/store"
+	"github.com/sotah-inc/server/app/pkg/tracing"
 	"github.com/twinj/uuid"
 )
 
 type DownloadAllAuctionsStateConfig struct {
 	ProjectId string
 
-	MessengerHost string
-	MessengerPort int
+	MessengerHost    string
+	MessengerPort    int
+	MessengerConnect messenger.ConnectConfig
 }
 
-func NewDownloadAllAuctionsState(config DownloadAllAuctionsStateConfig) (DownloadAllAuctionsState, error) {
+func NewDownloadAllAuctionsState(ctx context.Context, config DownloadAllAuctionsStateConfig) (DownloadAllAuctionsState, error) {
 	// establishing an initial state
 	sta := DownloadAllAuctionsState{
 		State: state.NewState(uuid.NewV4(), true),
@@ -37,13 +40,14 @@ func NewDownloadAllAuctionsState(config DownloadAllAuctionsStateConfig) (Downloa
 
 		return DownloadAllAuctionsState{}, err
 	}
-	sta.downloadAuctionsTopic, err = sta.IO.BusClient.FirmTopic(string(subjects.DownloadAuctions))
+	sta.downloadAuctionsTopic, err = sta.IO.BusClient.FirmTopic(ctx, string(subjects.DownloadAuctions))
 	if err != nil {
 		log.Fatalf("Failed to get firm topic: %s", err.Error())
 
 		return DownloadAllAuctionsState{}, err
 	}
 	sta.computeAllLiveAuctionsTopic, err = sta.IO.BusClient.FirmTopic(
+		ctx,
 		string(subjects.ComputeAllLiveAuctions),
 	)
 	if err != nil {
@@ -52,6 +56,7 @@ func NewDownloadAllAuctionsState(config DownloadAllAuctionsStateConfig) (Downloa
 		return DownloadAllAuctionsState{}, err
 	}
 	sta.computeAllPricelistHistoriesTopic, err = sta.IO.BusClient.FirmTopic(
+		ctx,
 		string(subjects.ComputeAllPricelistHistories),
 	)
 	if err != nil {
@@ -69,7 +74,7 @@ func NewDownloadAllAuctionsState(config DownloadAllAuctionsStateConfig) (Downloa
 	}
 
 	// connecting to the messenger host
-	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort)
+	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort, config.MessengerConnect)
 	if err != nil {
 		return DownloadAllAuctionsState{}, err
 	}
@@ -83,7 +88,7 @@ func NewDownloadAllAuctionsState(config DownloadAllAuctionsStateConfig) (Downloa
 	}
 
 	sta.bootBase = store.NewBootBase(sta.IO.StoreClient, "us-central1")
-	sta.bootBucket, err = sta.bootBase.GetFirmBucket()
+	sta.bootBucket, err = sta.bootBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm bucket: %s", err.Error())
 
@@ -91,7 +96,7 @@ func NewDownloadAllAuctionsState(config DownloadAllAuctionsStateConfig) (Downloa
 	}
 
 	sta.realmsBase = store.NewRealmsBase(sta.IO.StoreClient, "us-central1", gameversions.Retail)
-	sta.realmsBucket, err = sta.realmsBase.GetFirmBucket()
+	sta.realmsBucket, err = sta.realmsBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm bucket: %s", err.Error())
 
@@ -124,21 +129,25 @@ func (sta DownloadAllAuctionsState) ListenForDownloadAllAuctions(
 	stop chan interface{},
 	onStopped chan interface{},
 ) {
-	in := make(chan interface{})
+	in := make(chan bus.Message)
 	go func() {
-		for {
-			<-in
-			if err := sta.Run(); err != nil {
+		for busMsg := range in {
+			ctx, cancel := busMsg.Context()
+			ctx, span := tracing.Start(ctx, "DownloadAllAuctions")
+			if err := sta.Run(ctx); err != nil {
+				span.SetError(err)
 				logging.WithField("error", err.Error()).Error("Failed to run")
 			}
+			span.End()
+			cancel()
 		}
 	}()
 
 	// establishing subscriber config
 	config := bus.SubscribeConfig{
 		Stop: stop,
-		Callback: func(busMsg bus.Message) {
-			in <- struct{}{}
+		Callback: func(ctx context.Context, busMsg bus.Message) {
+			in <- busMsg
 		},
 		OnReady:   onReady,
 		OnStopped: onStopped,
@@ -146,7 +155,8 @@ func (sta DownloadAllAuctionsState) ListenForDownloadAllAuctions(
 
 	// starting up worker for the subscription
 	go func() {
-		if err := sta.IO.BusClient.SubscribeToTopic(string(subjects.DownloadAllAuctions), config); err != nil {
+		err := sta.IO.BusClient.SubscribeToTopic(context.Background(), string(subjects.DownloadAllAuctions), config)
+		if err != nil {
 			logging.WithField("error", err.Error()).Fatal("Failed to subscribe to topic")
 		}
 	}()
@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"time"
@@ -18,6 +19,7 @@ import (
 )
 
 func (sta DownloadAllAuctionsState) PublishToReceiveRealms(
+	ctx context.Context,
 	regionRealmMap sotah.RegionRealmMap,
 	tuples bus.RegionRealmTimestampTuples,
 ) error {
@@ -25,7 +27,7 @@ func (sta DownloadAllAuctionsState) PublishToReceiveRealms(
 	regionRealmSlugs := tuples.ToRegionRealmSlugs()
 
 	// gathering hell-realms for syncing
-	hellRegionRealms, err := sta.IO.HellClient.GetRegionRealms(regionRealmSlugs, gameversions.Retail)
+	hellRegionRealms, err := sta.IO.HellClient.GetRegionRealms(ctx, regionRealmSlugs, gameversions.Retail)
 	if err != nil {
 		return err
 	}
@@ -42,7 +44,7 @@ func (sta DownloadAllAuctionsState) PublishToReceiveRealms(
 			"downloaded": tuple.TargetTimestamp,
 		}).Info("Setting downloaded value for hell realm")
 	}
-	if err := sta.IO.HellClient.WriteRegionRealms(hellRegionRealms, gameversions.Retail); err != nil {
+	if err := sta.IO.HellClient.WriteRegionRealms(ctx, hellRegionRealms, gameversions.Retail); err != nil {
 		return err
 	}
 
@@ -51,7 +53,7 @@ func (sta DownloadAllAuctionsState) PublishToReceiveRealms(
 		return err
 	}
 
-	req, err := sta.IO.Messenger.Request(string(subjects.ReceiveRealms), jsonEncoded)
+	req, err := sta.IO.Messenger.Request(ctx, string(subjects.ReceiveRealms), jsonEncoded)
 	if err != nil {
 		return err
 	}
@@ -63,15 +65,15 @@ func (sta DownloadAllAuctionsState) PublishToReceiveRealms(
 	return nil
 }
 
-func (sta DownloadAllAuctionsState) Run() error {
-	regions, err := sta.bootBase.GetRegions(sta.bootBucket)
+func (sta DownloadAllAuctionsState) Run(ctx context.Context) error {
+	regions, err := sta.bootBase.GetRegions(ctx, sta.bootBucket)
 	if err != nil {
 		return err
 	}
 
 	regionRealmMap := sotah.RegionRealmMap{}
 	for _, region := range regions {
-		realms, err := sta.realmsBase.GetAllRealms(region.Name, sta.realmsBucket)
+		realms, err := sta.realmsBase.GetAllRealms(ctx, region.Name, sta.realmsBucket)
 		if err != nil {
 			return err
 		}
@@ -88,7 +90,7 @@ func (sta DownloadAllAuctionsState) Run() error {
 
 	// enqueueing them and gathering result jobs
 	startTime := time.Now()
-	responseItems, err := sta.IO.BusClient.BulkRequest(sta.downloadAuctionsTopic, messages, 120*time.Second)
+	responseItems, err := sta.IO.BusClient.BulkRequest(ctx, sta.downloadAuctionsTopic, messages, 120*time.Second)
 	if err != nil {
 		return err
 	}
@@ -118,7 +120,7 @@ func (sta DownloadAllAuctionsState) Run() error {
 	}
 
 	// reporting metrics
-	if err := sta.IO.BusClient.PublishMetrics(metric.Metrics{
+	if err := sta.IO.BusClient.PublishMetrics(ctx, metric.Metrics{
 		"download_all_auctions_duration": int(int64(time.Since(startTime)) / 1000 / 1000 / 1000),
 		"included_realms":                len(validatedResponseItems),
 	}); err != nil {
@@ -133,7 +135,7 @@ func (sta DownloadAllAuctionsState) Run() error {
 
 	// publishing to receive-realms
 	logging.Info("Publishing realms to receive-realms")
-	if err := sta.PublishToReceiveRealms(regionRealmMap, tuples); err != nil {
+	if err := sta.PublishToReceiveRealms(ctx, regionRealmMap, tuples); err != nil {
 		return err
 	}
 
@@ -147,13 +149,13 @@ func (sta DownloadAllAuctionsState) Run() error {
 
 	// publishing to compute-all-live-auctions
 	logging.WithField("tuples", len(tuples)).Info("Publishing to compute-all-live-auctions")
-	if _, err := sta.IO.BusClient.Publish(sta.computeAllLiveAuctionsTopic, encodedTuplesMsg); err != nil {
+	if _, err := sta.IO.BusClient.Publish(ctx, sta.computeAllLiveAuctionsTopic, encodedTuplesMsg); err != nil {
 		return err
 	}
 
 	// publishing to compute-all-pricelist-histories
 	logging.Info("Publishing to compute-all-live-auctions")
-	if _, err := sta.IO.BusClient.Publish(sta.computeAllPricelistHistoriesTopic, encodedTuplesMsg); err != nil {
+	if _, err := sta.IO.BusClient.Publish(ctx, sta.computeAllPricelistHistoriesTopic, encodedTuplesMsg); err != nil {
 		return err
 	}
 
@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"log"
 
 	"cloud.google.com/go/storage"
@@ -18,7 +19,7 @@ type DownloadAuctionsStateConfig struct {
 	ProjectId string
 }
 
-func NewDownloadAuctionsState(config DownloadAuctionsStateConfig) (DownloadAuctionsState, error) {
+func NewDownloadAuctionsState(ctx context.Context, config DownloadAuctionsStateConfig) (DownloadAuctionsState, error) {
 	// establishing an initial state
 	sta := DownloadAuctionsState{
 		State: state.NewState(uuid.NewV4(), true),
@@ -41,7 +42,7 @@ func NewDownloadAuctionsState(config DownloadAuctionsStateConfig) (DownloadAucti
 	}
 
 	sta.bootBase = store.NewBootBase(sta.IO.StoreClient, "us-central1")
-	sta.bootBucket, err = sta.bootBase.GetFirmBucket()
+	sta.bootBucket, err = sta.bootBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm bucket: %s", err.Error())
 
@@ -49,7 +50,7 @@ func NewDownloadAuctionsState(config DownloadAuctionsStateConfig) (DownloadAucti
 	}
 
 	sta.realmsBase = store.NewRealmsBase(sta.IO.StoreClient, "us-central1", gameversions.Retail)
-	sta.realmsBucket, err = sta.realmsBase.GetFirmBucket()
+	sta.realmsBucket, err = sta.realmsBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm bucket: %s", err.Error())
 
@@ -57,7 +58,7 @@ func NewDownloadAuctionsState(config DownloadAuctionsStateConfig) (DownloadAucti
 	}
 
 	sta.auctionsStoreBase = store.NewAuctionsBaseV2(sta.IO.StoreClient, regions.USCentral1, gameversions.Retail)
-	sta.auctionsBucket, err = sta.auctionsStoreBase.GetFirmBucket()
+	sta.auctionsBucket, err = sta.auctionsStoreBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm bucket: %s", err.Error())
 
@@ -69,21 +70,21 @@ func NewDownloadAuctionsState(config DownloadAuctionsStateConfig) (DownloadAucti
 		regions.USCentral1,
 		gameversions.Retail,
 	)
-	sta.auctionsManifestBucket, err = sta.auctionManifestStoreBase.GetFirmBucket()
+	sta.auctionsManifestBucket, err = sta.auctionManifestStoreBase.GetFirmBucket(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get firm bucket: %s", err.Error())
 
 		return DownloadAuctionsState{}, err
 	}
 
-	sta.regions, err = sta.bootBase.GetRegions(sta.bootBucket)
+	sta.regions, err = sta.bootBase.GetRegions(ctx, sta.bootBucket)
 	if err != nil {
 		log.Fatalf("Failed to get regions: %s", err.Error())
 
 		return DownloadAuctionsState{}, err
 	}
 
-	blizzardCredentials, err := sta.bootBase.GetBlizzardCredentials(sta.bootBucket)
+	blizzardCredentials, err := sta.bootBase.GetBlizzardCredentials(ctx, sta.bootBucket)
 	if err != nil {
 		log.Fatalf("Failed to get blizzard-credentials: %s", err.Error())
 
@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -33,7 +34,7 @@ func (sta DownloadAuctionsState) ResolveRealm(job bus.CollectAuctionsJob) (sotah
 	return realm, nil
 }
 
-func (sta DownloadAuctionsState) Handle(job bus.CollectAuctionsJob) bus.Message {
+func (sta DownloadAuctionsState) Handle(ctx context.Context, job bus.CollectAuctionsJob) bus.Message {
 	m := bus.NewMessage()
 
 	realm, err := sta.ResolveRealm(job)
@@ -102,7 +103,7 @@ func (sta DownloadAuctionsState) Handle(job bus.CollectAuctionsJob) bus.Message
 	lastModifiedTimestamp := sotah.UnixTimestamp(lastModifiedTime.Unix())
 
 	obj := sta.auctionsStoreBase.GetObject(realm, lastModifiedTime, sta.auctionsBucket)
-	exists, err := sta.auctionsStoreBase.ObjectExists(obj)
+	exists, err := sta.auctionsStoreBase.ObjectExists(ctx, obj)
 	if err != nil {
 		m.Err = err.Error()
 		m.Code = codes.GenericError
@@ -155,7 +156,7 @@ func (sta DownloadAuctionsState) Handle(job bus.CollectAuctionsJob) bus.Message
 		"realm":         realm.Slug,
 		"last-modified": lastModifiedTimestamp,
 	}).Info("Parsed, saving to raw-auctions store")
-	if err := sta.auctionsStoreBase.Handle(resp.Body, lastModifiedTime, realm, sta.auctionsBucket); err != nil {
+	if err := sta.auctionsStoreBase.Handle(ctx, resp.Body, lastModifiedTime, realm, sta.auctionsBucket); err != nil {
 		m.Err = err.Error()
 		m.Code = codes.GenericError
 
@@ -167,7 +168,7 @@ func (sta DownloadAuctionsState) Handle(job bus.CollectAuctionsJob) bus.Message
 		"realm":         realm.Slug,
 		"last-modified": lastModifiedTimestamp,
 	}).Info("Saved, adding to auction-manifest file")
-	if err := sta.auctionManifestStoreBase.Handle(lastModifiedTimestamp, realm, sta.auctionsManifestBucket); err != nil {
+	if err := sta.auctionManifestStoreBase.Handle(ctx, lastModifiedTimestamp, realm, sta.auctionsManifestBucket); err != nil {
 		m.Err = err.Error()
 		m.Code = codes.GenericError
 
@@ -191,7 +192,7 @@ func (sta DownloadAuctionsState) Handle(job bus.CollectAuctionsJob) bus.Message
 	return m
 }
 
-func (sta DownloadAuctionsState) Run(data string) error {
+func (sta DownloadAuctionsState) Run(ctx context.Context, data string) error {
 	var in bus.Message
 	if err := json.Unmarshal([]byte(data), &in); err != nil {
 		return err
@@ -202,9 +203,13 @@ func (sta DownloadAuctionsState) Run(data string) error {
 		return err
 	}
 
-	msg := sta.Handle(job)
+	ctx, span := startRealmJobSpan(ctx, "DownloadAuctions", in, job.RegionName, job.RealmSlug)
+	defer span.End()
+
+	msg := sta.Handle(ctx, job)
+	recordReply(span, msg)
 	msg.ReplyToId = in.ReplyToId
-	if _, err := sta.IO.BusClient.ReplyTo(in, msg); err != nil {
+	if _, err := sta.IO.BusClient.ReplyTo(ctx, in, msg); err != nil {
 		return err
 	}
 
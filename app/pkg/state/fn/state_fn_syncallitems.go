@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"log"
 
 	"cloud.google.com/go/pubsub"
@@ -8,6 +9,7 @@ import (
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/state"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
+	"github.com/sotah-inc/server/app/pkg/tracing"
 	"github.com/twinj/uuid"
 )
 
@@ -15,7 +17,7 @@ type SyncAllItemsStateConfig struct {
 	ProjectId string
 }
 
-func NewSyncAllItemsState(config SyncAllItemsStateConfig) (SyncAllItemsState, error) {
+func NewSyncAllItemsState(ctx context.Context, config SyncAllItemsStateConfig) (SyncAllItemsState, error) {
 	// establishing an initial state
 	sta := SyncAllItemsState{
 		State: state.NewState(uuid.NewV4(), true),
@@ -28,19 +30,19 @@ func NewSyncAllItemsState(config SyncAllItemsStateConfig) (SyncAllItemsState, er
 
 		return SyncAllItemsState{}, err
 	}
-	sta.syncItemsTopic, err = sta.IO.BusClient.FirmTopic(string(subjects.SyncItems))
+	sta.syncItemsTopic, err = sta.IO.BusClient.FirmTopic(ctx, string(subjects.SyncItems))
 	if err != nil {
 		log.Fatalf("Failed to get firm topic: %s", err.Error())
 
 		return SyncAllItemsState{}, err
 	}
-	sta.syncItemIconsTopic, err = sta.IO.BusClient.FirmTopic(string(subjects.SyncItemIcons))
+	sta.syncItemIconsTopic, err = sta.IO.BusClient.FirmTopic(ctx, string(subjects.SyncItemIcons))
 	if err != nil {
 		log.Fatalf("Failed to get firm topic: %s", err.Error())
 
 		return SyncAllItemsState{}, err
 	}
-	sta.filterInItemsToSyncTopic, err = sta.IO.BusClient.FirmTopic(string(subjects.FilterInItemsToSync))
+	sta.filterInItemsToSyncTopic, err = sta.IO.BusClient.FirmTopic(ctx, string(subjects.FilterInItemsToSync))
 	if err != nil {
 		log.Fatalf("Failed to get firm topic: %s", err.Error())
 
@@ -71,16 +73,21 @@ func (sta SyncAllItemsState) ListenForSyncAllItems(
 	in := make(chan bus.Message)
 	go func() {
 		for busMsg := range in {
-			if err := sta.Run(busMsg); err != nil {
+			ctx, cancel := busMsg.Context()
+			ctx, span := tracing.Start(ctx, "SyncAllItems")
+			if err := sta.Run(ctx, busMsg); err != nil {
+				span.SetError(err)
 				logging.WithField("error", err.Error()).Error("Failed to run")
 			}
+			span.End()
+			cancel()
 		}
 	}()
 
 	// establishing subscriber config
 	config := bus.SubscribeConfig{
 		Stop: stop,
-		Callback: func(busMsg bus.Message) {
+		Callback: func(ctx context.Context, busMsg bus.Message) {
 			in <- busMsg
 		},
 		OnReady:   onReady,
@@ -89,7 +96,8 @@ func (sta SyncAllItemsState) ListenForSyncAllItems(
 
 	// starting up worker for the subscription
 	go func() {
-		if err := sta.IO.BusClient.SubscribeToTopic(string(subjects.SyncAllItems), config); err != nil {
+		err := sta.IO.BusClient.SubscribeToTopic(context.Background(), string(subjects.SyncAllItems), config)
+		if err != nil {
 			logging.WithField("error", err.Error()).Fatal("Failed to subscribe to topic")
 		}
 	}()
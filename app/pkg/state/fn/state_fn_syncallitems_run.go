@@ -1,6 +1,7 @@
 package fn
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -15,7 +16,7 @@ import (
 	"github.com/sotah-inc/server/app/pkg/metric"
 )
 
-func (sta SyncAllItemsState) HandleItemIds(ids blizzard.ItemIds) error {
+func (sta SyncAllItemsState) HandleItemIds(ctx context.Context, ids blizzard.ItemIds) error {
 	if len(ids) == 0 {
 		logging.Info("No item-ids in sync-payload, skipping")
 
@@ -35,7 +36,7 @@ func (sta SyncAllItemsState) HandleItemIds(ids blizzard.ItemIds) error {
 
 	// enqueueing them
 	logging.WithField("messages", len(messages)).Info("Bulk-requesting with messages")
-	responses, err := sta.IO.BusClient.BulkRequest(sta.syncItemsTopic, messages, 60*time.Second)
+	responses, err := sta.IO.BusClient.BulkRequest(ctx, sta.syncItemsTopic, messages, 60*time.Second)
 	if err != nil {
 		return err
 	}
@@ -55,7 +56,7 @@ func (sta SyncAllItemsState) HandleItemIds(ids blizzard.ItemIds) error {
 	return nil
 }
 
-func (sta SyncAllItemsState) HandleItemIcons(iconsMap map[string]blizzard.ItemIds) error {
+func (sta SyncAllItemsState) HandleItemIcons(ctx context.Context, iconsMap map[string]blizzard.ItemIds) error {
 	if len(iconsMap) == 0 {
 		logging.Info("No icons in sync-payload, skipping")
 
@@ -75,7 +76,7 @@ func (sta SyncAllItemsState) HandleItemIcons(iconsMap map[string]blizzard.ItemId
 
 	// enqueueing them
 	logging.WithField("messages", len(messages)).Info("Bulk-requesting with messages")
-	responses, err := sta.IO.BusClient.BulkRequest(sta.syncItemIconsTopic, messages, 120*time.Second)
+	responses, err := sta.IO.BusClient.BulkRequest(ctx, sta.syncItemIconsTopic, messages, 120*time.Second)
 	if err != nil {
 		return err
 	}
@@ -95,7 +96,7 @@ func (sta SyncAllItemsState) HandleItemIcons(iconsMap map[string]blizzard.ItemId
 	return nil
 }
 
-func (sta SyncAllItemsState) Run(in bus.Message) error {
+func (sta SyncAllItemsState) Run(ctx context.Context, in bus.Message) error {
 	// validating that the provided item-ids are valid
 	providedItemIds, err := blizzard.NewItemIds(in.Data)
 	if err != nil {
@@ -109,7 +110,7 @@ func (sta SyncAllItemsState) Run(in bus.Message) error {
 	startTime := time.Now()
 
 	// filtering in items-to-sync
-	response, err := sta.IO.BusClient.Request(sta.filterInItemsToSyncTopic, encodedItemIds, 30*time.Second)
+	response, err := sta.IO.BusClient.Request(ctx, sta.filterInItemsToSyncTopic, encodedItemIds, 30*time.Second)
 	if err != nil {
 		return err
 	}
@@ -126,17 +127,17 @@ func (sta SyncAllItemsState) Run(in bus.Message) error {
 	}
 
 	// handling item-ids
-	if err := sta.HandleItemIds(syncPayload.Ids); err != nil {
+	if err := sta.HandleItemIds(ctx, syncPayload.Ids); err != nil {
 		return err
 	}
 
 	// handling item-icons
-	if err := sta.HandleItemIcons(syncPayload.IconIdsMap); err != nil {
+	if err := sta.HandleItemIcons(ctx, syncPayload.IconIdsMap); err != nil {
 		return err
 	}
 
 	// reporting metrics
-	if err := sta.IO.BusClient.PublishMetrics(metric.Metrics{
+	if err := sta.IO.BusClient.PublishMetrics(ctx, metric.Metrics{
 		"sync_all_items_duration": int(int64(time.Since(startTime)) / 1000 / 1000 / 1000),
 		"sync_all_items_ids":      len(syncPayload.Ids),
 		"sync_all_items_icons":    len(syncPayload.IconIdsMap),
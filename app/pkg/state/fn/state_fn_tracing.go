@@ -0,0 +1,33 @@
+package fn
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sotah-inc/server/app/pkg/bus"
+	"github.com/sotah-inc/server/app/pkg/bus/codes"
+	"github.com/sotah-inc/server/app/pkg/tracing"
+)
+
+// startRealmJobSpan carries on the trace of the requester of a job for a realm
+func startRealmJobSpan(
+	ctx context.Context,
+	name string,
+	in bus.Message,
+	regionName string,
+	realmSlug string,
+) (context.Context, tracing.Span) {
+	ctx, span := tracing.Start(in.TraceContext(ctx), name)
+	span.SetAttribute(tracing.RegionAttribute, regionName)
+	span.SetAttribute(tracing.RealmAttribute, realmSlug)
+
+	return ctx, span
+}
+
+// recordReply records the outcome of a job on its span, from the reply to its requester
+func recordReply(span tracing.Span, msg bus.Message) {
+	span.SetAttribute(tracing.CodeAttribute, int(msg.Code))
+	if msg.Code != codes.Ok {
+		span.SetError(errors.New(msg.Err))
+	}
+}
@@ -1,7 +1,10 @@
 package state
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/sotah-inc/server/app/pkg/blizzard"
@@ -9,6 +12,7 @@ import (
 	"github.com/sotah-inc/server/app/pkg/database"
 	dCodes "github.com/sotah-inc/server/app/pkg/database/codes"
 	"github.com/sotah-inc/server/app/pkg/diskstore"
+	"github.com/sotah-inc/server/app/pkg/health"
 	"github.com/sotah-inc/server/app/pkg/hell"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/messenger"
@@ -28,10 +32,57 @@ type requestError struct {
 
 // databases
 type Databases struct {
-	PricelistHistoryDatabases database.PricelistHistoryDatabases
-	LiveAuctionsDatabases     database.LiveAuctionsDatabases
-	ItemsDatabase             database.ItemsDatabase
-	MetaDatabase              database.MetaDatabase
+	PricelistHistoryDatabases       database.PricelistHistoryDatabases
+	RegionPricelistHistoryDatabases database.RegionPricelistHistoryDatabases
+	LiveAuctionsDatabases           database.LiveAuctionsDatabases
+	ItemsDatabase                   database.ItemsDatabase
+	MetaDatabase                    database.MetaDatabase
+	RecipesDatabase                 database.RecipesDatabase
+	APIKeysDatabase                 database.APIKeysDatabase
+}
+
+// Close closes every opened database, returning the first error after attempting all of them
+func (d Databases) Close() error {
+	logging.Info("Closing databases")
+
+	var firstErr error
+	closers := []func() error{
+		d.PricelistHistoryDatabases.Close,
+		d.RegionPricelistHistoryDatabases.Close,
+		d.LiveAuctionsDatabases.Close,
+		d.ItemsDatabase.Close,
+		d.MetaDatabase.Close,
+		d.RecipesDatabase.Close,
+		d.APIKeysDatabase.Close,
+	}
+	for _, closeFunc := range closers {
+		if err := closeFunc(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+/*
+Ping checks that the databases of fixed size are still open, leaving out the pricelist-history shards since those are
+opened and pruned while taking in data
+*/
+func (d Databases) Ping() error {
+	pingers := []func() error{
+		d.LiveAuctionsDatabases.Ping,
+		d.ItemsDatabase.Ping,
+		d.MetaDatabase.Ping,
+		d.RecipesDatabase.Ping,
+		d.APIKeysDatabase.Ping,
+	}
+	for _, ping := range pingers {
+		if err := ping(); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // io bundle
@@ -54,6 +105,7 @@ type busListener struct {
 	onReady   chan interface{}
 	stop      chan interface{}
 	onStopped chan interface{}
+	ready     health.Flag
 }
 
 type SubjectBusListeners map[subjects.Subject]busListenFunc
@@ -63,9 +115,10 @@ func NewBusListeners(sListeners SubjectBusListeners) BusListeners {
 	for subj, l := range sListeners {
 		out[subj] = busListener{
 			call:      l,
-			onStopped: make(chan interface{}),
+			onStopped: make(chan interface{}, 1),
 			onReady:   make(chan interface{}),
 			stop:      make(chan interface{}),
+			ready:     health.NewFlag(fmt.Sprintf("bus-listener %s", subj)),
 		}
 	}
 
@@ -80,15 +133,32 @@ func (ls BusListeners) Listen() {
 	for _, l := range ls {
 		l.call(l.onReady, l.stop, l.onStopped)
 		<-l.onReady
+		l.ready.Raise()
 	}
 }
 
+// Check reports the first bus-listener that is not subscribed, for readiness
+func (ls BusListeners) Check() error {
+	for _, l := range ls {
+		if err := l.ready.Check(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stop signals every bus-listener at once and waits for each to finish its in-flight callbacks
 func (ls BusListeners) Stop() {
 	logging.WithField("count", len(ls)).Info("Stopping bus-listeners")
 
 	for _, l := range ls {
-		l.stop <- struct{}{}
+		close(l.stop)
+	}
+
+	for _, l := range ls {
 		<-l.onStopped
+		l.ready.Lower()
 	}
 }
 
@@ -127,11 +197,12 @@ func (ls Listeners) Listen() error {
 	return nil
 }
 
+// Stop unsubscribes every listener without blocking on listeners that never subscribed
 func (ls Listeners) Stop() {
 	logging.Info("Stopping listeners")
 
 	for _, l := range ls {
-		l.stopChan <- struct{}{}
+		close(l.stopChan)
 	}
 }
 
@@ -152,6 +223,21 @@ type State struct {
 	Statuses sotah.Statuses
 }
 
+// CheckStatuses reports whether statuses were loaded, including one for every region, for readiness
+func (sta State) CheckStatuses() error {
+	if len(sta.Statuses) == 0 {
+		return errors.New("no statuses were loaded")
+	}
+
+	for _, reg := range sta.Regions {
+		if _, ok := sta.Statuses[reg.Name]; !ok {
+			return fmt.Errorf("no status was loaded for region %s", reg.Name)
+		}
+	}
+
+	return nil
+}
+
 type RealmTimeTuple struct {
 	Realm      sotah.Realm
 	TargetTime time.Time
@@ -180,6 +266,17 @@ func DatabaseCodeToMessengerCode(dCode dCodes.Code) mCodes.Code {
 	return mCodes.Blank
 }
 
+// fromDatabase serves a database query as an endpoint, translating its codes for the messenger
+func fromDatabase[Req any, Resp any](
+	query func(ctx context.Context, req Req) (Resp, dCodes.Code, error),
+) messenger.HandlerFunc[Req, Resp] {
+	return func(ctx context.Context, req Req) (Resp, mCodes.Code, error) {
+		resp, respCode, err := query(ctx, req)
+
+		return resp, DatabaseCodeToMessengerCode(respCode), err
+	}
+}
+
 func NewRealmModificationDatesRequest(data []byte) (RealmModificationDatesRequest, error) {
 	var r RealmModificationDatesRequest
 	if err := json.Unmarshal(data, &r); err != nil {
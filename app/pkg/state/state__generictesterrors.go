@@ -1,22 +1,25 @@
 package state
 
 import (
-	nats "github.com/nats-io/go-nats"
+	"context"
+	"errors"
+
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	"github.com/sotah-inc/server/app/pkg/messenger/codes"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
 )
 
 func (sta State) ListenForGenericTestErrors(stop ListenStopChan) error {
-	err := sta.IO.Messenger.Subscribe(string(subjects.GenericTestErrors), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-		m.Err = "Test error"
-		m.Code = codes.GenericError
-		sta.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.GenericTestErrors),
+		stop,
+		messenger.Endpoint[messenger.Empty, messenger.Empty]{
+			Decode: messenger.DecodeEmpty,
+			Encode: messenger.EncodeEmpty,
+			Handle: func(_ context.Context, _ messenger.Empty) (messenger.Empty, codes.Code, error) {
+				return messenger.Empty{}, codes.GenericError, errors.New("Test error")
+			},
+		},
+	)
 }
@@ -1,12 +1,17 @@
 package state
 
 import (
+	"context"
 	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/bus"
 	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
 	"github.com/sotah-inc/server/app/pkg/diskstore"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/messenger"
@@ -24,8 +29,9 @@ type APIStateConfig struct {
 
 	GCloudProjectID string
 
-	MessengerHost string
-	MessengerPort int
+	MessengerHost    string
+	MessengerPort    int
+	MessengerConnect messenger.ConnectConfig
 
 	DiskStoreCacheDir string
 
@@ -33,14 +39,20 @@ type APIStateConfig struct {
 	BlizzardClientSecret string
 
 	ItemsDatabaseDir string
+
+	APIKeysDatabaseDir string
+
+	// where session secrets are kept across restarts, and how often they are rotated, where zero never rotates them
+	SessionSecretsFilepath string
+	SessionSecretRotation  time.Duration
 }
 
-func NewAPIState(config APIStateConfig) (APIState, error) {
+func NewAPIState(ctx context.Context, config APIStateConfig) (APIState, error) {
 	// establishing an initial state
 	apiState := APIState{
-		State: NewState(uuid.NewV4(), config.SotahConfig.UseGCloud),
+		State:                 NewState(uuid.NewV4(), config.SotahConfig.UseGCloud),
+		modificationDatesLock: &sync.RWMutex{},
 	}
-	apiState.SessionSecret = uuid.NewV4()
 
 	// setting api-state from config, including filtering in regions based on config whitelist
 	apiState.Regions = config.SotahConfig.FilterInRegions(config.SotahConfig.Regions)
@@ -82,7 +94,7 @@ func NewAPIState(config APIStateConfig) (APIState, error) {
 	}
 
 	// connecting to the messenger host
-	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort)
+	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort, config.MessengerConnect)
 	if err != nil {
 		return APIState{}, err
 	}
@@ -99,7 +111,7 @@ func NewAPIState(config APIStateConfig) (APIState, error) {
 	apiState.IO.Resolver = resolver.NewResolver(blizzardClient, apiState.IO.Reporter)
 
 	// filling state with region statuses
-	for job := range apiState.IO.Resolver.GetStatuses(apiState.Regions) {
+	for job := range apiState.IO.Resolver.GetStatuses(ctx, apiState.Regions) {
 		if job.Err != nil {
 			return APIState{}, job.Err
 		}
@@ -129,12 +141,53 @@ func NewAPIState(config APIStateConfig) (APIState, error) {
 	apiState.ItemClasses = itemClasses
 
 	// loading the items database
+	if _, err := database.MigrateSchemas(config.ItemsDatabaseDir, schemakinds.Items); err != nil {
+		return APIState{}, err
+	}
 	itemsDatabase, err := database.NewItemsDatabase(config.ItemsDatabaseDir)
 	if err != nil {
 		return APIState{}, err
 	}
 	apiState.IO.Databases.ItemsDatabase = itemsDatabase
 
+	// loading the recipes database and seeding it with configured recipes
+	if _, err := database.MigrateSchemas(config.ItemsDatabaseDir, schemakinds.Recipes); err != nil {
+		return APIState{}, err
+	}
+	recipesDatabase, err := database.NewRecipesDatabase(config.ItemsDatabaseDir)
+	if err != nil {
+		return APIState{}, err
+	}
+	apiState.IO.Databases.RecipesDatabase = recipesDatabase
+
+	recipes, err := config.SotahConfig.ResolveRecipes()
+	if err != nil {
+		return APIState{}, err
+	}
+	if err := recipesDatabase.PersistRecipes(recipes); err != nil {
+		return APIState{}, err
+	}
+
+	// loading the api-keys database, which is kept by the api alone
+	apiKeysDatabase, err := database.NewAPIKeysDatabase(config.APIKeysDatabaseDir)
+	if err != nil {
+		return APIState{}, err
+	}
+	apiState.IO.Databases.APIKeysDatabase = apiKeysDatabase
+
+	// loading session secrets, so that sessions outlive restarts
+	if err := util.EnsureDirExists(filepath.Dir(config.SessionSecretsFilepath)); err != nil {
+		return APIState{}, err
+	}
+	apiState.SessionSecrets, err = NewSessionSecretKeeper(
+		ctx,
+		NewSessionSecretsFile(config.SessionSecretsFilepath),
+		config.SessionSecretRotation,
+	)
+	if err != nil {
+		return APIState{}, err
+	}
+
 	// gathering profession icons
 	for i, prof := range apiState.Professions {
 		apiState.Professions[i].IconURL = blizzard.DefaultGetItemIconURL(prof.Icon)
@@ -148,6 +201,12 @@ func NewAPIState(config APIStateConfig) (APIState, error) {
 		subjects.Items:                       apiState.ListenForItems,
 		subjects.ItemsQuery:                  apiState.ListenForItemsQuery,
 		subjects.QueryRealmModificationDates: apiState.ListenForQueryRealmModificationDates,
+		subjects.CraftingProfits:             apiState.ListenForCraftingProfits,
+		subjects.APIKeysResolve:              apiState.ListenForAPIKeysResolve,
+		subjects.APIKeysUsage:                apiState.ListenForAPIKeysUsage,
+		subjects.APIKeysIssue:                apiState.ListenForAPIKeysIssue,
+		subjects.APIKeysRevoke:               apiState.ListenForAPIKeysRevoke,
+		subjects.APIKeysList:                 apiState.ListenForAPIKeysList,
 	})
 
 	apiState.RegionRealmModificationDates = sotah.RegionRealmModificationDates{}
@@ -158,13 +217,22 @@ func NewAPIState(config APIStateConfig) (APIState, error) {
 type APIState struct {
 	State
 
-	SessionSecret uuid.UUID
-	ItemClasses   blizzard.ItemClasses
-	Expansions    []sotah.Expansion
-	Professions   []sotah.Profession
-	ItemBlacklist ItemBlacklist
+	SessionSecrets SessionSecretKeeper
+	ItemClasses    blizzard.ItemClasses
+	Expansions     []sotah.Expansion
+	Professions    []sotah.Profession
+	ItemBlacklist  ItemBlacklist
 
+	// guards RegionRealmModificationDates, which the collector writes while listeners and health read
 	RegionRealmModificationDates sotah.RegionRealmModificationDates
+	modificationDatesLock        *sync.RWMutex
+}
+
+func (sta APIState) RealmModificationDates() sotah.RegionRealmModificationDates {
+	sta.modificationDatesLock.RLock()
+	defer sta.modificationDatesLock.RUnlock()
+
+	return sta.RegionRealmModificationDates.Clone()
 }
 
 type ItemBlacklist []blizzard.ItemID
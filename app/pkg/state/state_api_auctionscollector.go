@@ -1,29 +1,43 @@
 package state
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/health"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/metric"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
 )
 
-func (sta APIState) StartCollector(stopChan sotah.WorkerStopChan) sotah.WorkerStopChan {
-	sta.collectRegions()
+// CollectorInterval - also the deadline of each collection, since one running longer is stuck
+const CollectorInterval = 20 * time.Minute
+
+func (sta APIState) collectRegionsWithDeadline() {
+	ctx, cancel := context.WithTimeout(context.Background(), CollectorInterval)
+	defer cancel()
+
+	sta.collectRegions(ctx)
+}
+
+func (sta APIState) StartCollector(stopChan sotah.WorkerStopChan, heartbeat health.Heartbeat) sotah.WorkerStopChan {
+	sta.collectRegionsWithDeadline()
 
 	onStop := make(sotah.WorkerStopChan)
 	go func() {
-		ticker := time.NewTicker(20 * time.Minute)
+		ticker := time.NewTicker(CollectorInterval)
 
 		logging.Info("Starting collector")
 	outer:
 		for {
 			select {
 			case <-ticker.C:
+				heartbeat.Beat()
+
 				// refreshing the access-token for the Resolver blizz client
 				nextClient, err := sta.IO.Resolver.BlizzardClient.RefreshFromHTTP(blizzard.OAuthTokenEndpoint)
 				if err != nil {
@@ -33,7 +47,7 @@ func (sta APIState) StartCollector(stopChan sotah.WorkerStopChan) sotah.WorkerSt
 				}
 				sta.IO.Resolver.BlizzardClient = nextClient
 
-				sta.collectRegions()
+				sta.collectRegionsWithDeadline()
 			case <-stopChan:
 				ticker.Stop()
 
@@ -47,7 +61,7 @@ func (sta APIState) StartCollector(stopChan sotah.WorkerStopChan) sotah.WorkerSt
 	return onStop
 }
 
-func (sta APIState) collectRegions() {
+func (sta APIState) collectRegions(ctx context.Context) {
 	logging.Info("Collecting regions")
 
 	// for subsequently pushing to the live-auctions-intake listener
@@ -74,8 +88,9 @@ func (sta APIState) collectRegions() {
 				"realms": len(status.Realms),
 			}).Debug("Downloading region")
 			for getAuctionsJob := range sta.IO.Resolver.GetAuctionsForRealms(
+				ctx,
 				status.Realms,
-				sta.RegionRealmModificationDates,
+				sta.RealmModificationDates(),
 			) {
 				if getAuctionsJob.Err != nil {
 					logrus.WithFields(getAuctionsJob.ToLogrusFields()).Error("Failed to fetch auctions")
@@ -111,6 +126,7 @@ func (sta APIState) collectRegions() {
 			regionRealmTimestamps[job.Realm.Region.Name][job.Realm.Slug] = job.TargetTime.Unix()
 
 			// updating the realm last-modified in realm-modification-dates
+			sta.modificationDatesLock.Lock()
 			realmModDates := sta.RegionRealmModificationDates.Get(job.Realm.Region.Name, job.Realm.Slug)
 			realmModDates.Downloaded = job.TargetTime.Unix()
 
@@ -119,6 +135,7 @@ func (sta APIState) collectRegions() {
 				job.Realm.Slug,
 				realmModDates,
 			)
+			sta.modificationDatesLock.Unlock()
 
 			// appending to received item-ids
 			for _, itemId := range job.ItemIds {
@@ -172,7 +189,7 @@ func (sta APIState) collectRegions() {
 					return sotah.ItemsMap{}, false, err
 				}
 
-				getItemsJobs := sta.IO.Resolver.GetItems(primaryRegion, newItemIds)
+				getItemsJobs := sta.IO.Resolver.GetItems(ctx, primaryRegion, newItemIds)
 				for job := range getItemsJobs {
 					if job.Err != nil {
 						logging.WithFields(logrus.Fields{
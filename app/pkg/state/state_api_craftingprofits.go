@@ -0,0 +1,202 @@
+package state
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	"github.com/sotah-inc/server/app/pkg/messenger/codes"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/state/subjects"
+	"github.com/sotah-inc/server/app/pkg/util"
+)
+
+func newCraftingProfitsRequest(data []byte) (craftingProfitsRequest, error) {
+	r := &craftingProfitsRequest{}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return craftingProfitsRequest{}, err
+	}
+
+	return *r, nil
+}
+
+type craftingProfitsRequest struct {
+	RegionName  blizzard.RegionName `json:"region_name"`
+	RealmSlug   blizzard.RealmSlug  `json:"realm_slug"`
+	Profession  string              `json:"profession"`
+	RecipeIds   []sotah.RecipeID    `json:"recipe_ids"`
+	LowerBounds int64               `json:"lower_bounds"`
+	UpperBounds int64               `json:"upper_bounds"`
+}
+
+func (r craftingProfitsRequest) Validate() error {
+	if len(r.RecipeIds) == 0 && r.Profession == "" {
+		return errors.New("either recipe ids or a profession must be provided")
+	}
+	if r.UpperBounds > 0 && r.LowerBounds > r.UpperBounds {
+		return errors.New("lower bounds cannot be after upper bounds")
+	}
+
+	return nil
+}
+
+func (r craftingProfitsRequest) resolveRecipes(sta State) (sotah.Recipes, error) {
+	if len(r.RecipeIds) > 0 {
+		return sta.IO.Databases.RecipesDatabase.FindRecipes(r.RecipeIds)
+	}
+
+	recipes, err := sta.IO.Databases.RecipesDatabase.GetRecipes()
+	if err != nil {
+		return sotah.Recipes{}, err
+	}
+
+	return recipes.FilterInProfession(r.Profession), nil
+}
+
+func (r craftingProfitsRequest) resolve(ctx context.Context, sta State) (craftingProfitsResponse, requestError) {
+	recipes, err := r.resolveRecipes(sta)
+	if err != nil {
+		return craftingProfitsResponse{}, requestError{codes.GenericError, err.Error()}
+	}
+
+	if len(recipes) == 0 {
+		return craftingProfitsResponse{}, requestError{codes.NotFound, "No recipes found"}
+	}
+
+	// gathering current prices from live-auctions
+	iPrices, err := sta.NewPricelist(ctx, database.GetPricelistRequest{
+		RegionName: r.RegionName,
+		RealmSlug:  r.RealmSlug,
+		ItemIds:    recipes.ItemIds(),
+	})
+	if err != nil {
+		return craftingProfitsResponse{}, requestError{codes.GenericError, err.Error()}
+	}
+
+	res := craftingProfitsResponse{
+		Recipes: recipes,
+		Profits: sotah.NewRecipeProfits(recipes, iPrices),
+		History: sotah.RecipeProfitHistories{},
+	}
+
+	// optionally gathering historical margins from pricelist-histories
+	if r.UpperBounds == 0 {
+		return res, requestError{codes.Ok, ""}
+	}
+
+	ipHistories, err := sta.NewPricelistHistory(ctx, database.GetPricelistHistoryRequest{
+		RegionName:  r.RegionName,
+		RealmSlug:   r.RealmSlug,
+		ItemIds:     recipes.ItemIds(),
+		LowerBounds: r.LowerBounds,
+		UpperBounds: r.UpperBounds,
+	})
+	if err != nil {
+		return craftingProfitsResponse{}, requestError{codes.GenericError, err.Error()}
+	}
+
+	for _, recipe := range recipes {
+		res.History[recipe.ID] = sotah.NewRecipeProfitHistory(recipe, ipHistories)
+	}
+
+	return res, requestError{codes.Ok, ""}
+}
+
+type craftingProfitsResponse struct {
+	Recipes sotah.Recipes               `json:"recipes"`
+	Profits sotah.RecipeProfits         `json:"profits"`
+	History sotah.RecipeProfitHistories `json:"history"`
+}
+
+func (res craftingProfitsResponse) encodeForMessage() (string, error) {
+	jsonEncoded, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+
+	gzipEncoded, err := util.GzipEncode(jsonEncoded)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(gzipEncoded), nil
+}
+
+func (sta APIState) ListenForCraftingProfits(stop ListenStopChan) error {
+	return serveCraftingProfits(sta.State, stop)
+}
+
+// serveCraftingProfits serves crafting profits from the recipes database of the state, for the api and prod-api alike
+func serveCraftingProfits(sta State, stop ListenStopChan) error {
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.CraftingProfits),
+		stop,
+		messenger.Endpoint[craftingProfitsRequest, craftingProfitsResponse]{
+			Decode: newCraftingProfitsRequest,
+			Encode: craftingProfitsResponse.encodeForMessage,
+			Handle: func(
+				ctx context.Context,
+				request craftingProfitsRequest,
+			) (craftingProfitsResponse, codes.Code, error) {
+				// computing profits for the requested recipes
+				res, reErr := request.resolve(ctx, sta)
+				if reErr.code != codes.Ok {
+					return craftingProfitsResponse{}, reErr.code, errors.New(reErr.message)
+				}
+
+				return res, codes.Ok, nil
+			},
+		},
+	)
+}
+
+func (sta State) NewPricelist(ctx context.Context, req database.GetPricelistRequest) (sotah.ItemPrices, error) {
+	encodedMessage, err := json.Marshal(req)
+	if err != nil {
+		return sotah.ItemPrices{}, err
+	}
+
+	msg, err := sta.IO.Messenger.Request(ctx, string(subjects.PriceList), encodedMessage)
+	if err != nil {
+		return sotah.ItemPrices{}, err
+	}
+
+	if msg.Code != codes.Ok {
+		return sotah.ItemPrices{}, errors.New(msg.Err)
+	}
+
+	res, err := database.NewGetPricelistResponseFromEncoded(msg.Data)
+	if err != nil {
+		return sotah.ItemPrices{}, err
+	}
+
+	return res.Pricelist, nil
+}
+
+func (sta State) NewPricelistHistory(ctx context.Context, req database.GetPricelistHistoryRequest) (sotah.ItemPriceHistories, error) {
+	encodedMessage, err := json.Marshal(req)
+	if err != nil {
+		return sotah.ItemPriceHistories{}, err
+	}
+
+	msg, err := sta.IO.Messenger.Request(ctx, string(subjects.PriceListHistory), encodedMessage)
+	if err != nil {
+		return sotah.ItemPriceHistories{}, err
+	}
+
+	if msg.Code != codes.Ok {
+		return sotah.ItemPriceHistories{}, errors.New(msg.Err)
+	}
+
+	res, err := database.NewGetPricelistHistoryResponseFromEncoded(msg.Data)
+	if err != nil {
+		return sotah.ItemPriceHistories{}, err
+	}
+
+	return res.History, nil
+}
@@ -1,10 +1,10 @@
 package state
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 
-	nats "github.com/nats-io/go-nats"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	"github.com/sotah-inc/server/app/pkg/messenger/codes"
@@ -27,8 +27,8 @@ type itemsRequest struct {
 	ItemIds []blizzard.ItemID `json:"itemIds"`
 }
 
-func (iRequest itemsRequest) resolve(sta APIState) (sotah.ItemsMap, error) {
-	return sta.IO.Databases.ItemsDatabase.FindItems(iRequest.ItemIds)
+func (iRequest itemsRequest) resolve(ctx context.Context, sta APIState) (sotah.ItemsMap, error) {
+	return sta.IO.Databases.ItemsDatabase.FindItems(ctx, iRequest.ItemIds)
 }
 
 type itemsResponse struct {
@@ -50,44 +50,21 @@ func (iResponse itemsResponse) encodeForMessage() (string, error) {
 }
 
 func (sta APIState) ListenForItems(stop ListenStopChan) error {
-	err := sta.IO.Messenger.Subscribe(string(subjects.Items), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		// resolving the request
-		iRequest, err := newItemsRequest(natsMsg.Data)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.MsgJSONParseError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		iMap, err := iRequest.resolve(sta)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.GenericError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		iResponse := itemsResponse{iMap}
-		data, err := iResponse.encodeForMessage()
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.MsgJSONParseError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		m.Data = data
-		sta.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.Items),
+		stop,
+		messenger.Endpoint[itemsRequest, itemsResponse]{
+			Decode: newItemsRequest,
+			Encode: itemsResponse.encodeForMessage,
+			Handle: func(ctx context.Context, iRequest itemsRequest) (itemsResponse, codes.Code, error) {
+				iMap, err := iRequest.resolve(ctx, sta)
+				if err != nil {
+					return itemsResponse{}, codes.GenericError, err
+				}
+
+				return itemsResponse{iMap}, codes.Ok, nil
+			},
+		},
+	)
 }
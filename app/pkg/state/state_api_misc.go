@@ -1,12 +1,12 @@
 package state
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
-	nats "github.com/nats-io/go-nats"
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/messenger"
@@ -15,25 +15,34 @@ import (
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
 )
 
-func (sta State) NewRegions() (sotah.RegionList, error) {
+// BootRetryConfig - how many times the boot subject is requested on starting up, while the api may yet be starting too
+type BootRetryConfig struct {
+	Attempts int
+	Wait     time.Duration
+}
+
+// DefaultBootRetryConfig - twenty attempts a quarter second apart, as the boot flags default to
+var DefaultBootRetryConfig = BootRetryConfig{Attempts: 20, Wait: 250 * time.Millisecond}
+
+func (sta State) NewRegions(ctx context.Context, retry BootRetryConfig) (sotah.RegionList, error) {
 	msg, err := func() (messenger.Message, error) {
 		attempts := 0
 
 		for {
-			out, err := sta.IO.Messenger.Request(string(subjects.Boot), []byte{})
+			out, err := sta.IO.Messenger.Request(ctx, string(subjects.Boot), []byte{})
 			if err == nil {
 				return out, nil
 			}
 
 			attempts++
 
-			if attempts >= 20 {
+			if attempts >= retry.Attempts {
 				return messenger.Message{}, fmt.Errorf("failed to fetch boot message after %d attempts", attempts)
 			}
 
 			logrus.WithField("attempt", attempts).Info("Requested boot, sleeping until next")
 
-			time.Sleep(250 * time.Millisecond)
+			time.Sleep(retry.Wait)
 		}
 	}()
 	if err != nil {
@@ -57,32 +66,31 @@ type BootResponse struct {
 	ItemClasses blizzard.ItemClasses `json:"item_classes"`
 	Expansions  []sotah.Expansion    `json:"expansions"`
 	Professions []sotah.Profession   `json:"professions"`
+
+	ProfessionRecipes sotah.ProfessionRecipes `json:"profession_recipes"`
 }
 
 func (sta APIState) ListenForBoot(stop ListenStopChan) error {
-	err := sta.IO.Messenger.Subscribe(string(subjects.Boot), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		encodedResponse, err := json.Marshal(BootResponse{
-			Regions:     sta.Regions,
-			ItemClasses: sta.ItemClasses,
-			Expansions:  sta.Expansions,
-			Professions: sta.Professions,
-		})
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.MsgJSONParseError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		m.Data = string(encodedResponse)
-		sta.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.Boot),
+		stop,
+		messenger.Endpoint[messenger.Empty, BootResponse]{
+			Decode: messenger.DecodeEmpty,
+			Handle: func(_ context.Context, _ messenger.Empty) (BootResponse, codes.Code, error) {
+				recipes, err := sta.IO.Databases.RecipesDatabase.GetRecipes()
+				if err != nil {
+					return BootResponse{}, codes.GenericError, err
+				}
+
+				return BootResponse{
+					Regions:           sta.Regions,
+					ItemClasses:       sta.ItemClasses,
+					Expansions:        sta.Expansions,
+					Professions:       sta.Professions,
+					ProfessionRecipes: recipes.ToProfessionRecipes(),
+				}, codes.Ok, nil
+			},
+		},
+	)
 }
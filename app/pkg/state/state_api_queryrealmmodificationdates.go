@@ -1,9 +1,9 @@
 package state
 
 import (
+	"context"
 	"errors"
 
-	nats "github.com/nats-io/go-nats"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	mCodes "github.com/sotah-inc/server/app/pkg/messenger/codes"
@@ -12,79 +12,58 @@ import (
 )
 
 func (sta APIState) ListenForQueryRealmModificationDates(stop ListenStopChan) error {
-	err := sta.IO.Messenger.Subscribe(string(subjects.QueryRealmModificationDates), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		req, err := NewRealmModificationDatesRequest(natsMsg.Data)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.GenericError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		regionName, err := func() (blizzard.RegionName, error) {
-			for _, region := range sta.Regions {
-				if region.Name != blizzard.RegionName(req.RegionName) {
-					continue
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.QueryRealmModificationDates),
+		stop,
+		messenger.Endpoint[RealmModificationDatesRequest, RealmModificationDatesResponse]{
+			Decode: NewRealmModificationDatesRequest,
+			Encode: messenger.EncodeBytes(RealmModificationDatesResponse.EncodeForDelivery),
+			Handle: func(
+				_ context.Context,
+				req RealmModificationDatesRequest,
+			) (RealmModificationDatesResponse, mCodes.Code, error) {
+				regionName, err := func() (blizzard.RegionName, error) {
+					for _, region := range sta.Regions {
+						if region.Name != blizzard.RegionName(req.RegionName) {
+							continue
+						}
+
+						if _, ok := sta.Statuses[blizzard.RegionName(req.RegionName)]; !ok {
+							continue
+						}
+
+						return region.Name, nil
+					}
+
+					return blizzard.RegionName(""), errors.New("region not found")
+				}()
+				if err != nil {
+					return RealmModificationDatesResponse{}, mCodes.NotFound, err
 				}
 
-				if _, ok := sta.Statuses[blizzard.RegionName(req.RegionName)]; !ok {
-					continue
-				}
-
-				return region.Name, nil
-			}
-
-			return blizzard.RegionName(""), errors.New("region not found")
-		}()
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.NotFound
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
+				realm, err := func() (sotah.Realm, error) {
+					for _, realm := range sta.Statuses[regionName].Realms {
+						if realm.Slug != blizzard.RealmSlug(req.RealmSlug) {
+							continue
+						}
 
-			return
-		}
+						return realm, nil
+					}
 
-		realm, err := func() (sotah.Realm, error) {
-			for _, realm := range sta.Statuses[regionName].Realms {
-				if realm.Slug != blizzard.RealmSlug(req.RealmSlug) {
-					continue
+					return sotah.Realm{}, errors.New("realm not found")
+				}()
+				if err != nil {
+					return RealmModificationDatesResponse{}, mCodes.NotFound, err
 				}
 
-				return realm, nil
-			}
-
-			return sotah.Realm{}, errors.New("realm not found")
-		}()
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.NotFound
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		res := RealmModificationDatesResponse{
-			RealmModificationDates: sta.RegionRealmModificationDates.Get(realm.Region.Name, realm.Slug),
-		}
-
-		encodedData, err := res.EncodeForDelivery()
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.GenericError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		m.Data = string(encodedData)
-		sta.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
+				sta.modificationDatesLock.RLock()
+				defer sta.modificationDatesLock.RUnlock()
 
-	return nil
+				return RealmModificationDatesResponse{
+					RealmModificationDates: sta.RegionRealmModificationDates.Get(realm.Region.Name, realm.Slug),
+				}, mCodes.Ok, nil
+			},
+		},
+	)
 }
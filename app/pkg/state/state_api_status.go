@@ -1,10 +1,10 @@
 package state
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
-	nats "github.com/nats-io/go-nats"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	"github.com/sotah-inc/server/app/pkg/messenger/codes"
@@ -45,63 +45,37 @@ func (sr StatusRequest) resolve(sta APIState) (sotah.Region, error) {
 }
 
 func (sta APIState) ListenForStatus(stop ListenStopChan) error {
-	err := sta.IO.Messenger.Subscribe(string(subjects.Status), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		sr, err := newStatusRequest(natsMsg.Data)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.MsgJSONParseError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		reg, err := sr.resolve(sta)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.NotFound
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		regionStatus, ok := sta.Statuses[reg.Name]
-		if !ok {
-			m.Err = "Region found but not in Statuses"
-			m.Code = codes.NotFound
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		encodedStatus, err := json.Marshal(regionStatus)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.GenericError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		m.Data = string(encodedStatus)
-		sta.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.Status),
+		stop,
+		messenger.Endpoint[StatusRequest, sotah.Status]{
+			Decode: newStatusRequest,
+			Handle: func(_ context.Context, sr StatusRequest) (sotah.Status, codes.Code, error) {
+				reg, err := sr.resolve(sta)
+				if err != nil {
+					return sotah.Status{}, codes.NotFound, err
+				}
+
+				regionStatus, ok := sta.Statuses[reg.Name]
+				if !ok {
+					return sotah.Status{}, codes.NotFound, errors.New("Region found but not in Statuses")
+				}
+
+				return regionStatus, codes.Ok, nil
+			},
+		},
+	)
 }
 
-func (sta State) NewStatus(reg sotah.Region) (sotah.Status, error) {
+func (sta State) NewStatus(ctx context.Context, reg sotah.Region) (sotah.Status, error) {
 	lm := StatusRequest{RegionName: reg.Name}
 	encodedMessage, err := json.Marshal(lm)
 	if err != nil {
 		return sotah.Status{}, err
 	}
 
-	msg, err := sta.IO.Messenger.Request(string(subjects.Status), encodedMessage)
+	msg, err := sta.IO.Messenger.Request(ctx, string(subjects.Status), encodedMessage)
 	if err != nil {
 		return sotah.Status{}, err
 	}
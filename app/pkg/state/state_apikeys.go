@@ -0,0 +1,162 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/apikeys"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	mCodes "github.com/sotah-inc/server/app/pkg/messenger/codes"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/state/subjects"
+)
+
+// errAPIKeyNotFound - replied for keys that were never issued and for secrets that do not match, alike
+var errAPIKeyNotFound = errors.New("api key was not found")
+
+// ListenForAPIKeysResolve looks up issued keys for the key stores of every process, along with their usage today
+func (sta State) ListenForAPIKeysResolve(stop ListenStopChan) error {
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.APIKeysResolve),
+		stop,
+		messenger.Endpoint[apikeys.ResolveRequest, apikeys.Resolution]{
+			Handle: func(_ context.Context, req apikeys.ResolveRequest) (apikeys.Resolution, mCodes.Code, error) {
+				id, secret, ok := apikeys.ParseKey(req.Key)
+				if !ok {
+					return apikeys.Resolution{}, mCodes.NotFound, errAPIKeyNotFound
+				}
+
+				key, found, err := sta.IO.Databases.APIKeysDatabase.GetAPIKey(id)
+				if err != nil {
+					return apikeys.Resolution{}, mCodes.GenericError, err
+				}
+				if !found || !apikeys.Matches(key, secret) {
+					return apikeys.Resolution{}, mCodes.NotFound, errAPIKeyNotFound
+				}
+
+				usage, err := sta.IO.Databases.APIKeysDatabase.GetAPIKeyUsage(id, sotah.APIKeyUsageDay(time.Now()))
+				if err != nil {
+					return apikeys.Resolution{}, mCodes.GenericError, err
+				}
+
+				return apikeys.Resolution{Key: key.Redacted(), UsedToday: usage.Total()}, mCodes.Ok, nil
+			},
+		},
+	)
+}
+
+// ListenForAPIKeysUsage records the usage published by the key stores of every process
+func (sta State) ListenForAPIKeysUsage(stop ListenStopChan) error {
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.APIKeysUsage),
+		stop,
+		messenger.Endpoint[sotah.APIKeyUsage, messenger.Empty]{
+			Encode: messenger.EncodeEmpty,
+			Handle: func(_ context.Context, usage sotah.APIKeyUsage) (messenger.Empty, mCodes.Code, error) {
+				if len(usage.Day) == 0 {
+					return messenger.Empty{}, mCodes.UserError, errors.New("day cannot be blank")
+				}
+
+				if err := sta.IO.Databases.APIKeysDatabase.PersistAPIKeyUsage(usage); err != nil {
+					return messenger.Empty{}, mCodes.GenericError, err
+				}
+
+				return messenger.Empty{}, mCodes.Ok, nil
+			},
+		},
+	)
+}
+
+func (sta State) ListenForAPIKeysIssue(stop ListenStopChan) error {
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.APIKeysIssue),
+		stop,
+		messenger.Endpoint[apikeys.IssueRequest, apikeys.IssueResponse]{
+			Handle: func(_ context.Context, req apikeys.IssueRequest) (apikeys.IssueResponse, mCodes.Code, error) {
+				key, apiKey, err := apikeys.Issue(req.APIKey(), time.Now())
+				if err != nil {
+					return apikeys.IssueResponse{}, mCodes.GenericError, err
+				}
+
+				if err := sta.IO.Databases.APIKeysDatabase.PersistAPIKey(key); err != nil {
+					return apikeys.IssueResponse{}, mCodes.GenericError, err
+				}
+
+				logging.WithFields(logrus.Fields{
+					"id":   key.ID,
+					"name": key.Name,
+					"role": key.Role,
+				}).Info("Issued api key")
+
+				return apikeys.IssueResponse{Key: key.Redacted(), APIKey: apiKey}, mCodes.Ok, nil
+			},
+		},
+	)
+}
+
+// ListenForAPIKeysRevoke revokes keys, which the key stores of other processes go on letting through until they expire
+func (sta State) ListenForAPIKeysRevoke(stop ListenStopChan) error {
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.APIKeysRevoke),
+		stop,
+		messenger.Endpoint[apikeys.RevokeRequest, sotah.APIKey]{
+			Handle: func(_ context.Context, req apikeys.RevokeRequest) (sotah.APIKey, mCodes.Code, error) {
+				key, found, err := sta.IO.Databases.APIKeysDatabase.GetAPIKey(req.ID)
+				if err != nil {
+					return sotah.APIKey{}, mCodes.GenericError, err
+				}
+				if !found {
+					return sotah.APIKey{}, mCodes.NotFound, errAPIKeyNotFound
+				}
+
+				if !key.IsRevoked() {
+					key.RevokedAt = sotah.UnixTimestamp(time.Now().Unix())
+					if err := sta.IO.Databases.APIKeysDatabase.PersistAPIKey(key); err != nil {
+						return sotah.APIKey{}, mCodes.GenericError, err
+					}
+
+					logging.WithField("id", key.ID).Info("Revoked api key")
+				}
+
+				return key.Redacted(), mCodes.Ok, nil
+			},
+		},
+	)
+}
+
+func (sta State) ListenForAPIKeysList(stop ListenStopChan) error {
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.APIKeysList),
+		stop,
+		messenger.Endpoint[messenger.Empty, []apikeys.ListedKey]{
+			Decode: messenger.DecodeEmpty,
+			Handle: func(_ context.Context, _ messenger.Empty) ([]apikeys.ListedKey, mCodes.Code, error) {
+				keys, err := sta.IO.Databases.APIKeysDatabase.GetAPIKeys()
+				if err != nil {
+					return nil, mCodes.GenericError, err
+				}
+
+				day := sotah.APIKeyUsageDay(time.Now())
+				out := []apikeys.ListedKey{}
+				for _, key := range keys {
+					usage, err := sta.IO.Databases.APIKeysDatabase.GetAPIKeyUsage(key.ID, day)
+					if err != nil {
+						return nil, mCodes.GenericError, err
+					}
+
+					out = append(out, apikeys.ListedKey{Key: key.Redacted(), Usage: usage})
+				}
+
+				return out, mCodes.Ok, nil
+			},
+		},
+	)
+}
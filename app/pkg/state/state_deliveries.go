@@ -0,0 +1,30 @@
+package state
+
+import (
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	"github.com/sotah-inc/server/app/pkg/messenger/deliveries"
+	"github.com/sotah-inc/server/app/pkg/state/subjects"
+)
+
+/*
+SubjectDeliveries - how the requests of each subject are delivered, where the queries of live-auctions and
+pricelist-histories are balanced so that replicas of either may share them, and intake is broadcast so that every replica
+keeps up, as is every other subject
+*/
+var SubjectDeliveries = messenger.Deliveries{
+	string(subjects.Auctions):               deliveries.Balanced,
+	string(subjects.PriceList):              deliveries.Balanced,
+	string(subjects.Owners):                 deliveries.Balanced,
+	string(subjects.OwnersQuery):            deliveries.Balanced,
+	string(subjects.OwnersQueryByItems):     deliveries.Balanced,
+	string(subjects.PriceListHistory):       deliveries.Balanced,
+	string(subjects.PriceListHistoryV2):     deliveries.Balanced,
+	string(subjects.PriceListForecast):      deliveries.Balanced,
+	string(subjects.RegionPriceListHistory): deliveries.Balanced,
+
+	string(subjects.LiveAuctionsIntake):                deliveries.Broadcast,
+	string(subjects.PricelistHistoriesIntake):          deliveries.Broadcast,
+	string(subjects.PricelistHistoriesIntakeV2):        deliveries.Broadcast,
+	string(subjects.ReceiveComputedLiveAuctions):       deliveries.Broadcast,
+	string(subjects.ReceiveComputedPricelistHistories): deliveries.Broadcast,
+}
@@ -0,0 +1,34 @@
+package state
+
+import (
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/blizzard"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+)
+
+// RealmFreshness - the modification dates of a realm, along with how long ago the latest of them was
+type RealmFreshness struct {
+	sotah.RealmModificationDates
+
+	Age string `json:"age"`
+}
+
+type RegionRealmFreshness map[blizzard.RegionName]map[blizzard.RealmSlug]RealmFreshness
+
+func NewRegionRealmFreshness(modDates sotah.RegionRealmModificationDates) RegionRealmFreshness {
+	out := RegionRealmFreshness{}
+	for regionName, realmsModDates := range modDates {
+		out[regionName] = map[blizzard.RealmSlug]RealmFreshness{}
+		for realmSlug, realmModDates := range realmsModDates {
+			age := "never"
+			if latest := realmModDates.Latest(); latest > 0 {
+				age = time.Since(time.Unix(latest, 0)).Truncate(time.Second).String()
+			}
+
+			out[regionName][realmSlug] = RealmFreshness{RealmModificationDates: realmModDates, Age: age}
+		}
+	}
+
+	return out
+}
@@ -1,35 +1,41 @@
 package state
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
 	"github.com/sotah-inc/server/app/pkg/diskstore"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	"github.com/sotah-inc/server/app/pkg/metric"
+	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
 	"github.com/sotah-inc/server/app/pkg/util"
 	"github.com/twinj/uuid"
 )
 
 type LiveAuctionsStateConfig struct {
-	MessengerHost string
-	MessengerPort int
+	MessengerHost    string
+	MessengerPort    int
+	MessengerConnect messenger.ConnectConfig
+	BootRetry        BootRetryConfig
 
 	DiskStoreCacheDir string
 
 	LiveAuctionsDatabaseDir string
+	Retention               sotah.RetentionConfig
 }
 
-func NewLiveAuctionsState(config LiveAuctionsStateConfig) (LiveAuctionsState, error) {
+func NewLiveAuctionsState(ctx context.Context, config LiveAuctionsStateConfig) (LiveAuctionsState, error) {
 	laState := LiveAuctionsState{
 		State: NewState(uuid.NewV4(), false),
 	}
 
 	// connecting to the messenger host
 	logging.Info("Connecting messenger")
-	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort)
+	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort, config.MessengerConnect)
 	if err != nil {
 		return LiveAuctionsState{}, err
 	}
@@ -40,7 +46,7 @@ func NewLiveAuctionsState(config LiveAuctionsStateConfig) (LiveAuctionsState, er
 
 	// gathering regions
 	logging.Info("Gathering regions")
-	regions, err := laState.NewRegions()
+	regions, err := laState.NewRegions(ctx, config.BootRetry)
 	if err != nil {
 		return LiveAuctionsState{}, err
 	}
@@ -49,7 +55,7 @@ func NewLiveAuctionsState(config LiveAuctionsStateConfig) (LiveAuctionsState, er
 	// gathering statuses
 	logging.Info("Gathering statuses")
 	for _, reg := range laState.Regions {
-		status, err := laState.NewStatus(reg)
+		status, err := laState.NewStatus(ctx, reg)
 		if err != nil {
 			return LiveAuctionsState{}, err
 		}
@@ -89,6 +95,9 @@ func NewLiveAuctionsState(config LiveAuctionsStateConfig) (LiveAuctionsState, er
 
 	// loading the live-auctions databases
 	logging.Info("Connecting to live-auctions databases")
+	if _, err := database.MigrateSchemas(config.LiveAuctionsDatabaseDir, schemakinds.LiveAuctions); err != nil {
+		return LiveAuctionsState{}, err
+	}
 	ladBases, err := database.NewLiveAuctionsDatabases(config.LiveAuctionsDatabaseDir, laState.Statuses)
 	if err != nil {
 		return LiveAuctionsState{}, err
@@ -1,11 +1,11 @@
 package state
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 
-	nats "github.com/nats-io/go-nats"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	"github.com/sotah-inc/server/app/pkg/messenger/codes"
@@ -37,6 +37,19 @@ type AuctionsRequest struct {
 	ItemFilters   []blizzard.ItemID            `json:"item_filters"`
 }
 
+func (ar AuctionsRequest) Validate() error {
+	if ar.Page < 0 {
+		return errors.New("Page must be >=0")
+	}
+	if ar.Count <= 0 {
+		return errors.New("Count must be >0")
+	} else if ar.Count > 1000 {
+		return errors.New("Count must be <=1000")
+	}
+
+	return nil
+}
+
 func (ar AuctionsRequest) resolve(laState LiveAuctionsState) (sotah.MiniAuctionList, requestError) {
 	regionLadBases, ok := laState.IO.Databases.LiveAuctionsDatabases[ar.RegionName]
 	if !ok {
@@ -48,15 +61,6 @@ func (ar AuctionsRequest) resolve(laState LiveAuctionsState) (sotah.MiniAuctionL
 		return sotah.MiniAuctionList{}, requestError{codes.NotFound, "Invalid Realm"}
 	}
 
-	if ar.Page < 0 {
-		return sotah.MiniAuctionList{}, requestError{codes.UserError, "Page must be >=0"}
-	}
-	if ar.Count == 0 {
-		return sotah.MiniAuctionList{}, requestError{codes.UserError, "Count must be >0"}
-	} else if ar.Count > 1000 {
-		return sotah.MiniAuctionList{}, requestError{codes.UserError, "Count must be <=1000"}
-	}
-
 	maList, err := realmLadbase.GetMiniAuctionList()
 	if err != nil {
 		return sotah.MiniAuctionList{}, requestError{codes.GenericError, err.Error()}
@@ -86,99 +90,68 @@ func (ar auctionsResponse) encodeForMessage() (string, error) {
 }
 
 func (laState LiveAuctionsState) ListenForAuctions(stop ListenStopChan) error {
-	err := laState.IO.Messenger.Subscribe(string(subjects.Auctions), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		// resolving the request
-		aRequest, err := newAuctionsRequest(natsMsg.Data)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.MsgJSONParseError
-			laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// resolving data from State
-		realmAuctions, reErr := aRequest.resolve(laState)
-		if reErr.code != codes.Ok {
-			m.Err = reErr.message
-			m.Code = reErr.code
-			laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// initial response format
-		aResponse := auctionsResponse{Total: -1, TotalCount: -1, AuctionList: realmAuctions}
-
-		// filtering in auctions by owners or items
-		if len(aRequest.OwnerFilters) > 0 {
-			aResponse.AuctionList = aResponse.AuctionList.FilterByOwnerNames(aRequest.OwnerFilters)
-		}
-		if len(aRequest.ItemFilters) > 0 {
-			aResponse.AuctionList = aResponse.AuctionList.FilterByItemIDs(aRequest.ItemFilters)
-		}
-
-		// calculating the total for paging
-		aResponse.Total = len(aResponse.AuctionList)
-
-		// calculating the total-count for review
-		totalCount := 0
-		for _, mAuction := range realmAuctions {
-			totalCount += len(mAuction.AucList)
-		}
-		aResponse.TotalCount = totalCount
-
-		// optionally sorting
-		if aRequest.SortKind != sortkinds.None && aRequest.SortDirection != sortdirections.None {
-			err = aResponse.AuctionList.Sort(aRequest.SortKind, aRequest.SortDirection)
-			if err != nil {
-				m.Err = err.Error()
-				m.Code = codes.UserError
-				laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-				return
-			}
-		}
-
-		// truncating the list
-		aResponse.AuctionList, err = aResponse.AuctionList.Limit(aRequest.Count, aRequest.Page)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.UserError
-			laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// encoding the auctions list for output
-		data, err := aResponse.encodeForMessage()
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.GenericError
-			laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		m.Data = data
-		laState.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return messenger.Serve(
+		laState.IO.Messenger,
+		string(subjects.Auctions),
+		stop,
+		messenger.Endpoint[AuctionsRequest, auctionsResponse]{
+			Decode: newAuctionsRequest,
+			Encode: auctionsResponse.encodeForMessage,
+			Handle: func(_ context.Context, aRequest AuctionsRequest) (auctionsResponse, codes.Code, error) {
+				// resolving data from State
+				realmAuctions, reErr := aRequest.resolve(laState)
+				if reErr.code != codes.Ok {
+					return auctionsResponse{}, reErr.code, errors.New(reErr.message)
+				}
+
+				// initial response format
+				aResponse := auctionsResponse{Total: -1, TotalCount: -1, AuctionList: realmAuctions}
+
+				// filtering in auctions by owners or items
+				if len(aRequest.OwnerFilters) > 0 {
+					aResponse.AuctionList = aResponse.AuctionList.FilterByOwnerNames(aRequest.OwnerFilters)
+				}
+				if len(aRequest.ItemFilters) > 0 {
+					aResponse.AuctionList = aResponse.AuctionList.FilterByItemIDs(aRequest.ItemFilters)
+				}
+
+				// calculating the total for paging
+				aResponse.Total = len(aResponse.AuctionList)
+
+				// calculating the total-count for review
+				totalCount := 0
+				for _, mAuction := range realmAuctions {
+					totalCount += len(mAuction.AucList)
+				}
+				aResponse.TotalCount = totalCount
+
+				// optionally sorting
+				if aRequest.SortKind != sortkinds.None && aRequest.SortDirection != sortdirections.None {
+					if err := aResponse.AuctionList.Sort(aRequest.SortKind, aRequest.SortDirection); err != nil {
+						return auctionsResponse{}, codes.UserError, err
+					}
+				}
+
+				// truncating the list
+				var err error
+				aResponse.AuctionList, err = aResponse.AuctionList.Limit(aRequest.Count, aRequest.Page)
+				if err != nil {
+					return auctionsResponse{}, codes.UserError, err
+				}
+
+				return aResponse, codes.Ok, nil
+			},
+		},
+	)
 }
 
-func (sta State) NewMiniAuctionsList(req AuctionsRequest) (sotah.MiniAuctionList, error) {
+func (sta State) NewMiniAuctionsList(ctx context.Context, req AuctionsRequest) (sotah.MiniAuctionList, error) {
 	encodedMessage, err := json.Marshal(req)
 	if err != nil {
 		return sotah.MiniAuctionList{}, err
 	}
 
-	msg, err := sta.IO.Messenger.Request(string(subjects.Auctions), encodedMessage)
+	msg, err := sta.IO.Messenger.Request(ctx, string(subjects.Auctions), encodedMessage)
 	if err != nil {
 		return sotah.MiniAuctionList{}, err
 	}
@@ -1,13 +1,15 @@
 package state
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 
-	nats "github.com/nats-io/go-nats"
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/database"
 	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	"github.com/sotah-inc/server/app/pkg/messenger/codes"
 	"github.com/sotah-inc/server/app/pkg/metric"
 	"github.com/sotah-inc/server/app/pkg/metric/kinds"
 	"github.com/sotah-inc/server/app/pkg/sotah"
@@ -202,22 +204,27 @@ func (laState LiveAuctionsState) ListenForLiveAuctionsIntake(stop ListenStopChan
 	in := make(chan liveAuctionsIntakeRequest, 30)
 
 	// starting up a listener for live-auctions-intake
-	err := laState.IO.Messenger.Subscribe(string(subjects.LiveAuctionsIntake), stop, func(natsMsg nats.Msg) {
-		// resolving the request
-		iRequest, err := newLiveAuctionsIntakeRequest(natsMsg.Data)
-		if err != nil {
-			logging.WithField("error", err.Error()).Error("Failed to parse live-auctions-intake-request")
-
-			return
-		}
-
-		laState.IO.Reporter.ReportWithPrefix(metric.Metrics{
-			"buffer_size": len(iRequest.RegionRealmTimestamps),
-		}, kinds.LiveAuctionsIntake)
-		logging.WithField("capacity", len(in)).Info("Received live-auctions-intake-request, pushing onto handle channel")
-
-		in <- iRequest
-	})
+	err := messenger.Serve(
+		laState.IO.Messenger,
+		string(subjects.LiveAuctionsIntake),
+		stop,
+		messenger.Endpoint[liveAuctionsIntakeRequest, messenger.Empty]{
+			Decode: newLiveAuctionsIntakeRequest,
+			Encode: messenger.EncodeEmpty,
+			Handle: func(_ context.Context, iRequest liveAuctionsIntakeRequest) (messenger.Empty, codes.Code, error) {
+				laState.IO.Reporter.ReportWithPrefix(metric.Metrics{
+					"buffer_size": len(iRequest.RegionRealmTimestamps),
+				}, kinds.LiveAuctionsIntake)
+				logging.WithField("capacity", len(in)).Info(
+					"Received live-auctions-intake-request, pushing onto handle channel",
+				)
+
+				in <- iRequest
+
+				return messenger.Empty{}, codes.Ok, nil
+			},
+		},
+	)
 	if err != nil {
 		return err
 	}
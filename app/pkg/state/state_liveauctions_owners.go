@@ -1,11 +1,11 @@
 package state
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"sort"
 
-	nats "github.com/nats-io/go-nats"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	"github.com/sotah-inc/server/app/pkg/messenger/codes"
@@ -49,64 +49,35 @@ func (request OwnersRequest) resolve(laState LiveAuctionsState) (sotah.MiniAucti
 }
 
 func (laState LiveAuctionsState) ListenForOwners(stop ListenStopChan) error {
-	err := laState.IO.Messenger.Subscribe(string(subjects.Owners), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		// resolving the request
-		request, err := newOwnersRequest(natsMsg.Data)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.MsgJSONParseError
-			laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// resolving mini-auctions-list from the request and State
-		mal, err := request.resolve(laState)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.NotFound
-			laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		o, err := sotah.NewOwnersFromAuctions(mal)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.GenericError
-			laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// optionally filtering in matches
-		if request.Query != "" {
-			o.Owners = o.Owners.Filter(request.Query)
-		}
-
-		// sorting and truncating
-		sort.Sort(sotah.OwnersByName(o.Owners))
-		o.Owners = o.Owners.Limit()
-
-		// marshalling for Messenger
-		encodedMessage, err := json.Marshal(o)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.GenericError
-			laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// dumping it out
-		m.Data = string(encodedMessage)
-		laState.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return messenger.Serve(
+		laState.IO.Messenger,
+		string(subjects.Owners),
+		stop,
+		messenger.Endpoint[OwnersRequest, sotah.Owners]{
+			Decode: newOwnersRequest,
+			Handle: func(_ context.Context, request OwnersRequest) (sotah.Owners, codes.Code, error) {
+				// resolving mini-auctions-list from the request and State
+				mal, err := request.resolve(laState)
+				if err != nil {
+					return sotah.Owners{}, codes.NotFound, err
+				}
+
+				o, err := sotah.NewOwnersFromAuctions(mal)
+				if err != nil {
+					return sotah.Owners{}, codes.GenericError, err
+				}
+
+				// optionally filtering in matches
+				if request.Query != "" {
+					o.Owners = o.Owners.Filter(request.Query)
+				}
+
+				// sorting and truncating
+				sort.Sort(sotah.OwnersByName(o.Owners))
+				o.Owners = o.Owners.Limit()
+
+				return o, codes.Ok, nil
+			},
+		},
+	)
 }
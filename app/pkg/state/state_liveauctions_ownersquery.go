@@ -1,12 +1,12 @@
 package state
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"sort"
 
 	"github.com/lithammer/fuzzysearch/fuzzy"
-	nats "github.com/nats-io/go-nats"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	"github.com/sotah-inc/server/app/pkg/messenger/codes"
@@ -80,14 +80,18 @@ type ownersQueryRequest struct {
 	Query      string              `json:"query"`
 }
 
-func (request ownersQueryRequest) resolve(laState LiveAuctionsState) (ownersQueryResult, error) {
+func (request ownersQueryRequest) Validate() error {
 	if request.RegionName == "" {
-		return ownersQueryResult{}, errors.New("region name cannot be blank")
+		return errors.New("region name cannot be blank")
 	}
 	if request.RealmSlug == "" {
-		return ownersQueryResult{}, errors.New("realm slug cannot be blank")
+		return errors.New("realm slug cannot be blank")
 	}
 
+	return nil
+}
+
+func (request ownersQueryRequest) resolve(laState LiveAuctionsState) (ownersQueryResult, error) {
 	// resolving region-Realm auctions
 	regionLadBases, ok := laState.IO.Databases.LiveAuctionsDatabases[request.RegionName]
 	if !ok {
@@ -127,61 +131,36 @@ func (request ownersQueryRequest) resolve(laState LiveAuctionsState) (ownersQuer
 }
 
 func (laState LiveAuctionsState) ListenForOwnersQuery(stop ListenStopChan) error {
-	err := laState.IO.Messenger.Subscribe(string(subjects.OwnersQuery), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		// resolving the request
-		request, err := newOwnersQueryRequest(natsMsg.Data)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.MsgJSONParseError
-			laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// resolving result from the request and State
-		result, err := request.resolve(laState)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.NotFound
-			laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// optionally sorting by rank and truncating or sorting by name
-		if request.Query != "" {
-			for i, oqItem := range result.Items {
-				oqItem.Rank = fuzzy.RankMatchFold(request.Query, oqItem.Target)
-				result.Items[i] = oqItem
-			}
-			result.Items = result.Items.filterLowRank()
-			sort.Sort(ownersQueryItemsByRank(result.Items))
-		} else {
-			sort.Sort(ownersQueryItemsByNames(result.Items))
-		}
-
-		// truncating
-		result.Items = result.Items.limit()
-
-		// marshalling for Messenger
-		encodedMessage, err := json.Marshal(result)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.GenericError
-			laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// dumping it out
-		m.Data = string(encodedMessage)
-		laState.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return messenger.Serve(
+		laState.IO.Messenger,
+		string(subjects.OwnersQuery),
+		stop,
+		messenger.Endpoint[ownersQueryRequest, ownersQueryResult]{
+			Decode: newOwnersQueryRequest,
+			Handle: func(_ context.Context, request ownersQueryRequest) (ownersQueryResult, codes.Code, error) {
+				// resolving result from the request and State
+				result, err := request.resolve(laState)
+				if err != nil {
+					return ownersQueryResult{}, codes.NotFound, err
+				}
+
+				// optionally sorting by rank and truncating or sorting by name
+				if request.Query != "" {
+					for i, oqItem := range result.Items {
+						oqItem.Rank = fuzzy.RankMatchFold(request.Query, oqItem.Target)
+						result.Items[i] = oqItem
+					}
+					result.Items = result.Items.filterLowRank()
+					sort.Sort(ownersQueryItemsByRank(result.Items))
+				} else {
+					sort.Sort(ownersQueryItemsByNames(result.Items))
+				}
+
+				// truncating
+				result.Items = result.Items.limit()
+
+				return result, codes.Ok, nil
+			},
+		},
+	)
 }
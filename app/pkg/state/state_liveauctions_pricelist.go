@@ -1,10 +1,11 @@
 package state
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 
-	nats "github.com/nats-io/go-nats"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	"github.com/sotah-inc/server/app/pkg/messenger/codes"
@@ -67,56 +68,33 @@ func (plResponse priceListResponse) encodeForMessage() (string, error) {
 }
 
 func (laState LiveAuctionsState) ListenForPriceList(stop ListenStopChan) error {
-	err := laState.IO.Messenger.Subscribe(string(subjects.PriceList), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		// resolving the request
-		plRequest, err := newPriceListRequest(natsMsg.Data)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.MsgJSONParseError
-			laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// resolving data from state
-		realmAuctions, reErr := plRequest.resolve(laState)
-		if reErr.code != codes.Ok {
-			m.Err = reErr.message
-			m.Code = reErr.code
-			laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// deriving a pricelist-response from the provided realm auctions
-		iPrices := sotah.NewItemPrices(realmAuctions)
-		responseItemPrices := sotah.ItemPrices{}
-		for _, itemId := range plRequest.ItemIds {
-			if iPrice, ok := iPrices[itemId]; ok {
-				responseItemPrices[itemId] = iPrice
-
-				continue
-			}
-		}
-
-		plResponse := priceListResponse{responseItemPrices}
-		data, err := plResponse.encodeForMessage()
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = codes.GenericError
-			laState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		m.Data = data
-		laState.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return messenger.Serve(
+		laState.IO.Messenger,
+		string(subjects.PriceList),
+		stop,
+		messenger.Endpoint[priceListRequest, priceListResponse]{
+			Decode: newPriceListRequest,
+			Encode: priceListResponse.encodeForMessage,
+			Handle: func(_ context.Context, plRequest priceListRequest) (priceListResponse, codes.Code, error) {
+				// resolving data from state
+				realmAuctions, reErr := plRequest.resolve(laState)
+				if reErr.code != codes.Ok {
+					return priceListResponse{}, reErr.code, errors.New(reErr.message)
+				}
+
+				// deriving a pricelist-response from the provided realm auctions
+				iPrices := sotah.NewItemPrices(realmAuctions)
+				responseItemPrices := sotah.ItemPrices{}
+				for _, itemId := range plRequest.ItemIds {
+					if iPrice, ok := iPrices[itemId]; ok {
+						responseItemPrices[itemId] = iPrice
+
+						continue
+					}
+				}
+
+				return priceListResponse{responseItemPrices}, codes.Ok, nil
+			},
+		},
+	)
 }
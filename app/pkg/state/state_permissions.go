@@ -0,0 +1,26 @@
+package state
+
+import (
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	"github.com/sotah-inc/server/app/pkg/messenger/roles"
+	"github.com/sotah-inc/server/app/pkg/state/subjects"
+)
+
+/*
+SubjectPermissions - the least role that may request each subject once requests are authenticated, where intake,
+secrets and the managing of api keys are left to internal services and every other subject is public
+*/
+var SubjectPermissions = messenger.Permissions{
+	string(subjects.LiveAuctionsIntake):          roles.Service,
+	string(subjects.PricelistHistoriesIntake):    roles.Service,
+	string(subjects.PricelistHistoriesIntakeV2):  roles.Service,
+	string(subjects.ReceiveRealms):               roles.Service,
+	string(subjects.SessionSecret):               roles.Service,
+	string(subjects.APIKeysResolve):              roles.Service,
+	string(subjects.APIKeysUsage):                roles.Service,
+	string(subjects.APIKeysIssue):                roles.Service,
+	string(subjects.APIKeysRevoke):               roles.Service,
+	string(subjects.APIKeysList):                 roles.Service,
+	string(subjects.RealmModificationDates):      roles.Client,
+	string(subjects.QueryRealmModificationDates): roles.Client,
+}
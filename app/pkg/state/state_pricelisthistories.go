@@ -1,31 +1,37 @@
 package state
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/sotah-inc/server/app/pkg/diskstore"
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	"github.com/sotah-inc/server/app/pkg/metric"
+	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/util"
 	"github.com/twinj/uuid"
 )
 
 type PricelistHistoriesStateConfig struct {
-	MessengerHost string
-	MessengerPort int
+	MessengerHost    string
+	MessengerPort    int
+	MessengerConnect messenger.ConnectConfig
+	BootRetry        BootRetryConfig
 
 	DiskStoreCacheDir string
 
 	PricelistHistoriesDatabaseDir string
+
+	Retention sotah.RetentionConfig
 }
 
-func NewPricelistHistoriesState(config PricelistHistoriesStateConfig) (PricelistHistoriesState, error) {
+func NewPricelistHistoriesState(ctx context.Context, config PricelistHistoriesStateConfig) (PricelistHistoriesState, error) {
 	phState := PricelistHistoriesState{
 		State: NewState(uuid.NewV4(), false),
 	}
 
 	// connecting to the messenger host
-	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort)
+	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort, config.MessengerConnect)
 	if err != nil {
 		return PricelistHistoriesState{}, err
 	}
@@ -35,7 +41,7 @@ func NewPricelistHistoriesState(config PricelistHistoriesStateConfig) (Pricelist
 	phState.IO.Reporter = metric.NewReporter(mess)
 
 	// gathering regions
-	regions, err := phState.NewRegions()
+	regions, err := phState.NewRegions(ctx, config.BootRetry)
 	if err != nil {
 		return PricelistHistoriesState{}, err
 	}
@@ -43,7 +49,7 @@ func NewPricelistHistoriesState(config PricelistHistoriesStateConfig) (Pricelist
 
 	// gathering statuses
 	for _, reg := range phState.Regions {
-		status, err := phState.NewStatus(reg)
+		status, err := phState.NewStatus(ctx, reg)
 		if err != nil {
 			return PricelistHistoriesState{}, err
 		}
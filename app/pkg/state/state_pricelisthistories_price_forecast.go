@@ -0,0 +1,20 @@
+package state
+
+import (
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	"github.com/sotah-inc/server/app/pkg/state/subjects"
+)
+
+func (sta PricelistHistoriesState) ListenForPriceListForecast(stop ListenStopChan) error {
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.PriceListForecast),
+		stop,
+		messenger.Endpoint[database.GetPricelistForecastRequest, database.GetPricelistForecastResponse]{
+			Decode: database.NewGetPricelistForecastRequest,
+			Encode: database.GetPricelistForecastResponse.EncodeForDelivery,
+			Handle: fromDatabase(sta.IO.Databases.PricelistHistoryDatabases.GetPricelistForecast),
+		},
+	)
+}
@@ -1,55 +1,20 @@
 package state
 
 import (
-	nats "github.com/nats-io/go-nats"
 	"github.com/sotah-inc/server/app/pkg/database"
-	dCodes "github.com/sotah-inc/server/app/pkg/database/codes"
 	"github.com/sotah-inc/server/app/pkg/messenger"
-	mCodes "github.com/sotah-inc/server/app/pkg/messenger/codes"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
 )
 
 func (sta PricelistHistoriesState) ListenForPriceListHistory(stop ListenStopChan) error {
-	err := sta.IO.Messenger.Subscribe(string(subjects.PriceListHistory), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		// resolving the request
-		request, err := database.NewGetPricelistHistoryRequest(natsMsg.Data)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.MsgJSONParseError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// querying the live-auctions-databases
-		resp, respCode, err := sta.IO.Databases.PricelistHistoryDatabases.GetPricelistHistory(request)
-		if respCode != dCodes.Ok {
-			m.Err = err.Error()
-			m.Code = DatabaseCodeToMessengerCode(respCode)
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// marshalling for messenger
-		encodedMessage, err := resp.EncodeForDelivery()
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.GenericError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// dumping it out
-		m.Data = encodedMessage
-		sta.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.PriceListHistory),
+		stop,
+		messenger.Endpoint[database.GetPricelistHistoryRequest, database.GetPricelistHistoryResponse]{
+			Decode: database.NewGetPricelistHistoryRequest,
+			Encode: database.GetPricelistHistoryResponse.EncodeForDelivery,
+			Handle: fromDatabase(sta.IO.Databases.PricelistHistoryDatabases.GetPricelistHistory),
+		},
+	)
 }
@@ -1,13 +1,15 @@
 package state
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 
-	nats "github.com/nats-io/go-nats"
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/database"
 	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	"github.com/sotah-inc/server/app/pkg/messenger/codes"
 	"github.com/sotah-inc/server/app/pkg/metric"
 	"github.com/sotah-inc/server/app/pkg/metric/kinds"
 	"github.com/sotah-inc/server/app/pkg/sotah"
@@ -135,24 +137,30 @@ func (sta PricelistHistoriesState) ListenForPricelistHistoriesIntake(stop Listen
 	in := make(chan pricelistHistoriesIntakeRequest, 30)
 
 	// starting up a listener for pricelist-histories-intake
-	err := sta.IO.Messenger.Subscribe(string(subjects.PricelistHistoriesIntake), stop, func(natsMsg nats.Msg) {
-		// resolving the request
-		pRequest, err := newPricelistHistoriesIntakeRequest(natsMsg.Data)
-		if err != nil {
-			logging.WithField("error", err.Error()).Error("Failed to parse pricelist-histories-intake-request")
-
-			return
-		}
-
-		sta.IO.Reporter.ReportWithPrefix(metric.Metrics{
-			"buffer_size": len(pRequest.RegionRealmTimestamps),
-		}, kinds.PricelistHistoriesIntake)
-		logging.WithField("capacity", len(in)).Info(
-			"Received pricelist-histories-intake-request, pushing onto handle channel",
-		)
-
-		in <- pRequest
-	})
+	err := messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.PricelistHistoriesIntake),
+		stop,
+		messenger.Endpoint[pricelistHistoriesIntakeRequest, messenger.Empty]{
+			Decode: newPricelistHistoriesIntakeRequest,
+			Encode: messenger.EncodeEmpty,
+			Handle: func(
+				_ context.Context,
+				pRequest pricelistHistoriesIntakeRequest,
+			) (messenger.Empty, codes.Code, error) {
+				sta.IO.Reporter.ReportWithPrefix(metric.Metrics{
+					"buffer_size": len(pRequest.RegionRealmTimestamps),
+				}, kinds.PricelistHistoriesIntake)
+				logging.WithField("capacity", len(in)).Info(
+					"Received pricelist-histories-intake-request, pushing onto handle channel",
+				)
+
+				in <- pRequest
+
+				return messenger.Empty{}, codes.Ok, nil
+			},
+		},
+	)
 	if err != nil {
 		return err
 	}
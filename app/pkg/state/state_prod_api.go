@@ -1,13 +1,18 @@
 package state
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"cloud.google.com/go/storage"
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/bus"
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
 	"github.com/sotah-inc/server/app/pkg/hell"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/messenger"
@@ -27,22 +32,35 @@ type ProdApiStateConfig struct {
 
 	GCloudProjectID string
 
-	MessengerHost string
-	MessengerPort int
+	MessengerHost    string
+	MessengerPort    int
+	MessengerConnect messenger.ConnectConfig
+
+	APIKeysDatabaseDir string
+	RecipesDatabaseDir string
+
+	// how often session secrets are rotated, where zero never rotates them
+	SessionSecretRotation time.Duration
 }
 
-func NewProdApiState(config ProdApiStateConfig) (ProdApiState, error) {
+func NewProdApiState(ctx context.Context, config ProdApiStateConfig) (ProdApiState, error) {
 	// establishing an initial state
 	apiState := ProdApiState{
-		State: NewState(uuid.NewV4(), config.SotahConfig.UseGCloud),
+		State:                NewState(uuid.NewV4(), config.SotahConfig.UseGCloud),
+		hellRegionRealmsLock: &sync.RWMutex{},
 	}
-	apiState.SessionSecret = uuid.NewV4()
 
 	// setting api-state from config, including filtering in regions based on config whitelist
 	apiState.Regions = config.SotahConfig.FilterInRegions(config.SotahConfig.Regions)
 	apiState.Expansions = config.SotahConfig.Expansions
 	apiState.Professions = config.SotahConfig.Professions
 
+	recipes, err := config.SotahConfig.ResolveRecipes()
+	if err != nil {
+		return ProdApiState{}, err
+	}
+	apiState.Recipes = recipes
+
 	// establishing a store
 	stor, err := store.NewClient(config.GCloudProjectID)
 	if err != nil {
@@ -53,17 +71,27 @@ func NewProdApiState(config ProdApiStateConfig) (ProdApiState, error) {
 	bootBase := store.NewBootBase(apiState.IO.StoreClient, regions.USCentral1)
 
 	var bootBucket *storage.BucketHandle
-	bootBucket, err = bootBase.GetFirmBucket()
+	bootBucket, err = bootBase.GetFirmBucket(ctx)
 	if err != nil {
 		return ProdApiState{}, err
 	}
-	blizzardCredentials, err := bootBase.GetBlizzardCredentials(bootBucket)
+	blizzardCredentials, err := bootBase.GetBlizzardCredentials(ctx, bootBucket)
+	if err != nil {
+		return ProdApiState{}, err
+	}
+
+	// loading session secrets from the boot bucket, so that sessions outlive restarts and are shared by every instance
+	apiState.SessionSecrets, err = NewSessionSecretKeeper(
+		ctx,
+		NewSessionSecretsBootObject(bootBase, bootBucket),
+		config.SessionSecretRotation,
+	)
 	if err != nil {
 		return ProdApiState{}, err
 	}
 
 	apiState.RealmsBase = store.NewRealmsBase(apiState.IO.StoreClient, regions.USCentral1, gameversions.Retail)
-	apiState.RealmsBucket, err = apiState.RealmsBase.GetFirmBucket()
+	apiState.RealmsBucket, err = apiState.RealmsBase.GetFirmBucket(ctx)
 	if err != nil {
 		return ProdApiState{}, err
 	}
@@ -85,7 +113,7 @@ func NewProdApiState(config ProdApiStateConfig) (ProdApiState, error) {
 	}
 
 	// connecting to the messenger host
-	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort)
+	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort, config.MessengerConnect)
 	if err != nil {
 		return ProdApiState{}, err
 	}
@@ -103,7 +131,7 @@ func NewProdApiState(config ProdApiStateConfig) (ProdApiState, error) {
 
 	// filling state with region statuses
 	for _, region := range apiState.Regions {
-		realms, err := apiState.RealmsBase.GetAllRealms(region.Name, apiState.RealmsBucket)
+		realms, err := apiState.RealmsBase.GetAllRealms(ctx, region.Name, apiState.RealmsBucket)
 		if err != nil {
 			return ProdApiState{}, err
 		}
@@ -135,14 +163,14 @@ func NewProdApiState(config ProdApiStateConfig) (ProdApiState, error) {
 
 	// gathering profession icons
 	itemIconsBase := store.NewItemIconsBase(stor, regions.USCentral1, gameversions.Retail)
-	itemIconsBucket, err := itemIconsBase.GetFirmBucket()
+	itemIconsBucket, err := itemIconsBase.GetFirmBucket(ctx)
 	if err != nil {
 		return ProdApiState{}, err
 	}
 	for i, prof := range apiState.Professions {
 		itemIconUrl, err := func() (string, error) {
 			obj := itemIconsBase.GetObject(prof.Icon, itemIconsBucket)
-			exists, err := itemIconsBase.ObjectExists(obj)
+			exists, err := itemIconsBase.ObjectExists(ctx, obj)
 			if err != nil {
 				return "", err
 			}
@@ -162,7 +190,7 @@ func NewProdApiState(config ProdApiStateConfig) (ProdApiState, error) {
 				return "", err
 			}
 
-			if err := itemIconsBase.Write(obj.NewWriter(stor.Context), body); err != nil {
+			if err := itemIconsBase.Write(obj.NewWriter(ctx), body); err != nil {
 				return "", err
 			}
 
@@ -179,6 +207,7 @@ func NewProdApiState(config ProdApiStateConfig) (ProdApiState, error) {
 		out := hell.RegionRealmsMap{}
 
 		hellRegionRealms, err := apiState.IO.HellClient.GetRegionRealms(
+			ctx,
 			apiState.Statuses.RegionRealmsMap().RegionRealmSlugs(),
 			gameversions.Retail,
 		)
@@ -192,6 +221,33 @@ func NewProdApiState(config ProdApiStateConfig) (ProdApiState, error) {
 		return ProdApiState{}, err
 	}
 
+	// loading the api-keys database, which is kept by the api alone
+	if err := util.EnsureDirExists(config.APIKeysDatabaseDir); err != nil {
+		return ProdApiState{}, err
+	}
+	apiKeysDatabase, err := database.NewAPIKeysDatabase(config.APIKeysDatabaseDir)
+	if err != nil {
+		return ProdApiState{}, err
+	}
+	apiState.IO.Databases.APIKeysDatabase = apiKeysDatabase
+
+	// loading the recipes database and seeding it with configured recipes
+	if err := util.EnsureDirExists(config.RecipesDatabaseDir); err != nil {
+		return ProdApiState{}, err
+	}
+	if _, err := database.MigrateSchemas(config.RecipesDatabaseDir, schemakinds.Recipes); err != nil {
+		return ProdApiState{}, err
+	}
+	recipesDatabase, err := database.NewRecipesDatabase(config.RecipesDatabaseDir)
+	if err != nil {
+		return ProdApiState{}, err
+	}
+	apiState.IO.Databases.RecipesDatabase = recipesDatabase
+
+	if err := recipesDatabase.PersistRecipes(apiState.Recipes); err != nil {
+		return ProdApiState{}, err
+	}
+
 	// establishing bus-listeners
 	apiState.BusListeners = NewBusListeners(SubjectBusListeners{
 		subjects.Status: apiState.ListenForBusStatus,
@@ -205,6 +261,12 @@ func NewProdApiState(config ProdApiStateConfig) (ProdApiState, error) {
 		subjects.ReceiveRealms:               apiState.ListenForReceiveRealms,
 		subjects.QueryRealmModificationDates: apiState.ListenForQueryRealmModificationDates,
 		subjects.RealmModificationDates:      apiState.ListenForRealmModificationDates,
+		subjects.APIKeysResolve:              apiState.ListenForAPIKeysResolve,
+		subjects.APIKeysUsage:                apiState.ListenForAPIKeysUsage,
+		subjects.APIKeysIssue:                apiState.ListenForAPIKeysIssue,
+		subjects.APIKeysRevoke:               apiState.ListenForAPIKeysRevoke,
+		subjects.APIKeysList:                 apiState.ListenForAPIKeysList,
+		subjects.CraftingProfits:             apiState.ListenForCraftingProfits,
 	})
 
 	return apiState, nil
@@ -216,14 +278,24 @@ type ProdApiState struct {
 	RealmsBase   store.RealmsBase
 	RealmsBucket *storage.BucketHandle
 
-	SessionSecret uuid.UUID
-	ItemClasses   blizzard.ItemClasses
-	Expansions    []sotah.Expansion
-	Professions   []sotah.Profession
-	ItemBlacklist ItemBlacklist
+	SessionSecrets SessionSecretKeeper
+	ItemClasses    blizzard.ItemClasses
+	Expansions     []sotah.Expansion
+	Professions    []sotah.Profession
+	Recipes        sotah.Recipes
+	ItemBlacklist  ItemBlacklist
 
 	BlizzardClientId     string
 	BlizzardClientSecret string
 
-	HellRegionRealms hell.RegionRealmsMap
+	// guards HellRegionRealms, which receiving realms merges into while listeners and health read
+	HellRegionRealms     hell.RegionRealmsMap
+	hellRegionRealmsLock *sync.RWMutex
+}
+
+func (sta ProdApiState) RealmModificationDates() sotah.RegionRealmModificationDates {
+	sta.hellRegionRealmsLock.RLock()
+	defer sta.hellRegionRealmsLock.RUnlock()
+
+	return sta.HellRegionRealms.ToRegionRealmModificationDates()
 }
@@ -0,0 +1,5 @@
+package state
+
+func (sta ProdApiState) ListenForCraftingProfits(stop ListenStopChan) error {
+	return serveCraftingProfits(sta.State, stop)
+}
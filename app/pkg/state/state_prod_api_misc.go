@@ -1,38 +1,29 @@
 package state
 
 import (
-	"encoding/json"
+	"context"
 
-	nats "github.com/nats-io/go-nats"
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	mCodes "github.com/sotah-inc/server/app/pkg/messenger/codes"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
 )
 
 func (sta ProdApiState) ListenForMessengerBoot(stop ListenStopChan) error {
-	err := sta.IO.Messenger.Subscribe(string(subjects.Boot), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		encodedResponse, err := json.Marshal(BootResponse{
-			Regions:     sta.Regions,
-			ItemClasses: sta.ItemClasses,
-			Expansions:  sta.Expansions,
-			Professions: sta.Professions,
-		})
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.MsgJSONParseError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		m.Data = string(encodedResponse)
-		sta.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.Boot),
+		stop,
+		messenger.Endpoint[messenger.Empty, BootResponse]{
+			Decode: messenger.DecodeEmpty,
+			Handle: func(_ context.Context, _ messenger.Empty) (BootResponse, mCodes.Code, error) {
+				return BootResponse{
+					Regions:           sta.Regions,
+					ItemClasses:       sta.ItemClasses,
+					Expansions:        sta.Expansions,
+					Professions:       sta.Professions,
+					ProfessionRecipes: sta.Recipes.ToProfessionRecipes(),
+				}, mCodes.Ok, nil
+			},
+		},
+	)
 }
@@ -1,7 +1,9 @@
 package state
 
 import (
-	nats "github.com/nats-io/go-nats"
+	"context"
+	"errors"
+
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/messenger"
@@ -11,61 +13,39 @@ import (
 )
 
 func (sta ProdApiState) ListenForQueryRealmModificationDates(stop ListenStopChan) error {
-	err := sta.IO.Messenger.Subscribe(string(subjects.QueryRealmModificationDates), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		req, err := NewRealmModificationDatesRequest(natsMsg.Data)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.GenericError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		logging.WithField("hell-region-realms", sta.HellRegionRealms.Total()).Info("Checking hell-region-realms")
-
-		hellRealms, ok := sta.HellRegionRealms[blizzard.RegionName(req.RegionName)]
-		if !ok {
-			m.Err = "region not found"
-			m.Code = mCodes.NotFound
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		hellRealm, ok := hellRealms[blizzard.RealmSlug(req.RealmSlug)]
-		if !ok {
-			m.Err = "realm not found"
-			m.Code = mCodes.NotFound
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		res := RealmModificationDatesResponse{
-			RealmModificationDates: sotah.RealmModificationDates{
-				Downloaded:                 int64(hellRealm.Downloaded),
-				LiveAuctionsReceived:       int64(hellRealm.LiveAuctionsReceived),
-				PricelistHistoriesReceived: int64(hellRealm.PricelistHistoriesReceived),
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.QueryRealmModificationDates),
+		stop,
+		messenger.Endpoint[RealmModificationDatesRequest, RealmModificationDatesResponse]{
+			Decode: NewRealmModificationDatesRequest,
+			Encode: messenger.EncodeBytes(RealmModificationDatesResponse.EncodeForDelivery),
+			Handle: func(
+				_ context.Context,
+				req RealmModificationDatesRequest,
+			) (RealmModificationDatesResponse, mCodes.Code, error) {
+				sta.hellRegionRealmsLock.RLock()
+				logging.WithField("hell-region-realms", sta.HellRegionRealms.Total()).Info("Checking hell-region-realms")
+
+				hellRealms, regionOk := sta.HellRegionRealms[blizzard.RegionName(req.RegionName)]
+				hellRealm, ok := hellRealms[blizzard.RealmSlug(req.RealmSlug)]
+				sta.hellRegionRealmsLock.RUnlock()
+				if !regionOk {
+					return RealmModificationDatesResponse{}, mCodes.NotFound, errors.New("region not found")
+				}
+
+				if !ok {
+					return RealmModificationDatesResponse{}, mCodes.NotFound, errors.New("realm not found")
+				}
+
+				return RealmModificationDatesResponse{
+					RealmModificationDates: sotah.RealmModificationDates{
+						Downloaded:                 int64(hellRealm.Downloaded),
+						LiveAuctionsReceived:       int64(hellRealm.LiveAuctionsReceived),
+						PricelistHistoriesReceived: int64(hellRealm.PricelistHistoriesReceived),
+					},
+				}, mCodes.Ok, nil
 			},
-		}
-
-		encodedData, err := res.EncodeForDelivery()
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.GenericError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		m.Data = string(encodedData)
-		sta.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+		},
+	)
 }
@@ -1,31 +1,28 @@
 package state
 
 import (
-	nats "github.com/nats-io/go-nats"
+	"context"
+
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	mCodes "github.com/sotah-inc/server/app/pkg/messenger/codes"
+	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
 )
 
 func (sta ProdApiState) ListenForRealmModificationDates(stop ListenStopChan) error {
-	err := sta.IO.Messenger.Subscribe(string(subjects.RealmModificationDates), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		encodedData, err := sta.HellRegionRealms.ToRegionRealmModificationDates().EncodeForDelivery()
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.GenericError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		m.Data = string(encodedData)
-		sta.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.RealmModificationDates),
+		stop,
+		messenger.Endpoint[messenger.Empty, sotah.RegionRealmModificationDates]{
+			Decode: messenger.DecodeEmpty,
+			Encode: messenger.EncodeBytes(sotah.RegionRealmModificationDates.EncodeForDelivery),
+			Handle: func(
+				_ context.Context,
+				_ messenger.Empty,
+			) (sotah.RegionRealmModificationDates, mCodes.Code, error) {
+				return sta.RealmModificationDates(), mCodes.Ok, nil
+			},
+		},
+	)
 }
@@ -1,9 +1,8 @@
 package state
 
 import (
-	"encoding/json"
+	"context"
 
-	nats "github.com/nats-io/go-nats"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	mCodes "github.com/sotah-inc/server/app/pkg/messenger/codes"
@@ -12,34 +11,27 @@ import (
 )
 
 func (sta ProdApiState) ListenForReceiveRealms(stop ListenStopChan) error {
-	err := sta.IO.Messenger.Subscribe(string(subjects.ReceiveRealms), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		var regionRealmSlugs map[blizzard.RegionName][]blizzard.RealmSlug
-		if err := json.Unmarshal(natsMsg.Data, &regionRealmSlugs); err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.GenericError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		hellRegionRealms, err := sta.IO.HellClient.GetRegionRealms(regionRealmSlugs, gameversions.Retail)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.GenericError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		sta.HellRegionRealms = sta.HellRegionRealms.Merge(hellRegionRealms)
-
-		sta.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.ReceiveRealms),
+		stop,
+		messenger.Endpoint[map[blizzard.RegionName][]blizzard.RealmSlug, messenger.Empty]{
+			Encode: messenger.EncodeEmpty,
+			Handle: func(
+				ctx context.Context,
+				regionRealmSlugs map[blizzard.RegionName][]blizzard.RealmSlug,
+			) (messenger.Empty, mCodes.Code, error) {
+				hellRegionRealms, err := sta.IO.HellClient.GetRegionRealms(ctx, regionRealmSlugs, gameversions.Retail)
+				if err != nil {
+					return messenger.Empty{}, mCodes.GenericError, err
+				}
+
+				sta.hellRegionRealmsLock.Lock()
+				sta.HellRegionRealms = sta.HellRegionRealms.Merge(hellRegionRealms)
+				sta.hellRegionRealmsLock.Unlock()
+
+				return messenger.Empty{}, mCodes.Ok, nil
+			},
+		},
+	)
 }
@@ -1,10 +1,10 @@
 package state
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
-	nats "github.com/nats-io/go-nats"
 	"github.com/sotah-inc/server/app/pkg/bus"
 	bCodes "github.com/sotah-inc/server/app/pkg/bus/codes"
 	"github.com/sotah-inc/server/app/pkg/logging"
@@ -22,14 +22,14 @@ func (sta ProdApiState) ListenForBusStatus(
 	// establishing subscriber config
 	config := bus.SubscribeConfig{
 		Stop: stop,
-		Callback: func(busMsg bus.Message) {
+		Callback: func(ctx context.Context, busMsg bus.Message) {
 			reply := bus.NewMessage()
 
 			sr, err := newStatusRequest([]byte(busMsg.Data))
 			if err != nil {
 				reply.Err = err.Error()
 				reply.Code = bCodes.MsgJSONParseError
-				if _, err := sta.IO.BusClient.ReplyTo(busMsg, reply); err != nil {
+				if _, err := sta.IO.BusClient.ReplyTo(ctx, busMsg, reply); err != nil {
 					logging.WithField("error", err.Error()).Error("Failed to reply")
 
 					return
@@ -50,7 +50,7 @@ func (sta ProdApiState) ListenForBusStatus(
 			if err != nil {
 				reply.Err = err.Error()
 				reply.Code = bCodes.NotFound
-				if _, err := sta.IO.BusClient.ReplyTo(busMsg, reply); err != nil {
+				if _, err := sta.IO.BusClient.ReplyTo(ctx, busMsg, reply); err != nil {
 					logging.WithField("error", err.Error()).Error("Failed to reply")
 
 					return
@@ -63,7 +63,7 @@ func (sta ProdApiState) ListenForBusStatus(
 			if !ok {
 				reply.Err = "Region found but not in Statuses"
 				reply.Code = bCodes.NotFound
-				if _, err := sta.IO.BusClient.ReplyTo(busMsg, reply); err != nil {
+				if _, err := sta.IO.BusClient.ReplyTo(ctx, busMsg, reply); err != nil {
 					logging.WithField("error", err.Error()).Error("Failed to reply")
 
 					return
@@ -76,7 +76,7 @@ func (sta ProdApiState) ListenForBusStatus(
 			if err != nil {
 				reply.Err = err.Error()
 				reply.Code = bCodes.GenericError
-				if _, err := sta.IO.BusClient.ReplyTo(busMsg, reply); err != nil {
+				if _, err := sta.IO.BusClient.ReplyTo(ctx, busMsg, reply); err != nil {
 					logging.WithField("error", err.Error()).Error("Failed to reply")
 
 					return
@@ -86,7 +86,7 @@ func (sta ProdApiState) ListenForBusStatus(
 			}
 
 			reply.Data = string(encodedStatus)
-			if _, err := sta.IO.BusClient.ReplyTo(busMsg, reply); err != nil {
+			if _, err := sta.IO.BusClient.ReplyTo(ctx, busMsg, reply); err != nil {
 				logging.WithField("error", err.Error()).Error("Failed to reply")
 
 				return
@@ -98,66 +98,41 @@ func (sta ProdApiState) ListenForBusStatus(
 
 	// starting up worker for the subscription
 	go func() {
-		if err := sta.IO.BusClient.SubscribeToTopic(string(subjects.Status), config); err != nil {
+		err := sta.IO.BusClient.SubscribeToTopic(context.Background(), string(subjects.Status), config)
+		if err != nil {
 			logging.WithField("error", err.Error()).Fatal("Failed to subscribe to topic")
 		}
 	}()
 }
 
 func (sta ProdApiState) ListenForMessengerStatus(stop ListenStopChan) error {
-	err := sta.IO.Messenger.Subscribe(string(subjects.Status), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		sr, err := newStatusRequest(natsMsg.Data)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.MsgJSONParseError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
+	return messenger.Serve(
+		sta.IO.Messenger,
+		string(subjects.Status),
+		stop,
+		messenger.Endpoint[StatusRequest, sotah.Status]{
+			Decode: newStatusRequest,
+			Handle: func(_ context.Context, sr StatusRequest) (sotah.Status, mCodes.Code, error) {
+				reg, err := func() (sotah.Region, error) {
+					for _, r := range sta.Regions {
+						if r.Name == sr.RegionName {
+							return r, nil
+						}
+					}
 
-		reg, err := func() (sotah.Region, error) {
-			for _, r := range sta.Regions {
-				if r.Name == sr.RegionName {
-					return r, nil
+					return sotah.Region{}, errors.New("could not find region")
+				}()
+				if err != nil {
+					return sotah.Status{}, mCodes.NotFound, err
 				}
-			}
-
-			return sotah.Region{}, errors.New("could not find region")
-		}()
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.NotFound
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
 
-		regionStatus, ok := sta.Statuses[reg.Name]
-		if !ok {
-			m.Err = "Region found but not in Statuses"
-			m.Code = mCodes.NotFound
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		encodedStatus, err := json.Marshal(regionStatus)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.GenericError
-			sta.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		m.Data = string(encodedStatus)
-		sta.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
+				regionStatus, ok := sta.Statuses[reg.Name]
+				if !ok {
+					return sotah.Status{}, mCodes.NotFound, errors.New("Region found but not in Statuses")
+				}
 
-	return nil
+				return regionStatus, mCodes.Ok, nil
+			},
+		},
+	)
 }
@@ -1,12 +1,16 @@
 package state
 
 import (
+	"context"
+
 	"cloud.google.com/go/storage"
 	"github.com/sotah-inc/server/app/pkg/bus"
 	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	"github.com/sotah-inc/server/app/pkg/metric"
+	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/sotah/gameversions"
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
 	"github.com/sotah-inc/server/app/pkg/store"
@@ -17,20 +21,22 @@ import (
 type ProdItemsStateConfig struct {
 	GCloudProjectID string
 
-	MessengerHost string
-	MessengerPort int
+	MessengerHost    string
+	MessengerPort    int
+	MessengerConnect messenger.ConnectConfig
 
 	ItemsDatabaseDir string
+	Snapshots        sotah.SnapshotConfig
 }
 
-func NewProdItemsState(config ProdItemsStateConfig) (ProdItemsState, error) {
+func NewProdItemsState(ctx context.Context, config ProdItemsStateConfig) (ProdItemsState, error) {
 	// establishing an initial state
 	itemsState := ProdItemsState{
 		State: NewState(uuid.NewV4(), true),
 	}
 
 	// connecting to the messenger host
-	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort)
+	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort, config.MessengerConnect)
 	if err != nil {
 		return ProdItemsState{}, err
 	}
@@ -52,7 +58,7 @@ func NewProdItemsState(config ProdItemsStateConfig) (ProdItemsState, error) {
 	itemsState.IO.StoreClient = storeClient
 
 	itemsState.ItemsBase = store.NewItemsBase(storeClient, regions.USCentral1, gameversions.Retail)
-	itemsState.ItemsBucket, err = itemsState.ItemsBase.GetFirmBucket()
+	itemsState.ItemsBucket, err = itemsState.ItemsBase.GetFirmBucket(ctx)
 	if err != nil {
 		return ProdItemsState{}, err
 	}
@@ -62,12 +68,30 @@ func NewProdItemsState(config ProdItemsStateConfig) (ProdItemsState, error) {
 
 	// loading the items database
 	logging.Info("Connecting to items database")
+	if _, err := database.MigrateSchemas(config.ItemsDatabaseDir, schemakinds.Items); err != nil {
+		return ProdItemsState{}, err
+	}
 	iBase, err := database.NewItemsDatabase(config.ItemsDatabaseDir)
 	if err != nil {
 		return ProdItemsState{}, err
 	}
 	itemsState.IO.Databases.ItemsDatabase = iBase
 
+	// establishing a snapshotter for the items database
+	itemsState.Snapshotter, err = NewSnapshotter(
+		ctx,
+		schemakinds.Items,
+		config.ItemsDatabaseDir,
+		config.Snapshots,
+		storeClient,
+		func() ([]database.SnapshotTarget, error) {
+			return iBase.SnapshotTargets(config.ItemsDatabaseDir)
+		},
+	)
+	if err != nil {
+		return ProdItemsState{}, err
+	}
+
 	// establishing bus-listeners
 	itemsState.BusListeners = NewBusListeners(SubjectBusListeners{
 		subjects.FilterInItemsToSync: itemsState.ListenForFilterIn,
@@ -88,4 +112,5 @@ type ProdItemsState struct {
 
 	ItemsBase   store.ItemsBase
 	ItemsBucket *storage.BucketHandle
+	Snapshotter Snapshotter
 }
@@ -1,6 +1,7 @@
 package state
 
 import (
+	"context"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -11,7 +12,7 @@ import (
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
 )
 
-func HandleFilterInItemsToSync(busMsg bus.Message, itemsState ProdItemsState, ids blizzard.ItemIds) error {
+func HandleFilterInItemsToSync(ctx context.Context, busMsg bus.Message, itemsState ProdItemsState, ids blizzard.ItemIds) error {
 	syncPayload, err := itemsState.IO.Databases.ItemsDatabase.FilterInItemsToSync(ids)
 	if err != nil {
 		return err
@@ -30,7 +31,7 @@ func HandleFilterInItemsToSync(busMsg bus.Message, itemsState ProdItemsState, id
 	reply := bus.NewMessage()
 	reply.Data = data
 
-	if _, err := itemsState.IO.BusClient.ReplyTo(busMsg, reply); err != nil {
+	if _, err := itemsState.IO.BusClient.ReplyTo(ctx, busMsg, reply); err != nil {
 		return err
 	}
 
@@ -51,7 +52,7 @@ func (itemsState ProdItemsState) ListenForFilterIn(
 	// establishing subscriber config
 	config := bus.SubscribeConfig{
 		Stop: stop,
-		Callback: func(busMsg bus.Message) {
+		Callback: func(ctx context.Context, busMsg bus.Message) {
 			ids, err := blizzard.NewItemIds(busMsg.Data)
 			if err != nil {
 				logging.WithField("error", err.Error()).Error("Failed to decode item-ids")
@@ -62,14 +63,14 @@ func (itemsState ProdItemsState) ListenForFilterIn(
 			// handling item-ids
 			logging.WithField("item-ids", len(ids)).Info("Filtering item-ids")
 			startTime := time.Now()
-			if err := HandleFilterInItemsToSync(busMsg, itemsState, ids); err != nil {
+			if err := HandleFilterInItemsToSync(ctx, busMsg, itemsState, ids); err != nil {
 				logging.WithField("error", err.Error()).Error("Failed to filter in items to sync")
 			}
 			logging.WithField("item-ids", len(ids)).Info("Done filtering item-ids")
 
 			// reporting metrics
 			m := metric.Metrics{"filter_in_items_to_sync": int(int64(time.Since(startTime)) / 1000 / 1000 / 1000)}
-			if err := itemsState.IO.BusClient.PublishMetrics(m); err != nil {
+			if err := itemsState.IO.BusClient.PublishMetrics(ctx, m); err != nil {
 				logging.WithField("error", err.Error()).Error("Failed to publish metric")
 
 				return
@@ -81,7 +82,12 @@ func (itemsState ProdItemsState) ListenForFilterIn(
 
 	// starting up worker for the subscription
 	go func() {
-		if err := itemsState.IO.BusClient.SubscribeToTopic(string(subjects.FilterInItemsToSync), config); err != nil {
+		err := itemsState.IO.BusClient.SubscribeToTopic(
+			context.Background(),
+			string(subjects.FilterInItemsToSync),
+			config,
+		)
+		if err != nil {
 			logging.WithField("error", err.Error()).Fatal("Failed to subscribe to topic")
 		}
 	}()
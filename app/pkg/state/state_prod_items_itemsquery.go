@@ -1,9 +1,9 @@
 package state
 
 import (
+	"context"
 	"time"
 
-	nats "github.com/nats-io/go-nats"
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/database"
 	dCodes "github.com/sotah-inc/server/app/pkg/database/codes"
@@ -14,52 +14,31 @@ import (
 )
 
 func (itemsState ProdItemsState) ListenForItemsQuery(stop ListenStopChan) error {
-	err := itemsState.IO.Messenger.Subscribe(string(subjects.ItemsQuery), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		// resolving the request
-		request, err := database.NewQueryItemsRequest(natsMsg.Data)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.MsgJSONParseError
-			itemsState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// querying the items-database
-		startTime := time.Now()
-		resp, respCode, err := itemsState.IO.Databases.ItemsDatabase.QueryItems(request)
-		if respCode != dCodes.Ok {
-			m.Err = err.Error()
-			m.Code = DatabaseCodeToMessengerCode(respCode)
-			itemsState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-		duration := time.Since(startTime)
-		logging.WithFields(logrus.Fields{
-			"query":          request.Query,
-			"duration-in-ms": int64(duration) / 1000 / 1000,
-		}).Info("Queried items")
-
-		// marshalling for messenger
-		encodedMessage, err := resp.EncodeForDelivery()
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.GenericError
-			itemsState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// dumping it out
-		m.Data = string(encodedMessage)
-		itemsState.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return messenger.Serve(
+		itemsState.IO.Messenger,
+		string(subjects.ItemsQuery),
+		stop,
+		messenger.Endpoint[database.QueryItemsRequest, database.QueryItemsResponse]{
+			Decode: database.NewQueryItemsRequest,
+			Encode: messenger.EncodeBytes(database.QueryItemsResponse.EncodeForDelivery),
+			Handle: func(
+				ctx context.Context,
+				request database.QueryItemsRequest,
+			) (database.QueryItemsResponse, mCodes.Code, error) {
+				// querying the items-database
+				startTime := time.Now()
+				resp, respCode, err := itemsState.IO.Databases.ItemsDatabase.QueryItems(ctx, request)
+				if respCode != dCodes.Ok {
+					return database.QueryItemsResponse{}, DatabaseCodeToMessengerCode(respCode), err
+				}
+				duration := time.Since(startTime)
+				logging.WithFields(logrus.Fields{
+					"query":          request.Query,
+					"duration-in-ms": int64(duration) / 1000 / 1000,
+				}).Info("Queried items")
+
+				return resp, mCodes.Ok, nil
+			},
+		},
+	)
 }
@@ -1,6 +1,7 @@
 package state
 
 import (
+	"context"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -12,12 +13,12 @@ import (
 	"github.com/sotah-inc/server/app/pkg/state/subjects"
 )
 
-func ReceiveSyncedItems(itemsState ProdItemsState, idNameMap sotah.ItemIdNameMap) error {
+func ReceiveSyncedItems(ctx context.Context, itemsState ProdItemsState, idNameMap sotah.ItemIdNameMap) error {
 	// declare channels for persisting in
 	encodedIn := make(chan database.PersistEncodedItemsInJob)
 
 	// declaring channel for fetching
-	getItemsOut := itemsState.ItemsBase.GetItems(idNameMap.ItemIds(), itemsState.ItemsBucket)
+	getItemsOut := itemsState.ItemsBase.GetItems(ctx, idNameMap.ItemIds(), itemsState.ItemsBucket)
 
 	// spinning up a goroutine to multiplex the results between get-items and persist-encoded-items
 	go func() {
@@ -40,6 +41,9 @@ func ReceiveSyncedItems(itemsState ProdItemsState, idNameMap sotah.ItemIdNameMap
 	return itemsState.IO.Databases.ItemsDatabase.PersistEncodedItems(encodedIn, idNameMap)
 }
 
+// receiveSyncedItemsTimeout - the deadline for receiving one batch of synced items, past which it is abandoned
+const receiveSyncedItemsTimeout = 10 * time.Minute
+
 func (itemsState ProdItemsState) ListenForSyncedItems(
 	onReady chan interface{},
 	stop chan interface{},
@@ -56,7 +60,8 @@ func (itemsState ProdItemsState) ListenForSyncedItems(
 			}).Info("Received synced item-ids")
 
 			startTime := time.Now()
-			if err := ReceiveSyncedItems(itemsState, idNameMap); err != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), receiveSyncedItemsTimeout)
+			if err := ReceiveSyncedItems(ctx, itemsState, idNameMap); err != nil {
 				logging.WithField("error", err.Error()).Error("Failed to receive synced items")
 			}
 			logging.WithFields(logrus.Fields{
@@ -66,7 +71,9 @@ func (itemsState ProdItemsState) ListenForSyncedItems(
 
 			// reporting metrics
 			m := metric.Metrics{"receive_synced_items": int(int64(time.Since(startTime)) / 1000 / 1000 / 1000)}
-			if err := itemsState.IO.BusClient.PublishMetrics(m); err != nil {
+			err := itemsState.IO.BusClient.PublishMetrics(ctx, m)
+			cancel()
+			if err != nil {
 				logging.WithField("error", err.Error()).Error("Failed to publish metric")
 
 				continue
@@ -77,7 +84,7 @@ func (itemsState ProdItemsState) ListenForSyncedItems(
 	// establishing subscriber config
 	config := bus.SubscribeConfig{
 		Stop: stop,
-		Callback: func(busMsg bus.Message) {
+		Callback: func(ctx context.Context, busMsg bus.Message) {
 			logging.WithField("subject", subjects.ReceiveSyncedItems).Info("Received message")
 
 			idNormalizedNameMap, err := sotah.NewItemIdNameMap(busMsg.Data)
@@ -95,7 +102,12 @@ func (itemsState ProdItemsState) ListenForSyncedItems(
 
 	// starting up worker for the subscription
 	go func() {
-		if err := itemsState.IO.BusClient.SubscribeToTopic(string(subjects.ReceiveSyncedItems), config); err != nil {
+		err := itemsState.IO.BusClient.SubscribeToTopic(
+			context.Background(),
+			string(subjects.ReceiveSyncedItems),
+			config,
+		)
+		if err != nil {
 			logging.WithField("error", err.Error()).Fatal("Failed to subscribe to topic")
 		}
 	}()
@@ -1,11 +1,13 @@
 package state
 
 import (
+	"context"
 	"fmt"
 
 	"cloud.google.com/go/storage"
 	"github.com/sotah-inc/server/app/pkg/bus"
 	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	"github.com/sotah-inc/server/app/pkg/metric"
@@ -21,20 +23,23 @@ import (
 type ProdLiveAuctionsStateConfig struct {
 	GCloudProjectID string
 
-	MessengerHost string
-	MessengerPort int
+	MessengerHost    string
+	MessengerPort    int
+	MessengerConnect messenger.ConnectConfig
 
 	LiveAuctionsDatabaseDir string
+	Retention               sotah.RetentionConfig
+	Snapshots               sotah.SnapshotConfig
 }
 
-func NewProdLiveAuctionsState(config ProdLiveAuctionsStateConfig) (ProdLiveAuctionsState, error) {
+func NewProdLiveAuctionsState(ctx context.Context, config ProdLiveAuctionsStateConfig) (ProdLiveAuctionsState, error) {
 	// establishing an initial state
 	liveAuctionsState := ProdLiveAuctionsState{
 		State: NewState(uuid.NewV4(), true),
 	}
 
 	// connecting to the messenger host
-	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort)
+	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort, config.MessengerConnect)
 	if err != nil {
 		return ProdLiveAuctionsState{}, err
 	}
@@ -56,7 +61,7 @@ func NewProdLiveAuctionsState(config ProdLiveAuctionsStateConfig) (ProdLiveAucti
 	liveAuctionsState.IO.StoreClient = storeClient
 
 	liveAuctionsState.LiveAuctionsBase = store.NewLiveAuctionsBase(storeClient, regions.USCentral1, gameversions.Retail)
-	liveAuctionsState.LiveAuctionsBucket, err = liveAuctionsState.LiveAuctionsBase.GetFirmBucket()
+	liveAuctionsState.LiveAuctionsBucket, err = liveAuctionsState.LiveAuctionsBase.GetFirmBucket(ctx)
 	if err != nil {
 		return ProdLiveAuctionsState{}, err
 	}
@@ -65,25 +70,25 @@ func NewProdLiveAuctionsState(config ProdLiveAuctionsStateConfig) (ProdLiveAucti
 
 	// gathering region-realms
 	statuses := sotah.Statuses{}
-	bootBucket, err := bootBase.GetFirmBucket()
+	bootBucket, err := bootBase.GetFirmBucket(ctx)
 	if err != nil {
 		return ProdLiveAuctionsState{}, err
 	}
 
-	regions, err := bootBase.GetRegions(bootBucket)
+	regions, err := bootBase.GetRegions(ctx, bootBucket)
 	if err != nil {
 		return ProdLiveAuctionsState{}, err
 	}
 
 	realmsBase := store.NewRealmsBase(storeClient, "us-central1", gameversions.Retail)
-	realmsBucket, err := realmsBase.GetFirmBucket()
+	realmsBucket, err := realmsBase.GetFirmBucket(ctx)
 	if err != nil {
 		return ProdLiveAuctionsState{}, err
 	}
 
 	regionRealms := sotah.RegionRealms{}
 	for _, region := range regions {
-		realms, err := realmsBase.GetAllRealms(region.Name, realmsBucket)
+		realms, err := realmsBase.GetAllRealms(ctx, region.Name, realmsBucket)
 		if err != nil {
 			return ProdLiveAuctionsState{}, err
 		}
@@ -116,12 +121,30 @@ func NewProdLiveAuctionsState(config ProdLiveAuctionsStateConfig) (ProdLiveAucti
 
 	// loading the live-auctions databases
 	logging.Info("Connecting to live-auctions databases")
+	if _, err := database.MigrateSchemas(config.LiveAuctionsDatabaseDir, schemakinds.LiveAuctions); err != nil {
+		return ProdLiveAuctionsState{}, err
+	}
 	ladBases, err := database.NewLiveAuctionsDatabases(config.LiveAuctionsDatabaseDir, liveAuctionsState.Statuses)
 	if err != nil {
 		return ProdLiveAuctionsState{}, err
 	}
 	liveAuctionsState.IO.Databases.LiveAuctionsDatabases = ladBases
 
+	// establishing a snapshotter for the live-auctions databases
+	liveAuctionsState.Snapshotter, err = NewSnapshotter(
+		ctx,
+		schemakinds.LiveAuctions,
+		config.LiveAuctionsDatabaseDir,
+		config.Snapshots,
+		storeClient,
+		func() ([]database.SnapshotTarget, error) {
+			return ladBases.SnapshotTargets(config.LiveAuctionsDatabaseDir)
+		},
+	)
+	if err != nil {
+		return ProdLiveAuctionsState{}, err
+	}
+
 	// establishing bus-listeners
 	liveAuctionsState.BusListeners = NewBusListeners(SubjectBusListeners{
 		subjects.ReceiveComputedLiveAuctions: liveAuctionsState.ListenForComputedLiveAuctions,
@@ -143,4 +166,5 @@ type ProdLiveAuctionsState struct {
 
 	LiveAuctionsBase   store.LiveAuctionsBase
 	LiveAuctionsBucket *storage.BucketHandle
+	Snapshotter        Snapshotter
 }
@@ -1,9 +1,9 @@
 package state
 
 import (
+	"context"
 	"time"
 
-	nats "github.com/nats-io/go-nats"
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/database"
 	dCodes "github.com/sotah-inc/server/app/pkg/database/codes"
@@ -14,54 +14,33 @@ import (
 )
 
 func (liveAuctionsState ProdLiveAuctionsState) ListenForOwnersQuery(stop ListenStopChan) error {
-	err := liveAuctionsState.IO.Messenger.Subscribe(string(subjects.OwnersQuery), stop, func(natsMsg nats.Msg) {
-		m := messenger.NewMessage()
-
-		// resolving the request
-		request, err := database.NewQueryOwnersRequest(natsMsg.Data)
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.MsgJSONParseError
-			liveAuctionsState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// querying the live-auctions-databases
-		startTime := time.Now()
-		resp, respCode, err := liveAuctionsState.IO.Databases.LiveAuctionsDatabases.QueryOwners(request)
-		if respCode != dCodes.Ok {
-			m.Err = err.Error()
-			m.Code = DatabaseCodeToMessengerCode(respCode)
-			liveAuctionsState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-		duration := time.Since(startTime)
-		logging.WithFields(logrus.Fields{
-			"region":         request.RegionName,
-			"realm":          request.RealmSlug,
-			"query":          request.Query,
-			"duration-in-ms": int64(duration) / 1000 / 1000,
-		}).Info("Queried owners")
-
-		// marshalling for messenger
-		encodedMessage, err := resp.EncodeForDelivery()
-		if err != nil {
-			m.Err = err.Error()
-			m.Code = mCodes.GenericError
-			liveAuctionsState.IO.Messenger.ReplyTo(natsMsg, m)
-
-			return
-		}
-
-		// dumping it out
-		m.Data = string(encodedMessage)
-		liveAuctionsState.IO.Messenger.ReplyTo(natsMsg, m)
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return messenger.Serve(
+		liveAuctionsState.IO.Messenger,
+		string(subjects.OwnersQuery),
+		stop,
+		messenger.Endpoint[database.QueryOwnersRequest, database.QueryOwnersResponse]{
+			Decode: database.NewQueryOwnersRequest,
+			Encode: messenger.EncodeBytes(database.QueryOwnersResponse.EncodeForDelivery),
+			Handle: func(
+				ctx context.Context,
+				request database.QueryOwnersRequest,
+			) (database.QueryOwnersResponse, mCodes.Code, error) {
+				// querying the live-auctions-databases
+				startTime := time.Now()
+				resp, respCode, err := liveAuctionsState.IO.Databases.LiveAuctionsDatabases.QueryOwners(ctx, request)
+				if respCode != dCodes.Ok {
+					return database.QueryOwnersResponse{}, DatabaseCodeToMessengerCode(respCode), err
+				}
+				duration := time.Since(startTime)
+				logging.WithFields(logrus.Fields{
+					"region":         request.RegionName,
+					"realm":          request.RealmSlug,
+					"query":          request.Query,
+					"duration-in-ms": int64(duration) / 1000 / 1000,
+				}).Info("Queried owners")
+
+				return resp, mCodes.Ok, nil
+			},
+		},
+	)
 }
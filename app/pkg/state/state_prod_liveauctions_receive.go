@@ -1,8 +1,8 @@
 package state
 
 import (
+	"context"
 	"errors"
-	"io/ioutil"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -16,10 +16,10 @@ import (
 	"github.com/sotah-inc/server/app/pkg/util"
 )
 
-func HandleComputedLiveAuctions(liveAuctionsState ProdLiveAuctionsState, tuples bus.RegionRealmTimestampTuples) {
+func HandleComputedLiveAuctions(ctx context.Context, liveAuctionsState ProdLiveAuctionsState, tuples bus.RegionRealmTimestampTuples) {
 	// declaring a load-in channel for the live-auctions db and starting it up
 	loadInJobs := make(chan database.LiveAuctionsLoadEncodedDataInJob)
-	loadOutJobs := liveAuctionsState.IO.Databases.LiveAuctionsDatabases.LoadEncodedData(loadInJobs)
+	loadOutJobs := liveAuctionsState.IO.Databases.LiveAuctionsDatabases.LoadEncodedData(ctx, loadInJobs)
 
 	// starting workers for handling tuples
 	in := make(chan bus.RegionRealmTimestampTuple)
@@ -50,20 +50,11 @@ func HandleComputedLiveAuctions(liveAuctionsState ProdLiveAuctionsState, tuples
 			}
 
 			// resolving the data
-			data, err := func() ([]byte, error) {
-				obj, err := liveAuctionsState.LiveAuctionsBase.GetFirmObject(realm, liveAuctionsState.LiveAuctionsBucket)
-				if err != nil {
-					return []byte{}, err
-				}
-
-				reader, err := obj.ReadCompressed(true).NewReader(liveAuctionsState.IO.StoreClient.Context)
-				if err != nil {
-					return []byte{}, err
-				}
-				defer reader.Close()
-
-				return ioutil.ReadAll(reader)
-			}()
+			data, err := liveAuctionsState.LiveAuctionsBase.GetCompressedData(
+				ctx,
+				realm,
+				liveAuctionsState.LiveAuctionsBucket,
+			)
 			if err != nil {
 				logging.WithField("error", err.Error()).Error("Failed to get data")
 
@@ -119,7 +110,7 @@ func (liveAuctionsState ProdLiveAuctionsState) ListenForComputedLiveAuctions(
 	// establishing subscriber config
 	config := bus.SubscribeConfig{
 		Stop: stop,
-		Callback: func(busMsg bus.Message) {
+		Callback: func(ctx context.Context, busMsg bus.Message) {
 			tuples, err := bus.NewRegionRealmTimestampTuples(busMsg.Data)
 			if err != nil {
 				logging.WithField("error", err.Error()).Error("Failed to decode region-realm-timestamps tuples")
@@ -130,14 +121,14 @@ func (liveAuctionsState ProdLiveAuctionsState) ListenForComputedLiveAuctions(
 			// handling requests
 			logging.WithField("requests", len(tuples)).Info("Received tuples")
 			startTime := time.Now()
-			HandleComputedLiveAuctions(liveAuctionsState, tuples)
+			HandleComputedLiveAuctions(ctx, liveAuctionsState, tuples)
 			logging.WithField("requests", len(tuples)).Info("Done handling tuples")
 
 			// reporting metrics
 			m := metric.Metrics{
 				"receive_all_live_auctions_duration": int(int64(time.Since(startTime)) / 1000 / 1000 / 1000),
 			}
-			if err := liveAuctionsState.IO.BusClient.PublishMetrics(m); err != nil {
+			if err := liveAuctionsState.IO.BusClient.PublishMetrics(ctx, m); err != nil {
 				logging.WithField("error", err.Error()).Error("Failed to publish metric")
 
 				return
@@ -150,6 +141,7 @@ func (liveAuctionsState ProdLiveAuctionsState) ListenForComputedLiveAuctions(
 	// starting up worker for the subscription
 	go func() {
 		if err := liveAuctionsState.IO.BusClient.SubscribeToTopic(
+			context.Background(),
 			string(subjects.ReceiveComputedLiveAuctions),
 			config,
 		); err != nil {
@@ -1,6 +1,7 @@
 package state
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/sotah-inc/server/app/pkg/bus"
@@ -14,8 +15,9 @@ import (
 type ProdMetricsStateConfig struct {
 	GCloudProjectID string
 
-	MessengerHost string
-	MessengerPort int
+	MessengerHost    string
+	MessengerPort    int
+	MessengerConnect messenger.ConnectConfig
 }
 
 func NewProdMetricsState(config ProdMetricsStateConfig) (ProdMetricsState, error) {
@@ -25,7 +27,7 @@ func NewProdMetricsState(config ProdMetricsStateConfig) (ProdMetricsState, error
 	}
 
 	// connecting to the messenger host
-	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort)
+	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort, config.MessengerConnect)
 	if err != nil {
 		return ProdMetricsState{}, err
 	}
@@ -62,7 +64,7 @@ func (metricsState ProdMetricsState) ListenForMetrics(
 	// establishing subscriber config
 	config := bus.SubscribeConfig{
 		Stop: stop,
-		Callback: func(busMsg bus.Message) {
+		Callback: func(ctx context.Context, busMsg bus.Message) {
 			var m metric.Metrics
 			if err := json.Unmarshal([]byte(busMsg.Data), &m); err != nil {
 				logging.WithField("error", err.Error()).Error("Failed to marshal metrics")
@@ -78,7 +80,8 @@ func (metricsState ProdMetricsState) ListenForMetrics(
 
 	// starting up worker for the subscription
 	go func() {
-		if err := metricsState.IO.BusClient.SubscribeToTopic(string(subjects.AppMetrics), config); err != nil {
+		err := metricsState.IO.BusClient.SubscribeToTopic(context.Background(), string(subjects.AppMetrics), config)
+		if err != nil {
 			logging.WithField("error", err.Error()).Fatal("Failed to subscribe to topic")
 		}
 	}()
@@ -1,11 +1,13 @@
 package state
 
 import (
+	"context"
 	"fmt"
 
 	"cloud.google.com/go/storage"
 	"github.com/sotah-inc/server/app/pkg/bus"
 	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/messenger"
 	"github.com/sotah-inc/server/app/pkg/metric"
@@ -21,20 +23,23 @@ import (
 type ProdPricelistHistoriesStateConfig struct {
 	GCloudProjectID string
 
-	MessengerHost string
-	MessengerPort int
+	MessengerHost    string
+	MessengerPort    int
+	MessengerConnect messenger.ConnectConfig
 
 	PricelistHistoriesDatabaseDir string
+
+	Retention sotah.RetentionConfig
 }
 
-func NewProdPricelistHistoriesState(config ProdPricelistHistoriesStateConfig) (ProdPricelistHistoriesState, error) {
+func NewProdPricelistHistoriesState(ctx context.Context, config ProdPricelistHistoriesStateConfig) (ProdPricelistHistoriesState, error) {
 	// establishing an initial state
 	phState := ProdPricelistHistoriesState{
 		State: NewState(uuid.NewV4(), true),
 	}
 
 	// connecting to the messenger host
-	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort)
+	mess, err := messenger.NewMessenger(config.MessengerHost, config.MessengerPort, config.MessengerConnect)
 	if err != nil {
 		return ProdPricelistHistoriesState{}, err
 	}
@@ -60,7 +65,7 @@ func NewProdPricelistHistoriesState(config ProdPricelistHistoriesStateConfig) (P
 		regions.USCentral1,
 		gameversions.Retail,
 	)
-	phState.PricelistHistoriesBucket, err = phState.PricelistHistoriesBase.GetFirmBucket()
+	phState.PricelistHistoriesBucket, err = phState.PricelistHistoriesBase.GetFirmBucket(ctx)
 	if err != nil {
 		return ProdPricelistHistoriesState{}, err
 	}
@@ -69,25 +74,25 @@ func NewProdPricelistHistoriesState(config ProdPricelistHistoriesStateConfig) (P
 
 	// gathering region-realms
 	statuses := sotah.Statuses{}
-	bootBucket, err := bootBase.GetFirmBucket()
+	bootBucket, err := bootBase.GetFirmBucket(ctx)
 	if err != nil {
 		return ProdPricelistHistoriesState{}, err
 	}
 
-	regions, err := bootBase.GetRegions(bootBucket)
+	regions, err := bootBase.GetRegions(ctx, bootBucket)
 	if err != nil {
 		return ProdPricelistHistoriesState{}, err
 	}
 
 	realmsBase := store.NewRealmsBase(storeClient, "us-central1", gameversions.Retail)
-	realmsBucket, err := realmsBase.GetFirmBucket()
+	realmsBucket, err := realmsBase.GetFirmBucket(ctx)
 	if err != nil {
 		return ProdPricelistHistoriesState{}, err
 	}
 
 	regionRealms := sotah.RegionRealms{}
 	for _, region := range regions {
-		realms, err := realmsBase.GetAllRealms(region.Name, realmsBucket)
+		realms, err := realmsBase.GetAllRealms(ctx, region.Name, realmsBucket)
 		if err != nil {
 			return ProdPricelistHistoriesState{}, err
 		}
@@ -102,6 +107,12 @@ func NewProdPricelistHistoriesState(config ProdPricelistHistoriesStateConfig) (P
 	// ensuring database paths exist
 	databasePaths := []string{}
 	for regionName, realms := range regionRealms {
+		databasePaths = append(databasePaths, fmt.Sprintf(
+			"%s/region-pricelist-histories/%s",
+			config.PricelistHistoriesDatabaseDir,
+			regionName,
+		))
+
 		for _, realm := range realms {
 			databasePaths = append(databasePaths, fmt.Sprintf(
 				"%s/pricelist-histories/%s/%s",
@@ -118,14 +129,42 @@ func NewProdPricelistHistoriesState(config ProdPricelistHistoriesStateConfig) (P
 	// initializing a reporter
 	phState.IO.Reporter = metric.NewReporter(mess)
 
+	// upgrading the databases ahead of loading them
+	_, err = database.MigrateSchemas(
+		config.PricelistHistoriesDatabaseDir,
+		schemakinds.PricelistHistories,
+		schemakinds.PricelistHistoryRollups,
+		schemakinds.RegionPricelistHistories,
+		schemakinds.Meta,
+	)
+	if err != nil {
+		return ProdPricelistHistoriesState{}, err
+	}
+
 	// loading the pricelist-histories databases
 	logging.Info("Connecting to pricelist-histories databases")
-	phdBases, err := database.NewPricelistHistoryDatabases(config.PricelistHistoriesDatabaseDir, phState.Statuses)
+	phdBases, err := database.NewPricelistHistoryDatabases(
+		config.PricelistHistoriesDatabaseDir,
+		phState.Statuses,
+		config.Retention,
+	)
 	if err != nil {
 		return ProdPricelistHistoriesState{}, err
 	}
 	phState.IO.Databases.PricelistHistoryDatabases = phdBases
 
+	// loading the region pricelist-histories databases
+	logging.Info("Connecting to region pricelist-histories databases")
+	rphdBases, err := database.NewRegionPricelistHistoryDatabases(
+		config.PricelistHistoriesDatabaseDir,
+		phState.Statuses,
+		config.Retention,
+	)
+	if err != nil {
+		return ProdPricelistHistoriesState{}, err
+	}
+	phState.IO.Databases.RegionPricelistHistoryDatabases = rphdBases
+
 	// loading the meta database
 	logging.Info("Connecting to the meta database")
 	metaDatabase, err := database.NewMetaDatabase(config.PricelistHistoriesDatabaseDir)
@@ -141,7 +180,9 @@ func NewProdPricelistHistoriesState(config ProdPricelistHistoriesStateConfig) (P
 
 	// establishing messenger-listeners
 	phState.Listeners = NewListeners(SubjectListeners{
-		subjects.PriceListHistory: phState.ListenForPriceListHistory,
+		subjects.PriceListHistory:       phState.ListenForPriceListHistory,
+		subjects.RegionPriceListHistory: phState.ListenForRegionPriceListHistory,
+		subjects.PriceListForecast:      phState.ListenForPriceListForecast,
 	})
 
 	return phState, nil
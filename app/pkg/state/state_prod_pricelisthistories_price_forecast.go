@@ -0,0 +1,20 @@
+package state
+
+import (
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	"github.com/sotah-inc/server/app/pkg/state/subjects"
+)
+
+func (phState ProdPricelistHistoriesState) ListenForPriceListForecast(stop ListenStopChan) error {
+	return messenger.Serve(
+		phState.IO.Messenger,
+		string(subjects.PriceListForecast),
+		stop,
+		messenger.Endpoint[database.GetPricelistForecastRequest, database.GetPricelistForecastResponse]{
+			Decode: database.NewGetPricelistForecastRequest,
+			Encode: database.GetPricelistForecastResponse.EncodeForDelivery,
+			Handle: fromDatabase(phState.IO.Databases.PricelistHistoryDatabases.GetPricelistForecast),
+		},
+	)
+}
@@ -1,6 +1,7 @@
 package state
 
 import (
+	"context"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -15,16 +16,17 @@ import (
 )
 
 func HandleComputedPricelistHistories(
+	ctx context.Context,
 	phState ProdPricelistHistoriesState,
 	requests []database.PricelistHistoriesComputeIntakeRequest,
 ) {
 	// declaring a get-in channel for gathering pricelist-histories
 	getInJobs := make(chan store.GetAllPricelistHistoriesInJob)
-	getOutJobs := phState.PricelistHistoriesBase.GetAll(getInJobs, phState.PricelistHistoriesBucket)
+	getOutJobs := phState.PricelistHistoriesBase.GetAll(ctx, getInJobs, phState.PricelistHistoriesBucket)
 
 	// declaring a load-in channel for the pricelist-histories db
 	loadInJobs := make(chan database.PricelistHistoryDatabaseEncodedLoadInJob)
-	loadOutJobs := phState.IO.Databases.PricelistHistoryDatabases.LoadEncoded(loadInJobs)
+	loadOutJobs := phState.IO.Databases.PricelistHistoryDatabases.LoadEncoded(ctx, loadInJobs)
 
 	// spinning up a worker for translating get-out-jobs to load-in-jobs
 	go func() {
@@ -39,8 +41,8 @@ func HandleComputedPricelistHistories(
 				RegionName:                outJob.RegionName,
 				RealmSlug:                 outJob.RealmSlug,
 				NormalizedTargetTimestamp: outJob.TargetTimestamp,
-				Data:      outJob.Data,
-				VersionId: outJob.VersionId,
+				Data:                      outJob.Data,
+				VersionId:                 outJob.VersionId,
 			}
 		}
 
@@ -51,8 +53,8 @@ func HandleComputedPricelistHistories(
 	go func() {
 		for _, request := range requests {
 			logging.WithFields(logrus.Fields{
-				"region": request.RegionName,
-				"realm":  request.RealmSlug,
+				"region":                      request.RegionName,
+				"realm":                       request.RealmSlug,
 				"normalized-target-timestamp": request.NormalizedTargetTimestamp,
 			}).Info("Loading request")
 
@@ -68,6 +70,7 @@ func HandleComputedPricelistHistories(
 
 	// waiting for the results to drain out
 	versionsToSet := sotah.PricelistHistoryVersions{}
+	regionTimestamps := database.RegionTargetTimestamps{}
 	for job := range loadOutJobs {
 		if job.Err != nil {
 			logging.WithFields(job.ToLogrusFields()).Error("Failed to load job")
@@ -86,12 +89,16 @@ func HandleComputedPricelistHistories(
 			job.NormalizedTargetTimestamp,
 			job.VersionId,
 		)
+		regionTimestamps = regionTimestamps.Insert(job.RegionName, job.NormalizedTargetTimestamp)
 	}
 
 	// setting versions
 	if err := phState.IO.Databases.MetaDatabase.SetPricelistHistoriesVersions(versionsToSet); err != nil {
 		logging.WithField("error", err.Error()).Error("Failed to persist pricelist-histories versions")
 	}
+
+	// queueing the region aggregates of the affected shards, which are computed off of the intake path
+	phState.IO.Databases.RegionPricelistHistoryDatabases.Enqueue(regionTimestamps)
 }
 
 func (phState ProdPricelistHistoriesState) ListenForComputedPricelistHistories(
@@ -102,7 +109,7 @@ func (phState ProdPricelistHistoriesState) ListenForComputedPricelistHistories(
 	// establishing subscriber config
 	config := bus.SubscribeConfig{
 		Stop: stop,
-		Callback: func(busMsg bus.Message) {
+		Callback: func(ctx context.Context, busMsg bus.Message) {
 			requests, err := database.NewPricelistHistoriesComputeIntakeRequests(busMsg.Data)
 			if err != nil {
 				logging.WithField("error", err.Error()).Error("Failed to decode compute-intake requests")
@@ -113,14 +120,14 @@ func (phState ProdPricelistHistoriesState) ListenForComputedPricelistHistories(
 			// handling requests
 			logging.WithField("requests", len(requests)).Info("Received requests")
 			startTime := time.Now()
-			HandleComputedPricelistHistories(phState, requests)
+			HandleComputedPricelistHistories(ctx, phState, requests)
 			logging.WithField("requests", len(requests)).Info("Done handling requests")
 
 			// reporting metrics
 			m := metric.Metrics{
 				"receive_all_pricelist_histories_duration": int(int64(time.Since(startTime)) / 1000 / 1000 / 1000),
 			}
-			if err := phState.IO.BusClient.PublishMetrics(m); err != nil {
+			if err := phState.IO.BusClient.PublishMetrics(ctx, m); err != nil {
 				logging.WithField("error", err.Error()).Error("Failed to publish metric")
 
 				return
@@ -133,6 +140,7 @@ func (phState ProdPricelistHistoriesState) ListenForComputedPricelistHistories(
 	// starting up worker for the subscription
 	go func() {
 		if err := phState.IO.BusClient.SubscribeToTopic(
+			context.Background(),
 			string(subjects.ReceiveComputedPricelistHistories),
 			config,
 		); err != nil {
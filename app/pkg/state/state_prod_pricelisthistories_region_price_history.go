@@ -0,0 +1,20 @@
+package state
+
+import (
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	"github.com/sotah-inc/server/app/pkg/state/subjects"
+)
+
+func (phState ProdPricelistHistoriesState) ListenForRegionPriceListHistory(stop ListenStopChan) error {
+	return messenger.Serve(
+		phState.IO.Messenger,
+		string(subjects.RegionPriceListHistory),
+		stop,
+		messenger.Endpoint[database.GetRegionPricelistHistoryRequest, database.GetRegionPricelistHistoryResponse]{
+			Decode: database.NewGetRegionPricelistHistoryRequest,
+			Encode: database.GetRegionPricelistHistoryResponse.EncodeForDelivery,
+			Handle: fromDatabase(phState.IO.Databases.RegionPricelistHistoryDatabases.GetRegionPricelistHistory),
+		},
+	)
+}
@@ -1,6 +1,8 @@
 package state
 
 import (
+	"context"
+
 	"github.com/sirupsen/logrus"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/database"
@@ -9,7 +11,7 @@ import (
 	"github.com/sotah-inc/server/app/pkg/store"
 )
 
-func (phState ProdPricelistHistoriesState) Sync() error {
+func (phState ProdPricelistHistoriesState) Sync(ctx context.Context) error {
 	// gathering region-realms
 	regionRealms := map[blizzard.RegionName]sotah.Realms{}
 	for regionName, status := range phState.Statuses {
@@ -18,7 +20,7 @@ func (phState ProdPricelistHistoriesState) Sync() error {
 
 	// gathering existing pricelist-histories versions
 	logging.Info("Gathering existing versions")
-	versions, err := phState.PricelistHistoriesBase.GetVersions(regionRealms, phState.PricelistHistoriesBucket)
+	versions, err := phState.PricelistHistoriesBase.GetVersions(ctx, regionRealms, phState.PricelistHistoriesBucket)
 	if err != nil {
 		return err
 	}
@@ -75,11 +77,11 @@ func (phState ProdPricelistHistoriesState) Sync() error {
 
 	// declaring a get-in channel for gathering pricelist-histories
 	getInJobs := make(chan store.GetAllPricelistHistoriesInJob)
-	getOutJobs := phState.PricelistHistoriesBase.GetAll(getInJobs, phState.PricelistHistoriesBucket)
+	getOutJobs := phState.PricelistHistoriesBase.GetAll(ctx, getInJobs, phState.PricelistHistoriesBucket)
 
 	// declaring a load-in channel for the pricelist-histories db
 	loadInJobs := make(chan database.PricelistHistoryDatabaseEncodedLoadInJob)
-	loadOutJobs := phState.IO.Databases.PricelistHistoryDatabases.LoadEncoded(loadInJobs)
+	loadOutJobs := phState.IO.Databases.PricelistHistoryDatabases.LoadEncoded(ctx, loadInJobs)
 
 	// spinning up a worker for translating get-out-jobs to load-in-jobs
 	go func() {
@@ -125,6 +127,7 @@ func (phState ProdPricelistHistoriesState) Sync() error {
 
 	// waiting for the results to drain out
 	versionsToSet := sotah.PricelistHistoryVersions{}
+	regionTimestamps := database.RegionTargetTimestamps{}
 	for job := range loadOutJobs {
 		if job.Err != nil {
 			logging.WithFields(job.ToLogrusFields()).Error("Failed to load job")
@@ -138,6 +141,7 @@ func (phState ProdPricelistHistoriesState) Sync() error {
 			job.NormalizedTargetTimestamp,
 			job.VersionId,
 		)
+		regionTimestamps = regionTimestamps.Insert(job.RegionName, job.NormalizedTargetTimestamp)
 
 		logging.WithFields(logrus.Fields{
 			"region": job.RegionName,
@@ -150,5 +154,8 @@ func (phState ProdPricelistHistoriesState) Sync() error {
 		return err
 	}
 
+	// the region aggregates of the loaded shards are stale, whether the shards were new or replaced
+	phState.IO.Databases.RegionPricelistHistoryDatabases.Enqueue(regionTimestamps)
+
 	return nil
 }
@@ -0,0 +1,227 @@
+package state
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/messenger"
+	mCodes "github.com/sotah-inc/server/app/pkg/messenger/codes"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/state/subjects"
+	"github.com/sotah-inc/server/app/pkg/store"
+)
+
+// SessionSecretsCheckInterval - how often session secrets are checked for being due, and for rotations made elsewhere
+const SessionSecretsCheckInterval = time.Minute
+
+// SessionSecretsPersister - where session secrets are kept across restarts
+type SessionSecretsPersister interface {
+	LoadSessionSecrets(ctx context.Context) (sotah.SessionSecrets, bool, error)
+	PersistSessionSecrets(ctx context.Context, secrets sotah.SessionSecrets) error
+}
+
+func NewSessionSecretsFile(filepath string) SessionSecretsFile {
+	return SessionSecretsFile{filepath: filepath}
+}
+
+// SessionSecretsFile - keeps session secrets in a local file, readable by its owner alone
+type SessionSecretsFile struct {
+	filepath string
+}
+
+func (f SessionSecretsFile) LoadSessionSecrets(_ context.Context) (sotah.SessionSecrets, bool, error) {
+	data, err := ioutil.ReadFile(f.filepath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return sotah.SessionSecrets{}, false, nil
+		}
+
+		return sotah.SessionSecrets{}, false, err
+	}
+
+	out, err := sotah.NewSessionSecretsFromJSON(data)
+	if err != nil {
+		return sotah.SessionSecrets{}, false, err
+	}
+
+	return out, true, nil
+}
+
+// PersistSessionSecrets writes to a temporary file first, so that a crash midway leaves the secrets before intact
+func (f SessionSecretsFile) PersistSessionSecrets(_ context.Context, secrets sotah.SessionSecrets) error {
+	encoded, err := secrets.EncodeForStorage()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.filepath), filepath.Base(f.filepath)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(encoded); err != nil {
+		tmp.Close()
+
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.filepath)
+}
+
+func NewSessionSecretsBootObject(bootBase store.BootBase, bootBucket *storage.BucketHandle) SessionSecretsBootObject {
+	return SessionSecretsBootObject{bootBase: bootBase, bootBucket: bootBucket}
+}
+
+// SessionSecretsBootObject - keeps session secrets in the boot bucket, shared by every instance of the api
+type SessionSecretsBootObject struct {
+	bootBase   store.BootBase
+	bootBucket *storage.BucketHandle
+}
+
+func (o SessionSecretsBootObject) LoadSessionSecrets(ctx context.Context) (sotah.SessionSecrets, bool, error) {
+	return o.bootBase.GetSessionSecrets(ctx, o.bootBucket)
+}
+
+func (o SessionSecretsBootObject) PersistSessionSecrets(ctx context.Context, secrets sotah.SessionSecrets) error {
+	return o.bootBase.PersistSessionSecrets(ctx, secrets, o.bootBucket)
+}
+
+/*
+NewSessionSecretKeeper loads the persisted session secrets, or makes and persists a first secret where there are none,
+rotating them straight away where a rotation came due while nothing was running
+*/
+func NewSessionSecretKeeper(
+	ctx context.Context,
+	persister SessionSecretsPersister,
+	rotation time.Duration,
+) (SessionSecretKeeper, error) {
+	keeper := SessionSecretKeeper{&sessionSecretKeeperState{persister: persister, rotation: rotation}}
+	if err := keeper.Refresh(ctx, time.Now()); err != nil {
+		return SessionSecretKeeper{}, err
+	}
+
+	return keeper, nil
+}
+
+/*
+SessionSecretKeeper - holds the session secrets of an api, rotating them once due, where apis sharing a persister
+take up rotations made by one another on refreshing, and rotations made by several at once are settled by the last
+one persisted
+*/
+type SessionSecretKeeper struct {
+	*sessionSecretKeeperState
+}
+
+type sessionSecretKeeperState struct {
+	persister SessionSecretsPersister
+	rotation  time.Duration
+
+	mu      sync.RWMutex
+	secrets sotah.SessionSecrets
+}
+
+func (k SessionSecretKeeper) Secrets() sotah.SessionSecrets {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.secrets
+}
+
+/*
+Refresh takes up the persisted secrets, rotating and persisting them once due, where secrets persisted with another
+rotation interval are rescheduled to this one, and is not meant to be called by more than one goroutine at once
+*/
+func (k SessionSecretKeeper) Refresh(ctx context.Context, now time.Time) error {
+	secrets, found, err := k.persister.LoadSessionSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	next := secrets.Reschedule(k.rotation)
+	switch {
+	case !found:
+		logging.Info("Making first session secret")
+
+		next = sotah.NewSessionSecrets(now, k.rotation)
+	case next.IsDue(now):
+		logging.WithField("previous_created_at", next.Current.CreatedAt).Info("Rotating session secret")
+
+		next = next.Rotate(now, k.rotation)
+	}
+
+	if !found || next != secrets {
+		if err := k.persister.PersistSessionSecrets(ctx, next); err != nil {
+			return err
+		}
+	}
+
+	k.mu.Lock()
+	k.secrets = next
+	k.mu.Unlock()
+
+	return nil
+}
+
+// StartRotating refreshes the secrets every check interval, so that they are rotated close to when they are due
+func (k SessionSecretKeeper) StartRotating(stopChan sotah.WorkerStopChan) sotah.WorkerStopChan {
+	onStop := make(sotah.WorkerStopChan)
+	go func() {
+		ticker := time.NewTicker(SessionSecretsCheckInterval)
+
+		logging.WithField("rotation", k.rotation.String()).Info("Starting session-secret rotation")
+	outer:
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), SessionSecretsCheckInterval)
+				if err := k.Refresh(ctx, time.Now()); err != nil {
+					logging.WithField("error", err.Error()).Error("Failed to refresh session secrets")
+				}
+				cancel()
+			case <-stopChan:
+				ticker.Stop()
+
+				break outer
+			}
+		}
+
+		onStop <- struct{}{}
+	}()
+
+	return onStop
+}
+
+/*
+sessionSecretData - the current and previous session secrets, where sessions are signed with the current one and
+verified against either, along with the current one alone as it was replied before secrets were rotated
+*/
+type sessionSecretData struct {
+	SessionSecret string `json:"session_secret"`
+	sotah.SessionSecrets
+}
+
+func serveSessionSecrets(mess messenger.Messenger, keeper SessionSecretKeeper, stop ListenStopChan) error {
+	return messenger.Serve(
+		mess,
+		string(subjects.SessionSecret),
+		stop,
+		messenger.Endpoint[messenger.Empty, sessionSecretData]{
+			Decode: messenger.DecodeEmpty,
+			Handle: func(_ context.Context, _ messenger.Empty) (sessionSecretData, mCodes.Code, error) {
+				secrets := keeper.Secrets()
+
+				return sessionSecretData{SessionSecret: secrets.Current.Secret, SessionSecrets: secrets}, mCodes.Ok, nil
+			},
+		},
+	)
+}
@@ -0,0 +1,196 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/sotah-inc/server/app/pkg/database"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/health"
+	"github.com/sotah-inc/server/app/pkg/logging"
+	"github.com/sotah-inc/server/app/pkg/sotah"
+	"github.com/sotah-inc/server/app/pkg/sotah/gameversions"
+	"github.com/sotah-inc/server/app/pkg/store"
+	"github.com/sotah-inc/server/app/pkg/store/regions"
+)
+
+func NewSnapshotter(
+	ctx context.Context,
+	kind schemakinds.SchemaKind,
+	databaseDir string,
+	config sotah.SnapshotConfig,
+	storeClient store.Client,
+	resolveTargets func() ([]database.SnapshotTarget, error),
+) (Snapshotter, error) {
+	snapshotsBase := store.NewSnapshotsBase(storeClient, regions.USCentral1, gameversions.Retail)
+	snapshotsBucket, err := snapshotsBase.ResolveBucket(ctx)
+	if err != nil {
+		return Snapshotter{}, err
+	}
+
+	return Snapshotter{
+		kind:            kind,
+		databaseDir:     databaseDir,
+		config:          config,
+		snapshotsBase:   snapshotsBase,
+		snapshotsBucket: snapshotsBucket,
+		resolveTargets:  resolveTargets,
+	}, nil
+}
+
+// Snapshotter - periodically streams the open databases of a kind to the store
+type Snapshotter struct {
+	kind        schemakinds.SchemaKind
+	databaseDir string
+	config      sotah.SnapshotConfig
+
+	snapshotsBase   store.SnapshotsBase
+	snapshotsBucket *storage.BucketHandle
+	resolveTargets  func() ([]database.SnapshotTarget, error)
+}
+
+func (s Snapshotter) Snapshot(ctx context.Context) (store.SnapshotManifest, error) {
+	targets, err := s.resolveTargets()
+	if err != nil {
+		return store.SnapshotManifest{}, err
+	}
+
+	manifest := store.SnapshotManifest{
+		Id:            time.Now().Unix(),
+		Kind:          s.kind,
+		SchemaVersion: database.CurrentSchemaVersion(s.kind),
+		Databases:     []store.SnapshotManifestEntry{},
+	}
+	for _, target := range targets {
+		size, err := s.snapshotsBase.WriteDatabase(ctx, s.kind, manifest.Id, target.Path, target.WriteTo, s.snapshotsBucket)
+		if err != nil {
+			logging.WithFields(logrus.Fields{
+				"error":    err.Error(),
+				"kind":     s.kind,
+				"pathname": target.Path,
+			}).Error("Failed to snapshot database")
+
+			return store.SnapshotManifest{}, err
+		}
+
+		manifest.Databases = append(manifest.Databases, store.SnapshotManifestEntry{Path: target.Path, Size: size})
+	}
+
+	if err := s.snapshotsBase.WriteManifest(ctx, manifest, s.snapshotsBucket); err != nil {
+		return store.SnapshotManifest{}, err
+	}
+
+	return manifest, nil
+}
+
+/*
+expiredSnapshotIds returns the ids of the complete snapshots beyond the count of those kept, along with those of the
+incomplete snapshots older than every kept one, which are failed ones rather than ones still being written
+*/
+func expiredSnapshotIds(ids []int64, incompleteIds []int64, keepCount int) []int64 {
+	if len(ids) == 0 {
+		return []int64{}
+	}
+
+	keepFrom := 0
+	if len(ids) > keepCount {
+		keepFrom = len(ids) - keepCount
+	}
+
+	out := append([]int64{}, ids[:keepFrom]...)
+	for _, id := range incompleteIds {
+		if keepFrom == len(ids) || id < ids[keepFrom] {
+			out = append(out, id)
+		}
+	}
+
+	return out
+}
+
+func (s Snapshotter) deleteSnapshot(ctx context.Context, id int64) error {
+	totalDeleted, err := s.snapshotsBase.DeleteSnapshot(ctx, s.kind, id, s.snapshotsBucket)
+	if err != nil {
+		return err
+	}
+
+	logging.WithFields(logrus.Fields{
+		"kind":    s.kind,
+		"id":      id,
+		"objects": totalDeleted,
+	}).Info("Deleted expired snapshot")
+
+	return nil
+}
+
+// prune deletes every snapshot older than the configured count of complete snapshots to keep
+func (s Snapshotter) prune(ctx context.Context) error {
+	ids, incompleteIds, err := s.snapshotsBase.GetSnapshotIds(ctx, s.kind, s.snapshotsBucket)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range expiredSnapshotIds(ids, incompleteIds, s.config.KeepCount()) {
+		if err := s.deleteSnapshot(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tick snapshots and then prunes, giving up on both once the next tick is due
+func (s Snapshotter) tick() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Interval())
+	defer cancel()
+
+	startTime := time.Now()
+	manifest, err := s.Snapshot(ctx)
+	if err != nil {
+		logging.WithField("error", err.Error()).Error("Failed to snapshot databases")
+
+		return
+	}
+
+	logging.WithFields(logrus.Fields{
+		"kind":      s.kind,
+		"id":        manifest.Id,
+		"databases": len(manifest.Databases),
+		"duration":  time.Since(startTime).String(),
+	}).Info("Snapshotted databases")
+
+	if err := s.prune(ctx); err != nil {
+		logging.WithField("error", err.Error()).Error("Failed to prune snapshots")
+
+		return
+	}
+}
+
+func (s Snapshotter) Start(stopChan sotah.WorkerStopChan, heartbeat health.Heartbeat) sotah.WorkerStopChan {
+	onStop := make(sotah.WorkerStopChan)
+	go func() {
+		ticker := time.NewTicker(s.config.Interval())
+
+		logging.WithFields(logrus.Fields{
+			"kind":     s.kind,
+			"interval": s.config.Interval().String(),
+		}).Info("Starting snapshotter")
+	outer:
+		for {
+			select {
+			case <-ticker.C:
+				heartbeat.Beat()
+				s.tick()
+			case <-stopChan:
+				ticker.Stop()
+
+				break outer
+			}
+		}
+
+		onStop <- struct{}{}
+	}()
+
+	return onStop
+}
@@ -0,0 +1,50 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiredSnapshotIds(t *testing.T) {
+	type scenario struct {
+		ids           []int64
+		incompleteIds []int64
+		keepCount     int
+		expected      []int64
+	}
+
+	scenarios := map[string]scenario{
+		"keeps everything within the count": {
+			ids:       []int64{100, 200},
+			keepCount: 2,
+			expected:  []int64{},
+		},
+		"prunes the oldest complete snapshots": {
+			ids:       []int64{100, 200, 300},
+			keepCount: 2,
+			expected:  []int64{100},
+		},
+		"prunes the failed snapshots older than every kept one": {
+			ids:           []int64{100, 300, 500},
+			incompleteIds: []int64{50, 200, 400, 600},
+			keepCount:     2,
+			expected:      []int64{100, 50, 200},
+		},
+		"failed snapshots never count towards those kept": {
+			ids:           []int64{100},
+			incompleteIds: []int64{200, 300},
+			keepCount:     1,
+			expected:      []int64{},
+		},
+		"keeps the failed snapshots without any complete one": {
+			incompleteIds: []int64{100},
+			keepCount:     1,
+			expected:      []int64{},
+		},
+	}
+
+	for name, s := range scenarios {
+		assert.Equal(t, s.expected, expiredSnapshotIds(s.ids, s.incompleteIds, s.keepCount), name)
+	}
+}
@@ -17,6 +17,8 @@ const (
 	PriceList                       Subject = "priceList"
 	PriceListHistory                Subject = "priceListHistory"
 	PriceListHistoryV2              Subject = "priceListHistoryV2"
+	PriceListForecast               Subject = "priceListForecast"
+	RegionPriceListHistory          Subject = "regionPriceListHistory"
 	Items                           Subject = "items"
 	Boot                            Subject = "boot"
 	SessionSecret                   Subject = "sessionSecret"
@@ -31,6 +33,12 @@ const (
 	AuctionsQuery                   Subject = "auctionsQuery"
 	QueryRealmModificationDates     Subject = "queryRealmModificationDates"
 	RealmModificationDates          Subject = "realmModificationDates"
+	CraftingProfits                 Subject = "craftingProfits"
+	APIKeysResolve                  Subject = "apiKeysResolve"
+	APIKeysUsage                    Subject = "apiKeysUsage"
+	APIKeysIssue                    Subject = "apiKeysIssue"
+	APIKeysRevoke                   Subject = "apiKeysRevoke"
+	APIKeysList                     Subject = "apiKeysList"
 )
 
 // gcloud fn-related
@@ -13,13 +13,13 @@ func NewClient(projectID string) (Client, error) {
 		return Client{}, err
 	}
 
-	s := Client{Context: ctx, projectID: projectID, client: client}
+	s := Client{projectID: projectID, client: client}
 
 	return s, nil
 }
 
+// Client - every operation of the bases takes its own context, so that each may be timed out or cancelled
 type Client struct {
-	Context   context.Context
 	projectID string
 	client    *storage.Client
 }
@@ -1,13 +1,24 @@
 package store
 
 import (
+	"context"
 	"errors"
 
 	"cloud.google.com/go/storage"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/store/regions"
+	"github.com/sotah-inc/server/app/pkg/tracing"
 )
 
+// startRealmSpan starts a span around reading or writing an object of a realm
+func startRealmSpan(ctx context.Context, name string, realm sotah.Realm) (context.Context, tracing.Span) {
+	ctx, span := tracing.Start(ctx, name)
+	span.SetAttribute(tracing.RegionAttribute, string(realm.Region.Name))
+	span.SetAttribute(tracing.RealmAttribute, string(realm.Slug))
+
+	return ctx, span
+}
+
 type base struct {
 	client       Client
 	storageClass string
@@ -18,15 +29,15 @@ func (b base) getBucket(name string) *storage.BucketHandle {
 	return b.client.client.Bucket(name)
 }
 
-func (b base) createBucket(bkt *storage.BucketHandle) error {
-	return bkt.Create(b.client.Context, b.client.projectID, &storage.BucketAttrs{
+func (b base) createBucket(ctx context.Context, bkt *storage.BucketHandle) error {
+	return bkt.Create(ctx, b.client.projectID, &storage.BucketAttrs{
 		StorageClass: b.storageClass,
 		Location:     string(b.location),
 	})
 }
 
-func (b base) BucketExists(bkt *storage.BucketHandle) (bool, error) {
-	_, err := bkt.Attrs(b.client.Context)
+func (b base) BucketExists(ctx context.Context, bkt *storage.BucketHandle) (bool, error) {
+	_, err := bkt.Attrs(ctx)
 	if err != nil {
 		if err != storage.ErrBucketNotExist {
 			return false, err
@@ -38,16 +49,16 @@ func (b base) BucketExists(bkt *storage.BucketHandle) (bool, error) {
 	return true, nil
 }
 
-func (b base) resolveBucket(name string) (*storage.BucketHandle, error) {
+func (b base) resolveBucket(ctx context.Context, name string) (*storage.BucketHandle, error) {
 	bkt := b.getBucket(name)
 
-	exists, err := b.BucketExists(bkt)
+	exists, err := b.BucketExists(ctx, bkt)
 	if err != nil {
 		return nil, err
 	}
 
 	if !exists {
-		if err := b.createBucket(bkt); err != nil {
+		if err := b.createBucket(ctx, bkt); err != nil {
 			return nil, err
 		}
 
@@ -57,9 +68,9 @@ func (b base) resolveBucket(name string) (*storage.BucketHandle, error) {
 	return bkt, nil
 }
 
-func (b base) getFirmBucket(name string) (*storage.BucketHandle, error) {
+func (b base) getFirmBucket(ctx context.Context, name string) (*storage.BucketHandle, error) {
 	bkt := b.getBucket(name)
-	exists, err := b.BucketExists(bkt)
+	exists, err := b.BucketExists(ctx, bkt)
 	if err != nil {
 		return nil, err
 	}
@@ -74,9 +85,9 @@ func (b base) getObject(name string, bkt *storage.BucketHandle) *storage.ObjectH
 	return bkt.Object(name)
 }
 
-func (b base) getFirmObject(name string, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
+func (b base) getFirmObject(ctx context.Context, name string, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
 	obj := bkt.Object(name)
-	exists, err := b.ObjectExists(obj)
+	exists, err := b.ObjectExists(ctx, obj)
 	if err != nil {
 		return nil, err
 	}
@@ -87,8 +98,8 @@ func (b base) getFirmObject(name string, bkt *storage.BucketHandle) (*storage.Ob
 	return obj, nil
 }
 
-func (b base) ObjectExists(obj *storage.ObjectHandle) (bool, error) {
-	_, err := obj.Attrs(b.client.Context)
+func (b base) ObjectExists(ctx context.Context, obj *storage.ObjectHandle) (bool, error) {
+	_, err := obj.Attrs(ctx)
 	if err != nil {
 		if err != storage.ErrObjectNotExist {
 			return false, err
@@ -1,9 +1,11 @@
 package store
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"sort"
 	"strconv"
 	"time"
 
@@ -36,12 +38,12 @@ func (b AuctionManifestBaseV2) GetBucket() *storage.BucketHandle {
 	return b.base.getBucket(b.getBucketName())
 }
 
-func (b AuctionManifestBaseV2) ResolveBucket() (*storage.BucketHandle, error) {
-	return b.base.resolveBucket(b.getBucketName())
+func (b AuctionManifestBaseV2) ResolveBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.resolveBucket(ctx, b.getBucketName())
 }
 
-func (b AuctionManifestBaseV2) GetFirmBucket() (*storage.BucketHandle, error) {
-	return b.base.getFirmBucket(b.getBucketName())
+func (b AuctionManifestBaseV2) GetFirmBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.getFirmBucket(ctx, b.getBucketName())
 }
 
 func (b AuctionManifestBaseV2) GetObjectPrefix(realm sotah.Realm) string {
@@ -57,19 +59,20 @@ func (b AuctionManifestBaseV2) GetObject(targetTimestamp sotah.UnixTimestamp, re
 }
 
 func (b AuctionManifestBaseV2) GetFirmObject(
+	ctx context.Context,
 	targetTimestamp sotah.UnixTimestamp,
 	realm sotah.Realm,
 	bkt *storage.BucketHandle,
 ) (*storage.ObjectHandle, error) {
-	return b.base.getFirmObject(b.GetObjectName(targetTimestamp, realm), bkt)
+	return b.base.getFirmObject(ctx, b.GetObjectName(targetTimestamp, realm), bkt)
 }
 
-func (b AuctionManifestBaseV2) Handle(targetTimestamp sotah.UnixTimestamp, realm sotah.Realm, bkt *storage.BucketHandle) error {
+func (b AuctionManifestBaseV2) Handle(ctx context.Context, targetTimestamp sotah.UnixTimestamp, realm sotah.Realm, bkt *storage.BucketHandle) error {
 	normalizedTargetTimestamp := sotah.UnixTimestamp(sotah.NormalizeTargetDate(time.Unix(int64(targetTimestamp), 0)).Unix())
 
 	obj := b.GetObject(normalizedTargetTimestamp, realm, bkt)
 	nextManifest, err := func() (sotah.AuctionManifest, error) {
-		exists, err := b.ObjectExists(obj)
+		exists, err := b.ObjectExists(ctx, obj)
 		if err != nil {
 			return sotah.AuctionManifest{}, err
 		}
@@ -78,7 +81,7 @@ func (b AuctionManifestBaseV2) Handle(targetTimestamp sotah.UnixTimestamp, realm
 			return sotah.AuctionManifest{}, nil
 		}
 
-		reader, err := obj.NewReader(b.client.Context)
+		reader, err := obj.NewReader(ctx)
 		if err != nil {
 			return sotah.AuctionManifest{}, nil
 		}
@@ -110,7 +113,7 @@ func (b AuctionManifestBaseV2) Handle(targetTimestamp sotah.UnixTimestamp, realm
 		return err
 	}
 
-	wc := obj.NewWriter(b.client.Context)
+	wc := obj.NewWriter(ctx)
 	wc.ContentType = "application/json"
 	wc.ContentEncoding = "gzip"
 	if _, err := wc.Write(gzipEncodedBody); err != nil {
@@ -129,13 +132,13 @@ type DeleteAuctionManifestJob struct {
 	Count int
 }
 
-func (b AuctionManifestBaseV2) DeleteAll(regionRealms map[blizzard.RegionName]sotah.Realms) chan DeleteAuctionManifestJob {
+func (b AuctionManifestBaseV2) DeleteAll(ctx context.Context, regionRealms map[blizzard.RegionName]sotah.Realms) chan DeleteAuctionManifestJob {
 	// spinning up the workers
 	in := make(chan sotah.Realm)
 	out := make(chan DeleteAuctionManifestJob)
 	worker := func() {
 		for realm := range in {
-			bkt, err := b.GetFirmBucket()
+			bkt, err := b.GetFirmBucket(ctx)
 			if err != nil {
 				out <- DeleteAuctionManifestJob{
 					Err:   err,
@@ -146,7 +149,7 @@ func (b AuctionManifestBaseV2) DeleteAll(regionRealms map[blizzard.RegionName]so
 				continue
 			}
 
-			it := bkt.Objects(b.client.Context, nil)
+			it := bkt.Objects(ctx, nil)
 			count := 0
 			for {
 				objAttrs, err := it.Next()
@@ -171,7 +174,7 @@ func (b AuctionManifestBaseV2) DeleteAll(regionRealms map[blizzard.RegionName]so
 				}
 
 				obj := bkt.Object(objAttrs.Name)
-				if err := obj.Delete(b.client.Context); err != nil {
+				if err := obj.Delete(ctx); err != nil {
 					out <- DeleteAuctionManifestJob{
 						Err:   err,
 						Realm: realm,
@@ -212,7 +215,7 @@ type WriteAllOutJob struct {
 	NormalizedTimestamp sotah.UnixTimestamp
 }
 
-func (b AuctionManifestBaseV2) WriteAll(bkt *storage.BucketHandle, realm sotah.Realm, manifests map[sotah.UnixTimestamp]sotah.AuctionManifest) chan WriteAllOutJob {
+func (b AuctionManifestBaseV2) WriteAll(ctx context.Context, bkt *storage.BucketHandle, realm sotah.Realm, manifests map[sotah.UnixTimestamp]sotah.AuctionManifest) chan WriteAllOutJob {
 	// spinning up the workers
 	in := make(chan WriteAllInJob)
 	out := make(chan WriteAllOutJob)
@@ -228,7 +231,7 @@ func (b AuctionManifestBaseV2) WriteAll(bkt *storage.BucketHandle, realm sotah.R
 				continue
 			}
 
-			wc := b.GetObject(inJob.NormalizedTimestamp, realm, bkt).NewWriter(b.client.Context)
+			wc := b.GetObject(inJob.NormalizedTimestamp, realm, bkt).NewWriter(ctx)
 			wc.ContentType = "application/json"
 			wc.ContentEncoding = "gzip"
 			if _, err := wc.Write(gzipEncodedBody); err != nil {
@@ -274,8 +277,8 @@ func (b AuctionManifestBaseV2) WriteAll(bkt *storage.BucketHandle, realm sotah.R
 	return out
 }
 
-func (b AuctionManifestBaseV2) NewAuctionManifest(obj *storage.ObjectHandle) (sotah.AuctionManifest, error) {
-	reader, err := obj.NewReader(b.client.Context)
+func (b AuctionManifestBaseV2) NewAuctionManifest(ctx context.Context, obj *storage.ObjectHandle) (sotah.AuctionManifest, error) {
+	reader, err := obj.NewReader(ctx)
 	if err != nil {
 		return sotah.AuctionManifest{}, err
 	}
@@ -293,7 +296,51 @@ func (b AuctionManifestBaseV2) NewAuctionManifest(obj *storage.ObjectHandle) (so
 	return out, nil
 }
 
+// GetLastModifiedBetween - gathers every dump last-modified timestamp for the realm within the bounds, in order
+func (b AuctionManifestBaseV2) GetLastModifiedBetween(
+	ctx context.Context,
+	realm sotah.Realm,
+	lowerBounds time.Time,
+	upperBounds time.Time,
+	bkt *storage.BucketHandle,
+) ([]sotah.UnixTimestamp, error) {
+	manifestTimestamps, err := b.GetTimestamps(ctx, realm, bkt)
+	if err != nil {
+		return []sotah.UnixTimestamp{}, err
+	}
+
+	out := []sotah.UnixTimestamp{}
+	for _, manifestTimestamp := range manifestTimestamps {
+		// each manifest covers one day of dumps
+		manifestTime := time.Unix(int64(manifestTimestamp), 0)
+		if manifestTime.After(upperBounds) || manifestTime.AddDate(0, 0, 1).Before(lowerBounds) {
+			continue
+		}
+
+		manifest, err := b.NewAuctionManifest(ctx, b.GetObject(manifestTimestamp, realm, bkt))
+		if err != nil {
+			return []sotah.UnixTimestamp{}, err
+		}
+
+		for _, lastModified := range manifest {
+			lastModifiedTime := time.Unix(int64(lastModified), 0)
+			if lastModifiedTime.Before(lowerBounds) || lastModifiedTime.After(upperBounds) {
+				continue
+			}
+
+			out = append(out, lastModified)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i] < out[j]
+	})
+
+	return out, nil
+}
+
 func (b AuctionManifestBaseV2) GetAllTimestamps(
+	ctx context.Context,
 	regionRealms map[blizzard.RegionName]sotah.Realms,
 	bkt *storage.BucketHandle,
 ) (sotah.RegionRealmTimestamps, error) {
@@ -303,7 +350,7 @@ func (b AuctionManifestBaseV2) GetAllTimestamps(
 	// spinning up workers
 	worker := func() {
 		for realm := range in {
-			timestamps, err := b.GetTimestamps(realm, bkt)
+			timestamps, err := b.GetTimestamps(ctx, realm, bkt)
 			if err != nil {
 				out <- GetTimestampsJob{
 					Err:   err,
@@ -354,43 +401,9 @@ func (b AuctionManifestBaseV2) GetAllTimestamps(
 	return results, nil
 }
 
-func (b AuctionManifestBaseV2) GetAllExpiredTimestamps(
-	regionRealms map[blizzard.RegionName]sotah.Realms,
-	bkt *storage.BucketHandle,
-) (sotah.RegionRealmTimestamps, error) {
-	regionRealmTimestamps, err := b.GetAllTimestamps(regionRealms, bkt)
-	if err != nil {
-		return sotah.RegionRealmTimestamps{}, err
-	}
-
-	out := sotah.RegionRealmTimestamps{}
-	limit := sotah.NormalizeTargetDate(time.Now()).AddDate(0, 0, -14)
-	for regionName, realmTimestamps := range regionRealmTimestamps {
-		for realmSlug, timestamps := range realmTimestamps {
-			for _, timestamp := range timestamps {
-				targetTime := time.Unix(int64(timestamp), 0)
-				if targetTime.After(limit) {
-					continue
-				}
-
-				if _, ok := out[regionName]; !ok {
-					out[regionName] = sotah.RealmTimestamps{}
-				}
-				if _, ok := out[regionName][realmSlug]; !ok {
-					out[regionName][realmSlug] = []sotah.UnixTimestamp{}
-				}
-
-				out[regionName][realmSlug] = append(out[regionName][realmSlug], timestamp)
-			}
-		}
-	}
-
-	return out, nil
-}
-
-func (b AuctionManifestBaseV2) GetTimestamps(realm sotah.Realm, bkt *storage.BucketHandle) ([]sotah.UnixTimestamp, error) {
+func (b AuctionManifestBaseV2) GetTimestamps(ctx context.Context, realm sotah.Realm, bkt *storage.BucketHandle) ([]sotah.UnixTimestamp, error) {
 	prefix := fmt.Sprintf("%s/", b.GetObjectPrefix(realm))
-	it := bkt.Objects(b.client.Context, &storage.Query{Prefix: prefix})
+	it := bkt.Objects(ctx, &storage.Query{Prefix: prefix})
 	out := []sotah.UnixTimestamp{}
 	for {
 		objAttrs, err := it.Next()
@@ -1,10 +1,13 @@
 package store
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"time"
 
 	"cloud.google.com/go/storage"
+	"github.com/sotah-inc/server/app/pkg/blizzard"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/sotah/gameversions"
 	"github.com/sotah-inc/server/app/pkg/store/regions"
@@ -31,12 +34,12 @@ func (b AuctionsBaseV2) GetBucket() *storage.BucketHandle {
 	return b.base.getBucket(b.getBucketName())
 }
 
-func (b AuctionsBaseV2) GetFirmBucket() (*storage.BucketHandle, error) {
-	return b.base.getFirmBucket(b.getBucketName())
+func (b AuctionsBaseV2) GetFirmBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.getFirmBucket(ctx, b.getBucketName())
 }
 
-func (b AuctionsBaseV2) ResolveBucket() (*storage.BucketHandle, error) {
-	return b.base.resolveBucket(b.getBucketName())
+func (b AuctionsBaseV2) ResolveBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.resolveBucket(ctx, b.getBucketName())
 }
 
 func (b AuctionsBaseV2) getObjectName(realm sotah.Realm, lastModified time.Time) string {
@@ -47,43 +50,114 @@ func (b AuctionsBaseV2) GetObject(realm sotah.Realm, lastModified time.Time, bkt
 	return b.base.getObject(b.getObjectName(realm, lastModified), bkt)
 }
 
-func (b AuctionsBaseV2) GetFirmObject(realm sotah.Realm, lastModified time.Time, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
-	return b.base.getFirmObject(b.getObjectName(realm, lastModified), bkt)
+func (b AuctionsBaseV2) GetFirmObject(ctx context.Context, realm sotah.Realm, lastModified time.Time, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
+	return b.base.getFirmObject(ctx, b.getObjectName(realm, lastModified), bkt)
 }
 
-func (b AuctionsBaseV2) Handle(jsonEncodedBody []byte, lastModified time.Time, realm sotah.Realm, bkt *storage.BucketHandle) error {
+func (b AuctionsBaseV2) Handle(ctx context.Context, jsonEncodedBody []byte, lastModified time.Time, realm sotah.Realm, bkt *storage.BucketHandle) error {
+	ctx, span := startRealmSpan(ctx, "store write auctions", realm)
+	defer span.End()
+
 	gzipEncodedBody, err := util.GzipEncode(jsonEncodedBody)
 	if err != nil {
+		span.SetError(err)
+
 		return err
 	}
 
 	// writing it out to the gcloud object
-	wc := b.GetObject(realm, lastModified, bkt).NewWriter(b.client.Context)
+	wc := b.GetObject(realm, lastModified, bkt).NewWriter(ctx)
 	wc.ContentType = "application/json"
 	wc.ContentEncoding = "gzip"
 	if _, err := wc.Write(gzipEncodedBody); err != nil {
+		span.SetError(err)
+
 		return err
 	}
 	if err := wc.Close(); err != nil {
+		span.SetError(err)
+
 		return err
 	}
 
 	return nil
 }
 
+// GetCompressedDump - reads the raw dump without decompressing it, as it was written by Handle
+func (b AuctionsBaseV2) GetCompressedDump(
+	ctx context.Context,
+	realm sotah.Realm,
+	lastModified time.Time,
+	bkt *storage.BucketHandle,
+) ([]byte, error) {
+	ctx, span := startRealmSpan(ctx, "store read auctions", realm)
+	defer span.End()
+
+	obj, err := b.GetFirmObject(ctx, realm, lastModified, bkt)
+	if err != nil {
+		span.SetError(err)
+
+		return []byte{}, err
+	}
+
+	reader, err := obj.ReadCompressed(true).NewReader(ctx)
+	if err != nil {
+		span.SetError(err)
+
+		return []byte{}, err
+	}
+	defer reader.Close()
+
+	return ioutil.ReadAll(reader)
+}
+
+func (b AuctionsBaseV2) GetAuctions(
+	ctx context.Context,
+	realm sotah.Realm,
+	lastModified time.Time,
+	bkt *storage.BucketHandle,
+) (blizzard.Auctions, error) {
+	ctx, span := startRealmSpan(ctx, "store read auctions", realm)
+	defer span.End()
+
+	obj, err := b.GetFirmObject(ctx, realm, lastModified, bkt)
+	if err != nil {
+		span.SetError(err)
+
+		return blizzard.Auctions{}, err
+	}
+
+	reader, err := obj.NewReader(ctx)
+	if err != nil {
+		span.SetError(err)
+
+		return blizzard.Auctions{}, err
+	}
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		span.SetError(err)
+
+		return blizzard.Auctions{}, err
+	}
+
+	return blizzard.NewAuctions(data)
+}
+
 type DeleteAuctionsJob struct {
 	Err             error
 	TargetTimestamp sotah.UnixTimestamp
 }
 
-func (b AuctionsBaseV2) DeleteAll(bkt *storage.BucketHandle, realm sotah.Realm, manifest sotah.AuctionManifest) chan DeleteAuctionsJob {
+func (b AuctionsBaseV2) DeleteAll(ctx context.Context, bkt *storage.BucketHandle, realm sotah.Realm, manifest sotah.AuctionManifest) chan DeleteAuctionsJob {
 	// spinning up the workers
 	in := make(chan sotah.UnixTimestamp)
 	out := make(chan DeleteAuctionsJob)
 	worker := func() {
 		for targetTimestamp := range in {
 			obj := bkt.Object(b.getObjectName(realm, time.Unix(int64(targetTimestamp), 0)))
-			exists, err := b.ObjectExists(obj)
+			exists, err := b.ObjectExists(ctx, obj)
 			if err != nil {
 				out <- DeleteAuctionsJob{
 					Err:             err,
@@ -101,7 +175,7 @@ func (b AuctionsBaseV2) DeleteAll(bkt *storage.BucketHandle, realm sotah.Realm,
 				continue
 			}
 
-			if err := obj.Delete(b.client.Context); err != nil {
+			if err := obj.Delete(ctx); err != nil {
 				out <- DeleteAuctionsJob{
 					Err:             err,
 					TargetTimestamp: targetTimestamp,
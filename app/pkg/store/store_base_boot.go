@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 
@@ -25,25 +26,25 @@ func (b BootBase) GetBucket() *storage.BucketHandle {
 	return b.base.getBucket(b.getBucketName())
 }
 
-func (b BootBase) GetFirmBucket() (*storage.BucketHandle, error) {
-	return b.base.getFirmBucket(b.getBucketName())
+func (b BootBase) GetFirmBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.getFirmBucket(ctx, b.getBucketName())
 }
 
 func (b BootBase) GetObject(name string, bkt *storage.BucketHandle) *storage.ObjectHandle {
 	return b.base.getObject(name, bkt)
 }
 
-func (b BootBase) GetFirmObject(name string, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
-	return b.base.getFirmObject(name, bkt)
+func (b BootBase) GetFirmObject(ctx context.Context, name string, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
+	return b.base.getFirmObject(ctx, name, bkt)
 }
 
-func (b BootBase) GetRegions(bkt *storage.BucketHandle) (sotah.RegionList, error) {
-	regionObj, err := b.getFirmObject("regions.json.gz", bkt)
+func (b BootBase) GetRegions(ctx context.Context, bkt *storage.BucketHandle) (sotah.RegionList, error) {
+	regionObj, err := b.getFirmObject(ctx, "regions.json.gz", bkt)
 	if err != nil {
 		return sotah.RegionList{}, err
 	}
 
-	reader, err := regionObj.NewReader(b.client.Context)
+	reader, err := regionObj.NewReader(ctx)
 	if err != nil {
 		return sotah.RegionList{}, err
 	}
@@ -61,13 +62,13 @@ func (b BootBase) GetRegions(bkt *storage.BucketHandle) (sotah.RegionList, error
 	return out, nil
 }
 
-func (b BootBase) GetBlizzardCredentials(bkt *storage.BucketHandle) (sotah.BlizzardCredentials, error) {
-	credentialsObj, err := b.getFirmObject("blizzard-credentials.json", bkt)
+func (b BootBase) GetBlizzardCredentials(ctx context.Context, bkt *storage.BucketHandle) (sotah.BlizzardCredentials, error) {
+	credentialsObj, err := b.getFirmObject(ctx, "blizzard-credentials.json", bkt)
 	if err != nil {
 		return sotah.BlizzardCredentials{}, err
 	}
 
-	reader, err := credentialsObj.NewReader(b.client.Context)
+	reader, err := credentialsObj.NewReader(ctx)
 	if err != nil {
 		return sotah.BlizzardCredentials{}, err
 	}
@@ -85,12 +86,12 @@ func (b BootBase) GetBlizzardCredentials(bkt *storage.BucketHandle) (sotah.Blizz
 	return out, nil
 }
 
-func (b BootBase) Guard(objName string, contents string, bkt *storage.BucketHandle) (bool, error) {
-	obj, err := b.GetFirmObject(objName, bkt)
+func (b BootBase) Guard(ctx context.Context, objName string, contents string, bkt *storage.BucketHandle) (bool, error) {
+	obj, err := b.GetFirmObject(ctx, objName, bkt)
 	if err != nil {
 		return false, err
 	}
-	reader, err := obj.NewReader(b.client.Context)
+	reader, err := obj.NewReader(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -101,3 +102,51 @@ func (b BootBase) Guard(objName string, contents string, bkt *storage.BucketHand
 
 	return string(data) == contents, nil
 }
+
+const sessionSecretsObjectName = "session-secrets.json"
+
+// GetSessionSecrets returns whether session secrets were stored, along with the secrets
+func (b BootBase) GetSessionSecrets(ctx context.Context, bkt *storage.BucketHandle) (sotah.SessionSecrets, bool, error) {
+	obj := b.GetObject(sessionSecretsObjectName, bkt)
+	exists, err := b.ObjectExists(ctx, obj)
+	if err != nil {
+		return sotah.SessionSecrets{}, false, err
+	}
+	if !exists {
+		return sotah.SessionSecrets{}, false, nil
+	}
+
+	reader, err := obj.NewReader(ctx)
+	if err != nil {
+		return sotah.SessionSecrets{}, false, err
+	}
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return sotah.SessionSecrets{}, false, err
+	}
+
+	out, err := sotah.NewSessionSecretsFromJSON(data)
+	if err != nil {
+		return sotah.SessionSecrets{}, false, err
+	}
+
+	return out, true, nil
+}
+
+func (b BootBase) PersistSessionSecrets(
+	ctx context.Context,
+	secrets sotah.SessionSecrets,
+	bkt *storage.BucketHandle,
+) error {
+	encoded, err := secrets.EncodeForStorage()
+	if err != nil {
+		return err
+	}
+
+	wc := b.GetObject(sessionSecretsObjectName, bkt).NewWriter(ctx)
+	wc.ContentType = "application/json"
+
+	return b.Write(wc, encoded)
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"fmt"
 
 	"cloud.google.com/go/storage"
@@ -30,12 +31,12 @@ func (b ItemIconsBase) GetBucket() *storage.BucketHandle {
 	return b.base.getBucket(b.GetBucketName())
 }
 
-func (b ItemIconsBase) GetFirmBucket() (*storage.BucketHandle, error) {
-	return b.base.getFirmBucket(b.GetBucketName())
+func (b ItemIconsBase) GetFirmBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.getFirmBucket(ctx, b.GetBucketName())
 }
 
-func (b ItemIconsBase) resolveBucket() (*storage.BucketHandle, error) {
-	return b.base.resolveBucket(b.GetBucketName())
+func (b ItemIconsBase) resolveBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.resolveBucket(ctx, b.GetBucketName())
 }
 
 func (b ItemIconsBase) GetObjectName(name string) string {
@@ -46,6 +47,6 @@ func (b ItemIconsBase) GetObject(name string, bkt *storage.BucketHandle) *storag
 	return b.base.getObject(b.GetObjectName(name), bkt)
 }
 
-func (b ItemIconsBase) GetFirmObject(name string, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
-	return b.base.getFirmObject(b.GetObjectName(name), bkt)
+func (b ItemIconsBase) GetFirmObject(ctx context.Context, name string, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
+	return b.base.getFirmObject(ctx, b.GetObjectName(name), bkt)
 }
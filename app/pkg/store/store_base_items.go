@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -34,12 +35,12 @@ func (b ItemsBase) GetBucket() *storage.BucketHandle {
 	return b.base.getBucket(b.getBucketName())
 }
 
-func (b ItemsBase) GetFirmBucket() (*storage.BucketHandle, error) {
-	return b.base.getFirmBucket(b.getBucketName())
+func (b ItemsBase) GetFirmBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.getFirmBucket(ctx, b.getBucketName())
 }
 
-func (b ItemsBase) resolveBucket() (*storage.BucketHandle, error) {
-	return b.base.resolveBucket(b.getBucketName())
+func (b ItemsBase) resolveBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.resolveBucket(ctx, b.getBucketName())
 }
 
 func (b ItemsBase) getObjectName(id blizzard.ItemID) string {
@@ -50,12 +51,12 @@ func (b ItemsBase) GetObject(id blizzard.ItemID, bkt *storage.BucketHandle) *sto
 	return b.base.getObject(b.getObjectName(id), bkt)
 }
 
-func (b ItemsBase) GetFirmObject(id blizzard.ItemID, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
-	return b.base.getFirmObject(b.getObjectName(id), bkt)
+func (b ItemsBase) GetFirmObject(ctx context.Context, id blizzard.ItemID, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
+	return b.base.getFirmObject(ctx, b.getObjectName(id), bkt)
 }
 
-func (b ItemsBase) NewItem(obj *storage.ObjectHandle) (sotah.Item, error) {
-	reader, err := obj.NewReader(b.client.Context)
+func (b ItemsBase) NewItem(ctx context.Context, obj *storage.ObjectHandle) (sotah.Item, error) {
+	reader, err := obj.NewReader(ctx)
 	if err != nil {
 		return sotah.Item{}, err
 	}
@@ -82,13 +83,13 @@ func (job GetItemsOutJob) ToLogrusFields() logrus.Fields {
 	}
 }
 
-func (b ItemsBase) GetItems(ids blizzard.ItemIds, bkt *storage.BucketHandle) chan GetItemsOutJob {
+func (b ItemsBase) GetItems(ctx context.Context, ids blizzard.ItemIds, bkt *storage.BucketHandle) chan GetItemsOutJob {
 	// spinning up workers
 	in := make(chan blizzard.ItemID)
 	out := make(chan GetItemsOutJob)
 	worker := func() {
 		for id := range in {
-			obj, err := b.GetFirmObject(id, bkt)
+			obj, err := b.GetFirmObject(ctx, id, bkt)
 			if err != nil {
 				out <- GetItemsOutJob{
 					Err: err,
@@ -98,7 +99,7 @@ func (b ItemsBase) GetItems(ids blizzard.ItemIds, bkt *storage.BucketHandle) cha
 				continue
 			}
 
-			reader, err := obj.ReadCompressed(true).NewReader(b.client.Context)
+			reader, err := obj.ReadCompressed(true).NewReader(ctx)
 			if err != nil {
 				out <- GetItemsOutJob{
 					Err: err,
@@ -142,7 +143,7 @@ func (b ItemsBase) GetItems(ids blizzard.ItemIds, bkt *storage.BucketHandle) cha
 	return out
 }
 
-func (b ItemsBase) WriteItem(obj *storage.ObjectHandle, item sotah.Item) error {
+func (b ItemsBase) WriteItem(ctx context.Context, obj *storage.ObjectHandle, item sotah.Item) error {
 	jsonEncoded, err := json.Marshal(item)
 	if err != nil {
 		return err
@@ -153,7 +154,7 @@ func (b ItemsBase) WriteItem(obj *storage.ObjectHandle, item sotah.Item) error {
 		return err
 	}
 
-	wc := obj.NewWriter(b.client.Context)
+	wc := obj.NewWriter(ctx)
 	wc.ContentType = "application/json"
 	wc.ContentEncoding = "gzip"
 	return b.Write(wc, gzipEncodedBody)
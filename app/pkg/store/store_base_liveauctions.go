@@ -1,7 +1,9 @@
 package store
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 
 	"cloud.google.com/go/storage"
 	"github.com/sotah-inc/server/app/pkg/blizzard"
@@ -26,16 +28,16 @@ func (b LiveAuctionsBase) getBucketName() string {
 	return "sotah-live-auctions"
 }
 
-func (b LiveAuctionsBase) GetFirmBucket() (*storage.BucketHandle, error) {
-	return b.base.getFirmBucket(b.getBucketName())
+func (b LiveAuctionsBase) GetFirmBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.getFirmBucket(ctx, b.getBucketName())
 }
 
 func (b LiveAuctionsBase) GetBucket() *storage.BucketHandle {
 	return b.base.getBucket(b.getBucketName())
 }
 
-func (b LiveAuctionsBase) resolveBucket() (*storage.BucketHandle, error) {
-	return b.base.resolveBucket(b.getBucketName())
+func (b LiveAuctionsBase) resolveBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.resolveBucket(ctx, b.getBucketName())
 }
 
 func (b LiveAuctionsBase) getObjectName(realm sotah.Realm) string {
@@ -46,24 +48,61 @@ func (b LiveAuctionsBase) GetObject(realm sotah.Realm, bkt *storage.BucketHandle
 	return b.base.getObject(b.getObjectName(realm), bkt)
 }
 
-func (b LiveAuctionsBase) GetFirmObject(realm sotah.Realm, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
-	return b.base.getFirmObject(b.getObjectName(realm), bkt)
+func (b LiveAuctionsBase) GetFirmObject(ctx context.Context, realm sotah.Realm, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
+	return b.base.getFirmObject(ctx, b.getObjectName(realm), bkt)
 }
 
-func (b LiveAuctionsBase) Handle(aucs blizzard.Auctions, realm sotah.Realm, bkt *storage.BucketHandle) error {
+func (b LiveAuctionsBase) Handle(ctx context.Context, aucs blizzard.Auctions, realm sotah.Realm, bkt *storage.BucketHandle) error {
+	ctx, span := startRealmSpan(ctx, "store write live-auctions", realm)
+	defer span.End()
+
 	// encoding auctions in the appropriate format
 	gzipEncodedBody, err := sotah.NewMiniAuctionListFromMiniAuctions(sotah.NewMiniAuctions(aucs)).EncodeForDatabase()
 	if err != nil {
+		span.SetError(err)
+
 		return err
 	}
 
 	// writing it out to the gcloud object
-	wc := b.GetObject(realm, bkt).NewWriter(b.client.Context)
+	wc := b.GetObject(realm, bkt).NewWriter(ctx)
 	wc.ContentType = "application/json"
 	wc.ContentEncoding = "gzip"
 	if err := b.Write(wc, gzipEncodedBody); err != nil {
+		span.SetError(err)
+
 		return err
 	}
 
 	return nil
 }
+
+// GetCompressedData - reads the live-auctions of a realm without decompressing them, as they are loaded into a database
+func (b LiveAuctionsBase) GetCompressedData(
+	ctx context.Context,
+	realm sotah.Realm,
+	bkt *storage.BucketHandle,
+) ([]byte, error) {
+	ctx, span := startRealmSpan(ctx, "store read live-auctions", realm)
+	defer span.End()
+
+	obj, err := b.GetFirmObject(ctx, realm, bkt)
+	if err != nil {
+		span.SetError(err)
+
+		return []byte{}, err
+	}
+
+	reader, err := obj.ReadCompressed(true).NewReader(ctx)
+	if err != nil {
+		span.SetError(err)
+
+		return []byte{}, err
+	}
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+	span.SetError(err)
+
+	return data, err
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/csv"
 	"errors"
@@ -15,6 +16,7 @@ import (
 	"github.com/sotah-inc/server/app/pkg/logging"
 	"github.com/sotah-inc/server/app/pkg/sotah"
 	"github.com/sotah-inc/server/app/pkg/sotah/gameversions"
+	"github.com/sotah-inc/server/app/pkg/sotah/retentionkinds"
 	"github.com/sotah-inc/server/app/pkg/store/regions"
 	"github.com/sotah-inc/server/app/pkg/util"
 	"github.com/twinj/uuid"
@@ -41,8 +43,8 @@ func (b PricelistHistoriesBaseV2) GetBucket() *storage.BucketHandle {
 	return b.base.getBucket(b.getBucketName())
 }
 
-func (b PricelistHistoriesBaseV2) GetFirmBucket() (*storage.BucketHandle, error) {
-	return b.base.getFirmBucket(b.getBucketName())
+func (b PricelistHistoriesBaseV2) GetFirmBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.getFirmBucket(ctx, b.getBucketName())
 }
 
 func (b PricelistHistoriesBaseV2) GetObjectPrefix(realm sotah.Realm) string {
@@ -57,11 +59,14 @@ func (b PricelistHistoriesBaseV2) GetObject(targetTime time.Time, realm sotah.Re
 	return b.base.getObject(b.getObjectName(targetTime, realm), bkt)
 }
 
-func (b PricelistHistoriesBaseV2) GetFirmObject(targetTime time.Time, realm sotah.Realm, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
-	return b.base.getFirmObject(b.getObjectName(targetTime, realm), bkt)
+func (b PricelistHistoriesBaseV2) GetFirmObject(ctx context.Context, targetTime time.Time, realm sotah.Realm, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
+	return b.base.getFirmObject(ctx, b.getObjectName(targetTime, realm), bkt)
 }
 
-func (b PricelistHistoriesBaseV2) Handle(aucs blizzard.Auctions, targetTime time.Time, rea sotah.Realm, bkt *storage.BucketHandle) (sotah.UnixTimestamp, error) {
+func (b PricelistHistoriesBaseV2) Handle(ctx context.Context, aucs blizzard.Auctions, targetTime time.Time, rea sotah.Realm, bkt *storage.BucketHandle) (sotah.UnixTimestamp, error) {
+	ctx, span := startRealmSpan(ctx, "store write pricelist-histories", rea)
+	defer span.End()
+
 	normalizedTargetDate := sotah.NormalizeTargetDate(targetTime)
 
 	// resolving unix-timestamp of target-time
@@ -72,7 +77,7 @@ func (b PricelistHistoriesBaseV2) Handle(aucs blizzard.Auctions, targetTime time
 
 	// resolving item-price-histories
 	ipHistories, err := func() (sotah.ItemPriceHistories, error) {
-		exists, err := b.ObjectExists(obj)
+		exists, err := b.ObjectExists(ctx, obj)
 		if err != nil {
 			return sotah.ItemPriceHistories{}, err
 		}
@@ -81,7 +86,7 @@ func (b PricelistHistoriesBaseV2) Handle(aucs blizzard.Auctions, targetTime time
 			return sotah.ItemPriceHistories{}, nil
 		}
 
-		reader, err := obj.NewReader(b.client.Context)
+		reader, err := obj.NewReader(ctx)
 		if err != nil {
 			return sotah.ItemPriceHistories{}, err
 		}
@@ -90,6 +95,8 @@ func (b PricelistHistoriesBaseV2) Handle(aucs blizzard.Auctions, targetTime time
 		return sotah.NewItemPriceHistoriesFromMinimized(reader)
 	}()
 	if err != nil {
+		span.SetError(err)
+
 		return 0, err
 	}
 
@@ -97,39 +104,54 @@ func (b PricelistHistoriesBaseV2) Handle(aucs blizzard.Auctions, targetTime time
 	iPrices := sotah.NewItemPrices(sotah.NewMiniAuctionListFromMiniAuctions(sotah.NewMiniAuctions(aucs)))
 
 	// merging item-prices into the item-price-histories
-	for itemId, prices := range iPrices {
-		pHistory := func() sotah.PriceHistory {
-			result, ok := ipHistories[itemId]
-			if !ok {
-				return sotah.PriceHistory{}
-			}
+	ipHistories.Merge(targetTimestamp, iPrices)
 
-			return result
-		}()
-		pHistory[targetTimestamp] = prices
+	if _, err := b.write(ctx, obj, ipHistories); err != nil {
+		span.SetError(err)
 
-		ipHistories[itemId] = pHistory
+		return 0, err
 	}
 
+	return sotah.UnixTimestamp(normalizedTargetDate.Unix()), nil
+}
+
+// Replace - overwrites the day of item-price-histories, rather than merging into it, returning the new version id
+func (b PricelistHistoriesBaseV2) Replace(
+	ctx context.Context,
+	ipHistories sotah.ItemPriceHistories,
+	targetTime time.Time,
+	rea sotah.Realm,
+	bkt *storage.BucketHandle,
+) (string, error) {
+	return b.write(ctx, b.GetObject(sotah.NormalizeTargetDate(targetTime), rea, bkt), ipHistories)
+}
+
+func (b PricelistHistoriesBaseV2) write(
+	ctx context.Context,
+	obj *storage.ObjectHandle,
+	ipHistories sotah.ItemPriceHistories,
+) (string, error) {
 	// encoding the item-price-histories for persistence
 	gzipEncodedBody, err := ipHistories.EncodeForPersistence()
 	if err != nil {
-		return 0, err
+		return "", err
 	}
 
 	// writing it out to the gcloud object
-	wc := obj.NewWriter(b.client.Context)
+	wc := obj.NewWriter(ctx)
 	wc.ContentType = "text/plain"
 	wc.ContentEncoding = "gzip"
 	if wc.Metadata == nil {
 		wc.Metadata = map[string]string{}
 	}
-	wc.Metadata["version_id"] = uuid.NewV4().String()
+	versionId := uuid.NewV4().String()
+	wc.Metadata["version_id"] = versionId
+
 	if err := b.Write(wc, gzipEncodedBody); err != nil {
-		return 0, err
+		return "", err
 	}
 
-	return sotah.UnixTimestamp(normalizedTargetDate.Unix()), nil
+	return versionId, nil
 }
 
 type GetAllPricelistHistoriesInJob struct {
@@ -157,6 +179,7 @@ func (job GetAllPricelistHistoriesOutJob) ToLogrusFields() logrus.Fields {
 }
 
 func (b PricelistHistoriesBaseV2) GetAll(
+	ctx context.Context,
 	in chan GetAllPricelistHistoriesInJob,
 	bkt *storage.BucketHandle,
 ) chan GetAllPricelistHistoriesOutJob {
@@ -167,6 +190,7 @@ func (b PricelistHistoriesBaseV2) GetAll(
 		for inJob := range in {
 			// resolving the obj
 			obj, err := b.GetFirmObject(
+				ctx,
 				time.Unix(int64(inJob.TargetTimestamp), 0),
 				sotah.NewSkeletonRealm(inJob.RegionName, inJob.RealmSlug),
 				bkt,
@@ -182,7 +206,7 @@ func (b PricelistHistoriesBaseV2) GetAll(
 				continue
 			}
 
-			objAttrs, err := obj.Attrs(b.client.Context)
+			objAttrs, err := obj.Attrs(ctx)
 			if err != nil {
 				out <- GetAllPricelistHistoriesOutJob{
 					Err:             err,
@@ -197,7 +221,7 @@ func (b PricelistHistoriesBaseV2) GetAll(
 			// resolving the data
 			data, err := func() (map[blizzard.ItemID][]byte, error) {
 				// gathering the data from the object
-				reader, err := obj.NewReader(b.client.Context)
+				reader, err := obj.NewReader(ctx)
 				if err != nil {
 					return map[blizzard.ItemID][]byte{}, err
 				}
@@ -291,10 +315,11 @@ type GetVersionOutJob struct {
 }
 
 func (b PricelistHistoriesBaseV2) GetVersions(
+	ctx context.Context,
 	regionRealms map[blizzard.RegionName]sotah.Realms,
 	bkt *storage.BucketHandle,
 ) (sotah.PricelistHistoryVersions, error) {
-	timestamps, err := b.GetAllTimestamps(regionRealms, bkt)
+	timestamps, err := b.GetAllTimestamps(ctx, regionRealms, bkt)
 	if err != nil {
 		return sotah.PricelistHistoryVersions{}, err
 	}
@@ -307,7 +332,7 @@ func (b PricelistHistoriesBaseV2) GetVersions(
 		for inJob := range inJobs {
 			realm := sotah.NewSkeletonRealm(inJob.RegionName, inJob.RealmSlug)
 
-			obj, err := b.GetFirmObject(time.Unix(int64(inJob.TargetTimestamp), 0), realm, bkt)
+			obj, err := b.GetFirmObject(ctx, time.Unix(int64(inJob.TargetTimestamp), 0), realm, bkt)
 			if err != nil {
 				outJobs <- GetVersionOutJob{
 					Err:             err,
@@ -319,7 +344,7 @@ func (b PricelistHistoriesBaseV2) GetVersions(
 				continue
 			}
 
-			objAttrs, err := obj.Attrs(b.client.Context)
+			objAttrs, err := obj.Attrs(ctx)
 			if err != nil {
 				outJobs <- GetVersionOutJob{
 					Err:             err,
@@ -410,6 +435,7 @@ func (b PricelistHistoriesBaseV2) GetVersions(
 }
 
 func (b PricelistHistoriesBaseV2) GetAllTimestamps(
+	ctx context.Context,
 	regionRealms map[blizzard.RegionName]sotah.Realms,
 	bkt *storage.BucketHandle,
 ) (sotah.RegionRealmTimestamps, error) {
@@ -419,7 +445,7 @@ func (b PricelistHistoriesBaseV2) GetAllTimestamps(
 	// spinning up workers
 	worker := func() {
 		for realm := range in {
-			timestamps, err := b.GetTimestamps(realm, bkt)
+			timestamps, err := b.GetTimestamps(ctx, realm, bkt)
 			if err != nil {
 				out <- GetTimestampsJob{
 					Err:   err,
@@ -471,17 +497,19 @@ func (b PricelistHistoriesBaseV2) GetAllTimestamps(
 }
 
 func (b PricelistHistoriesBaseV2) GetAllExpiredTimestamps(
+	ctx context.Context,
 	regionRealms map[blizzard.RegionName]sotah.Realms,
+	retention sotah.RetentionConfig,
 	bkt *storage.BucketHandle,
 ) (sotah.RegionRealmTimestamps, error) {
-	regionRealmTimestamps, err := b.GetAllTimestamps(regionRealms, bkt)
+	regionRealmTimestamps, err := b.GetAllTimestamps(ctx, regionRealms, bkt)
 	if err != nil {
 		return sotah.RegionRealmTimestamps{}, err
 	}
 
 	out := sotah.RegionRealmTimestamps{}
-	limit := sotah.NormalizeTargetDate(time.Now()).AddDate(0, 0, -14)
 	for regionName, realmTimestamps := range regionRealmTimestamps {
+		limit := retention.Limit(regionName, retentionkinds.PricelistHistories)
 		for realmSlug, timestamps := range realmTimestamps {
 			for _, timestamp := range timestamps {
 				targetTime := time.Unix(int64(timestamp), 0)
@@ -504,9 +532,9 @@ func (b PricelistHistoriesBaseV2) GetAllExpiredTimestamps(
 	return out, nil
 }
 
-func (b PricelistHistoriesBaseV2) GetTimestamps(realm sotah.Realm, bkt *storage.BucketHandle) ([]sotah.UnixTimestamp, error) {
+func (b PricelistHistoriesBaseV2) GetTimestamps(ctx context.Context, realm sotah.Realm, bkt *storage.BucketHandle) ([]sotah.UnixTimestamp, error) {
 	prefix := fmt.Sprintf("%s/", b.GetObjectPrefix(realm))
-	it := bkt.Objects(b.client.Context, &storage.Query{Prefix: prefix})
+	it := bkt.Objects(ctx, &storage.Query{Prefix: prefix})
 	out := []sotah.UnixTimestamp{}
 	for {
 		objAttrs, err := it.Next()
@@ -529,13 +557,17 @@ func (b PricelistHistoriesBaseV2) GetTimestamps(realm sotah.Realm, bkt *storage.
 	return out, nil
 }
 
-func (b PricelistHistoriesBaseV2) GetExpiredTimestamps(realm sotah.Realm, bkt *storage.BucketHandle) ([]sotah.UnixTimestamp, error) {
-	timestamps, err := b.GetTimestamps(realm, bkt)
+func (b PricelistHistoriesBaseV2) GetExpiredTimestampsBefore(
+	ctx context.Context,
+	realm sotah.Realm,
+	limit time.Time,
+	bkt *storage.BucketHandle,
+) ([]sotah.UnixTimestamp, error) {
+	timestamps, err := b.GetTimestamps(ctx, realm, bkt)
 	if err != nil {
 		return []sotah.UnixTimestamp{}, err
 	}
 
-	limit := sotah.NormalizeTargetDate(time.Now()).AddDate(0, 0, -14)
 	expiredTimestamps := []sotah.UnixTimestamp{}
 	for _, timestamp := range timestamps {
 		targetTime := time.Unix(int64(timestamp), 0)
@@ -555,6 +587,7 @@ type DeletePricelistHistoryJob struct {
 }
 
 func (b PricelistHistoriesBaseV2) DeleteAll(
+	ctx context.Context,
 	realm sotah.Realm,
 	timestamps []sotah.UnixTimestamp,
 	bkt *storage.BucketHandle,
@@ -572,7 +605,7 @@ func (b PricelistHistoriesBaseV2) DeleteAll(
 			entry.Info("Handling target-timestamp")
 
 			obj := bkt.Object(b.getObjectName(time.Unix(int64(targetTimestamp), 0), realm))
-			exists, err := b.ObjectExists(obj)
+			exists, err := b.ObjectExists(ctx, obj)
 			if err != nil {
 				entry.WithField("error", err.Error()).Error("Failed to check if obj exists")
 
@@ -594,7 +627,7 @@ func (b PricelistHistoriesBaseV2) DeleteAll(
 				continue
 			}
 
-			if err := obj.Delete(b.client.Context); err != nil {
+			if err := obj.Delete(ctx); err != nil {
 				entry.WithField("error", err.Error()).Error("Could not delete obj")
 
 				out <- DeletePricelistHistoryJob{
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -35,8 +36,8 @@ func (b RealmsBase) GetBucket() *storage.BucketHandle {
 	return b.base.getBucket(b.getBucketName())
 }
 
-func (b RealmsBase) GetFirmBucket() (*storage.BucketHandle, error) {
-	return b.base.getFirmBucket(b.getBucketName())
+func (b RealmsBase) GetFirmBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.getFirmBucket(ctx, b.getBucketName())
 }
 
 func (b RealmsBase) GetObjectName(regionName blizzard.RegionName, realmSlug blizzard.RealmSlug) string {
@@ -55,12 +56,12 @@ func (b RealmsBase) GetObject(
 	return b.base.getObject(b.GetObjectName(regionName, realmSlug), bkt)
 }
 
-func (b RealmsBase) GetFirmObject(regionName blizzard.RegionName, realmSlug blizzard.RealmSlug, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
-	return b.base.getFirmObject(b.GetObjectName(regionName, realmSlug), bkt)
+func (b RealmsBase) GetFirmObject(ctx context.Context, regionName blizzard.RegionName, realmSlug blizzard.RealmSlug, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
+	return b.base.getFirmObject(ctx, b.GetObjectName(regionName, realmSlug), bkt)
 }
 
-func (b RealmsBase) NewRealm(obj *storage.ObjectHandle) (sotah.Realm, error) {
-	reader, err := obj.NewReader(b.client.Context)
+func (b RealmsBase) NewRealm(ctx context.Context, obj *storage.ObjectHandle) (sotah.Realm, error) {
+	reader, err := obj.NewReader(ctx)
 	if err != nil {
 		return sotah.Realm{}, err
 	}
@@ -78,8 +79,8 @@ func (b RealmsBase) NewRealm(obj *storage.ObjectHandle) (sotah.Realm, error) {
 	return out, nil
 }
 
-func (b RealmsBase) GetAllRealms(regionName blizzard.RegionName, bkt *storage.BucketHandle) (sotah.Realms, error) {
-	return b.GetRealms(regionName, map[blizzard.RealmSlug]interface{}{}, bkt)
+func (b RealmsBase) GetAllRealms(ctx context.Context, regionName blizzard.RegionName, bkt *storage.BucketHandle) (sotah.Realms, error) {
+	return b.GetRealms(ctx, regionName, map[blizzard.RealmSlug]interface{}{}, bkt)
 }
 
 type GetRealmsOutJob struct {
@@ -88,6 +89,7 @@ type GetRealmsOutJob struct {
 }
 
 func (b RealmsBase) GetRealms(
+	ctx context.Context,
 	regionName blizzard.RegionName,
 	realmSlugWhitelist map[blizzard.RealmSlug]interface{},
 	bkt *storage.BucketHandle,
@@ -97,7 +99,7 @@ func (b RealmsBase) GetRealms(
 	out := make(chan GetRealmsOutJob)
 	worker := func() {
 		for objName := range in {
-			realm, err := b.NewRealm(b.getObject(objName, bkt))
+			realm, err := b.NewRealm(ctx, b.getObject(objName, bkt))
 			if err != nil {
 				out <- GetRealmsOutJob{
 					Err:   err,
@@ -120,7 +122,7 @@ func (b RealmsBase) GetRealms(
 
 	// queueing it up
 	prefix := fmt.Sprintf("%s/", b.GetObjectPrefix(regionName))
-	it := bkt.Objects(b.client.Context, &storage.Query{Prefix: prefix})
+	it := bkt.Objects(ctx, &storage.Query{Prefix: prefix})
 	go func() {
 		for {
 			objAttrs, err := it.Next()
@@ -164,7 +166,7 @@ func (b RealmsBase) GetRealms(
 	return results, nil
 }
 
-func (b RealmsBase) WriteRealm(realm sotah.Realm, bkt *storage.BucketHandle) error {
+func (b RealmsBase) WriteRealm(ctx context.Context, realm sotah.Realm, bkt *storage.BucketHandle) error {
 	obj := b.GetObject(realm.Region.Name, realm.Slug, bkt)
 
 	jsonEncoded, err := json.Marshal(realm)
@@ -177,7 +179,7 @@ func (b RealmsBase) WriteRealm(realm sotah.Realm, bkt *storage.BucketHandle) err
 		return err
 	}
 
-	wc := obj.NewWriter(b.client.Context)
+	wc := obj.NewWriter(ctx)
 	wc.ContentType = "application/json"
 	wc.ContentEncoding = "gzip"
 	return b.Write(wc, gzipEncodedBody)
@@ -188,13 +190,13 @@ type WriteRealmsMapJob struct {
 	Realm sotah.Realm
 }
 
-func (b RealmsBase) WriteRealms(regionRealms sotah.RegionRealms, bkt *storage.BucketHandle) error {
+func (b RealmsBase) WriteRealms(ctx context.Context, regionRealms sotah.RegionRealms, bkt *storage.BucketHandle) error {
 	// spinning up the workers
 	in := make(chan sotah.Realm)
 	out := make(chan WriteRealmsMapJob)
 	worker := func() {
 		for realm := range in {
-			if err := b.WriteRealm(realm, bkt); err != nil {
+			if err := b.WriteRealm(ctx, realm, bkt); err != nil {
 				out <- WriteRealmsMapJob{
 					Err:   err,
 					Realm: realm,
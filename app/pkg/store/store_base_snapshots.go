@@ -0,0 +1,311 @@
+package store
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/sotah-inc/server/app/pkg/database/schemakinds"
+	"github.com/sotah-inc/server/app/pkg/sotah/gameversions"
+	"github.com/sotah-inc/server/app/pkg/store/regions"
+	"google.golang.org/api/iterator"
+)
+
+func NewSnapshotsBase(c Client, location regions.Region, version gameversions.GameVersion) SnapshotsBase {
+	return SnapshotsBase{
+		base{client: c, location: location},
+		version,
+	}
+}
+
+type SnapshotManifestEntry struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// SnapshotManifest - lists every database of a snapshot, where paths are relative to the database dir
+type SnapshotManifest struct {
+	Id            int64                   `json:"id"`
+	Kind          schemakinds.SchemaKind  `json:"kind"`
+	SchemaVersion int                     `json:"schema_version"`
+	Databases     []SnapshotManifestEntry `json:"databases"`
+}
+
+type SnapshotsBase struct {
+	base
+	GameVersion gameversions.GameVersion
+}
+
+func (b SnapshotsBase) getBucketName() string {
+	return "sotah-snapshots"
+}
+
+func (b SnapshotsBase) GetBucket() *storage.BucketHandle {
+	return b.base.getBucket(b.getBucketName())
+}
+
+func (b SnapshotsBase) GetFirmBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.getFirmBucket(ctx, b.getBucketName())
+}
+
+func (b SnapshotsBase) ResolveBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.resolveBucket(ctx, b.getBucketName())
+}
+
+func (b SnapshotsBase) getKindPrefix(kind schemakinds.SchemaKind) string {
+	return fmt.Sprintf("%s/%s", b.GameVersion, kind)
+}
+
+func (b SnapshotsBase) getSnapshotPrefix(kind schemakinds.SchemaKind, id int64) string {
+	return fmt.Sprintf("%s/%d", b.getKindPrefix(kind), id)
+}
+
+func (b SnapshotsBase) getDatabaseObjectName(kind schemakinds.SchemaKind, id int64, path string) string {
+	return fmt.Sprintf("%s/databases/%s.gz", b.getSnapshotPrefix(kind, id), path)
+}
+
+func (b SnapshotsBase) getManifestObjectName(kind schemakinds.SchemaKind, id int64) string {
+	return fmt.Sprintf("%s/manifest.json", b.getSnapshotPrefix(kind, id))
+}
+
+// the latest manifest is written only once every database of a snapshot is stored
+func (b SnapshotsBase) getLatestObjectName(kind schemakinds.SchemaKind) string {
+	return fmt.Sprintf("%s/latest.json", b.getKindPrefix(kind))
+}
+
+// WriteDatabase streams the database into a gzipped object, returning the uncompressed size
+func (b SnapshotsBase) WriteDatabase(
+	ctx context.Context,
+	kind schemakinds.SchemaKind,
+	id int64,
+	path string,
+	writeTo func(w io.Writer) (int64, error),
+	bkt *storage.BucketHandle,
+) (int64, error) {
+	wc := b.getObject(b.getDatabaseObjectName(kind, id, path), bkt).NewWriter(ctx)
+	wc.ContentType = "application/octet-stream"
+
+	gzipWriter := gzip.NewWriter(wc)
+	size, err := writeTo(gzipWriter)
+	if err == nil {
+		err = gzipWriter.Close()
+	}
+	if err != nil {
+		wc.CloseWithError(err)
+
+		return 0, err
+	}
+
+	if err := wc.Close(); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+func (b SnapshotsBase) writeManifest(ctx context.Context, name string, manifest SnapshotManifest, bkt *storage.BucketHandle) error {
+	jsonEncoded, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	wc := b.getObject(name, bkt).NewWriter(ctx)
+	wc.ContentType = "application/json"
+
+	return b.Write(wc, jsonEncoded)
+}
+
+// WriteManifest completes the snapshot and marks it as the latest
+func (b SnapshotsBase) WriteManifest(ctx context.Context, manifest SnapshotManifest, bkt *storage.BucketHandle) error {
+	if err := b.writeManifest(ctx, b.getManifestObjectName(manifest.Kind, manifest.Id), manifest, bkt); err != nil {
+		return err
+	}
+
+	return b.writeManifest(ctx, b.getLatestObjectName(manifest.Kind), manifest, bkt)
+}
+
+func (b SnapshotsBase) readManifest(ctx context.Context, name string, bkt *storage.BucketHandle) (SnapshotManifest, error) {
+	obj, err := b.getFirmObject(ctx, name, bkt)
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+
+	reader, err := obj.NewReader(ctx)
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+
+	var out SnapshotManifest
+	if err := json.Unmarshal(data, &out); err != nil {
+		return SnapshotManifest{}, err
+	}
+
+	return out, nil
+}
+
+func (b SnapshotsBase) GetLatestManifest(ctx context.Context, kind schemakinds.SchemaKind, bkt *storage.BucketHandle) (SnapshotManifest, error) {
+	return b.readManifest(ctx, b.getLatestObjectName(kind), bkt)
+}
+
+func (b SnapshotsBase) GetManifest(
+	ctx context.Context,
+	kind schemakinds.SchemaKind,
+	id int64,
+	bkt *storage.BucketHandle,
+) (SnapshotManifest, error) {
+	return b.readManifest(ctx, b.getManifestObjectName(kind, id), bkt)
+}
+
+type snapshotDatabaseReader struct {
+	*gzip.Reader
+	objReader *storage.Reader
+}
+
+func (r snapshotDatabaseReader) Close() error {
+	if err := r.Reader.Close(); err != nil {
+		r.objReader.Close()
+
+		return err
+	}
+
+	return r.objReader.Close()
+}
+
+// NewDatabaseReader returns the uncompressed database of the snapshot, which the caller must close
+func (b SnapshotsBase) NewDatabaseReader(
+	ctx context.Context,
+	kind schemakinds.SchemaKind,
+	id int64,
+	path string,
+	bkt *storage.BucketHandle,
+) (io.ReadCloser, error) {
+	obj, err := b.getFirmObject(ctx, b.getDatabaseObjectName(kind, id, path), bkt)
+	if err != nil {
+		return nil, err
+	}
+
+	objReader, err := obj.NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	gzipReader, err := gzip.NewReader(objReader)
+	if err != nil {
+		objReader.Close()
+
+		return nil, err
+	}
+
+	return snapshotDatabaseReader{gzipReader, objReader}, nil
+}
+
+/*
+snapshotIds sorts the ids of the snapshots found among the object names into ascending complete and incomplete ones,
+where a snapshot is only complete once its manifest is written
+*/
+func snapshotIds(kindPrefix string, names []string) ([]int64, []int64) {
+	hasManifest := map[int64]bool{}
+	for _, name := range names {
+		if !strings.HasPrefix(name, kindPrefix) {
+			continue
+		}
+
+		// skipping objects outside of any snapshot, such as the latest manifest
+		parts := strings.SplitN(name[len(kindPrefix):], "/", 2)
+		if len(parts) < 2 {
+			continue
+		}
+
+		id, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		hasManifest[id] = hasManifest[id] || parts[1] == "manifest.json"
+	}
+
+	complete := []int64{}
+	incomplete := []int64{}
+	for id, isComplete := range hasManifest {
+		if isComplete {
+			complete = append(complete, id)
+
+			continue
+		}
+
+		incomplete = append(incomplete, id)
+	}
+
+	for _, ids := range [][]int64{complete, incomplete} {
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+	}
+
+	return complete, incomplete
+}
+
+/*
+GetSnapshotIds lists the ascending ids of every complete snapshot of the kind, apart from those of the snapshots that
+never had their manifest written
+*/
+func (b SnapshotsBase) GetSnapshotIds(
+	ctx context.Context,
+	kind schemakinds.SchemaKind,
+	bkt *storage.BucketHandle,
+) ([]int64, []int64, error) {
+	prefix := fmt.Sprintf("%s/", b.getKindPrefix(kind))
+	it := bkt.Objects(ctx, &storage.Query{Prefix: prefix})
+	names := []string{}
+	for {
+		objAttrs, err := it.Next()
+		if err != nil {
+			if err == iterator.Done {
+				break
+			}
+
+			return []int64{}, []int64{}, err
+		}
+
+		names = append(names, objAttrs.Name)
+	}
+
+	complete, incomplete := snapshotIds(prefix, names)
+
+	return complete, incomplete, nil
+}
+
+func (b SnapshotsBase) DeleteSnapshot(ctx context.Context, kind schemakinds.SchemaKind, id int64, bkt *storage.BucketHandle) (int, error) {
+	it := bkt.Objects(ctx, &storage.Query{Prefix: fmt.Sprintf("%s/", b.getSnapshotPrefix(kind, id))})
+	totalDeleted := 0
+	for {
+		objAttrs, err := it.Next()
+		if err != nil {
+			if err == iterator.Done {
+				break
+			}
+
+			return totalDeleted, err
+		}
+
+		if err := bkt.Object(objAttrs.Name).Delete(ctx); err != nil {
+			return totalDeleted, err
+		}
+		totalDeleted++
+	}
+
+	return totalDeleted, nil
+}
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotIds(t *testing.T) {
+	kindPrefix := "retail/live_auctions/"
+	names := []string{
+		"retail/live_auctions/latest.json",
+		"retail/live_auctions/300/databases/live-auctions/us/earthen-ring/live-auctions.db.gz",
+		"retail/live_auctions/300/manifest.json",
+		"retail/live_auctions/100/databases/live-auctions/us/earthen-ring/live-auctions.db.gz",
+		"retail/live_auctions/100/manifest.json",
+		// a snapshot that failed before its manifest was written
+		"retail/live_auctions/200/databases/live-auctions/us/earthen-ring/live-auctions.db.gz",
+		"retail/live_auctions/not-a-snapshot/manifest.json",
+		"retail/items/400/manifest.json",
+	}
+
+	complete, incomplete := snapshotIds(kindPrefix, names)
+	assert.Equal(t, []int64{100, 300}, complete)
+	assert.Equal(t, []int64{200}, incomplete)
+}
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"context"
+
 	"cloud.google.com/go/storage"
 	"github.com/sotah-inc/server/app/pkg/store/regions"
 )
@@ -18,12 +20,12 @@ type TransferBase struct {
 	bucketName string
 }
 
-func (b TransferBase) GetFirmBucket() (*storage.BucketHandle, error) {
-	return b.base.getFirmBucket(b.bucketName)
+func (b TransferBase) GetFirmBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	return b.base.getFirmBucket(ctx, b.bucketName)
 }
 
-func (b TransferBase) GetFirmObject(name string, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
-	return b.base.getFirmObject(name, bkt)
+func (b TransferBase) GetFirmObject(ctx context.Context, name string, bkt *storage.BucketHandle) (*storage.ObjectHandle, error) {
+	return b.base.getFirmObject(ctx, name, bkt)
 }
 
 func (b TransferBase) GetObject(name string, bkt *storage.BucketHandle) *storage.ObjectHandle {
@@ -0,0 +1,14 @@
+package exporterkinds
+
+// ExporterKind - typehint for these enums
+type ExporterKind string
+
+/*
+ExporterKinds - where finished spans are sent
+*/
+const (
+	None   ExporterKind = "none"
+	Stdout ExporterKind = "stdout"
+	File   ExporterKind = "file"
+	OTLP   ExporterKind = "otlp"
+)
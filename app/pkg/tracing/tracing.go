@@ -0,0 +1,181 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TraceID - identifies every span of a single journey, such as a realm making its way from download to intake
+type TraceID [16]byte
+
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id TraceID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+func (id *TraceID) UnmarshalText(text []byte) error {
+	return decodeHex(string(text), id[:])
+}
+
+type SpanID [8]byte
+
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id SpanID) MarshalText() ([]byte, error) {
+	if !id.IsValid() {
+		return []byte{}, nil
+	}
+
+	return []byte(id.String()), nil
+}
+
+func (id *SpanID) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*id = SpanID{}
+
+		return nil
+	}
+
+	return decodeHex(string(text), id[:])
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	_, _ = rand.Read(id[:])
+
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	_, _ = rand.Read(id[:])
+
+	return id
+}
+
+// SpanContext - what is carried across process boundaries, so that spans on either side belong to one trace
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+const (
+	traceparentVersion = "00"
+	sampledFlag        = "01"
+	unsampledFlag      = "00"
+)
+
+// Traceparent encodes the span context in the w3c trace-context format, or blank when it is not valid
+func (sc SpanContext) Traceparent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+
+	flag := unsampledFlag
+	if sc.Sampled {
+		flag = sampledFlag
+	}
+
+	return fmt.Sprintf("%s-%s-%s-%s", traceparentVersion, sc.TraceID, sc.SpanID, flag)
+}
+
+func ParseTraceparent(traceparent string) (SpanContext, error) {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 {
+		return SpanContext{}, errors.New("traceparent must have four parts")
+	}
+
+	if parts[0] != traceparentVersion {
+		return SpanContext{}, fmt.Errorf("unsupported traceparent version %s", parts[0])
+	}
+
+	var sc SpanContext
+	if err := decodeHex(parts[1], sc.TraceID[:]); err != nil {
+		return SpanContext{}, err
+	}
+	if err := decodeHex(parts[2], sc.SpanID[:]); err != nil {
+		return SpanContext{}, err
+	}
+
+	flags := make([]byte, 1)
+	if err := decodeHex(parts[3], flags); err != nil {
+		return SpanContext{}, err
+	}
+	sc.Sampled = flags[0]&1 == 1
+
+	if !sc.IsValid() {
+		return SpanContext{}, errors.New("traceparent ids cannot be zero")
+	}
+
+	return sc, nil
+}
+
+func decodeHex(encoded string, dst []byte) error {
+	if len(encoded) != hex.EncodedLen(len(dst)) {
+		return fmt.Errorf("expected %d hex characters but found %d", hex.EncodedLen(len(dst)), len(encoded))
+	}
+
+	_, err := hex.Decode(dst, []byte(encoded))
+
+	return err
+}
+
+type spanContextKey struct{}
+
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the span of the context, or a span that records nothing when there is none
+func SpanFromContext(ctx context.Context) Span {
+	span, ok := ctx.Value(spanContextKey{}).(Span)
+	if !ok {
+		return Span{}
+	}
+
+	return span
+}
+
+// Traceparent encodes the span of the context for carrying in a message envelope, or blank when there is none
+func Traceparent(ctx context.Context) string {
+	return SpanFromContext(ctx).SpanContext().Traceparent()
+}
+
+/*
+WithRemoteParent parents the spans started from the context on a span of another process, leaving the context as it
+is when the traceparent is blank or malformed
+*/
+func WithRemoteParent(ctx context.Context, traceparent string) context.Context {
+	if len(traceparent) == 0 {
+		return ctx
+	}
+
+	sc, err := ParseTraceparent(traceparent)
+	if err != nil {
+		return ctx
+	}
+
+	return ContextWithSpan(ctx, Span{spanContext: sc})
+}
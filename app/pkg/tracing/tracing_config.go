@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sotah-inc/server/app/pkg/tracing/exporterkinds"
+)
+
+const serviceName = "sotah-server"
+
+type Config struct {
+	ExporterKind exporterkinds.ExporterKind
+
+	// where the file exporter appends spans
+	File string
+
+	// where the otlp exporter posts spans, with a blank endpoint falling back to a local collector
+	OTLPEndpoint string
+
+	// the share of new traces that are recorded, where traces carried on from another process follow its decision
+	SampleRatio float64
+
+	// the command being run, written on every span by the otlp exporter
+	Command string
+}
+
+func NewExporter(config Config) (Exporter, error) {
+	switch config.ExporterKind {
+	case exporterkinds.None, "":
+		return nil, nil
+	case exporterkinds.Stdout:
+		return NewStdoutExporter(), nil
+	case exporterkinds.File:
+		if len(config.File) == 0 {
+			return nil, errors.New("trace file cannot be blank")
+		}
+
+		return NewFileExporter(config.File)
+	case exporterkinds.OTLP:
+		return NewOTLPExporter(OTLPConfig{
+			Endpoint: config.OTLPEndpoint,
+			ResourceAttributes: map[string]string{
+				"service.name":  serviceName,
+				"sotah.command": config.Command,
+			},
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported exporter kind %s", config.ExporterKind)
+	}
+}
+
+func NewTracerFromConfig(config Config) (Tracer, error) {
+	exporter, err := NewExporter(config)
+	if err != nil {
+		return Tracer{}, err
+	}
+
+	return NewTracer(exporter, config.SampleRatio), nil
+}
@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// Exporter - sends finished spans somewhere they may be looked at
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// NewWriterExporter writes each span as a line of json, for looking at traces locally
+func NewWriterExporter(w io.Writer) WriterExporter {
+	return WriterExporter{w: w, lock: &sync.Mutex{}}
+}
+
+func NewStdoutExporter() WriterExporter {
+	return NewWriterExporter(os.Stdout)
+}
+
+// NewFileExporter appends spans to the file, creating it where it does not exist
+func NewFileExporter(path string) (WriterExporter, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return WriterExporter{}, err
+	}
+
+	exporter := NewWriterExporter(f)
+	exporter.closer = f
+
+	return exporter, nil
+}
+
+type WriterExporter struct {
+	w      io.Writer
+	closer io.Closer
+	lock   *sync.Mutex
+}
+
+func (e WriterExporter) Export(_ context.Context, spans []SpanData) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	encoder := json.NewEncoder(e.w)
+	for _, span := range spans {
+		if err := encoder.Encode(span); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e WriterExporter) Shutdown(_ context.Context) error {
+	if e.closer == nil {
+		return nil
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return e.closer.Close()
+}
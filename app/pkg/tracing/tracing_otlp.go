@@ -0,0 +1,215 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+const (
+	// DefaultOTLPEndpoint - where a local collector receives otlp over http
+	DefaultOTLPEndpoint = "http://localhost:4318/v1/traces"
+
+	otlpScopeName = "github.com/sotah-inc/server/app/pkg/tracing"
+
+	// otlp span kind and status codes
+	otlpSpanKindInternal = 1
+	otlpStatusCodeError  = 2
+)
+
+type OTLPConfig struct {
+	Endpoint string
+
+	// attributes of the process, such as service.name, written on every span
+	ResourceAttributes map[string]string
+
+	// extra request headers, such as those authenticating with a hosted collector
+	Headers map[string]string
+}
+
+// NewOTLPExporter exports to an otlp collector over http, using the json encoding so that no protobuf is needed
+func NewOTLPExporter(config OTLPConfig) OTLPExporter {
+	if len(config.Endpoint) == 0 {
+		config.Endpoint = DefaultOTLPEndpoint
+	}
+
+	return OTLPExporter{config: config, client: &http.Client{}}
+}
+
+type OTLPExporter struct {
+	config OTLPConfig
+	client *http.Client
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           TraceID        `json:"traceId"`
+	SpanID            SpanID         `json:"spanId"`
+	ParentSpanID      SpanID         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+func newOTLPAnyValue(value interface{}) otlpAnyValue {
+	switch v := value.(type) {
+	case string:
+		return otlpAnyValue{StringValue: &v}
+	case bool:
+		return otlpAnyValue{BoolValue: &v}
+	case int:
+		encoded := strconv.FormatInt(int64(v), 10)
+
+		return otlpAnyValue{IntValue: &encoded}
+	case int64:
+		encoded := strconv.FormatInt(v, 10)
+
+		return otlpAnyValue{IntValue: &encoded}
+	case float64:
+		return otlpAnyValue{DoubleValue: &v}
+	default:
+		encoded := fmt.Sprint(v)
+
+		return otlpAnyValue{StringValue: &encoded}
+	}
+}
+
+// newOTLPKeyValues sorts by key, so that the encoding of a span does not vary
+func newOTLPKeyValues(attributes map[string]interface{}) []otlpKeyValue {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make([]otlpKeyValue, len(keys))
+	for i, key := range keys {
+		out[i] = otlpKeyValue{Key: key, Value: newOTLPAnyValue(attributes[key])}
+	}
+
+	return out
+}
+
+func newOTLPSpan(data SpanData) otlpSpan {
+	span := otlpSpan{
+		TraceID:           data.TraceID,
+		SpanID:            data.SpanID,
+		ParentSpanID:      data.ParentSpanID,
+		Name:              data.Name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(data.StartTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(data.EndTime.UnixNano(), 10),
+		Attributes:        newOTLPKeyValues(data.Attributes),
+	}
+	if len(data.Err) > 0 {
+		span.Status = otlpStatus{Code: otlpStatusCodeError, Message: data.Err}
+	}
+
+	return span
+}
+
+func (e OTLPExporter) newRequest(spans []SpanData) otlpRequest {
+	resourceAttributes := map[string]interface{}{}
+	for k, v := range e.config.ResourceAttributes {
+		resourceAttributes[k] = v
+	}
+
+	otlpSpans := make([]otlpSpan, len(spans))
+	for i, data := range spans {
+		otlpSpans[i] = newOTLPSpan(data)
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{Attributes: newOTLPKeyValues(resourceAttributes)},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: otlpScopeName},
+				Spans: otlpSpans,
+			}},
+		}},
+	}
+}
+
+func (e OTLPExporter) Export(ctx context.Context, spans []SpanData) error {
+	body, err := json.Marshal(e.newRequest(spans))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return fmt.Errorf("otlp endpoint responded with %d: %s", resp.StatusCode, respBody)
+	}
+
+	return nil
+}
+
+func (e OTLPExporter) Shutdown(_ context.Context) error {
+	e.client.CloseIdleConnections()
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"sync"
+	"time"
+)
+
+// attribute keys shared across spans
+const (
+	RegionAttribute  = "sotah.region"
+	RealmAttribute   = "sotah.realm"
+	SubjectAttribute = "messaging.destination"
+	CodeAttribute    = "sotah.code"
+)
+
+// SpanData - a finished span, as handed to an exporter
+type SpanData struct {
+	Name         string                 `json:"name"`
+	TraceID      TraceID                `json:"trace_id"`
+	SpanID       SpanID                 `json:"span_id"`
+	ParentSpanID SpanID                 `json:"parent_span_id,omitempty"`
+	StartTime    time.Time              `json:"start_time"`
+	EndTime      time.Time              `json:"end_time"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Err          string                 `json:"error,omitempty"`
+}
+
+func (data SpanData) Duration() time.Duration {
+	return data.EndTime.Sub(data.StartTime)
+}
+
+/*
+Span - a timed operation within a trace, where a span that is not sampled, or that was parented from another process,
+carries its span context but records nothing
+*/
+type Span struct {
+	spanContext SpanContext
+	state       *spanState
+}
+
+type spanState struct {
+	sync.Mutex
+
+	tracer Tracer
+	data   SpanData
+	ended  bool
+}
+
+func (s Span) SpanContext() SpanContext {
+	return s.spanContext
+}
+
+func (s Span) IsRecording() bool {
+	return s.state != nil
+}
+
+func (s Span) SetAttribute(key string, value interface{}) {
+	if s.state == nil {
+		return
+	}
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	if s.state.data.Attributes == nil {
+		s.state.data.Attributes = map[string]interface{}{}
+	}
+	s.state.data.Attributes[key] = value
+}
+
+// SetError marks the span as failed, where a nil error is ignored
+func (s Span) SetError(err error) {
+	if s.state == nil || err == nil {
+		return
+	}
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.state.data.Err = err.Error()
+}
+
+// End finishes the span and hands it to the exporter of its tracer, where ending again does nothing
+func (s Span) End() {
+	if s.state == nil {
+		return
+	}
+
+	s.state.Lock()
+	if s.state.ended {
+		s.state.Unlock()
+
+		return
+	}
+	s.state.ended = true
+	s.state.data.EndTime = time.Now()
+	data := s.state.data
+	s.state.Unlock()
+
+	s.state.tracer.enqueue(data)
+}
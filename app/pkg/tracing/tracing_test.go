@@ -0,0 +1,164 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingExporter struct {
+	spans []SpanData
+}
+
+func (e *recordingExporter) Export(_ context.Context, spans []SpanData) error {
+	e.spans = append(e.spans, spans...)
+
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func TestTraceparent(t *testing.T) {
+	sc := SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true}
+
+	parsed, err := ParseTraceparent(sc.Traceparent())
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, sc, parsed)
+
+	_, err = ParseTraceparent("00-00000000000000000000000000000000-0000000000000000-01")
+	assert.NotNil(t, err)
+
+	_, err = ParseTraceparent("not-a-traceparent")
+	assert.NotNil(t, err)
+
+	assert.Equal(t, "", SpanContext{}.Traceparent())
+}
+
+func TestTracerParentsSpans(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(exporter, 1)
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, child := tracer.Start(ctx, "child")
+	child.SetAttribute(RegionAttribute, "us")
+	child.SetError(errors.New("failed"))
+	child.End()
+	parent.End()
+
+	if !assert.Nil(t, tracer.Shutdown(context.Background())) {
+		return
+	}
+
+	if !assert.Len(t, exporter.spans, 2) {
+		return
+	}
+	assert.Equal(t, "child", exporter.spans[0].Name)
+	assert.Equal(t, parent.SpanContext().TraceID, exporter.spans[0].TraceID)
+	assert.Equal(t, parent.SpanContext().SpanID, exporter.spans[0].ParentSpanID)
+	assert.Equal(t, "us", exporter.spans[0].Attributes[RegionAttribute])
+	assert.Equal(t, "failed", exporter.spans[0].Err)
+	assert.False(t, exporter.spans[1].ParentSpanID.IsValid())
+}
+
+func TestTracerFollowsRemoteParent(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(exporter, 1)
+
+	remote := SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true}
+	ctx := WithRemoteParent(context.Background(), remote.Traceparent())
+	_, span := tracer.Start(ctx, "handle")
+	span.End()
+
+	// an unsampled remote parent is followed too, so nothing is recorded
+	_, unsampled := tracer.Start(
+		WithRemoteParent(context.Background(), SpanContext{TraceID: newTraceID(), SpanID: newSpanID()}.Traceparent()),
+		"ignored",
+	)
+	unsampled.End()
+
+	if !assert.Nil(t, tracer.Shutdown(context.Background())) {
+		return
+	}
+
+	if !assert.Len(t, exporter.spans, 1) {
+		return
+	}
+	assert.Equal(t, remote.TraceID, exporter.spans[0].TraceID)
+	assert.Equal(t, remote.SpanID, exporter.spans[0].ParentSpanID)
+	assert.False(t, unsampled.IsRecording())
+}
+
+func TestTracerWithoutExporter(t *testing.T) {
+	tracer := NewTracer(nil, 1)
+
+	ctx, span := tracer.Start(context.Background(), "unrecorded")
+	span.End()
+
+	assert.False(t, span.IsRecording())
+	assert.NotEqual(t, "", Traceparent(ctx))
+	assert.Nil(t, tracer.Shutdown(context.Background()))
+}
+
+func TestWriterExporter(t *testing.T) {
+	buf := bytes.Buffer{}
+	tracer := NewTracer(NewWriterExporter(&buf), 1)
+
+	_, span := tracer.Start(context.Background(), "written")
+	span.End()
+
+	if !assert.Nil(t, tracer.Shutdown(context.Background())) {
+		return
+	}
+
+	var decoded map[string]interface{}
+	if !assert.Nil(t, json.Unmarshal(buf.Bytes(), &decoded)) {
+		return
+	}
+	assert.Equal(t, "written", decoded["name"])
+	assert.Equal(t, span.SpanContext().TraceID.String(), decoded["trace_id"])
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var received otlpRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(OTLPConfig{
+		Endpoint:           server.URL,
+		ResourceAttributes: map[string]string{"service.name": "sotah"},
+	})
+	tracer := NewTracer(exporter, 1)
+
+	_, span := tracer.Start(context.Background(), "exported")
+	span.SetAttribute(CodeAttribute, 1)
+	span.End()
+
+	if !assert.Nil(t, tracer.Shutdown(context.Background())) {
+		return
+	}
+
+	if !assert.Len(t, received.ResourceSpans, 1) {
+		return
+	}
+	assert.Equal(t, "service.name", received.ResourceSpans[0].Resource.Attributes[0].Key)
+
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	if !assert.Len(t, spans, 1) {
+		return
+	}
+	assert.Equal(t, "exported", spans[0].Name)
+	assert.Equal(t, "1", *spans[0].Attributes[0].Value.IntValue)
+}
@@ -0,0 +1,204 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sotah-inc/server/app/pkg/logging"
+)
+
+const (
+	// exportInterval - how often finished spans are exported when the batch does not fill up first
+	exportInterval = 5 * time.Second
+
+	// exportBatchSize - how many finished spans are exported at once
+	exportBatchSize = 512
+
+	// exportTimeout - the deadline of a single export
+	exportTimeout = 10 * time.Second
+
+	// queueSize - how many finished spans may wait on an export, past which spans are dropped
+	queueSize = 4096
+)
+
+/*
+NewTracer starts exporting finished spans in batches, where a nil exporter records nothing but still hands trace
+context along, so that other processes may carry on the trace
+*/
+func NewTracer(exporter Exporter, sampleRatio float64) Tracer {
+	t := Tracer{
+		state: &tracerState{
+			exporter:    exporter,
+			sampleRatio: sampleRatio,
+			stop:        make(chan interface{}),
+			done:        make(chan interface{}),
+		},
+	}
+
+	if exporter == nil {
+		close(t.state.done)
+
+		return t
+	}
+
+	t.state.spans = make(chan SpanData, queueSize)
+	go t.work()
+
+	return t
+}
+
+type Tracer struct {
+	state *tracerState
+}
+
+type tracerState struct {
+	exporter    Exporter
+	sampleRatio float64
+
+	spans    chan SpanData
+	dropped  int64
+	stop     chan interface{}
+	stopOnce sync.Once
+	done     chan interface{}
+}
+
+// sampled decides from the trace id alone, so that every process reaches the same decision for a trace
+func (t Tracer) sampled(id TraceID) bool {
+	switch {
+	case t.state.sampleRatio >= 1:
+		return true
+	case t.state.sampleRatio <= 0:
+		return false
+	default:
+		return binary.BigEndian.Uint64(id[8:]) < uint64(t.state.sampleRatio*math.MaxUint64)
+	}
+}
+
+// Start starts a span that is a child of the span of the context, or the root of a new trace when there is none
+func (t Tracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent := SpanFromContext(ctx).SpanContext()
+
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = t.sampled(sc.TraceID)
+	}
+
+	span := Span{spanContext: sc}
+	if sc.Sampled && t.state.exporter != nil {
+		span.state = &spanState{
+			tracer: t,
+			data: SpanData{
+				Name:         name,
+				TraceID:      sc.TraceID,
+				SpanID:       sc.SpanID,
+				ParentSpanID: parent.SpanID,
+				StartTime:    time.Now(),
+			},
+		}
+	}
+
+	return ContextWithSpan(ctx, span), span
+}
+
+func (t Tracer) enqueue(data SpanData) {
+	if t.state.spans == nil {
+		return
+	}
+
+	select {
+	case t.state.spans <- data:
+	default:
+		atomic.AddInt64(&t.state.dropped, 1)
+	}
+}
+
+func (t Tracer) Dropped() int64 {
+	return atomic.LoadInt64(&t.state.dropped)
+}
+
+func (t Tracer) export(batch []SpanData) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	if err := t.state.exporter.Export(ctx, batch); err != nil {
+		logging.WithField("error", err.Error()).Error("Failed to export spans")
+	}
+}
+
+func (t Tracer) work() {
+	defer close(t.state.done)
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, exportBatchSize)
+	flush := func() {
+		t.export(batch)
+		batch = make([]SpanData, 0, exportBatchSize)
+	}
+
+	for {
+		select {
+		case data := <-t.state.spans:
+			batch = append(batch, data)
+			if len(batch) >= exportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.state.stop:
+			// taking whatever has finished by now, since spans ending after shutdown are not waited on
+			for {
+				select {
+				case data := <-t.state.spans:
+					batch = append(batch, data)
+					if len(batch) >= exportBatchSize {
+						flush()
+					}
+				default:
+					flush()
+
+					return
+				}
+			}
+		}
+	}
+}
+
+// Shutdown exports every span that has finished so far and then shuts down the exporter
+func (t Tracer) Shutdown(ctx context.Context) error {
+	t.state.stopOnce.Do(func() {
+		close(t.state.stop)
+	})
+
+	select {
+	case <-t.state.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if t.state.exporter == nil {
+		return nil
+	}
+
+	return t.state.exporter.Shutdown(ctx)
+}
+
+// DefaultTracer - what the package funcs start spans with, which records nothing until it is replaced on startup
+var DefaultTracer = NewTracer(nil, 0)
+
+func Start(ctx context.Context, name string) (context.Context, Span) {
+	return DefaultTracer.Start(ctx, name)
+}